@@ -86,11 +86,74 @@ while getopts "hs:o:p:" opt; do
 done
 shift $((OPTIND-1))
 
+# DOC_TARGET, if set by the webhook, is the per-branch publication target:
+# either a GCS prefix (gs://bucket/prefix) or a local directory within the
+# served docs site.
+GCS_TARGET=""
+if [[ ${DOC_TARGET} == gs://* ]]
+then
+  GCS_TARGET=${DOC_TARGET}
+  DOC_OUTPUT=$(mktemp -d)
+  trap 'rm -rf "$DOC_OUTPUT"' EXIT
+elif [ -n "${DOC_TARGET}" ]
+then
+  mkdir -p ${DOC_TARGET} || exit 1
+  DOC_OUTPUT=${DOC_TARGET}
+fi
+
 check_args
 
+# DOC_STATUS_FILE, if set by the webhook, receives a line per failure to
+# generate docs, as "<model>\t<reason>" (the model is empty for failures
+# that aren't specific to a model).
+DOC_STATUS_FILE=${DOC_STATUS_FILE:-/dev/null}
+
+record_failure () {
+  printf '%s\t%s\n' "$1" "$2" >> "$DOC_STATUS_FILE"
+  echo "doc generation failed for ${1:-all models}: $2" >&2
+}
+
+# check_docs checks the pages linked from each generated index, since
+# oc-stage.sh continues past models for which the docs plugin fails. Links to
+# missing or empty pages are recorded as failures of the model and removed
+# from the index, such that it only lists the published models.
+check_docs () {
+  local failed=0
+  local index dir link
+  while IFS= read -r index
+  do
+    dir=$(dirname "$index")
+    for link in $(grep -o 'href="[^"#:?]*\.html"' "$index" | sed 's/^href="//; s/"$//' | sort -u)
+    do
+      if [ ! -s "$dir/$link" ]
+      then
+        record_failure "$(basename "$link" .html)" "no docs generated at $link"
+        sed -i "\|href=\"$link\"|d" "$index"
+        failed=1
+      fi
+    done
+  done < <(find "$DOC_OUTPUT" -name index.html)
+  return $failed
+}
+
+STATUS=0
 if [ -z ${PUSH_BRANCH} ]
 then
   $OC_STAGE_DIR/oc-stage.sh -r $OC_STAGE_DIR -p $OC_PYANG_PLUGINS -o $DOC_OUTPUT -t -g models
 else
   $OC_STAGE_DIR/oc-stage.sh -r $OC_STAGE_DIR -p $OC_PYANG_PLUGINS -o $DOC_OUTPUT -b $PUSH_BRANCH -t -g models
 fi
+STAGE_STATUS=$?
+if [ $STAGE_STATUS -ne 0 ]
+then
+  record_failure "" "oc-stage.sh exited with status $STAGE_STATUS"
+  STATUS=1
+fi
+check_docs || STATUS=1
+
+if [ -n "${GCS_TARGET}" ]
+then
+  gsutil -m rsync -r -d $DOC_OUTPUT ${GCS_TARGET} || exit 1
+fi
+
+exit $STATUS
@@ -0,0 +1,267 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/version"
+)
+
+// ci_progress runs alongside the validators within the build, and
+// periodically edits a "CI progress" PR comment with the progress of each
+// per-model validator, as well as an estimate of its remaining time. Progress
+// is computed from the expected model count written by cmd_gen and the
+// completed models recorded by each validator script.
+
+const (
+	// progressCommentSignature identifies the progress comment on the PR
+	// such that it can be edited in-place.
+	progressCommentSignature = "CI progress for commit"
+)
+
+var (
+	// flags: should be string if it may not exist.
+	repoSlug    string // repoSlug is the "owner/repo" name of the models repo (e.g. openconfig/public).
+	prNumberStr string // prNumberStr is the PR number.
+	commitSHA   string
+	interval    time.Duration // interval is the time between updates to the progress comment.
+	timeout     time.Duration // timeout is the time after which the updater gives up.
+
+	// derived flags
+	owner    string
+	repo     string
+	prNumber int
+)
+
+func init() {
+	flag.StringVar(&repoSlug, "repo-slug", "", "repo where CI is run")
+	flag.StringVar(&prNumberStr, "pr-number", "", "PR number")
+	flag.StringVar(&commitSHA, "commit-sha", "", "commit SHA of the PR")
+	flag.DurationVar(&interval, "interval", 2*time.Minute, "time between updates to the progress comment")
+	flag.DurationVar(&timeout, "timeout", 90*time.Minute, "time after which to stop updating the progress comment")
+}
+
+// validatorProgress is the progress of a single validator@version.
+type validatorProgress struct {
+	// name is the display name of the validator and its version.
+	name      string
+	completed int
+	expected  int
+}
+
+// done returns whether the validator has finished running on all its models.
+func (p *validatorProgress) done() bool {
+	return p.completed >= p.expected
+}
+
+// remaining estimates the remaining time for the validator given the time
+// elapsed since it started, assuming each model takes roughly the same time.
+// It returns false if there is not yet enough information for an estimate.
+func (p *validatorProgress) remaining(elapsed time.Duration) (time.Duration, bool) {
+	switch {
+	case p.done():
+		return 0, true
+	case p.completed == 0:
+		return 0, false
+	}
+	return elapsed * time.Duration(p.expected-p.completed) / time.Duration(p.completed), true
+}
+
+// readProgress reads the progress of every per-model validator whose results
+// directory is within resultsDir. Directories without an expected model count
+// (e.g. repo-level validators) are skipped.
+func readProgress(resultsDir string) ([]*validatorProgress, error) {
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results directory %q: %v", resultsDir, err)
+	}
+
+	var progresses []*validatorProgress
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(resultsDir, entry.Name())
+
+		countPath := filepath.Join(dir, commonci.ExpectedModelCountFileName)
+		bs, err := os.ReadFile(countPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("failed to read expected model count file %q: %v", countPath, err)
+		}
+		expected, err := strconv.Atoi(strings.TrimSpace(string(bs)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse expected model count file %q: %v", countPath, err)
+		}
+
+		completed := 0
+		completedPath := filepath.Join(dir, commonci.CompletedModelsFileName)
+		switch bs, err := os.ReadFile(completedPath); {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("failed to read completed models file %q: %v", completedPath, err)
+		default:
+			completed = strings.Count(string(bs), "\n")
+		}
+
+		name := entry.Name()
+		nameSegments := strings.SplitN(name, "@", 2)
+		if validator, ok := commonci.Validators[nameSegments[0]]; ok {
+			var version string
+			if len(nameSegments) == 2 {
+				version = nameSegments[1]
+			}
+			name = commonci.AppendVersionToName(validator.Name, version)
+		}
+
+		progresses = append(progresses, &validatorProgress{
+			name:      name,
+			completed: completed,
+			expected:  expected,
+		})
+	}
+	return progresses, nil
+}
+
+// allDone returns whether all validators have finished running.
+func allDone(progresses []*validatorProgress) bool {
+	for _, p := range progresses {
+		if !p.done() {
+			return false
+		}
+	}
+	return true
+}
+
+// formatMinutes formats a duration in whole minutes for display.
+func formatMinutes(d time.Duration) string {
+	return fmt.Sprintf("%d min", int(d.Round(time.Minute).Minutes()))
+}
+
+// progressComment creates the PR comment displaying the progress of each
+// validator given the time elapsed since the validators started.
+func progressComment(progresses []*validatorProgress, elapsed time.Duration) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s %s (%s elapsed):\n\n", progressCommentSignature, commitSHA, formatMinutes(elapsed)))
+	b.WriteString("| Validator | Progress | ETA |\n")
+	b.WriteString("| --- | --- | --- |\n")
+	for _, p := range progresses {
+		var eta string
+		switch remaining, ok := p.remaining(elapsed); {
+		case p.done():
+			eta = commonci.Emoji("pass") + " done"
+		case !ok:
+			eta = "unknown"
+		default:
+			eta = "~" + formatMinutes(remaining)
+		}
+		b.WriteString(fmt.Sprintf("| %s | %d/%d | %s |\n", p.name, p.completed, p.expected, eta))
+	}
+	return b.String()
+}
+
+// runStart returns the start time of the CI run recorded by cmd_gen within
+// the plan at planPath, or fallback if it isn't recorded.
+func runStart(planPath string, fallback time.Time) (time.Time, error) {
+	plan, err := commonci.ReadPlan(planPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if plan == nil || plan.StartTime.IsZero() {
+		log.Printf("run start time isn't recorded in plan, measuring elapsed time from now")
+		return fallback, nil
+	}
+	return plan.StartTime, nil
+}
+
+func main() {
+	flag.Parse()
+	log.Printf("ci_progress version %s", version.String())
+	if repoSlug == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "no repo slug input")
+	}
+	repoSplit := strings.Split(repoSlug, "/")
+	owner = repoSplit[0]
+	repo = repoSplit[1]
+	if commitSHA == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "no commit SHA")
+	}
+	prNumber = 0
+	if prNumberStr != "" {
+		var err error
+		if prNumber, err = strconv.Atoi(prNumberStr); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "error encountered while parsing PR number: %s", err)
+		}
+	}
+	if prNumber == 0 {
+		log.Printf("not a PR build, skipping progress reporting")
+		return
+	}
+
+	if err := commonci.ReadUserConfig(); err != nil {
+		commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+	}
+
+	g, err := commonci.NewGitHubRequestHandler()
+	if err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+	}
+
+	// The ETA is measured from the start of the run, since the validators
+	// may have been running for a while before this updater started.
+	updaterStart := time.Now()
+	start, err := runStart(commonci.PlanFile, updaterStart)
+	if err != nil {
+		commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+	}
+	for {
+		progresses, err := readProgress(commonci.ResultsDir)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+		}
+		if len(progresses) == 0 {
+			log.Printf("no per-model validators to report progress for")
+			return
+		}
+
+		comment := progressComment(progresses, time.Since(start))
+		// Progress is informational, so don't stop on a failed update.
+		if err := g.AddEditOrDeletePRComment(progressCommentSignature, &comment, owner, repo, prNumber); err != nil {
+			log.Printf("couldn't post progress comment: %v", err)
+		}
+
+		switch {
+		case allDone(progresses):
+			return
+		case time.Since(updaterStart) > timeout:
+			log.Printf("timed out after %v while waiting for validators to complete", timeout)
+			return
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/commonci"
+)
+
+func TestReadProgress(t *testing.T) {
+	tests := []struct {
+		name         string
+		inResultsDir string
+		want         []*validatorProgress
+		wantAllDone  bool
+		wantErr      bool
+	}{{
+		name:         "basic",
+		inResultsDir: "testdata/results",
+		want: []*validatorProgress{{
+			name:      "ConfD Basic",
+			completed: 2,
+			expected:  2,
+		}, {
+			name:      "pyang",
+			completed: 2,
+			expected:  3,
+		}, {
+			name:      "pyang@head",
+			completed: 0,
+			expected:  3,
+		}},
+	}, {
+		name:         "results directory doesn't exist",
+		inResultsDir: "testdata/dne",
+		wantErr:      true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readProgress(tt.inResultsDir)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(validatorProgress{})); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+			if gotAllDone := allDone(got); gotAllDone != tt.wantAllDone {
+				t.Errorf("allDone: got %v, want %v", gotAllDone, tt.wantAllDone)
+			}
+		})
+	}
+}
+
+func TestProgressComment(t *testing.T) {
+	commitSHA = "abc"
+
+	tests := []struct {
+		name         string
+		inProgresses []*validatorProgress
+		inElapsed    time.Duration
+		want         string
+	}{{
+		name: "mixed progress",
+		inProgresses: []*validatorProgress{{
+			name:      "ConfD Basic",
+			completed: 2,
+			expected:  2,
+		}, {
+			name:      "pyang",
+			completed: 10,
+			expected:  30,
+		}, {
+			name:      "pyang@head",
+			completed: 0,
+			expected:  30,
+		}},
+		inElapsed: 5 * time.Minute,
+		want: `CI progress for commit abc (5 min elapsed):
+
+| Validator | Progress | ETA |
+| --- | --- | --- |
+| ConfD Basic | 2/2 | &#x2705; done |
+| pyang | 10/30 | ~10 min |
+| pyang@head | 0/30 | unknown |
+`,
+	}, {
+		name:      "no validators",
+		inElapsed: 0,
+		want: `CI progress for commit abc (0 min elapsed):
+
+| Validator | Progress | ETA |
+| --- | --- | --- |
+`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, progressComment(tt.inProgresses, tt.inElapsed)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunStart(t *testing.T) {
+	dir := t.TempDir()
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fallback := started.Add(20 * time.Minute)
+
+	plan := commonci.NewPlan("main")
+	plan.StartTime = started
+	for name, p := range map[string]*commonci.Plan{
+		"plan.json":          plan,
+		"no-start-time.json": commonci.NewPlan("main"),
+	} {
+		bs, err := commonci.MarshalPlan(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), bs, 0444); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "invalid.json"), []byte("startTime: now\n"), 0444); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		inFile  string
+		want    time.Time
+		wantErr bool
+	}{{
+		name:   "start time recorded",
+		inFile: "plan.json",
+		want:   started,
+	}, {
+		name:   "start time not recorded",
+		inFile: "no-start-time.json",
+		want:   fallback,
+	}, {
+		name:   "no plan",
+		inFile: "dne.json",
+		want:   fallback,
+	}, {
+		name:    "invalid plan",
+		inFile:  "invalid.json",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runStart(filepath.Join(dir, tt.inFile), fallback)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
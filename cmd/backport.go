@@ -0,0 +1,107 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/openconfig-ci/ocdiff"
+	"github.com/openconfig/models-ci/yangutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// backportCheckCmd represents the backport-check command, which checks that a
+// backport to a release branch is backward compatible.
+var backportCheckCmd = &cobra.Command{
+	Use:   "backport-check",
+	Short: "Check that a backport to a release branch only contains backward-compatible changes",
+	Long: `Use this command to gate a PR backporting changes to a release branch of
+openconfig/public, by diffing the PR's commit against the release branch:
+
+openconfig-ci backport-check --oldp release_branch/third_party --newp backport/third_party --oldroot release_branch/release --newroot backport/release
+
+Unlike diff --disallowed-incompats, any backward-incompatible change fails the
+check, even if it's allowed by a major openconfig-version increment, as does
+any major openconfig-version increment or openconfig-version downgrade, since
+none may be released within an existing release series.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		var report *ocdiff.DiffReport
+		buildReport := func() error {
+			oldfiles, err := yangutil.GetAllYANGFiles(viper.GetString("oldroot"))
+			if err != nil {
+				return fmt.Errorf("error while finding YANG files from the release branch root: %v", err)
+			}
+			newfiles, err := yangutil.GetAllYANGFiles(viper.GetString("newroot"))
+			if err != nil {
+				return fmt.Errorf("error while finding YANG files from the backport root: %v", err)
+			}
+			report, err = ocdiff.NewDiffReport(viper.GetStringSlice("oldp"), viper.GetStringSlice("newp"), oldfiles, newfiles)
+			return err
+		}
+		if err := runWithTimeout(cmd.Context(), viper.GetDuration("timeout"), buildReport); err != nil {
+			return err
+		}
+
+		opts := []ocdiff.Option{ocdiff.WithAllIncompats()}
+		if viper.GetBool("github-comment") {
+			opts = append(opts, ocdiff.WithGithubCommentStyle())
+		}
+		out := backportCheckOutput(report.Report(opts...), report.MajorVersionChanges(), report.VersionDowngrades())
+		if out == "" {
+			fmt.Println("Backport only contains backward-compatible changes.")
+			return nil
+		}
+		fmt.Print(out)
+		os.Exit(commonci.ExitValidationFailure)
+		return nil
+	},
+}
+
+// backportCheckOutput returns the output of the backport-check command given
+// the report of backward-incompatible changes, the major version changes and
+// the version downgrades, which is empty if the backport only contains
+// backward-compatible changes.
+func backportCheckOutput(incompats string, majorVersionChanges, versionDowngrades []string) string {
+	var b strings.Builder
+	if incompats != "" {
+		fmt.Fprintf(&b, "-----------Breaking changes that may not be backported to a release branch (note that this check is not exhaustive)-----------\n%s", incompats)
+	}
+	if len(majorVersionChanges) > 0 {
+		fmt.Fprintf(&b, "-----------Major version increments that may not be backported to a release branch-----------\n%s\n", strings.Join(majorVersionChanges, "\n"))
+	}
+	if len(versionDowngrades) > 0 {
+		fmt.Fprintf(&b, "-----------openconfig-version downgrades that may not be backported to a release branch-----------\n%s\n", strings.Join(versionDowngrades, "\n"))
+	}
+	return b.String()
+}
+
+func init() {
+	rootCmd.AddCommand(backportCheckCmd)
+
+	backportCheckCmd.Flags().StringSlice("oldp", []string{}, "search path for the YANG files of the release branch")
+	backportCheckCmd.Flags().StringSlice("newp", []string{}, "search path for the YANG files of the backport")
+	backportCheckCmd.Flags().StringP("oldroot", "o", "", "Root directory of the OpenConfig YANG files of the release branch")
+	backportCheckCmd.Flags().StringP("newroot", "n", "", "Root directory of the OpenConfig YANG files of the backport")
+	backportCheckCmd.Flags().Bool("github-comment", false, "Show output suitable for posting in a GitHub comment.")
+	backportCheckCmd.Flags().Duration("timeout", 0, fmt.Sprintf("Maximum time to spend parsing YANG files before exiting with status %d; 0 means no timeout.", yangutil.TimeoutExitCode))
+	backportCheckCmd.MarkFlagRequired("oldroot")
+	backportCheckCmd.MarkFlagRequired("newroot")
+}
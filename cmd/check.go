@@ -15,9 +15,14 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/openconfig/models-ci/commonci"
 	"github.com/openconfig/models-ci/openconfig-ci/ocdiff"
 	"github.com/openconfig/models-ci/yangutil"
 	"github.com/spf13/cobra"
@@ -32,19 +37,36 @@ var diffCmd = &cobra.Command{
 	Long: `Use this command to find what's different between two commits of openconfig/public:
 
 openconfig-ci diff --oldp public_old/third_party --newp public_new/third_party --oldroot public_old/release --newroot public_new/release
+
+To quickly diff a single changed module, resolving its imports using --newp:
+
+openconfig-ci diff --newp public_new/third_party,public_new/release --oldfile public_old/release/models/acl/openconfig-acl.yang --newfile public_new/release/models/acl/openconfig-acl.yang
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		viper.BindPFlags(cmd.Flags())
-		oldfiles, err := yangutil.GetAllYANGFiles(viper.GetString("oldroot"))
-		if err != nil {
-			return fmt.Errorf("error while finding YANG files from the old root: %v", err)
-		}
-		newfiles, err := yangutil.GetAllYANGFiles(viper.GetString("newroot"))
-		if err != nil {
-			return fmt.Errorf("error while finding YANG files from the new root: %v", err)
+		var report *ocdiff.DiffReport
+		buildReport := func() error {
+			if oldfile, newfile := viper.GetString("oldfile"), viper.GetString("newfile"); oldfile != "" || newfile != "" {
+				if oldfile == "" || newfile == "" {
+					return fmt.Errorf("both --oldfile and --newfile must be specified")
+				}
+				var err error
+				report, err = ocdiff.NewModuleDiff(oldfile, newfile, viper.GetStringSlice("newp"))
+				return err
+			}
+			oldfiles, err := yangutil.GetAllYANGFiles(viper.GetString("oldroot"))
+			if err != nil {
+				return fmt.Errorf("error while finding YANG files from the old root: %v", err)
+			}
+			newfiles, err := yangutil.GetAllYANGFiles(viper.GetString("newroot"))
+			if err != nil {
+				return fmt.Errorf("error while finding YANG files from the new root: %v", err)
+			}
+			report, err = ocdiff.NewDiffReport(viper.GetStringSlice("oldp"), viper.GetStringSlice("newp"), oldfiles, newfiles)
+			return err
 		}
-		report, err := ocdiff.NewDiffReport(viper.GetStringSlice("oldp"), viper.GetStringSlice("newp"), oldfiles, newfiles)
-		if err != nil {
+
+		if err := runWithTimeout(cmd.Context(), viper.GetDuration("timeout"), buildReport); err != nil {
 			return err
 		}
 
@@ -52,12 +74,34 @@ openconfig-ci diff --oldp public_old/third_party --newp public_new/third_party -
 		if viper.GetBool("github-comment") {
 			opts = append(opts, ocdiff.WithGithubCommentStyle())
 		}
+		if viper.GetBool("compressed-paths") {
+			opts = append(opts, ocdiff.WithCompressedPaths())
+		}
+		if historyFile := viper.GetString("release-history"); historyFile != "" {
+			f, err := os.Open(historyFile)
+			if err != nil {
+				return fmt.Errorf("cannot open release history file: %v", err)
+			}
+			h, err := ocdiff.ParseReleaseHistory(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("error while parsing release history file %q: %v", historyFile, err)
+			}
+			opts = append(opts, ocdiff.WithReleaseHistory(h, viper.GetString("next-release")))
+		}
 
 		if viper.GetBool("disallowed-incompats") {
 			opts = append(opts, ocdiff.WithDisallowedIncompatsOnly())
-			if out := report.Report(opts...); out != "" {
+			out := report.Report(opts...)
+			if out != "" {
 				fmt.Printf("-----------Breaking changes that need a major version increment (note that this check is not exhaustive)-----------\n%s", out)
-				os.Exit(1)
+			}
+			downgrades := report.VersionDowngrades()
+			if len(downgrades) > 0 {
+				fmt.Printf("-----------openconfig-version downgrades (e.g. versions reverted by a merge)-----------\n%s\n", strings.Join(downgrades, "\n"))
+			}
+			if out != "" || len(downgrades) > 0 {
+				os.Exit(commonci.ExitValidationFailure)
 			}
 		} else {
 			fmt.Print(report.Report(opts...))
@@ -66,6 +110,25 @@ openconfig-ci diff --oldp public_old/third_party --newp public_new/third_party -
 	},
 }
 
+// runWithTimeout runs f, which parses YANG files, exiting with
+// yangutil.TimeoutExitCode if it doesn't complete within the timeout. A zero
+// timeout means no timeout.
+func runWithTimeout(ctx context.Context, timeout time.Duration, f func() error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	switch err := yangutil.RunWithContext(ctx, f); {
+	case errors.Is(err, context.DeadlineExceeded):
+		fmt.Fprintf(os.Stderr, "timed out after %v while parsing YANG files\n", timeout)
+		os.Exit(yangutil.TimeoutExitCode)
+	case err != nil:
+		return err
+	}
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(diffCmd)
 
@@ -73,6 +136,12 @@ func init() {
 	diffCmd.Flags().StringSlice("newp", []string{}, "search path for new set of YANG files")
 	diffCmd.Flags().StringP("oldroot", "o", "", "Root directory of old OpenConfig YANG files")
 	diffCmd.Flags().StringP("newroot", "n", "", "Root directory of new OpenConfig YANG files")
-	diffCmd.Flags().Bool("disallowed-incompats", false, "only show disallowed (per semver.org) backward-incompatible changes. Note that the backward-incompatible checks are not exhausive.")
+	diffCmd.Flags().String("oldfile", "", "Old version of a single YANG module to diff instead of the old root")
+	diffCmd.Flags().String("newfile", "", "New version of a single YANG module to diff instead of the new root")
+	diffCmd.Flags().Bool("disallowed-incompats", false, "only show disallowed (per semver.org) backward-incompatible changes, along with openconfig-version downgrades, failing if there are any. Note that the backward-incompatible checks are not exhausive.")
 	diffCmd.Flags().Bool("github-comment", false, "Show output suitable for posting in a GitHub comment.")
+	diffCmd.Flags().Bool("compressed-paths", false, "Report paths under OpenConfig path compression (as seen by ygot and ygnmi), in which config/state leaf pairs share a path, such that identical changes to both are reported once and moves between config and state are reported as writability changes.")
+	diffCmd.Flags().String("release-history", "", "Release history file (see the release-history command) used to annotate added and deleted paths with their releases.")
+	diffCmd.Flags().String("next-release", "", "Release in which added paths are introduced, used with --release-history.")
+	diffCmd.Flags().Duration("timeout", 0, fmt.Sprintf("Maximum time to spend parsing YANG files before exiting with status %d; 0 means no timeout.", yangutil.TimeoutExitCode))
 }
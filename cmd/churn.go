@@ -0,0 +1,194 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/openconfig/models-ci/openconfig-ci/churn"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// mergedCommits returns the commits merged into to (i.e. its first-parent
+// history) since from, which may be empty to start from the first commit,
+// optionally restricted to the commit dates since and until.
+func mergedCommits(repoDir, from, to, since, until string) ([]churn.Commit, error) {
+	revRange := to
+	if from != "" {
+		revRange = from + ".." + to
+	}
+	args := []string{"-C", repoDir, "log", "--first-parent", "--reverse", "--format=%H%x00%s"}
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if until != "" {
+		args = append(args, "--until="+until)
+	}
+	out, err := exec.Command("git", append(args, revRange)...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list commits of %q: %v", revRange, err)
+	}
+	var commits []churn.Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\x00", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("unexpected git log line %q", line)
+		}
+		commits = append(commits, churn.Commit{SHA: parts[0], Subject: parts[1]})
+	}
+	return commits, nil
+}
+
+// gitError returns err along with the stderr of the failed git command, if
+// any.
+func gitError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
+
+// gitShow returns the contents of the file at path in the given commit.
+func gitShow(repoDir, commit, path string) (string, error) {
+	out, err := exec.Command("git", "-C", repoDir, "show", commit+":"+path).Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot read %q at %s: %v", path, commit, err)
+	}
+	return string(out), nil
+}
+
+// versionChanges returns the openconfig-version changes of the YANG files
+// under root made by the given commit relative to its first parent. As in
+// misc-checks, only modified files whose version changed and deleted files
+// that had a version are recorded.
+func versionChanges(repoDir, commit, root string) ([]churn.VersionChange, error) {
+	out, err := exec.Command("git", "-C", repoDir, "rev-list", "--parents", "-n", "1", commit).Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the parents of %s: %v", commit, gitError(err))
+	}
+	if len(strings.Fields(string(out))) < 2 {
+		// The first commit has no parent.
+		return nil, nil
+	}
+	parent := commit + "^"
+	out, err = exec.Command("git", "-C", repoDir, "diff", "--name-status", "--no-renames", parent, commit, "--", root).Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot diff %s against its first parent: %v", commit, gitError(err))
+	}
+	var changes []churn.VersionChange
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 || !strings.HasSuffix(fields[1], ".yang") {
+			continue
+		}
+		status, file := fields[0], fields[1]
+		if status != "M" && status != "D" {
+			continue
+		}
+		oldContents, err := gitShow(repoDir, parent, file)
+		if err != nil {
+			return nil, err
+		}
+		module, oldVersion := churn.ParseFile(oldContents)
+		var newVersion string
+		if status == "M" {
+			newContents, err := gitShow(repoDir, commit, file)
+			if err != nil {
+				return nil, err
+			}
+			var newModule string
+			if newModule, newVersion = churn.ParseFile(newContents); newModule != "" {
+				module = newModule
+			}
+			if newVersion == "" || newVersion == oldVersion {
+				continue
+			}
+		}
+		if oldVersion == "" {
+			continue
+		}
+		if module == "" {
+			module = strings.TrimSuffix(filepath.Base(file), ".yang")
+		}
+		changes = append(changes, churn.VersionChange{
+			File:       filepath.Base(file),
+			Module:     module,
+			OldVersion: oldVersion,
+			NewVersion: newVersion,
+		})
+	}
+	return changes, nil
+}
+
+// churnCmd represents the churn command, which summarizes the version changes
+// of each module within a release cycle.
+var churnCmd = &cobra.Command{
+	Use:   "churn",
+	Short: "Summarize the openconfig-version churn of each module between two releases or dates",
+	Long: `Use this command to summarize the openconfig-version bumps and breaking changes of
+each module made by the PRs merged between two release tags (or dates) of a clone of
+openconfig/public:
+
+openconfig-ci churn --repo public --from v4.0.0 --to master
+openconfig-ci churn --repo public --since 2024-01-01 --until 2024-04-01
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		repoDir := viper.GetString("repo")
+
+		commits, err := mergedCommits(repoDir, viper.GetString("from"), viper.GetString("to"), viper.GetString("since"), viper.GetString("until"))
+		if err != nil {
+			return err
+		}
+		for i := range commits {
+			if commits[i].Changes, err = versionChanges(repoDir, commits[i].SHA, viper.GetString("root")); err != nil {
+				return err
+			}
+		}
+
+		out := os.Stdout
+		if output := viper.GetString("output"); output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("cannot create churn report file: %v", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		return churn.WriteReport(out, churn.Aggregate(commits))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(churnCmd)
+
+	churnCmd.Flags().String("repo", ".", "git repository of the OpenConfig YANG files")
+	churnCmd.Flags().String("root", "release", "Root directory of the OpenConfig YANG files, relative to the repository root")
+	churnCmd.Flags().String("from", "", "release tag or commit from which to summarize the churn (exclusive); defaults to the first commit")
+	churnCmd.Flags().String("to", "HEAD", "release tag or commit up to which to summarize the churn (inclusive)")
+	churnCmd.Flags().String("since", "", "(optional) only summarize commits more recent than this date, e.g. 2024-01-01")
+	churnCmd.Flags().String("until", "", "(optional) only summarize commits older than this date, e.g. 2024-04-01")
+	churnCmd.Flags().StringP("output", "o", "", "churn report file to write instead of stdout")
+}
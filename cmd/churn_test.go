@@ -0,0 +1,69 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/openconfig-ci/churn"
+)
+
+func TestVersionChanges(t *testing.T) {
+	repoDir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	commitFile := func(contents string) string {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(repoDir, "release", "models"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(repoDir, "release", "models", "openconfig-foo.yang"), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", "-A")
+		git("commit", "-qm", "update")
+		return git("rev-parse", "HEAD")
+	}
+	git("init", "-q")
+	first := commitFile("module openconfig-foo { oc-ext:openconfig-version \"1.0.0\"; }\n")
+	second := commitFile("module openconfig-foo { oc-ext:openconfig-version \"1.1.0\"; }\n")
+
+	if got, err := versionChanges(repoDir, first, "release"); err != nil || got != nil {
+		t.Errorf("first commit: got (%v, %v), want (nil, nil)", got, err)
+	}
+	got, err := versionChanges(repoDir, second, "release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []churn.VersionChange{{File: "openconfig-foo.yang", Module: "openconfig-foo", OldVersion: "1.0.0", NewVersion: "1.1.0"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+	if _, err := versionChanges(repoDir, "0123456789abcdef0123456789abcdef01234567", "release"); err == nil {
+		t.Errorf("got no error for a non-existent commit")
+	}
+}
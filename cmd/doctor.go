@@ -0,0 +1,210 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// doctorProbeObject is the object downloaded to check access to the storage
+// bucket. It isn't expected to exist.
+const doctorProbeObject = "models-ci-doctor-probe"
+
+// defaultDoctorTools are the binaries used by the validators and CI steps in
+// the GCB image.
+var defaultDoctorTools = []string{"bash", "git", "go", "python3", "virtualenv", "gsutil", "badge", "yanglint", "protoc"}
+
+// doctorCheck is a single item of the doctor checklist.
+type doctorCheck struct {
+	name string
+	// run returns a description of what was found, or an error if the
+	// check failed.
+	run func() (string, error)
+}
+
+// runDoctorChecks runs the checks, writing a pass/fail line for each to w, and
+// returns the number of failed checks.
+func runDoctorChecks(w io.Writer, checks []doctorCheck) int {
+	failed := 0
+	for _, c := range checks {
+		detail, err := c.run()
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "[FAIL] %s: %v\n", c.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "[PASS] %s: %s\n", c.name, detail)
+	}
+	return failed
+}
+
+// dirCheck checks that dir is a writable directory.
+func dirCheck(dir string) (string, error) {
+	info, err := os.Stat(dir)
+	switch {
+	case err != nil:
+		return "", err
+	case !info.IsDir():
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-")
+	if err != nil {
+		return "", fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return dir, nil
+}
+
+// envCheck checks that the environment variable is set. Its value is only
+// shown if it isn't secret.
+func envCheck(name string, secret bool) (string, error) {
+	v, ok := os.LookupEnv(name)
+	switch {
+	case !ok || v == "":
+		return "", fmt.Errorf("not set")
+	case secret:
+		return "set", nil
+	default:
+		return v, nil
+	}
+}
+
+// githubTokenCheck checks that the GitHub access token is valid, and that it
+// can post statuses and comments to PRs and create gists.
+func githubTokenCheck() (string, error) {
+	// Creating the handler verifies the token's scopes.
+	h, err := commonci.NewGitHubRequestHandler()
+	if err != nil {
+		return "", err
+	}
+	login, scopes, err := h.TokenScopes()
+	if err != nil {
+		return "", fmt.Errorf("token rejected: %v", err)
+	}
+	if scopes == nil {
+		return fmt.Sprintf("authenticated as %s with a fine-grained token whose permissions can't be checked", login), nil
+	}
+	return fmt.Sprintf("authenticated as %s with scopes %s", login, strings.Join(scopes, ", ")), nil
+}
+
+// storageCheck checks that the storage bucket can be read.
+func storageCheck(ctx context.Context, client commonci.StorageClient, bucket string) (string, error) {
+	if _, err := client.Download(ctx, doctorProbeObject); err != nil && !errors.Is(err, commonci.ErrObjectNotExist) {
+		return "", err
+	}
+	return fmt.Sprintf("gs://%s is readable", bucket), nil
+}
+
+// toolCheck checks that the tool is in the PATH.
+func toolCheck(tool string) (string, error) {
+	return exec.LookPath(tool)
+}
+
+// modelRootCheck checks that the .spec.yml files within the model roots can
+// be parsed, and that all of their build files exist.
+func modelRootCheck(modelRoot string) (string, error) {
+	modelMap, err := commonci.ParseOCModels(modelRoot)
+	if err != nil {
+		return "", err
+	}
+	var missing []string
+	models := 0
+	for modelDirName, modelInfos := range modelMap.ModelInfoMap {
+		for _, modelInfo := range modelInfos {
+			models++
+			for _, buildFile := range modelInfo.BuildFiles {
+				if _, err := os.Stat(buildFile); err != nil {
+					missing = append(missing, fmt.Sprintf("%s (%s/.spec.yml)", buildFile, strings.ReplaceAll(modelDirName, ":", "/")))
+				}
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("missing build files: %s", strings.Join(missing, ", "))
+	}
+	return fmt.Sprintf("%d models in %d model directories", models, len(modelMap.ModelInfoMap)), nil
+}
+
+// doctorCmd represents the doctor command, which diagnoses the CI
+// environment.
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that the CI environment is set up correctly",
+	Long: `Use this command within the CI image to check the environment that the CI
+steps rely on (paths, environment variables, GitHub access token, storage bucket
+access, tool binaries and the models), printing a pass/fail checklist:
+
+openconfig-ci doctor --model-root /workspace/release/models
+`,
+	// Failed checks aren't usage errors.
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		ctx := cmd.Context()
+
+		checks := []doctorCheck{
+			{name: "workspace directory", run: func() (string, error) { return dirCheck(commonci.RootDir) }},
+			{name: "GOPATH", run: func() (string, error) { return envCheck("GOPATH", false) }},
+			{name: "post_results binary", run: func() (string, error) {
+				path := filepath.Join(os.Getenv("GOPATH"), "bin", "post_results")
+				if _, err := os.Stat(path); err != nil {
+					return "", err
+				}
+				return path, nil
+			}},
+			{name: "GITHUB_ACCESS_TOKEN", run: func() (string, error) { return envCheck("GITHUB_ACCESS_TOKEN", true) }},
+			{name: "GitHub access token", run: githubTokenCheck},
+		}
+		if bucket := viper.GetString("bucket"); bucket != "" {
+			checks = append(checks, doctorCheck{name: "storage bucket", run: func() (string, error) {
+				return storageCheck(ctx, commonci.NewStorageClient(bucket, "", false), bucket)
+			}})
+		}
+		for _, tool := range viper.GetStringSlice("tools") {
+			tool := tool
+			checks = append(checks, doctorCheck{name: "tool " + tool, run: func() (string, error) { return toolCheck(tool) }})
+		}
+		if modelRoot := viper.GetString("model-root"); modelRoot != "" {
+			checks = append(checks, doctorCheck{name: "model root", run: func() (string, error) { return modelRootCheck(modelRoot) }})
+		}
+
+		if failed := runDoctorChecks(os.Stdout, checks); failed > 0 {
+			return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().String("model-root", "", "Comma-separated list of model root directories whose .spec.yml files to check; not checked if empty.")
+	doctorCmd.Flags().String("bucket", "openconfig", "Storage bucket to which badges and results are uploaded; not checked if empty.")
+	doctorCmd.Flags().StringSlice("tools", defaultDoctorTools, "Tool binaries that must be in the PATH.")
+}
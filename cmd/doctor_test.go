@@ -0,0 +1,100 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/commonci"
+)
+
+func TestRunDoctorChecks(t *testing.T) {
+	var b strings.Builder
+	failed := runDoctorChecks(&b, []doctorCheck{
+		{name: "good", run: func() (string, error) { return "fine", nil }},
+		{name: "bad", run: func() (string, error) { return "", errors.New("broken") }},
+	})
+	if failed != 1 {
+		t.Errorf("got %d failed checks, want 1", failed)
+	}
+	want := "[PASS] good: fine\n[FAIL] bad: broken\n"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+// failingBucket is a storage bucket that can't be accessed.
+type failingBucket struct{}
+
+func (failingBucket) UploadPublic(ctx context.Context, object string, data []byte) error {
+	return errors.New("access denied")
+}
+
+func (failingBucket) UploadPrivate(ctx context.Context, object string, data []byte) error {
+	return errors.New("access denied")
+}
+
+func (failingBucket) ObjectURL(ctx context.Context, object string) (string, error) {
+	return "", errors.New("access denied")
+}
+
+func (failingBucket) Download(ctx context.Context, object string) ([]byte, error) {
+	return nil, errors.New("access denied")
+}
+
+func TestStorageCheck(t *testing.T) {
+	ctx := context.Background()
+	if _, err := storageCheck(ctx, &commonci.MemoryBucket{}, "b"); err != nil {
+		t.Errorf("accessible bucket: got error %v", err)
+	}
+	if _, err := storageCheck(ctx, failingBucket{}, "b"); err == nil {
+		t.Errorf("inaccessible bucket: got no error")
+	}
+}
+
+func TestModelRootCheck(t *testing.T) {
+	modelRoot := filepath.Join(t.TempDir(), "yang")
+	if err := os.MkdirAll(filepath.Join(modelRoot, "acl"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modelRoot, "acl", ".spec.yml"), []byte(`- name: openconfig-acl
+  build:
+    - yang/acl/openconfig-acl.yang
+  run-ci: true
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := modelRootCheck(modelRoot); err == nil || !strings.Contains(err.Error(), "openconfig-acl.yang") {
+		t.Errorf("missing build file: got error %v, want error mentioning openconfig-acl.yang", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(modelRoot, "acl", "openconfig-acl.yang"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := modelRootCheck(modelRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1 models in 1 model directories"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
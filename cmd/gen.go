@@ -0,0 +1,41 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/openconfig/models-ci/cmdgen"
+	"github.com/spf13/cobra"
+)
+
+// genCmd represents the gen command, which runs cmd_gen.
+var genCmd = &cobra.Command{
+	Use:   "gen [cmd_gen flags] [-- args...]",
+	Short: "Generate the validator scripts and post the initial PR statuses (cmd_gen)",
+	Long: `Use this command in place of the cmd_gen binary. The arguments are cmd_gen's
+flags in the same single-dash form, e.g.
+
+openconfig-ci gen -modelRoot release/models -repo-slug openconfig/public -pr-number 1 -commit-sha abc
+`,
+	// The flags are parsed by cmd_gen itself, so the root command's flags and
+	// config don't apply.
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmdgen.Main(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(genCmd)
+}
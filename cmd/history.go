@@ -0,0 +1,125 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/openconfig/models-ci/openconfig-ci/ocdiff"
+	"github.com/openconfig/models-ci/yangutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// releaseTags returns the release tags of the git repository at repoDir in
+// chronological order (by semantic version).
+func releaseTags(repoDir, pattern string) ([]string, error) {
+	out, err := exec.Command("git", "-C", repoDir, "tag", "--list", pattern, "--sort=v:refname").Output()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list release tags: %v", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// releaseSchemaPaths returns the schema paths of the given release tag of the
+// git repository at repoDir, where the search paths and root are relative to
+// the repository root.
+func releaseSchemaPaths(repoDir, tag string, searchPaths []string, root string) ([]string, error) {
+	worktree, err := os.MkdirTemp("", "openconfig-ci-release-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(worktree)
+	if out, err := exec.Command("git", "-C", repoDir, "worktree", "add", "--detach", worktree, tag).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("cannot check out release %q: %v: %s", tag, err, out)
+	}
+	defer exec.Command("git", "-C", repoDir, "worktree", "remove", "--force", worktree).Run()
+
+	var paths []string
+	for _, p := range searchPaths {
+		paths = append(paths, filepath.Join(worktree, p))
+	}
+	files, err := yangutil.GetAllYANGFiles(filepath.Join(worktree, root))
+	if err != nil {
+		return nil, fmt.Errorf("error while finding YANG files of release %q: %v", tag, err)
+	}
+	return ocdiff.SchemaPaths(paths, files)
+}
+
+// releaseHistoryCmd represents the release-history command, which records the
+// schema paths of each release of a models repository for annotating diff
+// reports.
+var releaseHistoryCmd = &cobra.Command{
+	Use:   "release-history",
+	Short: "Record the schema paths of each release of an OpenConfig models repository",
+	Long: `Use this command to create the release history file used by "diff --release-history"
+from the release tags of a clone of openconfig/public:
+
+openconfig-ci release-history --repo public -p third_party,release --root release -o release-history.txt
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		repoDir := viper.GetString("repo")
+
+		tags := viper.GetStringSlice("tags")
+		if len(tags) == 0 {
+			var err error
+			if tags, err = releaseTags(repoDir, viper.GetString("tag-pattern")); err != nil {
+				return err
+			}
+		}
+		if len(tags) == 0 {
+			return fmt.Errorf("no release tags found in repository %q", repoDir)
+		}
+
+		h := ocdiff.NewReleaseHistory()
+		for _, tag := range tags {
+			paths, err := releaseSchemaPaths(repoDir, tag, viper.GetStringSlice("paths"), viper.GetString("root"))
+			if err != nil {
+				return err
+			}
+			if err := h.AddRelease(tag, paths); err != nil {
+				return err
+			}
+		}
+
+		out := os.Stdout
+		if output := viper.GetString("output"); output != "" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("cannot create release history file: %v", err)
+			}
+			defer f.Close()
+			out = f
+		}
+		_, err := h.WriteTo(out)
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(releaseHistoryCmd)
+
+	releaseHistoryCmd.Flags().String("repo", ".", "git repository of the OpenConfig YANG files")
+	releaseHistoryCmd.Flags().StringSliceP("paths", "p", []string{"third_party", "release"}, "search paths for resolving YANG imports, relative to the repository root")
+	releaseHistoryCmd.Flags().String("root", "release", "Root directory of the OpenConfig YANG files, relative to the repository root")
+	releaseHistoryCmd.Flags().StringSlice("tags", []string{}, "release tags in chronological order; defaults to all tags matching --tag-pattern in version order")
+	releaseHistoryCmd.Flags().String("tag-pattern", "v*", "pattern of release tags to record")
+	releaseHistoryCmd.Flags().StringP("output", "o", "", "release history file to write instead of stdout")
+}
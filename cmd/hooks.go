@@ -0,0 +1,186 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// hookTemplate is the git hook script running fast local checks on the YANG
+// files changed relative to .DiffBase. The checks lint the versions of the
+// files being committed (or pushed), i.e. their staged (or committed) blobs,
+// rather than their working tree copies, which may differ.
+var hookTemplate = template.Must(template.New("hook").Parse(`#!/bin/bash
+# Generated by "openconfig-ci install-hooks": runs fast local checks on the
+# changed YANG files. Checks whose tools aren't installed are skipped.
+# Bypass with "git {{ .GitCommand }} --no-verify".
+
+ROOT=$(git rev-parse --show-toplevel) || exit 1
+cd "$ROOT" || exit 1
+SEARCH_PATHS=( {{- range .SearchPaths }} {{ printf "%q" . }} {{- end }} )
+{{- if .Cached }}
+BASE=HEAD
+# The staged blobs are "git show :<path>".
+REV=
+CHANGED=$(git diff --cached --name-only --diff-filter=d HEAD -- '*.yang')
+{{- else }}
+BASE=$(git merge-base HEAD {{ printf "%q" .DiffBase }}) || exit 1
+REV=HEAD
+CHANGED=$(git diff --name-only --diff-filter=d "$BASE" HEAD -- '*.yang')
+{{- end }}
+if [[ -z "$CHANGED" ]]; then
+  exit 0
+fi
+
+# Check out the blobs being checked into a temporary copy of their paths, from
+# which the checks are run such that they report the repo's paths.
+CHECKED=$(mktemp -d) || exit 1
+trap 'rm -rf "$CHECKED"' EXIT
+for f in $CHANGED; do
+  mkdir -p "$CHECKED/$(dirname "$f")" || exit 1
+  git show "$REV:$f" > "$CHECKED/$f" || exit 1
+done
+cd "$CHECKED" || exit 1
+search_paths=()
+for p in "${SEARCH_PATHS[@]}"; do
+  search_paths+=( "$ROOT/$p" )
+done
+
+status=0
+
+# Whitespace check
+if command -v wscheck &> /dev/null; then
+  out=$(wscheck $CHANGED)
+  if [[ -n "$out" ]]; then
+    echo "$out"
+    status=1
+  fi
+else
+  echo "wscheck not found, skipping whitespace check (go install github.com/openconfig/models-ci/validators/misc-checks/wscheck@latest)"
+fi
+
+# pyang lint
+if command -v pyang &> /dev/null; then
+  pyang_options=()
+  for p in "${search_paths[@]}"; do
+    pyang_options+=( -p "$p" )
+  done
+  for f in $CHANGED; do
+    pyang -W error "${pyang_options[@]}" "$f" || status=1
+  done
+else
+  echo "pyang not found, skipping pyang lint"
+fi
+
+# Version bump check
+if command -v openconfig-ci &> /dev/null; then
+  oldfile=$(mktemp --suffix=.yang)
+  for f in $CHANGED; do
+    # Newly-added files have nothing to compare against.
+    if git -C "$ROOT" show "$BASE:$f" > "$oldfile" 2> /dev/null; then
+      openconfig-ci diff --disallowed-incompats --newp "$(IFS=,; echo "${search_paths[*]}")" --oldfile "$oldfile" --newfile "$f" || status=1
+    fi
+  done
+  rm -f "$oldfile"
+else
+  echo "openconfig-ci not found, skipping version bump check"
+fi
+
+if [[ $status -ne 0 ]]; then
+  echo "{{ .GitCommand }} blocked by failed openconfig-ci checks."
+fi
+exit $status
+`))
+
+// hookParams is the input to hookTemplate.
+type hookParams struct {
+	// GitCommand is the git command that the hook runs before.
+	GitCommand string
+	// Cached indicates to check the staged changes against HEAD rather
+	// than the committed changes against DiffBase.
+	Cached      bool
+	DiffBase    string
+	SearchPaths []string
+}
+
+// installHooksCmd represents the install-hooks command, which installs git
+// hooks running fast local checks on changed YANG files.
+var installHooksCmd = &cobra.Command{
+	Use:   "install-hooks",
+	Short: "Install git hooks running fast local checks on changed YANG files",
+	Long: `Use this command within a clone of an OpenConfig models repository to install
+a git pre-commit hook (or pre-push hook) that runs the whitespace check, pyang
+lint and version bump check on the changed YANG files:
+
+openconfig-ci install-hooks -p release/models,third_party/ietf
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+
+		params := &hookParams{
+			GitCommand:  "commit",
+			Cached:      true,
+			SearchPaths: viper.GetStringSlice("paths"),
+		}
+		hookName := "pre-commit"
+		if viper.GetBool("pre-push") {
+			hookName = "pre-push"
+			params.GitCommand = "push"
+			params.Cached = false
+			params.DiffBase = viper.GetString("base")
+		}
+
+		out, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+		if err != nil {
+			return fmt.Errorf("cannot find git hooks directory, is this a git repository? %v", err)
+		}
+		hooksDir := strings.TrimSpace(string(out))
+		if err := os.MkdirAll(hooksDir, 0755); err != nil {
+			return fmt.Errorf("error while creating hooks directory %q: %v", hooksDir, err)
+		}
+
+		hookPath := filepath.Join(hooksDir, hookName)
+		if _, err := os.Stat(hookPath); err == nil && !viper.GetBool("force") {
+			return fmt.Errorf("hook %q already exists, use --force to overwrite it", hookPath)
+		}
+
+		var b strings.Builder
+		if err := hookTemplate.Execute(&b, params); err != nil {
+			return err
+		}
+		if err := os.WriteFile(hookPath, []byte(b.String()), 0755); err != nil {
+			return fmt.Errorf("error while writing hook %q: %v", hookPath, err)
+		}
+		fmt.Printf("Installed %s hook at %s\n", hookName, hookPath)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(installHooksCmd)
+
+	installHooksCmd.Flags().StringSliceP("paths", "p", []string{"release/models", "third_party/ietf"}, "search paths for resolving YANG imports, relative to the repository root")
+	installHooksCmd.Flags().Bool("pre-push", false, "install a pre-push hook checking all commits not in --base instead of a pre-commit hook")
+	installHooksCmd.Flags().String("base", "origin/master", "branch against which changes are checked by the pre-push hook")
+	installHooksCmd.Flags().Bool("force", false, "overwrite an existing hook")
+}
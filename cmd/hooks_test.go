@@ -0,0 +1,148 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHookChecksBlobs(t *testing.T) {
+	const (
+		file     = "release/models/openconfig-foo.yang"
+		clean    = "module openconfig-foo {\n  prefix foo;\n}\n"
+		tabbed   = "module openconfig-foo {\n\tprefix foo;\n}\n"
+		lintedAs = "pyang -W error -p %s/release/models release/models/openconfig-foo.yang"
+	)
+
+	// The fake tools log their arguments and, for pyang, the contents of
+	// the file linted, and wscheck reports lines with tabs.
+	binDir, logFile := t.TempDir(), filepath.Join(t.TempDir(), "log")
+	for name, script := range map[string]string{
+		"wscheck":       "grep -Hn $'\\t' \"$@\"\nexit 0\n",
+		"pyang":         "echo \"pyang $*\" >> " + logFile + "\ncat \"${@: -1}\" >> " + logFile + "\n",
+		"openconfig-ci": "exit 0\n",
+	} {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/bash\n"+script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		desc       string
+		inCached   bool
+		inCommit   string
+		inStaged   string
+		inWorktree string
+		wantPass   bool
+	}{{
+		desc:       "pre-commit staged tab",
+		inCached:   true,
+		inStaged:   tabbed,
+		inWorktree: clean,
+	}, {
+		desc:       "pre-commit worktree tab",
+		inCached:   true,
+		inStaged:   clean,
+		inWorktree: tabbed,
+		wantPass:   true,
+	}, {
+		desc:       "pre-push committed tab",
+		inCommit:   tabbed,
+		inWorktree: clean,
+	}, {
+		desc:       "pre-push worktree tab",
+		inCommit:   clean,
+		inWorktree: tabbed,
+		wantPass:   true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			repoDir := t.TempDir()
+			git := func(args ...string) string {
+				t.Helper()
+				cmd := exec.Command("git", append([]string{"-C", repoDir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, out)
+				}
+				return strings.TrimSpace(string(out))
+			}
+			writeFile := func(contents string) {
+				t.Helper()
+				if err := os.MkdirAll(filepath.Join(repoDir, filepath.Dir(file)), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(repoDir, file), []byte(contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			git("init", "-q")
+			git("commit", "-q", "--allow-empty", "-m", "base")
+			base := git("rev-parse", "HEAD")
+			if tt.inCommit != "" {
+				writeFile(tt.inCommit)
+				git("add", "-A")
+				git("commit", "-qm", "change")
+			}
+			if tt.inStaged != "" {
+				writeFile(tt.inStaged)
+				git("add", "-A")
+			}
+			writeFile(tt.inWorktree)
+
+			var b strings.Builder
+			if err := hookTemplate.Execute(&b, &hookParams{GitCommand: "commit", Cached: tt.inCached, DiffBase: base, SearchPaths: []string{"release/models"}}); err != nil {
+				t.Fatal(err)
+			}
+			hookPath := filepath.Join(t.TempDir(), "hook")
+			if err := os.WriteFile(hookPath, []byte(b.String()), 0755); err != nil {
+				t.Fatal(err)
+			}
+			os.Remove(logFile)
+			cmd := exec.Command("bash", hookPath)
+			cmd.Dir = repoDir
+			cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			out, err := cmd.CombinedOutput()
+			if gotPass := err == nil; gotPass != tt.wantPass {
+				t.Errorf("got pass %v, want %v, output:\n%s", gotPass, tt.wantPass, out)
+			}
+			if !tt.wantPass && !strings.Contains(string(out), file+":2:") {
+				t.Errorf("output doesn't report the tab at %s:2:\n%s", file, out)
+			}
+
+			// pyang lints the blob being checked in at the repo's path.
+			log, err := os.ReadFile(logFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := filepath.EvalSymlinks(repoDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantBlob := clean
+			if !tt.wantPass {
+				wantBlob = tabbed
+			}
+			if got, want := string(log), strings.Replace(lintedAs, "%s", root, 1)+"\n"+wantBlob; got != want {
+				t.Errorf("got pyang log %q, want %q", got, want)
+			}
+		})
+	}
+}
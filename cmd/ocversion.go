@@ -0,0 +1,42 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/openconfig/models-ci/ocversion"
+	"github.com/spf13/cobra"
+)
+
+// ocversionCmd represents the ocversion command, which lists the
+// openconfig-version of YANG files for misc-checks.
+var ocversionCmd = &cobra.Command{
+	Use:   "ocversion [ocversion flags] <file>...",
+	Short: "List the openconfig-version and module information of YANG files (ocversion)",
+	Long: `Use this command in place of the ocversion binary. The arguments are
+ocversion's flags in the same single-dash form followed by the YANG files, e.g.
+
+openconfig-ci ocversion -p release/models release/models/acl/openconfig-acl.yang
+`,
+	// The flags are parsed by ocversion itself, so the root command's flags and
+	// config don't apply.
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		ocversion.Main(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ocversionCmd)
+}
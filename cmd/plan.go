@@ -0,0 +1,154 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// planQuery answers a query of the validator scripts about the plan, which
+// is nil if cmd_gen didn't write one.
+type planQuery struct {
+	// nArgs is the number of arguments of the query.
+	nArgs int
+	run   func(p *commonci.Plan, args []string) (string, error)
+}
+
+// defaultBranch returns the default branch of the models repo.
+func defaultBranch(p *commonci.Plan) string {
+	if p == nil || p.DefaultBranch == "" {
+		return commonci.DefaultBranch
+	}
+	return p.DefaultBranch
+}
+
+// planQueries are the queries of the plan command by name.
+var planQueries = map[string]planQuery{
+	// extra-versions lists the versions of the validator other than its
+	// head version.
+	"extra-versions": {nArgs: 1, run: func(p *commonci.Plan, args []string) (string, error) {
+		if p == nil {
+			return "", nil
+		}
+		var versions []string
+		for _, v := range p.Validators {
+			if v.ValidatorId == args[0] && v.Version != "" && v.Version != "head" {
+				versions = append(versions, v.Version)
+			}
+		}
+		return strings.Join(versions, " "), nil
+	}},
+	"default-branch": {run: func(p *commonci.Plan, args []string) (string, error) {
+		return defaultBranch(p), nil
+	}},
+	// base-ref is the PR's base branch, or the default branch if it wasn't
+	// resolved by cmd_gen.
+	"base-ref": {run: func(p *commonci.Plan, args []string) (string, error) {
+		if p == nil || p.BaseRef == "" {
+			return defaultBranch(p), nil
+		}
+		return p.BaseRef, nil
+	}},
+	// merge-base is empty if it wasn't resolved by cmd_gen.
+	"merge-base": {run: func(p *commonci.Plan, args []string) (string, error) {
+		if p == nil {
+			return "", nil
+		}
+		return p.MergeBase, nil
+	}},
+	"pyang-matrix": {run: func(p *commonci.Plan, args []string) (string, error) {
+		return fmt.Sprint(p != nil && p.PyangMatrix), nil
+	}},
+	// compat-report is empty if there are no validators to report within
+	// the compatibility report.
+	"compat-report": {run: func(p *commonci.Plan, args []string) (string, error) {
+		if p == nil || len(p.CompatReport.Members) == 0 {
+			return "", nil
+		}
+		bs, err := json.MarshalIndent(p.CompatReport, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal compatibility report: %v", err)
+		}
+		return string(bs), nil
+	}},
+}
+
+// planQueryNames returns the sorted names of the plan queries.
+func planQueryNames() []string {
+	var names []string
+	for name := range planQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runPlanQuery returns the answer to the named query about the plan file at
+// path.
+func runPlanQuery(path, name string, args []string) (string, error) {
+	q, ok := planQueries[name]
+	if !ok {
+		return "", fmt.Errorf("unknown plan query %q, must be one of %s", name, strings.Join(planQueryNames(), ", "))
+	}
+	if len(args) != q.nArgs {
+		return "", fmt.Errorf("plan query %q accepts %d argument(s), got %d", name, q.nArgs, len(args))
+	}
+	p, err := commonci.ReadPlan(path)
+	if err != nil {
+		return "", err
+	}
+	return q.run(p, args)
+}
+
+// planCmd represents the plan command, which the validator scripts use to
+// read the plan written by cmd_gen.
+var planCmd = &cobra.Command{
+	Use:   "plan <query> [args...]",
+	Short: "Print a value of the CI run's plan written by cmd_gen",
+	Long: `Use this command within the validator scripts to read the plan written by
+cmd_gen, e.g. for the extra versions of a validator:
+
+openconfig-ci plan extra-versions pyang
+
+The queries are extra-versions <validator>, default-branch, base-ref,
+merge-base, pyang-matrix (true or false) and compat-report (the compatibility
+report as JSON, or empty if it has no validators). If there is no plan, the
+answers are the defaults, e.g. no extra versions.
+`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		answer, err := runPlanQuery(viper.GetString("plan-file"), args[0], args[1:])
+		if err != nil {
+			return commonci.WithExitCode(commonci.ExitConfigError, err)
+		}
+		fmt.Println(answer)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+
+	planCmd.Flags().String("plan-file", commonci.PlanFile, "plan file written by cmd_gen")
+}
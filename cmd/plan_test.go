@@ -0,0 +1,123 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+func TestRunPlanQuery(t *testing.T) {
+	p := commonci.NewPlan("main")
+	p.BaseRef = "release-1"
+	p.PyangMatrix = true
+	p.Validators = []commonci.PlannedValidator{
+		{ValidatorId: "pyang", Version: "head"},
+		{ValidatorId: "pyang", Version: "2.5.0"},
+		{ValidatorId: "pyang", Version: "2.6.0"},
+		{ValidatorId: "yanglint", Version: "2.1.x"},
+	}
+	bs, err := commonci.MarshalPlan(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planFile := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(planFile, bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	missingFile := filepath.Join(t.TempDir(), "plan.json")
+
+	tests := []struct {
+		name    string
+		inPath  string
+		inQuery string
+		inArgs  []string
+		want    string
+		wantErr bool
+	}{{
+		name:    "extra versions",
+		inPath:  planFile,
+		inQuery: "extra-versions",
+		inArgs:  []string{"pyang"},
+		want:    "2.5.0 2.6.0",
+	}, {
+		name:    "no extra versions",
+		inPath:  planFile,
+		inQuery: "extra-versions",
+		inArgs:  []string{"confd"},
+		want:    "",
+	}, {
+		name:    "base ref",
+		inPath:  planFile,
+		inQuery: "base-ref",
+		want:    "release-1",
+	}, {
+		name:    "default branch",
+		inPath:  planFile,
+		inQuery: "default-branch",
+		want:    "main",
+	}, {
+		name:    "pyang matrix",
+		inPath:  planFile,
+		inQuery: "pyang-matrix",
+		want:    "true",
+	}, {
+		name:    "empty compatibility report",
+		inPath:  planFile,
+		inQuery: "compat-report",
+		want:    "",
+	}, {
+		name:    "no plan extra versions",
+		inPath:  missingFile,
+		inQuery: "extra-versions",
+		inArgs:  []string{"pyang"},
+		want:    "",
+	}, {
+		name:    "no plan base ref",
+		inPath:  missingFile,
+		inQuery: "base-ref",
+		want:    commonci.DefaultBranch,
+	}, {
+		name:    "no plan pyang matrix",
+		inPath:  missingFile,
+		inQuery: "pyang-matrix",
+		want:    "false",
+	}, {
+		name:    "unknown query",
+		inPath:  planFile,
+		inQuery: "validators",
+		wantErr: true,
+	}, {
+		name:    "missing argument",
+		inPath:  planFile,
+		inQuery: "extra-versions",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runPlanQuery(tt.inPath, tt.inQuery, tt.inArgs)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/openconfig/models-ci/postresults"
+	"github.com/spf13/cobra"
+)
+
+// postResultsCmd represents the post-results command, which runs
+// post_results.
+var postResultsCmd = &cobra.Command{
+	Use:   "post-results [post_results flags]",
+	Short: "Post the results of a validator to the PR (post_results)",
+	Long: `Use this command in place of the post_results binary. The arguments are
+post_results's flags in the same single-dash form, e.g.
+
+openconfig-ci post-results -validator pyang -modelRoot release/models -repo-slug openconfig/public -pr-number 1 -commit-sha abc
+`,
+	// The flags are parsed by post_results itself, so the root command's flags and
+	// config don't apply.
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		postresults.Main(args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(postResultsCmd)
+}
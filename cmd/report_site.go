@@ -0,0 +1,55 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/report"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// reportSiteCmd represents the report-site command, which generates a static
+// site reporting the results of the validators.
+var reportSiteCmd = &cobra.Command{
+	Use:   "report-site --out <dir>",
+	Short: "Generate a static site reporting the validator results of a CI run",
+	Long: `Use this command to render the validator results of a CI run into a standalone
+static site, consisting of an index page linking to the full results of each
+validator, such that they can be published without GitHub:
+
+openconfig-ci report-site --results-dir /workspace/results --modelRoot /workspace/release/models --out site
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		// Register the validators defined by the models repo.
+		if err := commonci.ReadUserConfig(); err != nil {
+			return commonci.WithExitCode(commonci.ExitConfigError, err)
+		}
+		report.ModelRoot = viper.GetString("modelRoot")
+		report.SourceURL = viper.GetString("source-url")
+		return report.GenerateSite(viper.GetString("results-dir"), viper.GetString("out"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportSiteCmd)
+
+	reportSiteCmd.Flags().String("results-dir", commonci.ResultsDir, "directory containing the results directory of each validator")
+	reportSiteCmd.Flags().String("modelRoot", "", "comma-separated model roots relative to which the paths within the validator messages are reported")
+	reportSiteCmd.Flags().String("source-url", "", "(optional) URL under which the files of the models repo are viewed at the validated commit (e.g. https://github.com/openconfig/public/blob/<sha>), to which messages are linked")
+	reportSiteCmd.Flags().String("out", "", "directory into which to write the site")
+	reportSiteCmd.MarkFlagRequired("out")
+}
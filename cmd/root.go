@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -39,18 +41,23 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
-		os.Exit(1)
+		os.Exit(commonci.ExitCode(err))
 	}
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return commonci.WithExitCode(commonci.ExitConfigError, err)
+	})
+	rootCmd.Version = version.String()
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.openconfig-models-ci.yaml)")
+	rootCmd.PersistentFlags().StringVar(&commonci.SpecPathPrefix, "spec-path-prefix", commonci.SpecPathPrefix, "prefix of the build and examples paths within .spec.yml files that's replaced by the path of the model root containing them; if empty, the paths are relative to the model root")
 }
 
 // initConfig reads in config file and ENV variables if set.
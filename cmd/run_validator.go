@@ -0,0 +1,61 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/runner"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// runValidatorCmd represents the run-validator command, which runs the
+// per-model commands of a validator generated by cmd_gen.
+var runValidatorCmd = &cobra.Command{
+	Use:   "run-validator --plan <plan-file> [-- args...]",
+	Short: "Run the per-model commands of a validator as generated by cmd_gen -runner=go",
+	Long: `Use this command to run the validator plan generated by cmd_gen -runner=go, writing
+the per-model result files read by post_results. The arguments are substituted for
+"$@" and "$1" to "$9" within the plan's commands, as for the generated bash scripts:
+
+openconfig-ci run-validator --plan /workspace/results/pyang/plan.json -- pyang
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		plan, err := runner.ReadPlan(viper.GetString("plan"))
+		if err != nil {
+			return commonci.WithExitCode(commonci.ExitConfigError, err)
+		}
+
+		// Running commands are killed when CI cancels the job.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return commonci.WithExitCode(commonci.ExitInfraError, runner.Run(ctx, plan, args, viper.GetInt("parallelism")))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runValidatorCmd)
+
+	runValidatorCmd.Flags().String("plan", "", "validator plan file generated by cmd_gen")
+	runValidatorCmd.Flags().Int("parallelism", runtime.NumCPU(), "maximum number of models validated at once if the validator runs in parallel")
+	runValidatorCmd.MarkFlagRequired("plan")
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yangentry"
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/yangutil"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// modelBuildFiles returns the build files of the named model as defined by
+// the .spec.yml files within the model roots.
+func modelBuildFiles(modelRoots, modelName string) ([]string, error) {
+	modelMap, err := commonci.ParseOCModels(modelRoots)
+	if err != nil {
+		return nil, err
+	}
+	for _, modelInfos := range modelMap.ModelInfoMap {
+		for _, modelInfo := range modelInfos {
+			if modelInfo.Name == modelName {
+				return modelInfo.BuildFiles, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("model %q not found in the .spec.yml files of %q", modelName, modelRoots)
+}
+
+// treeCmd represents the tree command, which prints the schema tree of a
+// model's build files.
+var treeCmd = &cobra.Command{
+	Use:   "tree <model-name>",
+	Short: "Print the schema tree of an OpenConfig model as built by CI",
+	Long: `Use this command to print a pyang-style tree of a model defined in a .spec.yml
+file, using the same build files as CI:
+
+openconfig-ci tree --modelRoot public/release/models -p public/third_party openconfig-interfaces
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		viper.BindPFlags(cmd.Flags())
+		modelRoots := viper.GetString("modelRoot")
+		buildFiles, err := modelBuildFiles(modelRoots, args[0])
+		if err != nil {
+			return err
+		}
+
+		// The model roots are also searched since build files import
+		// modules of other models.
+		paths := append(viper.GetStringSlice("paths"), commonci.SplitModelRoots(modelRoots)...)
+		entries, errs := yangentry.Parse(buildFiles, paths)
+		if errs != nil {
+			return fmt.Errorf("error while parsing build files of model %q: %v", args[0], errs)
+		}
+
+		// Imported modules are also parsed, so only print the modules of
+		// the build files.
+		var modules []string
+		for _, file := range buildFiles {
+			module := strings.TrimSuffix(filepath.Base(file), ".yang")
+			if i := strings.Index(module, "@"); i != -1 {
+				module = module[:i]
+			}
+			if _, ok := entries[module]; ok {
+				modules = append(modules, module)
+			}
+		}
+		sort.Strings(modules)
+		for i, module := range modules {
+			if i != 0 {
+				fmt.Println()
+			}
+			if err := yangutil.WriteTree(os.Stdout, entries[module]); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+
+	treeCmd.Flags().String("modelRoot", "", "comma-separated model roots containing the .spec.yml files of the models")
+	treeCmd.Flags().StringSliceP("paths", "p", []string{}, "search paths for resolving YANG imports in addition to the model roots")
+}
@@ -0,0 +1,288 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+const (
+	// bazelBuildFileName is the name of the generated BUILD file.
+	bazelBuildFileName = "BUILD.bazel"
+	// bazelScriptsDir is the directory within the Bazel package containing
+	// the generated test scripts.
+	bazelScriptsDir = "ci-scripts"
+	// bazelResultsDir is the results directory of each test script. Bazel
+	// preserves the files within it as the test's outputs.
+	bazelResultsDir = "$TEST_UNDECLARED_OUTPUTS_DIR"
+)
+
+// bazelScriptFooter outputs the results of the model's validation, and fails
+// the test if the validation failed or timed out.
+const bazelScriptFooter = `wait
+cat ` + bazelResultsDir + `/*==* 2> /dev/null
+! compgen -G "` + bazelResultsDir + `/*==fail" > /dev/null && ! compgen -G "` + bazelResultsDir + `/*==timeout" > /dev/null
+`
+
+// bazelTest is a single sh_test target validating a single model.
+type bazelTest struct {
+	Name   string
+	Script string
+}
+
+// bazelSuite is the test_suite target of all tests of a validator.
+type bazelSuite struct {
+	ValidatorId string
+	Tests       []bazelTest
+}
+
+var bazelBuildTemplate = mustTemplate("bazel-build", `# Code generated by cmd_gen. DO NOT EDIT.
+
+filegroup(
+    name = "yang_files",
+    srcs = glob(
+        [
+{{- range .YangGlobs }}
+            "{{ . }}",
+{{- end }}
+        ],
+        allow_empty = True,
+    ),
+)
+{{- range .Suites }}
+{{- range .Tests }}
+
+sh_test(
+    name = "{{ .Name }}",
+    srcs = ["{{ .Script }}"],
+{{- if $.Args }}
+    args = [
+{{- range $.Args }}
+        {{ printf "%q" . }},
+{{- end }}
+    ],
+{{- end }}
+    data = [":yang_files"],
+)
+{{- end }}
+
+test_suite(
+    name = "{{ .ValidatorId }}",
+    tests = [
+{{- range .Tests }}
+        ":{{ .Name }}",
+{{- end }}
+    ],
+)
+{{- end }}
+`)
+
+// relBazelModelMap parses the models within modelRoots, with every path made
+// relative to the Bazel package at pkgDir, which must contain the models.
+func relBazelModelMap(modelRoots, pkgDir string) (commonci.OpenConfigModelMap, error) {
+	absPkgDir, err := filepath.Abs(pkgDir)
+	if err != nil {
+		return commonci.OpenConfigModelMap{}, err
+	}
+	var absRoots []string
+	for _, root := range commonci.SplitModelRoots(modelRoots) {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return commonci.OpenConfigModelMap{}, err
+		}
+		absRoots = append(absRoots, absRoot)
+	}
+	modelMap, err := commonci.ParseOCModels(strings.Join(absRoots, ","))
+	if err != nil {
+		return commonci.OpenConfigModelMap{}, err
+	}
+	for _, missing := range removeModelsWithMissingBuildFiles(modelMap) {
+		log.Printf("skipping model: %s", missing)
+	}
+
+	rel := func(path string) (string, error) {
+		relPath, err := filepath.Rel(absPkgDir, path)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, "../") {
+			return "", fmt.Errorf("%s is not within the Bazel package directory %s", path, absPkgDir)
+		}
+		return filepath.ToSlash(relPath), nil
+	}
+	for i, root := range modelMap.ModelRoots {
+		if modelMap.ModelRoots[i], err = rel(root); err != nil {
+			return commonci.OpenConfigModelMap{}, err
+		}
+	}
+	for _, modelInfos := range modelMap.ModelInfoMap {
+		for _, modelInfo := range modelInfos {
+			for i, buildFile := range modelInfo.BuildFiles {
+				if modelInfo.BuildFiles[i], err = rel(buildFile); err != nil {
+					return commonci.OpenConfigModelMap{}, err
+				}
+			}
+		}
+	}
+	return modelMap, nil
+}
+
+// bazelValidatorIds returns the validators for which Bazel test targets are
+// generated from the comma-separated validatorIds, or all per-model
+// validators if empty. misc-checks is excluded since it doesn't report a
+// result per model.
+func bazelValidatorIds(validatorIds string) ([]string, error) {
+	if validatorIds == "" {
+		var ids []string
+		for validatorId, validator := range commonci.Validators {
+			if validator.IsPerModel && validatorId != "misc-checks" && scriptTemplates[validatorId] != nil {
+				ids = append(ids, validatorId)
+			}
+		}
+		sort.Strings(ids)
+		return ids, nil
+	}
+	ids := strings.Split(validatorIds, ",")
+	for _, validatorId := range ids {
+		validator, ok := commonci.Validators[validatorId]
+		switch {
+		case !ok || scriptTemplates[validatorId] == nil:
+			return nil, fmt.Errorf("unrecognized validator %q", validatorId)
+		case !validator.IsPerModel || validatorId == "misc-checks":
+			return nil, fmt.Errorf("validator %q doesn't validate each model separately", validatorId)
+		}
+	}
+	return ids, nil
+}
+
+// genBazelTargets generates a Bazel package wrapping each per-model
+// invocation of each validator in an sh_test target, such that the
+// validations can be run under Bazel (e.g. using remote execution) instead of
+// GCB. The package is expected to be at the root of the models repo, with all
+// paths within modelMap relative to it. toolArgs are passed to each test in
+// the same way as the validator's test.sh (e.g. the path to pyang).
+//
+// The generated files are returned keyed by their path within the package.
+func genBazelTargets(validatorIds, toolArgs []string, modelMap commonci.OpenConfigModelMap) (map[string]string, error) {
+	modelDirNames := make([]string, 0, len(modelMap.ModelInfoMap))
+	for modelDirName := range modelMap.ModelInfoMap {
+		if !disabledModelPaths[modelDirName] {
+			modelDirNames = append(modelDirNames, modelDirName)
+		}
+	}
+	sort.Strings(modelDirNames)
+
+	files := map[string]string{}
+	var suites []bazelSuite
+	for _, validatorId := range validatorIds {
+		cmdTemplate, ok := scriptTemplates[validatorId]
+		if !ok {
+			return nil, fmt.Errorf("cmd_gen: unrecognized validatorId %q for creating a per-model test script", validatorId)
+		}
+		var header strings.Builder
+		if err := cmdTemplate.headerTemplate.Execute(&header, &cmdParams{
+			ModelRoots: modelMap.ModelRoots,
+			RepoRoot:   ".",
+			ResultsDir: bazelResultsDir,
+		}); err != nil {
+			return nil, err
+		}
+
+		suite := bazelSuite{ValidatorId: validatorId}
+		for _, modelDirName := range modelDirNames {
+			for _, modelInfo := range modelMap.ModelInfoMap[modelDirName] {
+				// Generate the command of each model separately.
+				modelOnly := commonci.OpenConfigModelMap{
+					ModelRoots:   modelMap.ModelRoots,
+					ModelInfoMap: map[string][]commonci.ModelInfo{modelDirName: {modelInfo}},
+				}
+				cmdStr, count, err := genValidatorCommandForModelDir(validatorId, bazelResultsDir, modelDirName, modelOnly, false)
+				if err != nil {
+					return nil, err
+				}
+				if count == 0 {
+					continue
+				}
+				name := strings.Join([]string{validatorId, strings.ReplaceAll(modelDirName, ":", "."), modelInfo.Name}, ".")
+				script := bazelScriptsDir + "/" + name + ".sh"
+				files[script] = header.String() + cmdStr + bazelScriptFooter
+				suite.Tests = append(suite.Tests, bazelTest{Name: name, Script: script})
+			}
+		}
+		suites = append(suites, suite)
+	}
+
+	var yangGlobs []string
+	for _, root := range append(append([]string{}, modelMap.ModelRoots...), "third_party/ietf") {
+		if root == "." {
+			yangGlobs = append(yangGlobs, "**/*.yang")
+			continue
+		}
+		yangGlobs = append(yangGlobs, root+"/**/*.yang")
+	}
+	// The example instance documents validated by yang-examples are also
+	// inputs of the tests.
+	exampleDirs := map[string]bool{}
+	for _, modelDirName := range modelDirNames {
+		for _, modelInfo := range modelMap.ModelInfoMap[modelDirName] {
+			if modelInfo.ExamplesDir != "" && !exampleDirs[modelInfo.ExamplesDir] {
+				exampleDirs[modelInfo.ExamplesDir] = true
+				yangGlobs = append(yangGlobs, modelInfo.ExamplesDir+"/**")
+			}
+		}
+	}
+
+	var build strings.Builder
+	if err := bazelBuildTemplate.Execute(&build, struct {
+		YangGlobs []string
+		Args      []string
+		Suites    []bazelSuite
+	}{
+		YangGlobs: yangGlobs,
+		Args:      toolArgs,
+		Suites:    suites,
+	}); err != nil {
+		return nil, err
+	}
+	files[bazelBuildFileName] = build.String()
+	return files, nil
+}
+
+// writeBazelTargets writes the files generated by genBazelTargets into the
+// Bazel package at pkgDir, replacing any previously generated scripts.
+func writeBazelTargets(pkgDir string, files map[string]string) error {
+	scriptsDir := filepath.Join(pkgDir, bazelScriptsDir)
+	if err := os.RemoveAll(scriptsDir); err != nil {
+		return fmt.Errorf("error while removing directory %q: %v", scriptsDir, err)
+	}
+	if err := os.MkdirAll(scriptsDir, 0755); err != nil {
+		return fmt.Errorf("error while creating directory %q: %v", scriptsDir, err)
+	}
+	for name, content := range files {
+		path := filepath.Join(pkgDir, filepath.FromSlash(name))
+		perm := os.FileMode(0755)
+		if name == bazelBuildFileName {
+			perm = 0644
+		}
+		if err := os.WriteFile(path, []byte(content), perm); err != nil {
+			return fmt.Errorf("error while writing file %q: %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// fixtureModelRoots returns the model roots used within the canonical
+// testdata results of the report package corresponding to the given model roots:
+// the first root is the release directory of the models repo, and any other
+// root is a sibling of it.
+func fixtureModelRoots(modelRoots []string) []string {
+	var roots []string
+	for i, root := range modelRoots {
+		if i == 0 {
+			roots = append(roots, commonci.RootDir+"/release/yang")
+			continue
+		}
+		roots = append(roots, commonci.RootDir+"/"+filepath.Base(root))
+	}
+	return roots
+}
+
+// canonicalizeFixture rewrites the local model roots and results directory
+// within every file in resultsDir into the paths they would have in GCB, such
+// that the results are independent of where they were generated.
+func canonicalizeFixture(resultsDir, validatorId string, modelRoots []string) error {
+	var oldnew [][2]string
+	oldnew = append(oldnew, [2]string{resultsDir, commonci.ValidatorResultsDir(validatorId, "")})
+	for i, root := range fixtureModelRoots(modelRoots) {
+		oldnew = append(oldnew, [2]string{modelRoots[i], root})
+	}
+	// Replace longer paths first, since a path may contain another.
+	sort.SliceStable(oldnew, func(i, j int) bool { return len(oldnew[i][0]) > len(oldnew[j][0]) })
+	var pairs []string
+	for _, p := range oldnew {
+		pairs = append(pairs, p[0], p[1])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(resultsDir, entry.Name())
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(replacer.Replace(string(bs))), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// genFixture runs the validator script on the models within modelRoots and
+// outputs the canonicalized results into resultsDir, for use as realistic
+// report testdata. toolArgs are passed to the script in the same way as
+// the validator's test.sh (e.g. the path to pyang).
+//
+// The third_party directory of the models repo isn't available locally, so
+// the models must be self-contained.
+func genFixture(validatorId string, toolArgs []string, resultsDir, modelRoots string) error {
+	cmdTemplate, ok := scriptTemplates[validatorId]
+	if !ok {
+		return fmt.Errorf("cmd_gen: unrecognized validatorId %q for creating a per-model test script", validatorId)
+	}
+
+	// Absolute paths can be canonicalized without ambiguity.
+	var absRoots []string
+	for _, root := range commonci.SplitModelRoots(modelRoots) {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		absRoots = append(absRoots, absRoot)
+	}
+	absResultsDir, err := filepath.Abs(resultsDir)
+	if err != nil {
+		return err
+	}
+
+	modelMap, err := commonci.ParseOCModels(strings.Join(absRoots, ","))
+	if err != nil {
+		return err
+	}
+	for _, missing := range removeModelsWithMissingBuildFiles(modelMap) {
+		log.Printf("skipping model: %s", missing)
+	}
+
+	var builder strings.Builder
+	if err := cmdTemplate.headerTemplate.Execute(&builder, &cmdParams{
+		ModelRoots: modelMap.ModelRoots,
+		RepoRoot:   commonci.RootDir,
+		ResultsDir: absResultsDir,
+	}); err != nil {
+		return err
+	}
+	modelDirNames := make([]string, 0, len(modelMap.ModelInfoMap))
+	for modelDirName := range modelMap.ModelInfoMap {
+		modelDirNames = append(modelDirNames, modelDirName)
+	}
+	sort.Strings(modelDirNames)
+	for _, modelDirName := range modelDirNames {
+		// Run serially such that the results are deterministic.
+		cmdStr, _, err := genValidatorCommandForModelDir(validatorId, absResultsDir, modelDirName, modelMap, false)
+		if err != nil {
+			return err
+		}
+		builder.WriteString(cmdStr)
+	}
+
+	if err := os.MkdirAll(absResultsDir, 0755); err != nil {
+		return fmt.Errorf("error while creating directory %q: %v", absResultsDir, err)
+	}
+	scriptPath := filepath.Join(absResultsDir, commonci.ScriptFileName)
+	if err := os.WriteFile(scriptPath, []byte(builder.String()), 0744); err != nil {
+		return fmt.Errorf("error while writing script file %q: %v", scriptPath, err)
+	}
+
+	outFile, err := os.Create(filepath.Join(absResultsDir, commonci.OutFileName))
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+	failPath := filepath.Join(absResultsDir, commonci.FailFileName)
+	failFile, err := os.Create(failPath)
+	if err != nil {
+		return err
+	}
+	defer failFile.Close()
+
+	cmd := exec.Command("bash", append([]string{scriptPath}, toolArgs...)...)
+	cmd.Stdout, cmd.Stderr = outFile, failFile
+	runErr := cmd.Run()
+
+	// Like test.sh, delete the fail file if it's empty and the script passed.
+	if info, err := failFile.Stat(); err == nil && info.Size() == 0 && runErr == nil {
+		failFile.Close()
+		if err := os.Remove(failPath); err != nil {
+			return err
+		}
+	}
+	// These are generated during the build rather than read by post_results.
+	for _, name := range []string{commonci.ScriptFileName, commonci.CompletedModelsFileName} {
+		if err := os.Remove(filepath.Join(absResultsDir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return canonicalizeFixture(absResultsDir, validatorId, absRoots)
+}
@@ -0,0 +1,227 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// githubActionsJob is a single job of the GitHub Actions job matrix, which
+// runs a validator on a shard of the model directories.
+type githubActionsJob struct {
+	Validator string `json:"validator"`
+	Version   string `json:"version"`
+	// Name is the <validatorId>[@<version>] name of the validator.
+	Name string `json:"name"`
+	// Shard is the 1-based index of the job's shard of the model
+	// directories, or 0 if the validator isn't sharded.
+	Shard int `json:"shard"`
+	// ModelDirs is the comma-separated list of the model directories of
+	// the shard, or empty if the validator isn't sharded.
+	ModelDirs string `json:"modelDirs"`
+}
+
+// githubActionsMatrix is a GitHub Actions job matrix (i.e. the value of a
+// job's strategy.matrix) consisting of only explicitly included jobs.
+type githubActionsMatrix struct {
+	Include []githubActionsJob `json:"include"`
+}
+
+// githubActionsValidators returns the validators and versions to run under
+// GitHub Actions in lexical order, which are all those with generated
+// scripts, along with their extra versions (see extraValidatorVersions), minus
+// the skipped validators.
+func githubActionsValidators(skippedValidators string, extra, pinned map[string][]string) []commonci.ValidatorAndVersion {
+	_, skippedMap := commonci.GetValidatorAndVersionsFromString(skippedValidators)
+	var validatorIds []string
+	for validatorId, validator := range commonci.Validators {
+		if _, ok := scriptTemplates[validatorId]; ok && !validator.ReportOnly {
+			validatorIds = append(validatorIds, validatorId)
+		}
+	}
+	sort.Strings(validatorIds)
+
+	var vvs []commonci.ValidatorAndVersion
+	for _, validatorId := range validatorIds {
+		for _, version := range append([]string{""}, extraValidatorVersions(validatorId, extra, pinned)...) {
+			if !skippedMap[validatorId][version] {
+				vvs = append(vvs, commonci.ValidatorAndVersion{ValidatorId: validatorId, Version: version})
+			}
+		}
+	}
+	return vvs
+}
+
+// isShardedValidator returns whether the validator's model directories can be
+// split across jobs or build steps: misc-checks compares the whole repo against master, and
+// repo-level validators run on the whole repo.
+func isShardedValidator(validatorId string) bool {
+	validator, ok := commonci.Validators[validatorId]
+	return ok && validator.IsPerModel && validatorId != "misc-checks"
+}
+
+// genGithubActionsMatrix generates the job matrix running each of the
+// validators on up to the given number of shards of the model directories.
+// Only model directories with models that the validator runs on are
+// included.
+func genGithubActionsMatrix(vvs []commonci.ValidatorAndVersion, shards int, modelMap commonci.OpenConfigModelMap) (*githubActionsMatrix, error) {
+	if shards < 1 {
+		return nil, fmt.Errorf("invalid number of shards %d, must be at least 1", shards)
+	}
+	matrix := &githubActionsMatrix{Include: []githubActionsJob{}}
+	for _, vv := range vvs {
+		validator, ok := commonci.Validators[vv.ValidatorId]
+		if !ok {
+			return nil, fmt.Errorf("unrecognized validator %q", vv.ValidatorId)
+		}
+		job := githubActionsJob{
+			Validator: vv.ValidatorId,
+			Version:   vv.Version,
+			Name:      commonci.AppendVersionToName(vv.ValidatorId, vv.Version),
+		}
+		if !isShardedValidator(vv.ValidatorId) {
+			matrix.Include = append(matrix.Include, job)
+			continue
+		}
+
+		for i, dirs := range splitShards(validatedModelDirs(validator, modelMap), shards) {
+			job.Shard = i + 1
+			job.ModelDirs = strings.Join(dirs, ",")
+			matrix.Include = append(matrix.Include, job)
+		}
+	}
+	return matrix, nil
+}
+
+// genGithubActionsScript generates the validator script of a single job of
+// the matrix, which validates the comma-separated model directories (or all
+// model directories if empty) of the models repo checked out at repoRoot, and
+// writes the results into resultsDir. It returns the script along with the
+// number of models it validates.
+func genGithubActionsScript(validatorId, version, modelDirs, repoRoot, resultsDir string, modelMap commonci.OpenConfigModelMap) (string, int, error) {
+	cmdTemplate, ok := scriptTemplates[validatorId]
+	if !ok {
+		return "", 0, fmt.Errorf("cmd_gen: unrecognized validatorId %q for creating a test script", validatorId)
+	}
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return "", 0, fmt.Errorf("cmd_gen: unrecognized validatorId %q", validatorId)
+	}
+	if !validator.IsPerModel {
+		if modelDirs != "" {
+			return "", 0, fmt.Errorf("validator %q runs on the whole repo, so model directories can't be specified", validatorId)
+		}
+		script, err := genRepoLevelValidatorScript(validatorId, repoRoot, resultsDir, modelMap)
+		return script, 0, err
+	}
+
+	var modelDirNames []string
+	if modelDirs == "" {
+		for modelDirName := range modelMap.ModelInfoMap {
+			if !disabledModelPaths[modelDirName] {
+				modelDirNames = append(modelDirNames, modelDirName)
+			}
+		}
+		sort.Strings(modelDirNames)
+	} else {
+		modelDirNames = strings.Split(modelDirs, ",")
+	}
+
+	var builder strings.Builder
+	if err := cmdTemplate.headerTemplate.Execute(&builder, &cmdParams{
+		ModelRoots: modelMap.ModelRoots,
+		RepoRoot:   repoRoot,
+		ResultsDir: resultsDir,
+	}); err != nil {
+		return "", 0, err
+	}
+	parallel := runInParallel(validatorId, version)
+	modelCount := 0
+	for _, modelDirName := range modelDirNames {
+		if _, ok := modelMap.ModelInfoMap[modelDirName]; !ok {
+			return "", 0, fmt.Errorf("unrecognized model directory %q", modelDirName)
+		}
+		cmdStr, count, err := genValidatorCommandForModelDir(validatorId, resultsDir, modelDirName, modelMap, parallel)
+		if err != nil {
+			return "", 0, err
+		}
+		builder.WriteString(cmdStr)
+		modelCount += count
+	}
+	// In case there are parallel commands.
+	builder.WriteString("wait\n")
+	return builder.String(), modelCount, nil
+}
+
+// githubActions prints the GitHub Actions job matrix if no validator is
+// specified, and otherwise writes the script of the job running the validator
+// on the shard of model directories into the results directory.
+func githubActions(modelMap commonci.OpenConfigModelMap) error {
+	if localValidatorId == "" {
+		vvs := githubActionsValidators(skippedValidators, extraVersionsMap, pinnedVersions)
+		matrix, err := genGithubActionsMatrix(vvs, shards, modelMap)
+		if err != nil {
+			return err
+		}
+		bs, err := json.Marshal(matrix)
+		if err != nil {
+			return fmt.Errorf("failed to marshal job matrix: %v", err)
+		}
+		fmt.Println(string(bs))
+		return nil
+	}
+
+	vvs, _ := commonci.GetValidatorAndVersionsFromString(localValidatorId)
+	if len(vvs) != 1 {
+		return fmt.Errorf("invalid validator %q, must be a single <validatorId>[@<version>]", localValidatorId)
+	}
+	resultsDir, err := filepath.Abs(localResultsDir)
+	if err != nil {
+		return err
+	}
+	// The models repo is checked out into the GitHub workspace rather than
+	// GCB's /workspace.
+	repoRoot := commonci.RootDir
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		repoRoot = workspace
+	}
+	script, modelCount, err := genGithubActionsScript(vvs[0].ValidatorId, vvs[0].Version, localModelDirName, repoRoot, resultsDir, modelMap)
+	if err != nil {
+		return err
+	}
+	script = withValidatorHooks(script, validatorHooksMap[vvs[0].ValidatorId])
+	if err := os.MkdirAll(resultsDir, 0755); err != nil {
+		return fmt.Errorf("error while creating directory %q: %v", resultsDir, err)
+	}
+	scriptPath := filepath.Join(resultsDir, commonci.ScriptFileName)
+	if err := os.WriteFile(scriptPath, []byte(script), 0744); err != nil {
+		return fmt.Errorf("error while writing script to path %q: %v", scriptPath, err)
+	}
+	if isShardedValidator(vvs[0].ValidatorId) {
+		modelCountPath := filepath.Join(resultsDir, commonci.ExpectedModelCountFileName)
+		if err := commonci.WriteFile(modelCountPath, []byte(strconv.Itoa(modelCount)), 0444); err != nil {
+			return fmt.Errorf("error while writing expected model count to path %q: %v", modelCountPath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/yangutil"
+)
+
+// yangDependencyRegex matches the modules imported or included by a YANG
+// module.
+var yangDependencyRegex = regexp.MustCompile(`(?m)^\s*(?:import|include)\s+["']?([A-Za-z0-9_.-]+)["']?`)
+
+// yangModuleName returns the name of the YANG module defined by the file,
+// which by convention is its base name without any revision.
+func yangModuleName(file string) string {
+	name := strings.TrimSuffix(filepath.Base(file), ".yang")
+	return strings.SplitN(name, "@", 2)[0]
+}
+
+// readChangedFiles reads the newline-separated list of files changed by the
+// PR (e.g. the output of "git diff --name-only"), relative to the repo root.
+func readChangedFiles(file string) ([]string, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading changed files file %q: %v", file, err)
+	}
+	return strings.Fields(string(bs)), nil
+}
+
+// yangDependencies returns the modules directly imported or included by each
+// YANG module within the directories. Directories that don't exist are
+// skipped.
+func yangDependencies(dirs []string) (map[string][]string, error) {
+	deps := map[string][]string{}
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		files, err := yangutil.GetAllYANGFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			bs, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			name := yangModuleName(file)
+			for _, match := range yangDependencyRegex.FindAllStringSubmatch(string(bs), -1) {
+				deps[name] = append(deps[name], match[1])
+			}
+		}
+	}
+	return deps, nil
+}
+
+// affectedModelDirs returns the model directories affected by the changed
+// files (relative to repoRoot): those with a changed .spec.yml or example
+// instance document, or a model whose build files directly or transitively
+// import or include a changed YANG module. If the changes may affect every model directory (e.g. a change to
+// the validators config or the disabled directories), then nil is returned.
+func affectedModelDirs(modelMap commonci.OpenConfigModelMap, changedFiles []string, repoRoot string) (map[string]bool, error) {
+	absRepoRoot, err := filepath.Abs(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	var relRoots []string
+	for _, root := range modelMap.ModelRoots {
+		absRoot, err := filepath.Abs(root)
+		if err != nil {
+			return nil, err
+		}
+		relRoot, err := filepath.Rel(absRepoRoot, absRoot)
+		if err != nil {
+			return nil, err
+		}
+		relRoots = append(relRoots, filepath.ToSlash(relRoot))
+	}
+
+	affected := map[string]bool{}
+	changedModules := map[string]bool{}
+	for _, file := range changedFiles {
+		switch {
+		case file == commonci.ValidatorsConfigFileName, file == commonci.DisabledDirsFileName, file == commonci.ValidatorVersionsFileName:
+			return nil, nil
+		case filepath.Ext(file) == ".yang":
+			changedModules[yangModuleName(file)] = true
+		case filepath.Base(file) == ".spec.yml":
+			dir := path.Dir(file)
+			for _, relRoot := range relRoots {
+				if relRoot == "." {
+					affected[strings.ReplaceAll(dir, "/", ":")] = true
+				} else if strings.HasPrefix(dir, relRoot+"/") {
+					affected[strings.ReplaceAll(strings.TrimPrefix(dir, relRoot+"/"), "/", ":")] = true
+				}
+			}
+		}
+	}
+
+	for modelDirName, modelInfos := range modelMap.ModelInfoMap {
+		for _, modelInfo := range modelInfos {
+			if modelInfo.ExamplesDir == "" {
+				continue
+			}
+			absDir, err := filepath.Abs(modelInfo.ExamplesDir)
+			if err != nil {
+				return nil, err
+			}
+			relDir, err := filepath.Rel(absRepoRoot, absDir)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range changedFiles {
+				if strings.HasPrefix(file, filepath.ToSlash(relDir)+"/") {
+					affected[modelDirName] = true
+				}
+			}
+		}
+	}
+	if len(changedModules) == 0 {
+		return affected, nil
+	}
+
+	deps, err := yangDependencies(append(append([]string{}, modelMap.ModelRoots...), filepath.Join(repoRoot, "third_party")))
+	if err != nil {
+		return nil, err
+	}
+	// dependsOnChange memoizes whether each module's transitive
+	// dependencies include a changed module.
+	dependsOnChange := map[string]bool{}
+	var visit func(module string, visiting map[string]bool) bool
+	visit = func(module string, visiting map[string]bool) bool {
+		if v, ok := dependsOnChange[module]; ok {
+			return v
+		}
+		if changedModules[module] {
+			dependsOnChange[module] = true
+			return true
+		}
+		// Guard against circular dependencies.
+		if visiting[module] {
+			return false
+		}
+		visiting[module] = true
+		result := false
+		for _, dep := range deps[module] {
+			if visit(dep, visiting) {
+				result = true
+				break
+			}
+		}
+		dependsOnChange[module] = result
+		return result
+	}
+
+	for modelDirName, modelInfos := range modelMap.ModelInfoMap {
+		for _, modelInfo := range modelInfos {
+			for _, buildFile := range modelInfo.BuildFiles {
+				if visit(yangModuleName(buildFile), map[string]bool{}) {
+					affected[modelDirName] = true
+				}
+			}
+		}
+	}
+	return affected, nil
+}
+
+// filterModelMap returns the model map with only the given model directories,
+// along with the sorted names of the removed model directories.
+func filterModelMap(modelMap commonci.OpenConfigModelMap, modelDirs map[string]bool) (commonci.OpenConfigModelMap, []string) {
+	filtered := commonci.OpenConfigModelMap{
+		ModelRoots:   modelMap.ModelRoots,
+		ModelInfoMap: map[string][]commonci.ModelInfo{},
+	}
+	var removed []string
+	for modelDirName, modelInfos := range modelMap.ModelInfoMap {
+		if !modelDirs[modelDirName] {
+			removed = append(removed, modelDirName)
+			continue
+		}
+		filtered.ModelInfoMap[modelDirName] = modelInfos
+	}
+	sort.Strings(removed)
+	return filtered, removed
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// listedValidator is a validator version that cmd_gen activates.
+type listedValidator struct {
+	ValidatorId string `json:"validatorId"`
+	Version     string `json:"version,omitempty"`
+	// Name is the <validatorId>[@<version>] name of the validator.
+	Name       string `json:"name"`
+	IsPerModel bool   `json:"isPerModel"`
+	// CompatReport indicates that the validator is reported within the
+	// compatibility report rather than by its own PR status.
+	CompatReport bool `json:"compatReport"`
+	// Script indicates that cmd_gen generates the validator's script.
+	Script bool `json:"script"`
+}
+
+// listedModel is a model defined by a .spec.yml file.
+type listedModel struct {
+	Name        string   `json:"name"`
+	BuildFiles  []string `json:"buildFiles"`
+	DocFiles    []string `json:"docFiles,omitempty"`
+	RunCi       bool     `json:"runCi"`
+	ExamplesDir string   `json:"examplesDir,omitempty"`
+	SpecFile    string   `json:"specFile"`
+}
+
+// listing is the output of -list, describing the resolved configuration of
+// the run for external tooling and debugging.
+type listing struct {
+	Validators   []listedValidator      `json:"validators"`
+	CompatReport *commonci.CompatReport `json:"compatReport"`
+	ModelRoots   []string               `json:"modelRoots"`
+	// Models are the models of each model directory keyed by the model
+	// directory's path relative to its model root.
+	Models map[string][]listedModel `json:"models"`
+	// DisabledModelDirs are the model directories disabled by the models
+	// repo, which don't undergo CI.
+	DisabledModelDirs []string `json:"disabledModelDirs"`
+}
+
+// genListing returns the listing of the validators activated given the
+// skipped validators, the extra and pinned versions, and the compatibility
+// report, along with the parsed models. PR control labels aren't applied,
+// since they aren't read.
+func genListing(skippedValidators string, extra, pinned map[string][]string, compatReport *commonci.CompatReport, modelMap commonci.OpenConfigModelMap) *listing {
+	_, skippedMap := commonci.GetValidatorAndVersionsFromString(skippedValidators)
+	var validatorIds []string
+	for validatorId, validator := range commonci.Validators {
+		if !validator.ReportOnly {
+			validatorIds = append(validatorIds, validatorId)
+		}
+	}
+	sort.Strings(validatorIds)
+
+	l := &listing{
+		Validators:   []listedValidator{},
+		CompatReport: compatReport,
+		ModelRoots:   modelMap.ModelRoots,
+		Models:       map[string][]listedModel{},
+	}
+	for _, validatorId := range validatorIds {
+		validator := commonci.Validators[validatorId]
+		_, hasScript := scriptTemplates[validatorId]
+		for _, version := range append([]string{""}, extraValidatorVersions(validatorId, extra, pinned)...) {
+			if skippedMap[validatorId][version] {
+				continue
+			}
+			_, inCompatReport := compatReport.Member(validatorId, version)
+			l.Validators = append(l.Validators, listedValidator{
+				ValidatorId:  validatorId,
+				Version:      version,
+				Name:         commonci.AppendVersionToName(validatorId, version),
+				IsPerModel:   validator.IsPerModel,
+				CompatReport: inCompatReport,
+				Script:       hasScript,
+			})
+		}
+	}
+
+	for modelDirName, modelInfos := range modelMap.ModelInfoMap {
+		models := []listedModel{}
+		for _, m := range modelInfos {
+			models = append(models, listedModel{
+				Name:        m.Name,
+				BuildFiles:  m.BuildFiles,
+				DocFiles:    m.DocFiles,
+				RunCi:       m.RunCi,
+				ExamplesDir: m.ExamplesDir,
+				SpecFile:    m.SpecFile,
+			})
+		}
+		l.Models[modelDirName] = models
+	}
+	l.DisabledModelDirs = []string{}
+	for modelDirName := range disabledModelPaths {
+		l.DisabledModelDirs = append(l.DisabledModelDirs, modelDirName)
+	}
+	sort.Strings(l.DisabledModelDirs)
+	return l
+}
+
+// writeListing writes the listing in the given format: "json", or "text",
+// which lists the validator names along with the number of models of each
+// model directory.
+func writeListing(w io.Writer, l *listing, format string) error {
+	switch format {
+	case "json":
+		bs, err := json.MarshalIndent(l, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal listing: %v", err)
+		}
+		_, err = fmt.Fprintf(w, "%s\n", bs)
+		return err
+	case "text":
+		var b strings.Builder
+		b.WriteString("validators:\n")
+		for _, v := range l.Validators {
+			if v.CompatReport {
+				fmt.Fprintf(&b, "  %s (compat-report)\n", v.Name)
+			} else {
+				fmt.Fprintf(&b, "  %s\n", v.Name)
+			}
+		}
+		modelDirNames := make([]string, 0, len(l.Models))
+		for modelDirName := range l.Models {
+			modelDirNames = append(modelDirNames, modelDirName)
+		}
+		sort.Strings(modelDirNames)
+		b.WriteString("model directories:\n")
+		for _, modelDirName := range modelDirNames {
+			var names []string
+			for _, m := range l.Models[modelDirName] {
+				names = append(names, m.Name)
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", modelDirName, strings.Join(names, ", "))
+		}
+		_, err := io.WriteString(w, b.String())
+		return err
+	default:
+		return fmt.Errorf("invalid -format %q, must be json or text", format)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// containerModelsCIDir is where the models-ci checkout is mounted within the
+// container, i.e. where GCB's validator steps find it within $GOPATH.
+const containerModelsCIDir = "/go/src/github.com/openconfig/models-ci"
+
+// dockerRunArgs returns the arguments of "docker run" running the validator's
+// test.sh within the models-ci checkout inside the image as the given user
+// (i.e. <uid>:<gid>), such that the files written into the models repo and the
+// results directory are owned by the user. As in GCB, the models repo is
+// mounted at commonci.RootDir, and the results directory at the validator's
+// results directory within commonci.ResultsDir.
+func dockerRunArgs(image, user, repoRoot, resultsDir, modelsCIDir, validatorId, version string) []string {
+	return []string{
+		"run", "--rm",
+		"--user", user,
+		// The user has no home directory within the image, which e.g.
+		// pip and virtualenv write into.
+		"-e", "HOME=/tmp",
+		"-e", "_MODEL_ROOT=" + modelRoot,
+		"-v", repoRoot + ":" + commonci.RootDir,
+		"-v", resultsDir + ":" + filepath.Join(commonci.ResultsDir, commonci.AppendVersionToName(validatorId, version)),
+		"-v", modelsCIDir + ":" + containerModelsCIDir + ":ro",
+		"-w", commonci.RootDir,
+		image,
+		"bash", filepath.Join(containerModelsCIDir, "validators", validatorId, "test.sh"),
+	}
+}
+
+// expandHome expands a leading ~ of path into the user's home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// localModelResults returns the names of the per-model result files (i.e.
+// "modelDir==model==status") within resultsDir in lexical order, excluding
+// the files recording each model's command.
+func localModelResults(resultsDir string) ([]string, error) {
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		return nil, err
+	}
+	var results []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.Count(e.Name(), "==") == 2 && !strings.HasSuffix(e.Name(), "==cmd") {
+			results = append(results, e.Name())
+		}
+	}
+	sort.Strings(results)
+	return results, nil
+}
+
+// runLocalDocker runs the validator given by -validator on the model directory
+// given by -modelDirName inside the docker image as it's run in GCB, i.e. by
+// its test.sh within -models-ci-dir, writing its results into -resultsDir. The
+// current directory must be the root of the models repo.
+func runLocalDocker(image string, modelMap commonci.OpenConfigModelMap) error {
+	vvs, _ := commonci.GetValidatorAndVersionsFromString(localValidatorId)
+	if len(vvs) != 1 {
+		return fmt.Errorf("invalid validator %q, must be a single <validatorId>[@<version>]", localValidatorId)
+	}
+	validatorId, version := vvs[0].ValidatorId, vvs[0].Version
+	repoRoot, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	resultsDir, err := expandHome(localResultsDir)
+	if err != nil {
+		return err
+	}
+	if resultsDir, err = filepath.Abs(resultsDir); err != nil {
+		return err
+	}
+	modelsCIDir, err := filepath.Abs(localModelsCIDir)
+	if err != nil {
+		return err
+	}
+	testScript := filepath.Join(modelsCIDir, "validators", validatorId, "test.sh")
+	if _, err := os.Stat(testScript); err != nil {
+		return fmt.Errorf("validator %s has no test.sh within -models-ci-dir: %v", validatorId, err)
+	}
+
+	// The script is run within the container, where the models repo and
+	// the results directory are mounted at their paths in GCB.
+	script, _, err := genGithubActionsScript(validatorId, version, localModelDirName, commonci.RootDir, filepath.Join(commonci.ResultsDir, commonci.AppendVersionToName(validatorId, version)), modelMap)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(resultsDir, 0755); err != nil {
+		return fmt.Errorf("error while creating directory %q: %v", resultsDir, err)
+	}
+	scriptPath := filepath.Join(resultsDir, commonci.ScriptFileName)
+	if err := os.WriteFile(scriptPath, []byte(script), 0744); err != nil {
+		return fmt.Errorf("error while writing script to path %q: %v", scriptPath, err)
+	}
+
+	cmd := exec.Command("docker", dockerRunArgs(image, fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), repoRoot, resultsDir, modelsCIDir, validatorId, version)...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		// test.sh fails to post the results since post_results isn't
+		// installed within the image, so only its results matter.
+		log.Printf("running %s in image %s exited with error: %v", commonci.AppendVersionToName(validatorId, version), image, err)
+	}
+
+	results, err := localModelResults(resultsDir)
+	if err != nil {
+		return err
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("running %s in image %s wrote no model results into %s", commonci.AppendVersionToName(validatorId, version), image, resultsDir)
+	}
+	for _, result := range results {
+		fmt.Println(result)
+	}
+	fmt.Printf("results written to %s\n", resultsDir)
+	return nil
+}
@@ -0,0 +1,2159 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cmdgen implements cmd_gen, which generates the validator scripts
+// and the plan of a CI run from the models repo's .spec.yml files, and posts
+// the initial PR statuses.
+package cmdgen
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"go/build"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/runner"
+	"github.com/openconfig/models-ci/util"
+	"github.com/openconfig/models-ci/version"
+)
+
+const (
+	// controlLabelPrefix is the prefix of PR labels that customize the
+	// CI run on the PR (see applyControlLabels).
+	controlLabelPrefix = "ci:"
+	// runPlanFileName is the name of the plan file of a validator run by
+	// "openconfig-ci run-validator", written into its results directory.
+	runPlanFileName = "plan.json"
+)
+
+var (
+	// Commandline flags: should be string if it may not exist
+	modelRoot          string // modelRoot is the comma-separated list of root directories of the models.
+	repoSlug           string // repoSlug is the "owner/repo" name of the models repo (e.g. openconfig/public).
+	prHeadRepoURL      string // prHeadRepoURL is the URL of the HEAD repo for PRs (e.g. https://github.com/openconfig/public).
+	commitSHA          string
+	branchName         string        // branchName is the name of the branch where the commit occurred.
+	tagName            string        // tagName is the release tag that triggered the run, if any.
+	defaultBranch      string        // defaultBranch is the default branch of the models repo, read from GitHub if empty.
+	prNumberStr        string        // prNumberStr is the PR number.
+	compatReports      string        // e.g. "goyang-ygot,pyangbind,pyang@1.7.8"
+	compatReportGating string        // e.g. "goyang-ygot"
+	extraVersions      string        // e.g. "yanglint@2.1.148,confd@8.0"
+	extraPyangVersions string        // e.g. "1.2.3,3.4.5"
+	skippedValidators  string        // e.g. "yanglint,pyang@head"
+	statusPrefix       string        // e.g. "models-ci/"
+	shadow             bool          // shadow indicates not to post anything to the PR.
+	maxReportedLevels  string        // e.g. "oc-pyang=3,pyangbind=3"
+	bannerFile         string        // bannerFile is a CI config banner file overriding the models repo's ci-banner.md.
+	requiredStatus     bool          // requiredStatus enables the aggregate "required" PR status.
+	requiredValidators string        // e.g. "pyang,oc-pyang,misc-checks"
+	requiredBreaking   bool          // requiredBreaking allows breaking changes under the "required" PR status.
+	changedFiles       string        // changedFiles is a file listing the files changed by the PR, enabling incremental CI.
+	fullRun            bool          // fullRun forces validating all model directories even if changedFiles is given.
+	reporterService    bool          // reporterService indicates that a post_results -watch reporter service posts the results.
+	confdSubstitute    string        // confdSubstitute is the validator run in place of ConfD Basic (e.g. yangson).
+	dryRun             bool          // dryRun prints the scripts, statuses and labels instead of making any GitHub calls or writing files.
+	validatorRunner    string        // validatorRunner is how per-model validators are run ("bash" or "go").
+	modelTimeout       time.Duration // modelTimeout is the maximum time spent validating each model.
+	maxParallel        int           // maxParallel is the maximum number of models validated at once by a parallel validator.
+	clean              bool          // clean removes the results and configuration of a previous run within the same workspace.
+	resultCache        bool          // resultCache reuses the results of cacheable validators from previous runs with the same inputs.
+	pyangMatrix        bool          // pyangMatrix reports all pyang versions by a single matrix status.
+	retries            string        // e.g. "goyang-ygot=2"
+	specPathPrefix     string        // e.g. "yang/"
+	reportOnlyRollout  string        // e.g. "ygnmi,pyang@3.0=2024-12-31"
+	validatorHooksFile string        // validatorHooksFile is a CI config file of bash snippets run by each validator's script.
+
+	// Derived flags (for ease of use)
+	owner     string
+	repo      string
+	prNumber  int
+	headOwner string
+	headRepo  string
+
+	// local run flags
+	local             bool   // local run toggle
+	localResultsDir   string // folder into which the command outputs its results
+	localValidatorId  string
+	localModelDirName string // a model directory (e.g. network-instance, aft)
+	dockerImage       string // dockerImage is the image in which to run the local validator.
+	localModelsCIDir  string // localModelsCIDir is the models-ci checkout whose test.sh runs the local validator within dockerImage.
+	portable          bool   // portable toggles printing a POSIX sh script for the local validator.
+	fixture           bool   // fixture toggles generating post_results testdata.
+	bazelOut          string // bazelOut is the Bazel package into which to generate test targets.
+	output            string // output is the CI system for which to generate output (gcb or github-actions).
+	shards            int    // shards is the number of GitHub Actions jobs over which to split each validator's models.
+	shard             string // shard is the N/M shard of each validator's models to validate in this GCB build.
+
+	// Miscellaneous flags
+	listBuildFiles bool   // Show all build files from the .spec.yml files as a single line.
+	list           bool   // list toggles printing the resolved validators and models.
+	listFormat     string // listFormat is the format of -list (json or text).
+
+	// disabledModelPaths are the paths whose models should not undergo CI,
+	// as read from the models repo's commonci.DisabledDirsFileName file.
+	// A multi-level directory uses ":" instead of "/" as the delimiter.
+	disabledModelPaths map[string]bool
+	// pinnedVersions are the versions of each validator to run in addition
+	// to the latest version, as read from the models repo's
+	// commonci.ValidatorVersionsFileName file.
+	pinnedVersions map[string][]string
+	// retriesMap are the number of times that each validator re-runs a
+	// failed model, as given by -retries, unless overridden by the
+	// model's .spec.yml.
+	retriesMap map[string]int
+	// validatorHooksMap are the hooks of each validator's script, as read
+	// from -validator-hooks-file.
+	validatorHooksMap map[string]validatorHook
+	// extraVersionsMap are the versions of each validator to run in
+	// addition to the latest version, as given by -extra-versions and
+	// -extra-pyang-versions.
+	extraVersionsMap map[string][]string
+)
+
+// flagSet contains the flags of cmd_gen, which are parsed by Main.
+var flagSet = flag.NewFlagSet("cmd_gen", flag.ExitOnError)
+
+func init() {
+	// GCB-required flags
+	flagSet.StringVar(&modelRoot, "modelRoot", "", "comma-separated list of root directories to OpenConfig models")
+	flagSet.StringVar(&repoSlug, "repo-slug", "", "repo where CI is run")
+	flagSet.StringVar(&prHeadRepoURL, "pr-head-repo-url", "", "PR head repo URL")
+	flagSet.StringVar(&commitSHA, "commit-sha", "", "commit SHA of the PR")
+	flagSet.StringVar(&prNumberStr, "pr-number", "", "PR number")
+	flagSet.StringVar(&branchName, "branch", "", "branch name of commit")
+	flagSet.StringVar(&defaultBranch, "default-branch", "", "(optional) default branch of the models repo (e.g. main), pushes to which upload the badges; if empty, it's read from the GitHub API")
+	flagSet.StringVar(&tagName, "tag", "", "(optional) release tag that triggered the run (e.g. $TAG_NAME in GCB) when there is no PR: all validators are run, and their badges and artifacts are uploaded for the release")
+	flagSet.StringVar(&compatReports, "compat-report", "", "comma-separated validators (e.g. goyang-ygot,pyang@1.7.8,pyang@head) in compatibility report instead of a standalone PR status")
+	flagSet.StringVar(&compatReportGating, "compat-report-gating", "", "comma-separated validators (e.g. goyang-ygot,pyang@head) within -compat-report whose failure fails the compatibility report's PR status; if empty, the compatibility report posts no PR status")
+	flagSet.StringVar(&skippedValidators, "skipped-validators", "", "comma-separated validators (e.g. goyang-ygot,pyang@1.7.8,pyang@head) not to be ran at all, not even in the compatibility report")
+	flagSet.StringVar(&extraVersions, "extra-versions", "", fmt.Sprintf("comma-separated <validatorId>@<version> (e.g. yanglint@2.1.148,confd@8.0) extra validator versions to run, each at least the validator's supported version; a validator's extra versions are ignored if the models repo's %s file pins its versions.", commonci.ValidatorVersionsFileName))
+	flagSet.StringVar(&extraPyangVersions, "extra-pyang-versions", "", "deprecated: comma-separated extra pyang versions to run, equivalent to -extra-versions=pyang@<version>,...")
+	flagSet.BoolVar(&shadow, "shadow", false, "run in shadow mode: compute and upload all results, but don't post any statuses, comments or labels to the PR")
+	flagSet.StringVar(&maxReportedLevels, "max-reported-levels", "", "comma-separated <validatorId>=<level> (e.g. oc-pyang=3) maximum level of pyang warnings shown in each validator's parsed results (errors are shown at every level); the full results are still posted to the gist")
+	flagSet.StringVar(&bannerFile, "banner-file", "", fmt.Sprintf("(optional) markdown file whose contents are prepended to every report, overriding the models repo's %s file", commonci.BannerFileName))
+	flagSet.BoolVar(&requiredStatus, "required-status", false, "post an aggregate \"required\" PR status that succeeds only when all -required-validators pass and there are no disallowed breaking changes, such that branch protection can require a single status context")
+	flagSet.StringVar(&requiredValidators, "required-validators", "", "comma-separated validators (e.g. pyang,oc-pyang) that must pass for the \"required\" PR status; defaults to misc-checks and all widely-used validators that aren't skipped or in the compatibility report")
+	flagSet.BoolVar(&requiredBreaking, "required-allow-breaking", false, "don't fail the \"required\" PR status on breaking changes (major openconfig-version changes or deleted files)")
+	flagSet.StringVar(&changedFiles, "changed-files", "", "(optional) file listing the files changed by the PR relative to the repo root (e.g. the output of \"git diff --name-only\"); if given, per-model validators other than misc-checks only validate the model directories affected by the changes, including via imports and includes")
+	flagSet.BoolVar(&fullRun, "full-run", false, "validate all model directories even if -changed-files is given")
+	flagSet.BoolVar(&reporterService, "reporter-service", false, "results are posted by a long-lived \"post_results -watch\" reporter service as soon as each validator is done, so validator steps only mark their results as done instead of posting them")
+	flagSet.StringVar(&confdSubstitute, "confd-substitute", "", "(optional) validator (currently only yangson) to run in place of ConfD Basic, which is then skipped; confd within -compat-report, -compat-report-gating and -required-validators refers to the substitute. Substitutes are otherwise skipped.")
+	flagSet.BoolVar(&dryRun, "dry-run", false, "print the generated validator scripts and configuration files, and the PR statuses and labels that would be posted, without making any GitHub calls (so GITHUB_ACCESS_TOKEN isn't needed) or writing any files. The PR's control labels aren't read.")
+	flagSet.StringVar(&validatorRunner, "runner", "bash", "how to run the per-model validators supported by \"openconfig-ci run-validator\" (currently pyang, oc-pyang, yanglint and confd): \"bash\" generates a bash script running each model's commands; \"go\" generates a plan.json run by openconfig-ci, which must be installed in $GOPATH/bin, along with a script.sh invoking it. Only applies to -output=gcb.")
+	flagSet.DurationVar(&modelTimeout, "model-timeout", 10*time.Minute, "(optional) maximum time spent validating each model, after which the model's result is \"timeout\"; 0 means no limit")
+	flagSet.IntVar(&maxParallel, "max-parallel", 8, "(optional) maximum number of models validated at once by validators that run in parallel, e.g. to keep memory-heavy validators like goyang-ygot from running out of memory; 0 means no limit")
+	flagSet.BoolVar(&clean, "clean", false, "remove the results and user configuration left by a previous run within the same workspace (e.g. a retried GCB build step) before generating the validator scripts, such that stale results directories don't activate skipped validators")
+	flagSet.BoolVar(&resultCache, "result-cache", false, fmt.Sprintf("reuse the results of cacheable validators from a previous run with the same model files, generated script and tool version, which are cached in the gs://%s bucket by post_results", commonci.BucketName))
+	flagSet.BoolVar(&pyangMatrix, "pyang-matrix", false, "report the results of all pyang versions (latest, head and extra versions) by a single \"pyang version matrix\" PR status linking to a page tabulating each model's result for each version, instead of by a standalone PR status for each non-latest version")
+	flagSet.StringVar(&retries, "retries", "", fmt.Sprintf("comma-separated <validatorId>=<count> (e.g. goyang-ygot=2) number of times each per-model validator re-runs a model after it fails (but not after it times out) before reporting its failure, e.g. for validators that fail transiently when fetching dependencies; a model's .spec.yml \"retries\" override it. At most %d.", maxModelRetries))
+	flagSet.StringVar(&specPathPrefix, "spec-path-prefix", commonci.SpecPathPrefix, "prefix of the build and examples paths within .spec.yml files that's replaced by the path of the model root containing them (e.g. with the default, yang/acl/openconfig-acl.yang within -modelRoot=release/models becomes release/models/acl/openconfig-acl.yang); if empty, the paths are relative to the model root")
+	flagSet.StringVar(&reportOnlyRollout, "report-only-rollout", "", fmt.Sprintf("comma-separated <validatorId>[@<version>][=<YYYY-MM-DD>] (e.g. ygnmi,pyang@3.0=2024-12-31) validators in report-only rollout until the given last day (or indefinitely), which run and post their results as usual but whose PR status never fails, and which aren't required by the \"required\" PR status; a validator defined by the models repo's %s file may instead set \"rollout: report-only\" and \"rollout-until\".", commonci.ValidatorsConfigFileName))
+	flagSet.StringVar(&validatorHooksFile, "validator-hooks-file", "", "(optional) YAML file mapping validatorIds to \"pre\" and \"post\" bash snippets (e.g. to warm caches, install pinned tool versions or collect extra artifacts) run by the validator's generated script before it validates any model and when it exits respectively; the post snippet doesn't change the script's exit status")
+	flagSet.StringVar(&statusPrefix, "status-context-prefix", "", "prefix applied to all PR status contexts (e.g. models-ci/) to distinguish between multiple CI deployments on the same repo")
+
+	// Local run flags
+	flagSet.BoolVar(&local, "local", false, "use with validator, modelDirName, resultsDir to get a particular model's command")
+	flagSet.StringVar(&localResultsDir, "resultsDir", "~/tmp/ci-results", "root directory to OpenConfig models")
+	flagSet.StringVar(&localValidatorId, "validator", "", "")
+	flagSet.StringVar(&localModelDirName, "modelDirName", "", "")
+	flagSet.StringVar(&dockerImage, "docker-image", "", "use with local to run the validator on the model directory inside the given image (e.g. the models-ci image used by GCB) using docker rather than printing its command, by running the validator's test.sh within models-ci-dir as the current user as in GCB, writing the results into resultsDir; must be run from the root of the models repo")
+	flagSet.StringVar(&localModelsCIDir, "models-ci-dir", filepath.Join(build.Default.GOPATH, "src/github.com/openconfig/models-ci"), "use with docker-image: the models-ci checkout whose validator test.sh scripts are run")
+	flagSet.BoolVar(&portable, "portable", false, "use with local to print a POSIX sh script (e.g. for macOS, without GNU bash or coreutils) validating the model directory with the validator, whose results are written into resultsDir unless overridden by $WORKDIR when it's run; must be run from the root of the models repo, and only the validators supported by -runner=go are supported. The script runs the models one at a time without -model-timeout, and takes the validator script's arguments (e.g. the pyang path).")
+	flagSet.BoolVar(&fixture, "fixture", false, "use with validator, resultsDir to run the validator script on all models and output canonical post_results testdata into resultsDir; arguments after the flags (e.g. the pyang path) are passed to the script")
+
+	flagSet.StringVar(&bazelOut, "bazelOut", "", "Bazel package directory at the root of the models repo into which to generate a BUILD file with an sh_test target for each model of each per-model validator (all by default, or those specified by -validator as a comma-separated list); arguments after the flags (e.g. the pyang path) are passed to every test")
+	flagSet.StringVar(&output, "output", "gcb", "CI system to generate output for: \"gcb\" writes the validator scripts under /workspace and posts the initial PR statuses; \"github-actions\" prints a JSON job matrix of validator x version x model directory shard, or with -validator (e.g. pyang@head) and -modelDirName (a comma-separated shard), writes the job's script into -resultsDir")
+	flagSet.StringVar(&shard, "shard", "", "N/M (e.g. 2/4) shard of the model directories validated by each per-model validator other than misc-checks, such that M parallel builds each given a different N together validate every model directory; the split is deterministic")
+	flagSet.IntVar(&shards, "shards", 1, "maximum number of shards of the model directories of each per-model validator in the GitHub Actions job matrix")
+
+	// Miscellaneous flags
+	flagSet.BoolVar(&listBuildFiles, "listBuildFiles", false, "Show all build files from the .spec.yml files as a single line.")
+	flagSet.BoolVar(&list, "list", false, "print the validators and versions that would be run (given -skipped-validators, -extra-versions, the pinned versions and -confd-substitute, but not the PR's control labels), their compatibility report membership, and the parsed models, without generating scripts or making any GitHub calls")
+	flagSet.StringVar(&listFormat, "format", "json", "format of -list: json or text")
+}
+
+// mustTemplate generates a template.Template for a particular named source template
+func mustTemplate(name, src string) *template.Template {
+	return template.Must(template.New(name).Parse(src))
+}
+
+type cmdParams struct {
+	ModelRoots   []string
+	RepoRoot     string
+	BuildFiles   []string
+	ModelDirName string
+	ModelName    string
+	// ExamplesDir is the directory of the model's example instance
+	// documents, if any.
+	ExamplesDir string
+	ResultsDir  string
+	Parallel    bool
+	// MaxParallel is the maximum number of models validated at once by a
+	// parallel validator, where 0 means no limit.
+	MaxParallel int
+	// ParseCacheDir is the directory of the goyang parse cache.
+	ParseCacheDir string
+	// Retries is the number of times that the model is re-run after it
+	// fails.
+	Retries int
+	// Env are the environment variables of the model's validator
+	// commands, as given by its .spec.yml.
+	Env map[string]string
+	// ExtraArgs are the extra arguments of the model's validator commands,
+	// as given by its .spec.yml for the validator.
+	ExtraArgs []string
+}
+
+// ModelTimeout returns the -model-timeout of the generated scripts in the
+// duration format of coreutils timeout, where 0 means no limit.
+func (p *cmdParams) ModelTimeout() string {
+	return fmt.Sprintf("%ds", int(modelTimeout/time.Second))
+}
+
+// modelFunctions is included in the header of the per-model validator
+// scripts. timed runs a validator command of a model within the model
+// timeout, and finish-model then moves the model's pass file to its result
+// status: timeout if any command timed out, and otherwise fail if the model's
+// status is non-zero. Both use the timed_out variable local to run-dir.
+// with-retries runs a model's command (e.g. run-dir) again while it fails,
+// up to the given number of times, which finish-model signals by clearing
+// the model's output and setting retry instead of failing the model.
+// with-args runs a model's command (e.g. run-dir) with the model_args array
+// set to the given number of arguments that follow, which run-dir passes to
+// the validator.
+// wait-for-slot waits until fewer than the given number of models are being
+// validated in parallel.
+const modelFunctions = `model_timeout={{ .ModelTimeout }}
+function timed() {
+  local status=0
+  timeout "$model_timeout" "$@" || status=$?
+  if [[ $status -eq 124 ]]; then
+    timed_out=1
+    >&2 echo "timed out after $model_timeout"
+  fi
+  return $status
+}
+function finish-model() {
+  declare prefix="$1"
+  if [[ $timed_out -eq 1 ]]; then
+    mv ${prefix}pass ${prefix}timeout
+  elif [[ $2 -ne 0 && ${retries_left:-0} -gt 0 ]]; then
+    >&2 echo "retrying ${prefix} after failure ($retries_left retries left)"
+    : > ${prefix}pass
+    retry=1
+    return
+  elif [[ $2 -ne 0 ]]; then
+    mv ${prefix}pass ${prefix}fail
+  fi
+  echo "${prefix}" >> "$workdir"/completed-models
+}
+function with-retries() {
+  local retries_left="$1" retry=1
+  shift
+  while [[ $retry -eq 1 ]]; do
+    retry=0
+    "$@"
+    retries_left=$((retries_left - 1))
+  done
+}
+function with-args() {
+  local model_args=( "${@:2:$1}" )
+  shift $(($1 + 1))
+  "$@"
+}
+function wait-for-slot() {
+  while [[ $(jobs -rp | wc -l) -ge $1 ]]; do
+    wait -n
+  done
+}
+`
+
+// runDirTemplate is the per-model template of validators whose header
+// defines run-dir, which validates the model's build files. At most
+// -max-parallel models are validated at once. The model's environment
+// variables prefix run-dir, and its extra arguments are passed by with-args.
+const runDirTemplate = `{{- if and .Parallel .MaxParallel }}wait-for-slot {{ .MaxParallel }}
+{{ end -}}
+{{ .EnvAssignments }}{{ if .Retries }}with-retries {{ .Retries }} {{ end }}{{ if .ExtraArgs }}with-args {{ len .ExtraArgs }} {{ range .QuotedExtraArgs }}{{ . }} {{ end }}{{ end }}run-dir "{{ .ModelDirName }}" "{{ .ModelName }}" {{- range $i, $buildFile := .BuildFiles }} {{ $buildFile }} {{- end }} {{- if .Parallel }} & {{- end }}
+`
+
+// scriptSpec contain the bash script templates for each validator.
+type scriptSpec struct {
+	// headerTemplate is generated once at the beginning of the script.
+	headerTemplate *template.Template
+	// perModelTemplate is generated once per model specified by .spec.yml.
+	perModelTemplate *template.Template
+	// runModel, if set, returns the commands of a model for
+	// "openconfig-ci run-validator", which replace the generated script
+	// with -runner=go.
+	runModel func(p *cmdParams) runner.Model
+}
+
+var (
+	// scriptTemplates contains templates for generating the validator
+	// scripts that checks the YANG models. They work in conjunction with a
+	// test.sh script for each validator, as well as the cloudbuild.yaml
+	// GCB script, which together create the running environment for the
+	// generated validator script.
+	scriptTemplates = map[string]*scriptSpec{
+		"pyang": {
+			headerTemplate: mustTemplate("pyang-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+``+"{{`"+util.PYANG_MSG_TEMPLATE_STRING+"`}}"+`
+cmd="$@"
+options=(
+{{- range .ModelRoots }}
+  -p {{ . }}
+{{- end }}
+  -p {{ .RepoRoot }}/third_party/ietf
+)
+script_options=(
+  --msg-template "$PYANG_MSG_TEMPLATE"
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  shift 2
+  echo pyang -W error "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  timed $cmd -W error "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("pyang", runDirTemplate),
+			runModel: func(p *cmdParams) runner.Model {
+				options := joinArgs([]string{"-W", "error"}, searchPathArgs(p))
+				return runner.Model{
+					Cmd:   strings.Join(joinArgs([]string{"pyang"}, options, p.ExtraArgs, p.BuildFiles), " "),
+					Steps: []runner.Step{{Argv: joinArgs([]string{"$@"}, options, []string{"--msg-template", util.PyangMsgTemplate}, p.ExtraArgs, p.BuildFiles)}},
+				}
+			},
+		},
+		"oc-pyang": {
+			headerTemplate: mustTemplate("oc-pyang-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+``+"{{`"+util.PYANG_MSG_TEMPLATE_STRING+"`}}"+`
+cmd="$@"
+options=(
+  --openconfig
+  --ignore-error=OC_RELATIVE_PATH
+{{- range .ModelRoots }}
+  -p {{ . }}
+{{- end }}
+  -p {{ .RepoRoot }}/third_party/ietf
+)
+script_options=(
+  --msg-template "$PYANG_MSG_TEMPLATE"
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  local cmd_display_options=( --plugindir '$OCPYANG_PLUGIN_DIR' "${options[@]}" )
+  local options=( --plugindir "$OCPYANG_PLUGIN_DIR" "${options[@]}" )
+  shift 2
+  echo pyang "${cmd_display_options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("oc-pyang", runDirTemplate),
+			runModel: func(p *cmdParams) runner.Model {
+				options := joinArgs([]string{"--plugindir", "$OCPYANG_PLUGIN_DIR", "--openconfig", "--ignore-error=OC_RELATIVE_PATH"}, searchPathArgs(p))
+				return runner.Model{
+					Cmd:   strings.Join(joinArgs([]string{"pyang"}, options, p.ExtraArgs, p.BuildFiles), " "),
+					Steps: []runner.Step{{Argv: joinArgs([]string{"$@"}, options, []string{"--msg-template", util.PyangMsgTemplate}, p.ExtraArgs, p.BuildFiles)}},
+				}
+			},
+		},
+		"pyangbind": {
+			headerTemplate: mustTemplate("pyangbind-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+``+"{{`"+util.PYANG_MSG_TEMPLATE_STRING+"`}}"+`
+cmd="$@"
+options=(
+  -f pybind
+{{- range .ModelRoots }}
+  -p {{ . }}
+{{- end }}
+  -p {{ .RepoRoot }}/third_party/ietf
+)
+script_options=(
+  --msg-template "$PYANG_MSG_TEMPLATE"
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  local output_file="$1"."$2".binding.py
+  local cmd_display_options=( --plugindir '$PYANGBIND_PLUGIN_DIR' -o "${output_file}" "${options[@]}" )
+  local options=( --plugindir "$PYANGBIND_PLUGIN_DIR" -o "${output_file}" "${options[@]}" )
+  shift 2
+  echo pyang "${cmd_display_options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  if [[ $status -eq "0" ]]; then
+    python "${output_file}" &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("pyangbind", runDirTemplate),
+		},
+		"goyang-ygot": {
+			headerTemplate: mustTemplate("goyang-ygot-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+`cmd="generator"
+options=(
+  -path={{ range .ModelRoots }}{{ . }},{{ end }}{{ .RepoRoot }}/third_party/ietf
+  -package_name=exampleoc -generate_fakeroot -fakeroot_name=device -compress_paths=true
+  -shorten_enum_leaf_names -trim_enum_openconfig_prefix -typedef_enum_with_defmod -enum_suffix_for_simple_union_enums
+  -exclude_modules=ietf-interfaces -generate_rename -generate_append -generate_getters
+  -generate_leaf_getters -generate_delete -annotations -generate_simple_unions
+  -list_builder_key_threshold=3
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/ygot/"$1"."$2"/
+  mkdir -p "$outdir"
+  local options=( -output_file="$outdir"/oc.go "${options[@]}" )
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  cd "$outdir"
+  if [[ $status -eq "0" ]]; then
+    go mod init &>> ${prefix}pass || status=1
+    go mod tidy &>> ${prefix}pass || status=1
+    go build &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("goyang-ygot", runDirTemplate),
+		},
+		// ygot-proto's script takes the directory of the ygot module, which
+		// contains the ywrapper and yext protos imported by the generated
+		// protos, as its first argument.
+		"ygot-proto": {
+			headerTemplate: mustTemplate("ygot-proto-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+`ygot_dir="$1"
+cmd="proto_generator"
+options=(
+  -path={{ range .ModelRoots }}{{ . }},{{ end }}{{ .RepoRoot }}/third_party/ietf
+  -package_name=openconfig -generate_fakeroot -fakeroot_name=device -compress_paths=true
+  -exclude_modules=ietf-interfaces
+  -ywrapper_path=proto/ywrapper -yext_path=proto/yext
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/ygot-proto/"$1"."$2"
+  mkdir -p "$outdir"
+  local options=( -output_dir="$outdir" "${options[@]}" )
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  if [[ $status -eq "0" ]]; then
+    find "$outdir" -name "*.proto" -print0 | xargs -0 protoc -I "$outdir" -I "$ygot_dir" --descriptor_set_out=/dev/null &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("ygot-proto", runDirTemplate),
+		},
+		// tree-diff's script takes the root of the models repo checked out
+		// at the PR's base, followed by the pyang command. The base's
+		// search paths and build files are the PR's with the repo root
+		// replaced, and a model without any build files at the base is
+		// diffed against an empty tree.
+		"tree-diff": {
+			headerTemplate: mustTemplate("tree-diff-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"/trees
+`+modelFunctions+`repo_root={{ .RepoRoot }}
+base_repo="$1"
+shift
+cmd="$@"
+options=(
+{{- range .ModelRoots }}
+  -p {{ . }}
+{{- end }}
+  -p {{ .RepoRoot }}/third_party/ietf
+)
+base_options=( "${options[@]/#$repo_root/$base_repo}" )
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  local tree="$workdir"/trees/"$1"=="$2"
+  shift 2
+  echo pyang -f tree "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local base_files=()
+  for file in "$@"; do
+    file="${file/#$repo_root/$base_repo}"
+    if [[ -f "$file" ]]; then
+      base_files+=( "$file" )
+    fi
+  done
+  local status=0
+  timed $cmd -f tree "${options[@]}" "${model_args[@]}" "$@" > "$tree".head 2> ${prefix}pass || status=1
+  if [[ $status -eq 0 ]]; then
+    : > "$tree".base
+    if [[ ${#base_files[@]} -ne "0" ]] && ! timeout "$model_timeout" $cmd -f tree "${base_options[@]}" "${model_args[@]}" "${base_files[@]}" > "$tree".base 2> /dev/null; then
+      echo "tree of the base could not be rendered" >> ${prefix}pass
+    fi
+    diff -u --label base --label head "$tree".base "$tree".head >> ${prefix}pass
+  fi
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("tree-diff", runDirTemplate),
+		},
+		// gnmi-paths generates the ypathgen path structs preferring both
+		// intended config and operational state, and extracts each one's
+		// schema path (minus the module name) from its doc comment into
+		// the model's path list.
+		"gnmi-paths": {
+			headerTemplate: mustTemplate("gnmi-paths-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"/paths
+`+modelFunctions+`cmd="generator"
+options=(
+  -path={{ range .ModelRoots }}{{ . }},{{ end }}{{ .RepoRoot }}/third_party/ietf
+  -package_name=exampleoc -generate_fakeroot -fakeroot_name=device -compress_paths=true
+  -exclude_modules=ietf-interfaces -generate_structs=false -generate_path_structs
+  -schema_struct_path=github.com/openconfig/ygot/exampleoc
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/gnmi-paths/"$1"."$2"
+  mkdir -p "$outdir"
+  local pathlist="$workdir"/paths/"$1"=="$2".txt
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  for state in false true; do
+    timed $cmd -path_structs_output_file="$outdir"/paths-$state.go -prefer_operational_state=$state "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &>> ${prefix}pass || status=1
+  done
+  if [[ $status -eq "0" ]]; then
+    sed -n 's|^// .* represents the /[^/]*\(/.*\) YANG schema element\.$|\1|p' "$outdir"/paths-*.go | sort -u > "$pathlist"
+    if [[ ! -s "$pathlist" ]]; then
+      echo "no gNMI paths were generated" >> ${prefix}pass
+      status=1
+    fi
+  fi
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("gnmi-paths", runDirTemplate),
+		},
+		"ygnmi": {
+			headerTemplate: mustTemplate("ygnmi-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+`cmd="ygnmi generator"
+options=(
+  --trim_module_prefix=openconfig
+  --exclude_modules=ietf-interfaces
+  --split_package_paths="/network-instances/network-instance/protocols/protocol/isis=netinstisis,/network-instances/network-instance/protocols/protocol/bgp=netinstbgp"
+  --paths={{ range .ModelRoots }}{{ . }}/...,{{ end }}{{ .RepoRoot }}/third_party/ietf/...
+  --annotations
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/ygnmi/"$1"."$2"
+  mkdir -p "$outdir"
+  local options=( --output_dir="${outdir}"/oc --base_package_path=ygnmi/"$1"."$2"/oc "${options[@]}" )
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  if [[ $status -eq "0" ]]; then
+    cd "$outdir/oc"
+    go mod init &> /dev/null || status=1
+    go mod tidy &> /dev/null || status=1
+    goimports -w *.go &> /dev/null || status=1
+    go build &> /dev/null || status=1
+  fi
+  if [[ $status -eq "1" ]]; then
+    # Only output if there is an error: otherwise the gist comment is too long.
+    go build &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+go install golang.org/x/tools/cmd/goimports@latest &>> ${prefix}pass || status=1
+`),
+			perModelTemplate: mustTemplate("ygnmi", runDirTemplate),
+		},
+		// yanglint's messages name the module rather than the file, so
+		// each build file is named by a util.YanglintFileMarker line before
+		// checking them all in one invocation.
+		"yanglint": {
+			headerTemplate: mustTemplate("yanglint-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+`cmd="yanglint"
+options=(
+{{- range .ModelRoots }}
+  -p {{ . }}
+{{- end }}
+  -p {{ .RepoRoot }}/third_party/ietf
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  for file in "$@"; do
+    echo "yanglint-file: $file" >> ${prefix}pass
+  done
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &>> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("yanglint", runDirTemplate),
+			runModel: func(p *cmdParams) runner.Model {
+				options := joinArgs([]string{"yanglint"}, searchPathArgs(p), p.ExtraArgs)
+				var markers []string
+				for _, file := range p.BuildFiles {
+					markers = append(markers, util.YanglintFileMarker+file)
+				}
+				return runner.Model{
+					Cmd:   strings.Join(joinArgs(options, p.BuildFiles), " "),
+					Steps: []runner.Step{{Marker: strings.Join(markers, "\n"), Argv: joinArgs(options, p.BuildFiles)}},
+				}
+			},
+		},
+		// yang-examples validates each example instance document of a
+		// model against its build files using yanglint.
+		"yang-examples": {
+			headerTemplate: mustTemplate("yang-examples-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+`cmd="yanglint"
+options=(
+{{- range .ModelRoots }}
+  -p {{ . }}
+{{- end }}
+  -p {{ .RepoRoot }}/third_party/ietf
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  declare examples_dir="$3"
+  shift 3
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" "$examples_dir/<example>" > ${prefix}cmd
+  local status=0
+  if [[ ! -d "$examples_dir" ]]; then
+    echo "examples directory $examples_dir does not exist" >> ${prefix}pass
+    status=1
+  fi
+  local example
+  for example in $(find "$examples_dir" -type f \( -name '*.json' -o -name '*.xml' \) 2> /dev/null | sort); do
+    echo "`+util.ExampleFileMarker+`$example" >> ${prefix}pass
+    local example_status=pass
+    timed $cmd "${options[@]}" "${model_args[@]}" "$@" "$example" &>> ${prefix}pass || example_status=fail
+    echo "`+util.ExampleStatusMarker+`$example_status" >> ${prefix}pass
+    if [[ $example_status == fail ]]; then
+      status=1
+    fi
+  done
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("yang-examples", `{{- if and .Parallel .MaxParallel }}wait-for-slot {{ .MaxParallel }}
+{{ end -}}
+{{ .EnvAssignments }}{{ if .Retries }}with-retries {{ .Retries }} {{ end }}{{ if .ExtraArgs }}with-args {{ len .ExtraArgs }} {{ range .QuotedExtraArgs }}{{ . }} {{ end }}{{ end }}run-dir "{{ .ModelDirName }}" "{{ .ModelName }}" "{{ .ExamplesDir }}" {{- range $i, $buildFile := .BuildFiles }} {{ $buildFile }} {{- end }} {{- if .Parallel }} & {{- end }}
+`),
+		},
+		"confd": {
+			headerTemplate: mustTemplate("confd-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+``),
+			perModelTemplate: mustTemplate("confd", `status=0
+timed_out=0
+{{- range $i, $buildFile := .BuildFiles }}
+{{ $.EnvAssignments }}timed $1 -c --yangpath $2 {{- range $.QuotedExtraArgs }} {{ . }}{{- end }} {{ $buildFile }} &>> {{ $.ResultsDir }}/{{ $.ModelDirName }}=={{ $.ModelName }}==pass || status=1
+{{- end }}
+finish-model "{{ .ResultsDir }}/{{ .ModelDirName }}=={{ .ModelName }}==" $status
+`),
+			runModel: func(p *cmdParams) runner.Model {
+				m := runner.Model{ContinueOnFailure: true}
+				for _, file := range p.BuildFiles {
+					m.Steps = append(m.Steps, runner.Step{Argv: joinArgs([]string{"$1", "-c", "--yangpath", "$2"}, p.ExtraArgs, []string{file})})
+				}
+				return m
+			},
+		},
+		// yangson validates the data model described by a YANG library,
+		// so the library of each model's build files and their imports is
+		// first written by yanglib, which also outputs the directories
+		// forming yangson's (non-recursive) module search path.
+		"yangson": {
+			headerTemplate: mustTemplate("yangson-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"/yanglib
+`+modelFunctions+`cmd="yangson"
+search_paths={{ range .ModelRoots }}{{ . }},{{ end }}{{ .RepoRoot }}/third_party/ietf
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  declare library="$workdir"/yanglib/"$1"=="$2".json
+  shift 2
+  local status=0
+  local module_path
+  module_path=$(/go/bin/yanglib -p "$search_paths" -o "$library" "$@" 2>> ${prefix}pass) || status=1
+  echo $cmd -p "$module_path" "${model_args[@]}" "$library" > ${prefix}cmd
+  if [[ $status -eq 0 ]]; then
+    timed $cmd -p "$module_path" "${model_args[@]}" "$library" &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+`),
+			perModelTemplate: mustTemplate("yangson", runDirTemplate),
+		},
+		"misc-checks": {
+			headerTemplate: mustTemplate("misc-checks-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`),
+			perModelTemplate: mustTemplate("misc-checks", `/go/bin/ocversion -timeout=10m -cache-dir={{ .ParseCacheDir }} -p {{ range .ModelRoots }}{{ . }},{{ end }}{{ .RepoRoot }}/third_party/ietf {{- range $i, $buildFile := .BuildFiles }} {{ $buildFile }} {{- end }} > {{ .ResultsDir }}/{{ .ModelDirName }}.{{ .ModelName }}.pr-file-parse-log
+case $? in
+  0) ;;
+  124) >&2 echo "parse of {{ .ModelDirName }}.{{ .ModelName }} timed out -- CI infra error." ;;
+  *) >&2 echo "parse of {{ .ModelDirName }}.{{ .ModelName }} reported non-zero status." ;;
+esac
+echo "{{ .ResultsDir }}/{{ .ModelDirName }}=={{ .ModelName }}==" >> {{ .ResultsDir }}/completed-models
+`),
+		},
+	}
+)
+
+// modelRootsEnv exports the comma-separated model roots to the script hooks
+// of the validators defined by the models repo.
+const modelRootsEnv = `export MODEL_ROOTS="{{ range $i, $root := .ModelRoots }}{{ if $i }},{{ end }}{{ $root }}{{ end }}"
+`
+
+// hookFile writes the script hook into a temporary file, which is run
+// instead of the models repo's copy, since the repo's copy may be changed by
+// the PR being validated.
+func hookFile(script []byte) string {
+	return `hook=$(mktemp)
+echo '` + base64.StdEncoding.EncodeToString(script) + `' | base64 -d > "$hook"
+chmod +x "$hook"
+`
+}
+
+// scriptHookSpec returns the script templates of a validator defined by the
+// models repo that runs the given script hook, read from the same trusted
+// source as the validator's definition. A per-model validator's header
+// defines run-dir, which runs the hook with the model directory, the model's
+// name and its build files.
+func scriptHookSpec(id string, script []byte, isPerModel bool) *scriptSpec {
+	if !isPerModel {
+		return &scriptSpec{
+			headerTemplate: mustTemplate(id+"-header", `#!/bin/bash
+`+modelRootsEnv+hookFile(script)+`"$hook"
+`),
+		}
+	}
+	return &scriptSpec{
+		headerTemplate: mustTemplate(id+"-header", `#!/bin/bash
+workdir={{ .ResultsDir }}
+mkdir -p "$workdir"
+`+modelFunctions+modelRootsEnv+hookFile(script)+`function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  echo "$hook" "$@" > ${prefix}cmd
+  local status=0
+  timed "$hook" "$@" &> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+`),
+		perModelTemplate: mustTemplate(id, runDirTemplate),
+	}
+}
+
+// registerValidators registers the validators defined by the config, along
+// with their script templates. The validators' script hooks are read with
+// read, like the config itself.
+func registerValidators(cfg *commonci.ValidatorsConfig, read repoConfigReader) error {
+	for _, v := range cfg.Validators {
+		if v.Script != "" {
+			script, err := read(v.Script)
+			switch {
+			case err != nil:
+				return fmt.Errorf("failed to read script %q of validator %q: %v", v.Script, v.Id, err)
+			case script == nil:
+				return fmt.Errorf("script %q of validator %q doesn't exist", v.Script, v.Id)
+			}
+			scriptTemplates[v.Id] = scriptHookSpec(v.Id, script, v.IsPerModel)
+			continue
+		}
+		spec := &scriptSpec{}
+		var err error
+		if spec.headerTemplate, err = template.New(v.Id + "-header").Parse(v.HeaderTemplate); err != nil {
+			return fmt.Errorf("invalid header-template for validator %q: %v", v.Id, err)
+		}
+		if v.PerModelTemplate != "" {
+			if spec.perModelTemplate, err = template.New(v.Id).Parse(v.PerModelTemplate); err != nil {
+				return fmt.Errorf("invalid per-model-template for validator %q: %v", v.Id, err)
+			}
+		}
+		scriptTemplates[v.Id] = spec
+	}
+	cfg.Register()
+	return nil
+}
+
+// genRepoLevelValidatorScript generates the whole validation script for the
+// given validator that isn't per-model, which consists of only its header.
+func genRepoLevelValidatorScript(validatorId, repoRoot, resultsDir string, modelMap commonci.OpenConfigModelMap) (string, error) {
+	cmdTemplate, ok := scriptTemplates[validatorId]
+	if !ok {
+		return "", fmt.Errorf("cmd_gen: unrecognized validatorId %q for creating a repo-level test script", validatorId)
+	}
+	var builder strings.Builder
+	if err := cmdTemplate.headerTemplate.Execute(&builder, &cmdParams{
+		ModelRoots: modelMap.ModelRoots,
+		RepoRoot:   repoRoot,
+		ResultsDir: resultsDir,
+	}); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// parseExtraVersions parses the -extra-versions flag, folding in the
+// deprecated -extra-pyang-versions flag, into a map of validatorId to the
+// extra versions to run.
+func parseExtraVersions(extraVersions, extraPyangVersions string) (map[string][]string, error) {
+	entries := []string{extraVersions}
+	for _, version := range strings.Split(extraPyangVersions, ",") {
+		if version != "" {
+			entries = append(entries, "pyang@"+version)
+		}
+	}
+	return commonci.ParseExtraVersions(strings.Join(entries, ","))
+}
+
+// extraValidatorVersions returns the versions of the validator to run in
+// addition to the latest version. These are the versions pinned by the models
+// repo if any, and otherwise the extra versions given to cmd_gen, followed by
+// pyang's head version for pyang.
+func extraValidatorVersions(validatorId string, extra, pinned map[string][]string) []string {
+	if versions, ok := pinned[validatorId]; ok {
+		return versions
+	}
+	versions := append([]string{}, extra[validatorId]...)
+	if validatorId != "pyang" {
+		return versions
+	}
+	for _, version := range versions {
+		if version == commonci.HeadVersion {
+			return versions
+		}
+	}
+	return append(versions, commonci.HeadVersion)
+}
+
+// runInParallel determines whether a particular validator and version should be run in parallel.
+func runInParallel(validatorId, version string) bool {
+	switch {
+	case validatorId == "pyang" && version == commonci.HeadVersion:
+		return false
+	default:
+		return true
+	}
+}
+
+// genValidatorCommandForModelDir generates the validator command for a single
+// modelDir, and returns it along with the number of models it validates.
+func genValidatorCommandForModelDir(validatorId, resultsDir, modelDirName string, modelMap commonci.OpenConfigModelMap, parallel bool) (string, int, error) {
+	var builder strings.Builder
+	cmdTemplate, ok := scriptTemplates[validatorId]
+	if !ok {
+		return "", 0, fmt.Errorf("cmd_gen: unrecognized validatorId %q for creating a per-model test script", validatorId)
+	}
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return "", 0, fmt.Errorf("cmd_gen: unrecognized validatorId %q", validatorId)
+	}
+	modelCount := 0
+	for _, modelInfo := range modelMap.ModelInfoMap[modelDirName] {
+		// First check whether to skip CI.
+		if !validatesModel(validator, modelInfo) {
+			continue
+		}
+		// Only models with examples are validated by yang-examples.
+		if validatorId == "yang-examples" && modelInfo.ExamplesDir == "" {
+			continue
+		}
+		if err := cmdTemplate.perModelTemplate.Execute(&builder, &cmdParams{
+			ModelRoots:    modelMap.ModelRoots,
+			RepoRoot:      commonci.RootDir,
+			BuildFiles:    modelInfo.BuildFiles,
+			ModelDirName:  modelDirName,
+			ModelName:     modelInfo.Name,
+			ExamplesDir:   modelInfo.ExamplesDir,
+			ResultsDir:    resultsDir,
+			Parallel:      parallel,
+			MaxParallel:   maxParallel,
+			ParseCacheDir: commonci.ParseCacheDir,
+			Retries:       modelRetries(validatorId, modelInfo),
+			Env:           modelInfo.Env,
+			ExtraArgs:     modelInfo.ExtraArgs[validatorId],
+		}); err != nil {
+			return "", 0, err
+		}
+		modelCount += 1
+	}
+	return builder.String(), modelCount, nil
+}
+
+// removeModelsWithMissingBuildFiles removes the build files of every model
+// whose .spec.yml references a build file that doesn't exist, such that no
+// validator commands are generated for it, and returns a description of each
+// missing build file prefixed by its location within the .spec.yml (e.g.
+// "acl/.spec.yml:4").
+func removeModelsWithMissingBuildFiles(modelMap commonci.OpenConfigModelMap) []string {
+	var missing []string
+	for _, modelDirName := range sortedModelDirNames(modelMap) {
+		modelInfos := modelMap.ModelInfoMap[modelDirName]
+		for i, modelInfo := range modelInfos {
+			var modelMissing bool
+			for j, buildFile := range modelInfo.BuildFiles {
+				if _, err := os.Stat(buildFile); err == nil {
+					continue
+				}
+				if relPath, err := commonci.RelModelPath(modelMap.ModelRoots, buildFile); err == nil {
+					buildFile = relPath
+				}
+				location := specLocation(modelMap.ModelRoots, modelInfo.SpecFile, 0)
+				if j < len(modelInfo.BuildFileLines) {
+					location = specLocation(modelMap.ModelRoots, modelInfo.SpecFile, modelInfo.BuildFileLines[j])
+				}
+				missing = append(missing, fmt.Sprintf("%s: build file %s does not exist", location, buildFile))
+				modelMissing = true
+			}
+			if modelMissing {
+				modelInfos[i].BuildFiles = nil
+			}
+		}
+	}
+	return missing
+}
+
+// labelPoster is an interface with just a function for posting GitHub labels to a PR.
+type labelPoster interface {
+	PostLabels(labels []commonci.PRLabel, owner, repo string, prNumber int) error
+}
+
+// githubClient is the subset of the GitHub API used by cmd_gen, which is
+// implemented by commonci.GithubRequestHandler, and by dryRunGitHub for dry
+// runs.
+type githubClient interface {
+	labelPoster
+	ListPRLabels(owner, repo string, prNumber int) ([]string, error)
+	UpdatePRStatus(update *commonci.GithubPRUpdate) error
+	DefaultBranch(owner, repo string) (string, error)
+	PRBase(owner, repo string, prNumber int) (string, string, error)
+	FileContents(owner, repo, ref, path string) ([]byte, error)
+	CreateCIOutputGist(description, content string) (string, string, error)
+}
+
+// dryRunGitHub prints the PR statuses and labels that would be posted to w
+// instead of posting them.
+type dryRunGitHub struct {
+	w io.Writer
+}
+
+// ListPRLabels returns no labels, since reading them would require a token.
+func (d dryRunGitHub) ListPRLabels(owner, repo string, prNumber int) ([]string, error) {
+	fmt.Fprintf(d.w, "dry run: not reading the control labels of %s/%s#%d\n", owner, repo, prNumber)
+	return nil, nil
+}
+
+// DefaultBranch returns "master", since reading the default branch would
+// require a token.
+func (d dryRunGitHub) DefaultBranch(owner, repo string) (string, error) {
+	fmt.Fprintf(d.w, "dry run: not reading the default branch of %s/%s, assuming master\n", owner, repo)
+	return "master", nil
+}
+
+// PRBase returns no base, since reading it would require a token, such that
+// later CI steps diff against the default branch.
+func (d dryRunGitHub) PRBase(owner, repo string, prNumber int) (string, string, error) {
+	fmt.Fprintf(d.w, "dry run: not reading the base of %s/%s#%d, assuming the default branch\n", owner, repo, prNumber)
+	return "", "", nil
+}
+
+// FileContents reads the file from the models repo's checkout instead, since
+// reading it from GitHub would require a token.
+func (d dryRunGitHub) FileContents(owner, repo, ref, path string) ([]byte, error) {
+	fmt.Fprintf(d.w, "dry run: reading %s from the checkout instead of %s/%s@%s\n", path, owner, repo, ref)
+	return readCheckoutConfig(path)
+}
+
+// CreateCIOutputGist prints the gist that would be created, returning a
+// placeholder URL.
+func (d dryRunGitHub) CreateCIOutputGist(description, content string) (string, string, error) {
+	fmt.Fprintf(d.w, "dry run: would create gist %q:\n%s", description, content)
+	return "https://gist.github.com/dry-run", "dry-run", nil
+}
+
+func (d dryRunGitHub) PostLabels(labels []commonci.PRLabel, owner, repo string, prNumber int) error {
+	for _, l := range labels {
+		fmt.Fprintf(d.w, "dry run: would apply label %q (colour %s) to %s/%s#%d\n", l.Name, l.Color, owner, repo, prNumber)
+	}
+	return nil
+}
+
+func (d dryRunGitHub) UpdatePRStatus(update *commonci.GithubPRUpdate) error {
+	fmt.Fprintf(d.w, "dry run: would post %s status %q to %s/%s@%s: %s\n", update.NewStatus, update.Context, update.Owner, update.Repo, update.Ref, update.Description)
+	return nil
+}
+
+// writeFile writes a file relaying the scripts or configuration of the run
+// to later CI steps, or prints it in a dry run.
+func writeFile(path string, data []byte, perm os.FileMode) error {
+	if dryRun {
+		fmt.Printf("dry run: would write %s:\n%s\n", path, data)
+		return nil
+	}
+	return commonci.WriteFile(path, data, perm)
+}
+
+// cleanWorkspace removes the results and configuration relayed to later CI
+// steps by a previous run, or prints them in a dry run.
+func cleanWorkspace() error {
+	for _, path := range []string{commonci.ResultsDir, commonci.UserConfigDir, commonci.RequiredStatusDir, commonci.InfraDegradedFile} {
+		if dryRun {
+			fmt.Printf("dry run: would remove %s\n", path)
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkdirAll creates a directory along with any parents, except in a dry run.
+func mkdirAll(path string, perm os.FileMode) error {
+	if dryRun {
+		return nil
+	}
+	return os.MkdirAll(path, perm)
+}
+
+// scriptModelDirs returns the sorted model directories validated by the given
+// validator within the shard, labelling the PR with the disabled model
+// directories that are skipped.
+func scriptModelDirs(g labelPoster, validatorId string, modelMap commonci.OpenConfigModelMap, shard modelShard) []string {
+	var inShard map[string]bool
+	if isShardedValidator(validatorId) {
+		inShard = shardModelDirs(shard, commonci.Validators[validatorId], modelMap)
+	}
+	allModelDirNames := make([]string, 0, len(modelMap.ModelInfoMap))
+	for modelDirName := range modelMap.ModelInfoMap {
+		allModelDirNames = append(allModelDirNames, modelDirName)
+	}
+	sort.Strings(allModelDirNames)
+
+	var modelDirNames []string
+	var skippedLabels []commonci.PRLabel
+	for _, modelDirName := range allModelDirNames {
+		if disabledModelPaths[modelDirName] {
+			log.Printf("skipping disabled model directory %s", modelDirName)
+			skippedLabels = append(skippedLabels, commonci.PRLabel{Name: "skipped: " + modelDirName, Color: commonci.LabelColors["orange"]})
+			continue
+		}
+		if inShard != nil && !inShard[modelDirName] {
+			continue
+		}
+		modelDirNames = append(modelDirNames, modelDirName)
+	}
+	if prNumber != 0 && len(skippedLabels) > 0 {
+		if err := g.PostLabels(skippedLabels, owner, repo, prNumber); err != nil {
+			log.Printf("couldn't label the PR with the skipped model directories: %v", err)
+		}
+	}
+	return modelDirNames
+}
+
+// genOpenConfigValidatorScript generates the whole validation script for the
+// given validator, and returns it along with the number of models it validates.
+// Tool version should be "" unless a non-latest version is used.
+// Scripts generated by this function assume the following:
+//  1. Each validator uses a different command which can be customized, but all
+//     will be run only on a single model as specified in the .spec.yml file.
+//  2. Thus, a validation command and result is provided for each model.
+//  3. A file indicating pass/fail is output for each model into the given result directory.
+//
+// Files names follow the "modelDir==model==status" format with no file extensions.
+// The local flag indicates to run this as a helper to generate the script,
+// rather than running it within GCB.
+// If the validator can be sharded, then only the model directories within the
+// given shard are validated.
+func genOpenConfigValidatorScript(g labelPoster, validatorId, version string, modelMap commonci.OpenConfigModelMap, shard modelShard) (string, int, error) {
+	resultsDir := commonci.ValidatorResultsDir(validatorId, version)
+	var builder strings.Builder
+
+	cmdTemplate, ok := scriptTemplates[validatorId]
+	if !ok {
+		return "", 0, fmt.Errorf("cmd_gen: unrecognized validatorId %q for creating a per-model test script", validatorId)
+	}
+	if err := cmdTemplate.headerTemplate.Execute(&builder, &cmdParams{
+		ModelRoots: modelMap.ModelRoots,
+		RepoRoot:   commonci.RootDir,
+		ResultsDir: resultsDir,
+	}); err != nil {
+		return "", 0, err
+	}
+
+	parallel := runInParallel(validatorId, version)
+	modelCount := 0
+	for _, modelDirName := range scriptModelDirs(g, validatorId, modelMap, shard) {
+		cmdStr, count, err := genValidatorCommandForModelDir(validatorId, resultsDir, modelDirName, modelMap, parallel)
+		if err != nil {
+			return "", 0, err
+		}
+		builder.WriteString(cmdStr)
+		modelCount += count
+	}
+
+	// In case there are parallel commands.
+	builder.WriteString("wait\n")
+	return builder.String(), modelCount, nil
+}
+
+// genOpenConfigValidatorPlan generates the run plan of the given validator,
+// which must have a runModel function, and returns it along with the number
+// of models it validates. The models are those validated by the script
+// generated by genOpenConfigValidatorScript.
+func genOpenConfigValidatorPlan(g labelPoster, validatorId, version string, modelMap commonci.OpenConfigModelMap, shard modelShard) (*runner.Plan, int, error) {
+	cmdTemplate, ok := scriptTemplates[validatorId]
+	if !ok || cmdTemplate.runModel == nil {
+		return nil, 0, fmt.Errorf("cmd_gen: validatorId %q cannot be run by openconfig-ci run-validator", validatorId)
+	}
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return nil, 0, fmt.Errorf("cmd_gen: unrecognized validatorId %q", validatorId)
+	}
+	plan := &runner.Plan{
+		ValidatorId:    validatorId,
+		ResultsDir:     commonci.ValidatorResultsDir(validatorId, version),
+		Parallel:       runInParallel(validatorId, version),
+		TimeoutSeconds: int(modelTimeout / time.Second),
+	}
+	for _, modelDirName := range scriptModelDirs(g, validatorId, modelMap, shard) {
+		for _, modelInfo := range modelMap.ModelInfoMap[modelDirName] {
+			if !validatesModel(validator, modelInfo) {
+				continue
+			}
+			plan.Models = append(plan.Models, planModel(cmdTemplate, validatorId, commonci.RootDir, plan.ResultsDir, modelDirName, modelInfo, modelMap))
+		}
+	}
+	return plan, len(plan.Models), nil
+}
+
+// planModel returns the commands of the model for "openconfig-ci
+// run-validator" given the root of the models repo and the results directory.
+func planModel(cmdTemplate *scriptSpec, validatorId, repoRoot, resultsDir, modelDirName string, modelInfo commonci.ModelInfo, modelMap commonci.OpenConfigModelMap) runner.Model {
+	params := &cmdParams{
+		ModelRoots:   modelMap.ModelRoots,
+		RepoRoot:     repoRoot,
+		BuildFiles:   modelInfo.BuildFiles,
+		ModelDirName: modelDirName,
+		ModelName:    modelInfo.Name,
+		ResultsDir:   resultsDir,
+		Env:          modelInfo.Env,
+		ExtraArgs:    modelInfo.ExtraArgs[validatorId],
+	}
+	m := cmdTemplate.runModel(params)
+	m.ModelDirName, m.ModelName = modelDirName, modelInfo.Name
+	m.Retries = modelRetries(validatorId, modelInfo)
+	m.Env = params.envList()
+	return m
+}
+
+// runnerScript returns the validator script that runs the given plan file
+// with "openconfig-ci run-validator", passing on the script's arguments.
+// openconfig-ci isn't exec'd, such that the script's post hook, if any, is
+// run after it.
+func runnerScript(planPath string) string {
+	var parallelism string
+	if maxParallel > 0 {
+		parallelism = fmt.Sprintf(" --parallelism %d", maxParallel)
+	}
+	return fmt.Sprintf(`#!/bin/bash
+$GOPATH/bin/openconfig-ci run-validator --plan %s%s -- "$@"
+`, planPath, parallelism)
+}
+
+// resultCacheHeader is inserted at the start of the scripts of cacheable
+// validators with -result-cache. If the results of a run with the same inputs
+// key (the first argument) and installed tool version are cached, then they
+// are restored into the results directory (the second argument) instead of
+// running the validator, with the cached out and fail files written to
+// stdout and stderr as the test.sh redirects them, and the script fails if
+// the cached run did. The key is computed as by commonci.ResultCacheKey.
+const resultCacheHeader = `result_cache_key=$( (echo -n %[1]s; cat %[2]s/%[3]s 2> /dev/null) | sha256sum | cut -d' ' -f1)
+result_cache_dir=$(mktemp -d)
+if gsutil -q cp gs://%[4]s/%[5]s/$result_cache_key.tar.gz $result_cache_dir/results.tar.gz 2> /dev/null && tar -xzf $result_cache_dir/results.tar.gz -C $result_cache_dir && rm $result_cache_dir/results.tar.gz; then
+  touch %[2]s/%[6]s
+  result_cache_status=0
+  if [[ -f $result_cache_dir/out ]]; then
+    cat $result_cache_dir/out
+  fi
+  if [[ -f $result_cache_dir/fail ]]; then
+    >&2 cat $result_cache_dir/fail
+    result_cache_status=1
+  fi
+  rm -f $result_cache_dir/out $result_cache_dir/fail
+  cp -rn $result_cache_dir/. %[2]s/
+  rm -rf $result_cache_dir
+  exit $result_cache_status
+fi
+rm -rf $result_cache_dir
+`
+
+// withResultCache returns the validator script with the resultCacheHeader
+// inserted after its shebang line.
+func withResultCache(script, inputsKey, resultsDir string) string {
+	shebang, body, _ := strings.Cut(script, "\n")
+	return shebang + "\n" + fmt.Sprintf(resultCacheHeader, inputsKey, resultsDir, commonci.LatestVersionFileName, commonci.BucketName, commonci.ResultCacheDir, commonci.ResultCacheHitFileName) + body
+}
+
+// resultCacheDirs returns the directories whose files are the inputs of the
+// cacheable validators besides their scripts: the model roots and the IETF
+// models.
+func resultCacheDirs(modelMap commonci.OpenConfigModelMap) []string {
+	dirs := append([]string{}, modelMap.ModelRoots...)
+	if ietfDir := filepath.Join(commonci.RootDir, "third_party", "ietf"); isDir(ietfDir) {
+		dirs = append(dirs, ietfDir)
+	}
+	return dirs
+}
+
+// isDir returns whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// searchPathArgs returns the pyang-style "-p" options of the model roots and
+// the IETF models.
+func searchPathArgs(p *cmdParams) []string {
+	var args []string
+	for _, modelRoot := range p.ModelRoots {
+		args = append(args, "-p", modelRoot)
+	}
+	return append(args, "-p", p.RepoRoot+"/third_party/ietf")
+}
+
+// joinArgs concatenates the given lists of arguments.
+func joinArgs(argLists ...[]string) []string {
+	var args []string
+	for _, argList := range argLists {
+		args = append(args, argList...)
+	}
+	return args
+}
+
+// inPyangMatrix returns whether the validator version is reported within the
+// pyang version matrix rather than by its own PR status, i.e. it's a
+// non-latest pyang version and -pyang-matrix is set.
+func inPyangMatrix(validatorId, version string) bool {
+	return pyangMatrix && validatorId == "pyang" && version != ""
+}
+
+// resolveReportOnlyRollouts returns the validators and validator versions in
+// report-only rollout at the given time: those given by the models repo's
+// validators config, if any, along with those given by -report-only-rollout,
+// which override them.
+func resolveReportOnlyRollouts(rolloutsStr string, cfg *commonci.ValidatorsConfig, now time.Time) (commonci.ReportOnlyRollouts, error) {
+	flagRollouts, err := commonci.ParseReportOnlyRollouts(rolloutsStr)
+	if err != nil {
+		return nil, err
+	}
+	rollouts := commonci.ReportOnlyRollouts{}
+	if cfg != nil {
+		rollouts = cfg.ReportOnlyRollouts()
+	}
+	for name, until := range flagRollouts {
+		rollouts[name] = until
+	}
+	active := rollouts.Active(now)
+	for name, until := range rollouts {
+		if _, ok := active[name]; !ok {
+			log.Printf("report-only rollout of %s ended on %s", name, until)
+		}
+	}
+	return active, nil
+}
+
+// postInitialStatus posts the initial status for all versions of a validator.
+func postInitialStatus(g githubClient, validatorId string, version string) error {
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return fmt.Errorf("validator %q not recognized", validatorId)
+	}
+	// Update the status to pending so that the user can see that we have received
+	// this request and are ready to run the CI.
+	update := &commonci.GithubPRUpdate{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         commitSHA,
+		Description: commonci.AppendVersionToName(validator.Name, version) + " Running",
+		NewStatus:   "pending",
+		Context:     validator.StatusName(version),
+	}
+
+	if err := g.UpdatePRStatus(update); err != nil {
+		log.Printf("error: couldn't update PR: %s", err)
+		log.Printf("GithubPRUpdate: %+v", update)
+		return err
+	}
+	return nil
+}
+
+// applyControlLabels folds the control labels on a PR into the
+// comma-separated skipped and compatibility report validators of the run, and
+// returns whether only condensed results should be reported, and whether all
+// model directories should be validated regardless of the PR's changes.
+// Labels without the controlLabelPrefix are ignored, as are (with a warning)
+// skip and compat labels of the comma-separated required validators, which
+// must post their own PR status. The control labels are:
+//   - ci:skip-<validatorId>[@<version>]: skip the validator.
+//   - ci:compat-<validatorId>[@<version>]: report the validator in the
+//     compatibility report instead of as a standalone PR status.
+//   - ci:full-matrix: don't skip any validators skipped by the CI
+//     configuration (validators skipped by labels are still skipped, as
+//     are those of substituteSkipped, i.e. ConfD Basic or the ConfD
+//     substitutes that aren't selected).
+//   - ci:full: ci:full-matrix, and validate all model directories even if
+//     unaffected by the PR's changes (e.g. for a docs-only change), as with
+//     -full-run.
+//   - ci:condensed-report: only post the condensed (i.e. failures only)
+//     results of each validator.
+func applyControlLabels(labels []string, skippedValidators, substituteSkipped, compatReports, requiredValidators string) (string, string, bool, bool) {
+	_, requiredMap := commonci.GetValidatorAndVersionsFromString(requiredValidators)
+	// isRequired returns whether the control's validator, if any, is
+	// required.
+	isRequired := func(control string) bool {
+		for _, prefix := range []string{"skip-", "compat-"} {
+			if vvs, _ := commonci.GetValidatorAndVersionsFromString(strings.TrimPrefix(control, prefix)); strings.HasPrefix(control, prefix) && len(vvs) == 1 {
+				return requiredMap[vvs[0].ValidatorId][vvs[0].Version]
+			}
+		}
+		return false
+	}
+	var labelSkipped []string
+	var fullMatrix, fullRun, condensedReport bool
+	for _, label := range labels {
+		if !strings.HasPrefix(label, controlLabelPrefix) {
+			continue
+		}
+		control := strings.TrimPrefix(label, controlLabelPrefix)
+		switch {
+		case isRequired(control):
+			log.Printf("warning: ignoring control label %q of a validator required by the \"required\" PR status", label)
+			continue
+		case control == "full-matrix":
+			fullMatrix = true
+		case control == "full":
+			fullMatrix, fullRun = true, true
+		case control == "condensed-report":
+			condensedReport = true
+		case strings.HasPrefix(control, "skip-") && isValidatorAndVersion(strings.TrimPrefix(control, "skip-")):
+			labelSkipped = append(labelSkipped, strings.TrimPrefix(control, "skip-"))
+		case strings.HasPrefix(control, "compat-") && isValidatorAndVersion(strings.TrimPrefix(control, "compat-")):
+			compatReports = strings.Trim(compatReports+","+strings.TrimPrefix(control, "compat-"), ",")
+		default:
+			log.Printf("ignoring unrecognized control label %q", label)
+			continue
+		}
+		log.Printf("applying control label %q", label)
+	}
+	if fullMatrix {
+		skippedValidators = ""
+	}
+	skippedValidators = strings.Trim(skippedValidators+","+substituteSkipped, ",")
+	if len(labelSkipped) > 0 {
+		skippedValidators = strings.Trim(skippedValidators+","+strings.Join(labelSkipped, ","), ",")
+	}
+	return skippedValidators, compatReports, condensedReport, fullRun
+}
+
+// confdSubstitutes are the validators that may be run in place of ConfD Basic,
+// whose availability is shrinking (see -confd-substitute).
+var confdSubstitutes = []string{"yangson"}
+
+// confdSubstituteSkipped returns the comma-separated validators to skip given
+// the selected ConfD substitute, if any: ConfD Basic if a substitute is
+// selected, and every substitute that isn't.
+func confdSubstituteSkipped(substitute string) (string, error) {
+	var skipped []string
+	found := substitute == ""
+	for _, s := range confdSubstitutes {
+		if s == substitute {
+			found = true
+			continue
+		}
+		skipped = append(skipped, s)
+	}
+	if !found {
+		return "", fmt.Errorf("%q is not a ConfD substitute, must be one of %v", substitute, confdSubstitutes)
+	}
+	if substitute != "" {
+		skipped = append([]string{"confd"}, skipped...)
+	}
+	return strings.Join(skipped, ","), nil
+}
+
+// replaceValidator replaces the validator from by the validator to within the
+// comma-separated list of validators, keeping any versions.
+func replaceValidator(validators, from, to string) string {
+	if validators == "" {
+		return ""
+	}
+	names := strings.Split(validators, ",")
+	for i, name := range names {
+		segments := strings.SplitN(strings.TrimSpace(name), "@", 2)
+		if segments[0] == from {
+			segments[0] = to
+			names[i] = strings.Join(segments, "@")
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// isValidatorAndVersion returns whether the <validatorId>@<version> name
+// refers to a known validator.
+func isValidatorAndVersion(name string) bool {
+	validator, ok := commonci.Validators[strings.SplitN(name, "@", 2)[0]]
+	return ok && !validator.ReportOnly
+}
+
+// newRequiredStatusPolicy creates the policy of the aggregate required
+// status from the comma-separated list of required validators. If the list is
+// empty, then misc-checks and all widely-used validators that aren't skipped
+// or in the compatibility report are required. Required validators must post
+// their own PR status, and so can't be skipped or in the compatibility report.
+func newRequiredStatusPolicy(requiredValidators string, allowBreaking bool, skippedValidators string, compatReport *commonci.CompatReport) (*commonci.RequiredStatusPolicy, error) {
+	_, skippedMap := commonci.GetValidatorAndVersionsFromString(skippedValidators)
+	policy := &commonci.RequiredStatusPolicy{
+		FormatVersion: commonci.RequiredStatusPolicyFormatVersion,
+		Validators:    []commonci.ValidatorAndVersion{},
+		AllowBreaking: allowBreaking,
+	}
+
+	if requiredValidators == "" {
+		var validatorIds []string
+		for validatorId, validator := range commonci.Validators {
+			if validator.IsWidelyUsedTool || validatorId == "misc-checks" {
+				validatorIds = append(validatorIds, validatorId)
+			}
+		}
+		sort.Strings(validatorIds)
+		for _, validatorId := range validatorIds {
+			_, inCompatReport := compatReport.Member(validatorId, "")
+			_, inRollout := commonci.ReportOnlyRollout.Lookup(validatorId, "")
+			if !skippedMap[validatorId][""] && !inCompatReport && !inRollout {
+				policy.Validators = append(policy.Validators, commonci.ValidatorAndVersion{ValidatorId: validatorId})
+			}
+		}
+		return policy, nil
+	}
+
+	vvs, _ := commonci.GetValidatorAndVersionsFromString(requiredValidators)
+	for _, vv := range vvs {
+		name := commonci.AppendVersionToName(vv.ValidatorId, vv.Version)
+		validator, ok := commonci.Validators[vv.ValidatorId]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("unrecognized validator %q", name)
+		case validator.ReportOnly:
+			return nil, fmt.Errorf("validator %q doesn't post its own PR status", name)
+		case skippedMap[vv.ValidatorId][vv.Version]:
+			return nil, fmt.Errorf("validator %q is skipped", name)
+		case inPyangMatrix(vv.ValidatorId, vv.Version):
+			return nil, fmt.Errorf("validator %q is in the pyang version matrix", name)
+		}
+		if _, ok := commonci.ReportOnlyRollout.Lookup(vv.ValidatorId, vv.Version); ok {
+			return nil, fmt.Errorf("validator %q is in report-only rollout", name)
+		}
+		if _, ok := compatReport.Member(vv.ValidatorId, vv.Version); ok {
+			return nil, fmt.Errorf("validator %q is in the compatibility report", name)
+		}
+		policy.Validators = append(policy.Validators, vv)
+	}
+	return policy, nil
+}
+
+// readBanner reads the banner to display in every report from bannerFile if
+// specified, or otherwise from repoBannerFile if it exists. An empty banner is
+// returned if there is none.
+func readBanner(bannerFile, repoBannerFile string) (string, error) {
+	path := bannerFile
+	if path == "" {
+		path = repoBannerFile
+	}
+	bs, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && bannerFile == "":
+		return "", nil
+	case err != nil:
+		return "", fmt.Errorf("error while reading banner file %q: %v", path, err)
+	}
+	return strings.TrimSpace(string(bs)), nil
+}
+
+// Main runs cmd_gen with the given command-line arguments, excluding the
+// program name.
+func Main(args []string) {
+	start := time.Now().UTC().Truncate(time.Second)
+	// Parse derived flags.
+	flagSet.Parse(args)
+	log.Printf("cmd_gen version %s", version.String())
+
+	if modelRoot == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "Must supply modelRoot path")
+	}
+	if validatorRunner != "bash" && validatorRunner != "go" {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -runner %q, must be \"bash\" or \"go\"", validatorRunner)
+	}
+	// Populate information necessary for validation script generation.
+	commonci.SpecPathPrefix = specPathPrefix
+	modelMap, err := commonci.ParseOCModels(modelRoot)
+	if err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "CI flow failed due to error encountered while parsing spec files, commonci.ParseOCModels: %v", err)
+	}
+
+	if listBuildFiles {
+		fmt.Println(modelMap.SingleLineBuildFiles())
+		return
+	}
+
+	prNumber = 0
+	if prNumberStr != "" {
+		var err error
+		if prNumber, err = strconv.Atoi(prNumberStr); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "error encountered while parsing PR number: %s", err)
+		}
+	}
+
+	// A PR's CI configuration is read from its base branch, since a PR
+	// could otherwise change the commands run with CI's credentials.
+	var h githubClient = dryRunGitHub{w: os.Stdout}
+	var baseRef, mergeBase string
+	readRepoConfig := repoConfigReader(readCheckoutConfig)
+	if prNumber != 0 && !dryRun {
+		owner, repo, _ = strings.Cut(repoSlug, "/")
+		if h, err = commonci.NewGitHubRequestHandler(); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+		if baseRef, mergeBase, err = h.PRBase(owner, repo, prNumber); err != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "error while reading the base of the PR: %v", err)
+		}
+		readRepoConfig = baseBranchConfigReader(h, owner, repo, baseRef)
+	}
+
+	// Register the validators defined by the models repo.
+	validatorsConfig, err := readValidatorsConfig(readRepoConfig)
+	if err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+	}
+	if validatorsConfig != nil {
+		if err := registerValidators(validatorsConfig, readRepoConfig); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+	}
+
+	// Skip the model directories disabled by the models repo.
+	if disabledModelPaths, err = readDisabledDirs(readRepoConfig); err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+	}
+	for modelDirName := range disabledModelPaths {
+		if _, ok := modelMap.ModelInfoMap[modelDirName]; !ok {
+			log.Printf("disabled model directory %s doesn't exist", modelDirName)
+		}
+	}
+
+	// Run the validator versions pinned by the models repo.
+	if pinnedVersions, err = readValidatorVersions(readRepoConfig); err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+	}
+	if extraVersionsMap, err = parseExtraVersions(extraVersions, extraPyangVersions); err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -extra-versions or -extra-pyang-versions flag: %v", err)
+	}
+	if retriesMap, err = parseRetries(retries); err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -retries flag: %v", err)
+	}
+	if validatorHooksMap, err = readValidatorHooks(validatorHooksFile); err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -validator-hooks-file flag: %v", err)
+	}
+	if commonci.ReportOnlyRollout, err = resolveReportOnlyRollouts(reportOnlyRollout, validatorsConfig, time.Now()); err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -report-only-rollout flag: %v", err)
+	}
+	for validatorId, versions := range extraVersionsMap {
+		if _, ok := pinnedVersions[validatorId]; ok {
+			log.Printf("ignoring extra %s versions %v since they're pinned by %s", validatorId, versions, commonci.ValidatorVersionsFileName)
+		}
+	}
+
+	// Handle test fixture generation case.
+	if fixture {
+		if localValidatorId == "" {
+			commonci.Fatalf(commonci.ExitConfigError, "no validator specified")
+		}
+		if err := genFixture(localValidatorId, flagSet.Args(), localResultsDir, modelRoot); err != nil {
+			commonci.Fatal(err)
+		}
+		return
+	}
+
+	// Handle Bazel test target generation case.
+	if bazelOut != "" {
+		validatorIds, err := bazelValidatorIds(localValidatorId)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+		bazelModelMap, err := relBazelModelMap(modelRoot, bazelOut)
+		if err != nil {
+			commonci.Fatal(err)
+		}
+		files, err := genBazelTargets(validatorIds, flagSet.Args(), bazelModelMap)
+		if err != nil {
+			commonci.Fatal(err)
+		}
+		if err := writeBazelTargets(bazelOut, files); err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+		}
+		return
+	}
+
+	// Run the selected ConfD substitute, if any, in place of ConfD Basic.
+	substituteSkipped, err := confdSubstituteSkipped(confdSubstitute)
+	if err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -confd-substitute flag: %v", err)
+	}
+	// The substitute's skips are folded in along with the control labels
+	// below, since ci:full-matrix doesn't undo them.
+	if confdSubstitute != "" {
+		compatReports = replaceValidator(compatReports, "confd", confdSubstitute)
+		compatReportGating = replaceValidator(compatReportGating, "confd", confdSubstitute)
+		requiredValidators = replaceValidator(requiredValidators, "confd", confdSubstitute)
+	}
+
+	// Handle listing case.
+	if list {
+		skippedValidators, _, _, _ = applyControlLabels(nil, skippedValidators, substituteSkipped, compatReports, "")
+		compatReport, err := commonci.NewCompatReport(
+			commonci.ValidatorAndVersionsDiff(compatReports, skippedValidators),
+			commonci.ValidatorAndVersionsDiff(compatReportGating, skippedValidators))
+		if err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "invalid -compat-report-gating flag: %v", err)
+		}
+		if err := writeListing(os.Stdout, genListing(skippedValidators, extraVersionsMap, pinnedVersions, compatReport, modelMap), listFormat); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+		return
+	}
+
+	// Check the .spec.yml files before any build files are removed below.
+	specProblems := checkSpecs(modelMap)
+
+	// Check the model directories against the models repo's index, if any,
+	// which is reported via misc-checks.
+	modelIndex, err := commonci.ReadModelIndex(filepath.Join(commonci.RootDir, commonci.ModelIndexFileName))
+	if err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+	}
+	modelIndexProblems := modelIndex.Check(modelMap)
+
+	// Validators would otherwise fail with confusing per-tool errors, so
+	// report missing build files once via misc-checks instead.
+	missingBuildFiles := removeModelsWithMissingBuildFiles(modelMap)
+	for _, missing := range missingBuildFiles {
+		log.Printf("skipping model: %s", missing)
+	}
+
+	// Handle GitHub Actions case.
+	switch output {
+	case "gcb":
+	case "github-actions":
+		if err := githubActions(modelMap); err != nil {
+			commonci.Fatal(err)
+		}
+		return
+	default:
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -output %q, must be gcb or github-actions", output)
+	}
+
+	// Handle local call case.
+	if local {
+		if localModelDirName == "" {
+			commonci.Fatalf(commonci.ExitConfigError, "no modelDirName specified")
+		}
+		if localValidatorId == "" {
+			commonci.Fatalf(commonci.ExitConfigError, "no validator specified")
+		}
+		if dockerImage != "" {
+			if err := runLocalDocker(dockerImage, modelMap); err != nil {
+				commonci.Fatal(err)
+			}
+			return
+		}
+		if portable {
+			if err := printPortableScript(modelMap); err != nil {
+				commonci.Fatal(err)
+			}
+			return
+		}
+		cmdStr, _, err := genValidatorCommandForModelDir(localValidatorId, localResultsDir, localModelDirName, modelMap, true)
+		if err != nil {
+			commonci.Fatal(err)
+		}
+		fmt.Print(cmdStr)
+		return
+	} else if dockerImage != "" {
+		commonci.Fatalf(commonci.ExitConfigError, "docker-image can only be specified for local runs")
+	} else if portable {
+		commonci.Fatalf(commonci.ExitConfigError, "portable can only be specified for local runs")
+	} else if localModelDirName != "" || localValidatorId != "" {
+		commonci.Fatalf(commonci.ExitConfigError, "modelDirName and validator can only be specified for local cmd generation")
+	}
+
+	repoSplit := strings.Split(repoSlug, "/")
+	owner = repoSplit[0]
+	repo = repoSplit[1]
+	if commitSHA == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "no commit SHA")
+	}
+
+	var forkSlug string
+	headOwner = owner
+	headRepo = repo
+	if prHeadRepoURL != "" {
+		// Expected format: e.g. https://github.com/openconfig/public
+		URLSplit := strings.Split(prHeadRepoURL, "/")
+		headOwner = URLSplit[len(URLSplit)-2]
+		headRepo = URLSplit[len(URLSplit)-1]
+		if headOwner != owner || headRepo != repo {
+			forkSlug = headOwner + "/" + headRepo
+			log.Printf("fork detected for remote repo %q", forkSlug)
+			commonci.StatusCommentPR = prNumber
+		}
+	}
+
+	if _, ok := h.(dryRunGitHub); ok && !dryRun {
+		if h, err = commonci.NewGitHubRequestHandler(); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+	}
+
+	if defaultBranch == "" {
+		if defaultBranch, err = h.DefaultBranch(owner, repo); err != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "error while reading the default branch of %s/%s: %v", owner, repo, err)
+		}
+	}
+	commonci.DefaultBranch = defaultBranch
+
+	// If it's a push on the default branch or a release tag, just upload
+	// badges for normal validators as the only action.
+	push := prNumber == 0
+	if push && branchName != defaultBranch && tagName == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "cmd_gen: pr-number not supplied as a flag to the build. Try re-running (by commenting \"/gcbrun\" on the GitHub PR) to see whether the $_PR_NUMBER substitution variable for Google Cloud Build gets passed into the build. If this branch is not associated with a PR, then it is inferred that this is a push action on a branch other than the default branch (see -default-branch), and thus there is no CI action that is expected, and in this case please re-examine your push triggers.")
+	}
+	pushToMaster := push && tagName == ""
+
+	// Skip testing non-widely used validators, as we don't need to post
+	// badges for those tools. A release runs all validators.
+	if pushToMaster {
+		for validatorId, validator := range commonci.Validators {
+			if !validator.IsWidelyUsedTool {
+				// Here we assume simply that non widely-used checks don't have a version specified.
+				skippedValidators += "," + validatorId
+			}
+		}
+	}
+
+	// Fold the PR's control labels, and the ConfD substitute's skips, into
+	// the configuration of this run.
+	var labels []string
+	if !push {
+		if labels, err = h.ListPRLabels(owner, repo, prNumber); err != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "error while listing PR labels: %v", err)
+		}
+	}
+	// Labels can't skip the explicitly required validators, which would
+	// fail the run.
+	var labelRequired string
+	if requiredStatus {
+		labelRequired = requiredValidators
+	}
+	var condensedReport, labelFullRun bool
+	skippedValidators, compatReports, condensedReport, labelFullRun = applyControlLabels(labels, skippedValidators, substituteSkipped, compatReports, labelRequired)
+	fullRun = fullRun || labelFullRun
+
+	validatorShard, err := parseModelShard(shard)
+	if err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -shard flag: %v", err)
+	}
+	if validatorShard.Count > 1 {
+		log.Printf("validating shard %s of the model directories", validatorShard)
+	}
+
+	// Only validate the model directories affected by the PR's changes.
+	// misc-checks always checks the whole repo.
+	validatedModelMap := modelMap
+	if changedFiles != "" && !fullRun && !push {
+		files, err := readChangedFiles(changedFiles)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+		affected, err := affectedModelDirs(modelMap, files, commonci.RootDir)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "error while computing the model directories affected by the PR: %v", err)
+		}
+		if affected == nil {
+			log.Printf("incremental CI: changes affect all model directories")
+		} else {
+			var unaffected []string
+			validatedModelMap, unaffected = filterModelMap(modelMap, affected)
+			log.Printf("incremental CI: skipping %d model directories unaffected by the PR: %s", len(unaffected), strings.Join(unaffected, ", "))
+		}
+	}
+
+	if clean {
+		if err := cleanWorkspace(); err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "error while cleaning the workspace: %v", err)
+		}
+	}
+	if err := mkdirAll(commonci.ResultsDir, 0644); err != nil {
+		commonci.Fatalf(commonci.ExitInfraError, "error while creating directory %q: %v", commonci.ResultsDir, err)
+	}
+	if err := mkdirAll(commonci.UserConfigDir, 0644); err != nil {
+		commonci.Fatalf(commonci.ExitInfraError, "error while creating directory %q: %v", commonci.UserConfigDir, err)
+	}
+
+	// Notify later CI steps of the validators defined by the models repo.
+	if validatorsConfig != nil && !dryRun {
+		if err := commonci.WriteValidatorsConfig(commonci.ValidatorsConfigFile, validatorsConfig); err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+		}
+	}
+
+	// The plan of the run is relayed to later CI steps once complete.
+	plan := commonci.NewPlan(defaultBranch)
+	plan.StartTime = start
+
+	// If this is a fork, let later CI steps know the fork repo slug, and
+	// fall back to a PR comment if statuses can't be posted to the fork PR.
+	plan.ForkSlug = forkSlug
+	plan.StatusCommentPR = commonci.StatusCommentPR
+
+	// Notify later CI steps of the PR's actual base to diff against, which
+	// isn't the default branch for a PR to a release branch, and whose
+	// head may have moved on since a stale PR branch was created.
+	if !push && baseRef == "" {
+		if baseRef, mergeBase, err = h.PRBase(owner, repo, prNumber); err != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "error while reading the base of the PR: %v", err)
+		}
+	}
+	plan.BaseRef, plan.MergeBase = baseRef, mergeBase
+
+	// Notify later CI steps of the status context prefix to use.
+	commonci.StatusContextPrefix = statusPrefix
+	plan.StatusContextPrefix = statusPrefix
+	// The statuses of the validators whose results depend on the shard
+	// name it, such that the shards' statuses don't overwrite each other.
+	if validatorShard.Count > 1 {
+		commonci.Shard = validatorShard.String()
+		plan.Shard = commonci.Shard
+	}
+
+	// Notify later CI steps of the release being validated.
+	if tagName != "" && prNumber == 0 {
+		commonci.ReleaseTag = tagName
+		plan.ReleaseTag = tagName
+	}
+
+	// Notify later CI steps that nothing should be posted to the PR.
+	commonci.ShadowMode = shadow
+	plan.ShadowMode = shadow
+	if shadow {
+		log.Printf("running in shadow mode: nothing will be posted to the PR")
+	}
+
+	// Notify later CI steps that the reporter service posts the results.
+	plan.ReporterService = reporterService
+
+	// Notify later CI steps of the maximum message level to report for each validator.
+	if maxReportedLevels != "" {
+		if plan.MaxReportedLevels, err = commonci.ParseMaxReportedLevels(maxReportedLevels); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "invalid -max-reported-levels flag: %v", err)
+		}
+	}
+
+	// Notify later CI steps of the banner to display in every report.
+	if plan.Banner, err = readBanner(bannerFile, filepath.Join(commonci.RootDir, commonci.BannerFileName)); err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+	}
+
+	// Notify later CI steps that only condensed results should be posted.
+	plan.CondensedReport = condensedReport
+
+	// Notify later CI steps that all pyang versions are reported by the matrix.
+	plan.PyangMatrix = pyangMatrix
+
+	// Notify later CI steps of the validators whose PR status never fails.
+	plan.ReportOnlyRollouts = commonci.ReportOnlyRollout
+
+	compatReport, err := commonci.NewCompatReport(
+		commonci.ValidatorAndVersionsDiff(compatReports, skippedValidators),
+		commonci.ValidatorAndVersionsDiff(compatReportGating, skippedValidators))
+	if err != nil {
+		commonci.Fatalf(commonci.ExitConfigError, "invalid -compat-report-gating flag: %v", err)
+	}
+	// Notify later CI steps of the validators that should be reported as a compatibility report.
+	plan.CompatReport = compatReport
+
+	_, skippedValidatorsMap := commonci.GetValidatorAndVersionsFromString(skippedValidators)
+
+	// Generate validation scripts, files, and post initial status on GitHub.
+	// The compatibility report only has a PR status if it gates merge.
+	if !push && compatReport.GatesMerge() {
+		if errs := postInitialStatus(h, "compat-report", ""); errs != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "%v", errs)
+		}
+	}
+
+	// Report problems with the .spec.yml files before any validators run.
+	if !push && !skippedValidatorsMap[specCheckId][""] {
+		for _, problem := range specProblems {
+			log.Printf(".spec.yml problem: %s", problem)
+		}
+		if err := postSpecCheckStatus(h, specProblems); err != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "%v", err)
+		}
+	}
+
+	// Notify later CI steps of the policy of the aggregate required status.
+	if requiredStatus && !push {
+		policy, err := newRequiredStatusPolicy(requiredValidators, requiredBreaking, skippedValidators, compatReport)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "invalid -required-validators flag: %v", err)
+		}
+		plan.RequiredStatusPolicy = policy
+		if errs := postInitialStatus(h, "required", ""); errs != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "%v", errs)
+		}
+	}
+	// The pyang version matrix only has a PR status if pyang runs at more
+	// than one version.
+	if pyangMatrix && !push {
+		var pyangVersions int
+		for _, version := range append([]string{""}, extraValidatorVersions("pyang", extraVersionsMap, pinnedVersions)...) {
+			if !skippedValidatorsMap["pyang"][version] {
+				pyangVersions++
+			}
+		}
+		if pyangVersions > 1 {
+			if errs := postInitialStatus(h, "pyang-matrix", ""); errs != nil {
+				commonci.Fatalf(commonci.ExitGitHubError, "%v", errs)
+			}
+		}
+	}
+	// filesHash is the hash of the files validated by the cacheable
+	// validators, computed once if -result-cache is set.
+	var filesHash string
+	for validatorId, validator := range commonci.Validators {
+		if validator.ReportOnly {
+			continue
+		}
+
+		versions := extraValidatorVersions(validatorId, extraVersionsMap, pinnedVersions)
+
+		// Empty string means the latest version, which is always run.
+		versionsToRun := append([]string{""}, versions...)
+
+		// Generate validation commands for the validator.
+		for _, version := range versionsToRun {
+			if skippedValidatorsMap[validatorId][version] {
+				log.Printf("Not activating skipped validator: %s", commonci.AppendVersionToName(validatorId, version))
+				continue
+			}
+			if push && version == commonci.HeadVersion {
+				log.Printf("Skipping badge posting for @head revision for %s", commonci.AppendVersionToName(validatorId, version))
+				continue
+			}
+
+			// Post initial PR status. The non-latest pyang versions
+			// are reported within the pyang version matrix.
+			if _, ok := compatReport.Member(validatorId, version); !ok && !inPyangMatrix(validatorId, version) {
+				if errs := postInitialStatus(h, validatorId, version); errs != nil {
+					commonci.Fatalf(commonci.ExitGitHubError, "%v", errs)
+				}
+			}
+
+			// Create results dir, which activates the validator script.
+			validatorResultsDir := commonci.ValidatorResultsDir(validatorId, version)
+			if err := mkdirAll(validatorResultsDir, 0644); err != nil {
+				commonci.Fatalf(commonci.ExitInfraError, "error while creating directory %q: %v", validatorResultsDir, err)
+			}
+			log.Printf("Created results directory %q", validatorResultsDir)
+			plan.Validators = append(plan.Validators, commonci.PlannedValidator{ValidatorId: validatorId, Version: version, ResultsDir: validatorResultsDir})
+			planned := &plan.Validators[len(plan.Validators)-1]
+
+			if validatorId == "misc-checks" && len(missingBuildFiles) > 0 {
+				missingBuildFilesPath := filepath.Join(validatorResultsDir, commonci.MissingBuildFilesFileName)
+				if err := writeFile(missingBuildFilesPath, []byte(strings.Join(missingBuildFiles, "\n")+"\n"), 0444); err != nil {
+					commonci.Fatalf(commonci.ExitInfraError, "error while writing missing build files to path %q: %v", missingBuildFilesPath, err)
+				}
+			}
+			if validatorId == "misc-checks" && modelIndex != nil {
+				var content string
+				if len(modelIndexProblems) > 0 {
+					content = strings.Join(modelIndexProblems, "\n") + "\n"
+				}
+				modelIndexProblemsPath := filepath.Join(validatorResultsDir, commonci.ModelIndexProblemsFileName)
+				if err := writeFile(modelIndexProblemsPath, []byte(content), 0444); err != nil {
+					commonci.Fatalf(commonci.ExitInfraError, "error while writing model index problems to path %q: %v", modelIndexProblemsPath, err)
+				}
+			}
+
+			if !validator.IsPerModel {
+				// Built-in repo-level validators are run
+				// directly on the entire models directory, so
+				// only those defined by the models repo have a
+				// generated script.
+				if _, ok := scriptTemplates[validatorId]; !ok {
+					continue
+				}
+				scriptStr, err := genRepoLevelValidatorScript(validatorId, commonci.RootDir, validatorResultsDir, modelMap)
+				if err != nil {
+					commonci.Fatalf(commonci.ExitFailure, "error while generating validator script: %v", err)
+				}
+				scriptStr = withValidatorHooks(scriptStr, validatorHooksMap[validatorId])
+				scriptPath := filepath.Join(validatorResultsDir, commonci.ScriptFileName)
+				if err := writeFile(scriptPath, []byte(scriptStr), 0744); err != nil {
+					commonci.Fatalf(commonci.ExitInfraError, "error while writing script to path %q: %v", scriptPath, err)
+				}
+				planned.Script = scriptPath
+				continue
+			}
+
+			validatorModelMap := validatedModelMap
+			if validatorId == "misc-checks" {
+				validatorModelMap = modelMap
+			}
+			var scriptStr string
+			var modelCount int
+			// planStr is the plan run by the script, if any.
+			var planStr string
+			if spec := scriptTemplates[validatorId]; validatorRunner == "go" && spec != nil && spec.runModel != nil {
+				var runPlan *runner.Plan
+				if runPlan, modelCount, err = genOpenConfigValidatorPlan(h, validatorId, version, validatorModelMap, validatorShard); err != nil {
+					commonci.Fatalf(commonci.ExitFailure, "error while generating validator plan: %v", err)
+				}
+				bs, err := runner.MarshalPlan(runPlan)
+				if err != nil {
+					commonci.Fatal(err)
+				}
+				planPath := filepath.Join(validatorResultsDir, runPlanFileName)
+				if err := writeFile(planPath, bs, 0444); err != nil {
+					commonci.Fatalf(commonci.ExitInfraError, "error while writing plan to path %q: %v", planPath, err)
+				}
+				scriptStr = runnerScript(planPath)
+				planStr = string(bs)
+			} else if scriptStr, modelCount, err = genOpenConfigValidatorScript(h, validatorId, version, validatorModelMap, validatorShard); err != nil {
+				commonci.Fatalf(commonci.ExitFailure, "error while generating validator script: %v", err)
+			}
+			scriptStr = withValidatorHooks(scriptStr, validatorHooksMap[validatorId])
+			// cacheInputs describes the validator's commands for the
+			// result cache, including its hooks, which may change the
+			// tools that it runs.
+			cacheInputs := scriptStr + planStr
+			if resultCache && validator.Cacheable && version != commonci.HeadVersion {
+				if filesHash == "" {
+					if filesHash, err = commonci.FilesHash(resultCacheDirs(modelMap)); err != nil {
+						commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+					}
+				}
+				planned.CacheKey = commonci.ResultCacheInputsKey(validatorId, version, cacheInputs, filesHash)
+				scriptStr = withResultCache(scriptStr, planned.CacheKey, validatorResultsDir)
+			}
+			scriptPath := filepath.Join(validatorResultsDir, commonci.ScriptFileName)
+			if err := writeFile(scriptPath, []byte(scriptStr), 0744); err != nil {
+				commonci.Fatalf(commonci.ExitInfraError, "error while writing script to path %q: %v", scriptPath, err)
+			}
+			modelCountPath := filepath.Join(validatorResultsDir, commonci.ExpectedModelCountFileName)
+			if err := writeFile(modelCountPath, []byte(strconv.Itoa(modelCount)), 0444); err != nil {
+				commonci.Fatalf(commonci.ExitInfraError, "error while writing expected model count to path %q: %v", modelCountPath, err)
+			}
+			planned.Script = scriptPath
+			planned.ModelCount = modelCount
+		}
+	}
+
+	// Relay the plan of the run to later CI steps, in a stable order.
+	sort.SliceStable(plan.Validators, func(i, j int) bool {
+		return commonci.AppendVersionToName(plan.Validators[i].ValidatorId, plan.Validators[i].Version) < commonci.AppendVersionToName(plan.Validators[j].ValidatorId, plan.Validators[j].Version)
+	})
+	bs, err := commonci.MarshalPlan(plan)
+	if err != nil {
+		commonci.Fatal(err)
+	}
+	if err := writeFile(commonci.PlanFile, bs, 0444); err != nil {
+		commonci.Fatalf(commonci.ExitInfraError, "error while writing plan file %q: %v", commonci.PlanFile, err)
+	}
+}
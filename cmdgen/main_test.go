@@ -0,0 +1,3049 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/runner"
+	"github.com/openconfig/models-ci/util"
+)
+
+// Fake LabelPoster for testing.
+type postLabelRecorder struct {
+	labels []string
+	// calls is the number of calls posting labels.
+	calls int
+}
+
+func (p *postLabelRecorder) PostLabels(labels []commonci.PRLabel, owner, repo string, prNumber int) error {
+	for _, l := range labels {
+		p.labels = append(p.labels, l.Name)
+	}
+	p.calls++
+	return nil
+}
+
+// wantModelFunctions is modelFunctions with the default
+// -model-timeout.
+const wantModelFunctions = `model_timeout=600s
+function timed() {
+  local status=0
+  timeout "$model_timeout" "$@" || status=$?
+  if [[ $status -eq 124 ]]; then
+    timed_out=1
+    >&2 echo "timed out after $model_timeout"
+  fi
+  return $status
+}
+function finish-model() {
+  declare prefix="$1"
+  if [[ $timed_out -eq 1 ]]; then
+    mv ${prefix}pass ${prefix}timeout
+  elif [[ $2 -ne 0 && ${retries_left:-0} -gt 0 ]]; then
+    >&2 echo "retrying ${prefix} after failure ($retries_left retries left)"
+    : > ${prefix}pass
+    retry=1
+    return
+  elif [[ $2 -ne 0 ]]; then
+    mv ${prefix}pass ${prefix}fail
+  fi
+  echo "${prefix}" >> "$workdir"/completed-models
+}
+function with-retries() {
+  local retries_left="$1" retry=1
+  shift
+  while [[ $retry -eq 1 ]]; do
+    retry=0
+    "$@"
+    retries_left=$((retries_left - 1))
+  done
+}
+function with-args() {
+  local model_args=( "${@:2:$1}" )
+  shift $(($1 + 1))
+  "$@"
+}
+function wait-for-slot() {
+  while [[ $(jobs -rp | wc -l) -ge $1 ]]; do
+    wait -n
+  done
+}
+`
+
+func TestGenOpenConfigValidatorScript(t *testing.T) {
+	prNumber = 1
+	basicModelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatalf("TestGenOpenConfigLinterScript: Failed to parse models for testing: %v", err)
+	}
+
+	tests := []struct {
+		name                 string
+		inValidatorName      string
+		inModelMap           commonci.OpenConfigModelMap
+		inDisabledModelPaths map[string]bool
+		wantCmd              string
+		wantSkipLabels       []string
+		wantModelCount       int
+		wantErr              bool
+	}{{
+		name:            "basic pyang",
+		inModelMap:      basicModelMap,
+		inValidatorName: "pyang",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/pyang
+mkdir -p "$workdir"
+` + wantModelFunctions + `PYANG_MSG_TEMPLATE='messages:{{path:"{file}" line:{line} code:"{code}" type:"{type}" level:{level} message:'"'{msg}'}}"
+cmd="$@"
+options=(
+  -p testdata
+  -p /workspace/third_party/ietf
+)
+script_options=(
+  --msg-template "$PYANG_MSG_TEMPLATE"
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  shift 2
+  echo pyang -W error "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  timed $cmd -W error "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:                 "basic pyang with model to be skipped",
+		inModelMap:           basicModelMap,
+		inValidatorName:      "pyang",
+		inDisabledModelPaths: map[string]bool{"acl": true, "dne": true},
+		wantSkipLabels:       []string{"skipped: acl"},
+		wantModelCount:       2,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/pyang
+mkdir -p "$workdir"
+` + wantModelFunctions + `PYANG_MSG_TEMPLATE='messages:{{path:"{file}" line:{line} code:"{code}" type:"{type}" level:{level} message:'"'{msg}'}}"
+cmd="$@"
+options=(
+  -p testdata
+  -p /workspace/third_party/ietf
+)
+script_options=(
+  --msg-template "$PYANG_MSG_TEMPLATE"
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  shift 2
+  echo pyang -W error "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  timed $cmd -W error "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic oc-pyang",
+		inModelMap:      basicModelMap,
+		inValidatorName: "oc-pyang",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/oc-pyang
+mkdir -p "$workdir"
+` + wantModelFunctions + `PYANG_MSG_TEMPLATE='messages:{{path:"{file}" line:{line} code:"{code}" type:"{type}" level:{level} message:'"'{msg}'}}"
+cmd="$@"
+options=(
+  --openconfig
+  --ignore-error=OC_RELATIVE_PATH
+  -p testdata
+  -p /workspace/third_party/ietf
+)
+script_options=(
+  --msg-template "$PYANG_MSG_TEMPLATE"
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  local cmd_display_options=( --plugindir '$OCPYANG_PLUGIN_DIR' "${options[@]}" )
+  local options=( --plugindir "$OCPYANG_PLUGIN_DIR" "${options[@]}" )
+  shift 2
+  echo pyang "${cmd_display_options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic pyangbind",
+		inModelMap:      basicModelMap,
+		inValidatorName: "pyangbind",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/pyangbind
+mkdir -p "$workdir"
+` + wantModelFunctions + `PYANG_MSG_TEMPLATE='messages:{{path:"{file}" line:{line} code:"{code}" type:"{type}" level:{level} message:'"'{msg}'}}"
+cmd="$@"
+options=(
+  -f pybind
+  -p testdata
+  -p /workspace/third_party/ietf
+)
+script_options=(
+  --msg-template "$PYANG_MSG_TEMPLATE"
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  local output_file="$1"."$2".binding.py
+  local cmd_display_options=( --plugindir '$PYANGBIND_PLUGIN_DIR' -o "${output_file}" "${options[@]}" )
+  local options=( --plugindir "$PYANGBIND_PLUGIN_DIR" -o "${output_file}" "${options[@]}" )
+  shift 2
+  echo pyang "${cmd_display_options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  if [[ $status -eq "0" ]]; then
+    python "${output_file}" &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic goyang-ygot",
+		inModelMap:      basicModelMap,
+		inValidatorName: "goyang-ygot",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/goyang-ygot
+mkdir -p "$workdir"
+` + wantModelFunctions + `cmd="generator"
+options=(
+  -path=testdata,/workspace/third_party/ietf
+  -package_name=exampleoc -generate_fakeroot -fakeroot_name=device -compress_paths=true
+  -shorten_enum_leaf_names -trim_enum_openconfig_prefix -typedef_enum_with_defmod -enum_suffix_for_simple_union_enums
+  -exclude_modules=ietf-interfaces -generate_rename -generate_append -generate_getters
+  -generate_leaf_getters -generate_delete -annotations -generate_simple_unions
+  -list_builder_key_threshold=3
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/ygot/"$1"."$2"/
+  mkdir -p "$outdir"
+  local options=( -output_file="$outdir"/oc.go "${options[@]}" )
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  cd "$outdir"
+  if [[ $status -eq "0" ]]; then
+    go mod init &>> ${prefix}pass || status=1
+    go mod tidy &>> ${prefix}pass || status=1
+    go build &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic ygnmi",
+		inModelMap:      basicModelMap,
+		inValidatorName: "ygnmi",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/ygnmi
+mkdir -p "$workdir"
+` + wantModelFunctions + `cmd="ygnmi generator"
+options=(
+  --trim_module_prefix=openconfig
+  --exclude_modules=ietf-interfaces
+  --split_package_paths="/network-instances/network-instance/protocols/protocol/isis=netinstisis,/network-instances/network-instance/protocols/protocol/bgp=netinstbgp"
+  --paths=testdata/...,/workspace/third_party/ietf/...
+  --annotations
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/ygnmi/"$1"."$2"
+  mkdir -p "$outdir"
+  local options=( --output_dir="${outdir}"/oc --base_package_path=ygnmi/"$1"."$2"/oc "${options[@]}" )
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  if [[ $status -eq "0" ]]; then
+    cd "$outdir/oc"
+    go mod init &> /dev/null || status=1
+    go mod tidy &> /dev/null || status=1
+    goimports -w *.go &> /dev/null || status=1
+    go build &> /dev/null || status=1
+  fi
+  if [[ $status -eq "1" ]]; then
+    # Only output if there is an error: otherwise the gist comment is too long.
+    go build &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+go install golang.org/x/tools/cmd/goimports@latest &>> ${prefix}pass || status=1
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic ygot-proto",
+		inModelMap:      basicModelMap,
+		inValidatorName: "ygot-proto",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/ygot-proto
+mkdir -p "$workdir"
+` + wantModelFunctions + `ygot_dir="$1"
+cmd="proto_generator"
+options=(
+  -path=testdata,/workspace/third_party/ietf
+  -package_name=openconfig -generate_fakeroot -fakeroot_name=device -compress_paths=true
+  -exclude_modules=ietf-interfaces
+  -ywrapper_path=proto/ywrapper -yext_path=proto/yext
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/ygot-proto/"$1"."$2"
+  mkdir -p "$outdir"
+  local options=( -output_dir="$outdir" "${options[@]}" )
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  status=0
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &> ${prefix}pass || status=1
+  if [[ $status -eq "0" ]]; then
+    find "$outdir" -name "*.proto" -print0 | xargs -0 protoc -I "$outdir" -I "$ygot_dir" --descriptor_set_out=/dev/null &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic tree-diff",
+		inModelMap:      basicModelMap,
+		inValidatorName: "tree-diff",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/tree-diff
+mkdir -p "$workdir"/trees
+` + wantModelFunctions + `repo_root=/workspace
+base_repo="$1"
+shift
+cmd="$@"
+options=(
+  -p testdata
+  -p /workspace/third_party/ietf
+)
+base_options=( "${options[@]/#$repo_root/$base_repo}" )
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  local tree="$workdir"/trees/"$1"=="$2"
+  shift 2
+  echo pyang -f tree "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local base_files=()
+  for file in "$@"; do
+    file="${file/#$repo_root/$base_repo}"
+    if [[ -f "$file" ]]; then
+      base_files+=( "$file" )
+    fi
+  done
+  local status=0
+  timed $cmd -f tree "${options[@]}" "${model_args[@]}" "$@" > "$tree".head 2> ${prefix}pass || status=1
+  if [[ $status -eq 0 ]]; then
+    : > "$tree".base
+    if [[ ${#base_files[@]} -ne "0" ]] && ! timeout "$model_timeout" $cmd -f tree "${base_options[@]}" "${model_args[@]}" "${base_files[@]}" > "$tree".base 2> /dev/null; then
+      echo "tree of the base could not be rendered" >> ${prefix}pass
+    fi
+    diff -u --label base --label head "$tree".base "$tree".head >> ${prefix}pass
+  fi
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic gnmi-paths",
+		inModelMap:      basicModelMap,
+		inValidatorName: "gnmi-paths",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/gnmi-paths
+mkdir -p "$workdir"/paths
+` + wantModelFunctions + `cmd="generator"
+options=(
+  -path=testdata,/workspace/third_party/ietf
+  -package_name=exampleoc -generate_fakeroot -fakeroot_name=device -compress_paths=true
+  -exclude_modules=ietf-interfaces -generate_structs=false -generate_path_structs
+  -schema_struct_path=github.com/openconfig/ygot/exampleoc
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  outdir=$GOPATH/src/gnmi-paths/"$1"."$2"
+  mkdir -p "$outdir"
+  local pathlist="$workdir"/paths/"$1"=="$2".txt
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  for state in false true; do
+    timed $cmd -path_structs_output_file="$outdir"/paths-$state.go -prefer_operational_state=$state "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &>> ${prefix}pass || status=1
+  done
+  if [[ $status -eq "0" ]]; then
+    sed -n 's|^// .* represents the /[^/]*\(/.*\) YANG schema element\.$|\1|p' "$outdir"/paths-*.go | sort -u > "$pathlist"
+    if [[ ! -s "$pathlist" ]]; then
+      echo "no gNMI paths were generated" >> ${prefix}pass
+      status=1
+    fi
+  fi
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic yanglint",
+		inModelMap:      basicModelMap,
+		inValidatorName: "yanglint",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/yanglint
+mkdir -p "$workdir"
+` + wantModelFunctions + `cmd="yanglint"
+options=(
+  -p testdata
+  -p /workspace/third_party/ietf
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  for file in "$@"; do
+    echo "yanglint-file: $file" >> ${prefix}pass
+  done
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &>> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic yang-examples",
+		inModelMap:      basicModelMap,
+		inValidatorName: "yang-examples",
+		wantModelCount:  1,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/yang-examples
+mkdir -p "$workdir"
+` + wantModelFunctions + `cmd="yanglint"
+options=(
+  -p testdata
+  -p /workspace/third_party/ietf
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  declare examples_dir="$3"
+  shift 3
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" "$examples_dir/<example>" > ${prefix}cmd
+  local status=0
+  if [[ ! -d "$examples_dir" ]]; then
+    echo "examples directory $examples_dir does not exist" >> ${prefix}pass
+    status=1
+  fi
+  local example
+  for example in $(find "$examples_dir" -type f \( -name '*.json' -o -name '*.xml' \) 2> /dev/null | sort); do
+    echo "example-file: $example" >> ${prefix}pass
+    local example_status=pass
+    timed $cmd "${options[@]}" "${model_args[@]}" "$@" "$example" &>> ${prefix}pass || example_status=fail
+    echo "example-status: $example_status" >> ${prefix}pass
+    if [[ $example_status == fail ]]; then
+      status=1
+    fi
+  done
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" "testdata/acl/examples" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait
+`,
+	}, {
+		name:            "basic yangson",
+		inModelMap:      basicModelMap,
+		inValidatorName: "yangson",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/yangson
+mkdir -p "$workdir"/yanglib
+` + wantModelFunctions + `cmd="yangson"
+search_paths=testdata,/workspace/third_party/ietf
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  declare library="$workdir"/yanglib/"$1"=="$2".json
+  shift 2
+  local status=0
+  local module_path
+  module_path=$(/go/bin/yanglib -p "$search_paths" -o "$library" "$@" 2>> ${prefix}pass) || status=1
+  echo $cmd -p "$module_path" "${model_args[@]}" "$library" > ${prefix}cmd
+  if [[ $status -eq 0 ]]; then
+    timed $cmd -p "$module_path" "${model_args[@]}" "$library" &>> ${prefix}pass || status=1
+  fi
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-optical-amplifier" testdata/optical-transport/openconfig-optical-amplifier.yang &
+wait-for-slot 8
+run-dir "optical-transport" "openconfig-transport-line-protection" testdata/optical-transport/openconfig-transport-line-protection.yang &
+wait
+`,
+	}, {
+		name:            "basic confd",
+		inModelMap:      basicModelMap,
+		inValidatorName: "confd",
+		wantModelCount:  3,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/confd
+mkdir -p "$workdir"
+` + wantModelFunctions + `status=0
+timed_out=0
+timed $1 -c --yangpath $2 testdata/acl/openconfig-acl.yang &>> /workspace/results/confd/acl==openconfig-acl==pass || status=1
+timed $1 -c --yangpath $2 testdata/acl/openconfig-acl-evil-twin.yang &>> /workspace/results/confd/acl==openconfig-acl==pass || status=1
+finish-model "/workspace/results/confd/acl==openconfig-acl==" $status
+status=0
+timed_out=0
+timed $1 -c --yangpath $2 testdata/optical-transport/openconfig-optical-amplifier.yang &>> /workspace/results/confd/optical-transport==openconfig-optical-amplifier==pass || status=1
+finish-model "/workspace/results/confd/optical-transport==openconfig-optical-amplifier==" $status
+status=0
+timed_out=0
+timed $1 -c --yangpath $2 testdata/optical-transport/openconfig-transport-line-protection.yang &>> /workspace/results/confd/optical-transport==openconfig-transport-line-protection==pass || status=1
+finish-model "/workspace/results/confd/optical-transport==openconfig-transport-line-protection==" $status
+wait
+`,
+	}, {
+		name:            "basic misc-checks",
+		inModelMap:      basicModelMap,
+		inValidatorName: "misc-checks",
+		wantModelCount:  5,
+		wantCmd: `#!/bin/bash
+workdir=/workspace/results/misc-checks
+mkdir -p "$workdir"
+/go/bin/ocversion -timeout=10m -cache-dir=/workspace/parse-cache -p testdata,/workspace/third_party/ietf testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang > /workspace/results/misc-checks/acl.openconfig-acl.pr-file-parse-log
+case $? in
+  0) ;;
+  124) >&2 echo "parse of acl.openconfig-acl timed out -- CI infra error." ;;
+  *) >&2 echo "parse of acl.openconfig-acl reported non-zero status." ;;
+esac
+echo "/workspace/results/misc-checks/acl==openconfig-acl==" >> /workspace/results/misc-checks/completed-models
+/go/bin/ocversion -timeout=10m -cache-dir=/workspace/parse-cache -p testdata,/workspace/third_party/ietf testdata/optical-transport/openconfig-optical-amplifier.yang > /workspace/results/misc-checks/optical-transport.openconfig-optical-amplifier.pr-file-parse-log
+case $? in
+  0) ;;
+  124) >&2 echo "parse of optical-transport.openconfig-optical-amplifier timed out -- CI infra error." ;;
+  *) >&2 echo "parse of optical-transport.openconfig-optical-amplifier reported non-zero status." ;;
+esac
+echo "/workspace/results/misc-checks/optical-transport==openconfig-optical-amplifier==" >> /workspace/results/misc-checks/completed-models
+/go/bin/ocversion -timeout=10m -cache-dir=/workspace/parse-cache -p testdata,/workspace/third_party/ietf testdata/optical-transport/openconfig-transport-line-connectivity.yang testdata/optical-transport/openconfig-wavelength-router.yang > /workspace/results/misc-checks/optical-transport.openconfig-wavelength-router.pr-file-parse-log
+case $? in
+  0) ;;
+  124) >&2 echo "parse of optical-transport.openconfig-wavelength-router timed out -- CI infra error." ;;
+  *) >&2 echo "parse of optical-transport.openconfig-wavelength-router reported non-zero status." ;;
+esac
+echo "/workspace/results/misc-checks/optical-transport==openconfig-wavelength-router==" >> /workspace/results/misc-checks/completed-models
+/go/bin/ocversion -timeout=10m -cache-dir=/workspace/parse-cache -p testdata,/workspace/third_party/ietf testdata/optical-transport/openconfig-transport-line-protection.yang > /workspace/results/misc-checks/optical-transport.openconfig-transport-line-protection.pr-file-parse-log
+case $? in
+  0) ;;
+  124) >&2 echo "parse of optical-transport.openconfig-transport-line-protection timed out -- CI infra error." ;;
+  *) >&2 echo "parse of optical-transport.openconfig-transport-line-protection reported non-zero status." ;;
+esac
+echo "/workspace/results/misc-checks/optical-transport==openconfig-transport-line-protection==" >> /workspace/results/misc-checks/completed-models
+/go/bin/ocversion -timeout=10m -cache-dir=/workspace/parse-cache -p testdata,/workspace/third_party/ietf testdata/optical-transport/openconfig-optical-attenuator.yang > /workspace/results/misc-checks/optical-transport.openconfig-optical-attenuator.pr-file-parse-log
+case $? in
+  0) ;;
+  124) >&2 echo "parse of optical-transport.openconfig-optical-attenuator timed out -- CI infra error." ;;
+  *) >&2 echo "parse of optical-transport.openconfig-optical-attenuator reported non-zero status." ;;
+esac
+echo "/workspace/results/misc-checks/optical-transport==openconfig-optical-attenuator==" >> /workspace/results/misc-checks/completed-models
+wait
+`,
+	}, {
+		name:            "unrecognized validatorID",
+		inModelMap:      basicModelMap,
+		inValidatorName: "foo",
+		wantErr:         true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labelRecorder := &postLabelRecorder{}
+			disabledModelPaths = tt.inDisabledModelPaths
+
+			got, gotModelCount, err := genOpenConfigValidatorScript(labelRecorder, tt.inValidatorName, "", tt.inModelMap, modelShard{})
+			if got := err != nil; got != tt.wantErr {
+				t.Fatalf("got error %v,	wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(strings.Split(tt.wantCmd, "\n"), strings.Split(got, "\n")); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tt.wantSkipLabels, labelRecorder.labels); diff != "" {
+				t.Errorf("skipped models (-want, +got):\n%s", diff)
+			}
+			if len(tt.wantSkipLabels) > 0 && labelRecorder.calls != 1 {
+				t.Errorf("skipped model labels posted in %d calls, want 1", labelRecorder.calls)
+			}
+
+			if gotModelCount != tt.wantModelCount {
+				t.Errorf("got model count %d, want %d", gotModelCount, tt.wantModelCount)
+			}
+		})
+	}
+}
+
+func TestParseModelShard(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    modelShard
+		wantErr bool
+	}{{
+		in:   "",
+		want: modelShard{},
+	}, {
+		in:   "2/4",
+		want: modelShard{Index: 2, Count: 4},
+	}, {
+		in:      "0/4",
+		wantErr: true,
+	}, {
+		in:      "5/4",
+		wantErr: true,
+	}, {
+		in:      "2",
+		wantErr: true,
+	}, {
+		in:      "a/b",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseModelShard(tt.in)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenOpenConfigValidatorScriptShards(t *testing.T) {
+	prNumber = 1
+	disabledModelPaths = nil
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		inValidatorName string
+		inShard         modelShard
+		wantModelDirs   []string
+		wantModelCount  int
+	}{{
+		name:            "first shard",
+		inValidatorName: "pyang",
+		inShard:         modelShard{Index: 1, Count: 2},
+		wantModelDirs:   []string{"acl"},
+		wantModelCount:  1,
+	}, {
+		name:            "second shard",
+		inValidatorName: "pyang",
+		inShard:         modelShard{Index: 2, Count: 2},
+		wantModelDirs:   []string{"optical-transport"},
+		wantModelCount:  2,
+	}, {
+		name:            "more shards than model directories",
+		inValidatorName: "pyang",
+		inShard:         modelShard{Index: 3, Count: 3},
+	}, {
+		name:            "misc-checks isn't sharded",
+		inValidatorName: "misc-checks",
+		inShard:         modelShard{Index: 2, Count: 2},
+		wantModelDirs:   []string{"acl", "optical-transport"},
+		wantModelCount:  5,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotModelCount, err := genOpenConfigValidatorScript(&postLabelRecorder{}, tt.inValidatorName, "", modelMap, tt.inShard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, modelDirName := range []string{"acl", "optical-transport"} {
+				want := false
+				for _, dir := range tt.wantModelDirs {
+					want = want || dir == modelDirName
+				}
+				// The model directory is either quoted or a prefix of a results file.
+				if contains := strings.Contains(got, `"`+modelDirName+`"`) || strings.Contains(got, "/"+modelDirName+"=="); contains != want {
+					t.Errorf("script validates %s: got %v, want %v", modelDirName, contains, want)
+				}
+			}
+			if gotModelCount != tt.wantModelCount {
+				t.Errorf("got model count %d, want %d", gotModelCount, tt.wantModelCount)
+			}
+		})
+	}
+}
+
+func TestMaxParallel(t *testing.T) {
+	prNumber = 1
+	disabledModelPaths = nil
+	defer func(old int) { maxParallel = old }(maxParallel)
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name              string
+		inMaxParallel     int
+		inValidatorName   string
+		inVersion         string
+		wantWaitForSlot   bool
+		wantRunnerCommand string
+	}{{
+		name:              "bounded",
+		inMaxParallel:     2,
+		inValidatorName:   "goyang-ygot",
+		wantWaitForSlot:   true,
+		wantRunnerCommand: `$GOPATH/bin/openconfig-ci run-validator --plan /workspace/results/pyang/plan.json --parallelism 2 -- "$@"`,
+	}, {
+		name:              "unlimited",
+		inMaxParallel:     0,
+		inValidatorName:   "goyang-ygot",
+		wantRunnerCommand: `$GOPATH/bin/openconfig-ci run-validator --plan /workspace/results/pyang/plan.json -- "$@"`,
+	}, {
+		name:              "sequential validator",
+		inMaxParallel:     2,
+		inValidatorName:   "pyang",
+		inVersion:         "head",
+		wantRunnerCommand: `$GOPATH/bin/openconfig-ci run-validator --plan /workspace/results/pyang/plan.json --parallelism 2 -- "$@"`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxParallel = tt.inMaxParallel
+			got, _, err := genOpenConfigValidatorScript(&postLabelRecorder{}, tt.inValidatorName, tt.inVersion, modelMap, modelShard{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotWaitForSlot := strings.Contains(got, "\nwait-for-slot "); gotWaitForSlot != tt.wantWaitForSlot {
+				t.Errorf("script waits for a slot: got %v, want %v", gotWaitForSlot, tt.wantWaitForSlot)
+			}
+			gotRunner := strings.Split(runnerScript("/workspace/results/pyang/plan.json"), "\n")[1]
+			if diff := cmp.Diff(tt.wantRunnerCommand, gotRunner); diff != "" {
+				t.Errorf("runner script (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenOpenConfigValidatorPlan(t *testing.T) {
+	prNumber = 1
+	disabledModelPaths = nil
+	defer func(old time.Duration) { modelTimeout = old }(modelTimeout)
+	modelTimeout = 5 * time.Minute
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, gotModelCount, err := genOpenConfigValidatorPlan(&postLabelRecorder{}, "yanglint", "", modelMap, modelShard{Index: 1, Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := []string{"yanglint", "-p", "testdata", "-p", "/workspace/third_party/ietf"}
+	want := &runner.Plan{
+		ValidatorId:    "yanglint",
+		ResultsDir:     "/workspace/results/yanglint",
+		Parallel:       true,
+		TimeoutSeconds: 300,
+		Models: []runner.Model{{
+			ModelDirName: "acl",
+			ModelName:    "openconfig-acl",
+			Cmd:          "yanglint -p testdata -p /workspace/third_party/ietf testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang",
+			Steps: []runner.Step{{
+				Marker: "yanglint-file: testdata/acl/openconfig-acl.yang\nyanglint-file: testdata/acl/openconfig-acl-evil-twin.yang",
+				Argv:   append(options, "testdata/acl/openconfig-acl.yang", "testdata/acl/openconfig-acl-evil-twin.yang"),
+			}},
+		}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("plan (-want, +got):\n%s", diff)
+	}
+	if gotModelCount != 1 {
+		t.Errorf("got model count %d, want 1", gotModelCount)
+	}
+
+	got, _, err = genOpenConfigValidatorPlan(&postLabelRecorder{}, "pyang", "head", modelMap, modelShard{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Parallel {
+		t.Errorf("pyang@head plan is parallel")
+	}
+	wantArgv := []string{"$@", "-W", "error", "-p", "testdata", "-p", "/workspace/third_party/ietf", "--msg-template", util.PyangMsgTemplate, "testdata/optical-transport/openconfig-optical-amplifier.yang"}
+	if diff := cmp.Diff(wantArgv, got.Models[1].Steps[0].Argv); diff != "" {
+		t.Errorf("pyang argv (-want, +got):\n%s", diff)
+	}
+
+	if _, _, err := genOpenConfigValidatorPlan(&postLabelRecorder{}, "goyang-ygot", "", modelMap, modelShard{}); err == nil {
+		t.Errorf("got no error for validator without a Go runner")
+	}
+}
+
+func TestRegisterValidators(t *testing.T) {
+	prNumber = 1
+	disabledModelPaths = nil
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := commonci.ParseValidatorsConfig([]byte(`
+validators:
+  - id: my-linter
+    name: My Linter
+    per-model: true
+    header-template: |
+      #!/bin/bash
+      mkdir -p {{ .ResultsDir }}
+    per-model-template: |
+      my-linter {{ .ModelName }} {{- range .BuildFiles }} {{ . }} {{- end }} > {{ .ResultsDir }}/{{ .ModelDirName }}=={{ .ModelName }}==pass
+  - id: my-repo-check
+    name: My Repo Check
+    header-template: |
+      #!/bin/bash
+      my-repo-check {{ range .ModelRoots }}{{ . }}{{ end }}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registerValidators(cfg, readCheckoutConfig); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		for _, id := range []string{"my-linter", "my-repo-check"} {
+			delete(commonci.Validators, id)
+			delete(scriptTemplates, id)
+		}
+	}()
+
+	if v := commonci.Validators["my-linter"]; v == nil || v.Name != "My Linter" || !v.IsPerModel {
+		t.Errorf("my-linter not registered correctly: %+v", v)
+	}
+
+	got, gotModelCount, err := genOpenConfigValidatorScript(&postLabelRecorder{}, "my-linter", "", modelMap, modelShard{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCmd := `#!/bin/bash
+mkdir -p /workspace/results/my-linter
+my-linter openconfig-acl testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang > /workspace/results/my-linter/acl==openconfig-acl==pass
+my-linter openconfig-optical-amplifier testdata/optical-transport/openconfig-optical-amplifier.yang > /workspace/results/my-linter/optical-transport==openconfig-optical-amplifier==pass
+my-linter openconfig-transport-line-protection testdata/optical-transport/openconfig-transport-line-protection.yang > /workspace/results/my-linter/optical-transport==openconfig-transport-line-protection==pass
+wait
+`
+	if diff := cmp.Diff(strings.Split(wantCmd, "\n"), strings.Split(got, "\n")); diff != "" {
+		t.Errorf("per-model script (-want, +got):\n%s", diff)
+	}
+	if gotModelCount != 3 {
+		t.Errorf("got model count %d, want 3", gotModelCount)
+	}
+
+	got, err = genRepoLevelValidatorScript("my-repo-check", commonci.RootDir, commonci.ValidatorResultsDir("my-repo-check", ""), modelMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#!/bin/bash\nmy-repo-check testdata\n"; got != want {
+		t.Errorf("repo-level script: got %q, want %q", got, want)
+	}
+}
+
+func TestScriptHookSpec(t *testing.T) {
+	repoRoot, resultsDir := t.TempDir(), t.TempDir()
+	// The hook fails models named openconfig-bad, and outputs its
+	// arguments and the model roots. It's run regardless of the PR's copy
+	// of the hook.
+	hook := []byte(`#!/bin/bash
+echo "$MODEL_ROOTS" "$@"
+[[ $2 != openconfig-bad ]]
+`)
+	if err := os.MkdirAll(filepath.Join(repoRoot, "ci"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repoRoot, "ci", "check.sh"), []byte("#!/bin/bash\necho changed by the PR\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := scriptHookSpec("my-check", hook, true)
+	var script strings.Builder
+	params := &cmdParams{ModelRoots: []string{"release/models", "experimental"}, RepoRoot: repoRoot, ResultsDir: resultsDir}
+	if err := spec.headerTemplate.Execute(&script, params); err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []struct{ modelDir, model string }{{"acl", "openconfig-acl"}, {"wifi:mac", "openconfig-bad"}} {
+		params.ModelDirName, params.ModelName = model.modelDir, model.model
+		params.BuildFiles = []string{"release/models/" + model.model + ".yang"}
+		if err := spec.perModelTemplate.Execute(&script, params); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, err := exec.Command("bash", "-c", script.String()).CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+
+	for result, want := range map[string]string{
+		"acl==openconfig-acl==pass":      "release/models,experimental acl openconfig-acl release/models/openconfig-acl.yang\n",
+		"wifi:mac==openconfig-bad==fail": "release/models,experimental wifi:mac openconfig-bad release/models/openconfig-bad.yang\n",
+	} {
+		got, err := os.ReadFile(filepath.Join(resultsDir, result))
+		if err != nil {
+			t.Errorf("missing result: %v", err)
+			continue
+		}
+		if string(got) != want {
+			t.Errorf("%s: got output %q, want %q", result, got, want)
+		}
+	}
+
+	spec = scriptHookSpec("my-repo-check", hook, false)
+	script.Reset()
+	if err := spec.headerTemplate.Execute(&script, params); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("bash", "-c", script.String(), "bash").CombinedOutput()
+	if err != nil {
+		t.Fatalf("repo-level script failed: %v\n%s", err, out)
+	}
+	if want := "release/models,experimental\n"; string(out) != want {
+		t.Errorf("repo-level script: got output %q, want %q", out, want)
+	}
+}
+
+func TestRegisterValidatorsScriptHook(t *testing.T) {
+	cfg := &commonci.ValidatorsConfig{
+		Validators: []*commonci.ValidatorConfig{{
+			Id:     "my-check",
+			Name:   "My Check",
+			Script: "ci/check.sh",
+		}},
+	}
+	read := baseBranchConfigReader(fakeFileContents{ref: "master"}, "o", "r", "master")
+	if err := registerValidators(cfg, read); err == nil {
+		t.Errorf("got no error for missing script")
+	}
+	if _, ok := commonci.Validators["my-check"]; ok {
+		delete(commonci.Validators, "my-check")
+		t.Errorf("validator with missing script was registered")
+	}
+
+	read = baseBranchConfigReader(fakeFileContents{ref: "master", files: map[string]string{"ci/check.sh": "#!/bin/bash\necho base branch\n"}}, "o", "r", "master")
+	if err := registerValidators(cfg, read); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		delete(commonci.Validators, "my-check")
+		delete(scriptTemplates, "my-check")
+	}()
+	var script strings.Builder
+	if err := scriptTemplates["my-check"].headerTemplate.Execute(&script, &cmdParams{}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("bash", "-c", script.String()).CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	if want := "base branch\n"; string(out) != want {
+		t.Errorf("got output %q, want %q", out, want)
+	}
+}
+
+func TestRegisterValidatorsInvalidTemplate(t *testing.T) {
+	cfg := &commonci.ValidatorsConfig{
+		Validators: []*commonci.ValidatorConfig{{
+			Id:             "my-linter",
+			Name:           "My Linter",
+			HeaderTemplate: "{{ .ResultsDir ",
+		}},
+	}
+	if err := registerValidators(cfg, readCheckoutConfig); err == nil {
+		t.Errorf("got no error for invalid template")
+	}
+	if _, ok := commonci.Validators["my-linter"]; ok {
+		delete(commonci.Validators, "my-linter")
+		t.Errorf("validator with invalid template was registered")
+	}
+}
+
+func TestRemoveModelsWithMissingBuildFiles(t *testing.T) {
+	modelRoot := t.TempDir()
+	writeFile := func(path, content string) {
+		path = filepath.Join(modelRoot, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("acl/.spec.yml", `- name: openconfig-acl
+  build:
+    - yang/acl/openconfig-acl.yang
+    - yang/acl/openconfig-acl-deleted.yang
+  run-ci: true
+- name: openconfig-packet-match
+  build:
+    - yang/acl/openconfig-packet-match.yang
+  run-ci: true
+`)
+	writeFile("acl/openconfig-acl.yang", "")
+	writeFile("acl/openconfig-packet-match.yang", "")
+	writeFile("wifi/mac/.spec.yml", `- name: openconfig-wifi-mac
+  build:
+    - yang/wifi/mac/openconfig-wifi-mac.yang
+  run-ci: true
+`)
+
+	modelMap, err := commonci.ParseOCModels(modelRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantMissing := []string{
+		"acl/.spec.yml:4: build file acl/openconfig-acl-deleted.yang does not exist",
+		"wifi/mac/.spec.yml:3: build file wifi/mac/openconfig-wifi-mac.yang does not exist",
+	}
+	if diff := cmp.Diff(wantMissing, removeModelsWithMissingBuildFiles(modelMap)); diff != "" {
+		t.Errorf("missing build files (-want, +got):\n%s", diff)
+	}
+
+	wantBuildFiles := map[string][][]string{
+		"acl":      {nil, {filepath.Join(modelRoot, "acl/openconfig-packet-match.yang")}},
+		"wifi:mac": {nil},
+	}
+	gotBuildFiles := map[string][][]string{}
+	for modelDirName, modelInfos := range modelMap.ModelInfoMap {
+		for _, modelInfo := range modelInfos {
+			gotBuildFiles[modelDirName] = append(gotBuildFiles[modelDirName], modelInfo.BuildFiles)
+		}
+	}
+	if diff := cmp.Diff(wantBuildFiles, gotBuildFiles); diff != "" {
+		t.Errorf("build files (-want, +got):\n%s", diff)
+	}
+}
+
+func TestSpecLocation(t *testing.T) {
+	modelRoots := []string{"release/models", "third_party/models"}
+	tests := []struct {
+		desc       string
+		inSpecFile string
+		inLine     int
+		want       string
+	}{{
+		desc:       "within second model root",
+		inSpecFile: "third_party/models/wifi/mac/.spec.yml",
+		inLine:     4,
+		want:       "wifi/mac/.spec.yml:4",
+	}, {
+		desc:       "unknown line",
+		inSpecFile: "release/models/acl/.spec.yml",
+		want:       "acl/.spec.yml",
+	}, {
+		desc:       "outside model roots",
+		inSpecFile: "other/acl/.spec.yml",
+		inLine:     2,
+		want:       "other/acl/.spec.yml:2",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := specLocation(modelRoots, tt.inSpecFile, tt.inLine); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckSpecs(t *testing.T) {
+	modelRoot := t.TempDir()
+	writeFile := func(path, content string) {
+		path = filepath.Join(modelRoot, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("acl/.spec.yml", `- name: openconfig-acl
+  build:
+    - yang/acl/openconfig-acl.yang
+    - yang/acl/openconfig-acl-deleted.yang
+  run-ci: true
+- build:
+    - yang/acl/openconfig-packet-match.yang
+  run-ci: true
+`)
+	writeFile("acl/openconfig-acl.yang", "")
+	writeFile("acl/openconfig-packet-match.yang", "")
+	writeFile("empty/.spec.yml", "[]\n")
+	writeFile("wifi/mac/.spec.yml", `- name: openconfig-acl
+  build:
+    - yang/wifi/mac/openconfig-wifi-mac.yang
+  run-ci: true
+- name: openconfig-wifi-phy
+  examples: yang/wifi/phy/examples
+  run-ci: true
+`)
+	writeFile("wifi/mac/openconfig-wifi-mac.yang", "")
+	writeFile("aft/.spec.yml", `- name: openconfig-aft
+  build:
+    - yang/aft/openconfig-aft.yang
+  run-ci: true
+  retries:
+    goyang-ygot: 2
+    foo: 1
+    regexp: 1
+    yanglint: 6
+  env:
+    YANGLINT_FEATURES: all
+    BAD-NAME: x
+  extra-args:
+    yanglint: ["-F", "openconfig-aft:*"]
+    bar: ["-x"]
+`)
+	writeFile("aft/openconfig-aft.yang", "")
+
+	modelMap, err := commonci.ParseOCModels(modelRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"acl/.spec.yml:4: build file acl/openconfig-acl-deleted.yang does not exist",
+		"acl/.spec.yml:6: missing model name",
+		`aft/.spec.yml:1: retries of model "openconfig-aft" for unrecognized validator "foo"`,
+		`aft/.spec.yml:1: retries of model "openconfig-aft" for validator "regexp", which doesn't validate each model`,
+		`aft/.spec.yml:1: retries of model "openconfig-aft" for validator "yanglint" must be from 0 to 5, got 6`,
+		`aft/.spec.yml:1: invalid environment variable name "BAD-NAME" of model "openconfig-aft"`,
+		`aft/.spec.yml:1: extra-args of model "openconfig-aft" for unrecognized validator "bar"`,
+		"empty/.spec.yml: no models defined",
+		`wifi/mac/.spec.yml:1: duplicate model name "openconfig-acl", also defined at acl/.spec.yml:1`,
+		`wifi/mac/.spec.yml:5: model "openconfig-wifi-phy" has no build files`,
+		`wifi/mac/.spec.yml:5: examples directory wifi/phy/examples of model "openconfig-wifi-phy" does not exist`,
+	}
+	if diff := cmp.Diff(want, checkSpecs(modelMap)); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestPostSpecCheckStatus(t *testing.T) {
+	owner, repo, commitSHA = "o", "r", "abc"
+	defer func() { owner, repo, commitSHA = "", "", "" }()
+
+	tests := []struct {
+		name       string
+		inProblems []string
+		want       string
+	}{{
+		name: "pass",
+		want: "dry run: would post success status \"Spec Check\" to o/r@abc: Spec Check Passed\n",
+	}, {
+		name:       "problems",
+		inProblems: []string{"acl/.spec.yml:6: missing model name", "empty/.spec.yml: no models defined"},
+		want: `dry run: would create gist "Spec Check":
+The .spec.yml files of commit abc have the following problems:
+
+- acl/.spec.yml:6: missing model name
+- empty/.spec.yml: no models defined
+dry run: would post failure status "Spec Check" to o/r@abc: Spec Check Failed: 2 problem(s) in .spec.yml files
+`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := postSpecCheckStatus(dryRunGitHub{w: &b}, tt.inProblems); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadBanner(t *testing.T) {
+	dir := t.TempDir()
+	repoBannerFile := filepath.Join(dir, "repo-banner.md")
+	if err := os.WriteFile(repoBannerFile, []byte("repo banner\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	configBannerFile := filepath.Join(dir, "config-banner.md")
+	if err := os.WriteFile(configBannerFile, []byte("\nconfig banner\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		inBannerFile     string
+		inRepoBannerFile string
+		want             string
+		wantErr          bool
+	}{{
+		name:             "repo banner",
+		inRepoBannerFile: repoBannerFile,
+		want:             "repo banner",
+	}, {
+		name:             "CI config banner overrides repo banner",
+		inBannerFile:     configBannerFile,
+		inRepoBannerFile: repoBannerFile,
+		want:             "config banner",
+	}, {
+		name:             "no banner",
+		inRepoBannerFile: filepath.Join(dir, "dne.md"),
+		want:             "",
+	}, {
+		name:             "CI config banner doesn't exist",
+		inBannerFile:     filepath.Join(dir, "dne.md"),
+		inRepoBannerFile: repoBannerFile,
+		wantErr:          true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBanner(tt.inBannerFile, tt.inRepoBannerFile)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRequiredStatusPolicy(t *testing.T) {
+	compatReport, err := commonci.NewCompatReport("pyangbind,pyang@head", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                 string
+		inRequiredValidators string
+		inSkippedValidators  string
+		inPyangMatrix        bool
+		inRollouts           commonci.ReportOnlyRollouts
+		want                 []commonci.ValidatorAndVersion
+		wantErr              bool
+	}{{
+		name:                "defaults to widely-used validators and misc-checks",
+		inSkippedValidators: "confd,ygnmi,yangson",
+		want: []commonci.ValidatorAndVersion{
+			{ValidatorId: "goyang-ygot"},
+			{ValidatorId: "misc-checks"},
+			{ValidatorId: "oc-pyang"},
+			{ValidatorId: "pyang"},
+			{ValidatorId: "yanglint"},
+		},
+	}, {
+		name:                 "explicit validators",
+		inRequiredValidators: "pyang,regexp",
+		want: []commonci.ValidatorAndVersion{
+			{ValidatorId: "pyang"},
+			{ValidatorId: "regexp"},
+		},
+	}, {
+		name:                 "unrecognized validator",
+		inRequiredValidators: "pyang,foo",
+		wantErr:              true,
+	}, {
+		name:                 "report-only validator",
+		inRequiredValidators: "compat-report",
+		wantErr:              true,
+	}, {
+		name:                 "skipped validator",
+		inRequiredValidators: "confd",
+		inSkippedValidators:  "confd",
+		wantErr:              true,
+	}, {
+		name:                 "validator in compatibility report",
+		inRequiredValidators: "pyang@head",
+		wantErr:              true,
+	}, {
+		name:                 "pyang version without matrix",
+		inRequiredValidators: "pyang@2.5.3",
+		want: []commonci.ValidatorAndVersion{
+			{ValidatorId: "pyang", Version: "2.5.3"},
+		},
+	}, {
+		name:                 "pyang version in matrix",
+		inRequiredValidators: "pyang@2.5.3",
+		inPyangMatrix:        true,
+		wantErr:              true,
+	}, {
+		name:                 "latest pyang in matrix",
+		inRequiredValidators: "pyang",
+		inPyangMatrix:        true,
+		want: []commonci.ValidatorAndVersion{
+			{ValidatorId: "pyang"},
+		},
+	}, {
+		name:                "defaults exclude validators in report-only rollout",
+		inSkippedValidators: "confd,ygnmi,yangson",
+		inRollouts:          commonci.ReportOnlyRollouts{"goyang-ygot": "", "pyang@3.0": ""},
+		want: []commonci.ValidatorAndVersion{
+			{ValidatorId: "misc-checks"},
+			{ValidatorId: "oc-pyang"},
+			{ValidatorId: "pyang"},
+			{ValidatorId: "yanglint"},
+		},
+	}, {
+		name:                 "validator version in report-only rollout",
+		inRequiredValidators: "pyang,pyang@3.0",
+		inRollouts:           commonci.ReportOnlyRollouts{"pyang@3.0": "2024-12-31"},
+		wantErr:              true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pyangMatrix = tt.inPyangMatrix
+			commonci.ReportOnlyRollout = tt.inRollouts
+			defer func() { pyangMatrix, commonci.ReportOnlyRollout = false, nil }()
+			got, err := newRequiredStatusPolicy(tt.inRequiredValidators, false, tt.inSkippedValidators, compatReport)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got.Validators); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResolveReportOnlyRollouts(t *testing.T) {
+	cfg := &commonci.ValidatorsConfig{
+		Validators: []*commonci.ValidatorConfig{
+			{Id: "goyang-ygot", Rollout: commonci.RolloutReportOnly, RolloutUntil: "2024-06-30"},
+			{Id: "yanglint", Rollout: commonci.RolloutReportOnly},
+		},
+	}
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		inRollouts string
+		inConfig   *commonci.ValidatorsConfig
+		want       commonci.ReportOnlyRollouts
+		wantErr    bool
+	}{{
+		name: "none",
+		want: commonci.ReportOnlyRollouts{},
+	}, {
+		name:       "flag",
+		inRollouts: "pyang@3.0=2024-12-31,yangson=2024-01-31",
+		want:       commonci.ReportOnlyRollouts{"pyang@3.0": "2024-12-31"},
+	}, {
+		name:     "validators config",
+		inConfig: cfg,
+		want:     commonci.ReportOnlyRollouts{"yanglint": ""},
+	}, {
+		name:       "flag overrides validators config",
+		inRollouts: "goyang-ygot=2024-07-31",
+		inConfig:   cfg,
+		want:       commonci.ReportOnlyRollouts{"goyang-ygot": "2024-07-31", "yanglint": ""},
+	}, {
+		name:       "invalid flag",
+		inRollouts: "pyang@3.0=soon",
+		wantErr:    true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveReportOnlyRollouts(tt.inRollouts, tt.inConfig, now)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDryRunGitHub(t *testing.T) {
+	owner, repo, commitSHA = "o", "r", "abc"
+	defer func() { owner, repo, commitSHA = "", "", "" }()
+
+	var b strings.Builder
+	g := dryRunGitHub{w: &b}
+	labels, err := g.ListPRLabels("o", "r", 1)
+	if err != nil || labels != nil {
+		t.Errorf("ListPRLabels: got (%v, %v), want no labels", labels, err)
+	}
+	if branch, err := g.DefaultBranch("o", "r"); err != nil || branch != "master" {
+		t.Errorf("DefaultBranch: got (%q, %v), want master", branch, err)
+	}
+	if err := g.PostLabels([]commonci.PRLabel{{Name: "skipped: wifi:mac", Color: commonci.LabelColors["orange"]}}, "o", "r", 1); err != nil {
+		t.Error(err)
+	}
+	if err := postInitialStatus(g, "pyang", "head"); err != nil {
+		t.Error(err)
+	}
+
+	want := `dry run: not reading the control labels of o/r#1
+dry run: not reading the default branch of o/r, assuming master
+dry run: would apply label "skipped: wifi:mac" (colour ffa500) to o/r#1
+dry run: would post pending status "pyang@head" to o/r@abc: pyang@head Running
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+// fakeFileContents is a githubClient returning the contents of files on the
+// given ref, and failing otherwise.
+type fakeFileContents struct {
+	githubClient
+	ref   string
+	files map[string]string
+}
+
+func (f fakeFileContents) FileContents(owner, repo, ref, path string) ([]byte, error) {
+	if owner != "o" || repo != "r" || ref != f.ref {
+		return nil, fmt.Errorf("unexpected read of %s/%s@%s", owner, repo, ref)
+	}
+	if contents, ok := f.files[path]; ok {
+		return []byte(contents), nil
+	}
+	return nil, nil
+}
+
+func TestReadValidatorsConfig(t *testing.T) {
+	const config = `validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "#!/bin/bash"
+`
+	tests := []struct {
+		desc    string
+		inFiles map[string]string
+		wantIds []string
+		wantErr bool
+	}{{
+		desc:    "base branch config",
+		inFiles: map[string]string{commonci.ValidatorsConfigFileName: config},
+		wantIds: []string{"my-linter"},
+	}, {
+		desc: "no config",
+	}, {
+		desc:    "invalid config",
+		inFiles: map[string]string{commonci.ValidatorsConfigFileName: "validators: {"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := readValidatorsConfig(baseBranchConfigReader(fakeFileContents{ref: "master", files: tt.inFiles}, "o", "r", "master"))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			var gotIds []string
+			if got != nil {
+				for _, v := range got.Validators {
+					gotIds = append(gotIds, v.Id)
+				}
+			}
+			if diff := cmp.Diff(tt.wantIds, gotIds); diff != "" {
+				t.Errorf("validators (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadDisabledDirs(t *testing.T) {
+	tests := []struct {
+		desc    string
+		inFiles map[string]string
+		want    map[string]bool
+		wantErr bool
+	}{{
+		desc:    "base branch disabled dirs",
+		inFiles: map[string]string{commonci.DisabledDirsFileName: "disabled-dirs: [wifi/mac]\n"},
+		want:    map[string]bool{"wifi:mac": true},
+	}, {
+		desc: "no disabled dirs file",
+	}, {
+		desc:    "invalid disabled dirs file",
+		inFiles: map[string]string{commonci.DisabledDirsFileName: "disabled-dirs: {"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := readDisabledDirs(baseBranchConfigReader(fakeFileContents{ref: "master", files: tt.inFiles}, "o", "r", "master"))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadValidatorVersions(t *testing.T) {
+	tests := []struct {
+		desc    string
+		inFiles map[string]string
+		want    map[string][]string
+		wantErr bool
+	}{{
+		desc:    "base branch pinned versions",
+		inFiles: map[string]string{commonci.ValidatorVersionsFileName: "versions: {pyang: [head]}\n"},
+		want:    map[string][]string{"pyang": {"head"}},
+	}, {
+		desc: "no validator versions file",
+	}, {
+		desc:    "invalid validator versions file",
+		inFiles: map[string]string{commonci.ValidatorVersionsFileName: "versions: {pyang: [not-a-version]}\n"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := readValidatorVersions(baseBranchConfigReader(fakeFileContents{ref: "master", files: tt.inFiles}, "o", "r", "master"))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConfdSubstitute(t *testing.T) {
+	tests := []struct {
+		name         string
+		inSubstitute string
+		wantSkipped  string
+		wantErr      bool
+	}{{
+		name:        "no substitute",
+		wantSkipped: "yangson",
+	}, {
+		name:         "yangson",
+		inSubstitute: "yangson",
+		wantSkipped:  "confd",
+	}, {
+		name:         "not a substitute",
+		inSubstitute: "pyang",
+		wantErr:      true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := confdSubstituteSkipped(tt.inSubstitute)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.wantSkipped {
+				t.Errorf("got %q, want %q", got, tt.wantSkipped)
+			}
+		})
+	}
+
+	if got, want := replaceValidator("pyang@head,confd,confd-like", "confd", "yangson"), "pyang@head,yangson,confd-like"; got != want {
+		t.Errorf("replaceValidator: got %q, want %q", got, want)
+	}
+	if got := replaceValidator("", "confd", "yangson"); got != "" {
+		t.Errorf("replaceValidator of empty list: got %q, want empty", got)
+	}
+}
+
+func TestApplyControlLabels(t *testing.T) {
+	tests := []struct {
+		name                string
+		inLabels            []string
+		inSkipped           string
+		inSubstituteSkipped string
+		inCompat            string
+		inRequired          string
+		wantSkipped         string
+		wantCompat          string
+		wantCondensedReport bool
+		wantFullRun         bool
+	}{{
+		name:        "no control labels",
+		inLabels:    []string{"breaking", "skip-confd"},
+		inSkipped:   "yanglint",
+		inCompat:    "pyangbind",
+		wantSkipped: "yanglint",
+		wantCompat:  "pyangbind",
+	}, {
+		name:        "skip and compat labels",
+		inLabels:    []string{"ci:skip-confd", "ci:skip-pyang@head", "ci:compat-goyang-ygot"},
+		inSkipped:   "yanglint",
+		inCompat:    "pyangbind",
+		wantSkipped: "yanglint,confd,pyang@head",
+		wantCompat:  "pyangbind,goyang-ygot",
+	}, {
+		name:        "full matrix keeps skip labels",
+		inLabels:    []string{"ci:skip-confd", "ci:full-matrix"},
+		inSkipped:   "yanglint,ygnmi",
+		wantSkipped: "confd",
+	}, {
+		name:        "full run",
+		inLabels:    []string{"ci:skip-confd", "ci:full"},
+		inSkipped:   "yanglint,ygnmi",
+		wantSkipped: "confd",
+		wantFullRun: true,
+	}, {
+		name:                "full matrix keeps ConfD skipped for its substitute",
+		inLabels:            []string{"ci:full-matrix"},
+		inSkipped:           "yanglint",
+		inSubstituteSkipped: "confd",
+		wantSkipped:         "confd",
+	}, {
+		name:                "full run keeps unselected substitutes skipped",
+		inLabels:            []string{"ci:skip-pyang@head", "ci:full"},
+		inSkipped:           "yanglint",
+		inSubstituteSkipped: "yangson",
+		wantSkipped:         "yangson,pyang@head",
+		wantFullRun:         true,
+	}, {
+		name:                "substitute skips without labels",
+		inSkipped:           "yanglint",
+		inSubstituteSkipped: "yangson",
+		wantSkipped:         "yanglint,yangson",
+	}, {
+		name:                "condensed report",
+		inLabels:            []string{"ci:condensed-report"},
+		wantCondensedReport: true,
+	}, {
+		name:     "unrecognized control labels",
+		inLabels: []string{"ci:skip-foo", "ci:compat-compat-report", "ci:bar"},
+	}, {
+		name:        "labels of required validators ignored",
+		inLabels:    []string{"ci:skip-pyang", "ci:skip-pyang@2.6.1", "ci:compat-misc-checks", "ci:compat-confd"},
+		inRequired:  "pyang,misc-checks",
+		wantSkipped: "pyang@2.6.1",
+		wantCompat:  "confd",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSkipped, gotCompat, gotCondensedReport, gotFullRun := applyControlLabels(tt.inLabels, tt.inSkipped, tt.inSubstituteSkipped, tt.inCompat, tt.inRequired)
+			if gotSkipped != tt.wantSkipped {
+				t.Errorf("skipped: got %q, want %q", gotSkipped, tt.wantSkipped)
+			}
+			if gotCompat != tt.wantCompat {
+				t.Errorf("compat: got %q, want %q", gotCompat, tt.wantCompat)
+			}
+			if gotCondensedReport != tt.wantCondensedReport {
+				t.Errorf("condensed report: got %v, want %v", gotCondensedReport, tt.wantCondensedReport)
+			}
+			if gotFullRun != tt.wantFullRun {
+				t.Errorf("full run: got %v, want %v", gotFullRun, tt.wantFullRun)
+			}
+		})
+	}
+}
+
+func TestGenFixture(t *testing.T) {
+	modelRoot := filepath.Join(t.TempDir(), "yang")
+	for path, content := range map[string]string{
+		"acl/.spec.yml": `- name: openconfig-acl
+  build:
+    - yang/acl/openconfig-acl.yang
+  run-ci: true
+`,
+		"acl/openconfig-acl.yang": "fail",
+		"optical-transport/.spec.yml": `- name: openconfig-optical-amplifier
+  build:
+    - yang/optical-transport/openconfig-optical-amplifier.yang
+  run-ci: true
+`,
+		"optical-transport/openconfig-optical-amplifier.yang": "pass",
+	} {
+		path = filepath.Join(modelRoot, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The fake tool fails on files containing "fail", reporting the file.
+	tool := filepath.Join(t.TempDir(), "fake-pyang")
+	if err := os.WriteFile(tool, []byte(`#!/bin/bash
+file="${@: -1}"
+echo "$file:1: $(cat "$file")"
+! grep -q fail "$file"
+`), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	resultsDir := filepath.Join(t.TempDir(), "pyang-fixture")
+	if err := genFixture("pyang", []string{tool}, resultsDir, modelRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]string{}
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		bs, err := os.ReadFile(filepath.Join(resultsDir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[entry.Name()] = string(bs)
+	}
+
+	want := map[string]string{
+		"acl==openconfig-acl==cmd":                              "pyang -W error -p /workspace/release/yang -p /workspace/third_party/ietf /workspace/release/yang/acl/openconfig-acl.yang\n",
+		"acl==openconfig-acl==fail":                             "/workspace/release/yang/acl/openconfig-acl.yang:1: fail\n",
+		"optical-transport==openconfig-optical-amplifier==cmd":  "pyang -W error -p /workspace/release/yang -p /workspace/third_party/ietf /workspace/release/yang/optical-transport/openconfig-optical-amplifier.yang\n",
+		"optical-transport==openconfig-optical-amplifier==pass": "/workspace/release/yang/optical-transport/openconfig-optical-amplifier.yang:1: pass\n",
+		"out": "",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestGenBazelTargets(t *testing.T) {
+	pkgDir := t.TempDir()
+	modelRoot := filepath.Join(pkgDir, "release", "yang")
+	for path, content := range map[string]string{
+		"acl/.spec.yml": `- name: openconfig-acl
+  build:
+    - yang/acl/openconfig-acl.yang
+  run-ci: true
+`,
+		"acl/openconfig-acl.yang": "fail",
+		"optical-transport/.spec.yml": `- name: openconfig-optical-amplifier
+  build:
+    - yang/optical-transport/openconfig-optical-amplifier.yang
+  run-ci: true
+`,
+		"optical-transport/openconfig-optical-amplifier.yang": "pass",
+	} {
+		path = filepath.Join(modelRoot, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := relBazelModelMap(modelRoot, filepath.Join(pkgDir, "release", "other")); err == nil {
+		t.Errorf("got no error for models outside of the Bazel package")
+	}
+	modelMap, err := relBazelModelMap(modelRoot, pkgDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake tool fails on files containing "fail".
+	files, err := genBazelTargets([]string{"pyang"}, []string{"./fake-pyang"}, modelMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gotFiles []string
+	for name := range files {
+		gotFiles = append(gotFiles, name)
+	}
+	wantFiles := []string{
+		"BUILD.bazel",
+		"ci-scripts/pyang.acl.openconfig-acl.sh",
+		"ci-scripts/pyang.optical-transport.openconfig-optical-amplifier.sh",
+	}
+	if diff := cmp.Diff(wantFiles, gotFiles, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Errorf("files (-want, +got):\n%s", diff)
+	}
+
+	wantBuild := `# Code generated by cmd_gen. DO NOT EDIT.
+
+filegroup(
+    name = "yang_files",
+    srcs = glob(
+        [
+            "release/yang/**/*.yang",
+            "third_party/ietf/**/*.yang",
+        ],
+        allow_empty = True,
+    ),
+)
+
+sh_test(
+    name = "pyang.acl.openconfig-acl",
+    srcs = ["ci-scripts/pyang.acl.openconfig-acl.sh"],
+    args = [
+        "./fake-pyang",
+    ],
+    data = [":yang_files"],
+)
+
+sh_test(
+    name = "pyang.optical-transport.openconfig-optical-amplifier",
+    srcs = ["ci-scripts/pyang.optical-transport.openconfig-optical-amplifier.sh"],
+    args = [
+        "./fake-pyang",
+    ],
+    data = [":yang_files"],
+)
+
+test_suite(
+    name = "pyang",
+    tests = [
+        ":pyang.acl.openconfig-acl",
+        ":pyang.optical-transport.openconfig-optical-amplifier",
+    ],
+)
+`
+	if diff := cmp.Diff(strings.Split(wantBuild, "\n"), strings.Split(files["BUILD.bazel"], "\n")); diff != "" {
+		t.Errorf("BUILD file (-want, +got):\n%s", diff)
+	}
+
+	if err := writeBazelTargets(pkgDir, files); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkgDir, "fake-pyang"), []byte(`#!/bin/bash
+! grep -q fail "${@: -1}"
+`), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Run each test as Bazel would, from the package directory.
+	for script, wantPass := range map[string]bool{
+		"ci-scripts/pyang.acl.openconfig-acl.sh":                             false,
+		"ci-scripts/pyang.optical-transport.openconfig-optical-amplifier.sh": true,
+	} {
+		cmd := exec.Command("bash", script, "./fake-pyang")
+		cmd.Dir = pkgDir
+		cmd.Env = append(os.Environ(), "TEST_UNDECLARED_OUTPUTS_DIR="+t.TempDir())
+		if err := cmd.Run(); (err == nil) != wantPass {
+			t.Errorf("%s: got error %v, want pass %v", script, err, wantPass)
+		}
+	}
+}
+
+func TestWithResultCache(t *testing.T) {
+	const inputsKey = "inputs"
+	toolVersion := []byte("pyang 2.6.0\n")
+
+	// The bucket is emulated by a directory read by a fake gsutil.
+	bucketDir := t.TempDir()
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, "gsutil"), []byte(`#!/bin/bash
+# Usage: gsutil -q cp gs://<bucket>/<object> <file>
+cp "$BUCKET_DIR/${3#gs://*/}" "$4"
+`), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cachedDir := t.TempDir()
+	for name, content := range map[string]string{
+		"out":                       "cached out\n",
+		"fail":                      "cached fail\n",
+		"acl==openconfig-acl==fail": "",
+	} {
+		if err := os.WriteFile(filepath.Join(cachedDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archive, err := commonci.ArchiveResults(cachedDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := filepath.Join(bucketDir, commonci.ResultCacheObject(commonci.ResultCacheKey(inputsKey, toolVersion)))
+	if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(object, archive, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		desc         string
+		inInputsKey  string
+		wantStdout   string
+		wantStderr   string
+		wantErr      bool
+		wantRestored bool
+	}{{
+		desc:         "cached",
+		inInputsKey:  inputsKey,
+		wantStdout:   "cached out\n",
+		wantStderr:   "cached fail\n",
+		wantErr:      true,
+		wantRestored: true,
+	}, {
+		desc:        "not cached",
+		inInputsKey: "other",
+		wantStdout:  "ran\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			resultsDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(resultsDir, commonci.LatestVersionFileName), toolVersion, 0644); err != nil {
+				t.Fatal(err)
+			}
+			script := withResultCache("#!/bin/bash\necho ran\n", tt.inInputsKey, resultsDir)
+			if !strings.HasPrefix(script, "#!/bin/bash\nresult_cache_key=") {
+				t.Errorf("result cache header not inserted after the shebang:\n%s", script)
+			}
+
+			var stdout, stderr strings.Builder
+			cmd := exec.Command("bash", "-c", script)
+			cmd.Env = append(os.Environ(), "PATH="+binDir+":"+os.Getenv("PATH"), "BUCKET_DIR="+bucketDir)
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if stdout.String() != tt.wantStdout || stderr.String() != tt.wantStderr {
+				t.Errorf("got stdout %q and stderr %q, want %q and %q", stdout.String(), stderr.String(), tt.wantStdout, tt.wantStderr)
+			}
+			for _, name := range []string{"acl==openconfig-acl==fail", commonci.ResultCacheHitFileName} {
+				if _, err := os.Stat(filepath.Join(resultsDir, name)); (err == nil) != tt.wantRestored {
+					t.Errorf("%s: got stat error %v, want restored %v", name, err, tt.wantRestored)
+				}
+			}
+		})
+	}
+}
+
+func TestBazelValidatorIds(t *testing.T) {
+	got, err := bazelValidatorIds("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"confd", "gnmi-paths", "goyang-ygot", "oc-pyang", "pyang", "pyangbind", "tree-diff", "yang-examples", "yanglint", "yangson", "ygnmi", "ygot-proto"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("all validators (-want, +got):\n%s", diff)
+	}
+
+	for _, validatorIds := range []string{"pyang,foo", "misc-checks", "regexp"} {
+		if _, err := bazelValidatorIds(validatorIds); err == nil {
+			t.Errorf("%q: got no error", validatorIds)
+		}
+	}
+}
+
+func TestGenGithubActionsMatrix(t *testing.T) {
+	disabledModelPaths = nil
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vvs := []commonci.ValidatorAndVersion{{ValidatorId: "misc-checks"}, {ValidatorId: "pyang", Version: "head"}}
+
+	tests := []struct {
+		name     string
+		inShards int
+		want     []githubActionsJob
+		wantErr  bool
+	}{{
+		name:     "single shard",
+		inShards: 1,
+		want: []githubActionsJob{
+			{Validator: "misc-checks", Name: "misc-checks"},
+			{Validator: "pyang", Version: "head", Name: "pyang@head", Shard: 1, ModelDirs: "acl,optical-transport"},
+		},
+	}, {
+		name:     "more shards than model directories",
+		inShards: 3,
+		want: []githubActionsJob{
+			{Validator: "misc-checks", Name: "misc-checks"},
+			{Validator: "pyang", Version: "head", Name: "pyang@head", Shard: 1, ModelDirs: "acl"},
+			{Validator: "pyang", Version: "head", Name: "pyang@head", Shard: 2, ModelDirs: "optical-transport"},
+		},
+	}, {
+		name:     "invalid shards",
+		inShards: 0,
+		wantErr:  true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := genGithubActionsMatrix(vvs, tt.inShards, modelMap)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got.Include); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseExtraVersions(t *testing.T) {
+	tests := []struct {
+		desc                 string
+		inExtraVersions      string
+		inExtraPyangVersions string
+		want                 map[string][]string
+		wantErr              bool
+	}{{
+		desc: "none",
+		want: map[string][]string{},
+	}, {
+		desc:                 "extra versions of multiple validators",
+		inExtraVersions:      "yanglint@2.1.148,confd@8.0,pyang@2.5.3",
+		inExtraPyangVersions: "2.5.2",
+		want:                 map[string][]string{"yanglint": {"2.1.148"}, "confd": {"8.0"}, "pyang": {"2.5.3", "2.5.2"}},
+	}, {
+		desc:                 "unsupported extra pyang version",
+		inExtraPyangVersions: "1.7.8",
+		wantErr:              true,
+	}, {
+		desc:            "version below validator's supported version",
+		inExtraVersions: "confd@7.1",
+		wantErr:         true,
+	}, {
+		desc:                 "same version given by both flags",
+		inExtraVersions:      "pyang@2.5.3",
+		inExtraPyangVersions: "2.5.3",
+		wantErr:              true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := parseExtraVersions(tt.inExtraVersions, tt.inExtraPyangVersions)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGithubActionsValidators(t *testing.T) {
+	tests := []struct {
+		desc      string
+		inSkipped string
+		inExtra   map[string][]string
+		inPinned  map[string][]string
+		want      []commonci.ValidatorAndVersion
+		notWant   []commonci.ValidatorAndVersion
+	}{{
+		desc:      "extra pyang versions",
+		inSkipped: "pyang,yanglint",
+		inExtra:   map[string][]string{"pyang": {"2.5.3"}},
+		want:      []commonci.ValidatorAndVersion{{ValidatorId: "pyang", Version: "2.5.3"}, {ValidatorId: "pyang", Version: "head"}, {ValidatorId: "misc-checks"}},
+		// Skipped validators and those without scripts aren't run.
+		notWant: []commonci.ValidatorAndVersion{{ValidatorId: "pyang"}, {ValidatorId: "yanglint"}, {ValidatorId: "regexp"}, {ValidatorId: "compat-report"}},
+	}, {
+		desc:    "extra versions of other validators",
+		inExtra: map[string][]string{"yanglint": {"2.1.148"}, "confd": {"8.0"}, "pyang": {"head"}},
+		want:    []commonci.ValidatorAndVersion{{ValidatorId: "yanglint"}, {ValidatorId: "yanglint", Version: "2.1.148"}, {ValidatorId: "confd"}, {ValidatorId: "confd", Version: "8.0"}, {ValidatorId: "pyang", Version: "head"}},
+	}, {
+		desc:     "pinned versions override extra versions",
+		inExtra:  map[string][]string{"pyang": {"2.5.3"}, "yanglint": {"2.1.148"}},
+		inPinned: map[string][]string{"pyang": {"2.5.2"}, "yanglint": {"2.1.111"}},
+		want:     []commonci.ValidatorAndVersion{{ValidatorId: "pyang"}, {ValidatorId: "pyang", Version: "2.5.2"}, {ValidatorId: "yanglint"}, {ValidatorId: "yanglint", Version: "2.1.111"}},
+		notWant:  []commonci.ValidatorAndVersion{{ValidatorId: "pyang", Version: "2.5.3"}, {ValidatorId: "pyang", Version: "head"}, {ValidatorId: "yanglint", Version: "2.1.148"}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := githubActionsValidators(tt.inSkipped, tt.inExtra, tt.inPinned)
+			gotMap := map[string]map[string]bool{}
+			for _, vv := range got {
+				if gotMap[vv.ValidatorId] == nil {
+					gotMap[vv.ValidatorId] = map[string]bool{}
+				}
+				if gotMap[vv.ValidatorId][vv.Version] {
+					t.Errorf("got %v more than once", vv)
+				}
+				gotMap[vv.ValidatorId][vv.Version] = true
+			}
+			for _, want := range tt.want {
+				if !gotMap[want.ValidatorId][want.Version] {
+					t.Errorf("missing %v", want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if gotMap[notWant.ValidatorId][notWant.Version] {
+					t.Errorf("got unexpected %v", notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestGenGithubActionsScript(t *testing.T) {
+	disabledModelPaths = nil
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, gotModelCount, err := genGithubActionsScript("yanglint", "", "acl", "/github/workspace", "/tmp/results", modelMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantCmd := `#!/bin/bash
+workdir=/tmp/results
+mkdir -p "$workdir"
+` + wantModelFunctions + `cmd="yanglint"
+options=(
+  -p testdata
+  -p /github/workspace/third_party/ietf
+)
+script_options=(
+)
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  shift 2
+  echo $cmd "${options[@]}" "${model_args[@]}" "$@" > ${prefix}cmd
+  local status=0
+  for file in "$@"; do
+    echo "yanglint-file: $file" >> ${prefix}pass
+  done
+  timed $cmd "${options[@]}" "${script_options[@]}" "${model_args[@]}" "$@" &>> ${prefix}pass || status=1
+  finish-model "$prefix" $status
+}
+wait-for-slot 8
+run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang &
+wait
+`
+	if diff := cmp.Diff(strings.Split(wantCmd, "\n"), strings.Split(got, "\n")); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+	if gotModelCount != 1 {
+		t.Errorf("got model count %d, want 1", gotModelCount)
+	}
+
+	if _, _, err := genGithubActionsScript("yanglint", "", "acl,bgp", "/github/workspace", "/tmp/results", modelMap); err == nil {
+		t.Errorf("got no error for unrecognized model directory")
+	}
+}
+
+func TestAffectedModelDirs(t *testing.T) {
+	repoRoot := t.TempDir()
+	modelRoot := filepath.Join(repoRoot, "release", "models")
+	for path, content := range map[string]string{
+		"release/models/a/.spec.yml": `- name: openconfig-a
+  build:
+    - yang/a/openconfig-a.yang
+  run-ci: true
+`,
+		"release/models/a/openconfig-a.yang": `module openconfig-a {
+  import openconfig-b-types { prefix oc-b-types; }
+  include openconfig-a-sub;
+}`,
+		"release/models/a/openconfig-a-sub.yang": `submodule openconfig-a-sub {
+  belongs-to openconfig-a { prefix oc-a; }
+}`,
+		"release/models/b/.spec.yml": `- name: openconfig-b
+  build:
+    - yang/b/openconfig-b.yang
+  run-ci: true
+`,
+		"release/models/b/openconfig-b.yang": `module openconfig-b {
+  import openconfig-b-types { prefix oc-b-types; }
+}`,
+		"release/models/b/openconfig-b-types.yang": `module openconfig-b-types {
+}`,
+		"release/models/c/.spec.yml": `- name: openconfig-c
+  build:
+    - yang/c/openconfig-c.yang
+  examples: yang/c/examples
+  run-ci: true
+`,
+		"release/models/c/openconfig-c.yang": `module openconfig-c {
+  import "ietf-x" { prefix x; }
+}`,
+		"third_party/ietf/ietf-x.yang": `module ietf-x {
+}`,
+	} {
+		path = filepath.Join(repoRoot, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modelMap, err := commonci.ParseOCModels(modelRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		inChangedFiles []string
+		want           map[string]bool
+	}{{
+		name:           "no model changes",
+		inChangedFiles: []string{"README.md"},
+		want:           map[string]bool{},
+	}, {
+		name:           "directly changed build file",
+		inChangedFiles: []string{"release/models/b/openconfig-b.yang"},
+		want:           map[string]bool{"b": true},
+	}, {
+		name:           "imported module",
+		inChangedFiles: []string{"release/models/b/openconfig-b-types.yang"},
+		want:           map[string]bool{"a": true, "b": true},
+	}, {
+		name:           "included submodule",
+		inChangedFiles: []string{"release/models/a/openconfig-a-sub.yang"},
+		want:           map[string]bool{"a": true},
+	}, {
+		name:           "third_party module",
+		inChangedFiles: []string{"third_party/ietf/ietf-x.yang"},
+		want:           map[string]bool{"c": true},
+	}, {
+		name:           "spec file",
+		inChangedFiles: []string{"release/models/c/.spec.yml"},
+		want:           map[string]bool{"c": true},
+	}, {
+		name:           "example instance document",
+		inChangedFiles: []string{"release/models/c/examples/c.json"},
+		want:           map[string]bool{"c": true},
+	}, {
+		name:           "validators config affects all",
+		inChangedFiles: []string{"release/models/c/.spec.yml", ".ci-validators.yml"},
+		want:           nil,
+	}, {
+		name:           "disabled directories affect all",
+		inChangedFiles: []string{".ci-disabled-dirs.yml"},
+		want:           nil,
+	}, {
+		name:           "validator versions affect all",
+		inChangedFiles: []string{".ci-validator-versions.yml"},
+		want:           nil,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := affectedModelDirs(modelMap, tt.inChangedFiles, repoRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+
+	filtered, gotRemoved := filterModelMap(modelMap, map[string]bool{"b": true})
+	if diff := cmp.Diff([]string{"a", "c"}, gotRemoved); diff != "" {
+		t.Errorf("removed model directories (-want, +got):\n%s", diff)
+	}
+	if _, ok := filtered.ModelInfoMap["b"]; !ok || len(filtered.ModelInfoMap) != 1 {
+		t.Errorf("got filtered model directories %v, want only b", filtered.ModelInfoMap)
+	}
+}
+
+func TestDockerRunArgs(t *testing.T) {
+	modelRoot = "release/models"
+	defer func() { modelRoot = "" }()
+	got := dockerRunArgs("models-ci-image", "1000:1000", "/src/public", "/tmp/results", "/src/models-ci", "pyang", "head")
+	want := []string{
+		"run", "--rm",
+		"--user", "1000:1000",
+		"-e", "HOME=/tmp",
+		"-e", "_MODEL_ROOT=release/models",
+		"-v", "/src/public:/workspace",
+		"-v", "/tmp/results:/workspace/results/pyang@head",
+		"-v", "/src/models-ci:/go/src/github.com/openconfig/models-ci:ro",
+		"-w", "/workspace",
+		"models-ci-image",
+		"bash", "/go/src/github.com/openconfig/models-ci/validators/pyang/test.sh",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestRunLocalDocker(t *testing.T) {
+	disabledModelPaths = nil
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fake docker records its arguments, and writes a model result
+	// into the results directory mounted for pyang as its test.sh would.
+	binDir, argsFile := t.TempDir(), filepath.Join(t.TempDir(), "args")
+	if err := os.WriteFile(filepath.Join(binDir, "docker"), []byte(`#!/bin/bash
+echo "$@" > `+argsFile+`
+while [[ $# -gt 0 ]]; do
+  if [[ $1 == -v && ${2#*:} == /workspace/results/pyang ]]; then
+    touch "${2%%:*}/acl==openconfig-acl==pass"
+  fi
+  shift
+done
+`), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+":"+os.Getenv("PATH"))
+
+	modelsCIDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(modelsCIDir, "validators", "pyang"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modelsCIDir, "validators", "pyang", "test.sh"), nil, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	resultsDir := t.TempDir()
+	localValidatorId, localModelDirName, localResultsDir, localModelsCIDir = "pyang", "acl", resultsDir, modelsCIDir
+	defer func() { localValidatorId, localModelDirName, localResultsDir, localModelsCIDir = "", "", "", "" }()
+	if err := runLocalDocker("models-ci-image", modelMap); err != nil {
+		t.Fatal(err)
+	}
+	got, err := localModelResults(resultsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"acl==openconfig-acl==pass"}, got); diff != "" {
+		t.Errorf("model results (-want, +got):\n%s", diff)
+	}
+	repoRoot, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotArgs, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantArgs := strings.Join(dockerRunArgs("models-ci-image", fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()), repoRoot, resultsDir, modelsCIDir, "pyang", ""), " ") + "\n"
+	if diff := cmp.Diff(wantArgs, string(gotArgs)); diff != "" {
+		t.Errorf("docker arguments (-want, +got):\n%s", diff)
+	}
+	// The script writes its results where the results directory is
+	// mounted within the container.
+	script, err := os.ReadFile(filepath.Join(resultsDir, commonci.ScriptFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(script), "workdir=/workspace/results/pyang\n") || strings.Contains(string(script), resultsDir) {
+		t.Errorf("script doesn't write its results into /workspace/results/pyang:\n%s", script)
+	}
+
+	localValidatorId = "oc-pyang"
+	if err := runLocalDocker("models-ci-image", modelMap); err == nil {
+		t.Errorf("got no error for validator without test.sh")
+	}
+
+	localValidatorId, localModelDirName = "pyang", "bgp"
+	if err := runLocalDocker("models-ci-image", modelMap); err == nil {
+		t.Errorf("got no error for unrecognized model directory")
+	}
+}
+
+func TestGenListing(t *testing.T) {
+	disabledModelPaths = map[string]bool{"optical-transport": true}
+	defer func() { disabledModelPaths = nil }()
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compatReport, err := commonci.NewCompatReport("yanglint", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := genListing("confd,gnmi-paths,goyang-ygot,misc-checks,oc-pyang,pyang@head,pyangbind,regexp,tree-diff,yang-examples,ygnmi,ygot-proto,yangson", map[string][]string{"pyang": {"2.5.3"}}, nil, compatReport, modelMap)
+	wantValidators := []listedValidator{{
+		ValidatorId: "pyang",
+		Name:        "pyang",
+		IsPerModel:  true,
+		Script:      true,
+	}, {
+		ValidatorId: "pyang",
+		Version:     "2.5.3",
+		Name:        "pyang@2.5.3",
+		IsPerModel:  true,
+		Script:      true,
+	}, {
+		ValidatorId:  "yanglint",
+		Name:         "yanglint",
+		IsPerModel:   true,
+		CompatReport: true,
+		Script:       true,
+	}}
+	if diff := cmp.Diff(wantValidators, got.Validators); diff != "" {
+		t.Errorf("validators (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"optical-transport"}, got.DisabledModelDirs); diff != "" {
+		t.Errorf("disabled model directories (-want, +got):\n%s", diff)
+	}
+	wantACL := []listedModel{{
+		Name:        "openconfig-acl",
+		BuildFiles:  []string{"testdata/acl/openconfig-acl.yang", "testdata/acl/openconfig-acl-evil-twin.yang"},
+		DocFiles:    []string{"yang/acl/openconfig-packet-match-types.yang", "yang/acl/openconfig-acl.yang"},
+		RunCi:       true,
+		ExamplesDir: "testdata/acl/examples",
+		SpecFile:    "testdata/acl/.spec.yml",
+	}}
+	if diff := cmp.Diff(wantACL, got.Models["acl"]); diff != "" {
+		t.Errorf("acl models (-want, +got):\n%s", diff)
+	}
+
+	var b strings.Builder
+	if err := writeListing(&b, got, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var decoded listing
+	if err := json.Unmarshal([]byte(b.String()), &decoded); err != nil {
+		t.Fatalf("invalid JSON listing: %v", err)
+	}
+	if diff := cmp.Diff(got.Validators, decoded.Validators); diff != "" {
+		t.Errorf("decoded validators (-want, +got):\n%s", diff)
+	}
+
+	b.Reset()
+	if err := writeListing(&b, got, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "  yanglint (compat-report)\n") || !strings.Contains(b.String(), "  acl: openconfig-acl\n") {
+		t.Errorf("unexpected text listing:\n%s", b.String())
+	}
+	if err := writeListing(&b, got, "yaml"); err == nil {
+		t.Errorf("got no error for unsupported format")
+	}
+}
+
+func TestParseRetries(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		want    map[string]int
+		wantErr bool
+	}{{
+		desc: "none",
+		want: map[string]int{},
+	}, {
+		desc: "multiple validators",
+		in:   "goyang-ygot=2,ygot-proto=0",
+		want: map[string]int{"goyang-ygot": 2, "ygot-proto": 0},
+	}, {
+		desc:    "missing count",
+		in:      "goyang-ygot",
+		wantErr: true,
+	}, {
+		desc:    "unrecognized validator",
+		in:      "foo=1",
+		wantErr: true,
+	}, {
+		desc:    "too many retries",
+		in:      "goyang-ygot=6",
+		wantErr: true,
+	}, {
+		desc:    "negative count",
+		in:      "goyang-ygot=-1",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := parseRetries(tt.in)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModelRetries(t *testing.T) {
+	prNumber = 1
+	disabledModelPaths = nil
+	defer func() { retriesMap = nil }()
+	retriesMap = map[string]int{"pyang": 1, "yanglint": 2}
+	modelMap := commonci.OpenConfigModelMap{
+		ModelRoots: []string{"testdata"},
+		ModelInfoMap: map[string][]commonci.ModelInfo{
+			"acl": {{
+				Name:       "openconfig-acl",
+				BuildFiles: []string{"testdata/acl/openconfig-acl.yang"},
+				RunCi:      true,
+				Retries:    map[string]int{"pyang": 3, "yanglint": 0},
+			}},
+		},
+	}
+
+	tests := []struct {
+		desc            string
+		inValidatorName string
+		wantCommand     string
+		wantRetries     int
+	}{{
+		desc:            "model's retries",
+		inValidatorName: "pyang",
+		wantCommand:     `with-retries 3 run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang &`,
+		wantRetries:     3,
+	}, {
+		desc:            "model disables retries",
+		inValidatorName: "yanglint",
+		wantCommand:     `run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang &`,
+	}, {
+		desc:            "no retries",
+		inValidatorName: "oc-pyang",
+		wantCommand:     `run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang &`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			script, _, err := genOpenConfigValidatorScript(&postLabelRecorder{}, tt.inValidatorName, "", modelMap, modelShard{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(script, "\n"+tt.wantCommand+"\n") {
+				t.Errorf("script doesn't contain command %q:\n%s", tt.wantCommand, script)
+			}
+			plan, _, err := genOpenConfigValidatorPlan(&postLabelRecorder{}, tt.inValidatorName, "", modelMap, modelShard{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := plan.Models[0].Retries; got != tt.wantRetries {
+				t.Errorf("got plan retries %d, want %d", got, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestWithRetries(t *testing.T) {
+	tests := []struct {
+		desc       string
+		inFailures int
+		inRetries  int
+		wantResult string
+		wantRuns   string
+	}{{
+		desc:       "passes after retry",
+		inFailures: 2,
+		inRetries:  2,
+		wantResult: "pass",
+		wantRuns:   "xxx",
+	}, {
+		desc:       "fails after retries",
+		inFailures: 3,
+		inRetries:  2,
+		wantResult: "fail",
+		wantRuns:   "xxx",
+	}, {
+		desc:       "passes without retry",
+		inRetries:  2,
+		wantResult: "pass",
+		wantRuns:   "x",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			workdir := t.TempDir()
+			var header strings.Builder
+			if err := mustTemplate("model-functions", modelFunctions).Execute(&header, &cmdParams{}); err != nil {
+				t.Fatal(err)
+			}
+			// run-dir fails the model until it has run more than
+			// inFailures times, recording each run.
+			script := fmt.Sprintf(`workdir=%s
+%s
+function run-dir() {
+  declare prefix="$workdir"/"$1"=="$2"==
+  local timed_out=0
+  echo -n x >> "$workdir"/runs
+  local status=0
+  echo "run $(cat "$workdir"/runs)" &> ${prefix}pass
+  [[ $(cat "$workdir"/runs | wc -c) -gt %d ]] || status=1
+  finish-model "$prefix" $status
+}
+with-retries %d run-dir acl openconfig-acl
+`, workdir, header.String(), tt.inFailures, tt.inRetries)
+			cmd := exec.Command("bash", "-c", script)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("script failed: %v\n%s", err, out)
+			}
+
+			runs, err := os.ReadFile(filepath.Join(workdir, "runs"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(runs) != tt.wantRuns {
+				t.Errorf("got runs %q, want %q", runs, tt.wantRuns)
+			}
+			result, err := os.ReadFile(filepath.Join(workdir, "acl==openconfig-acl=="+tt.wantResult))
+			if err != nil {
+				t.Fatalf("missing %s result: %v", tt.wantResult, err)
+			}
+			if want := "run " + tt.wantRuns + "\n"; string(result) != want {
+				t.Errorf("got output %q, want only the last run's %q", result, want)
+			}
+			completed, err := os.ReadFile(filepath.Join(workdir, "completed-models"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Count(string(completed), "\n"); got != 1 {
+				t.Errorf("model completed %d times, want once", got)
+			}
+		})
+	}
+}
+
+func TestModelEnv(t *testing.T) {
+	prNumber = 1
+	disabledModelPaths = nil
+	modelMap := commonci.OpenConfigModelMap{
+		ModelRoots: []string{"testdata"},
+		ModelInfoMap: map[string][]commonci.ModelInfo{
+			"acl": {{
+				Name:       "openconfig-acl",
+				BuildFiles: []string{"testdata/acl/openconfig-acl.yang"},
+				RunCi:      true,
+				Env:        map[string]string{"YANGLINT_FEATURES": "all", "MSG": "it's"},
+				ExtraArgs:  map[string][]string{"yanglint": {"-F", "openconfig-acl:*"}},
+			}},
+		},
+	}
+
+	tests := []struct {
+		desc            string
+		inValidatorName string
+		wantCommand     string
+		wantPlanArgs    []string
+	}{{
+		desc:            "env and extra args",
+		inValidatorName: "yanglint",
+		wantCommand:     `MSG='it'\''s' YANGLINT_FEATURES='all' with-args 2 '-F' 'openconfig-acl:*' run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang &`,
+		wantPlanArgs:    []string{"yanglint", "-p", "testdata", "-p", "/workspace/third_party/ietf", "-F", "openconfig-acl:*", "testdata/acl/openconfig-acl.yang"},
+	}, {
+		desc:            "env only",
+		inValidatorName: "pyang",
+		wantCommand:     `MSG='it'\''s' YANGLINT_FEATURES='all' run-dir "acl" "openconfig-acl" testdata/acl/openconfig-acl.yang &`,
+		wantPlanArgs:    []string{"$@", "-W", "error", "-p", "testdata", "-p", "/workspace/third_party/ietf", "--msg-template", util.PyangMsgTemplate, "testdata/acl/openconfig-acl.yang"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			script, _, err := genOpenConfigValidatorScript(&postLabelRecorder{}, tt.inValidatorName, "", modelMap, modelShard{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(script, "\n"+tt.wantCommand+"\n") {
+				t.Errorf("script doesn't contain command %q:\n%s", tt.wantCommand, script)
+			}
+			plan, _, err := genOpenConfigValidatorPlan(&postLabelRecorder{}, tt.inValidatorName, "", modelMap, modelShard{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff([]string{"MSG=it's", "YANGLINT_FEATURES=all"}, plan.Models[0].Env); diff != "" {
+				t.Errorf("plan env (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantPlanArgs, plan.Models[0].Steps[0].Argv); diff != "" {
+				t.Errorf("plan argv (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestModelEnvInvalidName(t *testing.T) {
+	prNumber = 1
+	disabledModelPaths = nil
+	modelMap := commonci.OpenConfigModelMap{
+		ModelRoots: []string{"testdata"},
+		ModelInfoMap: map[string][]commonci.ModelInfo{
+			"acl": {{
+				Name:            "openconfig-acl",
+				BuildFiles:      []string{"testdata/acl/openconfig-acl.yang"},
+				RunCi:           true,
+				InvalidEnvNames: []string{"$(touch pwned);X"},
+			}},
+		},
+	}
+
+	script, _, err := genOpenConfigValidatorScript(&postLabelRecorder{}, "pyang", "", modelMap, modelShard{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(script, `run-dir "acl"`) {
+		t.Errorf("script runs model with invalid environment variable name:\n%s", script)
+	}
+	plan, _, err := genOpenConfigValidatorPlan(&postLabelRecorder{}, "pyang", "", modelMap, modelShard{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Models) != 0 {
+		t.Errorf("plan runs model with invalid environment variable name: %v", plan.Models)
+	}
+
+	// Names not removed by ParseOCModels fail the generation.
+	if _, err := (&cmdParams{ModelName: "openconfig-acl", Env: map[string]string{"$(touch pwned);X": "x"}}).EnvAssignments(); err == nil {
+		t.Errorf("got no error for invalid environment variable name")
+	}
+}
+
+func TestWithArgs(t *testing.T) {
+	var header strings.Builder
+	if err := mustTemplate("model-functions", modelFunctions).Execute(&header, &cmdParams{}); err != nil {
+		t.Fatal(err)
+	}
+	// run-dir prints the model's arguments and environment variable, which
+	// mustn't leak to the next model.
+	script := fmt.Sprintf(`%s
+function run-dir() {
+  echo "$FEATURE" "${model_args[@]}" "$@"
+}
+FEATURE='a b' with-args 2 '-F' 'x y' run-dir acl openconfig-acl
+run-dir aft openconfig-aft
+`, header.String())
+	out, err := exec.Command("bash", "-c", script).CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+	if diff := cmp.Diff("a b -F x y acl openconfig-acl\n aft openconfig-aft\n", string(out)); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestGenPortableScript(t *testing.T) {
+	disabledModelPaths = nil
+	modelMap, err := commonci.ParseOCModels("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, gotModelCount, err := genPortableScript("pyang", "acl", "/repo", "/tmp/results", modelMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#!/bin/sh
+workdir=${WORKDIR:-}
+[ -n "$workdir" ] || workdir='/tmp/results'
+mkdir -p "$workdir"
+finish_model() {
+  if [ "$timed_out" -eq 1 ]; then
+    mv "${1}pass" "${1}timeout"
+  elif [ "$2" -ne 0 ]; then
+    mv "${1}pass" "${1}fail"
+  fi
+  echo "$1" >> "$workdir"/completed-models
+}
+prefix="$workdir"/'acl==openconfig-acl=='
+printf '%s\n' 'pyang -W error -p testdata -p /repo/third_party/ietf testdata/acl/openconfig-acl.yang testdata/acl/openconfig-acl-evil-twin.yang' > "${prefix}cmd"
+attempts=1
+while [ "$attempts" -gt 0 ]; do
+  attempts=$((attempts - 1))
+  status=0
+  timed_out=0
+  : > "${prefix}pass"
+  if [ "$status" -eq 0 ]; then
+    "$@" '-W' 'error' '-p' 'testdata' '-p' '/repo/third_party/ietf' '--msg-template' 'messages:{{path:"{file}" line:{line} code:"{code}" type:"{type}" level:{level} message:'\''{msg}'\''}}' 'testdata/acl/openconfig-acl.yang' 'testdata/acl/openconfig-acl-evil-twin.yang' >> "${prefix}pass" 2>&1 || { [ $? -ne 124 ] || timed_out=1; status=1; }
+  fi
+  [ "$status" -ne 0 ] && [ "$timed_out" -eq 0 ] || break
+done
+finish_model "$prefix" "$status"
+`
+	if diff := cmp.Diff(strings.Split(want, "\n"), strings.Split(got, "\n")); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+	if gotModelCount != 1 {
+		t.Errorf("got model count %d, want 1", gotModelCount)
+	}
+
+	if _, _, err := genPortableScript("goyang-ygot", "acl", "/repo", "/tmp/results", modelMap); err == nil {
+		t.Errorf("got no error for validator without a portable script")
+	}
+	if _, _, err := genPortableScript("pyang", "foo", "/repo", "/tmp/results", modelMap); err == nil {
+		t.Errorf("got no error for unrecognized model directory")
+	}
+
+	// Names not removed by ParseOCModels fail the generation.
+	if _, err := portableModel(runner.Model{ModelName: "openconfig-acl", Env: []string{"$(touch pwned);X=x"}, Steps: []runner.Step{{Argv: []string{"pyang"}}}}); err == nil {
+		t.Errorf("got no error for invalid environment variable name")
+	}
+}
+
+func TestPortableScriptRuns(t *testing.T) {
+	sh, err := exec.LookPath("dash")
+	if err != nil {
+		t.Skip("dash, a strictly POSIX shell, isn't installed")
+	}
+	countDir := t.TempDir()
+	modelMap := commonci.OpenConfigModelMap{
+		ModelRoots: []string{"testdata"},
+		ModelInfoMap: map[string][]commonci.ModelInfo{
+			"acl": {{
+				Name:       "openconfig-acl",
+				BuildFiles: []string{"testdata/acl/openconfig-acl.yang"},
+				RunCi:      true,
+				Env:        map[string]string{"MARK": "it's x"},
+				Retries:    map[string]int{"confd": 1},
+			}, {
+				Name:       "openconfig-acl-2",
+				BuildFiles: []string{"testdata/acl/openconfig-acl-evil-twin.yang"},
+				RunCi:      true,
+			}},
+		},
+	}
+	script, _, err := genPortableScript("confd", "acl", "/repo", "/nonexistent", modelMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scriptPath := filepath.Join(t.TempDir(), "script.sh")
+	if err := os.WriteFile(scriptPath, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The fake confd ($1) fails the first run of each build file, recording
+	// each run along with $MARK.
+	fakeConfd := filepath.Join(t.TempDir(), "confd")
+	if err := os.WriteFile(fakeConfd, []byte(fmt.Sprintf(`#!/bin/sh
+count=%s/$(basename "$4")
+printf x >> "$count"
+echo "run $(cat "$count") ${MARK:-unset}"
+[ $(wc -c < "$count") -gt 1 ]
+`, countDir)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	workdir := t.TempDir()
+	cmd := exec.Command(sh, scriptPath, fakeConfd, "/yangpath")
+	cmd.Env = append(os.Environ(), "WORKDIR="+workdir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("script failed: %v\n%s", err, out)
+	}
+
+	wantFiles := map[string]string{
+		"acl==openconfig-acl==pass":   "run xx it's x\n",
+		"acl==openconfig-acl-2==fail": "run x unset\n",
+		"completed-models":            workdir + "/acl==openconfig-acl==\n" + workdir + "/acl==openconfig-acl-2==\n",
+	}
+	for name, want := range wantFiles {
+		got, err := os.ReadFile(filepath.Join(workdir, name))
+		if err != nil {
+			t.Errorf("missing result file: %v", err)
+			continue
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s (-want, +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestParseValidatorHooks(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		want    map[string]validatorHook
+		wantErr bool
+	}{{
+		desc: "empty",
+		want: map[string]validatorHook{},
+	}, {
+		desc: "multiple validators",
+		in: `pyang:
+  pre: pip3 install pyang==2.6.1
+  post: |
+    cp /tmp/pyang.log /workspace/artifacts/
+goyang-ygot:
+  pre: go clean -modcache
+`,
+		want: map[string]validatorHook{
+			"pyang":       {Pre: "pip3 install pyang==2.6.1", Post: "cp /tmp/pyang.log /workspace/artifacts/\n"},
+			"goyang-ygot": {Pre: "go clean -modcache"},
+		},
+	}, {
+		desc:    "unrecognized validator",
+		in:      "foo:\n  pre: echo hi\n",
+		wantErr: true,
+	}, {
+		desc:    "unknown field",
+		in:      "pyang:\n  setup: echo hi\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := parseValidatorHooks([]byte(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithValidatorHooks(t *testing.T) {
+	tests := []struct {
+		desc       string
+		inScript   string
+		inHook     validatorHook
+		wantOutput string
+		wantErr    bool
+	}{{
+		desc:       "no hooks",
+		inScript:   "#!/bin/bash\necho ran\n",
+		wantOutput: "ran\n",
+	}, {
+		desc:       "pre and post",
+		inScript:   "#!/bin/bash\necho ran\n",
+		inHook:     validatorHook{Pre: "echo pre", Post: "echo post\n"},
+		wantOutput: "pre\nran\npost\n",
+	}, {
+		desc:       "post keeps the failure",
+		inScript:   "#!/bin/bash\necho ran\nexit 3\necho unreachable\n",
+		inHook:     validatorHook{Post: "echo post; true"},
+		wantOutput: "ran\npost\n",
+		wantErr:    true,
+	}, {
+		desc:       "no shebang",
+		inScript:   "echo ran\n",
+		inHook:     validatorHook{Pre: "echo pre"},
+		wantOutput: "pre\nran\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			script := withValidatorHooks(tt.inScript, tt.inHook)
+			if strings.HasPrefix(tt.inScript, "#!") && !strings.HasPrefix(script, "#!/bin/bash\n") {
+				t.Errorf("script doesn't start with its shebang:\n%s", script)
+			}
+			out, err := exec.Command("bash", "-c", script).Output()
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+					t.Errorf("got error %v, want exit status 3", err)
+				}
+			}
+			if diff := cmp.Diff(tt.wantOutput, string(out)); diff != "" {
+				t.Errorf("output (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
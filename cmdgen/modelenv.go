@@ -0,0 +1,106 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// shellQuote quotes s as a single bash word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// sortedEnvNames returns the names of the environment variables in order.
+func sortedEnvNames(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EnvAssignments returns the bash assignments of the model's environment
+// variables, each followed by a space, which prefix its validator commands.
+// An invalid name fails the script's generation, since it'd be interpreted by
+// the shell.
+func (p *cmdParams) EnvAssignments() (string, error) {
+	var b strings.Builder
+	for _, name := range sortedEnvNames(p.Env) {
+		if !commonci.ValidEnvName(name) {
+			return "", fmt.Errorf("invalid environment variable name %q of model %q", name, p.ModelName)
+		}
+		fmt.Fprintf(&b, "%s=%s ", name, shellQuote(p.Env[name]))
+	}
+	return b.String(), nil
+}
+
+// QuotedExtraArgs returns the model's extra validator arguments, each quoted
+// as a single bash word.
+func (p *cmdParams) QuotedExtraArgs() []string {
+	var args []string
+	for _, arg := range p.ExtraArgs {
+		args = append(args, shellQuote(arg))
+	}
+	return args
+}
+
+// envList returns the model's environment variables as "NAME=value" entries
+// in order, as set by "openconfig-ci run-validator".
+func (p *cmdParams) envList() []string {
+	var env []string
+	for _, name := range sortedEnvNames(p.Env) {
+		env = append(env, name+"="+p.Env[name])
+	}
+	return env
+}
+
+// validatesModel returns whether the validator runs on the model: the model
+// must have build files, and run-ci set unless the validator ignores it. A
+// model with invalid environment variable names is never run.
+func validatesModel(validator *commonci.Validator, modelInfo commonci.ModelInfo) bool {
+	return len(modelInfo.BuildFiles) > 0 && (modelInfo.RunCi || validator.IgnoreRunCi) && len(modelInfo.InvalidEnvNames) == 0
+}
+
+// checkModelEnv returns a description of each problem with the environment
+// variables and extra arguments of the model given by its .spec.yml, whose
+// location is given: each environment variable must have a valid name, and
+// the extra arguments must be for recognized per-model validators.
+func checkModelEnv(location string, modelInfo commonci.ModelInfo) []string {
+	var problems []string
+	for _, name := range modelInfo.InvalidEnvNames {
+		problems = append(problems, fmt.Sprintf("%s: invalid environment variable name %q of model %q", location, name, modelInfo.Name))
+	}
+
+	validatorIds := make([]string, 0, len(modelInfo.ExtraArgs))
+	for validatorId := range modelInfo.ExtraArgs {
+		validatorIds = append(validatorIds, validatorId)
+	}
+	sort.Strings(validatorIds)
+	for _, validatorId := range validatorIds {
+		switch validator, ok := commonci.Validators[validatorId]; {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: extra-args of model %q for unrecognized validator %q", location, modelInfo.Name, validatorId))
+		case !validator.IsPerModel:
+			problems = append(problems, fmt.Sprintf("%s: extra-args of model %q for validator %q, which doesn't validate each model", location, modelInfo.Name, validatorId))
+		}
+	}
+	return problems
+}
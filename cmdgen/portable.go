@@ -0,0 +1,203 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/runner"
+)
+
+// portableHeader is the start of a portable script, which only uses POSIX sh
+// and utilities such that it runs on macOS and BSD as well as Linux. The
+// results directory defaults to the one given to cmd_gen, and is overridden
+// by $WORKDIR. finish_model moves the model's pass file to its fail file if
+// the model's status is non-zero.
+const portableHeader = `#!/bin/sh
+workdir=${WORKDIR:-}
+[ -n "$workdir" ] || workdir=%s
+mkdir -p "$workdir"
+finish_model() {
+  if [ "$timed_out" -eq 1 ]; then
+    mv "${1}pass" "${1}timeout"
+  elif [ "$2" -ne 0 ]; then
+    mv "${1}pass" "${1}fail"
+  fi
+  echo "$1" >> "$workdir"/completed-models
+}
+`
+
+// argvRefRegex matches the references within the argv of a runner.Step to
+// the script's arguments and to environment variables, which are expanded by
+// the runner (see runner.Step).
+var argvRefRegex = regexp.MustCompile(`\$(\{[A-Za-z0-9_]+\}|[A-Za-z0-9_]+)`)
+
+// portableWord converts an element of the argv of a runner.Step into a
+// single sh word, which expands the same references as the runner.
+func portableWord(arg string) string {
+	if arg == "$@" {
+		return `"$@"`
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range argvRefRegex.FindAllStringIndex(arg, -1) {
+		if loc[0] > last {
+			b.WriteString(shellQuote(arg[last:loc[0]]))
+		}
+		name := strings.Trim(arg[loc[0]+1:loc[1]], "{}")
+		fmt.Fprintf(&b, `"${%s}"`, name)
+		last = loc[1]
+	}
+	if last < len(arg) || b.Len() == 0 {
+		b.WriteString(shellQuote(arg[last:]))
+	}
+	return b.String()
+}
+
+// portableStep returns the sh command running the step with the model's
+// environment variables, appending its output to the model's pass file. A
+// step exiting like timeout(1) (e.g. a parse with -timeout) times out the
+// model, as in the validator scripts run by CI. An
+// invalid environment variable name is an error, since it'd be interpreted by
+// the shell.
+func portableStep(m runner.Model, step runner.Step) (string, error) {
+	var words []string
+	for _, env := range m.Env {
+		name, value, _ := strings.Cut(env, "=")
+		if !commonci.ValidEnvName(name) {
+			return "", fmt.Errorf("invalid environment variable name %q of model %q", name, m.ModelName)
+		}
+		words = append(words, name+"="+shellQuote(value))
+	}
+	for _, arg := range step.Argv {
+		words = append(words, portableWord(arg))
+	}
+	cmd := strings.Join(words, " ")
+	if step.Dir != "" {
+		cmd = fmt.Sprintf("(mkdir -p %s && cd %s && %s)", shellQuote(step.Dir), shellQuote(step.Dir), cmd)
+	}
+	return cmd + ` >> "${prefix}pass" 2>&1 || { [ $? -ne 124 ] || timed_out=1; status=1; }`, nil
+}
+
+// portableModel returns the sh commands validating the model, which are run
+// again up to m.Retries times while the model fails. Unless the model
+// continues on failure, each step only runs if the previous steps passed.
+func portableModel(m runner.Model) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "prefix=\"$workdir\"/%s\n", shellQuote(m.ModelDirName+"=="+m.ModelName+"=="))
+	if m.Cmd != "" {
+		fmt.Fprintf(&b, "printf '%%s\\n' %s > \"${prefix}cmd\"\n", shellQuote(m.Cmd))
+	}
+	fmt.Fprintf(&b, "attempts=%d\n", m.Retries+1)
+	b.WriteString(`while [ "$attempts" -gt 0 ]; do
+  attempts=$((attempts - 1))
+  status=0
+  timed_out=0
+  : > "${prefix}pass"
+`)
+	for _, step := range m.Steps {
+		indent := "  "
+		if !m.ContinueOnFailure {
+			b.WriteString("  if [ \"$status\" -eq 0 ]; then\n")
+			indent = "    "
+		}
+		if step.Marker != "" {
+			fmt.Fprintf(&b, "%sprintf '%%s\\n' %s >> \"${prefix}pass\"\n", indent, shellQuote(step.Marker))
+		}
+		cmd, err := portableStep(m, step)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s%s\n", indent, cmd)
+		if !m.ContinueOnFailure {
+			b.WriteString("  fi\n")
+		}
+	}
+	b.WriteString(`  [ "$status" -ne 0 ] && [ "$timed_out" -eq 0 ] || break
+done
+finish_model "$prefix" "$status"
+`)
+	return b.String(), nil
+}
+
+// genPortableScript generates a POSIX sh script validating the models of the
+// model directory with the validator, for contributors running it locally
+// (e.g. on macOS) from repoRoot, the root of their models repo checkout. It
+// runs the same commands as "openconfig-ci run-validator", except that models
+// are validated one at a time without the model timeout. It also returns the
+// number of models it validates.
+func genPortableScript(validatorId, modelDirName, repoRoot, resultsDir string, modelMap commonci.OpenConfigModelMap) (string, int, error) {
+	cmdTemplate, ok := scriptTemplates[validatorId]
+	if !ok || cmdTemplate.runModel == nil {
+		return "", 0, fmt.Errorf("cmd_gen: validatorId %q has no portable script; only the validators supported by -runner=go do", validatorId)
+	}
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return "", 0, fmt.Errorf("cmd_gen: unrecognized validatorId %q", validatorId)
+	}
+	modelInfos, ok := modelMap.ModelInfoMap[modelDirName]
+	if !ok {
+		return "", 0, fmt.Errorf("unrecognized model directory %q", modelDirName)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, portableHeader, shellQuote(resultsDir))
+	modelCount := 0
+	for _, modelInfo := range modelInfos {
+		if !validatesModel(validator, modelInfo) {
+			continue
+		}
+		model, err := portableModel(planModel(cmdTemplate, validatorId, repoRoot, resultsDir, modelDirName, modelInfo, modelMap))
+		if err != nil {
+			return "", 0, err
+		}
+		b.WriteString(model)
+		modelCount += 1
+	}
+	return b.String(), modelCount, nil
+}
+
+// printPortableScript prints the portable script validating the model
+// directory given by -modelDirName with the validator given by -validator,
+// whose results are written into -resultsDir by default. The current
+// directory must be the root of the models repo.
+func printPortableScript(modelMap commonci.OpenConfigModelMap) error {
+	vvs, _ := commonci.GetValidatorAndVersionsFromString(localValidatorId)
+	if len(vvs) != 1 {
+		return fmt.Errorf("invalid validator %q, must be a single <validatorId>[@<version>]", localValidatorId)
+	}
+	repoRoot, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	resultsDir, err := expandHome(localResultsDir)
+	if err != nil {
+		return err
+	}
+	if resultsDir, err = filepath.Abs(resultsDir); err != nil {
+		return err
+	}
+	script, _, err := genPortableScript(vvs[0].ValidatorId, localModelDirName, repoRoot, resultsDir, modelMap)
+	if err != nil {
+		return err
+	}
+	fmt.Print(script)
+	return nil
+}
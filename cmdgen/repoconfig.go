@@ -0,0 +1,98 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// repoConfigReader reads a CI configuration file of the models repo given its
+// path relative to the root of the repo, returning nil if it doesn't exist.
+type repoConfigReader func(name string) ([]byte, error)
+
+// readCheckoutConfig reads the configuration file from the models repo's
+// checkout at commonci.RootDir.
+func readCheckoutConfig(name string) ([]byte, error) {
+	bs, err := os.ReadFile(filepath.Join(commonci.RootDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return bs, err
+}
+
+// baseBranchConfigReader returns the reader of the configuration files on a
+// PR's base branch, which unlike the PR's checkout can't be changed by the PR.
+func baseBranchConfigReader(h githubClient, owner, repo, baseRef string) repoConfigReader {
+	return func(name string) ([]byte, error) {
+		return h.FileContents(owner, repo, baseRef, name)
+	}
+}
+
+// readValidatorsConfig reads the models repo's validators config file. If the
+// file doesn't exist, then nil is returned.
+func readValidatorsConfig(read repoConfigReader) (*commonci.ValidatorsConfig, error) {
+	bs, err := read(commonci.ValidatorsConfigFileName)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to read validators config file %q: %v", commonci.ValidatorsConfigFileName, err)
+	case bs == nil:
+		return nil, nil
+	}
+	cfg, err := commonci.ParseValidatorsConfig(bs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validators config file %q: %v", commonci.ValidatorsConfigFileName, err)
+	}
+	return cfg, nil
+}
+
+// readDisabledDirs reads the models repo's disabled directories file,
+// returning no disabled directories if it doesn't exist.
+func readDisabledDirs(read repoConfigReader) (map[string]bool, error) {
+	bs, err := read(commonci.DisabledDirsFileName)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to read disabled directories file %q: %v", commonci.DisabledDirsFileName, err)
+	case bs == nil:
+		return nil, nil
+	}
+	disabled, err := commonci.ParseDisabledDirs(bs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid disabled directories file %q: %v", commonci.DisabledDirsFileName, err)
+	}
+	return disabled, nil
+}
+
+// readValidatorVersions reads the models repo's validator versions file,
+// returning no pinned versions if it doesn't exist.
+func readValidatorVersions(read repoConfigReader) (map[string][]string, error) {
+	bs, err := read(commonci.ValidatorVersionsFileName)
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to read validator versions file %q: %v", commonci.ValidatorVersionsFileName, err)
+	case bs == nil:
+		return nil, nil
+	}
+	versions, err := commonci.ParseValidatorVersions(bs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validator versions file %q: %v", commonci.ValidatorVersionsFileName, err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// maxModelRetries is the maximum number of times that a validator may re-run a
+// failed model, such that a misconfigured model can't stall the CI.
+const maxModelRetries = 5
+
+// parseRetries converts a comma-separated list of <validatorId>=<count>
+// entries (e.g. "goyang-ygot=2,ygot-proto=1") to a map of validatorId to the
+// number of times each model is re-run after it fails.
+func parseRetries(retriesStr string) (map[string]int, error) {
+	retries := map[string]int{}
+	for _, entry := range strings.Fields(strings.ReplaceAll(retriesStr, ",", " ")) {
+		validatorId, countStr, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid entry %q, expected <validatorId>=<count>", entry)
+		}
+		if _, ok := commonci.Validators[validatorId]; !ok {
+			return nil, fmt.Errorf("unrecognized validatorId %q in entry %q", validatorId, entry)
+		}
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 0 || count > maxModelRetries {
+			return nil, fmt.Errorf("invalid count in entry %q, expected an integer from 0 to %d", entry, maxModelRetries)
+		}
+		retries[validatorId] = count
+	}
+	return retries, nil
+}
+
+// modelRetries returns the number of times that the validator re-runs the
+// model after it fails: the model's own retries for the validator given by
+// its .spec.yml if any, and otherwise those given by -retries.
+func modelRetries(validatorId string, modelInfo commonci.ModelInfo) int {
+	if count, ok := modelInfo.Retries[validatorId]; ok {
+		return count
+	}
+	return retriesMap[validatorId]
+}
+
+// checkRetries returns a description of each problem with the retries of the
+// model given by its .spec.yml, whose location is given: each must be for a
+// recognized validator, and at most maxModelRetries.
+func checkRetries(location string, modelInfo commonci.ModelInfo) []string {
+	validatorIds := make([]string, 0, len(modelInfo.Retries))
+	for validatorId := range modelInfo.Retries {
+		validatorIds = append(validatorIds, validatorId)
+	}
+	sort.Strings(validatorIds)
+
+	var problems []string
+	for _, validatorId := range validatorIds {
+		count := modelInfo.Retries[validatorId]
+		switch validator, ok := commonci.Validators[validatorId]; {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: retries of model %q for unrecognized validator %q", location, modelInfo.Name, validatorId))
+		case !validator.IsPerModel:
+			problems = append(problems, fmt.Sprintf("%s: retries of model %q for validator %q, which doesn't validate each model", location, modelInfo.Name, validatorId))
+		case count < 0 || count > maxModelRetries:
+			problems = append(problems, fmt.Sprintf("%s: retries of model %q for validator %q must be from 0 to %d, got %d", location, modelInfo.Name, validatorId, maxModelRetries, count))
+		}
+	}
+	return problems
+}
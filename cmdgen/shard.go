@@ -0,0 +1,118 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// modelShard is the Index-th (1-based) of Count shards of the model
+// directories validated by a per-model validator. The zero value, like any
+// shard with a Count of at most 1, is all of the model directories.
+type modelShard struct {
+	Index int
+	Count int
+}
+
+// parseModelShard parses a shard in the "N/M" format (e.g. "2/4"). An empty
+// string is all of the model directories.
+func parseModelShard(s string) (modelShard, error) {
+	if s == "" {
+		return modelShard{}, nil
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return modelShard{}, fmt.Errorf("invalid shard %q, must be N/M", s)
+	}
+	index, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return modelShard{}, fmt.Errorf("invalid shard %q, must be N/M: %v", s, err)
+	}
+	count, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return modelShard{}, fmt.Errorf("invalid shard %q, must be N/M: %v", s, err)
+	}
+	if count < 1 || index < 1 || index > count {
+		return modelShard{}, fmt.Errorf("invalid shard %q, must be N/M with 1 <= N <= M", s)
+	}
+	return modelShard{Index: index, Count: count}, nil
+}
+
+// String returns the shard in the "N/M" format.
+func (s modelShard) String() string {
+	if s.Count <= 1 {
+		return "1/1"
+	}
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// splitShards splits the model directories into up to count contiguous shards
+// of as equal size as possible. No shard is empty, so there are fewer shards
+// than count if there are fewer model directories.
+func splitShards(dirs []string, count int) [][]string {
+	n := count
+	if len(dirs) < n {
+		n = len(dirs)
+	}
+	var split [][]string
+	start := 0
+	for i := 0; i < n; i++ {
+		end := start + (len(dirs)-start)/(n-i)
+		split = append(split, dirs[start:end])
+		start = end
+	}
+	return split
+}
+
+// validatedModelDirs returns the sorted, non-disabled model directories with
+// models that the validator runs on.
+func validatedModelDirs(validator *commonci.Validator, modelMap commonci.OpenConfigModelMap) []string {
+	var dirs []string
+	for modelDirName, modelInfos := range modelMap.ModelInfoMap {
+		if disabledModelPaths[modelDirName] {
+			continue
+		}
+		for _, modelInfo := range modelInfos {
+			if validatesModel(validator, modelInfo) {
+				dirs = append(dirs, modelDirName)
+				break
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// shardModelDirs returns the set of model directories within the shard of
+// those the validator runs on, or nil if the shard is all of them. The split
+// is deterministic, such that M build steps each running shard N/M together
+// validate every model directory exactly once.
+func shardModelDirs(s modelShard, validator *commonci.Validator, modelMap commonci.OpenConfigModelMap) map[string]bool {
+	if s.Count <= 1 {
+		return nil
+	}
+	inShard := map[string]bool{}
+	if split := splitShards(validatedModelDirs(validator, modelMap), s.Count); s.Index <= len(split) {
+		for _, dir := range split[s.Index-1] {
+			inShard[dir] = true
+		}
+	}
+	return inShard
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// specCheckId is the ID of the report-only validator whose PR status reports
+// the problems found by checkSpecs.
+const specCheckId = "spec-check"
+
+// specLocation returns the location of a line within the given .spec.yml
+// file relative to its model root (e.g. "acl/.spec.yml:4"). The line is
+// omitted if it's unknown (i.e. zero).
+func specLocation(modelRoots []string, specFile string, line int) string {
+	if relPath, err := commonci.RelModelPath(modelRoots, specFile); err == nil {
+		specFile = relPath
+	}
+	if line == 0 {
+		return specFile
+	}
+	return fmt.Sprintf("%s:%d", specFile, line)
+}
+
+// sortedModelDirNames returns the model directories of modelMap in order.
+func sortedModelDirNames(modelMap commonci.OpenConfigModelMap) []string {
+	modelDirNames := make([]string, 0, len(modelMap.ModelInfoMap))
+	for modelDirName := range modelMap.ModelInfoMap {
+		modelDirNames = append(modelDirNames, modelDirName)
+	}
+	sort.Strings(modelDirNames)
+	return modelDirNames
+}
+
+// checkSpecs validates the .spec.yml file of each model directory, and
+// returns a description of each problem prefixed by its location within the
+// .spec.yml. Each .spec.yml must define at least one model, each model must
+// have a name that is unique across the models repo, and a non-empty list of
+// build files that exist. The examples directory of a model, if any, must
+// exist, and its retries, environment variables and extra arguments must be
+// valid (see checkRetries and checkModelEnv).
+func checkSpecs(modelMap commonci.OpenConfigModelMap) []string {
+	var problems []string
+	// nameLocations stores the location of each model name for duplicate
+	// detection.
+	nameLocations := map[string]string{}
+	for _, modelDirName := range sortedModelDirNames(modelMap) {
+		modelInfos := modelMap.ModelInfoMap[modelDirName]
+		if len(modelInfos) == 0 {
+			// Without any models, there's no recorded spec file.
+			specFile := strings.ReplaceAll(modelDirName, ":", "/") + "/.spec.yml"
+			problems = append(problems, specFile+": no models defined")
+			continue
+		}
+		for _, modelInfo := range modelInfos {
+			location := specLocation(modelMap.ModelRoots, modelInfo.SpecFile, modelInfo.Line)
+			switch otherLocation, ok := nameLocations[modelInfo.Name]; {
+			case modelInfo.Name == "":
+				problems = append(problems, location+": missing model name")
+			case ok:
+				problems = append(problems, fmt.Sprintf("%s: duplicate model name %q, also defined at %s", location, modelInfo.Name, otherLocation))
+			default:
+				nameLocations[modelInfo.Name] = location
+			}
+
+			if len(modelInfo.BuildFiles) == 0 {
+				problems = append(problems, fmt.Sprintf("%s: model %q has no build files", location, modelInfo.Name))
+			}
+			for j, buildFile := range modelInfo.BuildFiles {
+				if _, err := os.Stat(buildFile); err == nil {
+					continue
+				}
+				if relPath, err := commonci.RelModelPath(modelMap.ModelRoots, buildFile); err == nil {
+					buildFile = relPath
+				}
+				buildFileLocation := specLocation(modelMap.ModelRoots, modelInfo.SpecFile, 0)
+				if j < len(modelInfo.BuildFileLines) {
+					buildFileLocation = specLocation(modelMap.ModelRoots, modelInfo.SpecFile, modelInfo.BuildFileLines[j])
+				}
+				problems = append(problems, fmt.Sprintf("%s: build file %s does not exist", buildFileLocation, buildFile))
+			}
+			if modelInfo.ExamplesDir != "" {
+				if info, err := os.Stat(modelInfo.ExamplesDir); err != nil || !info.IsDir() {
+					examplesDir := modelInfo.ExamplesDir
+					if relPath, err := commonci.RelModelPath(modelMap.ModelRoots, examplesDir); err == nil {
+						examplesDir = relPath
+					}
+					problems = append(problems, fmt.Sprintf("%s: examples directory %s of model %q does not exist", location, examplesDir, modelInfo.Name))
+				}
+			}
+			problems = append(problems, checkRetries(location, modelInfo)...)
+			problems = append(problems, checkModelEnv(location, modelInfo)...)
+		}
+	}
+	return problems
+}
+
+// postSpecCheckStatus posts the PR status reporting the problems found by
+// checkSpecs, which fails if there are any. The problems are listed within a
+// gist linked from the status.
+func postSpecCheckStatus(g githubClient, problems []string) error {
+	validator := commonci.Validators[specCheckId]
+	update := &commonci.GithubPRUpdate{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         commitSHA,
+		Description: validator.Name + " Passed",
+		NewStatus:   "success",
+		Context:     validator.StatusName(""),
+	}
+	if len(problems) > 0 {
+		content := fmt.Sprintf("The .spec.yml files of commit %s have the following problems:\n\n", commitSHA)
+		for _, problem := range problems {
+			content += "- " + problem + "\n"
+		}
+		url, _, err := g.CreateCIOutputGist(validator.Name, content)
+		if err != nil {
+			return fmt.Errorf("couldn't create gist of the .spec.yml problems: %v", err)
+		}
+		update.URL = url
+		update.NewStatus = "failure"
+		update.Description = fmt.Sprintf("%s Failed: %d problem(s) in .spec.yml files", validator.Name, len(problems))
+	}
+
+	if err := g.UpdatePRStatus(update); err != nil {
+		log.Printf("error: couldn't update PR: %s", err)
+		log.Printf("GithubPRUpdate: %+v", update)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmdgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"gopkg.in/yaml.v3"
+)
+
+// validatorHook is the bash snippets that a CI deployment runs within the
+// script of a validator, e.g. to warm caches, install pinned tool versions or
+// collect extra artifacts, without forking the script templates.
+type validatorHook struct {
+	// Pre is run at the start of the script, before any model is
+	// validated.
+	Pre string `yaml:"pre"`
+	// Post is run when the script exits, whether or not the validator
+	// passed, and doesn't change the script's exit status.
+	Post string `yaml:"post"`
+}
+
+// parseValidatorHooks parses the contents of a -validator-hooks-file, which
+// maps each validatorId to its hooks, e.g.
+//
+//	pyang:
+//	  pre: pip3 install pyang==2.6.1
+//	  post: cp /tmp/pyang-debug.log /workspace/artifacts/
+//
+// Every validator must be recognized.
+func parseValidatorHooks(bs []byte) (map[string]validatorHook, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	hooks := map[string]validatorHook{}
+	if err := dec.Decode(&hooks); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	validatorIds := make([]string, 0, len(hooks))
+	for validatorId := range hooks {
+		validatorIds = append(validatorIds, validatorId)
+	}
+	sort.Strings(validatorIds)
+	for _, validatorId := range validatorIds {
+		if _, ok := commonci.Validators[validatorId]; !ok {
+			return nil, fmt.Errorf("unrecognized validatorId %q", validatorId)
+		}
+	}
+	return hooks, nil
+}
+
+// readValidatorHooks reads the -validator-hooks-file at path. If path is
+// empty, then there are no hooks.
+func readValidatorHooks(path string) (map[string]validatorHook, error) {
+	if path == "" {
+		return nil, nil
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validator hooks file %q: %v", path, err)
+	}
+	hooks, err := parseValidatorHooks(bs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validator hooks file %q: %v", path, err)
+	}
+	return hooks, nil
+}
+
+// withValidatorHooks returns the validator script with the hook spliced in
+// after its shebang line, if any: the pre snippet is run first, and the post
+// snippet is run by an EXIT trap, which keeps the script's exit status.
+func withValidatorHooks(script string, hook validatorHook) string {
+	if strings.TrimSpace(hook.Pre) == "" && strings.TrimSpace(hook.Post) == "" {
+		return script
+	}
+	var shebang, body string
+	if strings.HasPrefix(script, "#!") {
+		shebang, body, _ = strings.Cut(script, "\n")
+		shebang += "\n"
+	} else {
+		body = script
+	}
+
+	var b strings.Builder
+	b.WriteString(shebang)
+	if pre := strings.TrimSpace(hook.Pre); pre != "" {
+		fmt.Fprintf(&b, "%s\n", pre)
+	}
+	if post := strings.TrimSpace(hook.Post); post != "" {
+		fmt.Fprintf(&b, "function validator-post-hook() {\n%s\n}\ntrap validator-post-hook EXIT\n", post)
+	}
+	b.WriteString(body)
+	return b.String()
+}
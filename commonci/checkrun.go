@@ -0,0 +1,182 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+// maxCheckRunAnnotations is the maximum number of annotations that GitHub
+// accepts in a single request creating or updating a check run.
+const maxCheckRunAnnotations = 50
+
+// CheckRunAnnotation is an annotation of a line of a file within the models
+// repo, which GitHub displays inline in a PR's changed files.
+type CheckRunAnnotation struct {
+	// Path is the path of the file relative to the root of the repo.
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// Level is "notice", "warning" or "failure".
+	Level   string `json:"annotation_level"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+}
+
+// CheckRun is a completed GitHub check run on a commit, which is created with
+// the CreateCheckRun method.
+type CheckRun struct {
+	Owner   string
+	Repo    string
+	HeadSHA string
+	// Name is the name of the check, e.g. the validator's status context.
+	Name       string
+	DetailsURL string
+	// Conclusion is "success", "failure" or "neutral".
+	Conclusion  string
+	Title       string
+	Summary     string
+	Annotations []*CheckRunAnnotation
+}
+
+// checkRunOutput is the output of a check run in a request to GitHub.
+// go-github's CheckRunOutput has the preview API's annotation fields, which
+// GitHub no longer accepts.
+type checkRunOutput struct {
+	Title       string                `json:"title"`
+	Summary     string                `json:"summary"`
+	Annotations []*CheckRunAnnotation `json:"annotations,omitempty"`
+}
+
+// checkRunRequest is the body of a request creating or updating a check run.
+type checkRunRequest struct {
+	Name        string            `json:"name"`
+	HeadSHA     string            `json:"head_sha,omitempty"`
+	DetailsURL  string            `json:"details_url,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Conclusion  string            `json:"conclusion,omitempty"`
+	CompletedAt *github.Timestamp `json:"completed_at,omitempty"`
+	Output      *checkRunOutput   `json:"output"`
+}
+
+// notAccepted returns whether the request failed without GitHub accepting
+// it, i.e. it was rate limited or the connection couldn't be established.
+func notAccepted(err error) bool {
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	var opErr *net.OpError
+	return errors.As(err, &rateErr) || errors.As(err, &abuseErr) || (errors.As(err, &opErr) && opErr.Op == "dial")
+}
+
+// retryNotAccepted retries a GitHub API call that isn't idempotent only while
+// it isn't accepted, such that it's never made twice, classifying the final
+// error.
+func retryNotAccepted(name string, f func() error) error {
+	var err error
+	for i := 0; i <= 5; i++ {
+		if err = f(); err == nil || !notAccepted(err) {
+			break
+		}
+		log.Printf("Retry %d of %s, error: %v", i, name, err)
+		time.Sleep(250 * time.Millisecond)
+	}
+	return classifyGitHubError(err)
+}
+
+// CreateCheckRun creates the completed check run on its commit. Since GitHub
+// limits the number of annotations per request, further annotations are
+// added by updating the check run. Creating check runs requires a GitHub
+// App's installation token, given by ChecksTokenEnv, which is only used for
+// check runs.
+func (g *GithubRequestHandler) CreateCheckRun(run *CheckRun) error {
+	if g.shadow {
+		log.Printf("shadow mode: not creating check run %q with %d annotations", run.Name, len(run.Annotations))
+		return nil
+	}
+	if g.checksClient == nil {
+		return fmt.Errorf("creating check runs requires a GitHub App's installation token given by %s", ChecksTokenEnv)
+	}
+	if run.Owner == "" || run.Repo == "" || run.HeadSHA == "" || run.Name == "" || run.Conclusion == "" {
+		return fmt.Errorf("must specify required fields (owner (%s), repo (%s), head SHA (%s), name (%s) and conclusion (%s)) for check run", run.Owner, run.Repo, run.HeadSHA, run.Name, run.Conclusion)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	annotations := run.Annotations
+	nextAnnotations := func() []*CheckRunAnnotation {
+		n := len(annotations)
+		if n > maxCheckRunAnnotations {
+			n = maxCheckRunAnnotations
+		}
+		batch := annotations[:n]
+		annotations = annotations[n:]
+		return batch
+	}
+
+	// go-github's CreateCheckRun and UpdateCheckRun send the preview API's
+	// annotation fields, so the requests are made directly.
+	var id int64
+	req := &checkRunRequest{
+		Name:        run.Name,
+		HeadSHA:     run.HeadSHA,
+		DetailsURL:  run.DetailsURL,
+		Status:      "completed",
+		Conclusion:  run.Conclusion,
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      &checkRunOutput{Title: run.Title, Summary: run.Summary, Annotations: nextAnnotations()},
+	}
+	// Creating the check run again would duplicate it, and adding the
+	// annotations again would duplicate them.
+	if err := retryNotAccepted("check run creation", func() error {
+		r, err := g.checksClient.NewRequest("POST", fmt.Sprintf("repos/%s/%s/check-runs", run.Owner, run.Repo), req)
+		if err != nil {
+			return err
+		}
+		created := &github.CheckRun{}
+		if _, err := g.checksClient.Do(ctx, r, created); err != nil {
+			return err
+		}
+		id = created.GetID()
+		return nil
+	}); err != nil {
+		return fmt.Errorf("could not create check run %q: %w", run.Name, err)
+	}
+
+	for len(annotations) > 0 {
+		// The output's title and summary are required with annotations.
+		req := &checkRunRequest{
+			Name:   run.Name,
+			Output: &checkRunOutput{Title: run.Title, Summary: run.Summary, Annotations: nextAnnotations()},
+		}
+		if err := retryNotAccepted("check run annotation", func() error {
+			r, err := g.checksClient.NewRequest("PATCH", fmt.Sprintf("repos/%s/%s/check-runs/%d", run.Owner, run.Repo, id), req)
+			if err != nil {
+				return err
+			}
+			_, err = g.checksClient.Do(ctx, r, nil)
+			return err
+		}); err != nil {
+			return fmt.Errorf("could not add annotations to check run %q: %w", run.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCreateCheckRun(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var gotRequests []string
+	decode := func(r *http.Request) *checkRunRequest {
+		req := &checkRunRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Output == nil || req.Output.Title != "title" || req.Output.Summary != "summary" {
+			t.Errorf("got output %+v, want title and summary", req.Output)
+		}
+		return req
+	}
+	mux.HandleFunc("/repos/o/r/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		req := decode(r)
+		if req.CompletedAt == nil {
+			t.Errorf("check run created without completion time")
+		}
+		gotRequests = append(gotRequests, fmt.Sprintf("create %s@%s %s %s %s: %d annotations from %s:%d", req.Name, req.HeadSHA, req.Status, req.Conclusion, req.DetailsURL, len(req.Output.Annotations), req.Output.Annotations[0].Path, req.Output.Annotations[0].StartLine))
+		fmt.Fprint(w, `{"id": 42}`)
+	})
+	mux.HandleFunc("/repos/o/r/check-runs/42", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "PATCH")
+		req := decode(r)
+		gotRequests = append(gotRequests, fmt.Sprintf("update %s: %d annotations from %s:%d", req.Name, len(req.Output.Annotations), req.Output.Annotations[0].Path, req.Output.Annotations[0].StartLine))
+		fmt.Fprint(w, `{"id": 42}`)
+	})
+
+	run := &CheckRun{
+		Owner:      "o",
+		Repo:       "r",
+		HeadSHA:    "sha",
+		Name:       "pyang",
+		DetailsURL: "url",
+		Conclusion: "failure",
+		Title:      "title",
+		Summary:    "summary",
+	}
+	for i := 1; i <= 120; i++ {
+		run.Annotations = append(run.Annotations, &CheckRunAnnotation{Path: "a.yang", StartLine: i, EndLine: i, Level: "failure", Message: "m"})
+	}
+	g := &GithubRequestHandler{client: client, checksClient: client, labels: map[string]bool{}}
+	if err := g.CreateCheckRun(run); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"create pyang@sha completed failure url: 50 annotations from a.yang:1",
+		"update pyang: 50 annotations from a.yang:51",
+		"update pyang: 20 annotations from a.yang:101",
+	}
+	if diff := cmp.Diff(want, gotRequests); diff != "" {
+		t.Errorf("requests (-want, +got):\n%s", diff)
+	}
+
+	if err := g.CreateCheckRun(&CheckRun{Owner: "o", Repo: "r", HeadSHA: "sha", Name: "pyang"}); err == nil {
+		t.Errorf("got no error for check run without conclusion")
+	}
+}
+
+func TestCreateCheckRunNotRetried(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var requests int
+	mux.HandleFunc("/repos/o/r/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		requests++
+		// The check run may have been created despite the error.
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	run := &CheckRun{Owner: "o", Repo: "r", HeadSHA: "sha", Name: "pyang", Conclusion: "success"}
+	if err := (&GithubRequestHandler{client: client, labels: map[string]bool{}}).CreateCheckRun(run); err == nil {
+		t.Errorf("got no error for check run without a checks client")
+	}
+	if err := (&GithubRequestHandler{client: client, checksClient: client, labels: map[string]bool{}}).CreateCheckRun(run); err == nil {
+		t.Errorf("got no error for failed check run creation")
+	}
+	if requests != 1 {
+		t.Errorf("got %d check run creation requests, want 1", requests)
+	}
+}
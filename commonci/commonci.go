@@ -15,10 +15,14 @@
 package commonci
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -36,14 +40,46 @@ const (
 	// passed from cmd_gen to later stages of the CI. It is common to all
 	// CI steps.
 	UserConfigDir = "/workspace/user-config"
-	// CompatReportValidatorsFile notifies later CI steps of the validators
-	// that should be reported as a compatibility report.
-	CompatReportValidatorsFile = UserConfigDir + "/compat-report-validators.txt"
-	// ForkSlugFile is created by cmd_gen to store the fork slug, if
-	// present, for later CI steps.
-	ForkSlugFile = UserConfigDir + "/fork-slug.txt"
+	// ParseCacheDir contains the goyang parse results cached by validators
+	// such that they may be reused by others parsing the same files.
+	ParseCacheDir = "/workspace/parse-cache"
+	// RequiredStatusDir contains the outcome of each validator recorded by
+	// post_results for evaluating the aggregate "required" PR status.
+	RequiredStatusDir = RootDir + "/required-status"
+	// BannerFileName by convention is the file at the root of the models
+	// repo containing a markdown announcement to display in every report.
+	BannerFileName = "ci-banner.md"
+	// InfraDegradedFile is created by the first post_results step to
+	// report degraded CI infrastructure, such that it's reported only once
+	// per build.
+	InfraDegradedFile = RootDir + "/infra-degraded"
 	// ScriptFileName by convention is the script with the validator commands.
 	ScriptFileName = "script.sh"
+	// ExpectedModelCountFileName by convention contains the number of
+	// models the validator script runs on, for progress reporting.
+	ExpectedModelCountFileName = "expected-model-count"
+	// CompletedModelsFileName by convention contains one line for each
+	// model the validator script has finished running on, for progress
+	// reporting.
+	CompletedModelsFileName = "completed-models"
+	// MissingBuildFilesFileName by convention contains one line describing
+	// each build file referenced by a .spec.yml file that doesn't exist. It
+	// is output by cmd_gen into the misc-checks results directory.
+	MissingBuildFilesFileName = "missing-build-files"
+	// MiscChecksOutcomeFileName by convention contains the machine-readable
+	// outcome of each check of misc-checks (see report.MiscChecksOutcome). It
+	// is output by post_results into the misc-checks results directory.
+	MiscChecksOutcomeFileName = "misc-checks-outcome.json"
+	// DisallowedIncompatsFileName by convention contains ocdiff's
+	// backward-incompatible changes that aren't allowed by the PR's version
+	// increments (see openconfig-ci diff --disallowed-incompats), which is
+	// empty if there are none. It is output by misc-checks into its results
+	// directory.
+	DisallowedIncompatsFileName = "disallowed-incompats"
+	// JUnitFileName by convention contains the JUnit XML report of the
+	// validator, with one test case per model (see report.JUnit). It is
+	// output by post_results into each validator's results directory.
+	JUnitFileName = "junit.xml"
 	// LatestVersionFileName by convention contains the version description
 	// of the tool as output by the tool during the build.
 	// Whenever the "latest" version of a tool has a version, it should
@@ -53,9 +89,22 @@ const (
 	OutFileName = "out"
 	// FailFileName by convention contains the stderr of the script file.
 	FailFileName = "fail"
-	// BadgeUploadCmdFile is output by post_results to upload the correct
-	// status badge to GCS.
-	BadgeUploadCmdFile = "upload-badge.sh"
+	// DoneFileName by convention is created within a validator's results
+	// directory once its results are complete, signalling the post_results
+	// reporter service to post them.
+	DoneFileName = "done"
+	// ExitedFileName by convention is created within each of a validator's
+	// results directories once its validator step exits, such that the
+	// reporter service can tell a step that exited without marking its
+	// results done (e.g. since it crashed) from one that's still running.
+	ExitedFileName = "exited"
+	// PathListsDirName by convention is the directory within the
+	// gnmi-paths results directory containing the gNMI path list of each
+	// model, named "modelDir==model.txt".
+	PathListsDirName = "paths"
+	// TimeoutStatus is the status of the "modelDir==model==status" result
+	// file of a model on which the validator timed out, which is a failure.
+	TimeoutStatus = "timeout"
 )
 
 // BoolStatusToString converts a pass/fail status from bool to string.
@@ -79,6 +128,10 @@ func Emoji(status string) string {
 		return "&#x26D4;" // blocked emoji
 	case "cmd":
 		return "&#x1F4B2;" // dollar-sign emoji
+	case "warning":
+		return "&#x26A0;&#xFE0F;" // warning-sign emoji
+	case TimeoutStatus:
+		return "&#x231B;" // hourglass emoji
 	}
 	return ""
 }
@@ -98,6 +151,16 @@ func ValidatorResultsDir(validatorId, version string) string {
 	return filepath.Join(ResultsDir, AppendVersionToName(validatorId, version))
 }
 
+// WriteFile writes data to the file at path, replacing any existing file
+// rather than truncating it. As such, a read-only file relayed to later CI
+// steps can be rewritten when a step is re-run within the same workspace.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
+
 // Validator describes a validation tool.
 type Validator struct {
 	// The longer name of the validator.
@@ -119,17 +182,214 @@ type Validator struct {
 	// SupportedVersion is the lowest version supported to run in CI for
 	// the validator. If empty, then all versions are supported.
 	SupportedVersion string
+	// HasExtraVersions indicates that the validator's test.sh runs the
+	// versions relayed by cmd_gen in addition to the latest version.
+	HasExtraVersions bool
+	// HasHead indicates that the validator can run the HeadVersion, which
+	// is built from the head of its source repository.
+	HasHead bool
+	// HasPatchWildcard indicates that the validator can run a version whose
+	// patch version is "x" (e.g. 2.1.x), which is the latest release of
+	// that minor version.
+	HasPatchWildcard bool
+	// Cacheable indicates that the validator's results only depend on the
+	// validated files, its generated script and its version (as described
+	// by its LatestVersionFileName if it's the latest version), such that
+	// they may be reused from the result cache (see ResultCacheKey).
+	Cacheable bool
+	// ResultsFormat is the format of the output of each model of a
+	// validator defined by the models repo (see ValidatorConfig), which
+	// determines how it's reported. Built-in validators are reported
+	// according to their validatorId.
+	ResultsFormat string
+}
+
+// hasShardStatus returns whether the results of the validator depend on the
+// build's shard of the model directories (see Shard): misc-checks compares the
+// whole repo against master, repo-level validators run on the whole repo, and
+// spec-check checks every .spec.yml file.
+func (v *Validator) hasShardStatus() bool {
+	switch v {
+	case Validators["misc-checks"], Validators["spec-check"]:
+		return false
+	}
+	return v.IsPerModel || v.ReportOnly
 }
 
-// StatusName determines the status description for the version of the validator.
+// shardStatusSuffix is appended to the status context of a validator whose
+// results depend on the build's shard, followed by the shard.
+const shardStatusSuffix = " (shard "
+
+// StatusName determines the status context for the version of the
+// validator. The name is prefixed by StatusContextPrefix, and names the
+// build's Shard if the validator's results depend on it, such that the
+// statuses of the shards don't overwrite each other.
 func (v *Validator) StatusName(version string) string {
 	if v == nil {
 		return ""
 	}
-	return AppendVersionToName(v.Name, version)
+	name := StatusContextPrefix + AppendVersionToName(v.Name, version)
+	if Shard != "" && v.hasShardStatus() {
+		name += shardStatusSuffix + Shard + ")"
+	}
+	return name
+}
+
+// IsCIStatusContext returns whether the given PR status context belongs to
+// this CI deployment, i.e. whether it has the configured StatusContextPrefix
+// and names a known validator. This allows stale statuses to be cleaned up
+// without touching those posted by other CI deployments on the same repo.
+func IsCIStatusContext(context string) bool {
+	if !strings.HasPrefix(context, StatusContextPrefix) {
+		return false
+	}
+	name := strings.SplitN(strings.TrimPrefix(context, StatusContextPrefix), shardStatusSuffix, 2)[0]
+	name = strings.SplitN(name, "@", 2)[0]
+	for _, v := range Validators {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadUserConfig sets the global CI configuration that is relayed by cmd_gen
+// to later CI steps through UserConfigDir, i.e. the validators defined by the
+// models repo and the Plan. Files that don't exist are ignored, leaving the
+// configuration at its default value.
+func ReadUserConfig() error {
+	// Validators must be registered first since the rest of the
+	// configuration may refer to them.
+	validatorsConfig, err := ReadValidatorsConfig(ValidatorsConfigFile)
+	if err != nil {
+		return err
+	}
+	if validatorsConfig != nil {
+		validatorsConfig.Register()
+	}
+
+	p, err := ReadPlan(PlanFile)
+	if err != nil || p == nil {
+		return err
+	}
+	StatusContextPrefix = p.StatusContextPrefix
+	Shard = p.Shard
+	ReleaseTag = p.ReleaseTag
+	if p.DefaultBranch != "" {
+		DefaultBranch = p.DefaultBranch
+	}
+	ShadowMode = p.ShadowMode
+	StatusCommentPR = p.StatusCommentPR
+	CondensedReport = p.CondensedReport
+	PyangMatrix = p.PyangMatrix
+	ReportOnlyRollout = p.ReportOnlyRollouts
+	MaxReportedLevels = p.MaxReportedLevels
+	ReporterService = p.ReporterService
+	Banner = p.Banner
+	return nil
+}
+
+// ParseMaxReportedLevels converts a comma-separated list of
+// <validatorId>=<level> entries (e.g. "oc-pyang=3,pyangbind=2") to a map of
+// validatorId to the maximum message level to report.
+func ParseMaxReportedLevels(maxReportedLevelsStr string) (map[string]uint32, error) {
+	levels := map[string]uint32{}
+	for _, entry := range strings.Fields(strings.ReplaceAll(maxReportedLevelsStr, ",", " ")) {
+		segments := strings.SplitN(entry, "=", 2)
+		if len(segments) != 2 {
+			return nil, fmt.Errorf("invalid entry %q, expected <validatorId>=<level>", entry)
+		}
+		if _, ok := Validators[segments[0]]; !ok {
+			return nil, fmt.Errorf("unrecognized validatorId %q in entry %q", segments[0], entry)
+		}
+		level, err := strconv.ParseUint(segments[1], 10, 32)
+		if err != nil || level == 0 {
+			return nil, fmt.Errorf("invalid level in entry %q, expected a positive integer", entry)
+		}
+		levels[segments[0]] = uint32(level)
+	}
+	return levels, nil
 }
 
 var (
+	// StatusContextPrefix is prepended to the context of every PR status
+	// posted by the CI (e.g. "models-ci/"), such that multiple CI
+	// deployments (e.g. staging and prod) against the same repo do not
+	// collide.
+	StatusContextPrefix string
+
+	// Shard is the N/M shard (e.g. "2/4") of the model directories
+	// validated by the per-model validators in this build, or empty if
+	// the model directories aren't split across builds (see cmd_gen's
+	// -shard flag).
+	Shard string
+
+	// ShadowMode indicates that the CI should compute and upload all of
+	// its results as usual, but should not post any statuses, comments or
+	// labels to the PR. Results are only available through the gists and
+	// the staging badge bucket. This allows maintainers to trial changes
+	// to the CI on real PRs without affecting contributors.
+	ShadowMode bool
+
+	// StatusCommentPR is the number of the PR being validated if its head
+	// is a fork, or 0 otherwise. The access token often lacks permission
+	// to post commit statuses to fork PRs, in which case the statuses are
+	// instead recorded in a single comment on the PR rather than failing.
+	StatusCommentPR int
+
+	// MaxReportedLevels is the maximum level of structured (i.e. pyang)
+	// warnings that's reported in each validator's parsed results, keyed
+	// by validatorId. Level 1 is the most severe, and errors are reported
+	// at every level. Less severe warnings (e.g. info-level plugin
+	// messages) are only available in the full results, which are posted
+	// separately to the gist. Validators without an entry report all
+	// levels.
+	MaxReportedLevels map[string]uint32
+
+	// CondensedReport indicates that only the condensed (i.e. failures
+	// only) results of each validator should be posted to the gists.
+	CondensedReport bool
+
+	// PyangMatrix indicates that the results of every pyang version are
+	// reported together by the "pyang-matrix" status, linking to a page
+	// tabulating each model's result for each version, rather than by a
+	// standalone status for each non-latest version.
+	PyangMatrix bool
+
+	// ReportOnlyRollout contains the validators and validator versions in
+	// report-only rollout, which post their results as usual but whose PR
+	// status never fails, such that new validators (e.g. a new major
+	// version of pyang) can be introduced without blocking PRs.
+	ReportOnlyRollout ReportOnlyRollouts
+
+	// Banner is a markdown announcement (e.g. of an upcoming validator
+	// requirement) prepended to every report posted by the CI.
+	Banner string
+
+	// ReleaseTag is the release tag (e.g. v1.2.0) of the commit being
+	// validated when the CI is triggered by a tag rather than by a PR or a
+	// push to the default branch. All validators are then run, and their
+	// badges and artifacts are uploaded for the release.
+	ReleaseTag string
+
+	// DefaultBranch is the default branch of the models repo (e.g. "main"),
+	// pushes to which upload the badges and artifacts of the latest
+	// results.
+	DefaultBranch = "master"
+
+	// ReporterService indicates that a long-lived post_results reporter
+	// service posts the results of each validator as soon as it's done,
+	// rather than each validator step invoking post_results.
+	ReporterService bool
+
+	// SpecPathPrefix is the prefix of the build and examples paths within
+	// .spec.yml files that stands for the model root, which ParseOCModels
+	// replaces with the model root's path (e.g. "yang/acl/openconfig-acl.yang"
+	// within the "release/models" model root becomes
+	// "release/models/acl/openconfig-acl.yang"). Paths without the prefix
+	// are taken to be relative to the model root.
+	SpecPathPrefix = "yang/"
+
 	// Validators contains the set of supported validators to be run under CI.
 	// The key is a unique identifier that's safe to use as a directory name.
 	Validators = map[string]*Validator{
@@ -138,6 +398,9 @@ var (
 			IsPerModel:       true,
 			IsWidelyUsedTool: true,
 			SupportedVersion: "2.2",
+			HasExtraVersions: true,
+			HasHead:          true,
+			Cacheable:        true,
 		},
 		"oc-pyang": {
 			Name:             "OpenConfig Linter",
@@ -148,26 +411,63 @@ var (
 			Name:             "pyangbind",
 			IsPerModel:       true,
 			IsWidelyUsedTool: true,
+			Cacheable:        true,
 		},
 		"goyang-ygot": {
 			Name:             "goyang/ygot",
 			IsPerModel:       true,
 			IsWidelyUsedTool: true,
+			Cacheable:        true,
 		},
 		"ygnmi": {
 			Name:             "ygnmi",
 			IsPerModel:       true,
 			IsWidelyUsedTool: true,
+			Cacheable:        true,
+		},
+		"yang-examples": {
+			Name:       "YANG examples",
+			IsPerModel: true,
+			Cacheable:  true,
+		},
+		"ygot-proto": {
+			Name:       "ygot proto_generator",
+			IsPerModel: true,
+			Cacheable:  true,
+		},
+		"gnmi-paths": {
+			Name:       "gNMI path extraction",
+			IsPerModel: true,
+			Cacheable:  true,
+		},
+		"tree-diff": {
+			Name:       "pyang tree diff",
+			IsPerModel: true,
 		},
 		"yanglint": {
 			Name:             "yanglint",
 			IsPerModel:       true,
 			IsWidelyUsedTool: true,
+			SupportedVersion: "2.0",
+			HasExtraVersions: true,
+			HasPatchWildcard: true,
+			Cacheable:        true,
 		},
 		"confd": {
 			Name:             "ConfD Basic",
 			IsPerModel:       true,
 			IsWidelyUsedTool: true,
+			SupportedVersion: "7.3",
+			HasExtraVersions: true,
+			Cacheable:        true,
+		},
+		// yangson is a commercial-free substitute for ConfD Basic, and
+		// so is skipped unless selected by cmd_gen -confd-substitute.
+		"yangson": {
+			Name:             "yangson",
+			IsPerModel:       true,
+			IsWidelyUsedTool: true,
+			Cacheable:        true,
 		},
 		"regexp": {
 			Name:       "regexp tests",
@@ -178,6 +478,13 @@ var (
 			IsPerModel:  true,
 			IgnoreRunCi: true,
 		},
+		// This is a report-only entry for cmd_gen's validation of the
+		// .spec.yml files, which is posted before any validators run.
+		"spec-check": {
+			Name:       "Spec Check",
+			IsPerModel: false,
+			ReportOnly: true,
+		},
 		// This is a report-only entry for all validators configured to
 		// report as a compatibility check instead of as a standalone
 		// PR status.
@@ -186,6 +493,22 @@ var (
 			IsPerModel: false,
 			ReportOnly: true,
 		},
+		// This is a report-only entry for the pyang version matrix,
+		// which reports the results of all pyang versions when
+		// PyangMatrix is set.
+		"pyang-matrix": {
+			Name:       "pyang version matrix",
+			IsPerModel: false,
+			ReportOnly: true,
+		},
+		// This is a report-only entry for the aggregate status of the
+		// validators required by the RequiredStatusPolicy, such that
+		// branch protection can require a single status context.
+		"required": {
+			Name:       "required",
+			IsPerModel: false,
+			ReportOnly: true,
+		},
 	}
 
 	// LabelColors are some helper hex colours for posting to GitHub.
@@ -211,15 +534,41 @@ type ModelInfo struct {
 	DocFiles   []string `yaml:"docs"`
 	BuildFiles []string `yaml:"build"`
 	RunCi      bool     `yaml:"run-ci"`
+	// ExamplesDir is the directory of example instance documents (JSON or
+	// XML) of the model, which are validated against it by yang-examples.
+	ExamplesDir string `yaml:"examples"`
+	// Retries is the number of times that each validator (keyed by
+	// validatorId) re-runs the model after it fails, e.g. when the
+	// validator fails transiently while fetching dependencies.
+	Retries map[string]int `yaml:"retries"`
+	// Env are the environment variables set for every validator command
+	// of the model, e.g. feature flags of a validator. Only valid names
+	// (see ValidEnvName) are kept by ParseOCModels.
+	Env map[string]string `yaml:"env"`
+	// InvalidEnvNames are the invalid names of environment variables given
+	// by the .spec.yml, which were removed from Env by ParseOCModels since
+	// they'd be interpreted by the shell. The validators don't run on such
+	// a model.
+	InvalidEnvNames []string `yaml:"-"`
+	// ExtraArgs are the extra command-line arguments passed to each
+	// validator (keyed by validatorId) when validating the model.
+	ExtraArgs map[string][]string `yaml:"extra-args"`
+	// SpecFile is the path to the .spec.yml file defining the model, and
+	// Line is the line of the model's entry within it.
+	SpecFile string `yaml:"-"`
+	Line     int    `yaml:"-"`
+	// BuildFileLines are the lines of each of BuildFiles within SpecFile.
+	BuildFileLines []int `yaml:"-"`
 }
 
 // OpenConfigModelMap represents the directory structure and model information
 // of the entire OpenConfig models required for CI.
 type OpenConfigModelMap struct {
-	// ModelRoot is the path to the OpenConfig models root directory.
-	ModelRoot string
+	// ModelRoots are the paths to the OpenConfig models root directories.
+	ModelRoots []string
 	// ModelInfoMap stores all ModelInfo for each model directory keyed by
-	// the relative path to the model directory's .spec.yml.
+	// the relative path to the model directory's .spec.yml from its model
+	// root.
 	ModelInfoMap map[string][]ModelInfo
 }
 
@@ -246,47 +595,143 @@ func (m OpenConfigModelMap) SingleLineBuildFiles() string {
 	return strings.Join(buildFiles, " ")
 }
 
-// ParseOCModels walks the path given at modelRoot to populate the OpenConfigModelMap.
-func ParseOCModels(modelRoot string) (OpenConfigModelMap, error) {
-	modelInfoMap := map[string][]ModelInfo{}
-	err := filepath.Walk(modelRoot, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("prevent panic by handling failure accessing a path %q: %v", path, err)
+// SplitModelRoots splits a comma-separated list of model root directories.
+func SplitModelRoots(modelRoots string) []string {
+	var roots []string
+	for _, root := range strings.Split(modelRoots, ",") {
+		if root = strings.TrimSpace(root); root != "" {
+			roots = append(roots, root)
 		}
-		if !info.IsDir() && info.Name() == ".spec.yml" {
-			file, err := os.Open(path)
-			if err != nil {
-				return fmt.Errorf("failed to open spec file at path %q: %v", path, err)
-			}
-			m := []ModelInfo{}
-			if err := yaml.NewDecoder(file).Decode(&m); err != nil {
-				return fmt.Errorf("error while unmarshalling spec file at path %q: %v", path, err)
-			}
+	}
+	return roots
+}
 
-			// Change the build paths to the absolute correct paths.
-			for _, info := range m {
-				for i, fileName := range info.BuildFiles {
-					info.BuildFiles[i] = filepath.Join(modelRoot, strings.TrimPrefix(fileName, "yang/"))
+// RelModelPath returns path relative to the first of modelRoots containing it.
+func RelModelPath(modelRoots []string, path string) (string, error) {
+	for _, root := range modelRoots {
+		relPath, err := filepath.Rel(root, path)
+		if err == nil && relPath != ".." && !strings.HasPrefix(relPath, "../") {
+			return relPath, nil
+		}
+	}
+	return "", fmt.Errorf("path %q is not within any model root %q", path, modelRoots)
+}
+
+// setSpecPositions sets the spec file of the models decoded from the given
+// .spec.yml document node, along with the lines of their entries and build
+// files.
+func setSpecPositions(models []ModelInfo, specFile string, doc *yaml.Node) {
+	for i := range models {
+		models[i].SpecFile = specFile
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.SequenceNode {
+		return
+	}
+	for i, entry := range doc.Content[0].Content {
+		if i >= len(models) {
+			break
+		}
+		models[i].Line = entry.Line
+		// The mapping's content alternates between keys and values.
+		for j := 0; j+1 < len(entry.Content); j += 2 {
+			if key, value := entry.Content[j], entry.Content[j+1]; key.Value == "build" && value.Kind == yaml.SequenceNode {
+				for _, buildFile := range value.Content {
+					models[i].BuildFileLines = append(models[i].BuildFileLines, buildFile.Line)
 				}
 			}
+		}
+	}
+}
+
+// envNameRegex matches the valid names of environment variables.
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidEnvName returns whether name is a valid environment variable name,
+// which is safe to put unquoted within a shell command.
+func ValidEnvName(name string) bool {
+	return envNameRegex.MatchString(name)
+}
 
-			relPath, err := filepath.Rel(modelRoot, filepath.Dir(path))
+// removeInvalidEnvNames moves the invalid environment variable names of each
+// model from its Env into its InvalidEnvNames.
+func removeInvalidEnvNames(models []ModelInfo) {
+	for i := range models {
+		for name := range models[i].Env {
+			if !ValidEnvName(name) {
+				models[i].InvalidEnvNames = append(models[i].InvalidEnvNames, name)
+				delete(models[i].Env, name)
+			}
+		}
+		sort.Strings(models[i].InvalidEnvNames)
+	}
+}
+
+// ParseOCModels walks each of the comma-separated root directories given at
+// modelRoots to populate the OpenConfigModelMap. Since model directories are
+// keyed by their path relative to their model root, the same model directory
+// may not exist within multiple model roots.
+func ParseOCModels(modelRoots string) (OpenConfigModelMap, error) {
+	roots := SplitModelRoots(modelRoots)
+	modelInfoMap := map[string][]ModelInfo{}
+	// modelDirRoots stores the model root of each model directory for
+	// collision detection.
+	modelDirRoots := map[string]string{}
+	for _, modelRoot := range roots {
+		err := filepath.Walk(modelRoot, func(path string, info os.FileInfo, err error) error {
 			if err != nil {
-				return fmt.Errorf("failed to calculate relpath at path %q (modelRoot %q): %v", path, modelRoot, err)
+				return fmt.Errorf("prevent panic by handling failure accessing a path %q: %v", path, err)
 			}
-			// Allow nested model directories to be used later on as a partial file name.
-			relPath = strings.ReplaceAll(relPath, "/", ":")
-			modelInfoMap[relPath] = m
+			if !info.IsDir() && info.Name() == ".spec.yml" {
+				file, err := os.Open(path)
+				if err != nil {
+					return fmt.Errorf("failed to open spec file at path %q: %v", path, err)
+				}
+				var node yaml.Node
+				m := []ModelInfo{}
+				if err := yaml.NewDecoder(file).Decode(&node); err != nil {
+					return fmt.Errorf("error while unmarshalling spec file at path %q: %v", path, err)
+				}
+				if err := node.Decode(&m); err != nil {
+					return fmt.Errorf("error while unmarshalling spec file at path %q: %v", path, err)
+				}
+				setSpecPositions(m, path, &node)
+				removeInvalidEnvNames(m)
+
+				// Change the build and examples paths to the absolute correct paths.
+				for j, info := range m {
+					for i, fileName := range info.BuildFiles {
+						info.BuildFiles[i] = filepath.Join(modelRoot, strings.TrimPrefix(fileName, SpecPathPrefix))
+					}
+					if info.ExamplesDir != "" {
+						m[j].ExamplesDir = filepath.Join(modelRoot, strings.TrimPrefix(info.ExamplesDir, SpecPathPrefix))
+					}
+				}
+
+				relPath, err := filepath.Rel(modelRoot, filepath.Dir(path))
+				if err != nil {
+					return fmt.Errorf("failed to calculate relpath at path %q (modelRoot %q): %v", path, modelRoot, err)
+				}
+				// Allow nested model directories to be used later on as a partial file name.
+				relPath = strings.ReplaceAll(relPath, "/", ":")
+				if otherRoot, ok := modelDirRoots[relPath]; ok {
+					return fmt.Errorf("model directory %q exists within multiple model roots: %q and %q", relPath, otherRoot, modelRoot)
+				}
+				modelDirRoots[relPath] = modelRoot
+				modelInfoMap[relPath] = m
+			}
+			return nil
+		})
+		if err != nil {
+			return OpenConfigModelMap{ModelRoots: roots, ModelInfoMap: modelInfoMap}, err
 		}
-		return nil
-	})
+	}
 
-	return OpenConfigModelMap{ModelRoot: modelRoot, ModelInfoMap: modelInfoMap}, err
+	return OpenConfigModelMap{ModelRoots: roots, ModelInfoMap: modelInfoMap}, nil
 }
 
 type ValidatorAndVersion struct {
-	ValidatorId string
-	Version     string
+	ValidatorId string `json:"validatorId"`
+	Version     string `json:"version,omitempty"`
 }
 
 // GetValidatorAndVersionsFromString converts a comma-separated list of
@@ -15,6 +15,8 @@
 package commonci
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -22,7 +24,7 @@ import (
 
 var (
 	basicModelMap = OpenConfigModelMap{
-		ModelRoot: "testdata",
+		ModelRoots: []string{"testdata"},
 		ModelInfoMap: map[string][]ModelInfo{
 			"acl": {{
 				Name: "openconfig-acl",
@@ -34,7 +36,10 @@ var (
 					"testdata/acl/openconfig-acl.yang",
 					"testdata/acl/openconfig-acl-evil-twin.yang",
 				},
-				RunCi: true,
+				RunCi:          true,
+				SpecFile:       "testdata/acl/.spec.yml",
+				Line:           15,
+				BuildFileLines: []int{20, 21},
 			}},
 			"optical-transport": {{
 				Name: "openconfig-terminal-device",
@@ -44,13 +49,18 @@ var (
 					"yang/optical-transport/openconfig-terminal-device.yang",
 					"yang/platform/openconfig-platform-transceiver.yang",
 				},
-				RunCi: true,
+				RunCi:    true,
+				SpecFile: "testdata/optical-transport/.spec.yml",
+				Line:     15,
 			}, {
 				Name: "openconfig-optical-amplifier",
 				BuildFiles: []string{
 					"testdata/optical-transport/openconfig-optical-amplifier.yang",
 				},
-				RunCi: true,
+				RunCi:          true,
+				SpecFile:       "testdata/optical-transport/.spec.yml",
+				Line:           22,
+				BuildFileLines: []int{24},
 			}, {
 				Name: "openconfig-wavelength-router",
 				DocFiles: []string{
@@ -64,7 +74,10 @@ var (
 					"testdata/optical-transport/openconfig-transport-line-connectivity.yang",
 					"testdata/optical-transport/openconfig-wavelength-router.yang",
 				},
-				RunCi: false,
+				RunCi:          false,
+				SpecFile:       "testdata/optical-transport/.spec.yml",
+				Line:           26,
+				BuildFileLines: []int{34, 35},
 			}, {
 				Name: "openconfig-transport-line-protection",
 				DocFiles: []string{
@@ -75,7 +88,10 @@ var (
 				BuildFiles: []string{
 					"testdata/optical-transport/openconfig-transport-line-protection.yang",
 				},
-				RunCi: true,
+				RunCi:          true,
+				SpecFile:       "testdata/optical-transport/.spec.yml",
+				Line:           37,
+				BuildFileLines: []int{43},
 			}, {
 				Name: "openconfig-optical-attenuator",
 				DocFiles: []string{
@@ -84,28 +100,150 @@ var (
 				BuildFiles: []string{
 					"testdata/optical-transport/openconfig-optical-attenuator.yang",
 				},
-				RunCi: false,
+				RunCi:          false,
+				SpecFile:       "testdata/optical-transport/.spec.yml",
+				Line:           45,
+				BuildFileLines: []int{49},
 			}},
 		},
 	}
 )
 
 func TestParseOCModels(t *testing.T) {
+	writeSpec := func(modelRoot, modelDir, content string) {
+		path := filepath.Join(modelRoot, modelDir, ".spec.yml")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	experimentalRoot := t.TempDir()
+	writeSpec(experimentalRoot, "wifi/mac", `- name: openconfig-wifi-mac
+  build:
+    - yang/wifi/mac/openconfig-wifi-mac.yang
+  run-ci: true
+`)
+	prefixedRoot := t.TempDir()
+	writeSpec(prefixedRoot, "wifi/mac", `- name: openconfig-wifi-mac
+  build:
+    - models/wifi/mac/openconfig-wifi-mac.yang
+  examples: models/wifi/mac/examples
+  run-ci: true
+`)
+	envRoot := t.TempDir()
+	writeSpec(envRoot, "aft", `- name: openconfig-aft
+  build:
+    - yang/aft/openconfig-aft.yang
+  run-ci: true
+  env:
+    YANGLINT_FEATURES: all
+    "X;touch pwned": x
+    BAD-NAME: x
+`)
+	collidingRoot := t.TempDir()
+	writeSpec(collidingRoot, "acl", `- name: openconfig-acl-experimental
+  run-ci: true
+`)
+
+	// An augment tree without .spec.yml files only contributes search paths.
+	augmentRoot := t.TempDir()
+	if err := os.WriteFile(filepath.Join(augmentRoot, "vendor-augments.yang"), []byte("module vendor-augments {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	multipleRootsModelMap := OpenConfigModelMap{
+		ModelRoots:   []string{"testdata", experimentalRoot},
+		ModelInfoMap: map[string][]ModelInfo{},
+	}
+	for modelDirName, modelInfos := range basicModelMap.ModelInfoMap {
+		multipleRootsModelMap.ModelInfoMap[modelDirName] = modelInfos
+	}
+	multipleRootsModelMap.ModelInfoMap["wifi:mac"] = []ModelInfo{{
+		Name:           "openconfig-wifi-mac",
+		BuildFiles:     []string{filepath.Join(experimentalRoot, "wifi/mac/openconfig-wifi-mac.yang")},
+		RunCi:          true,
+		SpecFile:       filepath.Join(experimentalRoot, "wifi/mac/.spec.yml"),
+		Line:           1,
+		BuildFileLines: []int{3},
+	}}
+
 	tests := []struct {
-		name        string
-		inModelRoot string
-		want        OpenConfigModelMap
+		name             string
+		inModelRoot      string
+		inSpecPathPrefix string
+		want             OpenConfigModelMap
+		wantErr          bool
 	}{{
 		name:        "basic",
 		inModelRoot: "testdata",
 		want:        basicModelMap,
+	}, {
+		name:        "multiple model roots",
+		inModelRoot: "testdata, " + experimentalRoot,
+		want:        multipleRootsModelMap,
+	}, {
+		name:        "model root without spec files",
+		inModelRoot: "testdata," + augmentRoot,
+		want: OpenConfigModelMap{
+			ModelRoots:   []string{"testdata", augmentRoot},
+			ModelInfoMap: basicModelMap.ModelInfoMap,
+		},
+	}, {
+		name:             "custom spec path prefix",
+		inModelRoot:      prefixedRoot,
+		inSpecPathPrefix: "models/",
+		want: OpenConfigModelMap{
+			ModelRoots: []string{prefixedRoot},
+			ModelInfoMap: map[string][]ModelInfo{
+				"wifi:mac": {{
+					Name:           "openconfig-wifi-mac",
+					BuildFiles:     []string{filepath.Join(prefixedRoot, "wifi/mac/openconfig-wifi-mac.yang")},
+					ExamplesDir:    filepath.Join(prefixedRoot, "wifi/mac/examples"),
+					RunCi:          true,
+					SpecFile:       filepath.Join(prefixedRoot, "wifi/mac/.spec.yml"),
+					Line:           1,
+					BuildFileLines: []int{3},
+				}},
+			},
+		},
+	}, {
+		name:        "invalid environment variable names",
+		inModelRoot: envRoot,
+		want: OpenConfigModelMap{
+			ModelRoots: []string{envRoot},
+			ModelInfoMap: map[string][]ModelInfo{
+				"aft": {{
+					Name:            "openconfig-aft",
+					BuildFiles:      []string{filepath.Join(envRoot, "aft/openconfig-aft.yang")},
+					RunCi:           true,
+					Env:             map[string]string{"YANGLINT_FEATURES": "all"},
+					InvalidEnvNames: []string{"BAD-NAME", "X;touch pwned"},
+					SpecFile:        filepath.Join(envRoot, "aft/.spec.yml"),
+					Line:            1,
+					BuildFileLines:  []int{3},
+				}},
+			},
+		},
+	}, {
+		name:        "model directory within multiple model roots",
+		inModelRoot: "testdata," + collidingRoot,
+		wantErr:     true,
 	}}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.inSpecPathPrefix != "" {
+				defer func(prefix string) { SpecPathPrefix = prefix }(SpecPathPrefix)
+				SpecPathPrefix = tt.inSpecPathPrefix
+			}
 			got, err := ParseOCModels(tt.inModelRoot)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
 			if err != nil {
-				t.Fatal(err)
+				return
 			}
 
 			if diff := cmp.Diff(tt.want, got); diff != "" {
@@ -115,6 +253,66 @@ func TestParseOCModels(t *testing.T) {
 	}
 }
 
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fork-slug.txt")
+	for _, want := range []string{"old", "new"} {
+		if err := WriteFile(path, []byte(want), 0444); err != nil {
+			t.Fatalf("error while writing %q: %v", want, err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0444); got != want {
+		t.Errorf("got permissions %v, want %v", got, want)
+	}
+}
+
+func TestRelModelPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		inModelRoots []string
+		inPath       string
+		want         string
+		wantErr      bool
+	}{{
+		name:         "first root",
+		inModelRoots: []string{"release/models", "experimental"},
+		inPath:       "release/models/acl/openconfig-acl.yang",
+		want:         "acl/openconfig-acl.yang",
+	}, {
+		name:         "second root",
+		inModelRoots: []string{"release/models", "experimental"},
+		inPath:       "experimental/wifi/openconfig-wifi-mac.yang",
+		want:         "wifi/openconfig-wifi-mac.yang",
+	}, {
+		name:         "not within any root",
+		inModelRoots: []string{"release/models", "experimental"},
+		inPath:       "third_party/ietf/ietf-interfaces.yang",
+		wantErr:      true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RelModelPath(tt.inModelRoots, tt.inPath)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetValidatorAndVersionsFromString(t *testing.T) {
 	tests := []struct {
 		desc       string
@@ -270,3 +468,158 @@ func TestValidatorAndVersionsDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestStatusName(t *testing.T) {
+	tests := []struct {
+		desc        string
+		inPrefix    string
+		inShard     string
+		inValidator *Validator
+		inVersion   string
+		want        string
+	}{{
+		desc:        "no prefix",
+		inValidator: Validators["pyang"],
+		want:        "pyang",
+	}, {
+		desc:        "no prefix with version",
+		inValidator: Validators["pyang"],
+		inVersion:   "head",
+		want:        "pyang@head",
+	}, {
+		desc:        "prefix with version",
+		inPrefix:    "models-ci/",
+		inValidator: Validators["pyang"],
+		inVersion:   "head",
+		want:        "models-ci/pyang@head",
+	}, {
+		desc:        "shard",
+		inPrefix:    "models-ci/",
+		inShard:     "2/4",
+		inValidator: Validators["pyang"],
+		inVersion:   "head",
+		want:        "models-ci/pyang@head (shard 2/4)",
+	}, {
+		desc:        "report on shard",
+		inShard:     "2/4",
+		inValidator: Validators["required"],
+		want:        "required (shard 2/4)",
+	}, {
+		desc:        "unsharded validator",
+		inShard:     "2/4",
+		inValidator: Validators["misc-checks"],
+		want:        "Miscellaneous Checks",
+	}, {
+		desc:        "repo-level validator",
+		inShard:     "2/4",
+		inValidator: Validators["regexp"],
+		want:        "regexp tests",
+	}, {
+		desc:     "nil validator",
+		inPrefix: "models-ci/",
+		want:     "",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			StatusContextPrefix, Shard = tt.inPrefix, tt.inShard
+			defer func() { StatusContextPrefix, Shard = "", "" }()
+			if got := tt.inValidator.StatusName(tt.inVersion); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsCIStatusContext(t *testing.T) {
+	tests := []struct {
+		desc      string
+		inPrefix  string
+		inContext string
+		want      bool
+	}{{
+		desc:      "no prefix",
+		inContext: "goyang/ygot",
+		want:      true,
+	}, {
+		desc:      "prefix with version",
+		inPrefix:  "models-ci/",
+		inContext: "models-ci/pyang@1.7.8",
+		want:      true,
+	}, {
+		desc:      "missing prefix",
+		inPrefix:  "models-ci/",
+		inContext: "pyang",
+		want:      false,
+	}, {
+		desc:      "other deployment's prefix",
+		inPrefix:  "models-ci/",
+		inContext: "models-ci-staging/pyang",
+		want:      false,
+	}, {
+		desc:      "shard",
+		inPrefix:  "models-ci/",
+		inContext: "models-ci/pyang@1.7.8 (shard 2/4)",
+		want:      true,
+	}, {
+		desc:      "unknown validator",
+		inPrefix:  "models-ci/",
+		inContext: "models-ci/foo",
+		want:      false,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			StatusContextPrefix = tt.inPrefix
+			defer func() { StatusContextPrefix = "" }()
+			if got := IsCIStatusContext(tt.inContext); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMaxReportedLevels(t *testing.T) {
+	tests := []struct {
+		desc    string
+		in      string
+		want    map[string]uint32
+		wantErr bool
+	}{{
+		desc: "empty",
+		in:   "",
+		want: map[string]uint32{},
+	}, {
+		desc: "multiple validators",
+		in:   "oc-pyang=3, pyangbind=2",
+		want: map[string]uint32{"oc-pyang": 3, "pyangbind": 2},
+	}, {
+		desc:    "missing level",
+		in:      "oc-pyang",
+		wantErr: true,
+	}, {
+		desc:    "zero level",
+		in:      "oc-pyang=0",
+		wantErr: true,
+	}, {
+		desc:    "non-numeric level",
+		in:      "oc-pyang=warning",
+		wantErr: true,
+	}, {
+		desc:    "unknown validator",
+		in:      "foo=3",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseMaxReportedLevels(tt.in)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompatReportFormatVersion is the version of the CompatReport document
+// format. It must be incremented whenever a change to the format isn't
+// understood by older readers.
+const CompatReportFormatVersion = 1
+
+// CompatReport describes the validators that are reported within the
+// compatibility report instead of as standalone PR statuses. It is relayed by
+// cmd_gen to later CI steps within the Plan.
+type CompatReport struct {
+	// FormatVersion is the CompatReportFormatVersion of the document.
+	FormatVersion int `json:"formatVersion"`
+	// Members are the validators within the compatibility report.
+	Members []CompatReportMember `json:"members"`
+}
+
+// CompatReportMember is a validator@version within the compatibility report.
+type CompatReportMember struct {
+	ValidatorId string `json:"validatorId"`
+	Version     string `json:"version,omitempty"`
+	// Order is the display order of the validator within the report, in
+	// ascending order.
+	Order int `json:"order"`
+	// GatesMerge indicates that a failure of the validator fails the
+	// compatibility report's PR status, which may then be required for
+	// merging.
+	GatesMerge bool `json:"gatesMerge,omitempty"`
+}
+
+// NewCompatReport creates a CompatReport from the comma-separated list of
+// <validatorId>@<version> names within the report, displayed in the given
+// order, and the comma-separated list of those that gate merge.
+func NewCompatReport(members, gating string) (*CompatReport, error) {
+	memberVVs, _ := GetValidatorAndVersionsFromString(members)
+	gatingVVs, gatingMap := GetValidatorAndVersionsFromString(gating)
+	report := &CompatReport{FormatVersion: CompatReportFormatVersion, Members: []CompatReportMember{}}
+	for i, vv := range memberVVs {
+		report.Members = append(report.Members, CompatReportMember{
+			ValidatorId: vv.ValidatorId,
+			Version:     vv.Version,
+			Order:       i,
+			GatesMerge:  gatingMap[vv.ValidatorId][vv.Version],
+		})
+	}
+	for _, vv := range gatingVVs {
+		if _, ok := report.Member(vv.ValidatorId, vv.Version); !ok {
+			return nil, fmt.Errorf("gating validator %q is not in the compatibility report", AppendVersionToName(vv.ValidatorId, vv.Version))
+		}
+	}
+	return report, nil
+}
+
+// Member returns the member of the report for the validator@version, and
+// whether it's in the report.
+func (r *CompatReport) Member(validatorId, version string) (CompatReportMember, bool) {
+	for _, m := range r.Members {
+		if m.ValidatorId == validatorId && m.Version == version {
+			return m, true
+		}
+	}
+	return CompatReportMember{}, false
+}
+
+// OrderedMembers returns the members of the report in display order.
+func (r *CompatReport) OrderedMembers() []CompatReportMember {
+	members := append([]CompatReportMember{}, r.Members...)
+	sort.SliceStable(members, func(i, j int) bool { return members[i].Order < members[j].Order })
+	return members
+}
+
+// GatesMerge returns whether any member of the report gates merge.
+func (r *CompatReport) GatesMerge() bool {
+	for _, m := range r.Members {
+		if m.GatesMerge {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the comma-separated <validatorId>@<version> names of the
+// members in display order.
+func (r *CompatReport) String() string {
+	var names []string
+	for _, m := range r.OrderedMembers() {
+		names = append(names, AppendVersionToName(m.ValidatorId, m.Version))
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewCompatReport(t *testing.T) {
+	tests := []struct {
+		desc      string
+		inMembers string
+		inGating  string
+		want      *CompatReport
+		wantStr   string
+		wantGates bool
+		wantErr   bool
+	}{{
+		desc: "empty",
+		want: &CompatReport{FormatVersion: CompatReportFormatVersion, Members: []CompatReportMember{}},
+	}, {
+		desc:      "members in order with a gating validator",
+		inMembers: "pyang@head,goyang-ygot,pyang@head",
+		inGating:  "goyang-ygot",
+		want: &CompatReport{FormatVersion: CompatReportFormatVersion, Members: []CompatReportMember{
+			{ValidatorId: "pyang", Version: "head", Order: 0},
+			{ValidatorId: "goyang-ygot", Order: 1, GatesMerge: true},
+		}},
+		wantStr:   "pyang@head,goyang-ygot",
+		wantGates: true,
+	}, {
+		desc:      "gating validator not in report",
+		inMembers: "pyang@head",
+		inGating:  "pyang",
+		wantErr:   true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := NewCompatReport(tt.inMembers, tt.inGating)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+			if s := got.String(); s != tt.wantStr {
+				t.Errorf("String: got %q, want %q", s, tt.wantStr)
+			}
+			if gates := got.GatesMerge(); gates != tt.wantGates {
+				t.Errorf("GatesMerge: got %v, want %v", gates, tt.wantGates)
+			}
+		})
+	}
+}
+
+func TestCompatReportOrderedMembers(t *testing.T) {
+	r := &CompatReport{Members: []CompatReportMember{
+		{ValidatorId: "pyangbind", Order: 2},
+		{ValidatorId: "goyang-ygot", Order: 0},
+		{ValidatorId: "pyang", Version: "head", Order: 1},
+	}}
+	if got, want := r.String(), "goyang-ygot,pyang@head,pyangbind"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DisabledDirsFileName by convention is the file at the root of the models
+// repo listing the model directories whose models don't undergo CI, such that
+// they can be disabled without a models-ci release.
+const DisabledDirsFileName = ".ci-disabled-dirs.yml"
+
+// DisabledDirs represents a DisabledDirsFileName file.
+type DisabledDirs struct {
+	// Dirs are the disabled model directories relative to the model root
+	// (e.g. wifi/mac).
+	Dirs []string `yaml:"disabled-dirs"`
+}
+
+// ParseDisabledDirs parses the contents of a DisabledDirsFileName file, and
+// returns the set of disabled model directory names, which use ":" instead of
+// "/" as the delimiter as in OpenConfigModelMap.
+func ParseDisabledDirs(bs []byte) (map[string]bool, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	d := &DisabledDirs{}
+	if err := dec.Decode(d); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	disabled := map[string]bool{}
+	for _, dir := range d.Dirs {
+		dir = strings.Trim(dir, "/")
+		if dir == "" {
+			return nil, fmt.Errorf("empty disabled directory")
+		}
+		disabled[strings.ReplaceAll(dir, "/", ":")] = true
+	}
+	return disabled, nil
+}
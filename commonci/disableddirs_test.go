@@ -0,0 +1,67 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDisabledDirs(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string]bool
+		wantErr bool
+	}{{
+		name: "empty",
+		in:   "",
+		want: map[string]bool{},
+	}, {
+		name: "valid",
+		in: `
+disabled-dirs:
+  - acl
+  - wifi/mac
+  - wifi/phy/
+`,
+		want: map[string]bool{
+			"acl":      true,
+			"wifi:mac": true,
+			"wifi:phy": true,
+		},
+	}, {
+		name:    "empty directory",
+		in:      "disabled-dirs: [\"\"]\n",
+		wantErr: true,
+	}, {
+		name:    "unknown field",
+		in:      "disabled: [acl]\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDisabledDirs([]byte(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
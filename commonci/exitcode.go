@@ -0,0 +1,97 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// Exit codes of the models-ci binaries (cmd_gen, post_results, ci_progress
+// and openconfig-ci), such that the CI steps wrapping them can act on the
+// type of failure, e.g. retry infra errors but not configuration errors.
+// Binaries whose YANG parsing timed out exit with yangutil.TimeoutExitCode
+// (124).
+const (
+	// ExitFailure is the exit code of failures that aren't classified,
+	// e.g. internal errors.
+	ExitFailure = 1
+	// ExitConfigError is the exit code of invalid flags or CI
+	// configuration, e.g. .spec.yml files or the validators config, which
+	// won't succeed on a retry.
+	ExitConfigError = 2
+	// ExitInfraError is the exit code of failures of the CI's environment
+	// other than GitHub, e.g. unwritable files or an unavailable GCS or
+	// reporter service, which may succeed on a retry.
+	ExitInfraError = 3
+	// ExitValidationFailure is the exit code of a check that failed on the
+	// models themselves, e.g. disallowed breaking changes.
+	ExitValidationFailure = 4
+	// ExitGitHubError is the exit code of failing to read from or post to
+	// GitHub, whether GitHub was unavailable or rejected the request.
+	ExitGitHubError = 5
+)
+
+// ExitError is an error that determines the exit code of the binary.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// WithExitCode wraps err such that the binary exits with the given code, or
+// returns nil if err is nil.
+func WithExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// ExitCode returns the exit code of a binary failing with err: the code of
+// the outermost ExitError it wraps, ExitInfraError if it wraps an InfraError,
+// and ExitFailure otherwise.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	if IsInfraError(err) {
+		return ExitInfraError
+	}
+	return ExitFailure
+}
+
+// Fatal logs err and exits with its ExitCode, like log.Fatal.
+func Fatal(err error) {
+	log.Print(err)
+	os.Exit(ExitCode(err))
+}
+
+// Fatalf logs the formatted message and exits with the given code, like
+// log.Fatalf.
+func Fatalf(code int, format string, v ...interface{}) {
+	log.Print(fmt.Sprintf(format, v...))
+	os.Exit(code)
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   error
+		want int
+	}{{
+		desc: "unclassified",
+		in:   errors.New("oops"),
+		want: ExitFailure,
+	}, {
+		desc: "config error",
+		in:   WithExitCode(ExitConfigError, errors.New("invalid flag")),
+		want: ExitConfigError,
+	}, {
+		desc: "wrapped exit error",
+		in:   fmt.Errorf("posting status: %w", WithExitCode(ExitGitHubError, errors.New("401"))),
+		want: ExitGitHubError,
+	}, {
+		desc: "infra error",
+		in:   fmt.Errorf("uploading: %w", &InfraError{Service: "GCS", Err: errors.New("503")}),
+		want: ExitInfraError,
+	}, {
+		desc: "exit code overrides infra error",
+		in:   WithExitCode(ExitGitHubError, &InfraError{Service: "GitHub", Err: errors.New("503")}),
+		want: ExitGitHubError,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := ExitCode(tt.in); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if err := WithExitCode(ExitConfigError, nil); err != nil {
+		t.Errorf("WithExitCode of nil error: got %v, want nil", err)
+	}
+}
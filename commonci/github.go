@@ -20,8 +20,10 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/github"
@@ -33,10 +35,35 @@ import (
 type GithubRequestHandler struct {
 	// Client is the connection to GitHub that should be utilised.
 	client *github.Client
+	// checksClient is the connection to GitHub authenticated by the GitHub
+	// App's installation token given by ChecksTokenEnv, if any, which is
+	// only used to create check runs: unlike the access token, it can't
+	// create gists or read its user.
+	checksClient *github.Client
 	// accessToken is the OAuth token that should be used for interactions with
 	// the GitHub API and to retrieve repo contents.
 	accessToken string
-	labels      map[string]bool
+	// labels are the labels known to be on the PR, which aren't posted
+	// again. They are guarded by labelsMu, since labels may be posted
+	// concurrently.
+	labelsMu sync.Mutex
+	labels   map[string]bool
+	// shadow indicates that nothing should be posted to the PR (see
+	// ShadowMode).
+	shadow bool
+	// statusCommentPR is the number of the fork PR whose statuses are
+	// recorded in a PR comment if the access token lacks permission to
+	// post commit statuses (see StatusCommentPR), and statusDenied is
+	// whether posting a commit status has been refused.
+	statusCommentPR int
+	statusDenied    bool
+	// rate is the rate limit reported by the last response to a request
+	// creating content, and lastContentRequest is when it was made, by
+	// which further content creation is paced (see paceDelay).
+	rate               github.Rate
+	lastContentRequest time.Time
+	// login is the access token's user, once read by authenticatedLogin.
+	login string
 }
 
 // GithubPRUpdate is used to specify how an update to the status of a PR should
@@ -51,6 +78,49 @@ type GithubPRUpdate struct {
 	Context     string
 }
 
+// InfraError is an error from an external dependency of the CI (e.g. GitHub)
+// that persisted after retries, as opposed to a problem with the models being
+// validated or with the CI configuration.
+type InfraError struct {
+	// Service is the name of the failing dependency, e.g. "GitHub".
+	Service string
+	Err     error
+}
+
+func (e *InfraError) Error() string {
+	return fmt.Sprintf("%s unavailable: %v", e.Service, e.Err)
+}
+
+func (e *InfraError) Unwrap() error {
+	return e.Err
+}
+
+// IsInfraError returns whether err is or wraps an InfraError.
+func IsInfraError(err error) bool {
+	var infraErr *InfraError
+	return errors.As(err, &infraErr)
+}
+
+// classifyGitHubError wraps err as an InfraError unless it's a client error
+// response from GitHub (e.g. a 404 or a validation failure), which indicates
+// a problem with the request rather than with GitHub. Rate limit errors are
+// infra errors since the request would otherwise have succeeded.
+func classifyGitHubError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var errResp *github.ErrorResponse
+	if errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode < 500 {
+		return err
+	}
+	return &InfraError{Service: "GitHub", Err: err}
+}
+
+// retry retries a GitHub API call, classifying the final error.
+func retry(name string, f func() error) error {
+	return classifyGitHubError(Retry(5, name, f))
+}
+
 // Retry retries a function maxN times or when it returns true.
 // In between each retry there is a small delay.
 // This is intended to be used for posting results to GitHub from GCB, which
@@ -86,17 +156,62 @@ func (g *GithubRequestHandler) CreateCIOutputGist(description, content string) (
 		},
 	}
 
-	if err := Retry(5, fmt.Sprintf("gist creation for %s with content\n%s\n", description, content), func() error {
+	if err := retry(fmt.Sprintf("gist creation for %s with content\n%s\n", description, content), func() error {
 		var err error
 		gist, _, err = g.client.Gists.Create(ctx, gist)
 		return err
 	}); err != nil {
-		return "", "", fmt.Errorf("could not create gist: %s", err)
+		return "", "", fmt.Errorf("could not create gist: %w", err)
 	}
 	return *gist.HTMLURL, *gist.ID, nil
 }
 
-// AddGistComment adds a comment to a gist and returns its ID.
+const (
+	// minContentInterval is the minimum time between requests creating
+	// content (e.g. gist comments), below which GitHub's secondary rate
+	// limits may reject them.
+	minContentInterval = time.Second
+	// rateLimitReserve is the number of remaining requests of the rate
+	// limit below which the remaining requests are spread out until the
+	// rate limit resets.
+	rateLimitReserve = 50
+	// maxPaceDelay is the maximum delay of a single request, beyond which
+	// it is made anyway, relying on retries instead.
+	maxPaceDelay = time.Minute
+)
+
+// paceDelay returns how long to wait at now before making a request creating
+// content, given the rate limit reported by the last such request made at
+// last.
+func paceDelay(rate github.Rate, last, now time.Time) time.Duration {
+	delay := last.Add(minContentInterval).Sub(now)
+	if rate.Limit != 0 && rate.Remaining < rateLimitReserve {
+		untilReset := rate.Reset.Time.Sub(now)
+		if spread := untilReset / time.Duration(rate.Remaining+1); spread > delay {
+			delay = spread
+		}
+	}
+	switch {
+	case delay < 0:
+		return 0
+	case delay > maxPaceDelay:
+		return maxPaceDelay
+	}
+	return delay
+}
+
+// paceContentRequest waits before a request creating content as given by
+// paceDelay.
+func (g *GithubRequestHandler) paceContentRequest() {
+	if delay := paceDelay(g.rate, g.lastContentRequest, time.Now()); delay > 0 {
+		log.Printf("Pacing GitHub content creation (rate limit: %d of %d remaining), waiting %v", g.rate.Remaining, g.rate.Limit, delay)
+		time.Sleep(delay)
+	}
+	g.lastContentRequest = time.Now()
+}
+
+// AddGistComment adds a comment to a gist and returns its ID. Successive
+// comments are paced according to GitHub's rate limits.
 func (g *GithubRequestHandler) AddGistComment(gistID, title, output string) (int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel() // cancel context if the function returns before the timeout
@@ -108,8 +223,12 @@ func (g *GithubRequestHandler) AddGistComment(gistID, title, output string) (int
 	}
 
 	var id int64
-	if err := Retry(5, "gist comment creation", func() error {
-		c, _, err := g.client.Gists.CreateComment(ctx, gistID, &github.GistComment{Body: &gistComment})
+	if err := retry("gist comment creation", func() error {
+		g.paceContentRequest()
+		c, resp, err := g.client.Gists.CreateComment(ctx, gistID, &github.GistComment{Body: &gistComment})
+		if resp != nil {
+			g.rate = resp.Rate
+		}
 		if err != nil {
 			return err
 		}
@@ -124,10 +243,18 @@ func (g *GithubRequestHandler) AddGistComment(gistID, title, output string) (int
 // UpdatePRStatus takes an input githubPRUpdate struct and updates a GitHub
 // pull request's status with the relevant details. It returns an error if
 // the update was not successful.
+//
+// If the handler is for a fork PR (see StatusCommentPR) and GitHub refuses
+// to create commit statuses, then the status is instead recorded in a
+// single comment on the PR.
 func (g *GithubRequestHandler) UpdatePRStatus(update *GithubPRUpdate) error {
 	if !validStatuses[update.NewStatus] {
 		return fmt.Errorf("invalid status %s", update.NewStatus)
 	}
+	if g.shadow {
+		log.Printf("shadow mode: not posting PR status: %+v", update)
+		return nil
+	}
 
 	if update.NewStatus == "" || update.Repo == "" || update.Ref == "" || update.Owner == "" {
 		return fmt.Errorf("must specify required fields (status (%s), repo (%s), reference (%s) and owner (%s)) for update", update.NewStatus, update.Repo, update.Ref, update.Owner)
@@ -152,10 +279,121 @@ func (g *GithubRequestHandler) UpdatePRStatus(update *GithubPRUpdate) error {
 		status.Description = &update.Description
 	}
 
-	return Retry(5, "PR status update", func() error {
+	if g.statusDenied {
+		return g.updateStatusComment(update, g.statusCommentPR)
+	}
+	var deniedErr error
+	if err := retry("PR status update", func() error {
 		_, _, err := g.client.Repositories.CreateStatus(ctx, update.Owner, update.Repo, update.Ref, status)
+		if g.statusCommentPR != 0 && isPermissionError(err) {
+			// Retrying won't grant the permission.
+			deniedErr = err
+			return nil
+		}
+		return err
+	}); err != nil {
+		return err
+	}
+	if deniedErr != nil {
+		log.Printf("not permitted to post commit statuses to fork PR #%d, recording statuses in a PR comment instead: %v", g.statusCommentPR, deniedErr)
+		g.statusDenied = true
+		return g.updateStatusComment(update, g.statusCommentPR)
+	}
+	return nil
+}
+
+// MergedPRForCommit returns the number and head SHA of the merged PR whose
+// merge commit (including a squash or rebase merge's commit) is sha, or 0 if
+// there isn't one, e.g. for a direct push. Reading is unaffected by shadow
+// mode.
+func (g *GithubRequestHandler) MergedPRForCommit(owner, repo, sha string) (int, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	// go-github doesn't support listing the PRs associated with a commit.
+	var prs []*github.PullRequest
+	if err := retry("listing PRs of commit", func() error {
+		req, err := g.client.NewRequest("GET", fmt.Sprintf("repos/%s/%s/commits/%s/pulls", owner, repo, sha), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Accept", "application/vnd.github.groot-preview+json")
+		prs = nil
+		_, err = g.client.Do(ctx, req, &prs)
 		return err
-	})
+	}); err != nil {
+		return 0, "", err
+	}
+	for _, pr := range prs {
+		if pr.MergedAt != nil && pr.GetMergeCommitSHA() == sha {
+			return pr.GetNumber(), pr.GetHead().GetSHA(), nil
+		}
+	}
+	return 0, "", nil
+}
+
+// combinedStatuses returns the latest status of each context on ref.
+func (g *GithubRequestHandler) combinedStatuses(ctx context.Context, owner, repo, ref string) ([]github.RepoStatus, error) {
+	var statuses []github.RepoStatus
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var combined *github.CombinedStatus
+		var resp *github.Response
+		if err := retry("getting combined status", func() error {
+			var err error
+			combined, resp, err = g.client.Repositories.GetCombinedStatus(ctx, owner, repo, ref, opts)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, combined.Statuses...)
+		if resp.NextPage == 0 {
+			return statuses, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// CopyFinalStatuses copies the latest final (i.e. not pending) status of each
+// of this CI deployment's status contexts (see IsCIStatusContext) on fromRef
+// onto toRef, and returns the copied contexts. Contexts that toRef already
+// has, e.g. since CI ran on it, aren't copied.
+func (g *GithubRequestHandler) CopyFinalStatuses(owner, repo, fromRef, toRef string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	statuses, err := g.combinedStatuses(ctx, owner, repo, fromRef)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := g.combinedStatuses(ctx, owner, repo, toRef)
+	if err != nil {
+		return nil, err
+	}
+	hasContext := map[string]bool{}
+	for _, s := range existing {
+		hasContext[s.GetContext()] = true
+	}
+
+	var copied []string
+	for _, s := range statuses {
+		if !IsCIStatusContext(s.GetContext()) || s.GetState() == "pending" || hasContext[s.GetContext()] {
+			continue
+		}
+		if err := g.UpdatePRStatus(&GithubPRUpdate{
+			Owner:       owner,
+			Repo:        repo,
+			Ref:         toRef,
+			NewStatus:   s.GetState(),
+			URL:         s.GetTargetURL(),
+			Description: s.GetDescription(),
+			Context:     s.GetContext(),
+		}); err != nil {
+			return copied, err
+		}
+		copied = append(copied, s.GetContext())
+	}
+	return copied, nil
 }
 
 // IsPRApproved checks whether a PR is approved or not.
@@ -165,7 +403,7 @@ func (g *GithubRequestHandler) IsPRApproved(owner, repo string, prNumber int) (b
 	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel() // cancel context if the function returns before the timeout
 	var reviews []*github.PullRequestReview
-	if err := Retry(5, "get PR reviews list", func() error {
+	if err := retry("get PR reviews list", func() error {
 		var err error
 		reviews, _, err = g.client.PullRequests.ListReviews(ctx, owner, repo, prNumber, nil)
 		return err
@@ -185,46 +423,202 @@ func (g *GithubRequestHandler) IsPRApproved(owner, repo string, prNumber int) (b
 	return false, nil
 }
 
+// PRBase returns the base branch of the PR (e.g. "master", or a release
+// branch), and the SHA of the merge base of the PR's head commit with the
+// current head of the base branch, against which the PR's changes should be
+// diffed. Reading is unaffected by shadow mode.
+func (g *GithubRequestHandler) PRBase(owner, repo string, prNumber int) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	var pr *github.PullRequest
+	if err := retry("getting PR", func() error {
+		var err error
+		pr, _, err = g.client.PullRequests.Get(ctx, owner, repo, prNumber)
+		return err
+	}); err != nil {
+		return "", "", err
+	}
+	baseRef, headSHA := pr.GetBase().GetRef(), pr.GetHead().GetSHA()
+	if baseRef == "" || headSHA == "" {
+		return "", "", fmt.Errorf("no base branch or head commit for PR %s/%s#%d", owner, repo, prNumber)
+	}
+
+	// The head commit of a PR from a fork is also available from the base
+	// repo, so both are compared within the base repo.
+	var comparison *github.CommitsComparison
+	if err := retry("comparing PR head with base branch", func() error {
+		var err error
+		comparison, _, err = g.client.Repositories.CompareCommits(ctx, owner, repo, baseRef, headSHA)
+		return err
+	}); err != nil {
+		return "", "", err
+	}
+	mergeBase := comparison.GetMergeBaseCommit().GetSHA()
+	if mergeBase == "" {
+		return "", "", fmt.Errorf("no merge base of PR %s/%s#%d with its base branch %s", owner, repo, prNumber, baseRef)
+	}
+	return baseRef, mergeBase, nil
+}
+
+// DefaultBranch returns the name of the default branch of the repo (e.g.
+// "main"). Reading is unaffected by shadow mode.
+func (g *GithubRequestHandler) DefaultBranch(owner, repo string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	var r *github.Repository
+	if err := retry("getting repo", func() error {
+		var err error
+		r, _, err = g.client.Repositories.Get(ctx, owner, repo)
+		return err
+	}); err != nil {
+		return "", err
+	}
+	if r.GetDefaultBranch() == "" {
+		return "", fmt.Errorf("no default branch for repo %s/%s", owner, repo)
+	}
+	return r.GetDefaultBranch(), nil
+}
+
+// FileContents returns the contents of the file at path within the repo at
+// ref, or nil if it doesn't exist. Reading is unaffected by shadow mode.
+func (g *GithubRequestHandler) FileContents(owner, repo, ref, path string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	var contents []byte
+	if err := retry(fmt.Sprintf("getting %s at %s", path, ref), func() error {
+		file, _, resp, err := g.client.Repositories.GetContents(ctx, owner, repo, path, &github.RepositoryContentGetOptions{Ref: ref})
+		switch {
+		case resp != nil && resp.StatusCode == http.StatusNotFound:
+			contents = nil
+			return nil
+		case err != nil:
+			return err
+		case file == nil:
+			return fmt.Errorf("%s at %s of repo %s/%s is a directory", path, ref, owner, repo)
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			return err
+		}
+		contents = []byte(content)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// PRLabel is a label to post to a PR, along with the colour with which it's
+// created if it doesn't exist within the repo.
+type PRLabel struct {
+	Name  string
+	Color string
+}
+
 // PostLabel posts the given label to the PR. It is idempotent.
 // unit tests can be created based on actual models-ci repo data that's sent back.
 func (g *GithubRequestHandler) PostLabel(labelName, labelColor, owner, repo string, prNumber int) error {
-	if g.labels[labelName] {
-		// Label already exists.
+	return g.PostLabels([]PRLabel{{Name: labelName, Color: labelColor}}, owner, repo, prNumber)
+}
+
+// PostLabels posts the given labels to the PR, adding those that aren't
+// already known to be on the PR in a single API call. It is idempotent, and
+// safe for concurrent use.
+func (g *GithubRequestHandler) PostLabels(labels []PRLabel, owner, repo string, prNumber int) error {
+	var newLabels []PRLabel
+	seen := map[string]bool{}
+	g.labelsMu.Lock()
+	for _, l := range labels {
+		if !g.labels[l.Name] && !seen[l.Name] {
+			newLabels = append(newLabels, l)
+			seen[l.Name] = true
+		}
+	}
+	g.labelsMu.Unlock()
+	if len(newLabels) == 0 {
+		// Labels already exist.
+		return nil
+	}
+	if g.shadow {
+		for _, l := range newLabels {
+			log.Printf("shadow mode: not posting label %q", l.Name)
+		}
 		return nil
 	}
 
-	label := &github.Label{Name: &labelName, Color: &labelColor}
 	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel()
 
-	// Label may very well already exist within the repo, so skip creation if we see it.
-	_, _, err := g.client.Issues.GetLabel(ctx, owner, repo, labelName)
-	if err != nil {
-		if err := Retry(5, "creating label", func() error {
-			_, _, err = g.client.Issues.CreateLabel(ctx, owner, repo, label)
-			return err
-		}); err != nil {
-			return err
+	var names []string
+	for _, l := range newLabels {
+		l := l
+		// Label may very well already exist within the repo, so skip creation if we see it.
+		if _, _, err := g.client.Issues.GetLabel(ctx, owner, repo, l.Name); err != nil {
+			if err := retry("creating label", func() error {
+				_, _, err := g.client.Issues.CreateLabel(ctx, owner, repo, &github.Label{Name: &l.Name, Color: &l.Color})
+				return err
+			}); err != nil {
+				return err
+			}
 		}
+		names = append(names, l.Name)
 	}
 
-	err = Retry(5, "adding label to PR", func() error {
-		_, _, err = g.client.Issues.AddLabelsToIssue(ctx, owner, repo, prNumber, []string{labelName})
+	if err := retry("adding labels to PR", func() error {
+		_, _, err := g.client.Issues.AddLabelsToIssue(ctx, owner, repo, prNumber, names)
+		return err
+	}); err != nil {
 		return err
-	})
-	if err == nil {
-		g.labels[labelName] = true
 	}
+	g.labelsMu.Lock()
+	defer g.labelsMu.Unlock()
+	for _, name := range names {
+		g.labels[name] = true
+	}
+	return nil
+}
 
-	return err
+// ListPRLabels returns the names of the labels on the PR. Reading is
+// unaffected by shadow mode.
+func (g *GithubRequestHandler) ListPRLabels(owner, repo string, prNumber int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	var names []string
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		var labels []*github.Label
+		var resp *github.Response
+		if err := retry("listing PR labels", func() error {
+			var err error
+			labels, resp, err = g.client.Issues.ListLabelsByIssue(ctx, owner, repo, prNumber, opts)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		for _, l := range labels {
+			names = append(names, l.GetName())
+		}
+		if resp.NextPage == 0 {
+			return names, nil
+		}
+		opts.Page = resp.NextPage
+	}
 }
 
 // DeleteLabel removes the given label from the PR. It does not remove the
 // label from the repo.
 func (g *GithubRequestHandler) DeleteLabel(labelName, owner, repo string, prNumber int) error {
+	if g.shadow {
+		log.Printf("shadow mode: not deleting label %q", labelName)
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel()
-	if err := Retry(5, "removing label from PR", func() error {
+	if err := retry("removing label from PR", func() error {
 		_, err := g.client.Issues.RemoveLabelForIssue(ctx, owner, repo, prNumber, labelName)
 		return err
 	}); err != nil {
@@ -234,15 +628,70 @@ func (g *GithubRequestHandler) DeleteLabel(labelName, owner, repo string, prNumb
 	// Do not take the second step to delete the label from the repo as
 	// we're only interested in deleting the label from the PR.
 
+	g.labelsMu.Lock()
+	defer g.labelsMu.Unlock()
 	delete(g.labels, labelName)
 	return nil
 }
 
+// authenticatedLogin returns the login of the access token's user, which
+// authors the comments posted by the handler. A GitHub App's installation
+// token can't read its user, so its bot's login (e.g. "models-ci[bot]") is
+// given by the GITHUB_BOT_LOGIN environment variable instead.
+func (g *GithubRequestHandler) authenticatedLogin(ctx context.Context) (string, error) {
+	if g.login != "" {
+		return g.login, nil
+	}
+	if login := os.Getenv("GITHUB_BOT_LOGIN"); login != "" {
+		g.login = login
+		return login, nil
+	}
+	var user *github.User
+	if err := retry("getting authenticated user", func() error {
+		var err error
+		user, _, err = g.client.Users.Get(ctx, "")
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("%w (set GITHUB_BOT_LOGIN for a GitHub App's token)", err)
+	}
+	if user.GetLogin() == "" {
+		return "", errors.New("no login for the access token's user")
+	}
+	g.login = user.GetLogin()
+	return g.login, nil
+}
+
+// listPRComments returns all comments of the PR, across all pages.
+func (g *GithubRequestHandler) listPRComments(ctx context.Context, owner, repo string, prNumber int) ([]*github.IssueComment, error) {
+	var comments []*github.IssueComment
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		var page []*github.IssueComment
+		var resp *github.Response
+		if err := retry("get PR comments list", func() error {
+			var err error
+			page, resp, err = g.client.Issues.ListComments(ctx, owner, repo, prNumber, opts)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+		comments = append(comments, page...)
+		if resp.NextPage == 0 {
+			return comments, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
 // AddPRComment posts a comment to the PR.
 func (g *GithubRequestHandler) AddPRComment(body *string, owner, repo string, prNumber int) error {
+	if g.shadow {
+		log.Printf("shadow mode: not posting PR comment:\n%s", *body)
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel()
-	if err := Retry(5, "posting issue comment to PR", func() error {
+	if err := retry("posting issue comment to PR", func() error {
 		_, _, err := g.client.Issues.CreateComment(ctx, owner, repo, prNumber, &github.IssueComment{Body: body})
 		return err
 	}); err != nil {
@@ -259,6 +708,10 @@ func (g *GithubRequestHandler) AddPRComment(body *string, owner, repo string, pr
 // is posted.
 // If body is nil, then it indicates delete.
 func (g *GithubRequestHandler) AddEditOrDeletePRComment(signature string, body *string, owner, repo string, prNumber int) error {
+	if g.shadow {
+		log.Printf("shadow mode: not posting, editing or deleting PR comment with signature %q", signature)
+		return nil
+	}
 	if signature == "" {
 		if body == nil {
 			return fmt.Errorf("PR comment body unspecified")
@@ -269,12 +722,8 @@ func (g *GithubRequestHandler) AddEditOrDeletePRComment(signature string, body *
 	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
 	defer cancel()
 
-	var comments []*github.IssueComment
-	if err := Retry(5, "get PR comments list", func() error {
-		var err error
-		comments, _, err = g.client.Issues.ListComments(ctx, owner, repo, prNumber, nil)
-		return err
-	}); err != nil {
+	comments, err := g.listPRComments(ctx, owner, repo, prNumber)
+	if err != nil {
 		// If somehow this fails, we should be resilient and just post another comment.
 		if body == nil {
 			return fmt.Errorf("list comments failed -- cannot find comment to delete")
@@ -286,14 +735,14 @@ func (g *GithubRequestHandler) AddEditOrDeletePRComment(signature string, body *
 		if strings.Contains(*pc.Body, signature) {
 			switch body {
 			case nil:
-				if err := Retry(5, "delete PR comment", func() error {
+				if err := retry("delete PR comment", func() error {
 					_, err := g.client.Issues.DeleteComment(ctx, owner, repo, *pc.ID)
 					return err
 				}); err != nil {
-					return fmt.Errorf("cannot delete comment: %v", err)
+					return fmt.Errorf("cannot delete comment: %w", err)
 				}
 			default:
-				if err := Retry(5, "edit PR comment", func() error {
+				if err := retry("edit PR comment", func() error {
 					_, _, err := g.client.Issues.EditComment(ctx, owner, repo, *pc.ID, &github.IssueComment{Body: body})
 					return err
 				}); err != nil {
@@ -310,35 +759,158 @@ func (g *GithubRequestHandler) AddEditOrDeletePRComment(signature string, body *
 	return g.AddPRComment(body, owner, repo, prNumber)
 }
 
+// TokenScopes returns the authenticated user's login and the OAuth scopes
+// of the access token. Fine-grained access tokens have no OAuth scopes, in
+// which case nil is returned.
+func (g *GithubRequestHandler) TokenScopes() (string, []string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var user *github.User
+	var resp *github.Response
+	if err := retry("getting authenticated user", func() error {
+		var err error
+		user, resp, err = g.client.Users.Get(ctx, "")
+		return err
+	}); err != nil {
+		return "", nil, err
+	}
+	var scopes []string
+	for _, scope := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return user.GetLogin(), scopes, nil
+}
+
+// requiredTokenScopes are the OAuth scopes the access token needs to post
+// statuses, labels and comments to PRs and to create gists. Each is satisfied
+// by any of its alternatives.
+var requiredTokenScopes = [][]string{{"repo", "public_repo"}, {"gist"}}
+
+// MissingTokenScopes returns the descriptions of the required OAuth scopes
+// that are missing from scopes.
+func MissingTokenScopes(scopes []string) []string {
+	hasScope := map[string]bool{}
+	for _, scope := range scopes {
+		hasScope[scope] = true
+	}
+	var missing []string
+	for _, alternatives := range requiredTokenScopes {
+		found := false
+		for _, scope := range alternatives {
+			found = found || hasScope[scope]
+		}
+		if !found {
+			desc := alternatives[0]
+			if len(alternatives) > 1 {
+				desc += fmt.Sprintf(" (or %s)", strings.Join(alternatives[1:], ", "))
+			}
+			missing = append(missing, desc)
+		}
+	}
+	return missing
+}
+
+// VerifyTokenScopes returns an error listing the missing scopes if the access
+// token doesn't have the OAuth scopes required by the CI. Fine-grained access
+// tokens have no OAuth scopes, and GitHub App installation tokens can't get
+// the authenticated user (403), so neither can be verified, in which case
+// the CI continues.
+func (g *GithubRequestHandler) VerifyTokenScopes() error {
+	login, scopes, err := g.TokenScopes()
+	var errResp *github.ErrorResponse
+	switch {
+	case errors.As(err, &errResp) && errResp.Response != nil && errResp.Response.StatusCode == http.StatusForbidden:
+		log.Printf("can't verify the access token's scopes, continuing: %v", err)
+		return nil
+	case err != nil:
+		return fmt.Errorf("couldn't verify the access token's scopes: %w", err)
+	case scopes == nil:
+		log.Printf("can't verify the scopes of the access token of %s since it has no OAuth scopes, continuing", login)
+		return nil
+	}
+	if missing := MissingTokenScopes(scopes); len(missing) > 0 {
+		return fmt.Errorf("the access token of %s is missing the required scopes: %s (it has %s)", login, strings.Join(missing, ", "), strings.Join(scopes, ", "))
+	}
+	return nil
+}
+
+// verifyTokenScopes verifies the scopes of the access token of a new
+// handler. It is a variable such that it can be replaced in tests.
+var verifyTokenScopes = (*GithubRequestHandler).VerifyTokenScopes
+
+var (
+	verifiedTokensMu sync.Mutex
+	// verifiedTokens are the access tokens whose scopes have been
+	// verified, such that each is only verified once per process.
+	verifiedTokens = map[string]bool{}
+)
+
+// ChecksTokenEnv is the environment variable giving the GitHub App
+// installation token with which check runs are created (see CreateCheckRun).
+const ChecksTokenEnv = "GITHUB_CHECKS_TOKEN"
+
+// newTokenClient returns a GitHub client authenticated by the access token.
+func newTokenClient(token string) *github.Client {
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: strings.TrimSpace(token)},
+	)
+	tc := oauth2.NewClient(context.Background(), ts)
+
+	// Set the timeout for the oauth client such that we do not hang around
+	// waiting for the client to complete.
+	tc.Timeout = 2 * time.Second
+
+	// Create a new GitHub client using the go-github library.
+	return github.NewClient(tc)
+}
+
 // NewGitHubRequestHandler sets up a new GithubRequestHandler struct which
 // creates an oauth2 client with a GitHub access token (as specified by the
 // GITHUB_ACCESS_TOKEN environment variable), and a connection to the GitHub
 // API through the github.com/google/go-github/github library. It returns the
 // initialised GithubRequestHandler struct, or an error as to why the
 // initialisation failed.
+//
+// The access token's OAuth scopes are verified once per process, and an error
+// listing the missing scopes is returned if it lacks those required by the CI.
+//
+// If ShadowMode is set, then the returned handler does not post anything to
+// PRs, but still creates gists. If StatusCommentPR is set, then the handler
+// falls back to recording statuses in a comment on that PR. Check runs are
+// created with the separate token given by ChecksTokenEnv, if set.
 func NewGitHubRequestHandler() (*GithubRequestHandler, error) {
 	accesstk := os.Getenv("GITHUB_ACCESS_TOKEN")
 	if accesstk == "" {
 		return nil, errors.New("newGitHubRequestHandler: invalid access token environment variable set")
 	}
 
-	ts := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: strings.TrimSpace(accesstk)},
-	)
-	tc := oauth2.NewClient(context.Background(), ts)
-
-	// Set the timeout for the oauth client such that we do not hang around
-	// waiting for the client to complete.
-	tc.Timeout = 2 * time.Second
-
-	// Create a new GitHub client using the go-github library.
-	client := github.NewClient(tc)
-	return &GithubRequestHandler{
+	client := newTokenClient(accesstk)
+	g := &GithubRequestHandler{
 		// If the environment variable GITHUB_SECRET was set then we store it in
 		// the struct, this is a secret that is used to calculate a hash of the
 		// message so that we can validate it.
-		client:      client,
-		accessToken: accesstk,
-		labels:      map[string]bool{},
-	}, nil
+		client:          client,
+		accessToken:     accesstk,
+		labels:          map[string]bool{},
+		shadow:          ShadowMode,
+		statusCommentPR: StatusCommentPR,
+	}
+	if checkstk := os.Getenv(ChecksTokenEnv); checkstk != "" {
+		g.checksClient = newTokenClient(checkstk)
+	}
+
+	// Fail fast on a token lacking scopes, rather than with the 404s that
+	// GitHub returns for unauthorized requests deep into a run.
+	verifiedTokensMu.Lock()
+	defer verifiedTokensMu.Unlock()
+	if !verifiedTokens[accesstk] {
+		if err := verifyTokenScopes(g); err != nil {
+			return nil, fmt.Errorf("newGitHubRequestHandler: %w", err)
+		}
+		verifiedTokens[accesstk] = true
+	}
+	return g, nil
 }
@@ -16,12 +16,15 @@ package commonci
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/github"
@@ -91,6 +94,132 @@ func TestRetry(t *testing.T) {
 	}
 }
 
+func TestUpdatePRStatusInfraError(t *testing.T) {
+	tests := []struct {
+		name         string
+		inStatusCode int
+		wantInfraErr bool
+	}{{
+		name:         "server error",
+		inStatusCode: http.StatusBadGateway,
+		wantInfraErr: true,
+	}, {
+		name:         "client error",
+		inStatusCode: http.StatusUnprocessableEntity,
+		wantInfraErr: false,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/repos/o/r/statuses/sha", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				http.Error(w, "", tt.inStatusCode)
+			})
+
+			g := &GithubRequestHandler{client: client, labels: map[string]bool{}}
+			err := g.UpdatePRStatus(&GithubPRUpdate{
+				Owner:     "o",
+				Repo:      "r",
+				Ref:       "sha",
+				NewStatus: "success",
+			})
+			if err == nil {
+				t.Fatalf("got no error, want error")
+			}
+			if got := IsInfraError(err); got != tt.wantInfraErr {
+				t.Errorf("IsInfraError(%v): got %v, want %v", err, got, tt.wantInfraErr)
+			}
+		})
+	}
+}
+
+func TestClassifyGitHubError(t *testing.T) {
+	tests := []struct {
+		name         string
+		inErr        error
+		wantInfraErr bool
+	}{{
+		name:  "nil",
+		inErr: nil,
+	}, {
+		name:         "connection error",
+		inErr:        fmt.Errorf("dial tcp: connection refused"),
+		wantInfraErr: true,
+	}, {
+		name:         "rate limited",
+		inErr:        &github.RateLimitError{Response: &http.Response{StatusCode: http.StatusForbidden}},
+		wantInfraErr: true,
+	}, {
+		name:  "not found",
+		inErr: &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}},
+	}, {
+		name:         "server error",
+		inErr:        &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}},
+		wantInfraErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyGitHubError(tt.inErr)
+			if tt.inErr == nil && err != nil {
+				t.Fatalf("got error %v for nil input", err)
+			}
+			if got := IsInfraError(fmt.Errorf("wrapped: %w", err)); got != tt.wantInfraErr {
+				t.Errorf("IsInfraError: got %v, want %v", got, tt.wantInfraErr)
+			}
+		})
+	}
+}
+
+func TestPaceDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		inRate github.Rate
+		inLast time.Time
+		want   time.Duration
+	}{{
+		name: "first request",
+		want: 0,
+	}, {
+		name:   "previous request just made",
+		inRate: github.Rate{Limit: 5000, Remaining: 4000, Reset: github.Timestamp{Time: now.Add(time.Hour)}},
+		inLast: now.Add(-200 * time.Millisecond),
+		want:   800 * time.Millisecond,
+	}, {
+		name:   "plenty of requests remaining",
+		inRate: github.Rate{Limit: 5000, Remaining: 4000, Reset: github.Timestamp{Time: now.Add(time.Hour)}},
+		inLast: now.Add(-time.Minute),
+		want:   0,
+	}, {
+		name:   "few requests remaining are spread out until the reset",
+		inRate: github.Rate{Limit: 5000, Remaining: 9, Reset: github.Timestamp{Time: now.Add(20 * time.Second)}},
+		inLast: now.Add(-time.Minute),
+		want:   2 * time.Second,
+	}, {
+		name:   "rate limit exhausted",
+		inRate: github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: now.Add(time.Hour)}},
+		inLast: now.Add(-time.Minute),
+		want:   maxPaceDelay,
+	}, {
+		name:   "rate limit already reset",
+		inRate: github.Rate{Limit: 5000, Remaining: 0, Reset: github.Timestamp{Time: now.Add(-time.Second)}},
+		inLast: now.Add(-time.Minute),
+		want:   0,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := paceDelay(tt.inRate, tt.inLast, now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 // setup sets up a test HTTP server along with a github.Client that is
 // configured to talk to that test server. Tests should register handlers on
 // mux which provide mock responses for the API method being tested.
@@ -274,18 +403,459 @@ func TestPostLabel(t *testing.T) {
 	}
 }
 
+func TestPostLabels(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	var mu sync.Mutex
+	var gotAdded [][]string
+	var gotCreated []string
+	mux.HandleFunc("/repos/o/r/labels/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("/repos/o/r/labels", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		v := new(github.Label)
+		json.NewDecoder(r.Body).Decode(v)
+		mu.Lock()
+		defer mu.Unlock()
+		gotCreated = append(gotCreated, v.GetName())
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		var v []string
+		json.NewDecoder(r.Body).Decode(&v)
+		mu.Lock()
+		defer mu.Unlock()
+		gotAdded = append(gotAdded, v)
+		fmt.Fprint(w, `[]`)
+	})
+
+	g := &GithubRequestHandler{client: client, labels: map[string]bool{"a": true}}
+	if err := g.PostLabels([]PRLabel{{"a", "c"}, {"b", "c"}, {"c", "c"}, {"b", "c"}}, "o", "r", 1); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([][]string{{"b", "c"}}, gotAdded); diff != "" {
+		t.Errorf("labels added to PR (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"b", "c"}, gotCreated); diff != "" {
+		t.Errorf("labels created (-want, +got):\n%s", diff)
+	}
+
+	// Posting the known labels concurrently makes no further calls.
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b", "c", "a", "b", "c"} {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.PostLabel(name, "c", "o", "r", 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(gotAdded) != 1 {
+		t.Errorf("got %d calls adding labels, want 1", len(gotAdded))
+	}
+	if diff := cmp.Diff(map[string]bool{"a": true, "b": true, "c": true}, g.labels); diff != "" {
+		t.Errorf("known labels (-want, +got):\n%s", diff)
+	}
+}
+
+func TestPostLabelsConcurrent(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+	mux.HandleFunc("/repos/o/r/labels/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{}`)
+	})
+	mux.HandleFunc("/repos/o/r/issues/1/labels", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/o/r/issues/1/labels/", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "DELETE")
+	})
+
+	g := &GithubRequestHandler{client: client, labels: map[string]bool{}}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("skipped: %d", i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.PostLabel(name, "c", "o", "r", 1); err != nil {
+				t.Error(err)
+			}
+			if err := g.DeleteLabel(name, "o", "r", 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(g.labels) != 0 {
+		t.Errorf("got known labels %v after deleting them, want none", g.labels)
+	}
+}
+
+func TestMergedPRForCommit(t *testing.T) {
+	tests := []struct {
+		name       string
+		inResponse string
+		wantNumber int
+		wantHead   string
+	}{{
+		name:       "squash merged PR",
+		inResponse: `[{"number": 2, "merged_at": null, "merge_commit_sha": "sha", "head": {"sha": "other"}}, {"number": 3, "merged_at": "2024-01-01T00:00:00Z", "merge_commit_sha": "sha", "head": {"sha": "head"}}]`,
+		wantNumber: 3,
+		wantHead:   "head",
+	}, {
+		name:       "PR merged into another commit",
+		inResponse: `[{"number": 3, "merged_at": "2024-01-01T00:00:00Z", "merge_commit_sha": "other", "head": {"sha": "head"}}]`,
+	}, {
+		name:       "direct push",
+		inResponse: `[]`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/repos/o/r/commits/sha/pulls", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				fmt.Fprint(w, tt.inResponse)
+			})
+
+			g := &GithubRequestHandler{client: client, labels: map[string]bool{}}
+			gotNumber, gotHead, err := g.MergedPRForCommit("o", "r", "sha")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotNumber != tt.wantNumber || gotHead != tt.wantHead {
+				t.Errorf("got (%d, %q), want (%d, %q)", gotNumber, gotHead, tt.wantNumber, tt.wantHead)
+			}
+		})
+	}
+}
+
+func TestPRBase(t *testing.T) {
+	tests := []struct {
+		name          string
+		inPR          string
+		inComparison  string
+		wantBaseRef   string
+		wantMergeBase string
+		wantErr       bool
+	}{{
+		name:          "PR to release branch",
+		inPR:          `{"number": 1, "base": {"ref": "release-v5", "sha": "stale"}, "head": {"sha": "head"}}`,
+		inComparison:  `{"merge_base_commit": {"sha": "mergebase"}}`,
+		wantBaseRef:   "release-v5",
+		wantMergeBase: "mergebase",
+	}, {
+		name:    "no base branch",
+		inPR:    `{"number": 1, "head": {"sha": "head"}}`,
+		wantErr: true,
+	}, {
+		name:         "no merge base",
+		inPR:         `{"number": 1, "base": {"ref": "master"}, "head": {"sha": "head"}}`,
+		inComparison: `{}`,
+		wantErr:      true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/repos/o/r/pulls/1", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				fmt.Fprint(w, tt.inPR)
+			})
+			mux.HandleFunc("/repos/o/r/compare/", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				if want := "/repos/o/r/compare/" + tt.wantBaseRef + "...head"; tt.wantBaseRef != "" && r.URL.Path != want {
+					t.Errorf("got request for %s, want %s", r.URL.Path, want)
+				}
+				fmt.Fprint(w, tt.inComparison)
+			})
+
+			g := &GithubRequestHandler{client: client, labels: map[string]bool{}}
+			gotBaseRef, gotMergeBase, err := g.PRBase("o", "r", 1)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotBaseRef != tt.wantBaseRef || gotMergeBase != tt.wantMergeBase {
+				t.Errorf("got (%q, %q), want (%q, %q)", gotBaseRef, gotMergeBase, tt.wantBaseRef, tt.wantMergeBase)
+			}
+		})
+	}
+}
+
+func TestFileContents(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/repos/o/r/contents/.ci-validators.yml", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		if got := r.URL.Query().Get("ref"); got != "master" {
+			t.Errorf("got ref %q, want master", got)
+		}
+		// "validators: []\n" encoded in base64.
+		fmt.Fprint(w, `{"type": "file", "encoding": "base64", "content": "dmFsaWRhdG9yczogW10K"}`)
+	})
+	mux.HandleFunc("/repos/o/r/contents/missing.yml", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	g := &GithubRequestHandler{client: client, labels: map[string]bool{}}
+	got, err := g.FileContents("o", "r", "master", ".ci-validators.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "validators: []\n"; string(got) != want {
+		t.Errorf("got contents %q, want %q", got, want)
+	}
+	if got, err := g.FileContents("o", "r", "master", "missing.yml"); err != nil || got != nil {
+		t.Errorf("got (%q, %v) for missing file, want (nil, nil)", got, err)
+	}
+}
+
+func TestCopyFinalStatuses(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/repos/o/r/commits/head/status", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"statuses": [
+			{"context": "pyang", "state": "success", "target_url": "url", "description": "desc"},
+			{"context": "regexp tests", "state": "pending"},
+			{"context": "ConfD Basic@7.3", "state": "failure"},
+			{"context": "misc-checks", "state": "success"},
+			{"context": "cla/google", "state": "success"}
+		]}`)
+	})
+	mux.HandleFunc("/repos/o/r/commits/merge/status", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "GET")
+		fmt.Fprint(w, `{"statuses": [
+			{"context": "misc-checks", "state": "failure"}
+		]}`)
+	})
+	var gotStatuses []string
+	mux.HandleFunc("/repos/o/r/statuses/merge", func(w http.ResponseWriter, r *http.Request) {
+		testMethod(t, r, "POST")
+		s := new(github.RepoStatus)
+		if err := json.NewDecoder(r.Body).Decode(s); err != nil {
+			t.Fatal(err)
+		}
+		gotStatuses = append(gotStatuses, fmt.Sprintf("%s:%s:%s:%s", s.GetContext(), s.GetState(), s.GetTargetURL(), s.GetDescription()))
+		fmt.Fprint(w, `{}`)
+	})
+
+	g := &GithubRequestHandler{client: client, labels: map[string]bool{}}
+	gotCopied, err := g.CopyFinalStatuses("o", "r", "head", "merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"pyang", "ConfD Basic@7.3"}, gotCopied); diff != "" {
+		t.Errorf("copied contexts (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"pyang:success:url:desc", "ConfD Basic@7.3:failure::"}, gotStatuses); diff != "" {
+		t.Errorf("posted statuses (-want, +got):\n%s", diff)
+	}
+}
+
+func TestShadowMode(t *testing.T) {
+	client, mux, _, teardown := setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request in shadow mode: %s %s", r.Method, r.URL)
+	})
+
+	g := &GithubRequestHandler{
+		client: client,
+		labels: map[string]bool{},
+		shadow: true,
+	}
+	body := "comment"
+	if err := g.UpdatePRStatus(&GithubPRUpdate{Owner: "o", Repo: "r", Ref: "sha", NewStatus: "success"}); err != nil {
+		t.Errorf("UpdatePRStatus: %v", err)
+	}
+	if err := g.UpdatePRStatus(&GithubPRUpdate{Owner: "o", Repo: "r", Ref: "sha", NewStatus: "invalid"}); err == nil {
+		t.Errorf("UpdatePRStatus: got no error for invalid status")
+	}
+	if err := g.PostLabel("n", "c", "o", "r", 1); err != nil {
+		t.Errorf("PostLabel: %v", err)
+	}
+	if err := g.DeleteLabel("n", "o", "r", 1); err != nil {
+		t.Errorf("DeleteLabel: %v", err)
+	}
+	if err := g.AddPRComment(&body, "o", "r", 1); err != nil {
+		t.Errorf("AddPRComment: %v", err)
+	}
+	if err := g.AddEditOrDeletePRComment("sig", &body, "o", "r", 1); err != nil {
+		t.Errorf("AddEditOrDeletePRComment: %v", err)
+	}
+	if err := g.CreateCheckRun(&CheckRun{Owner: "o", Repo: "r", HeadSHA: "sha", Name: "n", Conclusion: "success"}); err != nil {
+		t.Errorf("CreateCheckRun: %v", err)
+	}
+}
+
+func TestTokenScopes(t *testing.T) {
+	tests := []struct {
+		name       string
+		inScopes   string
+		wantScopes []string
+	}{{
+		name:       "classic token",
+		inScopes:   "gist, repo",
+		wantScopes: []string{"gist", "repo"},
+	}, {
+		name: "fine-grained token",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				if tt.inScopes != "" {
+					w.Header().Set("X-OAuth-Scopes", tt.inScopes)
+				}
+				fmt.Fprint(w, `{"login":"OpenConfigBot"}`)
+			})
+
+			g := &GithubRequestHandler{client: client}
+			gotLogin, gotScopes, err := g.TokenScopes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotLogin != "OpenConfigBot" {
+				t.Errorf("got login %q, want OpenConfigBot", gotLogin)
+			}
+			if diff := cmp.Diff(tt.wantScopes, gotScopes); diff != "" {
+				t.Errorf("scopes (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMissingTokenScopes(t *testing.T) {
+	tests := []struct {
+		name        string
+		inScopes    []string
+		wantMissing []string
+	}{{
+		name:     "all scopes",
+		inScopes: []string{"gist", "repo", "workflow"},
+	}, {
+		name:     "public repos only",
+		inScopes: []string{"public_repo", "gist"},
+	}, {
+		name:        "missing gist",
+		inScopes:    []string{"repo"},
+		wantMissing: []string{"gist"},
+	}, {
+		name:        "no scopes",
+		inScopes:    []string{},
+		wantMissing: []string{"repo (or public_repo)", "gist"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.wantMissing, MissingTokenScopes(tt.inScopes)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenScopes(t *testing.T) {
+	tests := []struct {
+		name          string
+		inScopes      string
+		inStatus      int
+		wantErrSubstr string
+	}{{
+		name:     "required scopes",
+		inScopes: "gist, repo",
+	}, {
+		name:          "missing scopes",
+		inScopes:      "read:org",
+		wantErrSubstr: "OpenConfigBot is missing the required scopes: repo (or public_repo), gist",
+	}, {
+		name: "fine-grained token",
+	}, {
+		name:     "installation token",
+		inStatus: http.StatusForbidden,
+	}, {
+		name:          "bad credentials",
+		inStatus:      http.StatusUnauthorized,
+		wantErrSubstr: "couldn't verify the access token's scopes",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				if tt.inStatus != 0 {
+					w.WriteHeader(tt.inStatus)
+					fmt.Fprint(w, `{"message":"Resource not accessible by integration"}`)
+					return
+				}
+				if tt.inScopes != "" {
+					w.Header().Set("X-OAuth-Scopes", tt.inScopes)
+				}
+				fmt.Fprint(w, `{"login":"OpenConfigBot"}`)
+			})
+
+			g := &GithubRequestHandler{client: client}
+			if diff := errdiff.Substring(g.VerifyTokenScopes(), tt.wantErrSubstr); diff != "" {
+				t.Errorf("VerifyTokenScopes: %s", diff)
+			}
+		})
+	}
+}
+
 func TestNewGitHubRequestHandler(t *testing.T) {
+	verified := 0
+	defer func(f func(*GithubRequestHandler) error) { verifyTokenScopes = f }(verifyTokenScopes)
+	verifyTokenScopes = func(g *GithubRequestHandler) error {
+		verified++
+		if g.accessToken == "badToken" {
+			return errors.New("missing the required scopes: gist")
+		}
+		return nil
+	}
+
 	tests := []struct {
 		name           string
 		inEnvSecret    string
 		inEnvToken     string
 		wantHashSecret string
 		wantToken      string
+		wantErr        bool
 	}{{
 		name:        "variables read from environment",
 		inEnvSecret: "testSecret",
 		inEnvToken:  "testToken",
 		wantToken:   "testToken",
+	}, {
+		name:        "token already verified",
+		inEnvSecret: "testSecret",
+		inEnvToken:  "testToken",
+		wantToken:   "testToken",
+	}, {
+		name:       "token missing scopes",
+		inEnvToken: "badToken",
+		wantErr:    true,
 	}}
 
 	for _, tt := range tests {
@@ -293,12 +863,19 @@ func TestNewGitHubRequestHandler(t *testing.T) {
 		os.Setenv("GITHUB_SECRET", tt.inEnvSecret)
 
 		g, err := NewGitHubRequestHandler()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: newGitHubRequestHandler(): got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
 		if err != nil {
-			t.Errorf("%s: newGitHubRequestHandler(): got: %v, want: no error", tt.name, err)
+			continue
 		}
 
 		if g.accessToken != tt.wantToken {
 			t.Errorf("%s: newGitHubRequestHandler(): did not get valid access token, got: %s, want: %s", tt.name, g.accessToken, tt.wantToken)
 		}
 	}
+	// The valid token is only verified once.
+	if verified != 2 {
+		t.Errorf("got %d token verifications, want 2", verified)
+	}
 }
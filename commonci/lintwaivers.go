@@ -0,0 +1,123 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LintWaiversFileName by convention is the file within a model
+	// directory waiving false positives of the OpenConfig linter.
+	LintWaiversFileName = "lint-waivers.yaml"
+	// lintWaiverExpiryLayout is the format of a lint waiver's expiry date.
+	lintWaiverExpiryLayout = "2006-01-02"
+)
+
+// LintWaiver waives the OpenConfig linter messages with an error code in a
+// file of a model directory.
+type LintWaiver struct {
+	// Code is the waived error code (e.g. OC_RELATIVE_PATH).
+	Code string `yaml:"code"`
+	// Path is the file whose messages are waived, relative to the model
+	// directory.
+	Path string `yaml:"path"`
+	// Justification explains why the messages are false positives.
+	Justification string `yaml:"justification"`
+	// Expiry is the last day (YYYY-MM-DD) on which the waiver applies.
+	Expiry string `yaml:"expiry"`
+
+	expiry time.Time
+}
+
+// Expired returns whether the waiver no longer applies at the given time.
+func (w *LintWaiver) Expired(now time.Time) bool {
+	return !now.Before(w.expiry.AddDate(0, 0, 1))
+}
+
+// LintWaivers represents a LintWaiversFileName file.
+type LintWaivers struct {
+	Waivers []*LintWaiver `yaml:"waivers"`
+}
+
+// ParseLintWaivers parses and validates the contents of a LintWaiversFileName
+// file. Every waiver must have a justification and an expiry, such that
+// waivers are reviewed rather than forgotten.
+func ParseLintWaivers(bs []byte) (*LintWaivers, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	w := &LintWaivers{}
+	if err := dec.Decode(w); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	for i, waiver := range w.Waivers {
+		switch {
+		case waiver.Code == "":
+			return nil, fmt.Errorf("waiver %d has no code", i+1)
+		case waiver.Path == "":
+			return nil, fmt.Errorf("waiver %d (%s) has no path", i+1, waiver.Code)
+		case waiver.Justification == "":
+			return nil, fmt.Errorf("waiver %d (%s %s) has no justification", i+1, waiver.Code, waiver.Path)
+		}
+		expiry, err := time.Parse(lintWaiverExpiryLayout, waiver.Expiry)
+		if err != nil {
+			return nil, fmt.Errorf("waiver %d (%s %s) has invalid expiry %q, must be YYYY-MM-DD", i+1, waiver.Code, waiver.Path, waiver.Expiry)
+		}
+		waiver.expiry = expiry
+		waiver.Path = path.Clean(waiver.Path)
+	}
+	return w, nil
+}
+
+// ReadLintWaivers reads the lint waivers file. If the file doesn't exist, then
+// nil is returned.
+func ReadLintWaivers(file string) (*LintWaivers, error) {
+	bs, err := os.ReadFile(file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read lint waivers file %q: %v", file, err)
+	}
+	w, err := ParseLintWaivers(bs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lint waivers file %q: %v", file, err)
+	}
+	return w, nil
+}
+
+// Match returns the unexpired waiver of the message with the given code in the
+// file (relative to the model directory), or nil if there is none.
+func (w *LintWaivers) Match(code, file string, now time.Time) *LintWaiver {
+	if w == nil {
+		return nil
+	}
+	for _, waiver := range w.Waivers {
+		if waiver.Code == code && waiver.Path == file && !waiver.Expired(now) {
+			return waiver
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseLintWaivers(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		wantWaivers int
+		wantErr     bool
+	}{{
+		name: "empty",
+		in:   "",
+	}, {
+		name: "valid",
+		in: `
+waivers:
+  - code: OC_RELATIVE_PATH
+    path: ./openconfig-acl.yang
+    justification: false positive
+    expiry: 2024-06-01
+`,
+		wantWaivers: 1,
+	}, {
+		name: "no justification",
+		in: `
+waivers:
+  - code: OC_RELATIVE_PATH
+    path: openconfig-acl.yang
+    expiry: 2024-06-01
+`,
+		wantErr: true,
+	}, {
+		name: "invalid expiry",
+		in: `
+waivers:
+  - code: OC_RELATIVE_PATH
+    path: openconfig-acl.yang
+    justification: false positive
+    expiry: June 2024
+`,
+		wantErr: true,
+	}, {
+		name: "unknown field",
+		in: `
+waivers:
+  - code: OC_RELATIVE_PATH
+    file: openconfig-acl.yang
+`,
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLintWaivers([]byte(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && len(got.Waivers) != tt.wantWaivers {
+				t.Errorf("got %d waivers, want %d", len(got.Waivers), tt.wantWaivers)
+			}
+		})
+	}
+}
+
+func TestLintWaiversMatch(t *testing.T) {
+	w, err := ParseLintWaivers([]byte(`
+waivers:
+  - code: OC_RELATIVE_PATH
+    path: ./openconfig-acl.yang
+    justification: false positive
+    expiry: 2024-06-01
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		inCode string
+		inFile string
+		inNow  time.Time
+		want   bool
+	}{{
+		name:   "match on the expiry date",
+		inCode: "OC_RELATIVE_PATH",
+		inFile: "openconfig-acl.yang",
+		inNow:  time.Date(2024, 6, 1, 23, 0, 0, 0, time.UTC),
+		want:   true,
+	}, {
+		name:   "expired",
+		inCode: "OC_RELATIVE_PATH",
+		inFile: "openconfig-acl.yang",
+		inNow:  time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:   "different code",
+		inCode: "OC_ENUM_CASE",
+		inFile: "openconfig-acl.yang",
+		inNow:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, {
+		name:   "different file",
+		inCode: "OC_RELATIVE_PATH",
+		inFile: "openconfig-acl-types.yang",
+		inNow:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.Match(tt.inCode, tt.inFile, tt.inNow) != nil; got != tt.want {
+				t.Errorf("got match %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadLintWaiversMissing(t *testing.T) {
+	w, err := ReadLintWaivers(filepath.Join(t.TempDir(), LintWaiversFileName))
+	if err != nil || w != nil {
+		t.Errorf("got (%v, %v), want (nil, nil)", w, err)
+	}
+}
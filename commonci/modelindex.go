@@ -0,0 +1,167 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ModelIndexFileName by convention is the file at the root of the
+	// models repo containing the metadata of each model directory, from
+	// which the index of the documentation site is generated.
+	ModelIndexFileName = "model-index.yml"
+	// ModelIndexProblemsFileName by convention contains one line describing
+	// each problem found by ModelIndex.Check. It is output by cmd_gen into
+	// the misc-checks results directory if the models repo has a
+	// ModelIndexFileName file.
+	ModelIndexProblemsFileName = "model-index-problems"
+)
+
+// readmeFileNames are the accepted names of a model directory's README.
+var readmeFileNames = []string{"README.md", "README"}
+
+// ModelIndexEntry is the metadata of a model directory.
+type ModelIndexEntry struct {
+	// Title is the human-readable title of the model directory (e.g.
+	// "Access Control Lists").
+	Title string `yaml:"title"`
+	// Maintainer is the maintainer of the model directory (e.g. a GitHub
+	// team).
+	Maintainer string `yaml:"maintainer"`
+}
+
+// ModelIndex represents a ModelIndexFileName file.
+type ModelIndex struct {
+	// Models maps each model directory relative to its model root (e.g.
+	// wifi/mac) to its metadata.
+	Models map[string]ModelIndexEntry `yaml:"models"`
+}
+
+// ParseModelIndex parses the contents of a ModelIndexFileName file.
+func ParseModelIndex(bs []byte) (*ModelIndex, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	idx := &ModelIndex{}
+	if err := dec.Decode(idx); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	// Normalize the model directories so that e.g. "wifi/mac/" is found.
+	models := map[string]ModelIndexEntry{}
+	for dir, entry := range idx.Models {
+		dir = strings.Trim(dir, "/")
+		if dir == "" {
+			return nil, fmt.Errorf("empty model directory")
+		}
+		models[dir] = entry
+	}
+	idx.Models = models
+	return idx, nil
+}
+
+// ReadModelIndex reads and parses the ModelIndexFileName file at path. If the
+// file doesn't exist, then nil is returned.
+func ReadModelIndex(path string) (*ModelIndex, error) {
+	bs, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read model index file %q: %v", path, err)
+	}
+	idx, err := ParseModelIndex(bs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid model index file %q: %v", path, err)
+	}
+	return idx, nil
+}
+
+// Check returns a description of each problem with the index and READMEs of
+// the model directories, prefixed by the model directory: each model
+// directory with a .spec.yml must have a README and an entry with a title and
+// a maintainer, and each entry must be of such a model directory. It returns
+// no problems for a nil index, i.e. if the models repo doesn't have one.
+func (idx *ModelIndex) Check(modelMap OpenConfigModelMap) []string {
+	if idx == nil {
+		return nil
+	}
+
+	modelDirs := make([]string, 0, len(modelMap.ModelInfoMap))
+	modelDirSet := map[string]bool{}
+	for modelDirName := range modelMap.ModelInfoMap {
+		modelDir := strings.ReplaceAll(modelDirName, ":", "/")
+		modelDirs = append(modelDirs, modelDir)
+		modelDirSet[modelDir] = true
+	}
+	sort.Strings(modelDirs)
+
+	var problems []string
+	for _, modelDir := range modelDirs {
+		if !hasReadme(modelMap.ModelRoots, modelDir) {
+			problems = append(problems, fmt.Sprintf("%s: missing README", modelDir))
+		}
+		entry, ok := idx.Models[modelDir]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s: missing entry in %s", modelDir, ModelIndexFileName))
+			continue
+		case entry.Title == "":
+			problems = append(problems, fmt.Sprintf("%s: %s entry has no title", modelDir, ModelIndexFileName))
+		}
+		if entry.Maintainer == "" {
+			problems = append(problems, fmt.Sprintf("%s: %s entry has no maintainer", modelDir, ModelIndexFileName))
+		}
+	}
+
+	var staleDirs []string
+	for modelDir := range idx.Models {
+		if !modelDirSet[modelDir] {
+			staleDirs = append(staleDirs, modelDir)
+		}
+	}
+	sort.Strings(staleDirs)
+	for _, modelDir := range staleDirs {
+		problems = append(problems, fmt.Sprintf("%s: stale %s entry, not a model directory with a .spec.yml", modelDir, ModelIndexFileName))
+	}
+	return problems
+}
+
+// hasReadme returns whether the model directory has a README within the model
+// root containing it.
+func hasReadme(modelRoots []string, modelDir string) bool {
+	for _, root := range modelRoots {
+		if _, err := os.Stat(filepath.Join(root, modelDir, ".spec.yml")); err != nil {
+			continue
+		}
+		for _, name := range readmeFileNames {
+			if _, err := os.Stat(filepath.Join(root, modelDir, name)); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseModelIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    *ModelIndex
+		wantErr bool
+	}{{
+		name: "empty",
+		in:   "",
+		want: &ModelIndex{Models: map[string]ModelIndexEntry{}},
+	}, {
+		name: "valid",
+		in: `
+models:
+  acl:
+    title: Access Control Lists
+    maintainer: openconfig/acl-maintainers
+  wifi/mac/:
+    title: WiFi MAC
+`,
+		want: &ModelIndex{Models: map[string]ModelIndexEntry{
+			"acl":      {Title: "Access Control Lists", Maintainer: "openconfig/acl-maintainers"},
+			"wifi/mac": {Title: "WiFi MAC"},
+		}},
+	}, {
+		name:    "empty directory",
+		in:      "models:\n  /:\n    title: Root\n",
+		wantErr: true,
+	}, {
+		name:    "unknown entry field",
+		in:      "models:\n  acl:\n    owner: me\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseModelIndex([]byte(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadModelIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ModelIndexFileName)
+	got, err := ReadModelIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got model index %v for missing file, want nil", got)
+	}
+
+	if err := os.WriteFile(path, []byte("models:\n  acl:\n    title: ACL\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ReadModelIndex(path); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(&ModelIndex{Models: map[string]ModelIndexEntry{"acl": {Title: "ACL"}}}, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestModelIndexCheck(t *testing.T) {
+	root := t.TempDir()
+	for path, content := range map[string]string{
+		"acl/.spec.yml":          "",
+		"acl/README.md":          "",
+		"wifi/mac/.spec.yml":     "",
+		"wifi/phy/.spec.yml":     "",
+		"wifi/phy/README":        "",
+		"wifi/mac/unrelated.txt": "",
+	} {
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	modelMap := OpenConfigModelMap{
+		ModelRoots: []string{root},
+		ModelInfoMap: map[string][]ModelInfo{
+			"acl":      {{Name: "openconfig-acl"}},
+			"wifi:mac": {{Name: "openconfig-wifi-mac"}},
+			"wifi:phy": {{Name: "openconfig-wifi-phy"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		in   *ModelIndex
+		want []string
+	}{{
+		name: "no index",
+	}, {
+		name: "complete",
+		in: &ModelIndex{Models: map[string]ModelIndexEntry{
+			"acl":      {Title: "ACL", Maintainer: "a"},
+			"wifi/mac": {Title: "WiFi MAC", Maintainer: "w"},
+			"wifi/phy": {Title: "WiFi PHY", Maintainer: "w"},
+		}},
+		want: []string{
+			"wifi/mac: missing README",
+		},
+	}, {
+		name: "missing, incomplete and stale entries",
+		in: &ModelIndex{Models: map[string]ModelIndexEntry{
+			"acl":      {Maintainer: "a"},
+			"wifi/phy": {},
+			"bgp":      {Title: "BGP", Maintainer: "b"},
+		}},
+		want: []string{
+			"acl: model-index.yml entry has no title",
+			"wifi/mac: missing README",
+			"wifi/mac: missing entry in model-index.yml",
+			"wifi/phy: model-index.yml entry has no title",
+			"wifi/phy: model-index.yml entry has no maintainer",
+			"bgp: stale model-index.yml entry, not a model directory with a .spec.yml",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, tt.in.Check(modelMap)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
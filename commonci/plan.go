@@ -0,0 +1,161 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+const (
+	// PlanFile is created by cmd_gen to describe the CI run to later CI
+	// steps, as a JSON Plan.
+	PlanFile = UserConfigDir + "/plan.json"
+	// PlanFormatVersion is the version of the Plan document. It is
+	// incremented whenever the document changes in a way that isn't
+	// understood by older readers.
+	PlanFormatVersion = 1
+)
+
+// Plan describes a CI run: the validators to run along with their versions and
+// scripts, and how their results are reported. It is the contract between
+// cmd_gen, which writes it to PlanFile, and the later CI steps, i.e. the
+// validator scripts and post_results.
+type Plan struct {
+	// FormatVersion is the PlanFormatVersion of the document.
+	FormatVersion int `json:"formatVersion"`
+	// DefaultBranch is the default branch of the models repo.
+	DefaultBranch string `json:"defaultBranch"`
+	// StartTime is when cmd_gen started, i.e. the start of the CI run.
+	StartTime time.Time `json:"startTime"`
+	// BaseRef is the PR's base branch, and MergeBase is the SHA of the
+	// merge base of the PR's head commit with the base branch, against
+	// which the PR's changes are diffed (e.g. by misc-checks).
+	BaseRef   string `json:"baseRef,omitempty"`
+	MergeBase string `json:"mergeBase,omitempty"`
+	// ForkSlug is the "owner/repo" of the PR's head repo if it's a fork.
+	ForkSlug string `json:"forkSlug,omitempty"`
+	// StatusCommentPR is the number of the PR if its head is a fork (see
+	// the StatusCommentPR global).
+	StatusCommentPR int `json:"statusCommentPR,omitempty"`
+	// ReleaseTag is the release tag of the commit being validated, if any.
+	ReleaseTag string `json:"releaseTag,omitempty"`
+	// StatusContextPrefix is the prefix applied to all PR status contexts.
+	StatusContextPrefix string `json:"statusContextPrefix,omitempty"`
+	// Shard is the N/M shard of the model directories validated by the
+	// per-model validators, if they're split across builds.
+	Shard string `json:"shard,omitempty"`
+	// ShadowMode indicates that nothing should be posted to the PR.
+	ShadowMode bool `json:"shadowMode,omitempty"`
+	// CondensedReport indicates that only the condensed (i.e. failures
+	// only) results should be posted.
+	CondensedReport bool `json:"condensedReport,omitempty"`
+	// PyangMatrix indicates that all pyang versions are reported by the
+	// single "pyang-matrix" status.
+	PyangMatrix bool `json:"pyangMatrix,omitempty"`
+	// ReporterService indicates that a long-lived post_results reporter
+	// service posts the results, such that validator steps only mark their
+	// results as done.
+	ReporterService bool `json:"reporterService,omitempty"`
+	// ReportOnlyRollouts are the validators and validator versions whose
+	// PR status never fails since they're in report-only rollout.
+	ReportOnlyRollouts ReportOnlyRollouts `json:"reportOnlyRollouts,omitempty"`
+	// MaxReportedLevels is the maximum message level reported for each
+	// validator that has one.
+	MaxReportedLevels map[string]uint32 `json:"maxReportedLevels,omitempty"`
+	// Banner is the markdown announcement to display in every report.
+	Banner string `json:"banner,omitempty"`
+	// CompatReport describes the validators reported within the
+	// compatibility report.
+	CompatReport *CompatReport `json:"compatReport"`
+	// RequiredStatusPolicy is the policy of the aggregate "required" PR
+	// status, if the status is enabled.
+	RequiredStatusPolicy *RequiredStatusPolicy `json:"requiredStatusPolicy,omitempty"`
+	// Validators are the validator@versions to run.
+	Validators []PlannedValidator `json:"validators"`
+}
+
+// PlannedValidator is a validator@version to run within a Plan.
+type PlannedValidator struct {
+	ValidatorId string `json:"validatorId"`
+	Version     string `json:"version,omitempty"`
+	// ResultsDir is the results directory of the validator, whose
+	// existence activates the validator.
+	ResultsDir string `json:"resultsDir"`
+	// Script is the path of the script running the validator's commands,
+	// if it has a generated script.
+	Script string `json:"script,omitempty"`
+	// ModelCount is the number of models validated by the script, if the
+	// validator is per-model.
+	ModelCount int `json:"modelCount,omitempty"`
+	// CacheKey is the ResultCacheInputsKey of the validator's run if its
+	// results may be reused from the result cache.
+	CacheKey string `json:"cacheKey,omitempty"`
+}
+
+// NewPlan returns an empty Plan for a models repo with the given default
+// branch.
+func NewPlan(defaultBranch string) *Plan {
+	return &Plan{
+		FormatVersion: PlanFormatVersion,
+		DefaultBranch: defaultBranch,
+		CompatReport:  &CompatReport{FormatVersion: CompatReportFormatVersion, Members: []CompatReportMember{}},
+		Validators:    []PlannedValidator{},
+	}
+}
+
+// MarshalPlan returns the contents of the plan's file.
+func MarshalPlan(p *Plan) ([]byte, error) {
+	bs, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal plan: %v", err)
+	}
+	return append(bs, '\n'), nil
+}
+
+// ReadPlan returns the plan relayed by cmd_gen through the file at path
+// (normally PlanFile). If the file doesn't exist, e.g. when a posting step is
+// re-run standalone, then nil is returned.
+func ReadPlan(path string) (*Plan, error) {
+	bs, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read plan file %q: %v", path, err)
+	}
+
+	p := &Plan{}
+	if err := json.Unmarshal(bs, p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan file %q: %v", path, err)
+	}
+	if p.FormatVersion != PlanFormatVersion {
+		return nil, fmt.Errorf("plan file %q has unsupported format version %d, expected %d", path, p.FormatVersion, PlanFormatVersion)
+	}
+	if p.CompatReport == nil {
+		return nil, fmt.Errorf("plan file %q has no compatibility report", path)
+	}
+	if p.CompatReport.FormatVersion != CompatReportFormatVersion {
+		return nil, fmt.Errorf("plan file %q has compatibility report with unsupported format version %d, expected %d", path, p.CompatReport.FormatVersion, CompatReportFormatVersion)
+	}
+	if p.RequiredStatusPolicy != nil && p.RequiredStatusPolicy.FormatVersion != RequiredStatusPolicyFormatVersion {
+		return nil, fmt.Errorf("plan file %q has required status policy with unsupported format version %d, expected %d", path, p.RequiredStatusPolicy.FormatVersion, RequiredStatusPolicyFormatVersion)
+	}
+	return p, nil
+}
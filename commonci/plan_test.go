@@ -0,0 +1,113 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadPlan(t *testing.T) {
+	dir := t.TempDir()
+	compatReport, err := NewCompatReport("pyang@head,goyang-ygot", "pyang@head")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewPlan("main")
+	want.StartTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	want.ForkSlug = "fork/public"
+	want.StatusCommentPR = 42
+	want.BaseRef = "release-v5"
+	want.MergeBase = "abc123"
+	want.ShadowMode = true
+	want.MaxReportedLevels = map[string]uint32{"oc-pyang": 3}
+	want.CompatReport = compatReport
+	want.RequiredStatusPolicy = &RequiredStatusPolicy{
+		FormatVersion: RequiredStatusPolicyFormatVersion,
+		Validators:    []ValidatorAndVersion{{ValidatorId: "misc-checks"}},
+	}
+	want.Validators = []PlannedValidator{
+		{ValidatorId: "pyang", ResultsDir: "/workspace/results/pyang", Script: "/workspace/results/pyang/script.sh", ModelCount: 2},
+		{ValidatorId: "pyang", Version: "head", ResultsDir: "/workspace/results/pyang@head", Script: "/workspace/results/pyang@head/script.sh", ModelCount: 2},
+	}
+	bs, err := MarshalPlan(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string]string{
+		"plan.json":              string(bs),
+		"bad-version.json":       `{"formatVersion": 999, "compatReport": {"formatVersion": 1, "members": []}}`,
+		"no-compat-report.json":  `{"formatVersion": 1}`,
+		"bad-compat-report.json": `{"formatVersion": 1, "compatReport": {"formatVersion": 999, "members": []}}`,
+		"bad-policy.json":        `{"formatVersion": 1, "compatReport": {"formatVersion": 1, "members": []}, "requiredStatusPolicy": {"formatVersion": 999}}`,
+		"invalid.json":           "defaultBranch: main\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0444); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		desc    string
+		inFile  string
+		want    *Plan
+		wantErr bool
+	}{{
+		desc:   "file exists",
+		inFile: "plan.json",
+		want:   want,
+	}, {
+		desc:   "file doesn't exist",
+		inFile: "dne.json",
+	}, {
+		desc:    "unsupported format version",
+		inFile:  "bad-version.json",
+		wantErr: true,
+	}, {
+		desc:    "no compatibility report",
+		inFile:  "no-compat-report.json",
+		wantErr: true,
+	}, {
+		desc:    "unsupported compatibility report format version",
+		inFile:  "bad-compat-report.json",
+		wantErr: true,
+	}, {
+		desc:    "unsupported required status policy format version",
+		inFile:  "bad-policy.json",
+		wantErr: true,
+	}, {
+		desc:    "not JSON",
+		inFile:  "invalid.json",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ReadPlan(filepath.Join(dir, tt.inFile))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
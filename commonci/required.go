@@ -0,0 +1,110 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RequiredStatusPolicyFormatVersion is the version of the
+// RequiredStatusPolicy document format.
+const RequiredStatusPolicyFormatVersion = 1
+
+// Outcomes of a validator recorded for the required status.
+const (
+	RequiredOutcomePass = "pass"
+	RequiredOutcomeFail = "fail"
+	// RequiredOutcomeBreaking indicates that the validator passed, but
+	// found breaking changes that are disallowed by the policy.
+	RequiredOutcomeBreaking = "breaking"
+)
+
+// RequiredStatusPolicy determines the aggregate "required" PR status, which
+// succeeds only when all of the policy's validators pass and there are no
+// disallowed breaking changes, such that branch protection need only require
+// a single status context. It is relayed by cmd_gen to later CI steps within
+// the Plan.
+type RequiredStatusPolicy struct {
+	// FormatVersion is the RequiredStatusPolicyFormatVersion of the
+	// document.
+	FormatVersion int `json:"formatVersion"`
+	// Validators are the validators that must pass.
+	Validators []ValidatorAndVersion `json:"validators"`
+	// AllowBreaking indicates that breaking changes (i.e. major
+	// openconfig-version changes and deleted files) found by misc-checks
+	// don't fail the required status.
+	AllowBreaking bool `json:"allowBreaking,omitempty"`
+}
+
+// Requires returns whether the validator@version is required by the policy.
+func (p *RequiredStatusPolicy) Requires(validatorId, version string) bool {
+	for _, vv := range p.Validators {
+		if vv.ValidatorId == validatorId && vv.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordRequiredOutcome records the outcome of the validator@version within
+// dir (normally RequiredStatusDir) for evaluating the required status.
+func RecordRequiredOutcome(dir, validatorId, version, outcome string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error while creating directory %q: %v", dir, err)
+	}
+	path := filepath.Join(dir, AppendVersionToName(validatorId, version))
+	if err := WriteFile(path, []byte(outcome), 0644); err != nil {
+		return fmt.Errorf("error while writing required outcome file %q: %v", path, err)
+	}
+	return nil
+}
+
+// Evaluate evaluates the policy against the outcomes recorded
+// within dir (normally RequiredStatusDir). It returns whether all of the
+// policy's validators have recorded outcomes, and if so, the
+// <validatorId>@<version> names of the validators that failed, and whether
+// disallowed breaking changes were found.
+func (p *RequiredStatusPolicy) Evaluate(dir string) (bool, []string, bool, error) {
+	var failures []string
+	var breaking bool
+	for _, vv := range p.Validators {
+		name := AppendVersionToName(vv.ValidatorId, vv.Version)
+		path := filepath.Join(dir, name)
+		bs, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return false, nil, false, nil
+		case err != nil:
+			return false, nil, false, fmt.Errorf("failed to read required outcome file %q: %v", path, err)
+		}
+		switch outcome := strings.TrimSpace(string(bs)); outcome {
+		case RequiredOutcomePass:
+		case RequiredOutcomeFail:
+			failures = append(failures, name)
+		case RequiredOutcomeBreaking:
+			if !p.AllowBreaking {
+				breaking = true
+			}
+		default:
+			return false, nil, false, fmt.Errorf("unrecognized outcome %q in required outcome file %q", outcome, path)
+		}
+	}
+	return true, failures, breaking, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRequiredStatusPolicyRequires(t *testing.T) {
+	p := &RequiredStatusPolicy{
+		FormatVersion: RequiredStatusPolicyFormatVersion,
+		Validators:    []ValidatorAndVersion{{ValidatorId: "pyang", Version: "head"}, {ValidatorId: "misc-checks"}},
+	}
+	if !p.Requires("pyang", "head") || p.Requires("pyang", "") {
+		t.Errorf("Requires: got wrong result for pyang versions")
+	}
+	if !p.Requires("misc-checks", "") {
+		t.Errorf("Requires: got misc-checks not required")
+	}
+}
+
+func TestRequiredStatusPolicyEvaluate(t *testing.T) {
+	tests := []struct {
+		desc            string
+		inAllowBreaking bool
+		inOutcomes      map[string]string
+		wantComplete    bool
+		wantFailures    []string
+		wantBreaking    bool
+		wantErr         bool
+	}{{
+		desc:       "incomplete",
+		inOutcomes: map[string]string{"pyang": RequiredOutcomePass},
+	}, {
+		desc:         "all pass",
+		inOutcomes:   map[string]string{"pyang": RequiredOutcomePass, "misc-checks": RequiredOutcomePass},
+		wantComplete: true,
+	}, {
+		desc:         "failure and disallowed breaking changes",
+		inOutcomes:   map[string]string{"pyang": RequiredOutcomeFail, "misc-checks": RequiredOutcomeBreaking},
+		wantComplete: true,
+		wantFailures: []string{"pyang"},
+		wantBreaking: true,
+	}, {
+		desc:            "allowed breaking changes",
+		inAllowBreaking: true,
+		inOutcomes:      map[string]string{"pyang": RequiredOutcomePass, "misc-checks": RequiredOutcomeBreaking},
+		wantComplete:    true,
+	}, {
+		desc:       "unrecognized outcome",
+		inOutcomes: map[string]string{"pyang": "pending", "misc-checks": RequiredOutcomePass},
+		wantErr:    true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			for validatorId, outcome := range tt.inOutcomes {
+				if err := RecordRequiredOutcome(dir, validatorId, "", outcome); err != nil {
+					t.Fatal(err)
+				}
+			}
+			p := &RequiredStatusPolicy{
+				Validators:    []ValidatorAndVersion{{ValidatorId: "pyang"}, {ValidatorId: "misc-checks"}},
+				AllowBreaking: tt.inAllowBreaking,
+			}
+			complete, failures, breaking, err := p.Evaluate(dir)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if complete != tt.wantComplete {
+				t.Errorf("complete: got %v, want %v", complete, tt.wantComplete)
+			}
+			if diff := cmp.Diff(tt.wantFailures, failures); diff != "" {
+				t.Errorf("failures (-want, +got):\n%s", diff)
+			}
+			if breaking != tt.wantBreaking {
+				t.Errorf("breaking: got %v, want %v", breaking, tt.wantBreaking)
+			}
+		})
+	}
+}
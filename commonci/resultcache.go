@@ -0,0 +1,158 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	// ResultCacheDir is the directory within the bucket containing the
+	// cached results of validator runs, each of which is a gzipped tarball
+	// of the validator's results directory named by its ResultCacheKey.
+	ResultCacheDir = "result-cache"
+	// ResultCacheHitFileName by convention is created within a validator's
+	// results directory when its results are restored from the result
+	// cache rather than by running the validator, such that they aren't
+	// cached again.
+	ResultCacheHitFileName = "result-cache-hit"
+)
+
+// resultCacheExcluded are the files of a results directory that aren't
+// cached, since they aren't results of running the validator script.
+var resultCacheExcluded = map[string]bool{
+	ScriptFileName:             true,
+	ExpectedModelCountFileName: true,
+	LatestVersionFileName:      true,
+	DoneFileName:               true,
+	ExitedFileName:             true,
+	ResultCacheHitFileName:     true,
+}
+
+// FilesHash returns a hash of the names and contents of all files within the
+// given directories, which changes whenever any of the files do.
+func FilesHash(dirs []string) (string, error) {
+	h := sha256.New()
+	for _, dir := range dirs {
+		var paths []string
+		if err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.Type().IsRegular() {
+				paths = append(paths, path)
+			}
+			return nil
+		}); err != nil {
+			return "", fmt.Errorf("error while hashing the files within %q: %v", dir, err)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			bs, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("error while hashing file: %v", err)
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", path, len(bs))
+			h.Write(bs)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ResultCacheInputsKey returns the key of the inputs of a validator run,
+// i.e. the validator and version, its generated script (or the files
+// describing its commands), and the hash of the validated files (see
+// FilesHash). The version description of the tool installed for the run is
+// only known once it's installed, and is combined with the inputs key by
+// ResultCacheKey.
+func ResultCacheInputsKey(validatorId, version, script, filesHash string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00", validatorId, version, filesHash, len(script))
+	io.WriteString(h, script)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ResultCacheKey returns the key of the cached results of a validator run
+// given the run's inputs key and the contents of the tool's
+// LatestVersionFileName, which is empty if it doesn't have one.
+//
+// The key must match the one computed by the generated validator scripts,
+// i.e. the sha256sum of the inputs key followed by the version description.
+func ResultCacheKey(inputsKey string, toolVersion []byte) string {
+	h := sha256.New()
+	io.WriteString(h, inputsKey)
+	h.Write(toolVersion)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ResultCacheObject returns the name of the object within the bucket of the
+// cached results with the given key.
+func ResultCacheObject(key string) string {
+	return ResultCacheDir + "/" + key + ".tar.gz"
+}
+
+// ArchiveResults returns a gzipped tarball of the results of a validator
+// within its results directory, excluding the files written by cmd_gen and
+// test.sh around running the validator script.
+func ArchiveResults(resultsDir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := filepath.WalkDir(resultsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(resultsDir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || !d.Type().IsRegular() || resultCacheExcluded[rel] {
+			return nil
+		}
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     filepath.ToSlash(rel),
+			Mode:     0644,
+			Size:     int64(len(bs)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+		_, err = tw.Write(bs)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("error while archiving results directory %q: %v", resultsDir, err)
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFilesHash(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles := func(files map[string]string) {
+		t.Helper()
+		for name, content := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	hash := func() string {
+		t.Helper()
+		h, err := FilesHash([]string{dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	writeFiles(map[string]string{"acl/openconfig-acl.yang": "module a {}", "acl/.spec.yml": "- name: a"})
+	h := hash()
+	if got := hash(); got != h {
+		t.Errorf("hash of unchanged files changed from %s to %s", h, got)
+	}
+	writeFiles(map[string]string{"acl/openconfig-acl.yang": "module b {}"})
+	if got := hash(); got == h {
+		t.Errorf("hash unchanged after changing a file")
+	}
+	h = hash()
+	writeFiles(map[string]string{"aft/openconfig-aft.yang": ""})
+	if got := hash(); got == h {
+		t.Errorf("hash unchanged after adding a file")
+	}
+	if _, err := FilesHash([]string{filepath.Join(dir, "dne")}); err == nil {
+		t.Errorf("got no error for a directory that doesn't exist")
+	}
+}
+
+func TestResultCacheKey(t *testing.T) {
+	inputsKey := ResultCacheInputsKey("pyang", "", "script", "files")
+	for _, other := range []string{
+		ResultCacheInputsKey("pyang", "2.5.3", "script", "files"),
+		ResultCacheInputsKey("yanglint", "", "script", "files"),
+		ResultCacheInputsKey("pyang", "", "other script", "files"),
+		ResultCacheInputsKey("pyang", "", "script", "other files"),
+	} {
+		if other == inputsKey {
+			t.Errorf("got the same inputs key %s for different inputs", inputsKey)
+		}
+	}
+
+	// The key is the sha256sum of the inputs key followed by the version
+	// description, as computed by the generated validator scripts.
+	if got, want := ResultCacheKey("inputs", []byte("pyang 2.6.0\n")), "c5adf6876790e8849eb1e2e2c2f37bc02e7f4b0a9678660fda097188d5f78d14"; got != want {
+		t.Errorf("got key %s, want %s", got, want)
+	}
+}
+
+func TestArchiveResults(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"out":                           "out",
+		"fail":                          "fail",
+		"acl==openconfig-acl==pass":     "",
+		"paths/acl==openconfig-acl.txt": "/acl",
+		ScriptFileName:                  "#!/bin/bash",
+		ExpectedModelCountFileName:      "1",
+		LatestVersionFileName:           "pyang 2.6.0",
+		DoneFileName:                    "",
+		ResultCacheHitFileName:          "",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive, err := ArchiveResults(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		bs, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[hdr.Name] = string(bs)
+	}
+
+	want := map[string]string{
+		"out":                           "out",
+		"fail":                          "fail",
+		"acl==openconfig-acl==pass":     "",
+		"paths/acl==openconfig-acl.txt": "/acl",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("archived files (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ModelResult is the result of a per-model validator on a single model, as
+// output by the validator script into files named "modelDir==model==status".
+type ModelResult struct {
+	// ModelDir is the model directory, with "/" replaced by ":".
+	ModelDir string
+	Model    string
+	// Status is either "pass", "fail", or "timeout" if the model failed by
+	// timing out.
+	Status string
+	// Output is the content of the status file.
+	Output string
+	// Cmd is the content of the model's "cmd" file, i.e. the command that
+	// produced the result, if present.
+	Cmd string
+	// Path is the path of the status file.
+	Path string
+}
+
+// Pass returns whether the validator passed on the model.
+func (r *ModelResult) Pass() bool {
+	return r.Status == "pass"
+}
+
+// TimedOut returns whether the validator timed out on the model.
+func (r *ModelResult) TimedOut() bool {
+	return r.Status == TimeoutStatus
+}
+
+// ResultsIterator iterates over the per-model results within a validator's
+// results directory in lexical order, such that all results of a model
+// directory are consecutive. Files that aren't in the
+// "modelDir==model==status" format are ignored.
+//
+// Usage:
+//
+//	it, err := NewResultsIterator(resultsDir)
+//	...
+//	for it.Next() {
+//		r := it.Result()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type ResultsIterator struct {
+	// paths are the paths of the result files in lexical order.
+	paths []string
+	next  int
+	cur   *ModelResult
+	err   error
+}
+
+// splitResultFileName splits a result file name into its model directory,
+// model and status, and returns false if it's not a result file.
+func splitResultFileName(name string) (string, string, string, bool) {
+	components := strings.Split(name, "==")
+	if len(components) != 3 {
+		return "", "", "", false
+	}
+	return components[0], components[1], components[2], true
+}
+
+// NewResultsIterator returns an iterator over the per-model results within
+// resultsDir.
+func NewResultsIterator(resultsDir string) (*ResultsIterator, error) {
+	it := &ResultsIterator{}
+	// filepath.Walk walks files in lexical order.
+	if err := filepath.Walk(resultsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("handle failure accessing a path %q: %v", path, err)
+		}
+		if _, _, _, ok := splitResultFileName(info.Name()); ok && !info.IsDir() {
+			it.paths = append(it.paths, path)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return it, nil
+}
+
+// Next advances to the next result, and returns false when there are no more
+// results or an error occurred.
+func (it *ResultsIterator) Next() bool {
+	var cmd, cmdModelDir, cmdModel string
+	for it.err == nil && it.next < len(it.paths) {
+		path := it.paths[it.next]
+		it.next++
+		modelDir, model, status, _ := splitResultFileName(filepath.Base(path))
+
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			it.err = fmt.Errorf("failed to read file at path %q: %v", path, err)
+			break
+		}
+
+		switch status {
+		case "cmd":
+			// Since "cmd" sorts before "fail", "pass" and "timeout", the
+			// command is read before the result it produced.
+			cmd, cmdModelDir, cmdModel = string(bs), modelDir, model
+			continue
+		case "pass", "fail", TimeoutStatus:
+		default:
+			it.err = fmt.Errorf("expect status at path %q to be pass, fail or timeout, got %v", path, status)
+			continue
+		}
+
+		it.cur = &ModelResult{
+			ModelDir: modelDir,
+			Model:    model,
+			Status:   status,
+			Output:   string(bs),
+			Path:     path,
+		}
+		if cmdModelDir == modelDir && cmdModel == model {
+			it.cur.Cmd = cmd
+		}
+		return true
+	}
+	it.cur = nil
+	return false
+}
+
+// Result returns the current result.
+func (it *ResultsIterator) Result() *ModelResult {
+	return it.cur
+}
+
+// Err returns the error encountered during iteration, if any.
+func (it *ResultsIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestResultsIterator(t *testing.T) {
+	tests := []struct {
+		name    string
+		inFiles map[string]string
+		want    []*ModelResult
+		wantErr bool
+	}{{
+		name: "results with commands",
+		inFiles: map[string]string{
+			"optical-transport==openconfig-optical-amplifier==pass":         "amp output",
+			"acl==openconfig-acl==cmd":                                      "pyang acl",
+			"acl==openconfig-acl==fail":                                     "acl output",
+			"optical-transport==openconfig-transport-line-protection==fail": "tlp output",
+			"out":         "script output",
+			"foobarbaz==": "not a result",
+		},
+		want: []*ModelResult{{
+			ModelDir: "acl",
+			Model:    "openconfig-acl",
+			Status:   "fail",
+			Output:   "acl output",
+			Cmd:      "pyang acl",
+		}, {
+			ModelDir: "optical-transport",
+			Model:    "openconfig-optical-amplifier",
+			Status:   "pass",
+			Output:   "amp output",
+		}, {
+			ModelDir: "optical-transport",
+			Model:    "openconfig-transport-line-protection",
+			Status:   "fail",
+			Output:   "tlp output",
+		}},
+	}, {
+		name: "command of another model isn't attached",
+		inFiles: map[string]string{
+			"acl==openconfig-a==pass": "",
+			"acl==openconfig-b==cmd":  "pyang b",
+			"acl==openconfig-c==pass": "",
+		},
+		want: []*ModelResult{{
+			ModelDir: "acl",
+			Model:    "openconfig-a",
+			Status:   "pass",
+		}, {
+			ModelDir: "acl",
+			Model:    "openconfig-c",
+			Status:   "pass",
+		}},
+	}, {
+		name: "timed out model",
+		inFiles: map[string]string{
+			"acl==openconfig-a==cmd":     "pyangbind a",
+			"acl==openconfig-a==timeout": "timed out after 600s",
+		},
+		want: []*ModelResult{{
+			ModelDir: "acl",
+			Model:    "openconfig-a",
+			Status:   "timeout",
+			Output:   "timed out after 600s",
+			Cmd:      "pyangbind a",
+		}},
+	}, {
+		name: "invalid status",
+		inFiles: map[string]string{
+			"acl==openconfig-a==pass":  "",
+			"acl==openconfig-b==maybe": "",
+		},
+		want: []*ModelResult{{
+			ModelDir: "acl",
+			Model:    "openconfig-a",
+			Status:   "pass",
+		}},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.inFiles {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			it, err := NewResultsIterator(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []*ModelResult
+			for it.Next() {
+				got = append(got, it.Result())
+			}
+			if gotErr := it.Err() != nil; gotErr != tt.wantErr {
+				t.Errorf("got error %v, wantErr: %v", it.Err(), tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(ModelResult{}, "Path")); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := NewResultsIterator("testdata/dne"); err == nil {
+		t.Errorf("got no error for nonexistent results directory")
+	}
+}
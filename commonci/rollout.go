@@ -0,0 +1,94 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// RolloutReportOnly is the rollout of a newly added validator that
+	// runs and posts its results as usual, but whose PR status never
+	// fails, such that it can be introduced without blocking PRs.
+	RolloutReportOnly = "report-only"
+	// rolloutUntilLayout is the format of the last day of a rollout.
+	rolloutUntilLayout = "2006-01-02"
+)
+
+// ReportOnlyRollouts maps each validator (e.g. "ygnmi") or validator version
+// (e.g. "pyang@3.0") in report-only rollout to the last day (YYYY-MM-DD) of
+// its rollout, or to "" if the rollout doesn't end.
+type ReportOnlyRollouts map[string]string
+
+// parseRolloutUntil parses the last day of a rollout.
+func parseRolloutUntil(until string) (time.Time, error) {
+	t, err := time.Parse(rolloutUntilLayout, until)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid rollout end %q, must be YYYY-MM-DD", until)
+	}
+	return t, nil
+}
+
+// ParseReportOnlyRollouts converts a comma-separated list of
+// <validatorId>[@<version>][=<YYYY-MM-DD>] entries (e.g.
+// "ygnmi,pyang@3.0=2024-12-31") to the ReportOnlyRollouts they describe. An
+// entry without a version applies to every version of the validator.
+func ParseReportOnlyRollouts(rolloutsStr string) (ReportOnlyRollouts, error) {
+	rollouts := ReportOnlyRollouts{}
+	for _, entry := range strings.Fields(strings.ReplaceAll(rolloutsStr, ",", " ")) {
+		name, until, _ := strings.Cut(entry, "=")
+		validatorId, _, _ := strings.Cut(name, "@")
+		if _, ok := Validators[validatorId]; !ok {
+			return nil, fmt.Errorf("unrecognized validatorId %q in entry %q", validatorId, entry)
+		}
+		if until != "" {
+			if _, err := parseRolloutUntil(until); err != nil {
+				return nil, fmt.Errorf("entry %q: %v", entry, err)
+			}
+		}
+		rollouts[name] = until
+	}
+	return rollouts, nil
+}
+
+// Active returns the rollouts that haven't ended at the given time, i.e.
+// whose last day, if any, isn't before it.
+func (r ReportOnlyRollouts) Active(now time.Time) ReportOnlyRollouts {
+	active := ReportOnlyRollouts{}
+	for name, until := range r {
+		if until != "" {
+			// Entries are validated when parsed.
+			if t, err := parseRolloutUntil(until); err != nil || !now.Before(t.AddDate(0, 0, 1)) {
+				continue
+			}
+		}
+		active[name] = until
+	}
+	return active
+}
+
+// Lookup returns the last day of the report-only rollout of the validator
+// version, or "" if the rollout doesn't end, and whether it's in report-only
+// rollout at all. A rollout of the version takes precedence over that of
+// every version of the validator.
+func (r ReportOnlyRollouts) Lookup(validatorId, version string) (string, bool) {
+	if until, ok := r[AppendVersionToName(validatorId, version)]; ok {
+		return until, true
+	}
+	until, ok := r[validatorId]
+	return until, ok
+}
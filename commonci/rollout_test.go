@@ -0,0 +1,106 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseReportOnlyRollouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    ReportOnlyRollouts
+		wantErr bool
+	}{{
+		name: "empty",
+		in:   "",
+		want: ReportOnlyRollouts{},
+	}, {
+		name: "validators and versions",
+		in:   "goyang-ygot, pyang@3.0=2024-12-31",
+		want: ReportOnlyRollouts{"goyang-ygot": "", "pyang@3.0": "2024-12-31"},
+	}, {
+		name:    "unrecognized validator",
+		in:      "my-linter",
+		wantErr: true,
+	}, {
+		name:    "invalid date",
+		in:      "pyang@3.0=Dec 31",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReportOnlyRollouts(tt.in)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReportOnlyRolloutsActive(t *testing.T) {
+	rollouts := ReportOnlyRollouts{
+		"goyang-ygot":   "",
+		"pyang@3.0":     "2024-12-31",
+		"yanglint@head": "2024-12-30",
+	}
+	now := time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC)
+	want := ReportOnlyRollouts{"goyang-ygot": "", "pyang@3.0": "2024-12-31"}
+	if diff := cmp.Diff(want, rollouts.Active(now)); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestReportOnlyRolloutsLookup(t *testing.T) {
+	rollouts := ReportOnlyRollouts{"pyang": "", "pyang@3.0": "2024-12-31"}
+	tests := []struct {
+		name        string
+		inValidator string
+		inVersion   string
+		wantUntil   string
+		wantOK      bool
+	}{{
+		name:        "version",
+		inValidator: "pyang",
+		inVersion:   "3.0",
+		wantUntil:   "2024-12-31",
+		wantOK:      true,
+	}, {
+		name:        "every version",
+		inValidator: "pyang",
+		inVersion:   "head",
+		wantOK:      true,
+	}, {
+		name:        "not in rollout",
+		inValidator: "yanglint",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, ok := rollouts.Lookup(tt.inValidator, tt.inVersion)
+			if until != tt.wantUntil || ok != tt.wantOK {
+				t.Errorf("got (%q, %v), want (%q, %v)", until, ok, tt.wantUntil, tt.wantOK)
+			}
+		})
+	}
+}
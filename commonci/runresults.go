@@ -0,0 +1,177 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RunResultsFormatVersion is the version of the RunResults document format.
+const RunResultsFormatVersion = 1
+
+const (
+	// runResultsDir is the directory within the storage bucket where the
+	// results of each CI run on a PR are stored.
+	runResultsDir = "run-results"
+	// shadowRunResultsDir is the directory where run results are stored
+	// when running in shadow mode.
+	shadowRunResultsDir = "run-results-shadow"
+)
+
+// RunResults are the parsed results of a per-model validator for a single CI
+// run on a PR, which are stored keyed by commit SHA such that the next run on
+// the PR can report the changes since.
+type RunResults struct {
+	// FormatVersion is the RunResultsFormatVersion of the document.
+	FormatVersion int `json:"formatVersion"`
+	// CommitSHA is the commit on which the validator was run.
+	CommitSHA string `json:"commitSHA"`
+	// PreviousCommitSHA is the commit of the previous run on the PR, if
+	// any.
+	PreviousCommitSHA string `json:"previousCommitSHA,omitempty"`
+	// Failures are the names of the failed models in the form
+	// "<modelDir>/<model>", in lexical order.
+	Failures []string `json:"failures"`
+}
+
+// NewRunResults returns the run results for the given commit from the
+// per-model results within resultsDir.
+func NewRunResults(commitSHA, resultsDir string) (*RunResults, error) {
+	r := &RunResults{
+		FormatVersion: RunResultsFormatVersion,
+		CommitSHA:     commitSHA,
+	}
+	it, err := NewResultsIterator(resultsDir)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		if result := it.Result(); !result.Pass() {
+			r.Failures = append(r.Failures, strings.ReplaceAll(result.ModelDir, ":", "/")+"/"+result.Model)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ChangesSince returns the failures of prev that were fixed, and the new
+// failures that didn't occur in prev.
+func (r *RunResults) ChangesSince(prev *RunResults) ([]string, []string) {
+	prevFailures := map[string]bool{}
+	for _, f := range prev.Failures {
+		prevFailures[f] = true
+	}
+	failures := map[string]bool{}
+	var introduced []string
+	for _, f := range r.Failures {
+		failures[f] = true
+		if !prevFailures[f] {
+			introduced = append(introduced, f)
+		}
+	}
+	var fixed []string
+	for _, f := range prev.Failures {
+		if !failures[f] {
+			fixed = append(fixed, f)
+		}
+	}
+	return fixed, introduced
+}
+
+// runResultsPrefix returns the prefix of the names of the objects storing the
+// run results of the validator on the PR.
+func runResultsPrefix(repoSlug string, prNumber int, validatorId, version string) string {
+	dir := runResultsDir
+	if ShadowMode {
+		dir = shadowRunResultsDir
+	}
+	return fmt.Sprintf("%s/%s/pr-%d/%s", dir, strings.ReplaceAll(repoSlug, "/", "-"), prNumber, AppendVersionToName(validatorId, version))
+}
+
+// downloadRunResults returns the run results stored in the object, or nil if
+// the object doesn't exist.
+func downloadRunResults(ctx context.Context, client StorageClient, object string) (*RunResults, error) {
+	bs, err := client.Download(ctx, object)
+	switch {
+	case errors.Is(err, ErrObjectNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	r := &RunResults{}
+	if err := json.Unmarshal(bs, r); err != nil {
+		return nil, fmt.Errorf("failed to parse run results %q: %v", object, err)
+	}
+	if r.FormatVersion != RunResultsFormatVersion {
+		return nil, fmt.Errorf("run results %q have unsupported format version %d, expected %d", object, r.FormatVersion, RunResultsFormatVersion)
+	}
+	return r, nil
+}
+
+// StoreRunResults stores the run results of the validator on the PR, and
+// returns the results of the previous run on the PR, or nil if there isn't
+// one.
+//
+// The results are stored keyed by commit SHA, along with the SHA of the
+// latest run, such that re-running CI on the same commit still compares
+// against the previous commit's results.
+func StoreRunResults(ctx context.Context, client StorageClient, repoSlug string, prNumber int, validatorId, version string, r *RunResults) (*RunResults, error) {
+	prefix := runResultsPrefix(repoSlug, prNumber, validatorId, version)
+	latestObject := prefix + "/latest"
+
+	latest, err := client.Download(ctx, latestObject)
+	switch {
+	case errors.Is(err, ErrObjectNotExist):
+	case err != nil:
+		return nil, err
+	case string(latest) != r.CommitSHA:
+		r.PreviousCommitSHA = string(latest)
+	default:
+		// Re-run on the same commit: keep comparing against the run
+		// that preceded the commit.
+		cur, err := downloadRunResults(ctx, client, prefix+"/"+r.CommitSHA+".json")
+		if err != nil {
+			return nil, err
+		}
+		if cur != nil {
+			r.PreviousCommitSHA = cur.PreviousCommitSHA
+		}
+	}
+
+	var prev *RunResults
+	if r.PreviousCommitSHA != "" {
+		if prev, err = downloadRunResults(ctx, client, prefix+"/"+r.PreviousCommitSHA+".json"); err != nil {
+			return nil, err
+		}
+	}
+
+	bs, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal run results: %v", err)
+	}
+	if err := client.UploadPublic(ctx, prefix+"/"+r.CommitSHA+".json", append(bs, '\n')); err != nil {
+		return nil, err
+	}
+	if err := client.UploadPublic(ctx, latestObject, []byte(r.CommitSHA)); err != nil {
+		return nil, err
+	}
+	return prev, nil
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewRunResults(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"acl==openconfig-acl==fail":                                     "acl output",
+		"optical-transport==openconfig-optical-amplifier==pass":         "amp output",
+		"optical-transport==openconfig-transport-line-protection==fail": "tlp output",
+		"wifi:mac==openconfig-wifi-mac==fail":                           "mac output",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := NewRunResults("abc", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &RunResults{
+		FormatVersion: RunResultsFormatVersion,
+		CommitSHA:     "abc",
+		Failures:      []string{"acl/openconfig-acl", "optical-transport/openconfig-transport-line-protection", "wifi/mac/openconfig-wifi-mac"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestChangesSince(t *testing.T) {
+	tests := []struct {
+		name           string
+		inPrevFailures []string
+		inFailures     []string
+		wantFixed      []string
+		wantIntroduced []string
+	}{{
+		name: "no failures",
+	}, {
+		name:           "unchanged",
+		inPrevFailures: []string{"acl/openconfig-acl"},
+		inFailures:     []string{"acl/openconfig-acl"},
+	}, {
+		name:           "fixed and introduced",
+		inPrevFailures: []string{"acl/openconfig-acl", "bgp/openconfig-bgp"},
+		inFailures:     []string{"bgp/openconfig-bgp", "isis/openconfig-isis"},
+		wantFixed:      []string{"acl/openconfig-acl"},
+		wantIntroduced: []string{"isis/openconfig-isis"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &RunResults{Failures: tt.inFailures}
+			gotFixed, gotIntroduced := r.ChangesSince(&RunResults{Failures: tt.inPrevFailures})
+			if diff := cmp.Diff(tt.wantFixed, gotFixed); diff != "" {
+				t.Errorf("fixed (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantIntroduced, gotIntroduced); diff != "" {
+				t.Errorf("introduced (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStoreRunResults(t *testing.T) {
+	ctx := context.Background()
+	bucket := &MemoryBucket{}
+	store := func(sha string, failures ...string) *RunResults {
+		t.Helper()
+		prev, err := StoreRunResults(ctx, bucket, "openconfig/public", 42, "pyang", "", &RunResults{
+			FormatVersion: RunResultsFormatVersion,
+			CommitSHA:     sha,
+			Failures:      failures,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prev
+	}
+
+	if prev := store("sha1", "acl/openconfig-acl"); prev != nil {
+		t.Errorf("first run: got previous results %v, want none", prev)
+	}
+	if prev := store("sha2"); prev == nil || prev.CommitSHA != "sha1" {
+		t.Errorf("second run: got previous results %v, want sha1's", prev)
+	}
+	// A re-run on the same commit still compares against the previous commit.
+	prev := store("sha2")
+	want := &RunResults{
+		FormatVersion: RunResultsFormatVersion,
+		CommitSHA:     "sha1",
+		Failures:      []string{"acl/openconfig-acl"},
+	}
+	if diff := cmp.Diff(want, prev); diff != "" {
+		t.Errorf("re-run: previous results (-want, +got):\n%s", diff)
+	}
+
+	wantObjects := []string{
+		"run-results/openconfig-public/pr-42/pyang/latest",
+		"run-results/openconfig-public/pr-42/pyang/sha1.json",
+		"run-results/openconfig-public/pr-42/pyang/sha2.json",
+	}
+	if diff := cmp.Diff(wantObjects, bucket.Objects()); diff != "" {
+		t.Errorf("stored objects (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+)
+
+const (
+	// statusCommentSignaturePrefix precedes the status context within the
+	// signature identifying the PR comment containing the status of that
+	// context on a fork PR (see StatusCommentPR).
+	statusCommentSignaturePrefix = "<!-- models-ci fork PR status: "
+	// statusCommentDataPrefix precedes the JSON-encoded statuses recorded
+	// within the status comment, from which the comment is rebuilt on each
+	// update.
+	statusCommentDataPrefix = "<!-- models-ci status data: "
+	statusCommentDataSuffix = " -->"
+)
+
+// statusCommentSignature returns the signature of the status comment of the
+// status context. Each context has its own comment, such that the concurrent
+// updates of different validators don't overwrite one another.
+func statusCommentSignature(context string) string {
+	return statusCommentSignaturePrefix + context + " -->"
+}
+
+// statusCommentEntry is a status recorded in the status comment.
+type statusCommentEntry struct {
+	State       string `json:"state"`
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// statusCommentData is the set of statuses recorded in the status comment,
+// keyed by status context, for the commit Ref.
+type statusCommentData struct {
+	Ref      string                        `json:"ref"`
+	Statuses map[string]statusCommentEntry `json:"statuses"`
+}
+
+// parseStatusComment returns the statuses recorded in the body of a status
+// comment. An empty set is returned if body doesn't contain any.
+func parseStatusComment(body string) *statusCommentData {
+	d := &statusCommentData{Statuses: map[string]statusCommentEntry{}}
+	i := strings.Index(body, statusCommentDataPrefix)
+	if i == -1 {
+		return d
+	}
+	data := body[i+len(statusCommentDataPrefix):]
+	if j := strings.Index(data, statusCommentDataSuffix); j != -1 {
+		data = data[:j]
+	}
+	var parsed statusCommentData
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil || parsed.Statuses == nil {
+		log.Printf("ignoring unparseable statuses in status comment: %v", err)
+		return d
+	}
+	return &parsed
+}
+
+// update records the status update, discarding the statuses of any other
+// commit, e.g. of a previous push to the PR.
+func (d *statusCommentData) update(u *GithubPRUpdate) {
+	if d.Ref != u.Ref {
+		d.Ref = u.Ref
+		d.Statuses = map[string]statusCommentEntry{}
+	}
+	d.Statuses[u.Context] = statusCommentEntry{
+		State:       u.NewStatus,
+		URL:         u.URL,
+		Description: u.Description,
+	}
+}
+
+// statusCommentIcons are the icons of the states of a status comment entry.
+var statusCommentIcons = map[string]string{
+	"pending": ":hourglass:",
+	"success": ":white_check_mark:",
+	"failure": ":x:",
+	"error":   ":warning:",
+}
+
+// render returns the body of the status comment with the given signature.
+func (d *statusCommentData) render(signature string) (string, error) {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return "", err
+	}
+	var contexts []string
+	for c := range d.Statuses {
+		contexts = append(contexts, c)
+	}
+	sort.Strings(contexts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n**CI status of %s**\n\n", signature, d.Ref)
+	b.WriteString("The CI can't post commit statuses to this PR from a fork, so they're shown here instead.\n\n")
+	b.WriteString("| | Check | Description |\n|---|---|---|\n")
+	for _, c := range contexts {
+		s := d.Statuses[c]
+		check := c
+		if s.URL != "" {
+			check = fmt.Sprintf("[%s](%s)", c, s.URL)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", statusCommentIcons[s.State], check, strings.ReplaceAll(s.Description, "|", `\|`))
+	}
+	fmt.Fprintf(&b, "\n%s%s%s\n", statusCommentDataPrefix, data, statusCommentDataSuffix)
+	return b.String(), nil
+}
+
+// isPermissionError returns whether err is GitHub refusing a request due to
+// the access token's lack of permission. GitHub responds with a 404 rather
+// than a 403 to some unauthorized requests.
+func isPermissionError(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	switch errResp.Response.StatusCode {
+	case http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// updateStatusComment records the status update in the status comment of its
+// context on PR prNumber, adding the comment if it doesn't exist yet. Only
+// the comments posted by the access token's user are considered, since
+// anyone may comment on the PR.
+func (g *GithubRequestHandler) updateStatusComment(update *GithubPRUpdate, prNumber int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 180*time.Second)
+	defer cancel()
+
+	login, err := g.authenticatedLogin(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't identify the author of the status comments: %w", err)
+	}
+	comments, err := g.listPRComments(ctx, update.Owner, update.Repo, prNumber)
+	if err != nil {
+		return fmt.Errorf("couldn't list the comments of PR #%d: %w", prNumber, err)
+	}
+	signature := statusCommentSignature(update.Context)
+	var comment *github.IssueComment
+	for _, c := range comments {
+		if c.GetUser().GetLogin() == login && strings.Contains(c.GetBody(), signature) {
+			comment = c
+			break
+		}
+	}
+
+	d := parseStatusComment(comment.GetBody())
+	d.update(update)
+	body, err := d.render(signature)
+	if err != nil {
+		return err
+	}
+	if comment == nil {
+		return g.AddPRComment(&body, update.Owner, update.Repo, prNumber)
+	}
+	return retry("edit PR status comment", func() error {
+		_, _, err := g.client.Issues.EditComment(ctx, update.Owner, update.Repo, comment.GetID(), &github.IssueComment{Body: &body})
+		return err
+	})
+}
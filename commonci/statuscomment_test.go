@@ -0,0 +1,231 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func TestStatusCommentData(t *testing.T) {
+	tests := []struct {
+		desc    string
+		inBody  string
+		inRef   string
+		want    *statusCommentData
+		wantRow string
+	}{{
+		desc:  "no existing comment",
+		inRef: "sha1",
+		want: &statusCommentData{Ref: "sha1", Statuses: map[string]statusCommentEntry{
+			"pyang": {State: "failure", URL: "url", Description: "a | b"},
+		}},
+		wantRow: `| :x: | [pyang](url) | a \| b |`,
+	}, {
+		desc:   "update of existing comment",
+		inBody: "<!-- models-ci fork PR status: pyang -->\n...\n" + `<!-- models-ci status data: {"ref":"sha1","statuses":{"goyang-ygot":{"state":"success"}}} -->`,
+		inRef:  "sha1",
+		want: &statusCommentData{Ref: "sha1", Statuses: map[string]statusCommentEntry{
+			"goyang-ygot": {State: "success"},
+			"pyang":       {State: "failure", URL: "url", Description: "a | b"},
+		}},
+		wantRow: "| :white_check_mark: | goyang-ygot |  |",
+	}, {
+		desc:   "statuses of previous commit discarded",
+		inBody: `<!-- models-ci status data: {"ref":"sha1","statuses":{"goyang-ygot":{"state":"success"}}} -->`,
+		inRef:  "sha2",
+		want: &statusCommentData{Ref: "sha2", Statuses: map[string]statusCommentEntry{
+			"pyang": {State: "failure", URL: "url", Description: "a | b"},
+		}},
+		wantRow: `| :x: | [pyang](url) | a \| b |`,
+	}, {
+		desc:   "unparseable data ignored",
+		inBody: `<!-- models-ci status data: {"ref": -->`,
+		inRef:  "sha1",
+		want: &statusCommentData{Ref: "sha1", Statuses: map[string]statusCommentEntry{
+			"pyang": {State: "failure", URL: "url", Description: "a | b"},
+		}},
+		wantRow: `| :x: | [pyang](url) | a \| b |`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			d := parseStatusComment(tt.inBody)
+			d.update(&GithubPRUpdate{Ref: tt.inRef, Context: "pyang", NewStatus: "failure", URL: "url", Description: "a | b"})
+			if diff := cmp.Diff(tt.want, d); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+
+			body, err := d.render(statusCommentSignature("pyang"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(body, "<!-- models-ci fork PR status: pyang -->\n") {
+				t.Errorf("rendered comment doesn't start with its signature:\n%s", body)
+			}
+			if !strings.Contains(body, tt.wantRow) {
+				t.Errorf("rendered comment doesn't contain row %q:\n%s", tt.wantRow, body)
+			}
+			if diff := cmp.Diff(tt.want, parseStatusComment(body)); diff != "" {
+				t.Errorf("reparsed (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdatePRStatusCommentFallback(t *testing.T) {
+	tests := []struct {
+		name            string
+		inStatusCode    int
+		inCommentPR     int
+		wantErr         bool
+		wantCommentPost bool
+	}{{
+		name:            "fork PR without status permission",
+		inStatusCode:    http.StatusForbidden,
+		inCommentPR:     1,
+		wantCommentPost: true,
+	}, {
+		name:            "fork PR with unauthorized status request reported as not found",
+		inStatusCode:    http.StatusNotFound,
+		inCommentPR:     1,
+		wantCommentPost: true,
+	}, {
+		name:         "non-fork PR without status permission",
+		inStatusCode: http.StatusForbidden,
+		wantErr:      true,
+	}, {
+		name:         "fork PR with other client error",
+		inStatusCode: http.StatusUnprocessableEntity,
+		inCommentPR:  1,
+		wantErr:      true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, mux, _, teardown := setup()
+			defer teardown()
+
+			var statusRequests int
+			mux.HandleFunc("/repos/o/r/statuses/sha", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "POST")
+				statusRequests++
+				http.Error(w, "", tt.inStatusCode)
+			})
+			mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "GET")
+				fmt.Fprint(w, `{"login": "models-ci-bot"}`)
+			})
+			// The PR author's comment spoofs the signature of a status
+			// comment.
+			comments := []*github.IssueComment{{
+				ID:   github.Int64(1),
+				User: &github.User{Login: github.String("author")},
+				Body: github.String(statusCommentSignature("pyang") + "\n" + `<!-- models-ci status data: {"ref":"sha","statuses":{"pyang":{"state":"success"}}} -->`),
+			}}
+			var posts, edits int
+			mux.HandleFunc("/repos/o/r/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case "GET":
+					// Each page has a single comment.
+					page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+					if page == 0 {
+						page = 1
+					}
+					if page < len(comments) {
+						w.Header().Set("Link", fmt.Sprintf(`<%s?page=%d>; rel="next"`, r.URL.Path, page+1))
+					}
+					var bs []byte
+					var err error
+					if page <= len(comments) {
+						bs, err = json.Marshal(comments[page-1 : page])
+					} else {
+						bs, err = json.Marshal(comments[:0])
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					w.Write(bs)
+				case "POST":
+					c := new(github.IssueComment)
+					if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+						t.Fatal(err)
+					}
+					c.ID = github.Int64(int64(len(comments) + 1))
+					c.User = &github.User{Login: github.String("models-ci-bot")}
+					comments = append(comments, c)
+					posts++
+					fmt.Fprint(w, `{}`)
+				}
+			})
+			mux.HandleFunc("/repos/o/r/issues/comments/", func(w http.ResponseWriter, r *http.Request) {
+				testMethod(t, r, "PATCH")
+				id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/repos/o/r/issues/comments/"))
+				if err != nil || id < 1 || id > len(comments) {
+					t.Fatalf("edit of unknown comment %s", r.URL.Path)
+				}
+				c := new(github.IssueComment)
+				if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+					t.Fatal(err)
+				}
+				comments[id-1].Body = c.Body
+				edits++
+				fmt.Fprint(w, `{}`)
+			})
+
+			g := &GithubRequestHandler{client: client, labels: map[string]bool{}, statusCommentPR: tt.inCommentPR}
+			for _, update := range []struct{ context, state string }{{"pyang", "pending"}, {"goyang-ygot", "pending"}, {"pyang", "failure"}} {
+				err := g.UpdatePRStatus(&GithubPRUpdate{Owner: "o", Repo: "r", Ref: "sha", NewStatus: update.state, Context: update.context})
+				if gotErr := err != nil; gotErr != tt.wantErr {
+					t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+				}
+			}
+			if !tt.wantCommentPost {
+				if posts+edits != 0 {
+					t.Errorf("got %d comments posted and %d edited, want none", posts, edits)
+				}
+				return
+			}
+
+			// Commit statuses are no longer attempted once refused.
+			if statusRequests != 1 {
+				t.Errorf("got %d commit status requests, want 1", statusRequests)
+			}
+			// Each context has its own comment, which is edited in place.
+			if posts != 2 || edits != 1 {
+				t.Errorf("got %d comments posted and %d edited, want 2 posted and 1 edited", posts, edits)
+			}
+			want := map[int64]*statusCommentData{
+				1: {Ref: "sha", Statuses: map[string]statusCommentEntry{"pyang": {State: "success"}}},
+				2: {Ref: "sha", Statuses: map[string]statusCommentEntry{"pyang": {State: "failure"}}},
+				3: {Ref: "sha", Statuses: map[string]statusCommentEntry{"goyang-ygot": {State: "pending"}}},
+			}
+			got := map[int64]*statusCommentData{}
+			for _, c := range comments {
+				got[c.GetID()] = parseStatusComment(c.GetBody())
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("statuses in comments (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,322 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BucketName is the Google Cloud Storage bucket to which CI artifacts are
+// uploaded.
+const BucketName = "openconfig"
+
+// ErrObjectNotExist is returned when downloading an object that doesn't exist.
+var ErrObjectNotExist = errors.New("object does not exist")
+
+const (
+	// storageURL is the public URL of GCS objects, followed by the bucket
+	// and object names.
+	storageURL = "https://storage.googleapis.com/"
+	// MaxSignedURLDuration is the maximum duration for which GCS accepts a
+	// signed URL, and maxImpersonatedSignedURLDuration is the maximum when
+	// it's signed using the environment's service account rather than a
+	// private key.
+	MaxSignedURLDuration             = 7 * 24 * time.Hour
+	maxImpersonatedSignedURLDuration = 12 * time.Hour
+)
+
+// StorageClient uploads CI artifacts (e.g. status badges and the reports they
+// link to) to a cloud storage bucket.
+type StorageClient interface {
+	// UploadPublic uploads data to the named object within the bucket,
+	// making it publicly readable (unless the bucket is private, see
+	// GCSBucket) and disabling caching such that the latest artifact is
+	// always served.
+	UploadPublic(ctx context.Context, object string, data []byte) error
+	// UploadPrivate uploads data to the named object within the bucket,
+	// which is only readable by the project's members (e.g. CI's service
+	// account), never publicly.
+	UploadPrivate(ctx context.Context, object string, data []byte) error
+	// ObjectURL returns the URL at which the named object is viewed, e.g.
+	// to link to it from PR statuses and gists.
+	ObjectURL(ctx context.Context, object string) (string, error)
+	// Download returns the contents of the named object within the
+	// bucket, or an error wrapping ErrObjectNotExist if it doesn't exist.
+	Download(ctx context.Context, object string) ([]byte, error)
+}
+
+// NewStorageClient returns the client for accessing the given bucket.
+//
+// If dryRun is true, then uploads are only logged and kept in memory. If
+// localDir is non-empty, then uploads are written to files within localDir
+// instead, which emulates the bucket for running locally.
+func NewStorageClient(bucket, localDir string, dryRun bool) StorageClient {
+	switch {
+	case dryRun:
+		return &MemoryBucket{Bucket: bucket}
+	case localDir != "":
+		return &LocalBucket{Dir: localDir}
+	default:
+		return &GCSBucket{Bucket: bucket}
+	}
+}
+
+// GCSBucket accesses a Google Cloud Storage bucket using gsutil, which
+// authenticates using the environment's credentials.
+type GCSBucket struct {
+	Bucket string
+	// SignedURLDuration, if non-zero, makes the bucket private: uploaded
+	// objects keep the bucket's default ACL rather than being publicly
+	// readable, and are viewed through URLs signed for this duration.
+	SignedURLDuration time.Duration
+	// SigningKeyFile is the service account private key file signing the
+	// URLs. If empty, then they're signed using the environment's service
+	// account, which is limited to 12 hours.
+	SigningKeyFile string
+}
+
+// ValidateSignedURLs returns an error if GCS wouldn't accept URLs signed for
+// the bucket's SignedURLDuration.
+func (b *GCSBucket) ValidateSignedURLs() error {
+	switch {
+	case b.SignedURLDuration < 0:
+		return fmt.Errorf("negative signed URL duration %v", b.SignedURLDuration)
+	case b.SignedURLDuration > MaxSignedURLDuration:
+		return fmt.Errorf("signed URL duration %v exceeds the maximum of %v", b.SignedURLDuration, MaxSignedURLDuration)
+	case b.SigningKeyFile == "" && b.SignedURLDuration > maxImpersonatedSignedURLDuration:
+		return fmt.Errorf("signed URL duration %v exceeds the maximum of %v without a signing key file", b.SignedURLDuration, maxImpersonatedSignedURLDuration)
+	}
+	return nil
+}
+
+// UploadPublic uploads data to the object within the GCS bucket.
+func (b *GCSBucket) UploadPublic(ctx context.Context, object string, data []byte) error {
+	acl := "public-read"
+	if b.SignedURLDuration != 0 {
+		// The bucket's default ACL is private.
+		acl = ""
+	}
+	return b.upload(ctx, object, data, acl)
+}
+
+// UploadPrivate uploads data to the object within the GCS bucket, which is
+// only readable by the project's members.
+func (b *GCSBucket) UploadPrivate(ctx context.Context, object string, data []byte) error {
+	return b.upload(ctx, object, data, "project-private")
+}
+
+// upload uploads data to the object within the GCS bucket with the given
+// canned ACL, or the bucket's default ACL if it's empty. A failed upload is an
+// ExitInfraError, since it may succeed on a retry.
+func (b *GCSBucket) upload(ctx context.Context, object string, data []byte, acl string) error {
+	url := fmt.Sprintf("gs://%s/%s", b.Bucket, object)
+	args := []string{"-h", "Cache-Control:no-cache", "cp"}
+	if acl != "" {
+		args = append(args, "-a", acl)
+	}
+	cmd := exec.CommandContext(ctx, "gsutil", append(args, "-", url)...)
+	cmd.Stdin = bytes.NewReader(data)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return WithExitCode(ExitInfraError, fmt.Errorf("failed to upload %s: %v\n%s", url, err, out))
+	}
+	return nil
+}
+
+// Download returns the contents of the object within the GCS bucket.
+func (b *GCSBucket) Download(ctx context.Context, object string) ([]byte, error) {
+	url := fmt.Sprintf("gs://%s/%s", b.Bucket, object)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gsutil", "cat", url)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	switch {
+	case err != nil && strings.Contains(stderr.String(), "No URLs matched"):
+		return nil, fmt.Errorf("%s: %w", url, ErrObjectNotExist)
+	case err != nil:
+		return nil, fmt.Errorf("failed to download %s: %v\n%s", url, err, stderr.String())
+	}
+	return out, nil
+}
+
+// ObjectURL returns the public URL of the object within the GCS bucket, or a
+// URL signed for SignedURLDuration if the bucket is private.
+func (b *GCSBucket) ObjectURL(ctx context.Context, object string) (string, error) {
+	if b.SignedURLDuration == 0 {
+		return storageURL + b.Bucket + "/" + object, nil
+	}
+	url := fmt.Sprintf("gs://%s/%s", b.Bucket, object)
+	args := []string{"signurl", "-d", fmt.Sprintf("%ds", int64(b.SignedURLDuration.Seconds()))}
+	if b.SigningKeyFile != "" {
+		args = append(args, b.SigningKeyFile)
+	} else {
+		args = append(args, "-u")
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gsutil", append(args, url)...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to sign URL of %s: %v\n%s", url, err, stderr.String())
+	}
+	return parseSignedURL(string(out))
+}
+
+// parseSignedURL returns the signed URL within the output of gsutil signurl,
+// which is a table whose last column is the signed URL.
+func parseSignedURL(out string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	fields := strings.Split(lines[len(lines)-1], "\t")
+	if signedURL := strings.TrimSpace(fields[len(fields)-1]); strings.HasPrefix(signedURL, "https://") {
+		return signedURL, nil
+	}
+	return "", fmt.Errorf("no signed URL in gsutil signurl output:\n%s", out)
+}
+
+// MemoryBucket is an in-memory bucket used for dry runs and tests. It is
+// safe for concurrent use.
+type MemoryBucket struct {
+	// Bucket is the name of the emulated bucket, only used for logging.
+	Bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	// private are the objects uploaded by UploadPrivate.
+	private map[string]bool
+}
+
+// UploadPublic logs the upload and stores data as the object.
+func (b *MemoryBucket) UploadPublic(ctx context.Context, object string, data []byte) error {
+	b.upload(object, data, false)
+	return nil
+}
+
+// UploadPrivate logs the upload and stores data as the private object.
+func (b *MemoryBucket) UploadPrivate(ctx context.Context, object string, data []byte) error {
+	b.upload(object, data, true)
+	return nil
+}
+
+// upload stores data as the object.
+func (b *MemoryBucket) upload(object string, data []byte, private bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.objects == nil {
+		b.objects = map[string][]byte{}
+		b.private = map[string]bool{}
+	}
+	b.objects[object] = append([]byte(nil), data...)
+	b.private[object] = private
+	log.Printf("dry run: uploaded %d bytes to gs://%s/%s", len(data), b.Bucket, object)
+}
+
+// Private returns whether the object was uploaded by UploadPrivate.
+func (b *MemoryBucket) Private(object string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.private[object]
+}
+
+// ObjectURL returns the public URL that the object would have within the
+// emulated GCS bucket.
+func (b *MemoryBucket) ObjectURL(ctx context.Context, object string) (string, error) {
+	return storageURL + b.Bucket + "/" + object, nil
+}
+
+// Download returns the contents of the object.
+func (b *MemoryBucket) Download(ctx context.Context, object string) ([]byte, error) {
+	data, ok := b.Object(object)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", object, ErrObjectNotExist)
+	}
+	return data, nil
+}
+
+// Object returns the contents of the object and whether it exists.
+func (b *MemoryBucket) Object(object string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.objects[object]
+	return data, ok
+}
+
+// Objects returns the sorted names of all uploaded objects.
+func (b *MemoryBucket) Objects() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var names []string
+	for name := range b.objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LocalBucket emulates a bucket using a local directory, where each object is
+// written to the file at its name relative to Dir.
+type LocalBucket struct {
+	Dir string
+}
+
+// UploadPublic writes data to the object's file within the directory.
+func (b *LocalBucket) UploadPublic(ctx context.Context, object string, data []byte) error {
+	path := filepath.Join(b.Dir, filepath.FromSlash(object))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error while creating directory for object %q: %v", object, err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error while writing object %q: %v", object, err)
+	}
+	return nil
+}
+
+// UploadPrivate writes data to the object's file within the directory, which
+// is as private as the directory.
+func (b *LocalBucket) UploadPrivate(ctx context.Context, object string, data []byte) error {
+	return b.UploadPublic(ctx, object, data)
+}
+
+// ObjectURL returns the file URL of the object's file within the directory.
+func (b *LocalBucket) ObjectURL(ctx context.Context, object string) (string, error) {
+	path, err := filepath.Abs(filepath.Join(b.Dir, filepath.FromSlash(object)))
+	if err != nil {
+		return "", err
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String(), nil
+}
+
+// Download returns the contents of the object's file within the directory.
+func (b *LocalBucket) Download(ctx context.Context, object string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(b.Dir, filepath.FromSlash(object)))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("%s: %w", object, ErrObjectNotExist)
+	case err != nil:
+		return nil, fmt.Errorf("error while reading object %q: %v", object, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewStorageClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		inLocalDir string
+		inDryRun   bool
+		want       StorageClient
+	}{{
+		name: "gcs",
+		want: &GCSBucket{Bucket: "openconfig"},
+	}, {
+		name:     "dry run",
+		inDryRun: true,
+		want:     &MemoryBucket{Bucket: "openconfig"},
+	}, {
+		name:       "dry run takes precedence over local bucket",
+		inLocalDir: "/tmp/bucket",
+		inDryRun:   true,
+		want:       &MemoryBucket{Bucket: "openconfig"},
+	}, {
+		name:       "local bucket",
+		inLocalDir: "/tmp/bucket",
+		want:       &LocalBucket{Dir: "/tmp/bucket"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewStorageClient("openconfig", tt.inLocalDir, tt.inDryRun)
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b *MemoryBucket) bool { return a.Bucket == b.Bucket })); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMemoryBucket(t *testing.T) {
+	b := &MemoryBucket{Bucket: "openconfig"}
+	ctx := context.Background()
+	if err := b.UploadPublic(ctx, "badges/pyang.svg", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UploadPublic(ctx, "badges/pyang.svg", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.UploadPublic(ctx, "badges/goyang-ygot.svg", []byte("svg")); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"badges/goyang-ygot.svg", "badges/pyang.svg"}, b.Objects()); diff != "" {
+		t.Errorf("Objects (-want, +got):\n%s", diff)
+	}
+	if got, ok := b.Object("badges/pyang.svg"); !ok || string(got) != "new" {
+		t.Errorf("Object: got (%q, %v), want (%q, true)", got, ok, "new")
+	}
+	if _, ok := b.Object("badges/dne.svg"); ok {
+		t.Errorf("Object: got non-existent object")
+	}
+}
+
+func TestLocalBucket(t *testing.T) {
+	dir := t.TempDir()
+	b := &LocalBucket{Dir: dir}
+	if err := b.UploadPublic(context.Background(), "compatibility-badges/openconfig-public:pyang.svg", []byte("svg")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "compatibility-badges", "openconfig-public:pyang.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "svg" {
+		t.Errorf("got %q, want %q", got, "svg")
+	}
+
+	url, err := b.ObjectURL(context.Background(), "compatibility-badges/openconfig-public:pyang.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "file://" + filepath.ToSlash(dir) + "/compatibility-badges/openconfig-public:pyang.svg"; url != want {
+		t.Errorf("ObjectURL: got %q, want %q", url, want)
+	}
+}
+
+func TestGCSBucketUploadError(t *testing.T) {
+	// Without gsutil on the PATH, the upload fails.
+	t.Setenv("PATH", t.TempDir())
+	b := &GCSBucket{Bucket: "openconfig"}
+	for name, upload := range map[string]func(context.Context, string, []byte) error{
+		"UploadPublic":  b.UploadPublic,
+		"UploadPrivate": b.UploadPrivate,
+	} {
+		err := upload(context.Background(), "badges/pyang.svg", []byte("svg"))
+		if got := ExitCode(err); got != ExitInfraError {
+			t.Errorf("%s: got exit code %d (error %v), want %d", name, got, err, ExitInfraError)
+		}
+	}
+}
+
+func TestObjectURL(t *testing.T) {
+	ctx := context.Background()
+	want := "https://storage.googleapis.com/openconfig/pyang-matrix/openconfig-public/abc.html"
+	for _, b := range []StorageClient{&GCSBucket{Bucket: "openconfig"}, &MemoryBucket{Bucket: "openconfig"}} {
+		got, err := b.ObjectURL(ctx, "pyang-matrix/openconfig-public/abc.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%T: got %q, want %q", b, got, want)
+		}
+	}
+}
+
+func TestValidateSignedURLs(t *testing.T) {
+	tests := []struct {
+		name      string
+		inBucket  *GCSBucket
+		wantError bool
+	}{{
+		name:     "public",
+		inBucket: &GCSBucket{Bucket: "openconfig"},
+	}, {
+		name:     "signed by service account",
+		inBucket: &GCSBucket{Bucket: "openconfig", SignedURLDuration: 12 * time.Hour},
+	}, {
+		name:      "too long for service account",
+		inBucket:  &GCSBucket{Bucket: "openconfig", SignedURLDuration: 13 * time.Hour},
+		wantError: true,
+	}, {
+		name:     "signed by key file",
+		inBucket: &GCSBucket{Bucket: "openconfig", SignedURLDuration: MaxSignedURLDuration, SigningKeyFile: "key.json"},
+	}, {
+		name:      "too long for key file",
+		inBucket:  &GCSBucket{Bucket: "openconfig", SignedURLDuration: MaxSignedURLDuration + time.Hour, SigningKeyFile: "key.json"},
+		wantError: true,
+	}, {
+		name:      "negative",
+		inBucket:  &GCSBucket{Bucket: "openconfig", SignedURLDuration: -time.Hour},
+		wantError: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.inBucket.ValidateSignedURLs(); (err != nil) != tt.wantError {
+				t.Errorf("got error %v, want error: %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestParseSignedURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        string
+		want      string
+		wantError bool
+	}{{
+		name: "signurl output",
+		in: "URL\tHTTP Method\tExpiration\tSigned URL\n" +
+			"gs://openconfig/pyang-matrix/openconfig-public/abc.html\tGET\t2024-01-01 12:00:00\thttps://storage.googleapis.com/openconfig/pyang-matrix/openconfig-public/abc.html?x-goog-signature=sig&x-goog-expires=3600\n",
+		want: "https://storage.googleapis.com/openconfig/pyang-matrix/openconfig-public/abc.html?x-goog-signature=sig&x-goog-expires=3600",
+	}, {
+		name:      "no signed URL",
+		in:        "URL\tHTTP Method\tExpiration\tSigned URL\n",
+		wantError: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSignedURL(tt.in)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("got error %v, want error: %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
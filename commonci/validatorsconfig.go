@@ -0,0 +1,216 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ValidatorsConfigFileName by convention is the file at the root of
+	// the models repo defining validators in addition to the built-in
+	// ones, such that new tools can be onboarded without rebuilding
+	// models-ci.
+	ValidatorsConfigFileName = ".ci-validators.yml"
+	// ValidatorsConfigFile is created by cmd_gen to store the models
+	// repo's validators config, if present, for later CI steps.
+	ValidatorsConfigFile = UserConfigDir + "/ci-validators.yml"
+)
+
+const (
+	// ResultsFormatRaw is the results format of a validator whose output is
+	// reported verbatim.
+	ResultsFormatRaw = "raw"
+	// ResultsFormatStandard is the results format of a validator whose
+	// output consists of "<file>:<line>: <status>: <message>" lines, like
+	// ConfD's.
+	ResultsFormatStandard = "standard"
+	// ResultsFormatPyangTextproto is the results format of a validator
+	// whose output consists of pyang messages formatted by
+	// util.PYANG_MSG_TEMPLATE_STRING, like pyang's.
+	ResultsFormatPyangTextproto = "pyang-textproto"
+)
+
+var (
+	// validatorIdRegex matches validator IDs that are safe to use as
+	// directory names, status contexts and within comma-separated
+	// validator lists.
+	validatorIdRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	// scriptPathRegex matches script paths that are safe to use within the
+	// generated validator scripts without quoting.
+	scriptPathRegex = regexp.MustCompile(`^[A-Za-z0-9_.][A-Za-z0-9_./-]*$`)
+)
+
+// ValidatorConfig is the definition of a single validator within a
+// ValidatorsConfigFileName file.
+type ValidatorConfig struct {
+	// Id is the unique identifier of the validator (see Validators).
+	Id string `yaml:"id"`
+	// The following fields correspond to those of Validator.
+	Name             string `yaml:"name"`
+	IsPerModel       bool   `yaml:"per-model"`
+	IgnoreRunCi      bool   `yaml:"ignore-run-ci"`
+	IsWidelyUsedTool bool   `yaml:"widely-used"`
+	SupportedVersion string `yaml:"supported-version"`
+	// HeaderTemplate is the Go template of the beginning of the validator
+	// script. For validators that aren't per-model, it is the entire
+	// script.
+	HeaderTemplate string `yaml:"header-template"`
+	// PerModelTemplate is the Go template of the validator script's
+	// command for each model. It is required for per-model validators.
+	PerModelTemplate string `yaml:"per-model-template"`
+	// Script is the path, relative to the root of the models repo, of an
+	// executable script run by the validator instead of the scripts
+	// generated from HeaderTemplate and PerModelTemplate. A per-model
+	// validator runs it for each model with the model directory, the
+	// model's name and its build files as arguments, and the model passes
+	// if it exits successfully. Otherwise, it's run once, and the
+	// validator passes if it exits successfully without stderr output.
+	// For a PR, it's read from the PR's base branch along with the config.
+	Script string `yaml:"script"`
+	// ResultsFormat is the format of the output of each model of a
+	// per-model validator, which determines how it's reported: one of
+	// ResultsFormatRaw (the default), ResultsFormatStandard or
+	// ResultsFormatPyangTextproto.
+	ResultsFormat string `yaml:"results"`
+	// Rollout is either empty or RolloutReportOnly, in which case the
+	// validator's PR status never fails.
+	Rollout string `yaml:"rollout"`
+	// RolloutUntil is the last day (YYYY-MM-DD) of the validator's
+	// rollout, after which its PR status fails as usual. If empty, the
+	// rollout doesn't end.
+	RolloutUntil string `yaml:"rollout-until"`
+}
+
+// ValidatorsConfig represents a ValidatorsConfigFileName file.
+type ValidatorsConfig struct {
+	Validators []*ValidatorConfig `yaml:"validators"`
+}
+
+// ParseValidatorsConfig parses and validates the contents of a
+// ValidatorsConfigFileName file. Validators may not redefine the built-in
+// validators.
+func ParseValidatorsConfig(bs []byte) (*ValidatorsConfig, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	cfg := &ValidatorsConfig{}
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	ids := map[string]bool{}
+	for _, v := range cfg.Validators {
+		switch {
+		case !validatorIdRegex.MatchString(v.Id):
+			return nil, fmt.Errorf("invalid validator id %q, must contain only lowercase letters, digits and dashes", v.Id)
+		case Validators[v.Id] != nil:
+			return nil, fmt.Errorf("validator %q is already defined", v.Id)
+		case ids[v.Id]:
+			return nil, fmt.Errorf("validator %q is defined more than once", v.Id)
+		case v.Name == "":
+			return nil, fmt.Errorf("validator %q has no name", v.Id)
+		case v.Script != "" && (v.HeaderTemplate != "" || v.PerModelTemplate != ""):
+			return nil, fmt.Errorf("validator %q has both a script and templates", v.Id)
+		case v.Script != "" && (!scriptPathRegex.MatchString(v.Script) || path.Clean(v.Script) != v.Script || strings.HasPrefix(v.Script, "../")):
+			return nil, fmt.Errorf("validator %q has invalid script %q, must be a path within the models repo", v.Id, v.Script)
+		case v.Script == "" && v.HeaderTemplate == "":
+			return nil, fmt.Errorf("validator %q has no header-template or script", v.Id)
+		case v.Script == "" && v.IsPerModel && v.PerModelTemplate == "":
+			return nil, fmt.Errorf("per-model validator %q has no per-model-template", v.Id)
+		case !v.IsPerModel && v.PerModelTemplate != "":
+			return nil, fmt.Errorf("validator %q has a per-model-template but is not per-model", v.Id)
+		case v.ResultsFormat != "" && v.ResultsFormat != ResultsFormatRaw && v.ResultsFormat != ResultsFormatStandard && v.ResultsFormat != ResultsFormatPyangTextproto:
+			return nil, fmt.Errorf("validator %q has invalid results format %q, must be %q, %q or %q", v.Id, v.ResultsFormat, ResultsFormatRaw, ResultsFormatStandard, ResultsFormatPyangTextproto)
+		case !v.IsPerModel && v.ResultsFormat != "" && v.ResultsFormat != ResultsFormatRaw:
+			return nil, fmt.Errorf("validator %q has results format %q but is not per-model", v.Id, v.ResultsFormat)
+		case v.Rollout != "" && v.Rollout != RolloutReportOnly:
+			return nil, fmt.Errorf("validator %q has invalid rollout %q, must be %q", v.Id, v.Rollout, RolloutReportOnly)
+		case v.Rollout == "" && v.RolloutUntil != "":
+			return nil, fmt.Errorf("validator %q has a rollout-until but no rollout", v.Id)
+		}
+		if v.RolloutUntil != "" {
+			if _, err := parseRolloutUntil(v.RolloutUntil); err != nil {
+				return nil, fmt.Errorf("validator %q: %v", v.Id, err)
+			}
+		}
+		ids[v.Id] = true
+	}
+	return cfg, nil
+}
+
+// ReadValidatorsConfig reads the validators config file at path. If the file
+// doesn't exist, then nil is returned.
+func ReadValidatorsConfig(path string) (*ValidatorsConfig, error) {
+	bs, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read validators config file %q: %v", path, err)
+	}
+	cfg, err := ParseValidatorsConfig(bs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validators config file %q: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// WriteValidatorsConfig writes the config to the file at path (normally
+// ValidatorsConfigFile).
+func WriteValidatorsConfig(path string, c *ValidatorsConfig) error {
+	bs, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal validators config: %v", err)
+	}
+	if err := WriteFile(path, bs, 0444); err != nil {
+		return fmt.Errorf("error while writing validators config file %q: %v", path, err)
+	}
+	return nil
+}
+
+// Register adds the config's validators to Validators.
+func (c *ValidatorsConfig) Register() {
+	for _, v := range c.Validators {
+		Validators[v.Id] = &Validator{
+			Name:             v.Name,
+			IsPerModel:       v.IsPerModel,
+			IgnoreRunCi:      v.IgnoreRunCi,
+			IsWidelyUsedTool: v.IsWidelyUsedTool,
+			SupportedVersion: v.SupportedVersion,
+			ResultsFormat:    v.ResultsFormat,
+		}
+	}
+}
+
+// ReportOnlyRollouts returns the config's validators in report-only rollout.
+func (c *ValidatorsConfig) ReportOnlyRollouts() ReportOnlyRollouts {
+	rollouts := ReportOnlyRollouts{}
+	for _, v := range c.Validators {
+		if v.Rollout == RolloutReportOnly {
+			rollouts[v.Id] = v.RolloutUntil
+		}
+	}
+	return rollouts
+}
@@ -0,0 +1,324 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseValidatorsConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    *ValidatorsConfig
+		wantErr bool
+	}{{
+		name: "empty",
+		in:   "",
+		want: &ValidatorsConfig{},
+	}, {
+		name: "valid",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    per-model: true
+    widely-used: true
+    supported-version: 1.2.3
+    header-template: "#!/bin/bash\n"
+    per-model-template: "my-linter {{ .ModelName }}\n"
+  - id: my-repo-check
+    name: My Repo Check
+    ignore-run-ci: true
+    header-template: "my-repo-check\n"
+`,
+		want: &ValidatorsConfig{
+			Validators: []*ValidatorConfig{{
+				Id:               "my-linter",
+				Name:             "My Linter",
+				IsPerModel:       true,
+				IsWidelyUsedTool: true,
+				SupportedVersion: "1.2.3",
+				HeaderTemplate:   "#!/bin/bash\n",
+				PerModelTemplate: "my-linter {{ .ModelName }}\n",
+			}, {
+				Id:             "my-repo-check",
+				Name:           "My Repo Check",
+				IgnoreRunCi:    true,
+				HeaderTemplate: "my-repo-check\n",
+			}},
+		},
+	}, {
+		name: "unknown field",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "my-linter\n"
+    colour: blue
+`,
+		wantErr: true,
+	}, {
+		name: "invalid id",
+		in: `
+validators:
+  - id: My_Linter
+    name: My Linter
+    header-template: "my-linter\n"
+`,
+		wantErr: true,
+	}, {
+		name: "redefines built-in validator",
+		in: `
+validators:
+  - id: pyang
+    name: My pyang
+    header-template: "pyang\n"
+`,
+		wantErr: true,
+	}, {
+		name: "duplicate id",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "my-linter\n"
+  - id: my-linter
+    name: My Other Linter
+    header-template: "my-linter\n"
+`,
+		wantErr: true,
+	}, {
+		name: "missing name",
+		in: `
+validators:
+  - id: my-linter
+    header-template: "my-linter\n"
+`,
+		wantErr: true,
+	}, {
+		name: "missing header-template",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+`,
+		wantErr: true,
+	}, {
+		name: "per-model without per-model-template",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    per-model: true
+    header-template: "#!/bin/bash\n"
+`,
+		wantErr: true,
+	}, {
+		name: "per-model-template without per-model",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "#!/bin/bash\n"
+    per-model-template: "my-linter {{ .ModelName }}\n"
+`,
+		wantErr: true,
+	}, {
+		name: "script hooks",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    per-model: true
+    script: ci/my-linter.sh
+    results: pyang-textproto
+  - id: my-repo-check
+    name: My Repo Check
+    script: ci/my-repo-check.sh
+`,
+		want: &ValidatorsConfig{
+			Validators: []*ValidatorConfig{{
+				Id:            "my-linter",
+				Name:          "My Linter",
+				IsPerModel:    true,
+				Script:        "ci/my-linter.sh",
+				ResultsFormat: "pyang-textproto",
+			}, {
+				Id:     "my-repo-check",
+				Name:   "My Repo Check",
+				Script: "ci/my-repo-check.sh",
+			}},
+		},
+	}, {
+		name: "script and templates",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    script: ci/my-linter.sh
+    header-template: "my-linter\n"
+`,
+		wantErr: true,
+	}, {
+		name: "script outside the models repo",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    script: ../my-linter.sh
+`,
+		wantErr: true,
+	}, {
+		name: "script with unsafe characters",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    script: "ci/my linter.sh; rm -rf /"
+`,
+		wantErr: true,
+	}, {
+		name: "invalid results format",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    per-model: true
+    script: ci/my-linter.sh
+    results: json
+`,
+		wantErr: true,
+	}, {
+		name: "results format of repo-level validator",
+		in: `
+validators:
+  - id: my-repo-check
+    name: My Repo Check
+    script: ci/my-repo-check.sh
+    results: standard
+`,
+		wantErr: true,
+	}, {
+		name: "report-only rollout",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "my-linter\n"
+    rollout: report-only
+    rollout-until: 2024-12-31
+`,
+		want: &ValidatorsConfig{
+			Validators: []*ValidatorConfig{{
+				Id:             "my-linter",
+				Name:           "My Linter",
+				HeaderTemplate: "my-linter\n",
+				Rollout:        "report-only",
+				RolloutUntil:   "2024-12-31",
+			}},
+		},
+	}, {
+		name: "invalid rollout",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "my-linter\n"
+    rollout: gradual
+`,
+		wantErr: true,
+	}, {
+		name: "rollout-until without rollout",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "my-linter\n"
+    rollout-until: 2024-12-31
+`,
+		wantErr: true,
+	}, {
+		name: "invalid rollout-until",
+		in: `
+validators:
+  - id: my-linter
+    name: My Linter
+    header-template: "my-linter\n"
+    rollout: report-only
+    rollout-until: 31/12/2024
+`,
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseValidatorsConfig([]byte(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReadWriteValidatorsConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ci-validators.yml")
+	got, err := ReadValidatorsConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got config %v for missing file, want nil", got)
+	}
+
+	want := &ValidatorsConfig{
+		Validators: []*ValidatorConfig{{
+			Id:               "my-linter",
+			Name:             "My Linter",
+			IsPerModel:       true,
+			HeaderTemplate:   "#!/bin/bash\n",
+			PerModelTemplate: "my-linter {{ .ModelName }}\n",
+		}},
+	}
+	if err := WriteValidatorsConfig(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = ReadValidatorsConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestValidatorsConfigReportOnlyRollouts(t *testing.T) {
+	cfg := &ValidatorsConfig{
+		Validators: []*ValidatorConfig{
+			{Id: "my-formatter"},
+			{Id: "my-linter", Rollout: RolloutReportOnly},
+			{Id: "my-repo-check", Rollout: RolloutReportOnly, RolloutUntil: "2024-12-31"},
+		},
+	}
+	want := ReportOnlyRollouts{"my-linter": "", "my-repo-check": "2024-12-31"}
+	if diff := cmp.Diff(want, cfg.ReportOnlyRollouts()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ValidatorVersionsFileName by convention is the file at the root of
+	// the models repo pinning the versions of each validator to run in
+	// addition to the latest version.
+	ValidatorVersionsFileName = ".ci-validator-versions.yml"
+	// HeadVersion is the version name of a validator built from the head
+	// of its source repository.
+	HeadVersion = "head"
+)
+
+// ValidatorVersions represents a ValidatorVersionsFileName file.
+type ValidatorVersions struct {
+	// Versions maps each validator ID to the versions to run in addition
+	// to the latest version (e.g. pyang: [2.5.3, head]).
+	Versions map[string][]string `yaml:"versions"`
+}
+
+// CheckValidatorVersion returns an error if the given version of the
+// validator can't be run in CI, i.e. if the validator doesn't run extra
+// versions, or if the version is neither HeadVersion nor a semantic version
+// at least the validator's SupportedVersion. A version whose patch version is
+// "x" (e.g. 2.1.x) is only accepted for validators with HasPatchWildcard.
+func CheckValidatorVersion(validatorId, version string) error {
+	validator, ok := Validators[validatorId]
+	switch {
+	case !ok:
+		return fmt.Errorf("unrecognized validator %q", validatorId)
+	case !validator.HasExtraVersions:
+		return fmt.Errorf("validator %q doesn't support running extra versions", validatorId)
+	case version == HeadVersion:
+		if !validator.HasHead {
+			return fmt.Errorf("validator %q doesn't support running %s", validatorId, HeadVersion)
+		}
+		return nil
+	}
+
+	if minor, ok := strings.CutSuffix(version, ".x"); ok {
+		if !validator.HasPatchWildcard {
+			return fmt.Errorf("validator %q doesn't support running the latest release of a minor version (%s)", validatorId, version)
+		}
+		if strings.Count(minor, ".") != 1 {
+			return fmt.Errorf("invalid %s version %q, expected <major>.<minor>.x", validatorId, version)
+		}
+		version = minor + ".0"
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s version string %q: %v", validatorId, version, err)
+	}
+	if validator.SupportedVersion == "" {
+		return nil
+	}
+	versionConstraints, err := semver.NewConstraint(fmt.Sprintf(">= %s", validator.SupportedVersion))
+	if err != nil {
+		return fmt.Errorf("internal error: failed to parse SupportedVersion of %s: %q", validatorId, validator.SupportedVersion)
+	}
+	if !versionConstraints.Check(v) {
+		return fmt.Errorf("invalid %s version: %s < %s", validatorId, version, validator.SupportedVersion)
+	}
+	return nil
+}
+
+// ParseExtraVersions parses a comma-separated list of
+// <validatorId>@<version> entries (e.g. "yanglint@2.1.148,confd@8.0") to a
+// map of validatorId to the versions to run in addition to the latest
+// version, in the order given. Each version is checked by
+// CheckValidatorVersion.
+func ParseExtraVersions(extraVersionsStr string) (map[string][]string, error) {
+	versions := map[string][]string{}
+	seen := map[string]bool{}
+	for _, entry := range strings.Fields(strings.ReplaceAll(extraVersionsStr, ",", " ")) {
+		segments := strings.SplitN(entry, "@", 2)
+		if len(segments) != 2 || segments[1] == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected <validatorId>@<version>", entry)
+		}
+		if err := CheckValidatorVersion(segments[0], segments[1]); err != nil {
+			return nil, err
+		}
+		if seen[entry] {
+			return nil, fmt.Errorf("extra version %s is given more than once", entry)
+		}
+		seen[entry] = true
+		versions[segments[0]] = append(versions[segments[0]], segments[1])
+	}
+	return versions, nil
+}
+
+// ParseValidatorVersions parses and validates the contents of a
+// ValidatorVersionsFileName file, and returns the pinned versions of each
+// validator.
+func ParseValidatorVersions(bs []byte) (map[string][]string, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	vs := &ValidatorVersions{}
+	if err := dec.Decode(vs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	for validatorId, versions := range vs.Versions {
+		seen := map[string]bool{}
+		for _, version := range versions {
+			if err := CheckValidatorVersion(validatorId, version); err != nil {
+				return nil, err
+			}
+			if seen[version] {
+				return nil, fmt.Errorf("version %s of validator %q is pinned more than once", version, validatorId)
+			}
+			seen[version] = true
+		}
+	}
+	return vs.Versions, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commonci
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseValidatorVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string][]string
+		wantErr bool
+	}{{
+		name: "empty",
+		in:   "",
+	}, {
+		name: "valid",
+		in: `
+versions:
+  pyang: [2.5.3, head]
+  yanglint: [2.1.111]
+`,
+		want: map[string][]string{
+			"pyang":    {"2.5.3", "head"},
+			"yanglint": {"2.1.111"},
+		},
+	}, {
+		name:    "unrecognized validator",
+		in:      "versions: {foo: [1.0.0]}\n",
+		wantErr: true,
+	}, {
+		name:    "validator without extra versions",
+		in:      "versions: {goyang-ygot: [1.0.0]}\n",
+		wantErr: true,
+	}, {
+		name:    "head unsupported",
+		in:      "versions: {yanglint: [head]}\n",
+		wantErr: true,
+	}, {
+		name:    "below supported version",
+		in:      "versions: {pyang: [1.7.8]}\n",
+		wantErr: true,
+	}, {
+		name:    "invalid version",
+		in:      "versions: {yanglint: [2.1.y]}\n",
+		wantErr: true,
+	}, {
+		name:    "duplicate version",
+		in:      "versions: {pyang: [2.5.3, 2.5.3]}\n",
+		wantErr: true,
+	}, {
+		name:    "unknown field",
+		in:      "pyang: [2.5.3]\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseValidatorVersions([]byte(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseExtraVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    map[string][]string
+		wantErr bool
+	}{{
+		name: "empty",
+		in:   "",
+		want: map[string][]string{},
+	}, {
+		name: "valid",
+		in:   "yanglint@2.1.148,confd@8.0, pyang@2.5.3,pyang@head",
+		want: map[string][]string{
+			"yanglint": {"2.1.148"},
+			"confd":    {"8.0"},
+			"pyang":    {"2.5.3", "head"},
+		},
+	}, {
+		name:    "missing version",
+		in:      "yanglint",
+		wantErr: true,
+	}, {
+		name:    "empty version",
+		in:      "yanglint@",
+		wantErr: true,
+	}, {
+		name:    "validator without extra versions",
+		in:      "goyang-ygot@1.0.0",
+		wantErr: true,
+	}, {
+		name:    "below supported version",
+		in:      "confd@7.1",
+		wantErr: true,
+	}, {
+		name: "patch wildcard",
+		in:   "yanglint@2.1.x",
+		want: map[string][]string{
+			"yanglint": {"2.1.x"},
+		},
+	}, {
+		name:    "patch wildcard of validator without wildcards",
+		in:      "pyang@2.5.x",
+		wantErr: true,
+	}, {
+		name:    "patch wildcard without minor version",
+		in:      "yanglint@2.x",
+		wantErr: true,
+	}, {
+		name:    "patch wildcard below supported version",
+		in:      "yanglint@1.9.x",
+		wantErr: true,
+	}, {
+		name:    "duplicate version",
+		in:      "yanglint@2.1.148,yanglint@2.1.148",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExtraVersions(tt.in)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,3 +18,6 @@ set -euo pipefail
 
 RESULTS_PROTO_DIR="proto/results"
 protoc -I=$RESULTS_PROTO_DIR --go_opt=paths=source_relative --go_out=$RESULTS_PROTO_DIR $RESULTS_PROTO_DIR/results.proto
+
+REPORTER_PROTO_DIR="proto/reporter"
+protoc -I=$REPORTER_PROTO_DIR --go_opt=paths=source_relative --go_out=$REPORTER_PROTO_DIR --go-grpc_opt=paths=source_relative --go-grpc_out=$REPORTER_PROTO_DIR $REPORTER_PROTO_DIR/reporter.proto
@@ -0,0 +1,22 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package e2e contains the end-to-end smoke test of the CI pipeline, which
+// runs cmd_gen, the generated validator scripts and post_results against the
+// test models in turn, checking the files through which the stages
+// communicate. It builds the binaries and runs bash, so it's only run with
+// the e2e build tag:
+//
+//	go test -tags e2e ./e2e
+package e2e
@@ -0,0 +1,232 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"flag"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openconfig/ygot/testutil"
+)
+
+var updateGolden = flag.Bool("update_golden", false, "Update golden files")
+
+const (
+	// repoSlug, prNumber and commitSHA identify the PR whose results are
+	// posted.
+	repoSlug  = "openconfig/public"
+	prNumber  = "1"
+	commitSHA = "0123456789abcdef"
+	// changedFile is the file changed by the PR, relative to the root of
+	// the models repo.
+	changedFile = "release/models/acl/openconfig-acl.yang"
+	// masterFileParseLog is the ocversion output for the PR's base branch,
+	// on which openconfig-acl had a lower version.
+	masterFileParseLog = `openconfig-acl.yang: belonging-module:"openconfig-acl" openconfig-version:"1.0.0"
+openconfig-bgp.yang: belonging-module:"openconfig-bgp" openconfig-version:"2.0.0"
+`
+)
+
+// buildBinaries builds cmd_gen and post_results into dir with a fixed
+// version, such that the footers of the reports are stable.
+func buildBinaries(t *testing.T, dir string) {
+	t.Helper()
+	ldflags := "-X github.com/openconfig/models-ci/version.Version=e2e -X github.com/openconfig/models-ci/version.Commit=e2e"
+	cmd := exec.Command("go", "build", "-o", dir+"/", "-ldflags", ldflags, "github.com/openconfig/models-ci/cmd_gen", "github.com/openconfig/models-ci/post_results")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build the binaries: %v\n%s", err, out)
+	}
+}
+
+// copyDir copies the files within src into dst.
+func copyDir(t *testing.T, src, dst string) {
+	t.Helper()
+	if err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), bs, 0644)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// run runs the command in the environment and returns its stdout, failing
+// the test if it fails.
+func run(t *testing.T, env []string, name string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("%s %s failed: %v\n%s", name, strings.Join(args, " "), err, stderr.String())
+	}
+	return string(out)
+}
+
+// runScript runs the validator's script.sh within its results directory as
+// its test.sh does, writing its stdout and stderr into the out and fail files,
+// the latter of which is deleted if it's empty and the script passed.
+func runScript(t *testing.T, env []string, resultsDir string, args ...string) {
+	t.Helper()
+	out, err := os.Create(filepath.Join(resultsDir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	fail, err := os.Create(filepath.Join(resultsDir, "fail"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fail.Close()
+
+	cmd := exec.Command("bash", append([]string{filepath.Join(resultsDir, "script.sh")}, args...)...)
+	cmd.Env = env
+	cmd.Stdout, cmd.Stderr = out, fail
+	if err := cmd.Run(); err != nil {
+		return
+	}
+	if info, err := fail.Stat(); err == nil && info.Size() == 0 {
+		os.Remove(fail.Name())
+	}
+}
+
+// writeFile writes the contents into the file at path.
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// checkGolden checks the report against the golden file.
+func checkGolden(t *testing.T, goldenFile, got string) {
+	t.Helper()
+	want, err := os.ReadFile(goldenFile)
+	if err != nil && !*updateGolden {
+		t.Fatal(err)
+	}
+	if got == string(want) {
+		return
+	}
+	if *updateGolden {
+		writeFile(t, goldenFile, got)
+		return
+	}
+	diff, _ := testutil.GenerateUnifiedDiff(string(want), got)
+	t.Errorf("did not post the expected results (file: %v), diff:\n%s", goldenFile, diff)
+}
+
+// TestPipeline runs cmd_gen on the test models, then the generated pyang and
+// misc-checks scripts with stub tools, then post_results in a dry run, and
+// checks what would be posted for each validator.
+func TestPipeline(t *testing.T) {
+	tmp := t.TempDir()
+	binDir := filepath.Join(tmp, "bin")
+	buildBinaries(t, binDir)
+	stubsDir, err := filepath.Abs("testdata/bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repoRoot := filepath.Join(tmp, "public")
+	modelRoot := filepath.Join(repoRoot, "release", "models")
+	copyDir(t, "testdata/models", modelRoot)
+	if err := os.MkdirAll(filepath.Join(repoRoot, "third_party", "ietf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	resultsRoot := filepath.Join(tmp, "results")
+
+	var env []string
+	for _, kv := range os.Environ() {
+		// The reports' footers name the image if it's set.
+		if !strings.HasPrefix(kv, "MODELS_CI_IMAGE_DIGEST=") && !strings.HasPrefix(kv, "PATH=") {
+			env = append(env, kv)
+		}
+	}
+	env = append(env, "GITHUB_WORKSPACE="+repoRoot, "PATH="+stubsDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	validatorIds := []string{"pyang", "misc-checks"}
+	for _, validatorId := range validatorIds {
+		run(t, env, filepath.Join(binDir, "cmd_gen"), "-modelRoot", modelRoot, "-output=github-actions", "-validator", validatorId, "-resultsDir", filepath.Join(resultsRoot, validatorId))
+	}
+
+	// Run pyang as by validators/pyang/test.sh.
+	pyangDir := filepath.Join(resultsRoot, "pyang")
+	writeFile(t, filepath.Join(pyangDir, "latest-version.txt"), run(t, env, filepath.Join(stubsDir, "pyang"), "--version"))
+	runScript(t, env, pyangDir, filepath.Join(stubsDir, "pyang"))
+
+	// Run misc-checks as by validators/misc-checks/test.sh, with the tools
+	// that the script expects within the image replaced by the stubs. The
+	// results of the steps that diff the PR against its base branch are
+	// written directly.
+	miscChecksDir := filepath.Join(resultsRoot, "misc-checks")
+	scriptPath := filepath.Join(miscChecksDir, "script.sh")
+	script, err := os.ReadFile(scriptPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, scriptPath, strings.ReplaceAll(string(script), "/go/bin/", stubsDir+"/"))
+	runScript(t, env, miscChecksDir)
+	parseLogs, err := filepath.Glob(filepath.Join(miscChecksDir, "*.pr-file-parse-log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parseLog strings.Builder
+	for _, path := range parseLogs {
+		bs, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parseLog.Write(bs)
+	}
+	writeFile(t, filepath.Join(miscChecksDir, "pr-file-parse-log"), parseLog.String())
+	yangFiles, err := filepath.Glob(filepath.Join(modelRoot, "*", "*.yang"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(miscChecksDir, "all-non-empty-files.txt"), strings.Join(yangFiles, "\n")+"\n")
+	writeFile(t, filepath.Join(miscChecksDir, "changed-files.txt"), changedFile+"\n")
+	writeFile(t, filepath.Join(miscChecksDir, "master-file-parse-log"), masterFileParseLog)
+	writeFile(t, filepath.Join(miscChecksDir, "whitespace-log"), "")
+
+	for _, validatorId := range validatorIds {
+		t.Run(validatorId, func(t *testing.T) {
+			out := run(t, env, filepath.Join(binDir, "post_results"), "-validator", validatorId, "-modelRoot", modelRoot, "-repo-slug", repoSlug, "-pr-number", prNumber, "-commit-sha", commitSHA, "-branch", "e2e", "-dry-run", "-results-dir", resultsRoot)
+			checkGolden(t, filepath.Join("testdata", validatorId+"-report.txt"), strings.ReplaceAll(out, tmp, "$TMP"))
+		})
+	}
+}
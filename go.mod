@@ -14,6 +14,7 @@ require (
 	github.com/spf13/viper v1.16.0
 	golang.org/x/exp v0.0.0-20230801115018-d63ba01acd4b
 	golang.org/x/oauth2 v0.7.0
+	google.golang.org/grpc v1.58.0-dev
 	google.golang.org/protobuf v1.33.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -38,6 +39,5 @@ require (
 	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/appengine v1.6.7 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230525234030-28d5490b6b19 // indirect
-	google.golang.org/grpc v1.58.0-dev // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
 )
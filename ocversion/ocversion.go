@@ -0,0 +1,196 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ocversion implements ocversion, which lists the openconfig-version
+// and other module information of the given YANG files.
+package ocversion
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	civersion "github.com/openconfig/models-ci/version"
+	"github.com/openconfig/models-ci/yangutil"
+)
+
+var (
+	pathStr  string
+	timeout  time.Duration
+	cacheDir string
+)
+
+// flagSet contains the flags of ocversion, which are parsed by Main.
+var flagSet = flag.NewFlagSet("ocversion", flag.ExitOnError)
+
+func init() {
+	flagSet.StringVar(&pathStr, "p", "", "comma separated list of directories to add to search path")
+	flagSet.DurationVar(&timeout, "timeout", 0, fmt.Sprintf("maximum time to spend parsing before exiting with status %d; 0 means no timeout", yangutil.TimeoutExitCode))
+	flagSet.StringVar(&cacheDir, "cache-dir", "", "directory of a parse cache shared with other invocations; empty means no caching")
+}
+
+// ocVersionsList list all files with their openconfig-version value. If not
+// present, it still lists the file. The source file name, namespace, prefix
+// and latest revision date of each module are also listed.
+func ocVersionsList(infos []*yangutil.ModuleInfo) string {
+	var builder strings.Builder
+	for _, info := range infos {
+		builder.WriteString(fmt.Sprintf("%s.yang:", info.Name))
+		builder.WriteString(fmt.Sprintf(" belonging-module:%q", info.BelongingModule))
+		for _, err := range info.Errors {
+			builder.WriteString(err + "\n")
+		}
+		if info.OpenconfigVersion != "" {
+			builder.WriteString(fmt.Sprintf(" openconfig-version:%q", info.OpenconfigVersion))
+		}
+		if info.SourceFile != "" {
+			builder.WriteString(fmt.Sprintf(" source-file:%q", info.SourceFile))
+		}
+		if info.Namespace != "" {
+			builder.WriteString(fmt.Sprintf(" namespace:%q", info.Namespace))
+		}
+		if info.Prefix != "" {
+			builder.WriteString(fmt.Sprintf(" prefix:%q", info.Prefix))
+		}
+		if info.LatestRevisionDate != "" {
+			builder.WriteString(fmt.Sprintf(" latest-revision-date:%q", info.LatestRevisionDate))
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func buildModuleEntries(paths, files []string) ([]*yang.Entry, []error) {
+	ms := yang.NewModules()
+
+	var errs []error
+	for _, path := range paths {
+		expanded, err := yang.PathsWithModules(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ms.AddPath(expanded...)
+	}
+
+	for _, name := range files {
+		if err := ms.Read(name); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	// Process the read files, exiting if any errors were found.
+	if errs := ms.Process(); errs != nil {
+		return nil, errs
+	}
+
+	// Keep track of the top level modules we read in.
+	// Those are the only modules we want to print below.
+	mods := map[string]*yang.Module{}
+	var names []string
+
+	for _, m := range ms.Modules {
+		if _, ok := mods[m.Name]; !ok {
+			mods[m.Name] = m
+			names = append(names, m.Name)
+		}
+	}
+	for _, m := range ms.SubModules {
+		if _, ok := mods[m.Name]; !ok {
+			mods[m.Name] = m
+			names = append(names, m.Name)
+		}
+	}
+	sort.Strings(names)
+	entries := make([]*yang.Entry, len(names))
+	for x, n := range names {
+		entries[x] = yang.ToEntry(mods[n])
+	}
+
+	return entries, nil
+}
+
+// Main runs ocversion with the given command-line arguments, excluding the
+// program name.
+func Main(args []string) {
+	flagSet.Parse(args)
+
+	paths := strings.Split(pathStr, ",")
+	files := flagSet.Args()
+
+	// The cache is only an optimization, so parse whenever it is unusable.
+	var cache *yangutil.ParseCache
+	var cacheKey string
+	if cacheDir != "" {
+		var err error
+		if cache, err = yangutil.NewParseCache(cacheDir); err == nil {
+			cacheKey, err = yangutil.ParseKey(civersion.String(), paths, files)
+		}
+		if err != nil {
+			cache = nil
+		}
+	}
+	if cache != nil {
+		if infos, ok, err := cache.Get(cacheKey); err == nil && ok {
+			fmt.Print(ocVersionsList(infos))
+			return
+		}
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// entries and errs are only read once the parse has returned.
+	var entries []*yang.Entry
+	var errs []error
+	if err := yangutil.RunWithContext(ctx, func() error {
+		entries, errs = buildModuleEntries(paths, files)
+		return nil
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "timed out after %v while parsing YANG files\n", timeout)
+		os.Exit(yangutil.TimeoutExitCode)
+	}
+	if errs != nil {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+
+	infos, err := yangutil.NewModuleInfos(entries)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if cache != nil {
+		if err := cache.Put(cacheKey, infos); err != nil {
+			fmt.Fprintf(os.Stderr, "couldn't cache the parse results in %q: %v\n", cacheDir, err)
+		}
+	}
+	fmt.Print(ocVersionsList(infos))
+}
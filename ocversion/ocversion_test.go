@@ -12,13 +12,14 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+package ocversion
 
 import (
 	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/yangutil"
 )
 
 func TestOcVersionsList(t *testing.T) {
@@ -37,17 +38,17 @@ func TestOcVersionsList(t *testing.T) {
 			"testdata/openconfig-single-extension.yang",
 			"testdata/openconfig-single-extension-submodule.yang",
 		},
-		want: `openconfig-extensions.yang: belonging-module:"openconfig-extensions"
-openconfig-extensions-submodule.yang: belonging-module:"openconfig-extensions" openconfig-version:"0.5.0"
-openconfig-single-extension.yang: belonging-module:"openconfig-single-extension" openconfig-version:"0.4.2"
-openconfig-single-extension-submodule.yang: belonging-module:"openconfig-single-extension" openconfig-version:"0.4.3"
+		want: `openconfig-extensions.yang: belonging-module:"openconfig-extensions" source-file:"openconfig-extensions.yang" namespace:"http://openconfig.net/yang/openconfig-ext" prefix:"oc-ext" latest-revision-date:"2018-10-17"
+openconfig-extensions-submodule.yang: belonging-module:"openconfig-extensions" openconfig-version:"0.5.0" source-file:"openconfig-extensions-submodule.yang" prefix:"oc-ext"
+openconfig-single-extension.yang: belonging-module:"openconfig-single-extension" openconfig-version:"0.4.2" source-file:"openconfig-single-extension.yang" namespace:"http://openconfig.net/yang/single-extension" prefix:"oc-single-extension"
+openconfig-single-extension-submodule.yang: belonging-module:"openconfig-single-extension" openconfig-version:"0.4.3" source-file:"openconfig-single-extension-submodule.yang" prefix:"oc-single-extension"
 `,
 	}, {
 		desc:    "multiple extensions",
 		inPath:  []string{"testdata"},
 		inFiles: []string{"testdata/openconfig-telemetry-types.yang"},
-		want: `openconfig-extensions.yang: belonging-module:"openconfig-extensions"
-openconfig-telemetry-types.yang: belonging-module:"openconfig-telemetry-types" openconfig-version:"0.4.2"
+		want: `openconfig-extensions.yang: belonging-module:"openconfig-extensions" source-file:"openconfig-extensions.yang" namespace:"http://openconfig.net/yang/openconfig-ext" prefix:"oc-ext" latest-revision-date:"2018-10-17"
+openconfig-telemetry-types.yang: belonging-module:"openconfig-telemetry-types" openconfig-version:"0.4.2" source-file:"openconfig-telemetry-types.yang" namespace:"http://openconfig.net/yang/telemetry-types" prefix:"oc-telemetry-types" latest-revision-date:"2018-11-21"
 `,
 	}, {
 		desc:    "invalid file",
@@ -58,9 +59,9 @@ openconfig-telemetry-types.yang: belonging-module:"openconfig-telemetry-types" o
 		desc:    "other-extensions module used for openconfig-extension value",
 		inPath:  []string{"testdata"},
 		inFiles: []string{"testdata/openconfig-use-other-extension.yang"},
-		want: `openconfig-extensions.yang: belonging-module:"openconfig-extensions"
-openconfig-use-other-extension.yang: belonging-module:"openconfig-use-other-extension"
-other-extensions.yang: belonging-module:"other-extensions"
+		want: `openconfig-extensions.yang: belonging-module:"openconfig-extensions" source-file:"openconfig-extensions.yang" namespace:"http://openconfig.net/yang/openconfig-ext" prefix:"oc-ext" latest-revision-date:"2018-10-17"
+openconfig-use-other-extension.yang: belonging-module:"openconfig-use-other-extension" source-file:"openconfig-use-other-extension.yang" namespace:"http://openconfig.net/yang/telemetry-types" prefix:"oc-telemetry-types" latest-revision-date:"2018-11-21"
+other-extensions.yang: belonging-module:"other-extensions" source-file:"other-extensions.yang" namespace:"http://openconfig.net/yang/openconfig-ext" prefix:"ot-ext" latest-revision-date:"2018-10-17"
 `,
 	}}
 
@@ -71,7 +72,12 @@ other-extensions.yang: belonging-module:"other-extensions"
 				t.Fatal(errs)
 			}
 
-			got, want := strings.Split(ocVersionsList(entries), "\n"), strings.Split(tt.want, "\n")
+			infos, err := yangutil.NewModuleInfos(entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, want := strings.Split(ocVersionsList(infos), "\n"), strings.Split(tt.want, "\n")
 			if diff := cmp.Diff(got, want); diff != "" {
 				t.Errorf("(-got, +want):\n%s", diff)
 			}
@@ -0,0 +1,239 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package churn aggregates the openconfig-version changes of the YANG modules
+// of the PRs merged within a release cycle into a per-module summary, to
+// inform release planning.
+package churn
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var (
+	moduleRegexp    = regexp.MustCompile(`(?m)^\s*(?:sub)?module\s+"?([\w.-]+)`)
+	belongsToRegexp = regexp.MustCompile(`belongs-to\s+"?([\w.-]+)`)
+	versionRegexp   = regexp.MustCompile(`[\w.-]+:openconfig-version\s+"?([^";\s]+)"?\s*;`)
+	prRegexp        = regexp.MustCompile(`(?:\(#(\d+)\)$|^Merge pull request #(\d+))`)
+)
+
+// ParseFile returns the module to which the YANG file with the given contents
+// belongs, i.e. its own name if it's a module and its belonging module's name
+// if it's a submodule, as well as its openconfig-version, if any.
+//
+// The file is scanned rather than parsed, such that the files of every merged
+// commit may be inspected cheaply, and regardless of whether their imports
+// resolve.
+func ParseFile(contents string) (string, string) {
+	var module, version string
+	if m := belongsToRegexp.FindStringSubmatch(contents); m != nil {
+		module = m[1]
+	} else if m := moduleRegexp.FindStringSubmatch(contents); m != nil {
+		module = m[1]
+	}
+	if m := versionRegexp.FindStringSubmatch(contents); m != nil {
+		version = m[1]
+	}
+	return module, version
+}
+
+// PRNumber returns the number of the PR merged by the commit with the given
+// subject, for both merge commits and squash merges, or 0 if it isn't known.
+func PRNumber(subject string) int {
+	m := prRegexp.FindStringSubmatch(strings.TrimSpace(subject))
+	if m == nil {
+		return 0
+	}
+	var n int
+	fmt.Sscan(m[1]+m[2], &n)
+	return n
+}
+
+// VersionChange is a change to the openconfig-version of a YANG file. An
+// empty NewVersion indicates that the file was deleted.
+type VersionChange struct {
+	File       string
+	Module     string
+	OldVersion string
+	NewVersion string
+}
+
+// Bump returns the kind of the change: "major", "minor", "patch", "deleted",
+// or "" if either version isn't a semantic version.
+func (c VersionChange) Bump() string {
+	if c.NewVersion == "" {
+		return "deleted"
+	}
+	oldver, err := semver.StrictNewVersion(c.OldVersion)
+	if err != nil {
+		return ""
+	}
+	newver, err := semver.StrictNewVersion(c.NewVersion)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case oldver.Major() != newver.Major():
+		return "major"
+	case oldver.Minor() != newver.Minor():
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// Breaking returns whether the change indicates a breaking change, i.e. a
+// major version increase from a version other than 0.x.y, or the deletion of
+// a versioned file. This matches the breaking change label of misc-checks.
+func (c VersionChange) Breaking() bool {
+	if c.NewVersion == "" {
+		return c.OldVersion != ""
+	}
+	oldver, err := semver.StrictNewVersion(c.OldVersion)
+	if err != nil {
+		return false
+	}
+	newver, err := semver.StrictNewVersion(c.NewVersion)
+	if err != nil {
+		return false
+	}
+	return oldver.Major() != 0 && oldver.Major() != newver.Major()
+}
+
+// Commit is a merged commit along with its openconfig-version changes.
+type Commit struct {
+	SHA     string
+	Subject string
+	Changes []VersionChange
+}
+
+// ModuleChurn is the churn of a single module across a release cycle.
+type ModuleChurn struct {
+	Module string
+	// PRs is the sorted numbers of the PRs changing the module's version.
+	// Commits whose PR number isn't known are counted by Commits only.
+	PRs      []int
+	Commits  int
+	Major    int
+	Minor    int
+	Patch    int
+	Deleted  int
+	Breaking int
+}
+
+// bumpRanks orders the kinds of version changes by significance. A deleted
+// file ranks lowest, since a module's remaining files record its bump.
+var bumpRanks = map[string]int{"deleted": 1, "patch": 2, "minor": 3, "major": 4}
+
+// Aggregate returns the churn of each module whose openconfig-version changed
+// in any of the commits, sorted by descending number of version changes and
+// then by module name. The changes to the files of a module within a commit
+// (e.g. to the module and its submodules) count as a single version change
+// of their most significant kind.
+func Aggregate(commits []Commit) []*ModuleChurn {
+	churns := map[string]*ModuleChurn{}
+	for _, c := range commits {
+		pr := PRNumber(c.Subject)
+		bumps := map[string]string{}
+		breaking := map[string]bool{}
+		var modules []string
+		for _, change := range c.Changes {
+			prev, ok := bumps[change.Module]
+			if !ok {
+				modules = append(modules, change.Module)
+			}
+			if bump := change.Bump(); !ok || bumpRanks[bump] > bumpRanks[prev] {
+				bumps[change.Module] = bump
+			}
+			breaking[change.Module] = breaking[change.Module] || change.Breaking()
+		}
+
+		for _, module := range modules {
+			mc, ok := churns[module]
+			if !ok {
+				mc = &ModuleChurn{Module: module}
+				churns[module] = mc
+			}
+			switch bumps[module] {
+			case "major":
+				mc.Major++
+			case "minor":
+				mc.Minor++
+			case "patch":
+				mc.Patch++
+			case "deleted":
+				mc.Deleted++
+			}
+			if breaking[module] {
+				mc.Breaking++
+			}
+			mc.Commits++
+			if pr != 0 {
+				mc.PRs = append(mc.PRs, pr)
+			}
+		}
+	}
+
+	var out []*ModuleChurn
+	for _, mc := range churns {
+		sort.Ints(mc.PRs)
+		out = append(out, mc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ti, tj := out[i].total(), out[j].total()
+		if ti != tj {
+			return ti > tj
+		}
+		return out[i].Module < out[j].Module
+	})
+	return out
+}
+
+// total returns the number of version changes of the module.
+func (mc *ModuleChurn) total() int {
+	return mc.Major + mc.Minor + mc.Patch + mc.Deleted
+}
+
+// WriteReport writes the churn summary as a markdown table, such that it may
+// be pasted into a release planning issue.
+func WriteReport(w io.Writer, churns []*ModuleChurn) error {
+	var b strings.Builder
+	b.WriteString("| module | PRs | major | minor | patch | deleted | breaking |\n")
+	b.WriteString("|---|---|---|---|---|---|---|\n")
+	var total ModuleChurn
+	for _, mc := range churns {
+		var prs []string
+		for _, pr := range mc.PRs {
+			prs = append(prs, fmt.Sprintf("#%d", pr))
+		}
+		if unknown := mc.Commits - len(mc.PRs); unknown > 0 {
+			prs = append(prs, fmt.Sprintf("%d other commit(s)", unknown))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %d | %d |\n", mc.Module, strings.Join(prs, ", "), mc.Major, mc.Minor, mc.Patch, mc.Deleted, mc.Breaking)
+		total.Major += mc.Major
+		total.Minor += mc.Minor
+		total.Patch += mc.Patch
+		total.Deleted += mc.Deleted
+		total.Breaking += mc.Breaking
+	}
+	fmt.Fprintf(&b, "| **total (%d modules)** | | %d | %d | %d | %d | %d |\n", len(churns), total.Major, total.Minor, total.Patch, total.Deleted, total.Breaking)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package churn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		wantModule  string
+		wantVersion string
+	}{{
+		name: "module",
+		in: `module openconfig-platform {
+  prefix "oc-platform";
+  import openconfig-extensions { prefix oc-ext; }
+  oc-ext:openconfig-version "0.24.0";
+}`,
+		wantModule:  "openconfig-platform",
+		wantVersion: "0.24.0",
+	}, {
+		name: "submodule",
+		in: `submodule openconfig-aft-common {
+  belongs-to "openconfig-aft" { prefix "oc-aft"; }
+  oc-ext:openconfig-version 2.3.0;
+}`,
+		wantModule:  "openconfig-aft",
+		wantVersion: "2.3.0",
+	}, {
+		name:       "no version",
+		in:         "module ietf-interfaces {\n}",
+		wantModule: "ietf-interfaces",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotModule, gotVersion := ParseFile(tt.in)
+			if gotModule != tt.wantModule || gotVersion != tt.wantVersion {
+				t.Errorf("got (%q, %q), want (%q, %q)", gotModule, gotVersion, tt.wantModule, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestPRNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{{
+		in:   "Add linecard slot identifier (#1234)",
+		want: 1234,
+	}, {
+		in:   "Merge pull request #987 from user/branch",
+		want: 987,
+	}, {
+		in:   "Fix typo in #12",
+		want: 0,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := PRNumber(tt.in); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionChange(t *testing.T) {
+	tests := []struct {
+		in           VersionChange
+		wantBump     string
+		wantBreaking bool
+	}{{
+		in:           VersionChange{OldVersion: "1.2.3", NewVersion: "2.0.0"},
+		wantBump:     "major",
+		wantBreaking: true,
+	}, {
+		in:       VersionChange{OldVersion: "0.2.3", NewVersion: "1.0.0"},
+		wantBump: "major",
+	}, {
+		in:       VersionChange{OldVersion: "1.2.3", NewVersion: "1.3.0"},
+		wantBump: "minor",
+	}, {
+		in:       VersionChange{OldVersion: "1.2.3", NewVersion: "1.2.4"},
+		wantBump: "patch",
+	}, {
+		in:           VersionChange{OldVersion: "1.2.3"},
+		wantBump:     "deleted",
+		wantBreaking: true,
+	}, {
+		in:       VersionChange{OldVersion: "1.2", NewVersion: "1.3"},
+		wantBump: "",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.in.OldVersion+"->"+tt.in.NewVersion, func(t *testing.T) {
+			if got := tt.in.Bump(); got != tt.wantBump {
+				t.Errorf("Bump: got %q, want %q", got, tt.wantBump)
+			}
+			if got := tt.in.Breaking(); got != tt.wantBreaking {
+				t.Errorf("Breaking: got %v, want %v", got, tt.wantBreaking)
+			}
+		})
+	}
+}
+
+func TestAggregateAndWriteReport(t *testing.T) {
+	commits := []Commit{{
+		SHA:     "a",
+		Subject: "Update AFT (#10)",
+		Changes: []VersionChange{
+			{File: "openconfig-aft.yang", Module: "openconfig-aft", OldVersion: "1.0.0", NewVersion: "1.1.0"},
+			{File: "openconfig-aft-common.yang", Module: "openconfig-aft", OldVersion: "1.0.0", NewVersion: "1.1.0"},
+			{File: "openconfig-platform-old.yang", Module: "openconfig-platform", OldVersion: "1.0.0"},
+			{File: "openconfig-platform.yang", Module: "openconfig-platform", OldVersion: "1.0.0", NewVersion: "2.0.0"},
+		},
+	}, {
+		SHA:     "b",
+		Subject: "Update AFT again",
+		Changes: []VersionChange{
+			{File: "openconfig-aft.yang", Module: "openconfig-aft", OldVersion: "1.1.0", NewVersion: "1.1.1"},
+		},
+	}, {
+		SHA:     "c",
+		Subject: "Merge pull request #5 from user/branch",
+		Changes: []VersionChange{
+			{File: "openconfig-old.yang", Module: "openconfig-old", OldVersion: "0.1.0"},
+		},
+	}, {
+		SHA:     "d",
+		Subject: "Update README (#11)",
+	}}
+
+	got := Aggregate(commits)
+	want := []*ModuleChurn{{
+		Module:  "openconfig-aft",
+		PRs:     []int{10},
+		Commits: 2,
+		Minor:   1,
+		Patch:   1,
+	}, {
+		Module:   "openconfig-old",
+		PRs:      []int{5},
+		Commits:  1,
+		Deleted:  1,
+		Breaking: 1,
+	}, {
+		Module:   "openconfig-platform",
+		PRs:      []int{10},
+		Commits:  1,
+		Major:    1,
+		Breaking: 1,
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Aggregate (-want, +got):\n%s", diff)
+	}
+
+	var b strings.Builder
+	if err := WriteReport(&b, got); err != nil {
+		t.Fatal(err)
+	}
+	wantReport := `| module | PRs | major | minor | patch | deleted | breaking |
+|---|---|---|---|---|---|---|
+| openconfig-aft | #10, 1 other commit(s) | 0 | 1 | 1 | 0 | 0 |
+| openconfig-old | #5 | 0 | 0 | 0 | 1 | 1 |
+| openconfig-platform | #10 | 1 | 0 | 0 | 0 | 1 |
+| **total (3 modules)** | | 1 | 1 | 1 | 1 | 2 |
+`
+	if diff := cmp.Diff(wantReport, b.String()); diff != "" {
+		t.Errorf("WriteReport (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocdiff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ReleaseHistory records the releases in which each schema path existed, and
+// is used to annotate the paths of a report with their release introduction.
+//
+// Its text format has one "<release> <path>" line for each path of each
+// release, where releases must first appear in chronological order.
+type ReleaseHistory struct {
+	// releases are the release tags in chronological order.
+	releases []string
+	// pathReleases stores the indices into releases of the releases in
+	// which each path existed, in increasing order.
+	pathReleases map[string][]int
+}
+
+// NewReleaseHistory returns an empty release history.
+func NewReleaseHistory() *ReleaseHistory {
+	return &ReleaseHistory{pathReleases: map[string][]int{}}
+}
+
+// AddRelease records the schema paths of the given release, which must be
+// later than all releases already recorded.
+func (h *ReleaseHistory) AddRelease(release string, paths []string) error {
+	for _, r := range h.releases {
+		if r == release {
+			return fmt.Errorf("release %q already recorded", release)
+		}
+	}
+	h.releases = append(h.releases, release)
+	i := len(h.releases) - 1
+	for _, path := range paths {
+		if releases := h.pathReleases[path]; len(releases) == 0 || releases[len(releases)-1] != i {
+			h.pathReleases[path] = append(releases, i)
+		}
+	}
+	return nil
+}
+
+// ParseReleaseHistory parses a release history in its text format.
+func ParseReleaseHistory(r io.Reader) (*ReleaseHistory, error) {
+	h := NewReleaseHistory()
+	releaseIndices := map[string]int{}
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected \"<release> <path>\", got %q", lineNo, line)
+		}
+		release, path := fields[0], fields[1]
+		i, ok := releaseIndices[release]
+		if !ok {
+			i = len(h.releases)
+			releaseIndices[release] = i
+			h.releases = append(h.releases, release)
+		}
+		// Duplicate lines are ignored, but a path's releases must be listed
+		// in chronological order.
+		releases := h.pathReleases[path]
+		if len(releases) != 0 && releases[len(releases)-1] >= i {
+			if releases[len(releases)-1] == i {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: release %q listed after later release %q", lineNo, release, h.releases[releases[len(releases)-1]])
+		}
+		h.pathReleases[path] = append(releases, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// WriteTo writes the release history in its text format.
+func (h *ReleaseHistory) WriteTo(w io.Writer) (int64, error) {
+	pathsByRelease := make([][]string, len(h.releases))
+	for path, releases := range h.pathReleases {
+		for _, i := range releases {
+			pathsByRelease[i] = append(pathsByRelease[i], path)
+		}
+	}
+
+	var n int64
+	for i, paths := range pathsByRelease {
+		sort.Strings(paths)
+		for _, path := range paths {
+			written, err := fmt.Fprintf(w, "%s %s\n", h.releases[i], path)
+			n += int64(written)
+			if err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// releaseRange describes the releases in which path existed, or returns
+// false if it didn't exist in any release.
+func (h *ReleaseHistory) releaseRange(path string) (string, bool) {
+	releases := h.pathReleases[path]
+	switch len(releases) {
+	case 0:
+		return "", false
+	case 1:
+		return "release " + h.releases[releases[0]], true
+	default:
+		return fmt.Sprintf("releases %s to %s", h.releases[releases[0]], h.releases[releases[len(releases)-1]]), true
+	}
+}
+
+// SchemaPaths returns the sorted schema paths of the given YANG files, for
+// recording a release in a ReleaseHistory.
+func SchemaPaths(paths, files []string) ([]string, error) {
+	entries, _, err := flattenedEntries(paths, files)
+	if err != nil {
+		return nil, err
+	}
+	var schemaPaths []string
+	for path := range entries {
+		schemaPaths = append(schemaPaths, path)
+	}
+	sort.Strings(schemaPaths)
+	return schemaPaths, nil
+}
+
+// addedAnnotation returns the release annotation of a path added in
+// nextRelease.
+func (h *ReleaseHistory) addedAnnotation(path, nextRelease string) string {
+	if nextRelease == "" {
+		nextRelease = "the next release"
+	}
+	if previously, ok := h.releaseRange(path); ok {
+		return fmt.Sprintf("reintroduced in %s, previously in %s", nextRelease, previously)
+	}
+	return "introduced in " + nextRelease
+}
+
+// deletedAnnotation returns the release annotation of a deleted path.
+func (h *ReleaseHistory) deletedAnnotation(path string) string {
+	if existed, ok := h.releaseRange(path); ok {
+		return "existed in " + existed
+	}
+	return "not in any release"
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ocdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseReleaseHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{{
+		name: "basic",
+		in: `v1.0.0 /a/b
+v1.0.0 /a/c
+v2.0.0 /a/b
+
+v2.0.0 /a/d
+`,
+		want: `v1.0.0 /a/b
+v1.0.0 /a/c
+v2.0.0 /a/b
+v2.0.0 /a/d
+`,
+	}, {
+		name: "duplicate lines",
+		in: `v1.0.0 /a/b
+v1.0.0 /a/b
+`,
+		want: `v1.0.0 /a/b
+`,
+	}, {
+		name: "release listed out of order",
+		in: `v1.0.0 /a/b
+v2.0.0 /a/b
+v1.0.0 /a/b
+`,
+		wantErr: true,
+	}, {
+		name:    "malformed line",
+		in:      "v1.0.0\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := ParseReleaseHistory(strings.NewReader(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var b strings.Builder
+			if _, err := h.WriteTo(&b); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReleaseHistoryAnnotations(t *testing.T) {
+	h := NewReleaseHistory()
+	if err := h.AddRelease("v1.0.0", []string{"/a/b", "/a/c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddRelease("v2.0.0", []string{"/a/b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddRelease("v1.0.0", nil); err == nil {
+		t.Errorf("AddRelease: got no error for duplicate release")
+	}
+
+	tests := []struct {
+		name        string
+		inPath      string
+		wantAdded   string
+		wantDeleted string
+	}{{
+		name:        "in multiple releases",
+		inPath:      "/a/b",
+		wantAdded:   "reintroduced in v3.0.0, previously in releases v1.0.0 to v2.0.0",
+		wantDeleted: "existed in releases v1.0.0 to v2.0.0",
+	}, {
+		name:        "in single release",
+		inPath:      "/a/c",
+		wantAdded:   "reintroduced in v3.0.0, previously in release v1.0.0",
+		wantDeleted: "existed in release v1.0.0",
+	}, {
+		name:        "not in any release",
+		inPath:      "/a/d",
+		wantAdded:   "introduced in v3.0.0",
+		wantDeleted: "not in any release",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.addedAnnotation(tt.inPath, "v3.0.0"); got != tt.wantAdded {
+				t.Errorf("addedAnnotation: got %q, want %q", got, tt.wantAdded)
+			}
+			if got := h.deletedAnnotation(tt.inPath); got != tt.wantDeleted {
+				t.Errorf("deletedAnnotation: got %q, want %q", got, tt.wantDeleted)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package ocdiff
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/Masterminds/semver/v3"
@@ -41,6 +42,26 @@ func NewDiffReport(oldpaths, newpaths, oldfiles, newfiles []string) (*DiffReport
 	return diffMaps(oldEntries, newEntries, oldModuleVersions, newModuleVersions), nil
 }
 
+// NewModuleDiff returns a diff report between two versions of a single YANG
+// module given the search paths for resolving its imports.
+//
+// Only the nodes defined by the module are compared, i.e. the module's own
+// schema tree and its augmentations of other modules, which is much faster
+// than NewDiffReport when checking a single changed module.
+func NewModuleDiff(oldFile, newFile string, searchPaths []string) (*DiffReport, error) {
+	oldEntries, oldModuleVersions, err := moduleEntries(searchPaths, oldFile)
+	if err != nil {
+		return nil, err
+	}
+
+	newEntries, newModuleVersions, err := moduleEntries(searchPaths, newFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffMaps(oldEntries, newEntries, oldModuleVersions, newModuleVersions), nil
+}
+
 // yangNodeInfo contains all information of a single new/deleted node necessary
 // for printing a report.
 type yangNodeInfo struct {
@@ -48,6 +69,7 @@ type yangNodeInfo struct {
 	schema            *yang.Entry
 	incompatAllowed   bool
 	versionChangeDesc string
+	incompatComments  []string
 }
 
 // yangNodeUpdateInfo contains all information of a single updated node necessary
@@ -69,6 +91,10 @@ type DiffReport struct {
 	deletedNodes      []*yangNodeInfo
 	oldModuleVersions map[string]*semver.Version
 	newModuleVersions map[string]*semver.Version
+	// oldEntries and newEntries are the diffed schema entries keyed by
+	// their paths.
+	oldEntries map[string]*yang.Entry
+	newEntries map[string]*yang.Entry
 }
 
 // Option can be used to modify the report outputs.
@@ -82,6 +108,17 @@ func WithDisallowedIncompatsOnly() Option {
 	}
 }
 
+// WithAllIncompats indicates to report only backward-incompatible changes,
+// including those allowed by version increments. This is stricter than
+// WithDisallowedIncompatsOnly, e.g. for backports to a release branch, on which
+// no backward-incompatible changes are allowed.
+func WithAllIncompats() Option {
+	return func(o *reportOptions) {
+		o.onlyReportDisallowedIncompats = true
+		o.allIncompats = true
+	}
+}
+
 // WithGithubCommentStyle indicates to report with GitHub comment styling.
 func WithGithubCommentStyle() Option {
 	return func(o *reportOptions) {
@@ -89,6 +126,28 @@ func WithGithubCommentStyle() Option {
 	}
 }
 
+// WithReleaseHistory indicates to annotate added paths with the release
+// introducing them, nextRelease (e.g. "v5.1.0"), and deleted paths with the
+// releases in which they existed.
+func WithReleaseHistory(h *ReleaseHistory, nextRelease string) Option {
+	return func(o *reportOptions) {
+		o.releaseHistory = h
+		o.nextRelease = nextRelease
+	}
+}
+
+// WithCompressedPaths indicates to report paths under OpenConfig path
+// compression, as seen by ygot and ygnmi consumers, in which the config and
+// state containers are removed such that each config/state pair of leaves
+// shares a single path. Identical changes to both leaves of a pair are
+// reported once, and moves between the config and state containers are
+// reported as changes of the leaf's writability.
+func WithCompressedPaths() Option {
+	return func(o *reportOptions) {
+		o.compressedPaths = true
+	}
+}
+
 // resolveOpts applies all the options and returns a struct containing the result.
 func resolveOpts(opts []Option) *reportOptions {
 	o := &reportOptions{}
@@ -100,12 +159,41 @@ func resolveOpts(opts []Option) *reportOptions {
 
 type reportOptions struct {
 	onlyReportDisallowedIncompats bool
+	allIncompats                  bool
 	githubComment                 bool
+	releaseHistory                *ReleaseHistory
+	nextRelease                   string
+	compressedPaths               bool
+}
+
+// skipIncompat returns whether to skip reporting a backward-incompatible
+// change, given whether it's allowed by the version increment.
+func (o *reportOptions) skipIncompat(incompatAllowed bool) bool {
+	return o.onlyReportDisallowedIncompats && incompatAllowed && !o.allIncompats
+}
+
+// addedDesc returns the description of an added node.
+func (o *reportOptions) addedDesc(n *yangNodeInfo) string {
+	if o.releaseHistory == nil {
+		return n.versionChangeDesc
+	}
+	return n.versionChangeDesc + "; " + o.releaseHistory.addedAnnotation(n.schema.Path(), o.nextRelease)
+}
+
+// deletedDesc returns the description of a deleted node.
+func (o *reportOptions) deletedDesc(n *yangNodeInfo) string {
+	if o.releaseHistory == nil {
+		return n.versionChangeDesc
+	}
+	return n.versionChangeDesc + "; " + o.releaseHistory.deletedAnnotation(n.schema.Path())
 }
 
 // Report outputs a report on the diff between the two sets of OpenConfig YANG files.
 func (r *DiffReport) Report(options ...Option) string {
 	opts := resolveOpts(options)
+	if opts.compressedPaths {
+		r = r.compressed()
+	}
 	r.Sort()
 	fmtstr := "%s %s: %s (%s)\n"
 	if opts.githubComment {
@@ -114,16 +202,16 @@ func (r *DiffReport) Report(options ...Option) string {
 	var b strings.Builder
 	for _, del := range r.deletedNodes {
 		// All deletions are breaking changes.
-		if opts.onlyReportDisallowedIncompats && del.incompatAllowed {
+		if opts.skipIncompat(del.incompatAllowed) {
 			continue
 		}
 		if del.schema.IsLeaf() || del.schema.IsLeafList() {
-			b.WriteString(fmt.Sprintf(fmtstr, "leaf", "deleted", del.path, del.versionChangeDesc))
+			b.WriteString(fmt.Sprintf(fmtstr, "leaf", "deleted", del.path, opts.deletedDesc(del)))
 		}
 	}
 	for _, upd := range r.updatedNodes {
 		// All type updates are breaking changes.
-		if opts.onlyReportDisallowedIncompats && upd.incompatAllowed {
+		if opts.skipIncompat(upd.incompatAllowed) {
 			continue
 		}
 		nodeTypeDesc := "non-leaf"
@@ -142,16 +230,73 @@ func (r *DiffReport) Report(options ...Option) string {
 			b.WriteString(fmt.Sprintf(fmtstr, nodeTypeDesc, "updated", upd.path, upd.versionChangeDesc))
 		}
 	}
-	if !opts.onlyReportDisallowedIncompats {
-		for _, added := range r.newNodes {
+	for _, added := range r.newNodes {
+		// Additions are only breaking changes when they're mandatory.
+		if len(added.incompatComments) > 0 {
+			if opts.skipIncompat(added.incompatAllowed) {
+				continue
+			}
+			nodeTypeDesc := "non-leaf"
 			if added.schema.IsLeaf() || added.schema.IsLeafList() {
-				b.WriteString(fmt.Sprintf(fmtstr, "leaf", "added", added.path, added.versionChangeDesc))
+				nodeTypeDesc = "leaf"
+			}
+			fmtstr := "%s added: %s: %s (%s)\n"
+			comments := strings.Join(added.incompatComments, "\n\t")
+			if opts.githubComment {
+				fmtstr = "%s added: `%s`\n* %s\n* (%s)\n\n"
+				comments = strings.Join(added.incompatComments, "\n* ")
 			}
+			b.WriteString(fmt.Sprintf(fmtstr, nodeTypeDesc, added.path, comments, opts.addedDesc(added)))
+			continue
+		}
+		if opts.onlyReportDisallowedIncompats {
+			continue
+		}
+		if added.schema.IsLeaf() || added.schema.IsLeafList() {
+			b.WriteString(fmt.Sprintf(fmtstr, "leaf", "added", added.path, opts.addedDesc(added)))
 		}
 	}
 	return b.String()
 }
 
+// MajorVersionChanges returns a description of the major openconfig-version
+// change of each module whose major version changed, sorted by module name.
+func (r *DiffReport) MajorVersionChanges() []string {
+	var changes []string
+	for moduleName, oldVersion := range r.oldModuleVersions {
+		newVersion, ok := r.newModuleVersions[moduleName]
+		if !ok || oldVersion == nil || newVersion == nil || oldVersion.Major() == newVersion.Major() {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%q: openconfig-version %v -> %v", moduleName, oldVersion, newVersion))
+	}
+	slices.Sort(changes)
+	return changes
+}
+
+// VersionDowngrades returns a description of each module whose
+// openconfig-version decreased or was removed, sorted by module name,
+// regardless of whether any of its nodes changed, e.g. to catch a version
+// reverted by a bad merge. Deleted modules aren't reported, since their nodes
+// are reported as deleted.
+func (r *DiffReport) VersionDowngrades() []string {
+	var downgrades []string
+	for moduleName, oldVersion := range r.oldModuleVersions {
+		if oldVersion == nil {
+			continue
+		}
+		newVersion, ok := r.newModuleVersions[moduleName]
+		switch {
+		case (!ok || newVersion == nil) && r.newEntries["/"+moduleName] != nil:
+			downgrades = append(downgrades, fmt.Sprintf("%q: openconfig-version %v removed", moduleName, oldVersion))
+		case newVersion != nil && newVersion.LessThan(oldVersion):
+			downgrades = append(downgrades, fmt.Sprintf("%q: openconfig-version %v -> %v", moduleName, oldVersion, newVersion))
+		}
+	}
+	slices.Sort(downgrades)
+	return downgrades
+}
+
 func (r *DiffReport) Sort() {
 	slices.SortFunc(r.newNodes, func(a, b *yangNodeInfo) int { return strings.Compare(a.path, b.path) })
 	slices.SortFunc(r.deletedNodes, func(a, b *yangNodeInfo) int { return strings.Compare(a.path, b.path) })
@@ -206,12 +351,7 @@ func (r *DiffReport) addPair(o *yang.Entry, n *yang.Entry) error {
 	switch {
 	case o == nil && n == nil:
 	case o == nil:
-		newModuleName, oldVersion, newVersion := r.getModuleAndVersions(n)
-		r.newNodes = append(r.newNodes, &yangNodeInfo{
-			schema:            n,
-			path:              n.Path(),
-			versionChangeDesc: fmt.Sprintf("%q: openconfig-version %v -> %v", newModuleName, oldVersion, newVersion),
-		})
+		r.addNew(n, false)
 	case n == nil:
 		r.deletedNodes = append(r.deletedNodes, &yangNodeInfo{
 			schema:            o,
@@ -232,6 +372,24 @@ func (r *DiffReport) addPair(o *yang.Entry, n *yang.Entry) error {
 			upd.incompatComments = append(upd.incompatComments, fmt.Sprintf("type changed from %s to %s", oldKind, newKind))
 			updated = true
 		}
+		// Containers become mandatory only through their descendants,
+		// which are reported themselves.
+		if !o.ReadOnly() && !n.IsContainer() && !isMandatory(o) && isMandatory(n) {
+			upd.incompatComments = append(upd.incompatComments, "became mandatory")
+			updated = true
+		}
+		if oldPresence, newPresence := hasPresence(o), hasPresence(n); oldPresence != newPresence {
+			if newPresence {
+				upd.incompatComments = append(upd.incompatComments, "presence added")
+			} else {
+				upd.incompatComments = append(upd.incompatComments, "presence removed")
+			}
+			updated = true
+		}
+		if comments := moduleIdentityChanges(o, n); len(comments) > 0 {
+			upd.incompatComments = append(upd.incompatComments, comments...)
+			updated = true
+		}
 		if updated {
 			r.updatedNodes = append(r.updatedNodes, upd)
 		}
@@ -239,6 +397,78 @@ func (r *DiffReport) addPair(o *yang.Entry, n *yang.Entry) error {
 	return nil
 }
 
+// addNew adds a new node to the report. existingParent indicates that the
+// node's parent exists in the old schema, in which case a mandatory node is
+// a backward-incompatible addition, since existing clients don't set it.
+func (r *DiffReport) addNew(n *yang.Entry, existingParent bool) {
+	moduleName, oldVersion, newVersion := r.getModuleAndVersions(n)
+	added := &yangNodeInfo{
+		schema:            n,
+		path:              n.Path(),
+		incompatAllowed:   isIncompatAllowed(oldVersion, newVersion),
+		versionChangeDesc: fmt.Sprintf("%q: openconfig-version %v -> %v", moduleName, oldVersion, newVersion),
+	}
+	if existingParent && !n.ReadOnly() && isMandatory(n) {
+		added.incompatComments = append(added.incompatComments, "mandatory node added")
+	}
+	r.newNodes = append(r.newNodes, added)
+}
+
+// moduleIdentityChanges returns the changes of the namespace and prefix of
+// the module if o and n are the entries of a module, which break existing
+// instance data and XPath expressions referencing the module.
+func moduleIdentityChanges(o, n *yang.Entry) []string {
+	oldModule, ok := o.Node.(*yang.Module)
+	if !ok {
+		return nil
+	}
+	newModule, ok := n.Node.(*yang.Module)
+	if !ok {
+		return nil
+	}
+	var comments []string
+	if oldNS, newNS := valueName(oldModule.Namespace), valueName(newModule.Namespace); oldNS != newNS {
+		comments = append(comments, fmt.Sprintf("namespace changed from %q to %q", oldNS, newNS))
+	}
+	if oldPrefix, newPrefix := valueName(oldModule.Prefix), valueName(newModule.Prefix); oldPrefix != newPrefix {
+		comments = append(comments, fmt.Sprintf("prefix changed from %q to %q", oldPrefix, newPrefix))
+	}
+	return comments
+}
+
+// valueName returns the argument of a YANG statement, or "" if it is absent.
+func valueName(v *yang.Value) string {
+	if v == nil {
+		return ""
+	}
+	return v.Name
+}
+
+// hasPresence returns whether e is a presence container.
+func hasPresence(e *yang.Entry) bool {
+	c, ok := e.Node.(*yang.Container)
+	return ok && c.Presence != nil
+}
+
+// isMandatory returns whether e must be present in a valid data tree whenever
+// its parent is, i.e. it is a mandatory leaf or choice, a list or leaf-list
+// with min-elements, or a non-presence container with mandatory descendants.
+func isMandatory(e *yang.Entry) bool {
+	switch {
+	case e.Mandatory == yang.TSTrue:
+		return true
+	case e.ListAttr != nil:
+		return e.ListAttr.MinElements > 0
+	case e.IsContainer() && !hasPresence(e):
+		for _, child := range e.Dir {
+			if isMandatory(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // belongingModule returns the module name if m is a module and the belonging
 // module name if m is a submodule.
 func belongingModule(m *yang.Module) string {
@@ -308,14 +538,216 @@ func diffMaps(oldEntries, newEntries map[string]*yang.Entry, oldModuleVersions,
 	report := &DiffReport{
 		oldModuleVersions: oldModuleVersions,
 		newModuleVersions: newModuleVersions,
+		oldEntries:        oldEntries,
+		newEntries:        newEntries,
 	}
 	for path, oldEntry := range oldEntries {
 		report.addPair(oldEntry, newEntries[path])
 	}
 	for path, newEntry := range newEntries {
 		if oldEntries[path] == nil {
-			report.addPair(oldEntries[path], newEntry)
+			report.addNew(newEntry, newEntry.Parent != nil && oldEntries[newEntry.Parent.Path()] != nil)
 		}
 	}
 	return report
 }
+
+// fileModuleName returns the name of the module or submodule defined in the
+// given YANG file.
+func fileModuleName(file string) (string, error) {
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	stmts, err := yang.Parse(string(bs), file)
+	if err != nil {
+		return "", err
+	}
+	for _, stmt := range stmts {
+		if stmt.Keyword == "module" || stmt.Keyword == "submodule" {
+			return stmt.Argument, nil
+		}
+	}
+	return "", fmt.Errorf("no module or submodule statement found in %q", file)
+}
+
+// moduleEntries returns the entries defined by the module in the given file
+// keyed by their paths, as well as the versions of all modules parsed while
+// resolving its imports.
+func moduleEntries(paths []string, file string) (map[string]*yang.Entry, map[string]*semver.Version, error) {
+	name, err := fileModuleName(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	moduleEntryMap, errs := yangentry.Parse([]string{file}, paths)
+	if errs != nil {
+		return nil, nil, fmt.Errorf("%v", errs)
+	}
+
+	moduleVersions := map[string]*semver.Version{}
+	entryMap := map[string]*yang.Entry{}
+	for moduleName, entry := range moduleEntryMap {
+		moduleEntriesAux(entry, name, moduleName == name, entryMap)
+		if version, err := getOpenConfigModuleVersion(entry); err == nil {
+			moduleVersions[moduleName] = version
+		}
+	}
+	return entryMap, moduleVersions, nil
+}
+
+// moduleEntriesAux adds entry and its descendants to entryMap if they're
+// defined by the given module. inModule indicates that entry is within a
+// subtree defined by the module, which may include nodes from groupings
+// defined in other modules.
+func moduleEntriesAux(entry *yang.Entry, moduleName string, inModule bool, entryMap map[string]*yang.Entry) {
+	if !inModule {
+		if m := yang.RootNode(entry.Node); m != nil && m.Name == moduleName {
+			inModule = true
+		}
+	}
+	if inModule {
+		entryMap[entry.Path()] = entry
+	}
+	for _, entry := range entry.Dir {
+		moduleEntriesAux(entry, moduleName, inModule, entryMap)
+	}
+}
+
+// compressPath returns the path under OpenConfig path compression, i.e.
+// without its config and state containers.
+func compressPath(path string) string {
+	elems := strings.Split(path, "/")
+	compressed := make([]string, 0, len(elems))
+	for i, elem := range elems {
+		if i != len(elems)-1 && (elem == "config" || elem == "state") {
+			continue
+		}
+		compressed = append(compressed, elem)
+	}
+	return strings.Join(compressed, "/")
+}
+
+// pathContainer returns the name of the container of the schema path, e.g.
+// "config" or "state".
+func pathContainer(path string) string {
+	elems := strings.Split(path, "/")
+	if len(elems) < 2 {
+		return ""
+	}
+	return elems[len(elems)-2]
+}
+
+// compressedEntries returns the entries keyed by compressed path. Of the
+// entries sharing a compressed path, a writable (i.e. config) entry is
+// preferred, such that a compressed path is writable if any of its entries
+// are.
+func compressedEntries(entries map[string]*yang.Entry) map[string]*yang.Entry {
+	compressed := map[string]*yang.Entry{}
+	for path, e := range entries {
+		cpath := compressPath(path)
+		if prev, ok := compressed[cpath]; ok && (!prev.ReadOnly() || e.ReadOnly()) {
+			continue
+		}
+		compressed[cpath] = e
+	}
+	return compressed
+}
+
+// compressed returns the report of the same changes with compressed paths
+// (see WithCompressedPaths).
+//
+// A deleted path whose compressed path still exists (e.g. a leaf moved from
+// the config to the state container, or a config leaf deleted while its state
+// leaf remains) is reported as becoming read-only if it was writable, and is
+// otherwise unchanged under compression, with its other changes. Similarly, an added path whose
+// compressed path existed isn't reported unless it's a breaking addition.
+func (r *DiffReport) compressed() *DiffReport {
+	r.Sort()
+	c := &DiffReport{
+		oldModuleVersions: r.oldModuleVersions,
+		newModuleVersions: r.newModuleVersions,
+		oldEntries:        r.oldEntries,
+		newEntries:        r.newEntries,
+	}
+	oldCompressed, newCompressed := compressedEntries(r.oldEntries), compressedEntries(r.newEntries)
+
+	// The updates of paths sharing a compressed path are merged, such that
+	// no change is lost, and only identical changes are reported once.
+	updated := map[string]*yangNodeUpdateInfo{}
+	addUpdate := func(upd *yangNodeUpdateInfo) {
+		prev, ok := updated[upd.path]
+		if !ok {
+			updated[upd.path] = upd
+			c.updatedNodes = append(c.updatedNodes, upd)
+			return
+		}
+		prev.incompatAllowed = prev.incompatAllowed && upd.incompatAllowed
+		for _, comment := range upd.incompatComments {
+			if !slices.Contains(prev.incompatComments, comment) {
+				prev.incompatComments = append(prev.incompatComments, comment)
+			}
+		}
+	}
+	// Changes that differ between the paths sharing a compressed path are
+	// qualified by the container of the path they were made to, e.g.
+	// "state: type changed from uint16 to uint64".
+	differing := map[string]bool{}
+	firstComments := map[string][]string{}
+	for _, upd := range r.updatedNodes {
+		cpath := compressPath(upd.path)
+		if comments, ok := firstComments[cpath]; !ok {
+			firstComments[cpath] = upd.incompatComments
+		} else if !slices.Equal(comments, upd.incompatComments) {
+			differing[cpath] = true
+		}
+	}
+	for _, upd := range r.updatedNodes {
+		cupd := *upd
+		cupd.path = compressPath(upd.path)
+		cupd.incompatComments = nil
+		for _, comment := range upd.incompatComments {
+			if differing[cupd.path] {
+				comment = pathContainer(upd.path) + ": " + comment
+			}
+			cupd.incompatComments = append(cupd.incompatComments, comment)
+		}
+		addUpdate(&cupd)
+	}
+
+	deleted := map[string]bool{}
+	for _, del := range r.deletedNodes {
+		cpath := compressPath(del.path)
+		newEntry := newCompressed[cpath]
+		switch {
+		case deleted[cpath]:
+		case newEntry == nil:
+			deleted[cpath] = true
+			cdel := *del
+			cdel.path = cpath
+			c.deletedNodes = append(c.deletedNodes, &cdel)
+		case !oldCompressed[cpath].ReadOnly() && newEntry.ReadOnly():
+			addUpdate(&yangNodeUpdateInfo{
+				path:              cpath,
+				oldSchema:         oldCompressed[cpath],
+				newSchema:         newEntry,
+				incompatAllowed:   del.incompatAllowed,
+				versionChangeDesc: del.versionChangeDesc,
+				incompatComments:  []string{"became read-only"},
+			})
+		}
+	}
+
+	added := map[string]bool{}
+	for _, add := range r.newNodes {
+		cpath := compressPath(add.path)
+		if added[cpath] || oldCompressed[cpath] != nil && len(add.incompatComments) == 0 {
+			continue
+		}
+		added[cpath] = true
+		cadd := *add
+		cadd.path = cpath
+		c.newNodes = append(c.newNodes, &cadd)
+	}
+	return c
+}
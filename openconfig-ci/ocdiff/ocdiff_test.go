@@ -19,6 +19,9 @@ import (
 	"os"
 	"testing"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/models-ci/yangutil"
 	"github.com/openconfig/ygot/testutil"
 )
@@ -34,7 +37,23 @@ func getAllYANGFilesTest(t *testing.T, path string) []string {
 	return files
 }
 
+func readReleaseHistoryTest(t *testing.T, file string) *ReleaseHistory {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	h, err := ParseReleaseHistory(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
 func TestDiffReport(t *testing.T) {
+	releaseHistory := readReleaseHistoryTest(t, "testdata/release-history.txt")
+
 	tests := []struct {
 		name     string
 		inOpts   []Option
@@ -61,6 +80,31 @@ func TestDiffReport(t *testing.T) {
 			WithDisallowedIncompatsOnly(),
 		},
 		wantFile: "testdata/github-comment-disallowed-incompats.txt",
+	}, {
+		name: "all-incompats",
+		inOpts: []Option{
+			WithAllIncompats(),
+		},
+		wantFile: "testdata/all-incompats.txt",
+	}, {
+		name: "release-history",
+		inOpts: []Option{
+			WithReleaseHistory(releaseHistory, "v4.0.0"),
+		},
+		wantFile: "testdata/release-history-report.txt",
+	}, {
+		name: "github-comment-release-history-no-next-release",
+		inOpts: []Option{
+			WithGithubCommentStyle(),
+			WithReleaseHistory(releaseHistory, ""),
+		},
+		wantFile: "testdata/github-comment-release-history.txt",
+	}, {
+		name: "compressed-paths",
+		inOpts: []Option{
+			WithCompressedPaths(),
+		},
+		wantFile: "testdata/compressed-paths.txt",
 	}}
 
 	for _, tt := range tests {
@@ -90,3 +134,171 @@ func TestDiffReport(t *testing.T) {
 		})
 	}
 }
+
+func TestModuleDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		inOldFile string
+		inNewFile string
+		inOpts    []Option
+		wantFile  string
+		wantErr   bool
+	}{{
+		name:      "port",
+		inOldFile: "testdata/yang/old/platform/openconfig-platform-port.yang",
+		inNewFile: "testdata/yang/new/platform/openconfig-platform-port.yang",
+		wantFile:  "testdata/module-diff-port.txt",
+	}, {
+		name:      "port-all-incompats",
+		inOldFile: "testdata/yang/old/platform/openconfig-platform-port.yang",
+		inNewFile: "testdata/yang/new/platform/openconfig-platform-port.yang",
+		inOpts: []Option{
+			WithAllIncompats(),
+		},
+		wantFile: "testdata/module-diff-port-all-incompats.txt",
+	}, {
+		name:      "linecard",
+		inOldFile: "testdata/yang/old/platform/openconfig-platform-linecard.yang",
+		inNewFile: "testdata/yang/new/platform/openconfig-platform-linecard.yang",
+		wantFile:  "testdata/module-diff-linecard.txt",
+	}, {
+		name:      "linecard-disallowed-incompats",
+		inOldFile: "testdata/yang/old/platform/openconfig-platform-linecard.yang",
+		inNewFile: "testdata/yang/new/platform/openconfig-platform-linecard.yang",
+		inOpts: []Option{
+			WithDisallowedIncompatsOnly(),
+		},
+		wantFile: "testdata/module-diff-linecard-disallowed-incompats.txt",
+	}, {
+		name:      "mandatory",
+		inOldFile: "testdata/mandatory/old/openconfig-mandatory-test.yang",
+		inNewFile: "testdata/mandatory/new/openconfig-mandatory-test.yang",
+		wantFile:  "testdata/module-diff-mandatory.txt",
+	}, {
+		name:      "mandatory-disallowed-incompats",
+		inOldFile: "testdata/mandatory/old/openconfig-mandatory-test.yang",
+		inNewFile: "testdata/mandatory/new/openconfig-mandatory-test.yang",
+		inOpts: []Option{
+			WithDisallowedIncompatsOnly(),
+		},
+		wantFile: "testdata/module-diff-mandatory-disallowed-incompats.txt",
+	}, {
+		name:      "namespace",
+		inOldFile: "testdata/namespace/old/openconfig-namespace-test.yang",
+		inNewFile: "testdata/namespace/new/openconfig-namespace-test.yang",
+		wantFile:  "testdata/module-diff-namespace.txt",
+	}, {
+		name:      "namespace-disallowed-incompats",
+		inOldFile: "testdata/namespace/old/openconfig-namespace-test.yang",
+		inNewFile: "testdata/namespace/new/openconfig-namespace-test.yang",
+		inOpts: []Option{
+			WithDisallowedIncompatsOnly(),
+		},
+		wantFile: "testdata/module-diff-namespace-disallowed-incompats.txt",
+	}, {
+		name:      "compressed",
+		inOldFile: "testdata/compressed/old/openconfig-compressed-test.yang",
+		inNewFile: "testdata/compressed/new/openconfig-compressed-test.yang",
+		wantFile:  "testdata/module-diff-compressed.txt",
+	}, {
+		name:      "compressed-paths",
+		inOldFile: "testdata/compressed/old/openconfig-compressed-test.yang",
+		inNewFile: "testdata/compressed/new/openconfig-compressed-test.yang",
+		inOpts: []Option{
+			WithCompressedPaths(),
+		},
+		wantFile: "testdata/module-diff-compressed-paths.txt",
+	}, {
+		name:      "compressed-paths-disallowed-incompats",
+		inOldFile: "testdata/compressed/old/openconfig-compressed-test.yang",
+		inNewFile: "testdata/compressed/new/openconfig-compressed-test.yang",
+		inOpts: []Option{
+			WithCompressedPaths(),
+			WithDisallowedIncompatsOnly(),
+		},
+		wantFile: "testdata/module-diff-compressed-paths-disallowed-incompats.txt",
+	}, {
+		name:      "file does not exist",
+		inOldFile: "testdata/yang/old/platform/openconfig-dne.yang",
+		inNewFile: "testdata/yang/new/platform/openconfig-platform-port.yang",
+		wantErr:   true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := NewModuleDiff(tt.inOldFile, tt.inNewFile, []string{"testdata/yang/incl", "testdata/yang/new"})
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			gotReport := report.Report(tt.inOpts...)
+			wantFileBytes, rferr := os.ReadFile(tt.wantFile)
+			if rferr != nil {
+				t.Fatalf("os.ReadFile(%q) error: %v", tt.wantFile, rferr)
+			}
+
+			if wantReport := string(wantFileBytes); gotReport != wantReport {
+				if *updateGolden {
+					if err := os.WriteFile(tt.wantFile, []byte(gotReport), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				diff, _ := testutil.GenerateUnifiedDiff(wantReport, gotReport)
+				t.Errorf("did not return correct report (file: %v), diff:\n%s", tt.wantFile, diff)
+			}
+		})
+	}
+}
+
+func TestMajorVersionChanges(t *testing.T) {
+	report, err := NewDiffReport([]string{"testdata/yang/incl"}, []string{"testdata/yang/incl"}, getAllYANGFilesTest(t, "testdata/yang/old"), getAllYANGFilesTest(t, "testdata/yang/new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`"openconfig-platform-port": openconfig-version 1.0.1 -> 2.0.0`}
+	if diff := cmp.Diff(want, report.MajorVersionChanges()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestVersionDowngrades(t *testing.T) {
+	report, err := NewDiffReport([]string{"testdata/yang/incl"}, []string{"testdata/yang/incl"}, getAllYANGFilesTest(t, "testdata/yang/old"), getAllYANGFilesTest(t, "testdata/yang/new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := report.VersionDowngrades(); len(got) != 0 {
+		t.Errorf("got downgrades %v, want none", got)
+	}
+
+	// Reversing the diff downgrades the versions of the changed modules.
+	report, err = NewDiffReport([]string{"testdata/yang/incl"}, []string{"testdata/yang/incl"}, getAllYANGFilesTest(t, "testdata/yang/new"), getAllYANGFilesTest(t, "testdata/yang/old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`"openconfig-platform": openconfig-version 0.24.0 -> 0.23.0`,
+		`"openconfig-platform-linecard": openconfig-version 1.2.0 -> 1.1.0`,
+		`"openconfig-platform-port": openconfig-version 2.0.0 -> 1.0.1`,
+	}
+	if diff := cmp.Diff(want, report.VersionDowngrades()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestVersionDowngradesUnchangedNodes(t *testing.T) {
+	// openconfig-c is deleted, and openconfig-d's version is removed.
+	report := &DiffReport{
+		oldModuleVersions: map[string]*semver.Version{"openconfig-a": semver.MustParse("1.2.0"), "openconfig-b": semver.MustParse("1.2.0"), "openconfig-c": semver.MustParse("1.0.0"), "openconfig-d": semver.MustParse("0.3.1")},
+		newModuleVersions: map[string]*semver.Version{"openconfig-a": semver.MustParse("1.1.9"), "openconfig-b": semver.MustParse("1.2.1")},
+		newEntries:        map[string]*yang.Entry{"/openconfig-a": {Name: "openconfig-a"}, "/openconfig-b": {Name: "openconfig-b"}, "/openconfig-d": {Name: "openconfig-d"}},
+	}
+	want := []string{
+		`"openconfig-a": openconfig-version 1.2.0 -> 1.1.9`,
+		`"openconfig-d": openconfig-version 0.3.1 removed`,
+	}
+	if diff := cmp.Diff(want, report.VersionDowngrades()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
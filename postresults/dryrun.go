@@ -0,0 +1,107 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// githubClient is the subset of the GitHub API used to post the results,
+// which is implemented by commonci.GithubRequestHandler, and by dryRunGitHub
+// for dry runs.
+type githubClient interface {
+	CreateCIOutputGist(description, content string) (string, string, error)
+	AddGistComment(gistID, title, output string) (int64, error)
+	AddEditOrDeletePRComment(signature string, body *string, owner, repo string, prNumber int) error
+	UpdatePRStatus(update *commonci.GithubPRUpdate) error
+	CreateCheckRun(run *commonci.CheckRun) error
+	PostLabel(labelName, labelColor, owner, repo string, prNumber int) error
+	DeleteLabel(labelName, owner, repo string, prNumber int) error
+}
+
+// newGitHubClient returns the client posting the results to GitHub, which
+// prints them to stdout instead in a dry run.
+func newGitHubClient() (githubClient, error) {
+	if dryRun {
+		return &dryRunGitHub{w: os.Stdout}, nil
+	}
+	return commonci.NewGitHubRequestHandler()
+}
+
+// validatorResultsDir returns the results directory of the validator version
+// within the -results-dir directory.
+func validatorResultsDir(validatorId, version string) string {
+	return filepath.Join(resultsRoot, commonci.AppendVersionToName(validatorId, version))
+}
+
+// dryRunGitHub prints the gists, comments, labels and PR statuses that would
+// be posted to w instead of posting them.
+type dryRunGitHub struct {
+	w io.Writer
+	// commentID is the ID of the last gist comment.
+	commentID int64
+}
+
+// CreateCIOutputGist prints the gist that would be created, returning a
+// placeholder URL.
+func (d *dryRunGitHub) CreateCIOutputGist(description, content string) (string, string, error) {
+	fmt.Fprintf(d.w, "dry run: would create gist %q:\n%s\n", description, content)
+	return "https://gist.github.com/dry-run", "dry-run", nil
+}
+
+// AddGistComment prints the gist comment that would be added, returning
+// successive placeholder IDs.
+func (d *dryRunGitHub) AddGistComment(gistID, title, output string) (int64, error) {
+	d.commentID++
+	fmt.Fprintf(d.w, "dry run: would add gist comment %d to gist %s:\n# %s\n%s\n", d.commentID, gistID, title, output)
+	return d.commentID, nil
+}
+
+func (d *dryRunGitHub) AddEditOrDeletePRComment(signature string, body *string, owner, repo string, prNumber int) error {
+	if body == nil {
+		fmt.Fprintf(d.w, "dry run: would delete any comment %q on %s/%s#%d\n", signature, owner, repo, prNumber)
+		return nil
+	}
+	fmt.Fprintf(d.w, "dry run: would post comment %q on %s/%s#%d:\n%s\n", signature, owner, repo, prNumber, *body)
+	return nil
+}
+
+func (d *dryRunGitHub) UpdatePRStatus(update *commonci.GithubPRUpdate) error {
+	fmt.Fprintf(d.w, "dry run: would post %s status %q to %s/%s@%s: %s (%s)\n", update.NewStatus, update.Context, update.Owner, update.Repo, update.Ref, update.Description, update.URL)
+	return nil
+}
+
+func (d *dryRunGitHub) CreateCheckRun(run *commonci.CheckRun) error {
+	fmt.Fprintf(d.w, "dry run: would create %s check run %q on %s/%s@%s: %s (%s)\n%s\n", run.Conclusion, run.Name, run.Owner, run.Repo, run.HeadSHA, run.Title, run.DetailsURL, run.Summary)
+	for _, a := range run.Annotations {
+		fmt.Fprintf(d.w, "  %s %s:%d %s: %s\n", a.Level, a.Path, a.StartLine, a.Title, a.Message)
+	}
+	return nil
+}
+
+func (d *dryRunGitHub) PostLabel(labelName, labelColor, owner, repo string, prNumber int) error {
+	fmt.Fprintf(d.w, "dry run: would apply label %q (colour %s) to %s/%s#%d\n", labelName, labelColor, owner, repo, prNumber)
+	return nil
+}
+
+func (d *dryRunGitHub) DeleteLabel(labelName, owner, repo string, prNumber int) error {
+	fmt.Fprintf(d.w, "dry run: would remove label %q from %s/%s#%d\n", labelName, owner, repo, prNumber)
+	return nil
+}
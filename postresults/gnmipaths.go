@@ -0,0 +1,97 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/report"
+)
+
+const (
+	// gnmiPathsDir is the directory within the bucket where the gNMI path
+	// lists generated by the gnmi-paths validator are stored.
+	gnmiPathsDir = "gnmi-paths"
+	// shadowGNMIPathsDir is the staging directory within the bucket where
+	// the gNMI path lists are stored when running in shadow mode.
+	shadowGNMIPathsDir = "gnmi-paths-shadow"
+)
+
+// gnmiPathsObjectPrefix returns the prefix of the names of the objects storing
+// the repo's gNMI path lists at ref, which is a commit SHA, "master" or a
+// release tag.
+func gnmiPathsObjectPrefix(ref string) string {
+	dir := gnmiPathsDir
+	if commonci.ShadowMode {
+		dir = shadowGNMIPathsDir
+	}
+	return fmt.Sprintf("%s/%s/%s/", dir, strings.ReplaceAll(repoSlug, "/", "-"), ref)
+}
+
+// uploadGNMIPaths uploads the gNMI path list of each model within the
+// gnmi-paths results directory into cloud storage using client, keyed by the
+// commit SHA, and also keyed by latestRef if non-empty (i.e. as the latest path
+// lists of master, or those of a release). It returns the section of the
+// report linking to the uploaded path lists, or "" if there are none.
+func uploadGNMIPaths(ctx context.Context, client commonci.StorageClient, resultsDir, latestRef string) (string, error) {
+	pathListsDir := filepath.Join(resultsDir, commonci.PathListsDirName)
+	// os.ReadDir returns the entries in lexical order.
+	entries, err := os.ReadDir(pathListsDir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return "", nil
+	case err != nil:
+		return "", fmt.Errorf("failed to read path lists directory %q: %v", pathListsDir, err)
+	}
+
+	refs := []string{commitSHA}
+	if latestRef != "" {
+		refs = append(refs, latestRef)
+	}
+	var out strings.Builder
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".txt" {
+			continue
+		}
+		bs, err := os.ReadFile(filepath.Join(pathListsDir, name))
+		if err != nil {
+			return "", fmt.Errorf("failed to read path list: %v", err)
+		}
+		for _, ref := range refs {
+			if err := client.UploadPublic(ctx, gnmiPathsObjectPrefix(ref)+name, bs); err != nil {
+				return "", err
+			}
+		}
+		url, err := client.ObjectURL(ctx, gnmiPathsObjectPrefix(commitSHA)+name)
+		if err != nil {
+			return "", err
+		}
+		model := strings.ReplaceAll(strings.ReplaceAll(strings.TrimSuffix(name, ".txt"), "==", "/"), ":", "/")
+		out.WriteString(report.SprintLineHTML(`<a href="%s">%s</a>: %d paths`, html.EscapeString(url), report.EscapeOutput(model), strings.Count(string(bs), "\n")))
+	}
+	if out.Len() == 0 {
+		return "", nil
+	}
+	return report.SprintSummaryHTML("pass", "gNMI path lists", "%s", out.String()), nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/commonci"
+)
+
+func TestUploadGNMIPaths(t *testing.T) {
+	repoSlug, commitSHA = "openconfig/public", "abc"
+	defer func() { repoSlug, commitSHA = "", "" }()
+
+	resultsDir := t.TempDir()
+	if got, err := uploadGNMIPaths(context.Background(), &commonci.MemoryBucket{Bucket: bucketName}, resultsDir, ""); err != nil || got != "" {
+		t.Errorf("no path lists: got (%q, %v), want no report and no error", got, err)
+	}
+
+	pathListsDir := filepath.Join(resultsDir, commonci.PathListsDirName)
+	if err := os.Mkdir(pathListsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	pathList := "/acl/acl-sets/acl-set\n/acl/acl-sets/acl-set/config/name\n"
+	if err := os.WriteFile(filepath.Join(pathListsDir, "acl==openconfig-acl.txt"), []byte(pathList), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		inLatestRef string
+		wantObjects []string
+	}{{
+		name:        "PR",
+		wantObjects: []string{"gnmi-paths/openconfig-public/abc/acl==openconfig-acl.txt"},
+	}, {
+		name:        "push to master",
+		inLatestRef: "master",
+		wantObjects: []string{
+			"gnmi-paths/openconfig-public/abc/acl==openconfig-acl.txt",
+			"gnmi-paths/openconfig-public/master/acl==openconfig-acl.txt",
+		},
+	}, {
+		name:        "release",
+		inLatestRef: "v1.2.0",
+		wantObjects: []string{
+			"gnmi-paths/openconfig-public/abc/acl==openconfig-acl.txt",
+			"gnmi-paths/openconfig-public/v1.2.0/acl==openconfig-acl.txt",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			client := &commonci.MemoryBucket{Bucket: bucketName}
+			got, err := uploadGNMIPaths(ctx, client, resultsDir, tt.inLatestRef)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := `<details>
+  <summary>&#x2705;&nbsp; gNMI path lists</summary>
+  <li><a href="https://storage.googleapis.com/openconfig/gnmi-paths/openconfig-public/abc/acl==openconfig-acl.txt">acl/openconfig-acl</a>: 2 paths</li>
+</details>
+`
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+			for _, object := range tt.wantObjects {
+				bs, err := client.Download(ctx, object)
+				if err != nil {
+					t.Fatalf("object %q not uploaded: %v", object, err)
+				}
+				if diff := cmp.Diff(pathList, string(bs)); diff != "" {
+					t.Errorf("object %q (-want, +got):\n%s", object, diff)
+				}
+			}
+			if tt.inLatestRef != "master" {
+				if _, err := client.Download(ctx, "gnmi-paths/openconfig-public/master/acl==openconfig-acl.txt"); !errors.Is(err, commonci.ErrObjectNotExist) {
+					t.Errorf("latest path list: got error %v, want it not uploaded", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1330 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package postresults implements post_results, which posts the results of
+// the validators run by the CI to the PR and uploads their badges.
+package postresults
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"log"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/report"
+	civersion "github.com/openconfig/models-ci/version"
+
+	rpb "github.com/openconfig/models-ci/proto/reporter"
+)
+
+// post_results posts the CI results for a given tool using the output from
+// running the tool's script generated by cmd_gen. The location of the results
+// is determined by common_ci.
+
+const (
+	// bucketName is the Google storage bucket name.
+	bucketName = commonci.BucketName
+	// badgeDir is the directory within the bucket where badges are stored.
+	badgeDir = "compatibility-badges"
+	// shadowBadgeDir is the staging directory within the bucket where
+	// badges are stored when running in shadow mode.
+	shadowBadgeDir = "compatibility-badges-shadow"
+	// reportDir is the directory within the bucket where the reports of a
+	// commit's validators are stored if the results can't be public.
+	reportDir = "reports"
+	// shadowReportDir is the staging directory within the bucket where
+	// reports are stored when running in shadow mode.
+	shadowReportDir = "reports-shadow"
+	// imageDigestEnvVar is the environment variable that, if set, contains
+	// the digest of the container image running the validators.
+	imageDigestEnvVar = "MODELS_CI_IMAGE_DIGEST"
+	// infraDegradedSignature identifies the PR comment reporting degraded CI
+	// infrastructure.
+	infraDegradedSignature = "CI infrastructure degraded for commit"
+	// infraStatusContext is the PR status context reporting degraded CI
+	// infrastructure, prefixed by commonci.StatusContextPrefix.
+	infraStatusContext = "CI Infrastructure"
+)
+
+var (
+	// flags: should be string if it may not exist.
+	validatorId   string // validatorId is the unique name identifying the validator (see commonci for all of them)
+	repoSlug      string // repoSlug is the "owner/repo" name of the models repo (e.g. openconfig/public).
+	prNumberStr   string // prNumberStr is the PR number.
+	branchName    string // branchName is the name of the branch where the commit occurred.
+	defaultBranch string // defaultBranch, if set, overrides the default branch of the models repo relayed by cmd_gen.
+	commitSHA     string
+	version       string // version is a specific version of the validator that's being run (empty means latest).
+	compatReports string // e.g. "goyang-ygot,pyangbind,pyang@1.7.8"
+	// compatReportGating is the subset of compatReports that gates merge.
+	compatReportGating string // e.g. "goyang-ygot"
+
+	// compatReportsOverride indicates that compatReports was supplied as a
+	// flag, overriding the compatibility report relayed by cmd_gen.
+	compatReportsOverride bool
+
+	// derived flags
+	owner    string
+	repo     string
+	prNumber int
+
+	// uploadDryRun indicates to only log badge and report uploads.
+	uploadDryRun bool
+	// dryRun indicates to print what would be posted to GitHub instead of
+	// posting it, and to only log uploads.
+	dryRun bool
+	// resultsRoot is the directory containing the results directory of
+	// each validator.
+	resultsRoot string
+	// signedURLDuration, if non-zero, keeps uploads private, linking to them
+	// with URLs signed for this duration, using the private key in
+	// signingKeyFile if set.
+	signedURLDuration time.Duration
+	signingKeyFile    string
+	// localBucketDir, if set, is a local directory emulating the storage
+	// bucket, into which badges and reports are written instead.
+	localBucketDir string
+	// storageClient uploads badges and reports to cloud storage.
+	storageClient commonci.StorageClient
+
+	// watch runs post_results as the reporter service, posting the
+	// results of each validator as soon as it's done.
+	watch         bool
+	watchInterval time.Duration // watchInterval is the time between scans of the results directory.
+	watchTimeout  time.Duration // watchTimeout is the time after which the reporter service gives up.
+	exited        bool          // exited marks the validator's step as exited for the reporter service.
+
+	// serveAddr, if set, is the address on which to serve the gRPC
+	// reporter service, to which validators submit their results.
+	serveAddr string
+	// reporterAddr, if set, is the address of the gRPC reporter service to
+	// which to submit the results instead of posting them.
+	reporterAddr string
+	reporterTLS  bool // reporterTLS indicates to connect to reporterAddr using TLS.
+	// reporterTokenFile is the file containing the per-run token that
+	// authenticates calls to the gRPC reporter service.
+	reporterTokenFile string
+	// finalizeReporterRun indicates to finalize the run of the reporter
+	// service at reporterAddr.
+	finalizeReporterRun bool
+
+	// checkRuns indicates to also post each validator's result as a check
+	// run annotating the lines of its messages.
+	checkRuns bool
+
+	// retargetStatuses indicates to copy the final statuses of the merged
+	// PR's head commit onto its merge commit on the default branch.
+	retargetStatuses bool
+)
+
+// makeBadge creates an SVG status badge using the badge-maker CLI. It is a
+// variable such that it can be replaced in tests.
+var makeBadge = func(ctx context.Context, status, validatorDesc, colour string) ([]byte, error) {
+	out, err := exec.CommandContext(ctx, "badge", status, validatorDesc, ":"+colour).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create badge: %v", err)
+	}
+	return out, nil
+}
+
+// flagSet contains the flags of post_results, which are parsed by Main.
+var flagSet = flag.NewFlagSet("post_results", flag.ExitOnError)
+
+func init() {
+	flagSet.StringVar(&validatorId, "validator", "", "unique name of the validator")
+	flagSet.StringVar(&report.ModelRoot, "modelRoot", "", "comma-separated list of root directories to OpenConfig models")
+	flagSet.StringVar(&repoSlug, "repo-slug", "", "repo where CI is run")
+	flagSet.StringVar(&prNumberStr, "pr-number", "", "PR number")
+	flagSet.StringVar(&branchName, "branch", "", "branch name of commit")
+	flagSet.StringVar(&defaultBranch, "default-branch", "", "(optional) default branch of the models repo (e.g. main), pushes to which upload the badges, overriding the branch relayed by cmd_gen.")
+	flagSet.StringVar(&commitSHA, "commit-sha", "", "commit SHA of the PR")
+	flagSet.StringVar(&version, "version", "", "(optional) specific version of the validator tool.")
+	flagSet.StringVar(&compatReports, "compat-report", "", "(optional) comma-separated validators (e.g. goyang-ygot,pyang@1.7.8,pyang@head) in the compatibility report, overriding the list relayed by cmd_gen. Useful when re-running a posting step standalone.")
+	flagSet.StringVar(&compatReportGating, "compat-report-gating", "", "(optional) comma-separated validators within -compat-report that gate merge. Only used with -compat-report.")
+	flagSet.BoolVar(&uploadDryRun, "upload-dry-run", false, "(optional) only log the uploads of badges and reports to cloud storage.")
+	flagSet.BoolVar(&dryRun, "dry-run", false, "(optional) print the gists, comments, labels and PR statuses that would be posted instead of making any GitHub calls (so GITHUB_ACCESS_TOKEN isn't needed), and only log uploads as with -upload-dry-run. -retarget-statuses isn't supported.")
+	flagSet.StringVar(&resultsRoot, "results-dir", commonci.ResultsDir, "(optional) directory containing the results directory of each validator, e.g. for a dry run on results produced outside of the CI workspace")
+	flagSet.BoolVar(&watch, "watch", false, "(optional) run as the long-lived reporter service enabled by cmd_gen -reporter-service: watch the results directory, posting the results of each validator as soon as it's done, until all are posted. -validator and -version are ignored.")
+	flagSet.BoolVar(&exited, "mark-exited", false, "(optional) mark the validator step running -validator as exited for the -watch reporter service, which then doesn't wait for those of its results directories not marked as done; called by each test.sh's exit trap. Other flags are ignored.")
+	flagSet.DurationVar(&watchInterval, "watch-interval", 5*time.Second, "(optional) time between scans of the results directory with -watch.")
+	flagSet.DurationVar(&watchTimeout, "watch-timeout", 90*time.Minute, "(optional) time after which to stop waiting for validators to complete with -watch.")
+	flagSet.StringVar(&serveAddr, "serve", "", "(optional) run as the gRPC reporter service listening on the given address (e.g. \":8080\"), posting the results of each validator as soon as they're submitted, until the run is finalized or -watch-timeout elapses. -validator and -version are ignored.")
+	flagSet.StringVar(&reporterAddr, "reporter-addr", "", "(optional) address of the gRPC reporter service (see -serve) to which to submit the validator's results instead of posting them.")
+	flagSet.BoolVar(&reporterTLS, "reporter-tls", false, "(optional) connect to -reporter-addr using TLS.")
+	flagSet.StringVar(&reporterTokenFile, "reporter-token-file", "", "file containing the per-run token (at least 32 characters, e.g. from \"openssl rand -hex 32\") with which the clients of the gRPC reporter service authenticate; required by -serve and -reporter-addr.")
+	flagSet.BoolVar(&finalizeReporterRun, "finalize-run", false, "(optional) finalize the run of the reporter service at -reporter-addr, failing if it failed to post the results of any validator. -validator and -version are ignored.")
+	flagSet.BoolVar(&retargetStatuses, "retarget-statuses", false, "(optional) on a push to the default branch, copy the final validator statuses of the merged PR's head commit onto -commit-sha (e.g. a squash merge's commit), such that the branch's commit history shows CI state without waiting for the push run. -validator and -version are ignored.")
+	flagSet.BoolVar(&checkRuns, "check-runs", false, "(optional) also post each validator's result as a GitHub check run, which annotates the lines of the errors and warnings of validators with structured output inline in the PR's changed files. Requires a GitHub App installation token given by $GITHUB_CHECKS_TOKEN, which is only used for check runs.")
+	flagSet.DurationVar(&signedURLDuration, "signed-url-duration", 0, "(optional) for models repos whose results can't be public, don't make uploads to cloud storage publicly readable, and instead link to them with URLs signed for this duration (at most 168h, or 12h without -signing-key-file). PR statuses then link to the uploaded reports of the validators instead of gists, which anyone with their URL can read.")
+	flagSet.StringVar(&signingKeyFile, "signing-key-file", "", "(optional) service account private key file signing the URLs with -signed-url-duration, instead of the environment's service account.")
+	flagSet.StringVar(&localBucketDir, "local-bucket-dir", "", "(optional) local directory emulating the cloud storage bucket, into which badges and reports are written instead.")
+}
+
+// reportFooter returns the footer appended to each posted report, which
+// identifies the versions of models-ci and of the validator (as returned by
+// report.Heading), as well as the container image, that produced the report,
+// such that the results are reproducible.
+func reportFooter(validatorDesc string) string {
+	parts := []string{"models-ci " + civersion.String(), validatorDesc}
+	if digest := os.Getenv(imageDigestEnvVar); digest != "" {
+		parts = append(parts, "image "+digest)
+	}
+	return fmt.Sprintf("\n<sub>Generated by %s</sub>\n", strings.Join(parts, ", "))
+}
+
+// withBanner prepends the configured banner, if any, to the report.
+func withBanner(report string) string {
+	if commonci.Banner == "" {
+		return report
+	}
+	return commonci.Banner + "\n\n---\n\n" + report
+}
+
+// runChanges stores the parsed results of the per-model validator for this
+// run on the PR, and returns the section reporting the changes since the PR's
+// previous run, or "" if there isn't one. Since the section is informational,
+// errors are only logged.
+func runChanges(ctx context.Context, validatorId, version, resultsDir string) string {
+	cur, err := commonci.NewRunResults(commitSHA, resultsDir)
+	if err != nil {
+		log.Printf("INFO: could not parse run results for %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+		return ""
+	}
+	prev, err := commonci.StoreRunResults(ctx, storageClient, repoSlug, prNumber, validatorId, version, cur)
+	if err != nil {
+		log.Printf("INFO: could not store run results for %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+		return ""
+	}
+	if prev == nil {
+		return ""
+	}
+	return runChangesHTML(prev, cur)
+}
+
+// runChangesHTML returns the section reporting the model failures fixed and
+// introduced since the previous run prev.
+func runChangesHTML(prev, cur *commonci.RunResults) string {
+	fixed, introduced := cur.ChangesSince(prev)
+	var out strings.Builder
+	for _, model := range fixed {
+		out.WriteString(report.SprintLineHTML("%s fixed: %s", commonci.Emoji("pass"), report.EscapeOutput(model)))
+	}
+	for _, model := range introduced {
+		out.WriteString(report.SprintLineHTML("%s new failure: %s", commonci.Emoji("fail"), report.EscapeOutput(model)))
+	}
+	if out.Len() == 0 {
+		out.WriteString("No changes in model results.\n")
+	}
+	sha := prev.CommitSHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	return report.SprintSummaryHTML(commonci.BoolStatusToString(len(introduced) == 0), fmt.Sprintf("changes since last run (%s)", report.EscapeOutput(sha)), "%s", out.String())
+}
+
+// badgeObjectPrefix returns the prefix of the names of the objects uploaded
+// for the repo's badges, which are versioned by the release tag, if any.
+func badgeObjectPrefix() string {
+	dir := badgeDir
+	if commonci.ShadowMode {
+		dir = shadowBadgeDir
+	}
+	// Make repo slug safe for use as file name.
+	slug := strings.ReplaceAll(repoSlug, "/", "-")
+	if commonci.ReleaseTag != "" {
+		slug += "@" + strings.ReplaceAll(commonci.ReleaseTag, "/", "-")
+	}
+	return fmt.Sprintf("%s/%s:", dir, slug)
+}
+
+// latestRef returns the ref, other than the commit SHA, under which to also
+// upload artifacts of a push: the default branch on a push to it, or the
+// release tag. It is empty for a PR.
+func latestRef() string {
+	switch {
+	case prNumber != 0:
+		return ""
+	case commonci.ReleaseTag != "":
+		return strings.ReplaceAll(commonci.ReleaseTag, "/", "-")
+	default:
+		return strings.ReplaceAll(commonci.DefaultBranch, "/", "-")
+	}
+}
+
+// uploadBadge uploads a status badge for the given validator and result into
+// cloud storage using client, along with the reports it links to keyed by
+// their file names. If counts is non-nil, then the badge also displays the
+// number of errors and warnings.
+func uploadBadge(ctx context.Context, client commonci.StorageClient, validatorDesc, validatorUniqueStr string, pass bool, counts *report.MessageCounts, reports map[string]string) error {
+	status := "fail"
+	colour := "red"
+	if pass {
+		status = "pass"
+		colour = "brightgreen"
+	}
+	if counts != nil {
+		status = fmt.Sprintf("%s, %d errors / %d warnings", status, counts.Errors, counts.Warnings)
+	}
+	badge, err := makeBadge(ctx, status, validatorDesc, colour)
+	if err != nil {
+		return err
+	}
+
+	prefix := badgeObjectPrefix()
+	if err := client.UploadPublic(ctx, prefix+validatorUniqueStr+".svg", badge); err != nil {
+		return err
+	}
+	var names []string
+	for name := range reports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := client.UploadPublic(ctx, prefix+name, []byte(reports[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// privateResults returns whether the results can't be public, in which case
+// PR statuses link to reports uploaded into cloud storage with signed URLs
+// rather than to gists, which anyone with their URL can read.
+func privateResults() bool {
+	return signedURLDuration != 0
+}
+
+// reportObjectPrefix returns the prefix of the names of the objects storing
+// the reports of the validators of the commit being validated.
+func reportObjectPrefix() string {
+	dir := reportDir
+	if commonci.ShadowMode {
+		dir = shadowReportDir
+	}
+	return fmt.Sprintf("%s/%s/%s/", dir, strings.ReplaceAll(repoSlug, "/", "-"), commitSHA)
+}
+
+// reportPage returns the uploaded page of the validator's results and its
+// execution output.
+func reportPage(result, runOutput, validatorDesc string) string {
+	return fmt.Sprintf("<p>%s</p><span style=\"white-space: pre-line\"><p>Execution output:\n%s</p></span>%s", result, runOutput, reportFooter(validatorDesc))
+}
+
+// uploadReport uploads the named page under reportObjectPrefix into cloud
+// storage using client, returning its URL.
+func uploadReport(ctx context.Context, client commonci.StorageClient, name, page string) (string, error) {
+	object := reportObjectPrefix() + name
+	if err := client.UploadPublic(ctx, object, []byte(page)); err != nil {
+		return "", commonci.WithExitCode(commonci.ExitInfraError, fmt.Errorf("couldn't upload report %s: %w", object, err))
+	}
+	url, err := client.ObjectURL(ctx, object)
+	if err != nil {
+		return "", commonci.WithExitCode(commonci.ExitInfraError, fmt.Errorf("couldn't get the URL of report %s: %w", object, err))
+	}
+	return url, nil
+}
+
+// uploadResultReports uploads the condensed and full reports of the validator
+// version in place of its gist, returning the URL of the condensed report,
+// which links to the full one. allLevelsResult, if non-empty, is the result
+// including the messages filtered from result.
+func uploadResultReports(ctx context.Context, client commonci.StorageClient, validatorUniqueStr, validatorDesc, runOutput, result, condensedResult, allLevelsResult string) (string, error) {
+	if allLevelsResult != "" {
+		result = allLevelsResult
+	}
+	fullURL, err := uploadReport(ctx, client, fullOutputFileName(validatorUniqueStr), reportPage(result, runOutput, validatorDesc))
+	if err != nil {
+		return "", err
+	}
+	return uploadReport(ctx, client, validatorUniqueStr+".html", reportPage(condensedResult+fullOutputLink(fullURL), runOutput, validatorDesc))
+}
+
+// fullOutputFileName returns the name of the uploaded file containing the
+// full output of the validator, which is linked by its condensed output.
+func fullOutputFileName(validatorUniqueStr string) string {
+	return validatorUniqueStr + "-full.html"
+}
+
+// fullOutputLink returns the link appended to condensed results pointing to
+// the full results at url.
+func fullOutputLink(url string) string {
+	return fmt.Sprintf("\n<p><a href=\"%s\">View full output</a></p>\n", url)
+}
+
+// readCompatReport returns the compatibility report relayed by cmd_gen, or
+// the one specified by flags if overridden.
+func readCompatReport() (*commonci.CompatReport, error) {
+	if compatReportsOverride {
+		return commonci.NewCompatReport(compatReports, compatReportGating)
+	}
+	plan, err := commonci.ReadPlan(commonci.PlanFile)
+	switch {
+	case err != nil:
+		return nil, err
+	case plan == nil:
+		log.Printf("warning: plan file %q not found, assuming no validators are in the compatibility report", commonci.PlanFile)
+		return &commonci.CompatReport{FormatVersion: commonci.CompatReportFormatVersion, Members: []commonci.CompatReportMember{}}, nil
+	}
+	return plan.CompatReport, nil
+}
+
+// plannedCacheKey returns the result cache inputs key of the validator's run
+// as relayed by cmd_gen, which is empty if its results aren't cacheable.
+func plannedCacheKey(validatorId, version string) (string, error) {
+	plan, err := commonci.ReadPlan(commonci.PlanFile)
+	if err != nil || plan == nil {
+		return "", err
+	}
+	for _, v := range plan.Validators {
+		if v.ValidatorId == validatorId && v.Version == version {
+			return v.CacheKey, nil
+		}
+	}
+	return "", nil
+}
+
+// cacheable returns why the results within resultsDir mustn't be reused by
+// later runs, or "" if they may be: only the results of a validator script
+// that completed normally, without timed-out models, are cached, since a
+// failure of the infra or the tool installation isn't a result of the inputs.
+func cacheable(resultsDir string) (string, error) {
+	if _, err := os.Stat(filepath.Join(resultsDir, commonci.FailFileName)); err == nil {
+		return "the validator script failed", nil
+	}
+	if bs, err := os.ReadFile(filepath.Join(resultsDir, commonci.ExpectedModelCountFileName)); err == nil {
+		expected, err := strconv.Atoi(strings.TrimSpace(string(bs)))
+		if err != nil {
+			return "", fmt.Errorf("invalid expected model count %q: %v", bs, err)
+		}
+		completed, err := os.ReadFile(filepath.Join(resultsDir, commonci.CompletedModelsFileName))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if n := strings.Count(string(completed), "\n"); n != expected {
+			return fmt.Sprintf("%d of %d models completed", n, expected), nil
+		}
+	}
+	it, err := commonci.NewResultsIterator(resultsDir)
+	if err != nil {
+		return "", err
+	}
+	for it.Next() {
+		if r := it.Result(); r.TimedOut() {
+			return fmt.Sprintf("model %s in %s timed out", r.Model, r.ModelDir), nil
+		}
+	}
+	return "", it.Err()
+}
+
+// cacheResults uploads the results within resultsDir to the private result
+// cache, keyed by the run's inputs key and the tool version described by its
+// commonci.LatestVersionFileName. Results that were themselves restored from
+// the result cache, or that aren't cacheable, aren't uploaded.
+func cacheResults(ctx context.Context, client commonci.StorageClient, inputsKey, resultsDir string) error {
+	if _, err := os.Stat(filepath.Join(resultsDir, commonci.ResultCacheHitFileName)); err == nil {
+		log.Printf("results within %s were restored from the result cache", resultsDir)
+		return nil
+	}
+	reason, err := cacheable(resultsDir)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		log.Printf("not caching the results within %s: %s", resultsDir, reason)
+		return nil
+	}
+	toolVersion, err := os.ReadFile(filepath.Join(resultsDir, commonci.LatestVersionFileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	archive, err := commonci.ArchiveResults(resultsDir)
+	if err != nil {
+		return err
+	}
+	return client.UploadPrivate(ctx, commonci.ResultCacheObject(commonci.ResultCacheKey(inputsKey, toolVersion)), archive)
+}
+
+// postCompatibilityReport posts the results for the validators to be reported
+// under a compatibility report in their display order. If any validators gate
+// merge, then a PR status is also posted, which fails if any of them failed.
+func postCompatibilityReport(compatReport *commonci.CompatReport) error {
+	members := compatReport.OrderedMembers()
+	if len(members) == 0 {
+		log.Printf("Skipping compatibility report -- no validator to report.")
+		return nil
+	}
+
+	validator, ok := commonci.Validators["compat-report"]
+	if !ok {
+		return fmt.Errorf("CI infra failure: compatibility report validator not found in commonci.Validators")
+	}
+
+	// Get the combined execution output, as well as each validator's header description.
+	var executionOutput string
+	var validatorDescs []string
+	for _, vv := range members {
+		resultsDir := validatorResultsDir(vv.ValidatorId, vv.Version)
+
+		validatorDesc, content, err := report.Heading(vv.ValidatorId, vv.Version, resultsDir)
+		if err != nil {
+			return fmt.Errorf("postResult: %v", err)
+		}
+		executionOutput += validatorDesc + ":\n" + content + "\n"
+		validatorDescs = append(validatorDescs, validatorDesc)
+	}
+
+	// Parse the test results of the validators.
+	results := make([]*compatResult, len(members))
+	for i, vv := range members {
+		resultsDir := validatorResultsDir(vv.ValidatorId, vv.Version)
+		testResultString, pass, _, err := report.Result(vv.ValidatorId, resultsDir, commonci.CondensedReport, 0)
+		if err != nil {
+			return fmt.Errorf("postResult: couldn't parse results for <%s>@<%s> in resultsDir %q: %v", vv.ValidatorId, vv.Version, resultsDir, err)
+		}
+		results[i] = &compatResult{desc: validatorDescs[i], pass: pass, result: testResultString}
+	}
+
+	var g githubClient
+	var err error
+	if err := commonci.Retry(5, "NewGitHubRequestHandler", func() error {
+		g, err = newGitHubClient()
+		return err
+	}); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("postCompatibilityReport: %w", err))
+	}
+	// reportURL is the URL of the whole report, and resultURLs are the URLs
+	// of each validator's results within it.
+	var reportURL string
+	var resultURLs []string
+	if privateResults() {
+		if reportURL, err = uploadReport(context.Background(), storageClient, validator.Name+".html", compatReportPage(results, executionOutput)); err != nil {
+			return fmt.Errorf("postCompatibilityReport: %w", err)
+		}
+		for i := range results {
+			resultURLs = append(resultURLs, fmt.Sprintf("%s#%s", reportURL, compatResultAnchor(i)))
+		}
+	} else if reportURL, resultURLs, err = postCompatGist(g, validator.Name, executionOutput, results); err != nil {
+		return fmt.Errorf("postCompatibilityReport: %w", err)
+	}
+
+	// Build a PR comment to be posted on the PR page linking to each
+	// validator's gist comment.
+	var commentBuilder strings.Builder
+	commentBuilder.WriteString(fmt.Sprintf("Compatibility Report for commit %s:\n", commitSHA))
+	var gatingFailures []string
+	for i, vv := range members {
+		pass := results[i].pass
+		commentBuilder.WriteString(fmt.Sprintf("%s [%s](%s)\n", commonci.Emoji(commonci.BoolStatusToString(pass)), validatorDescs[i], resultURLs[i]))
+		if vv.GatesMerge && !pass {
+			gatingFailures = append(gatingFailures, validatorDescs[i])
+		}
+	}
+	comment := withBanner(commentBuilder.String())
+	if err := g.AddEditOrDeletePRComment("Compatibility Report for commit", &comment, owner, repo, prNumber); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("postCompatibilityReport: couldn't post comment: %v", err))
+	}
+
+	if !compatReport.GatesMerge() {
+		return nil
+	}
+	if err := g.UpdatePRStatus(compatReportStatus(validator, reportURL, gatingFailures)); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("postCompatibilityReport: couldn't update PR: %w", err))
+	}
+	return nil
+}
+
+const (
+	// maxCombinedResultBytes is the size of a validator's results within
+	// the compatibility report up to which they're combined into a single
+	// gist comment with those of other such validators, reducing the
+	// number of GitHub API calls.
+	maxCombinedResultBytes = 2048
+	// maxCombinedCommentBytes is the maximum size of a combined gist
+	// comment, leaving room within GitHub's limit of 65535 bytes.
+	maxCombinedCommentBytes = 60000
+)
+
+// compatResult is the result of a validator within the compatibility report.
+type compatResult struct {
+	desc   string
+	pass   bool
+	result string
+}
+
+// title returns the title of the validator's results.
+func (r *compatResult) title() string {
+	return fmt.Sprintf("%s %s", commonci.Emoji(commonci.BoolStatusToString(r.pass)), r.desc)
+}
+
+// compatGistComment is a gist comment of the compatibility report containing
+// the results of one or more of its validators.
+type compatGistComment struct {
+	// members are the indices of the comment's validators.
+	members []int
+	title   string
+	output  string
+}
+
+// batchCompatGistComments returns the gist comments of the results of the
+// compatibility report's validators. Results larger than
+// maxCombinedResultBytes get their own comment, while the others are combined
+// in order into as few comments as possible.
+func batchCompatGistComments(results []*compatResult) []*compatGistComment {
+	var comments []*compatGistComment
+	var combined *compatGistComment
+	for i, r := range results {
+		if len(r.result) > maxCombinedResultBytes {
+			comments = append(comments, &compatGistComment{members: []int{i}})
+			continue
+		}
+		section := fmt.Sprintf("## %s\n%s%s", r.title(), r.result, reportFooter(r.desc))
+		if combined == nil || len(combined.output)+len(section) > maxCombinedCommentBytes {
+			combined = &compatGistComment{}
+			comments = append(comments, combined)
+		}
+		combined.members = append(combined.members, i)
+		combined.output += section
+	}
+
+	for _, c := range comments {
+		if len(c.members) == 1 {
+			r := results[c.members[0]]
+			c.title, c.output = r.title(), withBanner(r.result)+reportFooter(r.desc)
+			continue
+		}
+		pass := true
+		for _, i := range c.members {
+			pass = pass && results[i].pass
+		}
+		c.title = fmt.Sprintf("%s Results of %d validators", commonci.Emoji(commonci.BoolStatusToString(pass)), len(c.members))
+		c.output = withBanner(c.output)
+	}
+	return comments
+}
+
+// postCompatGist posts the gist of the compatibility report, returning its URL
+// and the URLs of the gist comments of each of the validators' results.
+func postCompatGist(g githubClient, name, executionOutput string, results []*compatResult) (string, []string, error) {
+	var gistURL, gistID string
+	if err := commonci.Retry(5, "CreateCIOutputGist", func() error {
+		var err error
+		gistURL, gistID, err = g.CreateCIOutputGist(name, executionOutput)
+		return err
+	}); err != nil {
+		return "", nil, commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("couldn't create gist: %w", err))
+	}
+
+	// Post the parsed test results of the validators as gist comments.
+	resultURLs := make([]string, len(results))
+	for _, c := range batchCompatGistComments(results) {
+		id, err := g.AddGistComment(gistID, c.title, c.output)
+		if err != nil {
+			return "", nil, commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("could not add gist comment: %w", err))
+		}
+		for _, i := range c.members {
+			resultURLs[i] = fmt.Sprintf("%s#gistcomment-%d", gistURL, id)
+		}
+	}
+	return gistURL, resultURLs, nil
+}
+
+// compatResultAnchor returns the ID of the section of the uploaded
+// compatibility report page containing the results of its ith validator.
+func compatResultAnchor(i int) string {
+	return fmt.Sprintf("validator-%d", i)
+}
+
+// compatReportPage returns the uploaded page of the compatibility report,
+// which is posted in place of its gist if the results are private.
+func compatReportPage(results []*compatResult, executionOutput string) string {
+	var b strings.Builder
+	for i, r := range results {
+		fmt.Fprintf(&b, "<h2 id=\"%s\">%s</h2>\n<p>%s</p>%s", compatResultAnchor(i), r.title(), r.result, reportFooter(r.desc))
+	}
+	fmt.Fprintf(&b, "<span style=\"white-space: pre-line\"><p>Execution output:\n%s</p></span>", executionOutput)
+	return b.String()
+}
+
+// compatReportStatus returns the PR status of a compatibility report that
+// gates merge given the descriptions of its gating validators that failed.
+func compatReportStatus(validator *commonci.Validator, url string, gatingFailures []string) *commonci.GithubPRUpdate {
+	update := &commonci.GithubPRUpdate{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         commitSHA,
+		URL:         url,
+		Context:     validator.StatusName(""),
+		NewStatus:   "success",
+		Description: validator.Name + " Succeeded",
+	}
+	if len(gatingFailures) > 0 {
+		update.NewStatus = "failure"
+		update.Description = fmt.Sprintf("%s Failed: %s", validator.Name, strings.Join(gatingFailures, ", "))
+	}
+	return update
+}
+
+// postBreakingChangeLabel posts label and information on whether the PR
+// contains breaking changes that necessitate a repository version bump.
+func postBreakingChangeLabel(g githubClient, versionRecords report.VersionRecords) error {
+	if versionRecords.HasBreaking() {
+		if err := g.PostLabel("breaking", "FF0000", owner, repo, prNumber); err != nil {
+			return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("couldn't post label: %v", err))
+		}
+		g.DeleteLabel("non-breaking", owner, repo, prNumber)
+	} else {
+		if err := g.PostLabel("non-breaking", "00FF00", owner, repo, prNumber); err != nil {
+			return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("couldn't post label: %v", err))
+		}
+		// Don't error out on error since it's possible the label doesn't exist.
+		g.DeleteLabel("breaking", owner, repo, prNumber)
+	}
+	// NOTE: "ajor" is not a typo.
+	majorVersionChangesComment := versionRecords.MajorVersionChanges(commitSHA)
+	if err := g.AddEditOrDeletePRComment("ajor YANG version changes in commit", &majorVersionChangesComment, owner, repo, prNumber); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("couldn't post major YANG version changes comment: %v", err))
+	}
+	return nil
+}
+
+// postResult retrieves the test output for the given validator and version
+// from its results folder and posts a gist and PR status linking to the gist,
+// or a PR status linking to the uploaded report if the results are private.
+func postResult(validatorId, version string) error {
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return fmt.Errorf("postResult: validator %q not found", validatorId)
+	}
+	resultsDir := validatorResultsDir(validatorId, version)
+
+	// If it's a push on the default branch or a release tag, just upload badge for
+	// normal validators as the only action.
+	push := prNumber == 0
+	if push && branchName != commonci.DefaultBranch && commonci.ReleaseTag == "" {
+		return fmt.Errorf("postResult: There is no action to take for a push on a branch other than the default branch %q, please re-examine your push triggers", commonci.DefaultBranch)
+	}
+
+	// The outcome of each check of misc-checks is processed once for both
+	// the JUnit report and its outcome file.
+	var miscChecksOutcome *report.MiscChecksOutcome
+	if validatorId == "misc-checks" {
+		var err error
+		if miscChecksOutcome, err = report.ReadMiscChecksOutcome(resultsDir); err != nil {
+			log.Printf("couldn't process the outcome of each misc-checks check: %v", err)
+		}
+	}
+
+	// Test summarization and dashboards may ingest the results as JUnit
+	// XML, including those of validators only posted within a report.
+	if !validator.ReportOnly {
+		if err := report.WriteJUnit(validatorId, version, resultsDir, miscChecksOutcome); err != nil {
+			log.Printf("couldn't write the JUnit report of %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+		}
+	}
+
+	compatReport, err := readCompatReport()
+	if err != nil {
+		return fmt.Errorf("postResult: %v", err)
+	}
+	_, inCompatReport := compatReport.Member(validatorId, version)
+
+	if !push {
+		if validatorId == "compat-report" {
+			log.Printf("Processing compatibility report for %s", compatReport)
+			return postCompatibilityReport(compatReport)
+		}
+		if validatorId == "pyang-matrix" {
+			return postPyangMatrix()
+		}
+		if commonci.PyangMatrix && validatorId == "pyang" && version != "" {
+			log.Printf("Validator %s part of pyang version matrix, skipping reporting standalone PR status.", commonci.AppendVersionToName(validatorId, version))
+			return nil
+		}
+
+		// Skip PR status reporting if validator is part of compatibility report.
+		if inCompatReport {
+			log.Printf("Validator %s part of compatibility report, skipping reporting standalone PR status.", commonci.AppendVersionToName(validatorId, version))
+			return nil
+		}
+	}
+
+	// Get information needed for posting badge or GitHub gist.
+	validatorDesc, runOutput, err := report.Heading(validatorId, version, resultsDir)
+	if err != nil {
+		return fmt.Errorf("postResult: %v", err)
+	}
+	maxLevel := commonci.MaxReportedLevels[validatorId]
+	testResultString, pass, versionRecords, err := report.Result(validatorId, resultsDir, false, maxLevel)
+	if err != nil {
+		return fmt.Errorf("postResult: couldn't parse results: %v", err)
+	}
+	// The condensed results, which only contain failures, are what the
+	// PR status and badge link to, with a link to the above results.
+	condensedTestResultString, _, _, err := report.Result(validatorId, resultsDir, true, maxLevel)
+	if err != nil {
+		return fmt.Errorf("postResult: couldn't parse condensed results: %v", err)
+	}
+	// Dashboards may read the outcome of each check of misc-checks.
+	if miscChecksOutcome != nil {
+		if err := report.WriteMiscChecksOutcome(resultsDir, miscChecksOutcome); err != nil {
+			log.Printf("couldn't write the outcome of each misc-checks check: %v", err)
+		}
+	}
+	// Results of the same inputs may be reused by later runs.
+	if cacheKey, err := plannedCacheKey(validatorId, version); err != nil {
+		log.Printf("couldn't read the result cache key of %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+	} else if cacheKey != "" {
+		if err := cacheResults(context.Background(), storageClient, cacheKey, resultsDir); err != nil {
+			log.Printf("couldn't cache the results of %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+		}
+	}
+
+	// The full results are posted separately if some messages are filtered.
+	var fullTestResultString string
+	if commonci.CondensedReport && !push {
+		// Only the condensed results are posted.
+		testResultString = condensedTestResultString
+	} else if maxLevel != 0 {
+		if fullTestResultString, _, _, err = report.Result(validatorId, resultsDir, false, 0); err != nil {
+			return fmt.Errorf("postResult: couldn't parse full results: %v", err)
+		}
+	}
+	if validatorId == "gnmi-paths" {
+		pathListsHTML, err := uploadGNMIPaths(context.Background(), storageClient, resultsDir, latestRef())
+		if err != nil {
+			return fmt.Errorf("postResult: couldn't upload gNMI path lists: %w", err)
+		}
+		testResultString = pathListsHTML + testResultString
+		condensedTestResultString = pathListsHTML + condensedTestResultString
+	}
+	if !push && validator.IsPerModel && validatorId != "misc-checks" {
+		// Show contributors iterating on fixes their progress since
+		// the PR's last run.
+		changes := runChanges(context.Background(), validatorId, version, resultsDir)
+		testResultString = changes + testResultString
+		condensedTestResultString = changes + condensedTestResultString
+	}
+
+	if push {
+		if validator.ReportOnly {
+			// Only upload results for running validators.
+			return nil
+		}
+		// Upload the badge along with the outputs it links to: the badge
+		// links to the condensed output, which links to the full output
+		// in the same directory.
+		validatorUniqueStr := commonci.AppendVersionToName(validatorId, version)
+		counts, err := report.CountMessages(validatorId, resultsDir)
+		if err != nil {
+			// The counts are informational, so don't fail the badge upload.
+			log.Printf("INFO: could not count messages for %s: %v", validatorUniqueStr, err)
+		}
+		reports := map[string]string{}
+		for name, result := range map[string]string{
+			validatorUniqueStr + ".html":           condensedTestResultString + fullOutputLink(fullOutputFileName(validatorUniqueStr)),
+			fullOutputFileName(validatorUniqueStr): testResultString,
+		} {
+			reports[name] = reportPage(result, runOutput, validatorDesc)
+		}
+		if err := uploadBadge(context.Background(), storageClient, validatorDesc, validatorUniqueStr, pass, counts, reports); err != nil {
+			return commonci.WithExitCode(commonci.ExitInfraError, fmt.Errorf("postResult: couldn't upload badge for <%s>@<%s>: %v", validatorId, version, err))
+		}
+
+		// Skip PR status reporting if validator is part of compatibility report.
+		if inCompatReport {
+			log.Printf("Validator %s part of compatibility report, skipping reporting standalone PR status.", commonci.AppendVersionToName(validatorId, version))
+			return nil
+		}
+	}
+
+	var g githubClient
+	if err := commonci.Retry(5, "NewGitHubRequestHandler", func() error {
+		g, err = newGitHubClient()
+		return err
+	}); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("postResult: %w", err))
+	}
+
+	if !push && validatorId == "misc-checks" {
+		if err := postBreakingChangeLabel(g, versionRecords); err != nil {
+			return err
+		}
+	}
+
+	var statusURL string
+	if privateResults() {
+		statusURL, err = uploadResultReports(context.Background(), storageClient, commonci.AppendVersionToName(validatorId, version), validatorDesc, runOutput, testResultString, condensedTestResultString, fullTestResultString)
+		if err != nil {
+			return fmt.Errorf("postResult: %w", err)
+		}
+	} else if statusURL, err = postResultGist(g, validatorDesc, runOutput, pass, testResultString, condensedTestResultString, fullTestResultString); err != nil {
+		return fmt.Errorf("postResult: %w", err)
+	}
+
+	prUpdate := resultStatus(validator, validatorId, version, validatorDesc, statusURL, pass)
+	if uperr := g.UpdatePRStatus(prUpdate); uperr != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("postResult: couldn't update PR: %w", uperr))
+	}
+	if checkRuns {
+		// The check run complements the PR status, so failing to post it
+		// doesn't fail the validator.
+		if annotations, err := report.Annotations(validatorId, resultsDir, maxLevel); err != nil {
+			log.Printf("couldn't annotate the results of %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+		} else if err := g.CreateCheckRun(resultCheckRun(prUpdate, pass, annotations)); err != nil {
+			log.Printf("couldn't post the check run of %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+		}
+	}
+	if !push {
+		if err := postRequiredStatus(g, validatorId, version, pass, resultsDir); err != nil {
+			return fmt.Errorf("postResult: %w", err)
+		}
+	}
+	return nil
+}
+
+// postResultGist posts the gist of the validator's results, returning the URL
+// of the gist comment of its condensed results that the PR status links to.
+// fullTestResultString, if non-empty, is the result including the messages
+// filtered from testResultString, which is posted as a further comment.
+func postResultGist(g githubClient, validatorDesc, runOutput string, pass bool, testResultString, condensedTestResultString, fullTestResultString string) (string, error) {
+	// Create gist representing test results. The "validatorDesc" is the
+	// title of the gist, and "runOutput" is the script execution output.
+	var url, gistID string
+	if err := commonci.Retry(5, "CreateCIOutputGist", func() error {
+		var err error
+		url, gistID, err = g.CreateCIOutputGist(validatorDesc, runOutput)
+		return err
+	}); err != nil {
+		return "", commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("couldn't create gist: %w", err))
+	}
+
+	// Post parsed test results as a gist comment.
+	id, err := g.AddGistComment(gistID, fmt.Sprintf("%s %s", commonci.Emoji(commonci.BoolStatusToString(pass)), validatorDesc), withBanner(testResultString)+reportFooter(validatorDesc))
+	if err != nil {
+		return "", commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("could not add gist comment: %w", err))
+	}
+	// Post the condensed results linking to the above results, which is
+	// what the PR status links to.
+	statusURL := url
+	if condensedTestResultString != testResultString {
+		fullURL := fmt.Sprintf("%s#gistcomment-%d", url, id)
+		condensedID, err := g.AddGistComment(gistID, fmt.Sprintf("%s %s (condensed)", commonci.Emoji(commonci.BoolStatusToString(pass)), validatorDesc), withBanner(condensedTestResultString)+fullOutputLink(fullURL)+reportFooter(validatorDesc))
+		if err != nil {
+			return "", commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("could not add condensed results gist comment: %w", err))
+		}
+		statusURL = fmt.Sprintf("%s#gistcomment-%d", url, condensedID)
+	}
+	if fullTestResultString != "" && fullTestResultString != testResultString {
+		if _, err := g.AddGistComment(gistID, fmt.Sprintf("%s %s (all message levels)", commonci.Emoji(commonci.BoolStatusToString(pass)), validatorDesc), withBanner(fullTestResultString)+reportFooter(validatorDesc)); err != nil {
+			return "", commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("could not add full results gist comment: %w", err))
+		}
+	}
+	return statusURL, nil
+}
+
+// resultStatus returns the PR status of the validator version linking to its
+// results at url given whether it passed. A validator in report-only rollout
+// succeeds even if it failed, with its failure noted in the description.
+func resultStatus(validator *commonci.Validator, validatorId, version, validatorDesc, url string, pass bool) *commonci.GithubPRUpdate {
+	update := &commonci.GithubPRUpdate{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         commitSHA,
+		URL:         url,
+		Context:     validator.StatusName(version),
+		NewStatus:   "success",
+		Description: validatorDesc + " Succeeded",
+	}
+	if pass {
+		return update
+	}
+	until, ok := commonci.ReportOnlyRollout.Lookup(validatorId, version)
+	switch {
+	case !ok:
+		update.NewStatus = "failure"
+		update.Description = validatorDesc + " Failed"
+	case until == "":
+		update.Description = validatorDesc + " Failed (report-only rollout)"
+	default:
+		update.Description = fmt.Sprintf("%s Failed (report-only rollout until %s)", validatorDesc, until)
+	}
+	return update
+}
+
+// resultCheckRun returns the check run of the validator's result, mirroring
+// its PR status, with the given annotations. A validator in report-only
+// rollout that failed is neutral.
+func resultCheckRun(update *commonci.GithubPRUpdate, pass bool, annotations []*commonci.CheckRunAnnotation) *commonci.CheckRun {
+	conclusion := update.NewStatus
+	if conclusion == "success" && !pass {
+		conclusion = "neutral"
+	}
+	return &commonci.CheckRun{
+		Owner:       update.Owner,
+		Repo:        update.Repo,
+		HeadSHA:     update.Ref,
+		Name:        update.Context,
+		DetailsURL:  update.URL,
+		Conclusion:  conclusion,
+		Title:       update.Description,
+		Summary:     fmt.Sprintf("%d message(s) annotated. See the [full results](%s).", len(annotations), update.URL),
+		Annotations: annotations,
+	}
+}
+
+// postRequiredStatus records the outcome of the validator for the aggregate
+// required status if the validator is required, and posts the required status
+// once all required validators have recorded their outcomes. Since each
+// validator records its outcome before evaluating the policy, the last one to
+// finish always posts the status.
+func postRequiredStatus(g githubClient, validatorId, version string, pass bool, resultsDir string) error {
+	plan, err := commonci.ReadPlan(commonci.PlanFile)
+	if err != nil || plan == nil {
+		return err
+	}
+	policy := plan.RequiredStatusPolicy
+	if policy == nil || !policy.Requires(validatorId, version) {
+		return nil
+	}
+
+	outcome := commonci.RequiredOutcomeFail
+	if pass {
+		outcome = commonci.RequiredOutcomePass
+	}
+	if pass && validatorId == "misc-checks" {
+		// Major version increments allow breaking changes, so only
+		// those that ocdiff disallows are breaking.
+		switch breaking, err := hasDisallowedIncompats(resultsDir); {
+		case err != nil:
+			log.Printf("couldn't determine whether there are disallowed breaking changes, failing the required status: %v", err)
+			outcome = commonci.RequiredOutcomeFail
+		case breaking:
+			outcome = commonci.RequiredOutcomeBreaking
+		}
+	}
+	if err := commonci.RecordRequiredOutcome(commonci.RequiredStatusDir, validatorId, version, outcome); err != nil {
+		return err
+	}
+
+	complete, failures, breaking, err := policy.Evaluate(commonci.RequiredStatusDir)
+	if err != nil || !complete {
+		return err
+	}
+	if err := g.UpdatePRStatus(requiredStatus(failures, breaking)); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("couldn't update required status: %w", err))
+	}
+	return nil
+}
+
+// hasDisallowedIncompats returns whether ocdiff found backward-incompatible
+// changes that the PR's version increments don't allow, as recorded within
+// the misc-checks results directory.
+func hasDisallowedIncompats(resultsDir string) (bool, error) {
+	bs, err := os.ReadFile(filepath.Join(resultsDir, commonci.DisallowedIncompatsFileName))
+	if err != nil {
+		return false, err
+	}
+	return len(bytes.TrimSpace(bs)) != 0, nil
+}
+
+// requiredStatus returns the aggregate required PR status given the required
+// validators that failed and whether disallowed breaking changes were found.
+func requiredStatus(failures []string, breaking bool) *commonci.GithubPRUpdate {
+	validator := commonci.Validators["required"]
+	update := &commonci.GithubPRUpdate{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         commitSHA,
+		Context:     validator.StatusName(""),
+		NewStatus:   "success",
+		Description: "All required checks passed",
+	}
+	var reasons []string
+	if len(failures) > 0 {
+		reasons = append(reasons, "failed: "+strings.Join(failures, ", "))
+	}
+	if breaking {
+		reasons = append(reasons, "disallowed breaking changes")
+	}
+	if len(reasons) > 0 {
+		update.NewStatus = "failure"
+		update.Description = "Required checks " + strings.Join(reasons, "; ")
+	}
+	return update
+}
+
+// claimInfraDegradedReport returns whether the caller should report degraded
+// CI infrastructure, which is true only for the first caller within the build
+// as recorded by the marker file at path.
+func claimInfraDegradedReport(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	switch {
+	case errors.Is(err, fs.ErrExist):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, f.Close()
+}
+
+// infraDegradedComment creates the PR comment reporting that results could
+// not be posted due to the given infra error.
+func infraDegradedComment(validatorDesc string, cause error) string {
+	var infraErr *commonci.InfraError
+	if errors.As(cause, &infraErr) {
+		cause = infraErr
+	}
+	return fmt.Sprintf("%s %s %s:\n\nResults of %s (and possibly other validators) could not be posted, so they do not reflect the models. Please re-run the CI once the services below have recovered.\n\n%s", commonci.Emoji("warning"), infraDegradedSignature, commitSHA, validatorDesc, report.BlockQuote(report.EscapeOutput(cause.Error())))
+}
+
+// reportInfraDegraded reports that posting the results of the validator
+// failed due to the given infra error. The validator's status is set to
+// "error" to distinguish it from a model failure, and the degradation is
+// reported in a separate status and PR comment once per build.
+//
+// Since the failing service may be GitHub itself, failures are only logged.
+func reportInfraDegraded(validatorId, version string, cause error) {
+	validator := commonci.Validators[validatorId]
+	g, err := newGitHubClient()
+	if err != nil {
+		log.Printf("couldn't report degraded CI infrastructure: %v", err)
+		return
+	}
+
+	if validator != nil && !validator.ReportOnly {
+		if err := g.UpdatePRStatus(&commonci.GithubPRUpdate{
+			Owner:       owner,
+			Repo:        repo,
+			Ref:         commitSHA,
+			NewStatus:   "error",
+			Description: "Results not posted: CI infrastructure degraded",
+			Context:     validator.StatusName(version),
+		}); err != nil {
+			log.Printf("couldn't update validator status for degraded CI infrastructure: %v", err)
+		}
+	}
+
+	switch first, err := claimInfraDegradedReport(commonci.InfraDegradedFile); {
+	case err != nil:
+		log.Printf("couldn't record degraded CI infrastructure report: %v", err)
+		return
+	case !first:
+		return
+	}
+
+	if err := g.UpdatePRStatus(&commonci.GithubPRUpdate{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         commitSHA,
+		NewStatus:   "error",
+		Description: "CI infrastructure degraded, some results were not posted",
+		Context:     commonci.StatusContextPrefix + infraStatusContext,
+	}); err != nil {
+		log.Printf("couldn't post degraded CI infrastructure status: %v", err)
+	}
+	if prNumber == 0 {
+		return
+	}
+	validatorDesc := validatorId
+	if validator != nil {
+		validatorDesc = commonci.AppendVersionToName(validator.Name, version)
+	}
+	comment := infraDegradedComment(validatorDesc, cause)
+	if err := g.AddEditOrDeletePRComment(infraDegradedSignature, &comment, owner, repo, prNumber); err != nil {
+		log.Printf("couldn't post degraded CI infrastructure comment: %v", err)
+	}
+}
+
+// retargetMergedPRStatuses copies the final validator statuses of the head
+// commit of the PR merged as mergeSHA onto mergeSHA. Commits that weren't
+// produced by merging a PR are skipped.
+func retargetMergedPRStatuses(mergeSHA string) error {
+	g, err := commonci.NewGitHubRequestHandler()
+	if err != nil {
+		return err
+	}
+	pr, headSHA, err := g.MergedPRForCommit(owner, repo, mergeSHA)
+	if err != nil {
+		return fmt.Errorf("couldn't find the PR merged as %s: %w", mergeSHA, err)
+	}
+	if pr == 0 {
+		log.Printf("%s isn't the merge commit of a PR, not retargeting statuses", mergeSHA)
+		return nil
+	}
+	copied, err := g.CopyFinalStatuses(owner, repo, headSHA, mergeSHA)
+	if err != nil {
+		return fmt.Errorf("couldn't copy the statuses of PR #%d (%s) onto %s: %w", pr, headSHA, mergeSHA, err)
+	}
+	log.Printf("copied statuses %v of PR #%d (%s) onto %s", copied, pr, headSHA, mergeSHA)
+	return nil
+}
+
+// Main runs post_results with the given command-line arguments, excluding
+// the program name.
+func Main(args []string) {
+	flagSet.Parse(args)
+	log.Printf("post_results version %s", civersion.String())
+	if exited {
+		if err := markExited(resultsRoot, validatorId); err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+		}
+		return
+	}
+	flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == "compat-report" {
+			compatReportsOverride = true
+		}
+	})
+	if repoSlug == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "no repo slug input")
+	}
+	repoSplit := strings.Split(repoSlug, "/")
+	owner = repoSplit[0]
+	repo = repoSplit[1]
+	if commitSHA == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "no commit SHA")
+	}
+	// Link each message to its line within the validated commit.
+	report.SourceURL = fmt.Sprintf("https://github.com/%s/%s/blob/%s", owner, repo, commitSHA)
+	prNumber = 0
+	if prNumberStr != "" {
+		var err error
+		if prNumber, err = strconv.Atoi(prNumberStr); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "error encountered while parsing PR number: %s", err)
+		}
+	}
+
+	// The default branch and the release tag, if any, are relayed by
+	// cmd_gen.
+	if err := commonci.ReadUserConfig(); err != nil {
+		commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+	}
+	if defaultBranch != "" {
+		commonci.DefaultBranch = defaultBranch
+	}
+	if prNumber == 0 && branchName != commonci.DefaultBranch && commonci.ReleaseTag == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "no PR branch name supplied or push trigger not on the default branch %q or a release tag", commonci.DefaultBranch)
+	}
+	storageClient = commonci.NewStorageClient(bucketName, localBucketDir, uploadDryRun || dryRun)
+	if gcs, ok := storageClient.(*commonci.GCSBucket); ok {
+		// Only GCS objects are public by default.
+		gcs.SignedURLDuration, gcs.SigningKeyFile = signedURLDuration, signingKeyFile
+		if err := gcs.ValidateSignedURLs(); err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "invalid -signed-url-duration: %v", err)
+		}
+	}
+
+	if checkRuns && !dryRun && os.Getenv(commonci.ChecksTokenEnv) == "" {
+		commonci.Fatalf(commonci.ExitConfigError, "-check-runs requires a GitHub App installation token in $%s", commonci.ChecksTokenEnv)
+	}
+
+	if retargetStatuses {
+		if prNumber != 0 || commonci.ReleaseTag != "" {
+			commonci.Fatalf(commonci.ExitConfigError, "-retarget-statuses is only supported on a push to the default branch")
+		}
+		if dryRun {
+			commonci.Fatalf(commonci.ExitConfigError, "-retarget-statuses isn't supported in a dry run")
+		}
+		if err := retargetMergedPRStatuses(commitSHA); err != nil {
+			commonci.Fatalf(commonci.ExitGitHubError, "%v", err)
+		}
+		return
+	}
+	if watch {
+		if err := watchResults(resultsRoot, watchInterval, watchTimeout, func(validatorId, version string) error {
+			err := postResult(validatorId, version)
+			if err != nil && commonci.IsInfraError(err) {
+				reportInfraDegraded(validatorId, version, err)
+			}
+			return err
+		}, func(validatorId, version string) {
+			reportInfraDegraded(validatorId, version, fmt.Errorf("the validator step of %s exited without marking its results done", commonci.AppendVersionToName(validatorId, version)))
+		}); err != nil {
+			commonci.Fatal(err)
+		}
+		return
+	}
+	if serveAddr != "" {
+		token, err := readReporterToken(reporterTokenFile)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+		lis, err := net.Listen("tcp", serveAddr)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "failed to listen on %q: %v", serveAddr, err)
+		}
+		log.Printf("serving reporter service on %s", lis.Addr())
+		if err := serveReporter(lis, newReporterServer(resultsRoot, func(validatorId, version string) error {
+			err := postResult(validatorId, version)
+			if err != nil && commonci.IsInfraError(err) {
+				reportInfraDegraded(validatorId, version, err)
+			}
+			return err
+		}), token, watchTimeout); err != nil {
+			commonci.Fatal(err)
+		}
+		return
+	}
+	if reporterAddr != "" && (finalizeReporterRun || !hasResultsDir(validatorId)) {
+		token, err := readReporterToken(reporterTokenFile)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitConfigError, "%v", err)
+		}
+		conn, err := dialReporter(reporterAddr, reporterTLS, token)
+		if err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+		}
+		client := rpb.NewReporterClient(conn)
+		if finalizeReporterRun {
+			err = finalizeRun(context.Background(), client)
+		} else {
+			err = submitResults(context.Background(), client, validatorId, version, validatorResultsDir(validatorId, version))
+		}
+		conn.Close()
+		if err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+		}
+		return
+	}
+	// The reporter service posts the results, except for the
+	// compatibility report and the pyang version matrix, which don't have a
+	// results directory.
+	if commonci.ReporterService && hasResultsDir(validatorId) {
+		if err := markDone(validatorResultsDir(validatorId, version)); err != nil {
+			commonci.Fatalf(commonci.ExitInfraError, "%v", err)
+		}
+		log.Printf("results of %s marked as done for the reporter service", commonci.AppendVersionToName(validatorId, version))
+		return
+	}
+
+	if err := postResult(validatorId, version); err != nil {
+		if commonci.IsInfraError(err) {
+			reportInfraDegraded(validatorId, version, err)
+		}
+		commonci.Fatal(err)
+	}
+}
@@ -0,0 +1,749 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/report"
+	civersion "github.com/openconfig/models-ci/version"
+)
+
+func TestRunChangesHTML(t *testing.T) {
+	tests := []struct {
+		name   string
+		inPrev *commonci.RunResults
+		inCur  *commonci.RunResults
+		want   string
+	}{{
+		name:   "no changes",
+		inPrev: &commonci.RunResults{CommitSHA: "0123456789abcdef", Failures: []string{"acl/openconfig-acl"}},
+		inCur:  &commonci.RunResults{CommitSHA: "fedcba9876543210", Failures: []string{"acl/openconfig-acl"}},
+		want: `<details>
+  <summary>&#x2705;&nbsp; changes since last run (0123456)</summary>
+No changes in model results.
+</details>
+`,
+	}, {
+		name:   "fixed",
+		inPrev: &commonci.RunResults{CommitSHA: "0123456789abcdef", Failures: []string{"acl/openconfig-acl"}},
+		inCur:  &commonci.RunResults{CommitSHA: "fedcba9876543210"},
+		want: `<details>
+  <summary>&#x2705;&nbsp; changes since last run (0123456)</summary>
+  <li>&#x2705; fixed: acl/openconfig-acl</li>
+</details>
+`,
+	}, {
+		name:   "fixed and new failures",
+		inPrev: &commonci.RunResults{CommitSHA: "0123456789abcdef", Failures: []string{"acl/openconfig-acl"}},
+		inCur:  &commonci.RunResults{CommitSHA: "fedcba9876543210", Failures: []string{"optical-transport/openconfig-optical-amplifier"}},
+		want: `<details>
+  <summary>&#x26D4;&nbsp; changes since last run (0123456)</summary>
+  <li>&#x2705; fixed: acl/openconfig-acl</li>
+  <li>&#x26D4; new failure: optical-transport/openconfig-optical-amplifier</li>
+</details>
+`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(strings.Split(tt.want, "\n"), strings.Split(runChangesHTML(tt.inPrev, tt.inCur), "\n")); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUploadBadge(t *testing.T) {
+	repoSlug = "openconfig/repo"
+	defer func() { repoSlug = "" }()
+	origMakeBadge := makeBadge
+	defer func() { makeBadge = origMakeBadge }()
+	makeBadge = func(_ context.Context, status, validatorDesc, colour string) ([]byte, error) {
+		return []byte(fmt.Sprintf("%s|%s|%s", status, validatorDesc, colour)), nil
+	}
+
+	reports := map[string]string{
+		"pyang.html":      "condensed",
+		"pyang-full.html": "full",
+	}
+	tests := []struct {
+		name                 string
+		inValidatorDesc      string
+		inValidatorUniqueStr string
+		inPass               bool
+		inCounts             *report.MessageCounts
+		inReports            map[string]string
+		inShadowMode         bool
+		inReleaseTag         string
+		wantObjects          map[string]string
+	}{{
+		name:                 "pass",
+		inValidatorDesc:      "pyang@1.2.3",
+		inValidatorUniqueStr: "pyang@latest",
+		inPass:               true,
+		inReports: map[string]string{
+			"pyang@latest.html":      "condensed",
+			"pyang@latest-full.html": "full",
+		},
+		wantObjects: map[string]string{
+			"compatibility-badges/openconfig-repo:pyang@latest.svg":       "pass|pyang@1.2.3|brightgreen",
+			"compatibility-badges/openconfig-repo:pyang@latest.html":      "condensed",
+			"compatibility-badges/openconfig-repo:pyang@latest-full.html": "full",
+		},
+	}, {
+		name:                 "fail",
+		inValidatorDesc:      "pyang@2.3.4",
+		inValidatorUniqueStr: "pyang",
+		inPass:               false,
+		inReports:            reports,
+		wantObjects: map[string]string{
+			"compatibility-badges/openconfig-repo:pyang.svg":       "fail|pyang@2.3.4|red",
+			"compatibility-badges/openconfig-repo:pyang.html":      "condensed",
+			"compatibility-badges/openconfig-repo:pyang-full.html": "full",
+		},
+	}, {
+		name:                 "pass with message counts",
+		inValidatorDesc:      "pyang@2.3.4",
+		inValidatorUniqueStr: "pyang",
+		inPass:               true,
+		inCounts:             &report.MessageCounts{Errors: 0, Warnings: 231},
+		inReports:            reports,
+		wantObjects: map[string]string{
+			"compatibility-badges/openconfig-repo:pyang.svg":       "pass, 0 errors / 231 warnings|pyang@2.3.4|brightgreen",
+			"compatibility-badges/openconfig-repo:pyang.html":      "condensed",
+			"compatibility-badges/openconfig-repo:pyang-full.html": "full",
+		},
+	}, {
+		name:                 "shadow mode",
+		inValidatorDesc:      "pyang@2.3.4",
+		inValidatorUniqueStr: "pyang",
+		inPass:               true,
+		inReports:            reports,
+		inShadowMode:         true,
+		wantObjects: map[string]string{
+			"compatibility-badges-shadow/openconfig-repo:pyang.svg":       "pass|pyang@2.3.4|brightgreen",
+			"compatibility-badges-shadow/openconfig-repo:pyang.html":      "condensed",
+			"compatibility-badges-shadow/openconfig-repo:pyang-full.html": "full",
+		},
+	}, {
+		name:                 "release",
+		inValidatorDesc:      "pyang@2.3.4",
+		inValidatorUniqueStr: "pyang",
+		inPass:               true,
+		inReports:            reports,
+		inReleaseTag:         "releases/v1.2.0",
+		wantObjects: map[string]string{
+			"compatibility-badges/openconfig-repo@releases-v1.2.0:pyang.svg":       "pass|pyang@2.3.4|brightgreen",
+			"compatibility-badges/openconfig-repo@releases-v1.2.0:pyang.html":      "condensed",
+			"compatibility-badges/openconfig-repo@releases-v1.2.0:pyang-full.html": "full",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commonci.ShadowMode, commonci.ReleaseTag = tt.inShadowMode, tt.inReleaseTag
+			defer func() { commonci.ShadowMode, commonci.ReleaseTag = false, "" }()
+			bucket := &commonci.MemoryBucket{Bucket: bucketName}
+			if err := uploadBadge(context.Background(), bucket, tt.inValidatorDesc, tt.inValidatorUniqueStr, tt.inPass, tt.inCounts, tt.inReports); err != nil {
+				t.Fatal(err)
+			}
+
+			gotObjects := map[string]string{}
+			for _, name := range bucket.Objects() {
+				data, _ := bucket.Object(name)
+				gotObjects[name] = string(data)
+			}
+			if diff := cmp.Diff(tt.wantObjects, gotObjects); diff != "" {
+				t.Errorf("uploaded objects (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUploadResultReports(t *testing.T) {
+	repoSlug, commitSHA = "openconfig/repo", "sha"
+	defer func() { repoSlug, commitSHA = "", "" }()
+
+	bucket := &commonci.MemoryBucket{Bucket: bucketName}
+	url, err := uploadResultReports(context.Background(), bucket, "pyang@latest", "pyang@1.2.3", "run output", "warnings", "condensed", "all levels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://storage.googleapis.com/" + bucketName + "/reports/openconfig-repo/sha/pyang@latest.html"; url != want {
+		t.Errorf("got URL %q, want %q", url, want)
+	}
+	full, _ := bucket.Object("reports/openconfig-repo/sha/pyang@latest-full.html")
+	if want := reportPage("all levels", "run output", "pyang@1.2.3"); string(full) != want {
+		t.Errorf("got full report %q, want %q", full, want)
+	}
+	condensed, _ := bucket.Object("reports/openconfig-repo/sha/pyang@latest.html")
+	if want := reportPage("condensed"+fullOutputLink("https://storage.googleapis.com/"+bucketName+"/reports/openconfig-repo/sha/pyang@latest-full.html"), "run output", "pyang@1.2.3"); string(condensed) != want {
+		t.Errorf("got condensed report %q, want %q", condensed, want)
+	}
+}
+
+func TestHasDisallowedIncompats(t *testing.T) {
+	resultsDir := t.TempDir()
+	if _, err := hasDisallowedIncompats(resultsDir); err == nil {
+		t.Errorf("got no error without disallowed incompats file")
+	}
+	path := filepath.Join(resultsDir, commonci.DisallowedIncompatsFileName)
+	for _, tt := range []struct {
+		contents string
+		want     bool
+	}{
+		{contents: "", want: false},
+		{contents: "\n", want: false},
+		{contents: "leaf /a/b deleted\n", want: true},
+	} {
+		if err := os.WriteFile(path, []byte(tt.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := hasDisallowedIncompats(resultsDir); err != nil || got != tt.want {
+			t.Errorf("%q: got (%v, %v), want (%v, nil)", tt.contents, got, err, tt.want)
+		}
+	}
+}
+
+func TestReportFooter(t *testing.T) {
+	civersion.Version, civersion.Commit = "v1.2.3", "abc123"
+	defer func() { civersion.Version, civersion.Commit = "", "" }()
+
+	tests := []struct {
+		name            string
+		inValidatorDesc string
+		inImageDigest   string
+		want            string
+	}{{
+		name:            "no image digest",
+		inValidatorDesc: "pyang@2.6.0",
+		want:            "\n<sub>Generated by models-ci v1.2.3 (commit abc123), pyang@2.6.0</sub>\n",
+	}, {
+		name:            "with image digest",
+		inValidatorDesc: "pyang@2.6.0",
+		inImageDigest:   "sha256:deadbeef",
+		want:            "\n<sub>Generated by models-ci v1.2.3 (commit abc123), pyang@2.6.0, image sha256:deadbeef</sub>\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(imageDigestEnvVar, tt.inImageDigest)
+			if got := reportFooter(tt.inValidatorDesc); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestRef(t *testing.T) {
+	tests := []struct {
+		name            string
+		inPRNumber      int
+		inReleaseTag    string
+		inDefaultBranch string
+		want            string
+	}{{
+		name:            "PR",
+		inPRNumber:      1,
+		inDefaultBranch: "master",
+		want:            "",
+	}, {
+		name:            "push to master",
+		inDefaultBranch: "master",
+		want:            "master",
+	}, {
+		name:            "push to non-master default branch",
+		inDefaultBranch: "release/2.x",
+		want:            "release-2.x",
+	}, {
+		name:            "release",
+		inReleaseTag:    "v1.2.0",
+		inDefaultBranch: "main",
+		want:            "v1.2.0",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prNumber, commonci.ReleaseTag, commonci.DefaultBranch = tt.inPRNumber, tt.inReleaseTag, tt.inDefaultBranch
+			defer func() { prNumber, commonci.ReleaseTag, commonci.DefaultBranch = 0, "", "master" }()
+			if got := latestRef(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaimInfraDegradedReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "infra-degraded")
+	for i, want := range []bool{true, false, false} {
+		got, err := claimInfraDegradedReport(path)
+		if err != nil {
+			t.Fatalf("claim %d: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("claim %d: got %v, want %v", i, got, want)
+		}
+	}
+
+	if _, err := claimInfraDegradedReport(filepath.Join(t.TempDir(), "dne", "infra-degraded")); err == nil {
+		t.Errorf("got no error for nonexistent directory")
+	}
+}
+
+func TestInfraDegradedComment(t *testing.T) {
+	commitSHA = "abc"
+	defer func() { commitSHA = "" }()
+
+	cause := &commonci.InfraError{Service: "GitHub", Err: fmt.Errorf("502 <Bad Gateway>")}
+	want := "&#x26A0;&#xFE0F; CI infrastructure degraded for commit abc:\n\n" +
+		"Results of pyang (and possibly other validators) could not be posted, so they do not reflect the models. Please re-run the CI once the services below have recovered.\n\n" +
+		"```\nGitHub unavailable: 502 &lt;Bad Gateway&gt;\n```"
+	if got := infraDegradedComment("pyang", fmt.Errorf("postResult: %w", cause)); got != want {
+		t.Errorf("(-want, +got):\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestWithBanner(t *testing.T) {
+	defer func() { commonci.Banner = "" }()
+
+	tests := []struct {
+		name     string
+		inBanner string
+		want     string
+	}{{
+		name: "no banner",
+		want: "report",
+	}, {
+		name:     "banner",
+		inBanner: "pyang 3.x will become mandatory next quarter.",
+		want:     "pyang 3.x will become mandatory next quarter.\n\n---\n\nreport",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commonci.Banner = tt.inBanner
+			if got := withBanner("report"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchCompatGistComments(t *testing.T) {
+	large := strings.Repeat("x", maxCombinedResultBytes+1)
+	pass := commonci.Emoji("pass")
+	fail := commonci.Emoji("fail")
+
+	tests := []struct {
+		name      string
+		inResults []*compatResult
+		want      []*compatGistComment
+	}{{
+		name:      "single validator",
+		inResults: []*compatResult{{desc: "goyang-ygot", pass: true, result: "ok"}},
+		want: []*compatGistComment{{
+			members: []int{0},
+			title:   pass + " goyang-ygot",
+			output:  "ok" + reportFooter("goyang-ygot"),
+		}},
+	}, {
+		name: "small results are combined",
+		inResults: []*compatResult{
+			{desc: "goyang-ygot", pass: true, result: "ok"},
+			{desc: "pyang@head", pass: false, result: large},
+			{desc: "yanglint", pass: false, result: "bad"},
+		},
+		want: []*compatGistComment{{
+			members: []int{0, 2},
+			title:   fail + " Results of 2 validators",
+			output:  "## " + pass + " goyang-ygot\nok" + reportFooter("goyang-ygot") + "## " + fail + " yanglint\nbad" + reportFooter("yanglint"),
+		}, {
+			members: []int{1},
+			title:   fail + " pyang@head",
+			output:  large + reportFooter("pyang@head"),
+		}},
+	}, {
+		name: "combined comments are split by size",
+		inResults: func() []*compatResult {
+			var results []*compatResult
+			for i := 0; i < maxCombinedCommentBytes/maxCombinedResultBytes+1; i++ {
+				results = append(results, &compatResult{desc: fmt.Sprintf("v%d", i), pass: true, result: large[1:]})
+			}
+			return results
+		}(),
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := batchCompatGistComments(tt.inResults)
+			if tt.want == nil {
+				// Only check that every validator is in exactly one
+				// comment of bounded size.
+				var members []int
+				for _, c := range got {
+					members = append(members, c.members...)
+					if len(c.output) > maxCombinedCommentBytes {
+						t.Errorf("comment of %d bytes exceeds %d bytes", len(c.output), maxCombinedCommentBytes)
+					}
+				}
+				if len(got) < 2 || len(members) != len(tt.inResults) {
+					t.Errorf("got %d comments with %d members, want at least 2 comments with %d members", len(got), len(members), len(tt.inResults))
+				}
+				return
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(compatGistComment{})); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCompatReportStatus(t *testing.T) {
+	owner, repo, commitSHA = "openconfig", "public", "abc"
+	defer func() { owner, repo, commitSHA = "", "", "" }()
+
+	tests := []struct {
+		name             string
+		inGatingFailures []string
+		want             *commonci.GithubPRUpdate
+	}{{
+		name: "gating validators passed",
+		want: &commonci.GithubPRUpdate{
+			Owner:       "openconfig",
+			Repo:        "public",
+			Ref:         "abc",
+			URL:         "https://gist.github.com/1",
+			Context:     "Compatibility Report",
+			NewStatus:   "success",
+			Description: "Compatibility Report Succeeded",
+		},
+	}, {
+		name:             "gating validators failed",
+		inGatingFailures: []string{"goyang-ygot", "pyang@head"},
+		want: &commonci.GithubPRUpdate{
+			Owner:       "openconfig",
+			Repo:        "public",
+			Ref:         "abc",
+			URL:         "https://gist.github.com/1",
+			Context:     "Compatibility Report",
+			NewStatus:   "failure",
+			Description: "Compatibility Report Failed: goyang-ygot, pyang@head",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compatReportStatus(commonci.Validators["compat-report"], "https://gist.github.com/1", tt.inGatingFailures)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResultStatus(t *testing.T) {
+	owner, repo, commitSHA = "openconfig", "public", "abc"
+	commonci.ReportOnlyRollout = commonci.ReportOnlyRollouts{"pyang@3.0": "2024-12-31", "goyang-ygot": ""}
+	defer func() { owner, repo, commitSHA, commonci.ReportOnlyRollout = "", "", "", nil }()
+
+	tests := []struct {
+		name            string
+		inValidatorId   string
+		inVersion       string
+		inPass          bool
+		wantStatus      string
+		wantDescription string
+	}{{
+		name:            "pass",
+		inValidatorId:   "pyang",
+		inPass:          true,
+		wantStatus:      "success",
+		wantDescription: "pyang Succeeded",
+	}, {
+		name:            "fail",
+		inValidatorId:   "pyang",
+		wantStatus:      "failure",
+		wantDescription: "pyang Failed",
+	}, {
+		name:            "fail in report-only rollout",
+		inValidatorId:   "goyang-ygot",
+		wantStatus:      "success",
+		wantDescription: "goyang-ygot Failed (report-only rollout)",
+	}, {
+		name:            "fail in report-only rollout with end",
+		inValidatorId:   "pyang",
+		inVersion:       "3.0",
+		wantStatus:      "success",
+		wantDescription: "pyang@3.0 Failed (report-only rollout until 2024-12-31)",
+	}, {
+		name:            "pass in report-only rollout",
+		inValidatorId:   "pyang",
+		inVersion:       "3.0",
+		inPass:          true,
+		wantStatus:      "success",
+		wantDescription: "pyang@3.0 Succeeded",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := commonci.Validators[tt.inValidatorId]
+			got := resultStatus(validator, tt.inValidatorId, tt.inVersion, commonci.AppendVersionToName(tt.inValidatorId, tt.inVersion), "https://gist.github.com/1", tt.inPass)
+			want := &commonci.GithubPRUpdate{
+				Owner:       "openconfig",
+				Repo:        "public",
+				Ref:         "abc",
+				URL:         "https://gist.github.com/1",
+				Context:     validator.StatusName(tt.inVersion),
+				NewStatus:   tt.wantStatus,
+				Description: tt.wantDescription,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResultCheckRun(t *testing.T) {
+	annotations := []*commonci.CheckRunAnnotation{{Path: "release/models/acl/openconfig-acl.yang", StartLine: 1, EndLine: 1, Level: "failure", Message: "m"}}
+	tests := []struct {
+		name           string
+		inStatus       string
+		inPass         bool
+		wantConclusion string
+	}{{
+		name:           "pass",
+		inStatus:       "success",
+		inPass:         true,
+		wantConclusion: "success",
+	}, {
+		name:           "fail",
+		inStatus:       "failure",
+		wantConclusion: "failure",
+	}, {
+		name:           "fail in report-only rollout",
+		inStatus:       "success",
+		wantConclusion: "neutral",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			update := &commonci.GithubPRUpdate{
+				Owner:       "openconfig",
+				Repo:        "public",
+				Ref:         "abc",
+				URL:         "https://gist.github.com/1",
+				Context:     "pyang",
+				NewStatus:   tt.inStatus,
+				Description: "pyang desc",
+			}
+			want := &commonci.CheckRun{
+				Owner:       "openconfig",
+				Repo:        "public",
+				HeadSHA:     "abc",
+				Name:        "pyang",
+				DetailsURL:  "https://gist.github.com/1",
+				Conclusion:  tt.wantConclusion,
+				Title:       "pyang desc",
+				Summary:     "1 message(s) annotated. See the [full results](https://gist.github.com/1).",
+				Annotations: annotations,
+			}
+			if diff := cmp.Diff(want, resultCheckRun(update, tt.inPass, annotations)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRequiredStatus(t *testing.T) {
+	owner, repo, commitSHA = "openconfig", "public", "abc"
+	defer func() { owner, repo, commitSHA = "", "", "" }()
+
+	tests := []struct {
+		name            string
+		inFailures      []string
+		inBreaking      bool
+		wantStatus      string
+		wantDescription string
+	}{{
+		name:            "pass",
+		wantStatus:      "success",
+		wantDescription: "All required checks passed",
+	}, {
+		name:            "failures",
+		inFailures:      []string{"pyang", "goyang-ygot"},
+		wantStatus:      "failure",
+		wantDescription: "Required checks failed: pyang, goyang-ygot",
+	}, {
+		name:            "failures and breaking changes",
+		inFailures:      []string{"pyang"},
+		inBreaking:      true,
+		wantStatus:      "failure",
+		wantDescription: "Required checks failed: pyang; disallowed breaking changes",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := requiredStatus(tt.inFailures, tt.inBreaking)
+			want := &commonci.GithubPRUpdate{
+				Owner:       "openconfig",
+				Repo:        "public",
+				Ref:         "abc",
+				Context:     "required",
+				NewStatus:   tt.wantStatus,
+				Description: tt.wantDescription,
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFullOutputLink(t *testing.T) {
+	want := "\n<p><a href=\"pyang@head-full.html\">View full output</a></p>\n"
+	if got := fullOutputLink(fullOutputFileName("pyang@head")); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCacheResults(t *testing.T) {
+	tests := []struct {
+		desc         string
+		inFiles      map[string]string
+		wantObject   string
+		wantUploaded bool
+	}{{
+		desc:         "latest version",
+		inFiles:      map[string]string{"out": "out", commonci.LatestVersionFileName: "pyang 2.6.0\n"},
+		wantObject:   commonci.ResultCacheObject(commonci.ResultCacheKey("inputs", []byte("pyang 2.6.0\n"))),
+		wantUploaded: true,
+	}, {
+		desc:         "extra version without version description",
+		inFiles:      map[string]string{"out": "out"},
+		wantObject:   commonci.ResultCacheObject(commonci.ResultCacheKey("inputs", nil)),
+		wantUploaded: true,
+	}, {
+		desc:       "restored from the result cache",
+		inFiles:    map[string]string{"out": "out", commonci.ResultCacheHitFileName: ""},
+		wantObject: commonci.ResultCacheObject(commonci.ResultCacheKey("inputs", nil)),
+	}, {
+		desc: "all models completed",
+		inFiles: map[string]string{
+			"acl==openconfig-acl==pass":         "",
+			"bgp==openconfig-bgp==fail":         "error",
+			commonci.ExpectedModelCountFileName: "2\n",
+			commonci.CompletedModelsFileName:    "acl==openconfig-acl==\nbgp==openconfig-bgp==\n",
+		},
+		wantObject:   commonci.ResultCacheObject(commonci.ResultCacheKey("inputs", nil)),
+		wantUploaded: true,
+	}, {
+		desc:       "validator script failed",
+		inFiles:    map[string]string{"out": "out", commonci.FailFileName: "pyang: command not found"},
+		wantObject: commonci.ResultCacheObject(commonci.ResultCacheKey("inputs", nil)),
+	}, {
+		desc: "model timed out",
+		inFiles: map[string]string{
+			"acl==openconfig-acl==pass":    "",
+			"bgp==openconfig-bgp==timeout": "timed out after 600s",
+		},
+		wantObject: commonci.ResultCacheObject(commonci.ResultCacheKey("inputs", nil)),
+	}, {
+		desc: "models not completed",
+		inFiles: map[string]string{
+			"acl==openconfig-acl==pass":         "",
+			commonci.ExpectedModelCountFileName: "2\n",
+			commonci.CompletedModelsFileName:    "acl==openconfig-acl==\n",
+		},
+		wantObject: commonci.ResultCacheObject(commonci.ResultCacheKey("inputs", nil)),
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.inFiles {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			bucket := &commonci.MemoryBucket{Bucket: bucketName}
+			if err := cacheResults(context.Background(), bucket, "inputs", dir); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := bucket.Object(tt.wantObject); ok != tt.wantUploaded {
+				t.Errorf("got objects %v, want %s uploaded: %v", bucket.Objects(), tt.wantObject, tt.wantUploaded)
+			}
+			if tt.wantUploaded && !bucket.Private(tt.wantObject) {
+				t.Errorf("cached results %s were uploaded publicly", tt.wantObject)
+			}
+		})
+	}
+}
+
+func TestDryRunGitHub(t *testing.T) {
+	owner, repo, prNumber, commitSHA = "o", "r", 1, "abc"
+	defer func() { owner, repo, prNumber, commitSHA = "", "", 0, "" }()
+
+	var b strings.Builder
+	g := &dryRunGitHub{w: &b}
+	url, gistID, err := g.CreateCIOutputGist("pyang", "run output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, title := range []string{"pyang", "pyang (condensed)"} {
+		if _, err := g.AddGistComment(gistID, title, "results"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := postBreakingChangeLabel(g, nil); err != nil {
+		t.Error(err)
+	}
+	status := resultStatus(commonci.Validators["pyang"], "pyang", "", "pyang", url+"#gistcomment-2", false)
+	if err := g.UpdatePRStatus(status); err != nil {
+		t.Error(err)
+	}
+	if err := g.CreateCheckRun(resultCheckRun(status, false, []*commonci.CheckRunAnnotation{{Path: "release/models/acl/openconfig-acl.yang", StartLine: 10, EndLine: 10, Level: "failure", Title: "BAD_VALUE", Message: "bad value"}})); err != nil {
+		t.Error(err)
+	}
+
+	want := `dry run: would create gist "pyang":
+run output
+dry run: would add gist comment 1 to gist dry-run:
+# pyang
+results
+dry run: would add gist comment 2 to gist dry-run:
+# pyang (condensed)
+results
+dry run: would apply label "non-breaking" (colour 00FF00) to o/r#1
+dry run: would remove label "breaking" from o/r#1
+dry run: would post comment "ajor YANG version changes in commit" on o/r#1:
+No major YANG version changes in commit abc
+dry run: would post failure status "pyang" to o/r@abc: pyang Failed (https://gist.github.com/dry-run#gistcomment-2)
+dry run: would create failure check run "pyang" on o/r@abc: pyang Failed (https://gist.github.com/dry-run#gistcomment-2)
+1 message(s) annotated. See the [full results](https://gist.github.com/dry-run#gistcomment-2).
+  failure release/models/acl/openconfig-acl.yang:10 BAD_VALUE: bad value
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/report"
+)
+
+const (
+	// pyangMatrixDir is the directory within the bucket where the pyang
+	// version matrix pages are stored.
+	pyangMatrixDir = "pyang-matrix"
+	// shadowPyangMatrixDir is the staging directory within the bucket
+	// where the pyang version matrix pages are stored when running in
+	// shadow mode.
+	shadowPyangMatrixDir = "pyang-matrix-shadow"
+)
+
+// hasResultsDir returns whether the validator has its own results directory,
+// i.e. it isn't the compatibility report or the pyang version matrix, which
+// report the results of other validators.
+func hasResultsDir(validatorId string) bool {
+	return validatorId != "compat-report" && validatorId != "pyang-matrix"
+}
+
+// pyangMatrixObject returns the name of the object storing the repo's pyang
+// version matrix page for the commit being validated.
+func pyangMatrixObject() string {
+	dir := pyangMatrixDir
+	if commonci.ShadowMode {
+		dir = shadowPyangMatrixDir
+	}
+	return fmt.Sprintf("%s/%s/%s.html", dir, strings.ReplaceAll(repoSlug, "/", "-"), commitSHA)
+}
+
+// plannedPyangVersions returns the pyang versions run by the CI in the order
+// that they're planned, i.e. with the latest version ("") first.
+func plannedPyangVersions() ([]string, error) {
+	plan, err := commonci.ReadPlan(commonci.PlanFile)
+	if err != nil || plan == nil {
+		return nil, err
+	}
+	var versions []string
+	for _, v := range plan.Validators {
+		if v.ValidatorId == "pyang" {
+			versions = append(versions, v.Version)
+		}
+	}
+	return versions, nil
+}
+
+// uploadPyangMatrix uploads the page tabulating the results of each model for
+// each of the pyang versions, whose results directories are within
+// resultsRoot, into cloud storage using client. It returns the
+// page's URL, whether every model passed with every version, and the models
+// whose results differ between versions.
+func uploadPyangMatrix(ctx context.Context, client commonci.StorageClient, resultsRoot string, versions []string) (string, bool, []string, error) {
+	var resultsDirs []string
+	for _, version := range versions {
+		resultsDirs = append(resultsDirs, filepath.Join(resultsRoot, commonci.AppendVersionToName("pyang", version)))
+	}
+	page, pass, differing, err := report.VersionMatrix("pyang", versions, resultsDirs)
+	if err != nil {
+		return "", false, nil, err
+	}
+	object := pyangMatrixObject()
+	if err := client.UploadPublic(ctx, object, []byte(page)); err != nil {
+		return "", false, nil, commonci.WithExitCode(commonci.ExitInfraError, fmt.Errorf("couldn't upload pyang version matrix: %w", err))
+	}
+	url, err := client.ObjectURL(ctx, object)
+	if err != nil {
+		return "", false, nil, commonci.WithExitCode(commonci.ExitInfraError, fmt.Errorf("couldn't get the URL of the pyang version matrix: %w", err))
+	}
+	return url, pass, differing, nil
+}
+
+// pyangMatrixStatus returns the PR status of the pyang version matrix linking
+// to its page given whether every model passed with every version, and the
+// models whose results differ between versions.
+func pyangMatrixStatus(validator *commonci.Validator, url string, pass bool, differing []string) *commonci.GithubPRUpdate {
+	update := &commonci.GithubPRUpdate{
+		Owner:       owner,
+		Repo:        repo,
+		Ref:         commitSHA,
+		URL:         url,
+		Context:     validator.StatusName(""),
+		NewStatus:   "success",
+		Description: validator.Name + " Succeeded",
+	}
+	switch {
+	case len(differing) > 0:
+		update.NewStatus = "failure"
+		update.Description = fmt.Sprintf("%s Failed: %d model(s) differ between versions", validator.Name, len(differing))
+	case !pass:
+		update.NewStatus = "failure"
+		update.Description = validator.Name + " Failed"
+	}
+	return update
+}
+
+// postPyangMatrix uploads the pyang version matrix and posts its PR status,
+// if pyang runs at more than one version.
+func postPyangMatrix() error {
+	validator, ok := commonci.Validators["pyang-matrix"]
+	if !ok {
+		return fmt.Errorf("CI infra failure: pyang version matrix validator not found in commonci.Validators")
+	}
+	versions, err := plannedPyangVersions()
+	if err != nil {
+		return fmt.Errorf("postPyangMatrix: %v", err)
+	}
+	if len(versions) < 2 {
+		log.Printf("Skipping pyang version matrix -- pyang runs at %d version(s).", len(versions))
+		return nil
+	}
+
+	url, pass, differing, err := uploadPyangMatrix(context.Background(), storageClient, resultsRoot, versions)
+	if err != nil {
+		return fmt.Errorf("postPyangMatrix: %w", err)
+	}
+	if len(differing) > 0 {
+		log.Printf("pyang results differ between versions for: %s", strings.Join(differing, ", "))
+	}
+
+	var g githubClient
+	if err := commonci.Retry(5, "NewGitHubRequestHandler", func() error {
+		g, err = newGitHubClient()
+		return err
+	}); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("postPyangMatrix: %w", err))
+	}
+	if err := g.UpdatePRStatus(pyangMatrixStatus(validator, url, pass, differing)); err != nil {
+		return commonci.WithExitCode(commonci.ExitGitHubError, fmt.Errorf("postPyangMatrix: couldn't update PR: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/commonci"
+)
+
+func TestUploadPyangMatrix(t *testing.T) {
+	repoSlug, commitSHA = "openconfig/public", "abc"
+	defer func() { repoSlug, commitSHA = "", "" }()
+
+	resultsRoot := t.TempDir()
+	for version, status := range map[string]string{"": "pass", "head": "fail"} {
+		resultsDir := filepath.Join(resultsRoot, commonci.AppendVersionToName("pyang", version))
+		if err := os.MkdirAll(resultsDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(resultsDir, "acl==openconfig-acl=="+status), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	client := &commonci.MemoryBucket{Bucket: bucketName}
+	url, pass, differing, err := uploadPyangMatrix(ctx, client, resultsRoot, []string{"", "head"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	object := "pyang-matrix/openconfig-public/abc.html"
+	if want := "https://storage.googleapis.com/openconfig/" + object; url != want {
+		t.Errorf("got URL %q, want %q", url, want)
+	}
+	if pass {
+		t.Errorf("got pass, want failure")
+	}
+	if diff := cmp.Diff([]string{"acl/openconfig-acl"}, differing); diff != "" {
+		t.Errorf("differing models (-want, +got):\n%s", diff)
+	}
+	bs, err := client.Download(ctx, object)
+	if err != nil {
+		t.Fatalf("matrix page not uploaded: %v", err)
+	}
+	if !strings.Contains(string(bs), "<th>latest</th><th>head</th>") {
+		t.Errorf("matrix page missing version headers:\n%s", bs)
+	}
+}
+
+func TestPyangMatrixStatus(t *testing.T) {
+	owner, repo, commitSHA = "openconfig", "public", "abc"
+	defer func() { owner, repo, commitSHA = "", "", "" }()
+
+	tests := []struct {
+		name            string
+		inPass          bool
+		inDiffering     []string
+		wantStatus      string
+		wantDescription string
+	}{{
+		name:            "all pass",
+		inPass:          true,
+		wantStatus:      "success",
+		wantDescription: "pyang version matrix Succeeded",
+	}, {
+		name:            "results differ",
+		inDiffering:     []string{"acl/openconfig-acl", "aft/openconfig-aft"},
+		wantStatus:      "failure",
+		wantDescription: "pyang version matrix Failed: 2 model(s) differ between versions",
+	}, {
+		name:            "fails with every version",
+		wantStatus:      "failure",
+		wantDescription: "pyang version matrix Failed",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &commonci.GithubPRUpdate{
+				Owner:       "openconfig",
+				Repo:        "public",
+				Ref:         "abc",
+				URL:         "https://storage.googleapis.com/openconfig/pyang-matrix/openconfig-public/abc.html",
+				Context:     "pyang version matrix",
+				NewStatus:   tt.wantStatus,
+				Description: tt.wantDescription,
+			}
+			got := pyangMatrixStatus(commonci.Validators["pyang-matrix"], want.URL, tt.inPass, tt.inDiffering)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/models-ci/commonci"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	rpb "github.com/openconfig/models-ci/proto/reporter"
+)
+
+// minReporterTokenLength is the minimum length of the reporter service's
+// per-run token.
+const minReporterTokenLength = 32
+
+// readReporterToken reads the per-run token authenticating calls to the
+// reporter service from the file at path, which is given to both the service
+// and its clients.
+func readReporterToken(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("the reporter service requires a per-run token given by -reporter-token-file")
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read reporter token file: %v", err)
+	}
+	token := strings.TrimSpace(string(bs))
+	if len(token) < minReporterTokenLength {
+		return "", fmt.Errorf("reporter token within %q must have at least %d characters", path, minReporterTokenLength)
+	}
+	return token, nil
+}
+
+// tokenAuthInterceptor rejects the calls to the reporter service that don't
+// carry the per-run token as their bearer token.
+func tokenAuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	want := []byte("Bearer " + token)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, got := range md.Get("authorization") {
+			if subtle.ConstantTimeCompare([]byte(got), want) == 1 {
+				return handler(ctx, req)
+			}
+		}
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid reporter token")
+	}
+}
+
+// tokenCredentials attaches the per-run token to each call to the reporter
+// service.
+type tokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
+
+// reporterServer is the gRPC reporter service. It writes the submitted
+// results into the results directory in the same layout as the validator
+// scripts, and posts the results of each validator once they're complete.
+type reporterServer struct {
+	rpb.UnimplementedReporterServer
+
+	resultsDir string
+	post       func(validatorId, version string) error
+
+	// mu serializes the submissions, and hence the posting of results.
+	mu sync.Mutex
+	// completed are the <validatorId>[@<version>] names of the validators
+	// whose results were completed by SubmitValidatorResult.
+	completed map[string]bool
+	posted    []string
+	failed    []string
+
+	finalizeOnce sync.Once
+	// finalized is closed by FinalizeRun.
+	finalized chan struct{}
+}
+
+// newReporterServer returns a reporter service writing results into
+// resultsDir and posting the results of each validator via post.
+func newReporterServer(resultsDir string, post func(validatorId, version string) error) *reporterServer {
+	return &reporterServer{
+		resultsDir: resultsDir,
+		post:       post,
+		completed:  map[string]bool{},
+		finalized:  make(chan struct{}),
+	}
+}
+
+// validName returns whether name can be used as a component of a result file
+// name.
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`) && !strings.Contains(name, "==")
+}
+
+// validatorName returns the <validatorId>[@<version>] name of a submitted
+// validator, which is the name of its results directory.
+func validatorName(v *rpb.Validator) (string, error) {
+	if _, ok := commonci.Validators[v.GetId()]; !ok {
+		return "", status.Errorf(codes.InvalidArgument, "unrecognized validator %q", v.GetId())
+	}
+	if v.GetVersion() != "" && !validName(v.GetVersion()) {
+		return "", status.Errorf(codes.InvalidArgument, "invalid version %q", v.GetVersion())
+	}
+	return commonci.AppendVersionToName(v.GetId(), v.GetVersion()), nil
+}
+
+// validatorDir returns the results directory of a submitted validator, which
+// must not have been completed.
+func (s *reporterServer) validatorDir(v *rpb.Validator) (string, error) {
+	name, err := validatorName(v)
+	if err != nil {
+		return "", err
+	}
+	if s.completed[name] {
+		return "", status.Errorf(codes.FailedPrecondition, "results of %s were already completed", name)
+	}
+	dir := filepath.Join(s.resultsDir, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", status.Errorf(codes.Internal, "error while creating directory %q: %v", dir, err)
+	}
+	return dir, nil
+}
+
+// SubmitModelResult writes the "modelDir==model==status" result file, along
+// with the "cmd" file if the command is known.
+func (s *reporterServer) SubmitModelResult(ctx context.Context, req *rpb.SubmitModelResultRequest) (*rpb.SubmitModelResultResponse, error) {
+	if !validName(req.GetModelDir()) || !validName(req.GetModel()) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid model directory %q or model %q", req.GetModelDir(), req.GetModel())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir, err := s.validatorDir(req.GetValidator())
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Join(dir, req.GetModelDir()+"=="+req.GetModel()+"==")
+	if req.GetCmd() != "" {
+		if err := os.WriteFile(prefix+"cmd", []byte(req.GetCmd()), 0644); err != nil {
+			return nil, status.Errorf(codes.Internal, "error while writing command file: %v", err)
+		}
+	}
+	resultStatus := commonci.BoolStatusToString(req.GetPass())
+	if req.GetTimedOut() {
+		resultStatus = commonci.TimeoutStatus
+	}
+	if err := os.WriteFile(prefix+resultStatus, []byte(req.GetOutput()), 0644); err != nil {
+		return nil, status.Errorf(codes.Internal, "error while writing result file: %v", err)
+	}
+	return &rpb.SubmitModelResultResponse{}, nil
+}
+
+// SubmitValidatorResult writes the validator's remaining result files, and
+// posts its results.
+func (s *reporterServer) SubmitValidatorResult(ctx context.Context, req *rpb.SubmitValidatorResultRequest) (*rpb.SubmitValidatorResultResponse, error) {
+	for _, f := range req.GetFiles() {
+		if !validName(f.GetName()) || f.GetName() == commonci.DoneFileName || f.GetName() == commonci.ExitedFileName {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid result file name %q", f.GetName())
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir, err := s.validatorDir(req.GetValidator())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range req.GetFiles() {
+		if err := os.WriteFile(filepath.Join(dir, f.GetName()), f.GetContent(), 0644); err != nil {
+			return nil, status.Errorf(codes.Internal, "error while writing result file: %v", err)
+		}
+	}
+	if err := markDone(dir); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	name := filepath.Base(dir)
+	s.completed[name] = true
+
+	log.Printf("posting results of %s", name)
+	if err := s.post(req.GetValidator().GetId(), req.GetValidator().GetVersion()); err != nil {
+		log.Printf("failed to post results of %s: %v", name, err)
+		s.failed = append(s.failed, name)
+		return nil, status.Errorf(codes.Internal, "failed to post results of %s: %v", name, err)
+	}
+	s.posted = append(s.posted, name)
+	return &rpb.SubmitValidatorResultResponse{}, nil
+}
+
+// FinalizeRun returns the validators whose results were posted or failed to
+// be posted, and signals the reporter to stop.
+func (s *reporterServer) FinalizeRun(ctx context.Context, req *rpb.FinalizeRunRequest) (*rpb.FinalizeRunResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.finalizeOnce.Do(func() { close(s.finalized) })
+	resp := &rpb.FinalizeRunResponse{
+		Posted: append([]string{}, s.posted...),
+		Failed: append([]string{}, s.failed...),
+	}
+	sort.Strings(resp.Posted)
+	sort.Strings(resp.Failed)
+	return resp, nil
+}
+
+// serveReporter serves the reporter service on lis until the run is finalized
+// or the timeout elapses, only accepting calls carrying the per-run token.
+func serveReporter(lis net.Listener, s *reporterServer, token string, timeout time.Duration) error {
+	g := grpc.NewServer(grpc.UnaryInterceptor(tokenAuthInterceptor(token)))
+	rpb.RegisterReporterServer(g, s)
+	errCh := make(chan error, 1)
+	go func() { errCh <- g.Serve(lis) }()
+
+	select {
+	case <-s.finalized:
+	case err := <-errCh:
+		return fmt.Errorf("reporter service failed: %v", err)
+	case <-time.After(timeout):
+		g.Stop()
+		return fmt.Errorf("timed out after %v while waiting for the run to be finalized", timeout)
+	}
+	// Let the FinalizeRun call complete.
+	g.GracefulStop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failed) > 0 {
+		sort.Strings(s.failed)
+		return fmt.Errorf("failed to post results of: %s", strings.Join(s.failed, ", "))
+	}
+	return nil
+}
+
+// dialReporter connects to the reporter service at addr, authenticating each
+// call with the per-run token.
+func dialReporter(addr string, useTLS bool, token string) (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(creds), grpc.WithPerRPCCredentials(tokenCredentials{token: token, requireTLS: useTLS}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to reporter service at %q: %v", addr, err)
+	}
+	return conn, nil
+}
+
+// submitResults submits the validator's results within resultsDir to the
+// reporter service, which then posts them.
+func submitResults(ctx context.Context, client rpb.ReporterClient, validatorId, version, resultsDir string) error {
+	v := &rpb.Validator{Id: validatorId, Version: version}
+	it, err := commonci.NewResultsIterator(resultsDir)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		r := it.Result()
+		if _, err := client.SubmitModelResult(ctx, &rpb.SubmitModelResultRequest{
+			Validator: v,
+			ModelDir:  r.ModelDir,
+			Model:     r.Model,
+			Pass:      r.Pass(),
+			Output:    r.Output,
+			Cmd:       r.Cmd,
+			TimedOut:  r.TimedOut(),
+		}); err != nil {
+			return fmt.Errorf("failed to submit result of model %s in %s: %v", r.Model, r.ModelDir, err)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read results directory %q: %v", resultsDir, err)
+	}
+	req := &rpb.SubmitValidatorResultRequest{Validator: v}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.Contains(entry.Name(), "==") || entry.Name() == commonci.DoneFileName || entry.Name() == commonci.ExitedFileName {
+			continue
+		}
+		bs, err := os.ReadFile(filepath.Join(resultsDir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read result file: %v", err)
+		}
+		req.Files = append(req.Files, &rpb.ResultFile{Name: entry.Name(), Content: bs})
+	}
+	if _, err := client.SubmitValidatorResult(ctx, req); err != nil {
+		return fmt.Errorf("failed to submit results of %s: %v", commonci.AppendVersionToName(validatorId, version), err)
+	}
+	return nil
+}
+
+// finalizeRun finalizes the run of the reporter service, and returns an error
+// if it failed to post the results of any validator.
+func finalizeRun(ctx context.Context, client rpb.ReporterClient) error {
+	resp, err := client.FinalizeRun(ctx, &rpb.FinalizeRunRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to finalize run: %v", err)
+	}
+	log.Printf("reporter service posted results of: %s", strings.Join(resp.GetPosted(), ", "))
+	if len(resp.GetFailed()) > 0 {
+		return fmt.Errorf("reporter service failed to post results of: %s", strings.Join(resp.GetFailed(), ", "))
+	}
+	return nil
+}
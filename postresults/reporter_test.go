@@ -0,0 +1,166 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	rpb "github.com/openconfig/models-ci/proto/reporter"
+)
+
+// readDirFiles returns the contents of the files within dir by name.
+func readDirFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, entry := range entries {
+		bs, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(bs)
+	}
+	return files
+}
+
+// testReporterToken is the per-run token of the reporter service in tests.
+const testReporterToken = "0123456789abcdef0123456789abcdef"
+
+func TestReadReporterToken(t *testing.T) {
+	dir := t.TempDir()
+	writeToken := func(name, token string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(token), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if got, err := readReporterToken(writeToken("token", testReporterToken+"\n")); err != nil || got != testReporterToken {
+		t.Errorf("got (%q, %v), want (%q, nil)", got, err, testReporterToken)
+	}
+	for desc, path := range map[string]string{
+		"no token file":   "",
+		"missing file":    filepath.Join(dir, "missing"),
+		"too short token": writeToken("short", "secret"),
+	} {
+		if _, err := readReporterToken(path); err == nil {
+			t.Errorf("%s: got no error", desc)
+		}
+	}
+}
+
+func TestReporterService(t *testing.T) {
+	srcDir := filepath.Join(t.TempDir(), "oc-pyang")
+	if err := os.Mkdir(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	srcFiles := map[string]string{
+		"acl==openconfig-acl==cmd":     "pyang openconfig-acl.yang",
+		"acl==openconfig-acl==pass":    "",
+		"bgp==openconfig-bgp==fail":    "openconfig-bgp.yang:1: error",
+		"aft==openconfig-aft==timeout": "timed out after 600s",
+		"out":                          "stdout",
+		"fail":                         "stderr",
+	}
+	for name, content := range srcFiles {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resultsDir := t.TempDir()
+	var posted []string
+	var gotFiles map[string]string
+	s := newReporterServer(resultsDir, func(validatorId, version string) error {
+		posted = append(posted, validatorId+" "+version)
+		if validatorId == "pyang" {
+			return errors.New("GitHub is down")
+		}
+		gotFiles = readDirFiles(t, filepath.Join(resultsDir, validatorId))
+		return nil
+	})
+
+	lis := bufconn.Listen(1 << 20)
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- serveReporter(lis, s, testReporterToken, time.Minute) }()
+	dial := func(token string) rpb.ReporterClient {
+		conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}), grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithPerRPCCredentials(tokenCredentials{token: token}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return rpb.NewReporterClient(conn)
+	}
+	client := dial(testReporterToken)
+	ctx := context.Background()
+
+	// Calls without the run's token are rejected.
+	if _, err := dial(strings.Repeat("x", minReporterTokenLength)).FinalizeRun(ctx, &rpb.FinalizeRunRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call with wrong token: got error %v, want Unauthenticated", err)
+	}
+
+	if err := submitResults(ctx, client, "oc-pyang", "", srcDir); err != nil {
+		t.Fatalf("submitResults: %v", err)
+	}
+	wantFiles := map[string]string{"done": ""}
+	for name, content := range srcFiles {
+		wantFiles[name] = content
+	}
+	if diff := cmp.Diff(wantFiles, gotFiles); diff != "" {
+		t.Errorf("posted results directory (-want, +got):\n%s", diff)
+	}
+
+	if err := submitResults(ctx, client, "oc-pyang", "", srcDir); err == nil || !strings.Contains(err.Error(), "already completed") {
+		t.Errorf("resubmission: got error %v, want already completed error", err)
+	}
+	if _, err := client.SubmitModelResult(ctx, &rpb.SubmitModelResultRequest{Validator: &rpb.Validator{Id: "pyang"}, ModelDir: "../acl", Model: "openconfig-acl"}); err == nil {
+		t.Errorf("invalid model directory: got no error")
+	}
+	if _, err := client.SubmitValidatorResult(ctx, &rpb.SubmitValidatorResultRequest{Validator: &rpb.Validator{Id: "nonexistent"}}); err == nil {
+		t.Errorf("unrecognized validator: got no error")
+	}
+	if _, err := client.SubmitValidatorResult(ctx, &rpb.SubmitValidatorResultRequest{Validator: &rpb.Validator{Id: "pyang", Version: "head"}}); err == nil {
+		t.Errorf("failed posting: got no error")
+	}
+
+	if err := finalizeRun(ctx, client); err == nil || !strings.Contains(err.Error(), "pyang@head") {
+		t.Errorf("finalizeRun: got error %v, want error for failing to post pyang@head", err)
+	}
+	if err := <-serveErr; err == nil || !strings.Contains(err.Error(), "pyang@head") {
+		t.Errorf("serveReporter: got error %v, want error for failing to post pyang@head", err)
+	}
+	if diff := cmp.Diff([]string{"oc-pyang ", "pyang head"}, posted); diff != "" {
+		t.Errorf("posted results (-want, +got):\n%s", diff)
+	}
+}
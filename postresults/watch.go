@@ -0,0 +1,166 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// markDone signals the reporter service that the validator's results within
+// resultsDir are complete.
+func markDone(resultsDir string) error {
+	donePath := filepath.Join(resultsDir, commonci.DoneFileName)
+	if err := commonci.WriteFile(donePath, nil, 0444); err != nil {
+		return fmt.Errorf("error while writing done file %q: %v", donePath, err)
+	}
+	return nil
+}
+
+// markExited signals the reporter service that the validator step running
+// the validator has exited, within the results directory of each of the
+// validator's versions under resultsRoot. It's called by the step's exit trap,
+// such that a step that exits without marking its results done isn't waited
+// for.
+func markExited(resultsRoot, validatorId string) error {
+	if validatorId == "" {
+		return fmt.Errorf("no validator whose step exited")
+	}
+	dirs, err := filepath.Glob(filepath.Join(resultsRoot, validatorId+"@*"))
+	if err != nil {
+		return err
+	}
+	for _, dir := range append([]string{filepath.Join(resultsRoot, validatorId)}, dirs...) {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		exitedPath := filepath.Join(dir, commonci.ExitedFileName)
+		if err := commonci.WriteFile(exitedPath, nil, 0444); err != nil {
+			return fmt.Errorf("error while writing exited file %q: %v", exitedPath, err)
+		}
+	}
+	return nil
+}
+
+// exists returns whether the file at path exists.
+func exists(path string) (bool, error) {
+	switch _, err := os.Stat(path); {
+	case errors.Is(err, fs.ErrNotExist):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to stat %q: %v", path, err)
+	}
+	return true, nil
+}
+
+// scanResults returns the sorted <validatorId>[@<version>] names of the
+// validators whose results directories within resultsDir are done and which
+// haven't been posted, those of the validators whose steps exited without
+// marking their results done, along with the number of validators that are
+// still running.
+func scanResults(resultsDir string, posted map[string]bool) ([]string, []string, int, error) {
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to read results directory %q: %v", resultsDir, err)
+	}
+	var done, exited []string
+	running := 0
+	for _, entry := range entries {
+		if !entry.IsDir() || posted[entry.Name()] {
+			continue
+		}
+		isDone, err := exists(filepath.Join(resultsDir, entry.Name(), commonci.DoneFileName))
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		// The step's exit trap runs after it marked its results done.
+		hasExited, err := exists(filepath.Join(resultsDir, entry.Name(), commonci.ExitedFileName))
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		switch {
+		case isDone:
+			done = append(done, entry.Name())
+		case hasExited:
+			exited = append(exited, entry.Name())
+		default:
+			running++
+		}
+	}
+	sort.Strings(done)
+	sort.Strings(exited)
+	return done, exited, running, nil
+}
+
+// splitValidatorName splits a <validatorId>[@<version>] name.
+func splitValidatorName(name string) (string, string) {
+	segments := strings.SplitN(name, "@", 2)
+	if len(segments) == 2 {
+		return segments[0], segments[1]
+	}
+	return segments[0], ""
+}
+
+// watchResults posts the results of each validator within resultsDir, via
+// post, as soon as they're done, until all of them have been posted or the
+// timeout elapses. A failure to post a validator's results doesn't prevent
+// posting the others. A validator whose step exited without marking its
+// results done is reported via exited rather than waited for, and fails the
+// watch.
+func watchResults(resultsDir string, interval, timeout time.Duration, post func(validatorId, version string) error, exited func(validatorId, version string)) error {
+	start := time.Now()
+	posted := map[string]bool{}
+	var failed []string
+	for {
+		done, exitedNames, running, err := scanResults(resultsDir, posted)
+		if err != nil {
+			return err
+		}
+		for _, name := range done {
+			posted[name] = true
+			validatorId, version := splitValidatorName(name)
+			log.Printf("posting results of %s", name)
+			if err := post(validatorId, version); err != nil {
+				log.Printf("failed to post results of %s: %v", name, err)
+				failed = append(failed, name)
+			}
+		}
+		for _, name := range exitedNames {
+			posted[name] = true
+			log.Printf("validator step of %s exited without marking its results done", name)
+			exited(splitValidatorName(name))
+			failed = append(failed, name)
+		}
+
+		switch {
+		case running == 0 && len(failed) > 0:
+			return fmt.Errorf("failed to post results of: %s", strings.Join(failed, ", "))
+		case running == 0:
+			return nil
+		case time.Since(start) > timeout:
+			return fmt.Errorf("timed out after %v while waiting for %d validators to complete", timeout, running)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postresults
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatchResults(t *testing.T) {
+	resultsDir := t.TempDir()
+	for _, name := range []string{"pyang", "pyang@head", "oc-pyang"} {
+		if err := os.Mkdir(filepath.Join(resultsDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"pyang", "pyang@head"} {
+		if err := markDone(filepath.Join(resultsDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var posted []string
+	post := func(validatorId, version string) error {
+		posted = append(posted, validatorId+" "+version)
+		if validatorId == "pyang" && version == "" {
+			// The last validator finishes while the first results
+			// are being posted.
+			if err := markDone(filepath.Join(resultsDir, "oc-pyang")); err != nil {
+				t.Fatal(err)
+			}
+			return errors.New("GitHub is down")
+		}
+		return nil
+	}
+
+	err := watchResults(resultsDir, time.Millisecond, time.Minute, post, func(validatorId, version string) {
+		t.Errorf("unexpectedly reported %s as exited", validatorId)
+	})
+	if err == nil || !strings.Contains(err.Error(), "pyang") {
+		t.Errorf("got error %v, want error for failing to post pyang", err)
+	}
+	if diff := cmp.Diff([]string{"pyang ", "pyang head", "oc-pyang "}, posted); diff != "" {
+		t.Errorf("posted results (-want, +got):\n%s", diff)
+	}
+}
+
+func TestWatchResultsTimeout(t *testing.T) {
+	resultsDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(resultsDir, "pyang"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err := watchResults(resultsDir, time.Millisecond, 10*time.Millisecond, func(validatorId, version string) error {
+		t.Errorf("unexpectedly posted %s", validatorId)
+		return nil
+	}, func(validatorId, version string) {
+		t.Errorf("unexpectedly reported %s as exited", validatorId)
+	})
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("got error %v, want timeout", err)
+	}
+}
+
+func TestWatchResultsExited(t *testing.T) {
+	resultsDir := t.TempDir()
+	for _, name := range []string{"pyang", "pyang@2.5.0", "oc-pyang", "oc-pyang@1.0"} {
+		if err := os.Mkdir(filepath.Join(resultsDir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// pyang@2.5.0's step crashed before marking its results done, whereas
+	// oc-pyang's step exited normally.
+	for _, name := range []string{"pyang", "oc-pyang"} {
+		if err := markDone(filepath.Join(resultsDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, validatorId := range []string{"pyang", "oc-pyang"} {
+		if err := markExited(resultsDir, validatorId); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var posted, exited []string
+	err := watchResults(resultsDir, time.Millisecond, time.Minute, func(validatorId, version string) error {
+		posted = append(posted, validatorId+" "+version)
+		return nil
+	}, func(validatorId, version string) {
+		exited = append(exited, validatorId+" "+version)
+	})
+	if err == nil || !strings.Contains(err.Error(), "pyang@2.5.0") {
+		t.Errorf("got error %v, want error for pyang@2.5.0", err)
+	}
+	if diff := cmp.Diff([]string{"oc-pyang ", "pyang "}, posted); diff != "" {
+		t.Errorf("posted results (-want, +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"oc-pyang 1.0", "pyang 2.5.0"}, exited); diff != "" {
+		t.Errorf("exited validators (-want, +got):\n%s", diff)
+	}
+}
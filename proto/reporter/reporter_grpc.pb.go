@@ -0,0 +1,211 @@
+//
+// Copyright 2024 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: reporter.proto
+
+package reporter
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Reporter_SubmitModelResult_FullMethodName     = "/reporter.Reporter/SubmitModelResult"
+	Reporter_SubmitValidatorResult_FullMethodName = "/reporter.Reporter/SubmitValidatorResult"
+	Reporter_FinalizeRun_FullMethodName           = "/reporter.Reporter/FinalizeRun"
+)
+
+// ReporterClient is the client API for Reporter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ReporterClient interface {
+	// SubmitModelResult submits the result of a per-model validator on a
+	// single model.
+	SubmitModelResult(ctx context.Context, in *SubmitModelResultRequest, opts ...grpc.CallOption) (*SubmitModelResultResponse, error)
+	// SubmitValidatorResult completes the results of a validator, which are
+	// then posted. No more results of the validator may be submitted after it.
+	SubmitValidatorResult(ctx context.Context, in *SubmitValidatorResultRequest, opts ...grpc.CallOption) (*SubmitValidatorResultResponse, error)
+	// FinalizeRun indicates that no more results will be submitted, after
+	// which the reporter stops.
+	FinalizeRun(ctx context.Context, in *FinalizeRunRequest, opts ...grpc.CallOption) (*FinalizeRunResponse, error)
+}
+
+type reporterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReporterClient(cc grpc.ClientConnInterface) ReporterClient {
+	return &reporterClient{cc}
+}
+
+func (c *reporterClient) SubmitModelResult(ctx context.Context, in *SubmitModelResultRequest, opts ...grpc.CallOption) (*SubmitModelResultResponse, error) {
+	out := new(SubmitModelResultResponse)
+	err := c.cc.Invoke(ctx, Reporter_SubmitModelResult_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reporterClient) SubmitValidatorResult(ctx context.Context, in *SubmitValidatorResultRequest, opts ...grpc.CallOption) (*SubmitValidatorResultResponse, error) {
+	out := new(SubmitValidatorResultResponse)
+	err := c.cc.Invoke(ctx, Reporter_SubmitValidatorResult_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reporterClient) FinalizeRun(ctx context.Context, in *FinalizeRunRequest, opts ...grpc.CallOption) (*FinalizeRunResponse, error) {
+	out := new(FinalizeRunResponse)
+	err := c.cc.Invoke(ctx, Reporter_FinalizeRun_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReporterServer is the server API for Reporter service.
+// All implementations must embed UnimplementedReporterServer
+// for forward compatibility
+type ReporterServer interface {
+	// SubmitModelResult submits the result of a per-model validator on a
+	// single model.
+	SubmitModelResult(context.Context, *SubmitModelResultRequest) (*SubmitModelResultResponse, error)
+	// SubmitValidatorResult completes the results of a validator, which are
+	// then posted. No more results of the validator may be submitted after it.
+	SubmitValidatorResult(context.Context, *SubmitValidatorResultRequest) (*SubmitValidatorResultResponse, error)
+	// FinalizeRun indicates that no more results will be submitted, after
+	// which the reporter stops.
+	FinalizeRun(context.Context, *FinalizeRunRequest) (*FinalizeRunResponse, error)
+	mustEmbedUnimplementedReporterServer()
+}
+
+// UnimplementedReporterServer must be embedded to have forward compatible implementations.
+type UnimplementedReporterServer struct {
+}
+
+func (UnimplementedReporterServer) SubmitModelResult(context.Context, *SubmitModelResultRequest) (*SubmitModelResultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitModelResult not implemented")
+}
+func (UnimplementedReporterServer) SubmitValidatorResult(context.Context, *SubmitValidatorResultRequest) (*SubmitValidatorResultResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitValidatorResult not implemented")
+}
+func (UnimplementedReporterServer) FinalizeRun(context.Context, *FinalizeRunRequest) (*FinalizeRunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FinalizeRun not implemented")
+}
+func (UnimplementedReporterServer) mustEmbedUnimplementedReporterServer() {}
+
+// UnsafeReporterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReporterServer will
+// result in compilation errors.
+type UnsafeReporterServer interface {
+	mustEmbedUnimplementedReporterServer()
+}
+
+func RegisterReporterServer(s grpc.ServiceRegistrar, srv ReporterServer) {
+	s.RegisterService(&Reporter_ServiceDesc, srv)
+}
+
+func _Reporter_SubmitModelResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitModelResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReporterServer).SubmitModelResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reporter_SubmitModelResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReporterServer).SubmitModelResult(ctx, req.(*SubmitModelResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reporter_SubmitValidatorResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitValidatorResultRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReporterServer).SubmitValidatorResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reporter_SubmitValidatorResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReporterServer).SubmitValidatorResult(ctx, req.(*SubmitValidatorResultRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reporter_FinalizeRun_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FinalizeRunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReporterServer).FinalizeRun(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reporter_FinalizeRun_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReporterServer).FinalizeRun(ctx, req.(*FinalizeRunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Reporter_ServiceDesc is the grpc.ServiceDesc for Reporter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (not even as a copy)
+var Reporter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "reporter.Reporter",
+	HandlerType: (*ReporterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitModelResult",
+			Handler:    _Reporter_SubmitModelResult_Handler,
+		},
+		{
+			MethodName: "SubmitValidatorResult",
+			Handler:    _Reporter_SubmitValidatorResult_Handler,
+		},
+		{
+			MethodName: "FinalizeRun",
+			Handler:    _Reporter_FinalizeRun_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "reporter.proto",
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// junitTestSuites is the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []*junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite contains the test cases of a validator.
+type junitTestSuite struct {
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is the result of a single model, or of a single check of
+// misc-checks. It has a failure if the validation failed, or an error if the
+// validator itself failed.
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+// junitProblem is the failure or error of a test case.
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Output  string `xml:",cdata"`
+}
+
+// newJUnitProblem returns a failure or error of a test case with the given
+// message and validator output, from which the characters that XML forbids
+// (e.g. the escape characters of colored output) are removed.
+func newJUnitProblem(message, output string) *junitProblem {
+	return &junitProblem{Message: message, Output: strings.Map(xmlChar, output)}
+}
+
+// xmlChar returns r if it's allowed within an XML document, and otherwise -1
+// such that strings.Map drops it.
+func xmlChar(r rune) rune {
+	switch {
+	case r == '\t', r == '\n', r == '\r',
+		r >= 0x20 && r <= 0xD7FF,
+		r >= 0xE000 && r <= 0xFFFD,
+		r >= 0x10000 && r <= 0x10FFFF:
+		return r
+	}
+	return -1
+}
+
+// add adds the test case to the suite, counting its failure or error.
+func (s *junitTestSuite) add(c *junitTestCase) {
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	if c.Failure != nil {
+		s.Failures++
+	}
+	if c.Error != nil {
+		s.Errors++
+	}
+}
+
+// JUnit returns the JUnit XML report of the results of the given validator
+// version, within which each model is a test case, whose class name is its
+// model directory. Each check of misc-checks is instead a test case, and a
+// validator that isn't per-model is a single test case. A failure of the
+// validator script of a per-model validator is reported as an error. The
+// outcome of misc-checks, as processed by ReadMiscChecksOutcome, is given
+// since it's also written by WriteMiscChecksOutcome, and may be nil if it
+// couldn't be processed.
+func JUnit(validatorId, version, resultsDir string, miscChecksOutcome *MiscChecksOutcome) ([]byte, error) {
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return nil, fmt.Errorf("validator %q not found", validatorId)
+	}
+	suiteName := commonci.AppendVersionToName(validatorId, version)
+	suite := &junitTestSuite{Name: suiteName}
+
+	failFileBytes, err := os.ReadFile(filepath.Join(resultsDir, commonci.FailFileName))
+	// existent fail file == failure.
+	executionFailed := err == nil
+
+	switch {
+	case !validator.IsPerModel:
+		c := &junitTestCase{ClassName: suiteName, Name: validator.Name}
+		if executionFailed {
+			c.Failure = newJUnitProblem("failed", string(failFileBytes))
+		}
+		suite.add(c)
+	case validatorId == "misc-checks":
+		if miscChecksOutcome == nil && !executionFailed {
+			return nil, fmt.Errorf("no outcome of the misc-checks checks")
+		}
+		if miscChecksOutcome != nil {
+			for _, check := range miscChecksOutcome.Checks {
+				c := &junitTestCase{ClassName: suiteName, Name: check.Description}
+				if !check.Pass {
+					var messages []string
+					for _, v := range check.Violations {
+						messages = append(messages, v.Message)
+					}
+					c.Failure = newJUnitProblem(fmt.Sprintf("%d violation(s)", len(check.Violations)), strings.Join(messages, "\n"))
+				}
+				suite.add(c)
+			}
+		}
+	default:
+		if err := addModelTestCases(suite, validatorId, resultsDir); err != nil {
+			return nil, err
+		}
+	}
+	if executionFailed && validator.IsPerModel {
+		// An execution failure suggests a CI infra failure.
+		suite.add(&junitTestCase{ClassName: suiteName, Name: "validator script", Error: newJUnitProblem("validator script failed", string(failFileBytes))})
+	}
+
+	bs, err := xml.MarshalIndent(&junitTestSuites{Suites: []*junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JUnit report of %s: %v", suiteName, err)
+	}
+	return append([]byte(xml.Header), append(bs, '\n')...), nil
+}
+
+// addModelTestCases adds the test case of each model within the per-model
+// validator's results directory to the suite. A model whose lint errors are
+// all waived passes, as in the report.
+func addModelTestCases(suite *junitTestSuite, validatorId, resultsDir string) error {
+	var prevModelDirName string
+	var waivers *modelLintWaivers
+	it, err := commonci.NewResultsIterator(resultsDir)
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		result := it.Result()
+		if validatorId == "oc-pyang" && result.ModelDir != prevModelDirName {
+			if waivers, err = readModelLintWaivers(result.ModelDir); err != nil {
+				// The report notes broken waivers files.
+				waivers = nil
+			}
+		}
+		prevModelDirName = result.ModelDir
+
+		c := &junitTestCase{ClassName: strings.ReplaceAll(result.ModelDir, ":", "/"), Name: result.Model}
+		switch {
+		case result.TimedOut():
+			c.Failure = newJUnitProblem("timed out", result.Output)
+		case !result.Pass():
+			waived, err := lintErrorsWaived(result.Output, waivers)
+			if err != nil {
+				return fmt.Errorf("error encountered while applying lint waivers for validator %q: %v", validatorId, err)
+			}
+			if !waived {
+				c.Failure = newJUnitProblem("failed", result.Output)
+			}
+		}
+		suite.add(c)
+	}
+	return it.Err()
+}
+
+// WriteJUnit writes the JUnit XML report of the results of the given
+// validator version into its results directory as commonci.JUnitFileName.
+func WriteJUnit(validatorId, version, resultsDir string, miscChecksOutcome *MiscChecksOutcome) error {
+	bs, err := JUnit(validatorId, version, resultsDir, miscChecksOutcome)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(resultsDir, commonci.JUnitFileName)
+	if err := commonci.WriteFile(path, bs, 0644); err != nil {
+		return fmt.Errorf("error while writing JUnit report %q: %v", path, err)
+	}
+	return nil
+}
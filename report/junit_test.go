@@ -0,0 +1,164 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/models-ci/commonci"
+)
+
+func TestJUnit(t *testing.T) {
+	tests := []struct {
+		name                 string
+		inValidatorId        string
+		inVersion            string
+		inValidatorResultDir string
+		want                 string
+	}{{
+		name:                 "per-model validator",
+		inValidatorId:        "pyang",
+		inVersion:            "head",
+		inValidatorResultDir: "testdata/pyang-counts",
+		want: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="pyang@head" tests="3" failures="1" errors="0">
+    <testcase classname="acl" name="openconfig-acl">
+      <failure message="failed"><![CDATA[messages:{path:"/workspace/release/yang/acl/openconfig-acl.yang" line:10 code:"BAD_VALUE" type:"error" level:1 message:'bad value'}
+messages:{path:"/workspace/release/yang/acl/openconfig-acl.yang" line:12 code:"LINT_FOO" type:"warning" level:4 message:'it's a warning'}
+]]></failure>
+    </testcase>
+    <testcase classname="optical-transport" name="openconfig-optical-amplifier"></testcase>
+    <testcase classname="optical-transport" name="openconfig-transport-line-protection"></testcase>
+  </testsuite>
+</testsuites>
+`,
+	}, {
+		name:                 "per-model validator script failure",
+		inValidatorId:        "oc-pyang",
+		inValidatorResultDir: "testdata/oc-pyang-with-fail-file",
+		want: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="oc-pyang" tests="4" failures="0" errors="1">
+    <testcase classname="acl" name="openconfig-acl"></testcase>
+    <testcase classname="optical-transport" name="openconfig-optical-amplifier"></testcase>
+    <testcase classname="optical-transport" name="openconfig-transport-line-protection"></testcase>
+    <testcase classname="oc-pyang" name="validator script">
+      <error message="validator script failed"></error>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+	}, {
+		name:                 "repo-level validator",
+		inValidatorId:        "regexp",
+		inValidatorResultDir: "testdata/regexp-tests-fail",
+		want: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="regexp" tests="1" failures="1" errors="0">
+    <testcase classname="regexp" name="regexp tests">
+      <failure message="failed"><![CDATA[I failed
+]]></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+	}, {
+		name:                 "output with characters XML forbids",
+		inValidatorId:        "regexp",
+		inValidatorResultDir: "testdata/regexp-colored-fail",
+		want: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="regexp" tests="1" failures="1" errors="0">
+    <testcase classname="regexp" name="regexp tests">
+      <failure message="failed"><![CDATA[test [31mfailed[0m
+]]></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JUnit(tt.inValidatorId, tt.inVersion, tt.inValidatorResultDir, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+			if err := xml.Unmarshal(got, &junitTestSuites{}); err != nil {
+				t.Errorf("report doesn't parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestWriteJUnitMiscChecks(t *testing.T) {
+	resultsDir := t.TempDir()
+	entries, err := os.ReadDir("testdata/misc-checks-fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		bs, err := os.ReadFile(filepath.Join("testdata/misc-checks-fail", entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(resultsDir, entry.Name()), bs, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outcome, err := ReadMiscChecksOutcome(resultsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteJUnit("misc-checks", "", resultsDir, outcome); err != nil {
+		t.Fatal(err)
+	}
+	bs, err := os.ReadFile(filepath.Join(resultsDir, commonci.JUnitFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(bs, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Suites) != 1 {
+		t.Fatalf("got %d test suites, want 1", len(got.Suites))
+	}
+	suite := got.Suites[0]
+	if suite.Name != "misc-checks" || suite.Tests != 8 || suite.Failures != 8 || suite.Errors != 0 {
+		t.Errorf("got suite %q with %d tests, %d failures and %d errors, want misc-checks with 8 tests, 8 failures and 0 errors", suite.Name, suite.Tests, suite.Failures, suite.Errors)
+	}
+	whitespace := suite.Cases[len(suite.Cases)-1]
+	want := &junitTestCase{
+		ClassName: "misc-checks",
+		Name:      "whitespace check",
+		Failure: &junitProblem{
+			Message: "3 violation(s)",
+			Output:  "openconfig-mpls.yang (line 12): tab character\nopenconfig-mpls.yang (line 40): trailing whitespace\nopenconfig-acl.yang (line 3): CRLF line ending",
+		},
+	}
+	if diff := cmp.Diff(want, whitespace); diff != "" {
+		t.Errorf("whitespace check (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/util"
+
+	pb "github.com/openconfig/models-ci/proto/results"
+)
+
+// now returns the current time against which lint waivers expire.
+var now = time.Now
+
+// modelLintWaivers are the lint waivers of a model directory.
+type modelLintWaivers struct {
+	// dir is the model directory relative to its model root (e.g.
+	// wifi/mac).
+	dir     string
+	waivers *commonci.LintWaivers
+}
+
+// readModelLintWaivers reads the lint waivers file of the model directory
+// (e.g. wifi:mac) from the first model root containing one. If there is none,
+// then nil is returned.
+func readModelLintWaivers(modelDirName string) (*modelLintWaivers, error) {
+	dir := strings.ReplaceAll(modelDirName, ":", "/")
+	for _, root := range commonci.SplitModelRoots(ModelRoot) {
+		waivers, err := commonci.ReadLintWaivers(filepath.Join(root, dir, commonci.LintWaiversFileName))
+		if err != nil {
+			return nil, err
+		}
+		if waivers != nil {
+			return &modelLintWaivers{dir: dir, waivers: waivers}, nil
+		}
+	}
+	return nil, nil
+}
+
+// match returns the unexpired waiver of the message, whose path is relative
+// to the model root, or nil if there is none.
+func (m *modelLintWaivers) match(msg *pb.PyangMessage) *commonci.LintWaiver {
+	if m == nil || !strings.HasPrefix(msg.Path, m.dir+"/") {
+		return nil
+	}
+	return m.waivers.Match(msg.Code, strings.TrimPrefix(msg.Path, m.dir+"/"), now())
+}
+
+// lintErrorsWaived returns whether the pyang output contains errors, all of
+// which are waived, such that the model is considered to pass.
+func lintErrorsWaived(rawOut string, waivers *modelLintWaivers) (bool, error) {
+	if waivers == nil {
+		return false, nil
+	}
+	pyangOutput, err := util.ParsePyangTextprotoOutput(rawOut)
+	if err != nil {
+		// Unstructured output can't be waived.
+		return false, nil
+	}
+	waived := 0
+	for _, msg := range pyangOutput.Messages {
+		if !strings.Contains(msg.Type, "error") {
+			continue
+		}
+		if msg.Path, err = relModelPath(msg.Path); err != nil {
+			return false, fmt.Errorf("failed to calculate relpath at path %q (ModelRoot %q) parsed from error message: %v", msg.Path, ModelRoot, err)
+		}
+		if waivers.match(msg) == nil {
+			return false, nil
+		}
+		waived++
+	}
+	return waived > 0, nil
+}
+
+// waiverHTML returns the HTML noting that a message is waived.
+func waiverHTML(w *commonci.LintWaiver) string {
+	return fmt.Sprintf(" <i>waived until %s: %s</i>", EscapeOutput(w.Expiry), EscapeOutput(w.Justification))
+}
+
+// lintWaiversSummaryHTML summarizes the lint waivers of the model
+// directories, such that waivers remain visible in the report. Expired
+// waivers, which no longer apply, and invalid lint waivers files are flagged.
+func lintWaiversSummaryHTML(waivers []*modelLintWaivers, invalid []string) string {
+	var lines strings.Builder
+	status := "pass"
+	for _, m := range waivers {
+		for _, w := range m.waivers.Waivers {
+			line := fmt.Sprintf("%s/%s: %s until %s: %s", EscapeOutput(m.dir), EscapeOutput(w.Path), EscapeOutput(w.Code), EscapeOutput(w.Expiry), EscapeOutput(w.Justification))
+			if w.Expired(now()) {
+				status = "warning"
+				line = fmt.Sprintf("%s expired: %s", commonci.Emoji("warning"), line)
+			}
+			lines.WriteString(SprintLineHTML("%s", line))
+		}
+	}
+	for _, msg := range invalid {
+		status = "warning"
+		lines.WriteString(SprintLineHTML("%s %s", commonci.Emoji("warning"), EscapeOutput(msg)))
+	}
+	if lines.Len() == 0 {
+		return ""
+	}
+	return SprintSummaryHTML(status, "lint waivers", "<ul>\n%s</ul>\n", lines.String())
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// matrixTemplate is the page tabulating the per-model results of several
+// versions of a validator.
+var matrixTemplate = template.Must(template.New("matrix").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; }
+tr.differs { background-color: #fff3cd; }
+</style>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+<p>{{ len .Differing }} of {{ len .Rows }} models have results that differ between versions (highlighted).</p>
+<table>
+<tr><th>Model</th>{{ range .Versions }}<th>{{ . }}</th>{{ end }}</tr>
+{{- range .Rows }}
+<tr{{ if .Differs }} class="differs"{{ end }}><td>{{ .Model }}</td>{{ range .Cells }}<td title="{{ .Status }}">{{ .Emoji }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+</body>
+</html>
+`))
+
+// matrixCell is the result of a model for a single version.
+type matrixCell struct {
+	Status string
+	Emoji  template.HTML
+}
+
+// matrixRow is the results of a model for each version.
+type matrixRow struct {
+	// Model is the model's name as "<modelDir>/<model>".
+	Model   string
+	Cells   []matrixCell
+	Differs bool
+}
+
+// VersionMatrix returns the HTML page tabulating the per-model results (model
+// × version) of the given versions of the validator, whose results
+// directories are given by resultsDirs in the same order. It also returns
+// whether every model passed with every version, and the models (as
+// "<modelDir>/<model>") whose results differ between versions in lexical
+// order. A model without a result for a version, e.g. since the version
+// doesn't support it, differs from the other versions.
+func VersionMatrix(validatorId string, versions, resultsDirs []string) (string, bool, []string, error) {
+	if len(versions) != len(resultsDirs) {
+		return "", false, nil, fmt.Errorf("got %d versions but %d results directories", len(versions), len(resultsDirs))
+	}
+	statuses := map[string][]string{}
+	for i, resultsDir := range resultsDirs {
+		it, err := commonci.NewResultsIterator(resultsDir)
+		if err != nil {
+			return "", false, nil, err
+		}
+		for it.Next() {
+			result := it.Result()
+			model := strings.ReplaceAll(result.ModelDir, ":", "/") + "/" + result.Model
+			if statuses[model] == nil {
+				statuses[model] = make([]string, len(versions))
+			}
+			statuses[model][i] = result.Status
+		}
+		if err := it.Err(); err != nil {
+			return "", false, nil, err
+		}
+	}
+
+	var models []string
+	for model := range statuses {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+
+	allPass := true
+	var rows []matrixRow
+	var differing []string
+	for _, model := range models {
+		row := matrixRow{Model: model}
+		for _, status := range statuses[model] {
+			if status != "pass" {
+				allPass = false
+			}
+			cell := matrixCell{Status: status, Emoji: template.HTML(commonci.Emoji(status))}
+			if status == "" {
+				cell = matrixCell{Status: "no result", Emoji: "&ndash;"}
+			}
+			row.Cells = append(row.Cells, cell)
+			if status != statuses[model][0] {
+				row.Differs = true
+			}
+		}
+		if row.Differs {
+			differing = append(differing, model)
+		}
+		rows = append(rows, row)
+	}
+
+	var headers []string
+	for _, version := range versions {
+		if version == "" {
+			version = "latest"
+		}
+		headers = append(headers, version)
+	}
+	var b strings.Builder
+	if err := matrixTemplate.Execute(&b, struct {
+		Title     string
+		Versions  []string
+		Rows      []matrixRow
+		Differing []string
+	}{
+		Title:     validatorId + " version matrix",
+		Versions:  headers,
+		Rows:      rows,
+		Differing: differing,
+	}); err != nil {
+		return "", false, nil, err
+	}
+	return b.String(), allPass, differing, nil
+}
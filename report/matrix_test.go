@@ -0,0 +1,113 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// writeResults creates a results directory containing an empty result file
+// for each of the given "modelDir==model==status" results.
+func writeResults(t *testing.T, results ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, result := range results {
+		if err := os.WriteFile(filepath.Join(dir, result), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestVersionMatrix(t *testing.T) {
+	tests := []struct {
+		name          string
+		inVersions    []string
+		inResults     [][]string
+		wantPass      bool
+		wantDiffering []string
+		wantRows      []string
+		wantErr       bool
+	}{{
+		name:       "all pass",
+		inVersions: []string{"", "head"},
+		inResults: [][]string{
+			{"acl==openconfig-acl==pass", "optical-transport==openconfig-optical-amplifier==pass"},
+			{"acl==openconfig-acl==pass", "optical-transport==openconfig-optical-amplifier==pass"},
+		},
+		wantPass: true,
+		wantRows: []string{
+			`<tr><td>acl/openconfig-acl</td><td title="pass">&#x2705;</td><td title="pass">&#x2705;</td></tr>`,
+			`<tr><td>optical-transport/openconfig-optical-amplifier</td><td title="pass">&#x2705;</td><td title="pass">&#x2705;</td></tr>`,
+		},
+	}, {
+		name:       "results differ",
+		inVersions: []string{"", "head", "2.5.3"},
+		inResults: [][]string{
+			{"acl==openconfig-acl==pass", "wifi:access-points==openconfig-access-points==fail"},
+			{"acl==openconfig-acl==fail", "wifi:access-points==openconfig-access-points==fail"},
+			{"wifi:access-points==openconfig-access-points==fail"},
+		},
+		wantDiffering: []string{"acl/openconfig-acl"},
+		wantRows: []string{
+			`<tr class="differs"><td>acl/openconfig-acl</td><td title="pass">&#x2705;</td><td title="fail">&#x26D4;</td><td title="no result">&ndash;</td></tr>`,
+			`<tr><td>wifi/access-points/openconfig-access-points</td><td title="fail">&#x26D4;</td><td title="fail">&#x26D4;</td><td title="fail">&#x26D4;</td></tr>`,
+		},
+	}, {
+		name:       "mismatched results directories",
+		inVersions: []string{"", "head"},
+		inResults:  [][]string{{"acl==openconfig-acl==pass"}},
+		wantErr:    true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resultsDirs []string
+			for _, results := range tt.inResults {
+				resultsDirs = append(resultsDirs, writeResults(t, results...))
+			}
+			page, pass, differing, err := VersionMatrix("pyang", tt.inVersions, resultsDirs)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if pass != tt.wantPass {
+				t.Errorf("got pass %v, want %v", pass, tt.wantPass)
+			}
+			if diff := cmp.Diff(tt.wantDiffering, differing); diff != "" {
+				t.Errorf("differing models (-want, +got):\n%s", diff)
+			}
+			var rows []string
+			for _, line := range strings.Split(page, "\n") {
+				if strings.HasPrefix(line, "<tr") && !strings.Contains(line, "<th>") {
+					rows = append(rows, line)
+				}
+			}
+			if diff := cmp.Diff(tt.wantRows, rows); diff != "" {
+				t.Errorf("rows (-want, +got):\n%s", diff)
+			}
+			if !strings.Contains(page, "<th>latest</th>") {
+				t.Errorf("page doesn't label the latest version:\n%s", page)
+			}
+		})
+	}
+}
@@ -0,0 +1,697 @@
+// Copyright 2023 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// MiscChecksOutcomeFormatVersion is the version of the MiscChecksOutcome
+// document format.
+const MiscChecksOutcomeFormatVersion = 1
+
+// MiscChecksOutcome is the machine-readable outcome of each check of
+// misc-checks, which post_results writes into the misc-checks results
+// directory alongside the report, such that dashboards can reason about the
+// individual checks rather than only the combined result.
+type MiscChecksOutcome struct {
+	// FormatVersion is the MiscChecksOutcomeFormatVersion of the document.
+	FormatVersion int `json:"formatVersion"`
+	// Pass indicates that all of the checks passed.
+	Pass   bool               `json:"pass"`
+	Checks []MiscCheckOutcome `json:"checks"`
+}
+
+// MiscCheckOutcome is the outcome of a single check of misc-checks.
+type MiscCheckOutcome struct {
+	// Id identifies the check (e.g. "whitespace").
+	Id string `json:"id"`
+	// Description is the title of the check within the report.
+	Description string `json:"description"`
+	Pass        bool   `json:"pass"`
+	// Summary describes what was checked if the check passed.
+	Summary    string               `json:"summary,omitempty"`
+	Violations []MiscCheckViolation `json:"violations,omitempty"`
+}
+
+// MiscCheckViolation is a single violation found by a check of misc-checks.
+type MiscCheckViolation struct {
+	// File is the file or model directory that the violation refers to,
+	// and Line is the line within the file, if known.
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+	// html is the line of the violation within the report, if it isn't
+	// simply the escaped message.
+	html string
+}
+
+// HTML returns the line of the violation within the report.
+func (v MiscCheckViolation) HTML() string {
+	if v.html != "" {
+		return v.html
+	}
+	return SprintLineHTML("%s", EscapeOutput(v.Message))
+}
+
+// newViolation returns a violation referring to the file whose message is
+// formatted according to the format specifier.
+func newViolation(file string, format string, a ...interface{}) MiscCheckViolation {
+	return MiscCheckViolation{File: file, Message: fmt.Sprintf(format, a...)}
+}
+
+type versionRecord struct {
+	File            string
+	OldMajorVersion uint64
+	NewMajorVersion uint64
+	OldVersion      string
+	NewVersion      string
+}
+
+// VersionRecords are the openconfig-version changes of the YANG files found by
+// misc-checks.
+type VersionRecords []versionRecord
+
+// MajorVersionChanges returns the PR comment listing the files of the commit
+// whose major versions changed, or that were deleted.
+func (s VersionRecords) MajorVersionChanges(commitSHA string) string {
+	var b strings.Builder
+	for _, change := range s {
+		if change.OldMajorVersion != change.NewMajorVersion || change.NewVersion == "" {
+			b.WriteString(fmt.Sprintf("%s: `%s` -> `%s`\n", change.File, change.OldVersion, change.NewVersion))
+		}
+	}
+	if b.Len() == 0 {
+		return fmt.Sprintf("No major YANG version changes in commit %s", commitSHA)
+	}
+	return fmt.Sprintf("Major YANG version changes in commit %s:\n%s", commitSHA, b.String())
+}
+
+// HasBreaking returns whether any of the changes is breaking, i.e. a major
+// version change or the deletion of a versioned file.
+func (s VersionRecords) HasBreaking() bool {
+	for _, change := range s {
+		if change.OldMajorVersion != 0 && change.OldMajorVersion != change.NewMajorVersion {
+			return true
+		}
+		if change.OldVersion != "" && change.NewVersion == "" {
+			// Deleted file
+			return true
+		}
+	}
+	return false
+}
+
+// bumpCounts returns the number of major, minor and patch openconfig-version
+// increases, as well as the number of deleted files with versions.
+func (s VersionRecords) bumpCounts() (int, int, int, int) {
+	var major, minor, patch, deleted int
+	for _, change := range s {
+		if change.NewVersion == "" {
+			deleted += 1
+			continue
+		}
+		oldver, err := semver.StrictNewVersion(change.OldVersion)
+		if err != nil {
+			continue
+		}
+		newver, err := semver.StrictNewVersion(change.NewVersion)
+		if err != nil {
+			continue
+		}
+		switch {
+		case oldver.Major() != newver.Major():
+			major += 1
+		case oldver.Minor() != newver.Minor():
+			minor += 1
+		default:
+			patch += 1
+		}
+	}
+	return major, minor, patch, deleted
+}
+
+// changeSummaryHTML returns a compact table of the totals of the PR's changes,
+// giving reviewers a quantitative overview regardless of the check results.
+//
+// A changed file's module is its belonging module if it was parsed on either
+// branch, and otherwise is assumed to be named after the file.
+func changeSummaryHTML(changedFiles []string, fileProperties map[string]map[string]string, versionRecords VersionRecords) string {
+	modules := map[string]struct{}{}
+	for _, file := range changedFiles {
+		mod, ok := fileProperties[file]["belonging-module"]
+		if !ok {
+			mod, ok = fileProperties[file]["master-belonging-module"]
+		}
+		if !ok {
+			mod = strings.TrimSuffix(file, ".yang")
+		}
+		modules[mod] = struct{}{}
+	}
+	major, minor, patch, deleted := versionRecords.bumpCounts()
+
+	var b strings.Builder
+	b.WriteString("<table>\n")
+	b.WriteString("  <tr><th>files changed</th><th>modules touched</th><th>major bumps</th><th>minor bumps</th><th>patch bumps</th><th>versioned files deleted</th></tr>\n")
+	b.WriteString(fmt.Sprintf("  <tr><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td><td>%d</td></tr>\n", len(changedFiles), len(modules), major, minor, patch, deleted))
+	b.WriteString("</table>\n")
+	return b.String()
+}
+
+// processMiscChecksOutput takes the raw result output from the misc-checks
+// results directory and returns its formatted report and the outcome of each
+// check.
+//
+// It also returns a list of version changes for each file.
+func processMiscChecksOutput(resultsDir string) (string, *MiscChecksOutcome, VersionRecords, error) {
+	fileProperties := map[string]map[string]string{}
+	changedFiles, err := readYangFilesList(filepath.Join(resultsDir, "changed-files.txt"))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	changedFileSet := map[string]struct{}{}
+	for _, file := range changedFiles {
+		if _, ok := fileProperties[file]; !ok {
+			fileProperties[file] = map[string]string{}
+		}
+		fileProperties[file]["changed"] = "true"
+		changedFileSet[file] = struct{}{}
+	}
+	if err := readGoyangVersionsLog(filepath.Join(resultsDir, "pr-file-parse-log"), false, fileProperties); err != nil {
+		return "", nil, nil, err
+	}
+	if err := readGoyangVersionsLog(filepath.Join(resultsDir, "master-file-parse-log"), true, fileProperties); err != nil {
+		return "", nil, nil, err
+	}
+
+	var ocVersionViolations []MiscCheckViolation
+	ocVersionChangedCount := 0
+	var reachabilityViolations []MiscCheckViolation
+	filesReachedCount := 0
+	// Only look at the PR's files as they might be different from the master's files.
+	allNonEmptyPRFiles, err := readYangFilesList(filepath.Join(resultsDir, "all-non-empty-files.txt"))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	allNonEmptyPRFileSet := map[string]struct{}{}
+	moduleFileGroups := map[string][]fileAndVersion{}
+	var versionRecords VersionRecords
+	for _, file := range allNonEmptyPRFiles {
+		allNonEmptyPRFileSet[file] = struct{}{}
+		properties, ok := fileProperties[file]
+
+		// Reachability check
+		if !ok || properties["reachable"] != "true" {
+			reachabilityViolations = append(reachabilityViolations, newViolation(file, "%s: file not used by any .spec.yml build.", file))
+			// If the file was not reached, then its other
+			// parameters would not have been parsed by goyang, so
+			// simply skip the rest of the checks.
+			continue
+		}
+		filesReachedCount += 1
+
+		// openconfig-version update check
+		ocVersion, hasVersion := properties["openconfig-version"]
+		masterOcVersion, hadVersion := properties["master-openconfig-version"]
+		switch {
+		case properties["changed"] != "true":
+			// We assume the versioning is correct without change.
+		case hadVersion && hasVersion:
+			oldver, newver, err := checkSemverIncrease(masterOcVersion, ocVersion, "openconfig-version")
+			if err != nil {
+				ocVersionViolations = append(ocVersionViolations, newViolation(file, "%s: %s", file, err))
+				break
+			}
+			ocVersionChangedCount += 1
+			versionRecords = append(versionRecords, versionRecord{
+				File:            file,
+				OldMajorVersion: oldver.Major(),
+				NewMajorVersion: newver.Major(),
+				OldVersion:      masterOcVersion,
+				NewVersion:      ocVersion,
+			})
+		case hadVersion && !hasVersion:
+			ocVersionViolations = append(ocVersionViolations, newViolation(file, "%s: openconfig-version was removed", file))
+		default: // If didn't have version before, any new version is accepted.
+			ocVersionChangedCount += 1
+		}
+
+		if mod, ok := properties["belonging-module"]; hasVersion && ok {
+			// Error checking is already done by the version update check.
+			if v, err := semver.StrictNewVersion(ocVersion); err == nil {
+				moduleFileGroups[mod] = append(moduleFileGroups[mod], fileAndVersion{name: file, version: v})
+			}
+		}
+	}
+	for file := range changedFileSet {
+		if _, ok := allNonEmptyPRFileSet[file]; !ok {
+			properties, ok := fileProperties[file]
+			if !ok {
+				// If the file was not reached, then it did not
+				// have a version, so simply skip the rest of
+				// the checks.
+				continue
+			}
+			masterOcVersion, hadVersion := properties["master-openconfig-version"]
+			if !hadVersion {
+				continue
+			}
+			oldver, err := semver.StrictNewVersion(masterOcVersion)
+			if err != nil {
+				ocVersionViolations = append(ocVersionViolations, newViolation(file, "%s: %s", file, err))
+				continue
+			}
+			versionRecords = append(versionRecords, versionRecord{
+				File:            file,
+				OldMajorVersion: oldver.Major(),
+				NewMajorVersion: 0,
+				OldVersion:      masterOcVersion,
+				NewVersion:      "",
+			})
+		}
+	}
+
+	namingViolations, namingCheckedCount := moduleNamingViolations(fileProperties, changedFileSet)
+	whitespaceViolations, err := readWhitespaceLog(filepath.Join(resultsDir, "whitespace-log"))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	missingBuildFileViolations, err := readViolationsFile(filepath.Join(resultsDir, commonci.MissingBuildFilesFileName))
+	if err != nil {
+		return "", nil, nil, err
+	}
+	// The model index check is only run if the models repo has an index.
+	modelIndexProblemsPath := filepath.Join(resultsDir, commonci.ModelIndexProblemsFileName)
+	_, err = os.Stat(modelIndexProblemsPath)
+	hasModelIndex := err == nil
+	modelIndexViolations, err := readViolationsFile(modelIndexProblemsPath)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	// Compute HTML string and the outcome of each check.
+	var out strings.Builder
+	out.WriteString(changeSummaryHTML(changedFiles, fileProperties, versionRecords))
+	outcome := &MiscChecksOutcome{FormatVersion: MiscChecksOutcomeFormatVersion, Pass: true}
+	appendViolationOut := func(id, desc string, violations []MiscCheckViolation, passString string) {
+		check := MiscCheckOutcome{Id: id, Description: desc, Pass: len(violations) == 0}
+		if check.Pass {
+			check.Summary = strings.TrimSpace(passString)
+			out.WriteString(SprintSummaryHTML(commonci.BoolStatusToString(true), desc, "%s", passString))
+		} else {
+			check.Violations = violations
+			var lines strings.Builder
+			for _, v := range violations {
+				lines.WriteString(v.HTML())
+			}
+			out.WriteString(SprintSummaryHTML(commonci.BoolStatusToString(false), desc, "%s", lines.String()))
+			outcome.Pass = false
+		}
+		outcome.Checks = append(outcome.Checks, check)
+	}
+	appendViolationOut("build-file-existence", ".spec.yml build file existence check", missingBuildFileViolations, "All build files referenced by .spec.yml files exist.\n")
+	if hasModelIndex {
+		appendViolationOut("model-index", "model directory README and index check", modelIndexViolations, fmt.Sprintf("All model directories have a README and a complete %s entry.\n", commonci.ModelIndexFileName))
+	}
+	appendViolationOut("openconfig-version-update", "openconfig-version update check", ocVersionViolations, fmt.Sprintf("%d file(s) correctly updated.\n", ocVersionChangedCount))
+	appendViolationOut("build-reachability", ".spec.yml build reachability check", reachabilityViolations, fmt.Sprintf("%d files reached by build rules.\n", filesReachedCount))
+	appendViolationOut("submodule-versions", "submodule versions must match the belonging module's version", versionGroupViolations(moduleFileGroups), fmt.Sprintf("%d module/submodule file groups have matching versions", len(moduleFileGroups)))
+	revisionViolations, revisionCheckedCount := revisionDateViolations(fileProperties)
+	appendViolationOut("revision-date-order", "belonging module's latest revision date must not precede its submodules'", revisionViolations, fmt.Sprintf("%d module/submodule file groups have ordered revision dates.\n", revisionCheckedCount))
+	appendViolationOut("naming", "file name, namespace and prefix check", namingViolations, fmt.Sprintf("%d changed file(s) have consistent names, namespaces and prefixes.\n", namingCheckedCount))
+	// wscheck skips the changed files that the PR deleted.
+	whitespaceCheckedCount := 0
+	for _, file := range changedFiles {
+		if _, ok := allNonEmptyPRFileSet[file]; ok {
+			whitespaceCheckedCount++
+		}
+	}
+	appendViolationOut("whitespace", "whitespace check", whitespaceViolations, fmt.Sprintf("%d changed file(s) have no tabs, trailing whitespace, CRLF line endings or missing final newlines.\n", whitespaceCheckedCount))
+
+	return out.String(), outcome, versionRecords, nil
+}
+
+// ReadMiscChecksOutcome returns the outcome of each check of misc-checks,
+// processed from the misc-checks results directory.
+func ReadMiscChecksOutcome(resultsDir string) (*MiscChecksOutcome, error) {
+	_, outcome, _, err := processMiscChecksOutput(resultsDir)
+	return outcome, err
+}
+
+// WriteMiscChecksOutcome writes the outcome of each check of misc-checks into
+// the misc-checks results directory as commonci.MiscChecksOutcomeFileName.
+func WriteMiscChecksOutcome(resultsDir string, outcome *MiscChecksOutcome) error {
+	bs, err := json.MarshalIndent(outcome, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal misc-checks outcome: %v", err)
+	}
+	path := filepath.Join(resultsDir, commonci.MiscChecksOutcomeFileName)
+	if err := commonci.WriteFile(path, append(bs, '\n'), 0644); err != nil {
+		return fmt.Errorf("error while writing misc-checks outcome file %q: %v", path, err)
+	}
+	return nil
+}
+
+// readYangFilesList reads a file containing a list of YANG files, and returns
+// a slice of these files. An unrecognized line causes an error to be returned.
+// The error checking is not robust, but should be sufficient for our limited use.
+func readYangFilesList(path string) ([]string, error) {
+	filesStr, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileMap := map[string]bool{}
+	for _, line := range strings.Split(filesStr, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fileSegments := strings.Split(line, "/")
+		yangFileName := strings.TrimSpace(fileSegments[len(fileSegments)-1])
+		if !strings.HasSuffix(yangFileName, ".yang") {
+			return nil, fmt.Errorf("while parsing %s: unrecognized line, expected a path ending in a YANG file: %s", path, line)
+		}
+		fileMap[yangFileName] = true
+	}
+
+	var files []string
+	for f := range fileMap {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// readWhitespaceLog reads the output of wscheck, where each line is a
+// "<file path>:<line>: <issue>" finding, and returns the findings as
+// violations. The log is written by the misc-checks script even if there are
+// no findings, so a missing log means that wscheck didn't run.
+func readWhitespaceLog(logPath string) ([]MiscCheckViolation, error) {
+	bs, err := os.ReadFile(logPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, commonci.WithExitCode(commonci.ExitInfraError, fmt.Errorf("whitespace log %q doesn't exist, wscheck didn't run", logPath))
+	case err != nil:
+		return nil, commonci.WithExitCode(commonci.ExitInfraError, fmt.Errorf("failed to read whitespace log: %w", err))
+	}
+	wsLog := string(bs)
+
+	var violations []MiscCheckViolation
+	for _, line := range strings.Split(wsLog, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		location, issue, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("while parsing %s: unrecognized line, expected \"<file>:<line>: <issue>\": %s", logPath, line)
+		}
+		i := strings.LastIndex(location, ":")
+		if i == -1 {
+			return nil, fmt.Errorf("while parsing %s: unrecognized line, expected \"<file>:<line>: <issue>\": %s", logPath, line)
+		}
+		v := newViolation(location[:i], "%s (line %s): %s", filepath.Base(location[:i]), location[i+1:], issue)
+		v.Line, _ = strconv.Atoi(location[i+1:])
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
+
+// readViolationsFile reads a file of violations output by cmd_gen (e.g. the
+// missing build files), each of which is a line prefixed by its location
+// (e.g. "acl/.spec.yml:4: ..." or "acl: ..."), and returns them. No violations
+// are returned if the file doesn't exist.
+func readViolationsFile(path string) ([]MiscCheckViolation, error) {
+	bs, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read file at path %q: %v", path, err)
+	}
+
+	var violations []MiscCheckViolation
+	for _, line := range strings.Split(string(bs), "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		v := MiscCheckViolation{Message: line}
+		if location, _, ok := strings.Cut(line, ": "); ok {
+			v.File = location
+			if file, lineStr, ok := strings.Cut(location, ":"); ok {
+				if lineNum, err := strconv.Atoi(lineStr); err == nil {
+					v.File, v.Line = file, lineNum
+				}
+			}
+		}
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
+
+// readGoyangVersionsLog returns a map of YANG files to file attributes as parsed from the log.
+// The file should be a list of YANG file to space-separated attributes.
+// e.g.
+// foo.yang: openconfig-version:"1.2.3" revision-version:"2.3.4"
+func readGoyangVersionsLog(logPath string, masterBranch bool, fileProperties map[string]map[string]string) error {
+	fileLog, err := readFile(logPath)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(fileLog, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fileSegments := strings.SplitN(line, ":", 2)
+		yangFileName := strings.TrimSpace(fileSegments[0])
+		if !strings.HasSuffix(yangFileName, ".yang") {
+			return fmt.Errorf("while parsing %s: unrecognized line heading %q, expected a \"<name>.yang:\" start to the line: %q", logPath, yangFileName, line)
+		}
+		propertyMap, ok := fileProperties[yangFileName]
+		if !ok {
+			propertyMap = map[string]string{}
+			fileProperties[yangFileName] = propertyMap
+		}
+
+		if !masterBranch {
+			propertyMap["reachable"] = "true"
+		}
+
+		for _, property := range strings.Fields(strings.TrimSpace(fileSegments[1])) {
+			segments := strings.SplitN(property, ":", 2)
+			if len(segments) != 2 {
+				return fmt.Errorf("while parsing %s: unrecognized property substring, expected \"<property name>:\"<property>\"\" separated by spaces: %q", logPath, property)
+			}
+			name, value := segments[0], segments[1]
+			if value[0] == '"' {
+				if len(value) == 1 || value[len(value)-1] != '"' {
+					return fmt.Errorf("while parsing %s: Got invalid property value format: %s -- if the property value starts with a quote, it is assumed to be an enclosing quote", logPath, property)
+				}
+				value = value[1 : len(value)-1] // Remove enclosing quotes.
+			}
+			switch name {
+			case "openconfig-version", "belonging-module", "latest-revision-version", "latest-revision-date", "source-file", "namespace", "prefix":
+				if masterBranch {
+					name = "master-" + name
+				}
+				propertyMap[name] = value
+			default:
+				log.Printf("skipped unrecognized YANG file property: %s", property)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSemverIncrease checks that newVersion is greater than the oldVersion
+// according to semantic versioning rules.
+// Note that any increase is fine, including jumps, e.g. 1.0.0 -> 1.0.2.
+// If there isn't an increase, a descriptive error message is returned.
+func checkSemverIncrease(oldVersion, newVersion, versionStringName string) (*semver.Version, *semver.Version, error) {
+	newV, err := semver.StrictNewVersion(newVersion)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid version string: %q", newVersion)
+	}
+	oldV, err := semver.StrictNewVersion(oldVersion)
+	switch {
+	case err != nil:
+		return nil, nil, fmt.Errorf("unexpected error, base branch version string unparseable: %q", oldVersion)
+	case newV.Equal(oldV):
+		return nil, nil, fmt.Errorf("file updated but %s string not updated: %q", versionStringName, oldVersion)
+	case !newV.GreaterThan(oldV):
+		return nil, nil, fmt.Errorf("new semantic version not valid, old version: %q, new version: %q", oldVersion, newVersion)
+	default:
+		return oldV, newV, nil
+	}
+}
+
+type fileAndVersion struct {
+	name    string
+	version *semver.Version
+}
+
+// versionGroupViolations returns the version violations where a group of
+// module/submodule files don't have matching versions.
+func versionGroupViolations(moduleFileGroups map[string][]fileAndVersion) []MiscCheckViolation {
+	var violations []MiscCheckViolation
+
+	var modules []string
+	for m := range moduleFileGroups {
+		modules = append(modules, m)
+	}
+	sort.Strings(modules)
+	for _, moduleName := range modules {
+		latestVersion := semver.MustParse("0.0.0")
+		latestVersionModule := ""
+		for _, nameAndVersion := range moduleFileGroups[moduleName] {
+			if nameAndVersion.version.GreaterThan(latestVersion) {
+				latestVersion = nameAndVersion.version
+				latestVersionModule = nameAndVersion.name
+			}
+		}
+		latestVersionString := latestVersion.Original()
+
+		var violation, violationHTML strings.Builder
+		for _, nameAndVersion := range moduleFileGroups[moduleName] {
+			if version := nameAndVersion.version.Original(); version != latestVersionString {
+				if violation.Len() != 0 {
+					violation.WriteString(",")
+					violationHTML.WriteString(",")
+				}
+				violation.WriteString(fmt.Sprintf(" %s (%s)", nameAndVersion.name, version))
+				violationHTML.WriteString(fmt.Sprintf(" <b>%s</b> (%s)", EscapeOutput(nameAndVersion.name), EscapeOutput(version)))
+			}
+		}
+		if violation.Len() != 0 {
+			v := newViolation(moduleName+".yang", "module set %s is at %s (%s), non-matching files:%s", moduleName, latestVersionString, latestVersionModule, violation.String())
+			v.html = SprintLineHTML("module set %s is at <b>%s</b> (%s), non-matching files:%s", EscapeOutput(moduleName), EscapeOutput(latestVersionString), EscapeOutput(latestVersionModule), violationHTML.String())
+			violations = append(violations, v)
+		}
+	}
+	return violations
+}
+
+// revisionDateViolations returns the violations where a submodule's latest
+// revision date is later than that of its belonging module, which pyang
+// reports as LINT_BAD_REVISION. It also returns the number of module/submodule
+// file groups that were checked.
+//
+// Only files reached by the build whose parse log properties contain the
+// latest revision date are checked.
+func revisionDateViolations(fileProperties map[string]map[string]string) ([]MiscCheckViolation, int) {
+	moduleSubmodules := map[string][]string{}
+	for file, properties := range fileProperties {
+		mod, ok := properties["belonging-module"]
+		if !ok || properties["reachable"] != "true" || properties["latest-revision-date"] == "" {
+			continue
+		}
+		if file != mod+".yang" {
+			moduleSubmodules[mod] = append(moduleSubmodules[mod], file)
+		}
+	}
+	var modules []string
+	for mod := range moduleSubmodules {
+		modules = append(modules, mod)
+	}
+	sort.Strings(modules)
+
+	var violations []MiscCheckViolation
+	checkedCount := 0
+	for _, mod := range modules {
+		moduleFile := mod + ".yang"
+		moduleDate := fileProperties[moduleFile]["latest-revision-date"]
+		if fileProperties[moduleFile]["reachable"] != "true" || moduleDate == "" {
+			continue
+		}
+		checkedCount += 1
+		submodules := moduleSubmodules[mod]
+		sort.Strings(submodules)
+		for _, submodule := range submodules {
+			// Revision dates are YYYY-MM-DD, so they sort lexically.
+			if date := fileProperties[submodule]["latest-revision-date"]; date > moduleDate {
+				v := newViolation(submodule, "%s latest revision (%s) is later than that of its belonging module %s (%s)", submodule, date, moduleFile, moduleDate)
+				v.html = SprintLineHTML("<b>%s</b> latest revision (%s) is later than that of its belonging module <b>%s</b> (%s)", EscapeOutput(submodule), EscapeOutput(date), EscapeOutput(moduleFile), EscapeOutput(moduleDate))
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations, checkedCount
+}
+
+const (
+	// ocNamespacePrefix is the prefix of the namespace of all OpenConfig
+	// modules.
+	ocNamespacePrefix = "http://openconfig.net/yang/"
+	// ocPrefixPrefix is the prefix of the prefix of all OpenConfig modules.
+	ocPrefixPrefix = "oc-"
+)
+
+// moduleNamingViolations returns the violations where a changed file's
+// name doesn't match the name of the module or submodule it defines, or where
+// an OpenConfig module's namespace or prefix doesn't follow the style guide.
+// It also returns the number of changed files that were checked.
+//
+// Only files whose parse log properties contain the source file are checked.
+func moduleNamingViolations(fileProperties map[string]map[string]string, changedFileSet map[string]struct{}) ([]MiscCheckViolation, int) {
+	var moduleFiles []string
+	for moduleFile := range fileProperties {
+		moduleFiles = append(moduleFiles, moduleFile)
+	}
+	sort.Strings(moduleFiles)
+
+	var violations []MiscCheckViolation
+	checkedCount := 0
+	for _, moduleFile := range moduleFiles {
+		properties := fileProperties[moduleFile]
+		sourceFile, ok := properties["source-file"]
+		if !ok {
+			continue
+		}
+		if _, ok := changedFileSet[sourceFile]; !ok {
+			continue
+		}
+		checkedCount += 1
+
+		moduleName := strings.TrimSuffix(moduleFile, ".yang")
+		if sourceFile != moduleFile {
+			violations = append(violations, newViolation(sourceFile, "%s: file name does not match the name of the module or submodule it defines (%s)", sourceFile, moduleName))
+		}
+		if !strings.HasPrefix(moduleName, "openconfig-") {
+			continue
+		}
+		// Submodules don't have a namespace.
+		if namespace, ok := properties["namespace"]; ok && !strings.HasPrefix(namespace, ocNamespacePrefix) {
+			violations = append(violations, newViolation(sourceFile, "%s: namespace %q does not follow the OpenConfig convention of starting with %q", sourceFile, namespace, ocNamespacePrefix))
+		}
+		if prefix, ok := properties["prefix"]; ok && !strings.HasPrefix(prefix, ocPrefixPrefix) {
+			violations = append(violations, newViolation(sourceFile, "%s: prefix %q does not follow the OpenConfig style guide of starting with %q", sourceFile, prefix, ocPrefixPrefix))
+		}
+	}
+	return violations, checkedCount
+}
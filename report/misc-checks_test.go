@@ -0,0 +1,276 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/openconfig/models-ci/commonci"
+)
+
+func TestHasBreaking(t *testing.T) {
+	tests := []struct {
+		desc         string
+		inVersions   VersionRecords
+		wantBreaking bool
+	}{{
+		desc: "deleted",
+		inVersions: VersionRecords{{
+			File:            "openconfig-deleted.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 0,
+			OldVersion:      "1.0.0",
+			NewVersion:      "",
+		}},
+		wantBreaking: true,
+	}, {
+		desc: "minor",
+		inVersions: VersionRecords{{
+			File:            "openconfig-acl-submodule.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 1,
+			OldVersion:      "1.1.3",
+			NewVersion:      "1.2.3",
+		}},
+		wantBreaking: false,
+	}, {
+		desc: "patch",
+		inVersions: VersionRecords{{
+			File:            "openconfig-acl.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 1,
+			OldVersion:      "1.2.2",
+			NewVersion:      "1.2.3",
+		}},
+		wantBreaking: false,
+	}, {
+		desc: "one",
+		inVersions: VersionRecords{{
+			File:            "openconfig-interface-submodule.yang",
+			OldMajorVersion: 0,
+			NewMajorVersion: 1,
+			OldVersion:      "0.5.0",
+			NewVersion:      "1.0.0",
+		}},
+		wantBreaking: false,
+	}, {
+		desc: "major",
+		inVersions: VersionRecords{{
+			File:            "openconfig-interface.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 2,
+			OldVersion:      "1.1.3",
+			NewVersion:      "2.0.0",
+		}},
+		wantBreaking: true,
+	}, {
+		desc: "minor",
+		inVersions: VersionRecords{{
+			File:            "openconfig-packet-match.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 1,
+			OldVersion:      "1.1.2",
+			NewVersion:      "1.2.0",
+		}},
+		wantBreaking: false,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got, want := tt.inVersions.HasBreaking(), tt.wantBreaking; got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMajorVersionChanges(t *testing.T) {
+	tests := []struct {
+		desc                    string
+		inVersions              VersionRecords
+		wantMajorVersionChanges string
+	}{{
+		desc: "deleted",
+		inVersions: VersionRecords{{
+			File:            "openconfig-deleted.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 0,
+			OldVersion:      "1.0.0",
+			NewVersion:      "",
+		}},
+		wantMajorVersionChanges: "Major YANG version changes in commit a0:\nopenconfig-deleted.yang: `1.0.0` -> ``\n",
+	}, {
+		desc: "minor",
+		inVersions: VersionRecords{{
+			File:            "openconfig-acl-submodule.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 1,
+			OldVersion:      "1.1.3",
+			NewVersion:      "1.2.3",
+		}},
+		wantMajorVersionChanges: `No major YANG version changes in commit a0`,
+	}, {
+		desc: "patch",
+		inVersions: VersionRecords{{
+			File:            "openconfig-acl.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 1,
+			OldVersion:      "1.2.2",
+			NewVersion:      "1.2.3",
+		}},
+		wantMajorVersionChanges: `No major YANG version changes in commit a0`,
+	}, {
+		desc: "one",
+		inVersions: VersionRecords{{
+			File:            "openconfig-interface-submodule.yang",
+			OldMajorVersion: 0,
+			NewMajorVersion: 1,
+			OldVersion:      "0.5.0",
+			NewVersion:      "1.0.0",
+		}},
+		wantMajorVersionChanges: "Major YANG version changes in commit a0:\nopenconfig-interface-submodule.yang: `0.5.0` -> `1.0.0`\n",
+	}, {
+		desc: "major",
+		inVersions: VersionRecords{{
+			File:            "openconfig-interface.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 2,
+			OldVersion:      "1.1.3",
+			NewVersion:      "2.0.0",
+		}},
+		wantMajorVersionChanges: "Major YANG version changes in commit a0:\nopenconfig-interface.yang: `1.1.3` -> `2.0.0`\n",
+	}, {
+		desc: "minor",
+		inVersions: VersionRecords{{
+			File:            "openconfig-packet-match.yang",
+			OldMajorVersion: 1,
+			NewMajorVersion: 1,
+			OldVersion:      "1.1.2",
+			NewVersion:      "1.2.0",
+		}},
+		wantMajorVersionChanges: `No major YANG version changes in commit a0`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got, want := tt.inVersions.MajorVersionChanges("a0"), tt.wantMajorVersionChanges; got != want {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWriteMiscChecksOutcome(t *testing.T) {
+	tests := []struct {
+		desc                 string
+		inResultsDir         string
+		wantPass             bool
+		wantFailedChecks     []string
+		wantWhitespaceIssues []MiscCheckViolation
+		// inRemoveFiles are removed from the copy of the results dir.
+		inRemoveFiles []string
+		// wantExitCode is the exit code of the error, if any.
+		wantExitCode int
+	}{{
+		desc:         "pass",
+		inResultsDir: "testdata/misc-checks-pass",
+		wantPass:     true,
+	}, {
+		desc:             "fail",
+		inResultsDir:     "testdata/misc-checks-fail",
+		wantFailedChecks: []string{"build-file-existence", "model-index", "openconfig-version-update", "build-reachability", "submodule-versions", "revision-date-order", "naming", "whitespace"},
+		wantWhitespaceIssues: []MiscCheckViolation{{
+			File:    "release/models/mpls/openconfig-mpls.yang",
+			Line:    12,
+			Message: "openconfig-mpls.yang (line 12): tab character",
+		}, {
+			File:    "release/models/mpls/openconfig-mpls.yang",
+			Line:    40,
+			Message: "openconfig-mpls.yang (line 40): trailing whitespace",
+		}, {
+			File:    "release/models/acl/deeper/openconfig-acl.yang",
+			Line:    3,
+			Message: "openconfig-acl.yang (line 3): CRLF line ending",
+		}},
+	}, {
+		desc:          "missing whitespace log",
+		inResultsDir:  "testdata/misc-checks-pass",
+		inRemoveFiles: []string{"whitespace-log"},
+		wantExitCode:  commonci.ExitInfraError,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			resultsDir := t.TempDir()
+			entries, err := os.ReadDir(tt.inResultsDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				bs, err := os.ReadFile(filepath.Join(tt.inResultsDir, entry.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(resultsDir, entry.Name()), bs, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, name := range tt.inRemoveFiles {
+				if err := os.Remove(filepath.Join(resultsDir, name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			outcome, err := ReadMiscChecksOutcome(resultsDir)
+			if tt.wantExitCode != 0 {
+				if got := commonci.ExitCode(err); got != tt.wantExitCode {
+					t.Errorf("got exit code %d (error %v), want %d", got, err, tt.wantExitCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteMiscChecksOutcome(resultsDir, outcome); err != nil {
+				t.Fatal(err)
+			}
+			bs, err := os.ReadFile(filepath.Join(resultsDir, commonci.MiscChecksOutcomeFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got MiscChecksOutcome
+			if err := json.Unmarshal(bs, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.FormatVersion != MiscChecksOutcomeFormatVersion {
+				t.Errorf("got format version %d, want %d", got.FormatVersion, MiscChecksOutcomeFormatVersion)
+			}
+			if got.Pass != tt.wantPass {
+				t.Errorf("got pass %v, want %v", got.Pass, tt.wantPass)
+			}
+			var gotFailedChecks []string
+			var gotWhitespaceIssues []MiscCheckViolation
+			for _, check := range got.Checks {
+				if check.Pass != (len(check.Violations) == 0) {
+					t.Errorf("check %s: got pass %v with %d violations", check.Id, check.Pass, len(check.Violations))
+				}
+				if !check.Pass {
+					gotFailedChecks = append(gotFailedChecks, check.Id)
+				}
+				if check.Id == "whitespace" {
+					gotWhitespaceIssues = check.Violations
+				}
+			}
+			if diff := cmp.Diff(tt.wantFailedChecks, gotFailedChecks); diff != "" {
+				t.Errorf("failed checks (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantWhitespaceIssues, gotWhitespaceIssues, cmpopts.IgnoreUnexported(MiscCheckViolation{})); diff != "" {
+				t.Errorf("whitespace violations (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ocStyleGuideURL is the OpenConfig style guide, which documents the rules
+// enforced by the OpenConfig linter.
+const ocStyleGuideURL = "https://github.com/openconfig/public/blob/master/doc/openconfig_style_guide.md"
+
+// ocCodeInfo explains an OpenConfig linter (oc-pyang) error code.
+type ocCodeInfo struct {
+	explanation string
+	link        string
+}
+
+// ocCodes maps OpenConfig linter error codes to their explanations. A code
+// ending in "_" matches all codes with that prefix.
+var ocCodes = map[string]ocCodeInfo{
+	"OC_RELATIVE_PATH": {
+		explanation: "leafref paths within a module must be relative rather than absolute.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_OPSTATE_CONFIG_PROPERTY": {
+		explanation: "leaves within a config container must be config true, and those within a state container must be config false.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_OPSTATE_CONTAINER_NAME": {
+		explanation: "the containers holding configuration and operational state must be named config and state respectively.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_OPSTATE_CONTAINER_COUNT": {
+		explanation: "a container may have at most one config and one state container.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_OPSTATE_APPLIED_CONFIG": {
+		explanation: "each leaf within a config container must be mirrored by a leaf with the same name and type within the state container.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_LIST_SURROUND_CONTAINER": {
+		explanation: "each list must be directly enclosed by a container with the plural of the list's name.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_LIST_NO_ENCLOSING_CONTAINER": {
+		explanation: "each list must be directly enclosed by a container that contains nothing else.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_ENUM_CASE": {
+		explanation: "enumeration values and identity names must be UPPER_CASE.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_ENUM_UNDERSCORES": {
+		explanation: "enumeration values and identity names must separate words using underscores.",
+		link:        ocStyleGuideURL,
+	},
+	"OC_STYLE_": {
+		explanation: "the statement doesn't follow the OpenConfig style conventions.",
+		link:        ocStyleGuideURL,
+	},
+}
+
+// lookupOCCode returns the explanation of the OpenConfig linter error code,
+// preferring an exact match over the longest matching prefix.
+func lookupOCCode(code string) (ocCodeInfo, bool) {
+	if info, ok := ocCodes[code]; ok {
+		return info, true
+	}
+	var match string
+	for prefix := range ocCodes {
+		if strings.HasSuffix(prefix, "_") && strings.HasPrefix(code, prefix) && len(prefix) > len(match) {
+			match = prefix
+		}
+	}
+	if match == "" {
+		return ocCodeInfo{}, false
+	}
+	return ocCodes[match], true
+}
+
+// ocCodeHTML returns the HTML explaining the OpenConfig linter error code for
+// display next to its message, or an empty string if the code is unknown.
+func ocCodeHTML(code string) string {
+	info, ok := lookupOCCode(code)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(` <i>%s: %s</i> (<a href="%s">style guide</a>)`, EscapeOutput(code), EscapeOutput(info.explanation), info.link)
+}
@@ -0,0 +1,748 @@
+// Copyright 2020 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package report renders the results of the validators, as written into their
+// results directories by the validator scripts, into HTML. post_results posts
+// the reports to GitHub, while GenerateSite writes them as a standalone static
+// site.
+package report
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/util"
+
+	pb "github.com/openconfig/models-ci/proto/results"
+)
+
+const (
+	// IgnorePyangWarnings ignores all warnings from pyang or pyang-based tools.
+	IgnorePyangWarnings = true
+	// IgnoreConfdWarnings ignores all warnings from ConfD.
+	IgnoreConfdWarnings = false
+)
+
+// ModelRoot is the comma-separated list of root directories of the models,
+// relative to which the paths within the validators' messages are reported.
+var ModelRoot string
+
+// SourceURL, if set, is the URL under which the files of the models repo are
+// viewed at the validated commit (e.g.
+// https://github.com/openconfig/public/blob/<sha>), such that each message is
+// linked to its line in the offending file.
+var SourceURL string
+
+// outputEscaper escapes the characters of tool output that HTML or GitHub
+// markdown would otherwise interpret, using HTML character references, which
+// are rendered verbatim. Unlike html.EscapeString, quotes are left as-is for
+// readability, since tool output is never put within HTML attributes.
+var outputEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"`", "&#96;",
+	"*", "&#42;",
+	"[", "&#91;",
+	"]", "&#93;",
+	"~", "&#126;",
+	"\\", "&#92;",
+)
+
+// EscapeOutput sanitizes tool output for inclusion within the HTML and
+// markdown of a GitHub comment, such that it can neither corrupt the
+// surrounding report (e.g. by closing a <pre> tag) nor inject content.
+func EscapeOutput(s string) string {
+	return outputEscaper.Replace(s)
+}
+
+// BlockQuote puts s within a markdown code block. The fence is longer than
+// any run of backticks within s, such that s cannot close the code block.
+func BlockQuote(s string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	return fence + "\n" + s + "\n" + fence
+}
+
+// SprintLineHTML prints a single list item to be put under a top-level summary item.
+func SprintLineHTML(format string, a ...interface{}) string {
+	return fmt.Sprintf("  <li>"+format+"</li>\n", a...)
+}
+
+// SprintSummaryHTML prints a top-level summary item containing free-form or list items.
+func SprintSummaryHTML(status, title, format string, a ...interface{}) string {
+	return fmt.Sprintf("<details>\n  <summary>%s&nbsp; %s</summary>\n"+format+"</details>\n", append([]interface{}{commonci.Emoji(status), title}, a...)...)
+}
+
+// readFile reads the entire file into a string and returns it along with an error if any.
+func readFile(path string) (string, error) {
+	outBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file at path %q: %v", path, err)
+	}
+	return string(outBytes), nil
+}
+
+// relModelPath converts path to be relative to the model root containing it,
+// or otherwise (e.g. for third-party files) relative to the first model root.
+func relModelPath(path string) (string, error) {
+	roots := commonci.SplitModelRoots(ModelRoot)
+	if relPath, err := commonci.RelModelPath(roots, path); err == nil {
+		return relPath, nil
+	}
+	var firstRoot string
+	if len(roots) > 0 {
+		firstRoot = roots[0]
+	}
+	return filepath.Rel(firstRoot, path)
+}
+
+// locationHTML returns the HTML of a message's location, i.e. relPath and
+// line number, linked to the line within SourceURL if set. absPath is the path
+// of the file as output by the validator, which isn't linked unless it's
+// within the models repo.
+func locationHTML(absPath, relPath string, line int) string {
+	location := fmt.Sprintf("%s (%d)", EscapeOutput(relPath), line)
+	if SourceURL == "" || !filepath.IsAbs(absPath) {
+		return location
+	}
+	repoPath, err := filepath.Rel(commonci.RootDir, absPath)
+	if err != nil || strings.HasPrefix(repoPath, "..") {
+		return location
+	}
+	link := SourceURL + (&url.URL{Path: "/" + filepath.ToSlash(repoPath)}).EscapedPath()
+	if line > 0 {
+		link += fmt.Sprintf("#L%d", line)
+	}
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(link), location)
+}
+
+// processStandardOutput takes raw pyang/confd output and transforms it to an
+// HTML format for display on a GitHub gist comment.
+// Errors are displayed in front of warnings.
+func processStandardOutput(rawOut string, pass, noWarnings bool) (string, error) {
+	return standardOutputHTML(util.ParseStandardOutput(rawOut), pass, noWarnings)
+}
+
+// processYanglintOutput takes raw yanglint output and transforms it to an
+// HTML format for display on a GitHub gist comment.
+// Errors are displayed in front of warnings.
+func processYanglintOutput(rawOut string, pass bool) (string, error) {
+	return standardOutputHTML(util.ParseYanglintOutput(rawOut), pass, false)
+}
+
+// processYangsonOutput takes raw yangson output and transforms it to an
+// HTML format for display on a GitHub gist comment.
+func processYangsonOutput(rawOut string, pass bool) (string, error) {
+	return standardOutputHTML(util.ParseYangsonOutput(rawOut), pass, false)
+}
+
+// processExamplesOutput takes raw yang-examples output and transforms it to
+// an HTML format for display on a GitHub gist comment, listing whether each
+// example instance document passed, along with the messages of those that
+// failed.
+func processExamplesOutput(rawOut string, pass bool) (string, error) {
+	results, otherLines := util.ParseExamplesOutput(rawOut)
+	var lines strings.Builder
+	for _, line := range otherLines {
+		lines.WriteString(SprintLineHTML("%s", EscapeOutput(line)))
+	}
+	for _, result := range results {
+		relPath, err := relModelPath(result.Path)
+		if err != nil {
+			return "", fmt.Errorf("failed to calculate relpath at path %q (ModelRoot %q) of example: %v", result.Path, ModelRoot, err)
+		}
+		line := fmt.Sprintf("%s&nbsp; %s", commonci.Emoji(commonci.BoolStatusToString(result.Pass)), EscapeOutput(relPath))
+		if !result.Pass && len(result.Lines) > 0 {
+			line += fmt.Sprintf(": <pre>%s</pre>", EscapeOutput(strings.Join(result.Lines, "\n")))
+		}
+		lines.WriteString(SprintLineHTML("%s", line))
+	}
+
+	var out strings.Builder
+	if pass {
+		out.WriteString("Passed.\n")
+	}
+	if lines.Len() > 0 {
+		out.WriteString("<ul>\n")
+		out.WriteString(lines.String())
+		out.WriteString("</ul>\n")
+	}
+	return out.String(), nil
+}
+
+// processTreeDiffOutput takes the unified diff of a model's pyang trees at the
+// PR's base and head, and transforms it to an HTML format for display on a
+// GitHub gist comment, summarizing the number of added and removed lines.
+func processTreeDiffOutput(rawOut string) string {
+	added, removed := 0, 0
+	for _, line := range strings.Split(rawOut, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	if added == 0 && removed == 0 {
+		return "No tree changes.\n"
+	}
+	return fmt.Sprintf("Tree changes: %d lines added, %d lines removed\n<pre>%s</pre>\n", added, removed, EscapeOutput(strings.TrimSpace(rawOut)))
+}
+
+// standardOutputHTML transforms parsed standard output to an HTML format for
+// display on a GitHub gist comment.
+// Errors are displayed in front of warnings.
+func standardOutputHTML(standardOutput util.StandardOutput, pass, noWarnings bool) (string, error) {
+	var errorLines, nonErrorLines strings.Builder
+	for _, errLine := range append(standardOutput.ErrorLines, standardOutput.WarningLines...) {
+		// Convert file path to relative path. yanglint messages
+		// output before any file marker have no path.
+		relPath := errLine.Path
+		if errLine.Path != "" {
+			var err error
+			if relPath, err = relModelPath(errLine.Path); err != nil {
+				return "", fmt.Errorf("failed to calculate relpath at path %q (ModelRoot %q) parsed from error message: %v", errLine.Path, ModelRoot, err)
+			}
+		}
+
+		processedLine := fmt.Sprintf("%s: %s: <pre>%s</pre>", locationHTML(errLine.Path, relPath, int(errLine.LineNo)), EscapeOutput(errLine.Status), EscapeOutput(errLine.Message))
+		switch {
+		case strings.Contains(errLine.Status, "error"):
+			errorLines.WriteString(SprintLineHTML("%s", processedLine))
+		case strings.Contains(errLine.Status, "warning"):
+			if !noWarnings {
+				nonErrorLines.WriteString(SprintLineHTML("%s", processedLine))
+			}
+		}
+	}
+	for _, line := range standardOutput.OtherLines {
+		nonErrorLines.WriteString(SprintLineHTML("%s", EscapeOutput(line)))
+	}
+
+	var out strings.Builder
+	if pass {
+		out.WriteString("Passed.\n")
+	}
+	if errorLines.Len() > 0 || nonErrorLines.Len() > 0 {
+		out.WriteString("<ul>\n")
+		out.WriteString(errorLines.String())
+		out.WriteString(nonErrorLines.String())
+		out.WriteString("</ul>\n")
+	}
+	return out.String(), nil
+}
+
+// levelOmitted returns whether the structured message is omitted from the
+// parsed results, i.e. whether it's a non-error message with a greater (i.e.
+// less severe) level than maxLevel, which is unlimited if 0. Errors are never
+// omitted, such that a failing model's errors are always reported.
+func levelOmitted(msg *pb.PyangMessage, maxLevel uint32) bool {
+	return maxLevel != 0 && msg.Level > maxLevel && !strings.Contains(msg.Type, "error")
+}
+
+// processPyangOutput takes raw pyang/confd output and transforms it to an
+// HTML format for display on a GitHub gist comment.
+// Errors are displayed in front of warnings.
+// If maxLevel is non-zero, then non-error messages with a greater (i.e. less
+// severe) level are omitted.
+// Messages waived by the lint waivers, if any, are displayed last.
+func processPyangOutput(rawOut string, pass, noWarnings bool, maxLevel uint32, waivers *modelLintWaivers) (string, error) {
+	var errorLines, nonErrorLines, waivedLines strings.Builder
+	if pyangOutput, err := util.ParsePyangTextprotoOutput(rawOut); err != nil {
+		log.Printf("INFO: could not parse pyang output as textproto (raw output below): %v\n%s", err, rawOut)
+		nonErrorLines.WriteString(fmt.Sprintf("  <pre>%s</pre>\n", EscapeOutput(strings.TrimSpace(rawOut))))
+	} else {
+		for _, msgLine := range pyangOutput.Messages {
+			if levelOmitted(msgLine, maxLevel) {
+				continue
+			}
+			// Convert file path to relative path.
+			absPath := msgLine.Path
+			var err error
+			if msgLine.Path, err = relModelPath(msgLine.Path); err != nil {
+				return "", fmt.Errorf("failed to calculate relpath at path %q (ModelRoot %q) parsed from error message: %v", msgLine.Path, ModelRoot, err)
+			}
+
+			processedLine := fmt.Sprintf("%s: %s: <pre>%s</pre>%s", locationHTML(absPath, msgLine.Path, int(msgLine.Line)), EscapeOutput(msgLine.Type), EscapeOutput(msgLine.Message), ocCodeHTML(msgLine.Code))
+			if waiver := waivers.match(msgLine); waiver != nil {
+				waivedLines.WriteString(SprintLineHTML("%s%s", processedLine, waiverHTML(waiver)))
+				continue
+			}
+			switch {
+			case strings.Contains(msgLine.Type, "error"):
+				errorLines.WriteString(SprintLineHTML("%s", processedLine))
+			case strings.Contains(msgLine.Type, "warning"):
+				if !noWarnings {
+					nonErrorLines.WriteString(SprintLineHTML("%s", processedLine))
+				}
+			}
+		}
+	}
+
+	var out strings.Builder
+	if pass {
+		out.WriteString("Passed.\n")
+	}
+	if errorLines.Len() > 0 || nonErrorLines.Len() > 0 || waivedLines.Len() > 0 {
+		out.WriteString("<ul>\n")
+		out.WriteString(errorLines.String())
+		out.WriteString(nonErrorLines.String())
+		out.WriteString(waivedLines.String())
+		out.WriteString("</ul>\n")
+	}
+	return out.String(), nil
+}
+
+// userfyBashCommand changes the bash command displayed to the user to be
+// something that's easier to use.
+func userfyBashCommand(cmd string) string {
+	return strings.NewReplacer("/workspace/", "$OC_WORKSPACE/", "$OCPYANG_PLUGIN_DIR", "$GOPATH/src/github.com/openconfig/oc-pyang/openconfig_pyang/plugins", "$PYANGBIND_PLUGIN_DIR", "$GOPATH/src/github.com/robshakir/pyangbind/pyangbind/plugin").Replace(cmd)
+}
+
+// parseModelResultsHTML transforms the output files of the validator script into HTML
+// to be displayed on GitHub.
+// If condensed=true, then only errors are provided.
+// If maxLevel is non-zero, then structured non-error messages with a greater
+// level are omitted.
+// For oc-pyang, models whose errors are all waived by their model directory's
+// lint waivers pass, and the lint waivers are summarized first.
+func parseModelResultsHTML(validatorId, validatorResultDir string, condensed bool, maxLevel uint32) (string, bool, error) {
+	var htmlOut, modelHTML strings.Builder
+	var prevModelDirName string
+	var waivers *modelLintWaivers
+	var allWaivers []*modelLintWaivers
+	var invalidWaivers []string
+
+	// Validators defined by the models repo are reported according to
+	// their results format.
+	var resultsFormat string
+	if validator, ok := commonci.Validators[validatorId]; ok {
+		resultsFormat = validator.ResultsFormat
+	}
+
+	allPass := true
+	modelDirPass := true
+	// Results are iterated by modelDir (note that each modelDir has
+	// multiple models. Each model corresponds to a result file).
+	it, err := commonci.NewResultsIterator(validatorResultDir)
+	if err != nil {
+		return "", false, err
+	}
+	for it.Next() {
+		result := it.Result()
+		modelDirName, modelName, status := result.ModelDir, result.Model, result.Status
+
+		// Write results one modelDir at a time in order to report overall modelDir status.
+		if prevModelDirName != "" && modelDirName != prevModelDirName {
+			if !condensed || !modelDirPass {
+				htmlOut.WriteString(SprintSummaryHTML(commonci.BoolStatusToString(modelDirPass), prevModelDirName, "%s", modelHTML.String()))
+			}
+			modelHTML.Reset()
+			modelDirPass = true
+		}
+		if validatorId == "oc-pyang" && modelDirName != prevModelDirName {
+			if waivers, err = readModelLintWaivers(modelDirName); err != nil {
+				// A broken waivers file shouldn't prevent reporting.
+				log.Printf("INFO: %v", err)
+				invalidWaivers = append(invalidWaivers, err.Error())
+			} else if waivers != nil {
+				allWaivers = append(allWaivers, waivers)
+			}
+		}
+		prevModelDirName = modelDirName
+
+		modelPass := result.Pass()
+		// Timeouts can't be waived since the lint errors are unknown.
+		if !modelPass && !result.TimedOut() {
+			if modelPass, err = lintErrorsWaived(result.Output, waivers); err != nil {
+				return "", false, fmt.Errorf("error encountered while applying lint waivers for validator %q: %v", validatorId, err)
+			}
+			if modelPass {
+				status = "pass"
+			}
+		}
+		if !modelPass {
+			allPass = false
+			modelDirPass = false
+		}
+
+		// Transform output string into HTML.
+		outString := result.Output
+		switch {
+		case result.TimedOut():
+			// The output is likely truncated, so isn't parsed.
+			outString = "Timed out.<br>\n" + strings.Join(strings.Split(EscapeOutput(outString), "\n"), "<br>\n")
+		case strings.Contains(validatorId, "pyang"):
+			outString, err = processPyangOutput(outString, modelPass, IgnorePyangWarnings, maxLevel, waivers)
+		case validatorId == "confd":
+			outString, err = processStandardOutput(outString, modelPass, IgnoreConfdWarnings)
+		case validatorId == "yanglint":
+			outString, err = processYanglintOutput(outString, modelPass)
+		case validatorId == "yangson":
+			outString, err = processYangsonOutput(outString, modelPass)
+		case validatorId == "yang-examples":
+			outString, err = processExamplesOutput(outString, modelPass)
+		case validatorId == "tree-diff" && modelPass:
+			// A failure is pyang failing to render the PR's tree.
+			outString = processTreeDiffOutput(outString)
+		case resultsFormat == commonci.ResultsFormatPyangTextproto:
+			outString, err = processPyangOutput(outString, modelPass, false, maxLevel, nil)
+		case resultsFormat == commonci.ResultsFormatStandard:
+			outString, err = processStandardOutput(outString, modelPass, false)
+		default:
+			outString = strings.Join(strings.Split(EscapeOutput(outString), "\n"), "<br>\n")
+			if modelPass {
+				outString = "Passed.\n" + outString
+			}
+		}
+		if !modelPass && outString == "" {
+			outString = "Failed.\n"
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("error encountered while processing output for validator %q: %v", validatorId, err)
+		}
+
+		if !condensed || !modelPass {
+			// Display bash command that produced the validator result if it exists.
+			var bashCommandSummary string
+			if result.Cmd != "" {
+				bashCommandSummary = fmt.Sprintf("%s&nbsp; %s\n<pre>%s</pre>\n", commonci.Emoji("cmd"), "bash command", EscapeOutput(userfyBashCommand(result.Cmd)))
+			}
+			// Also display the error string.
+			modelHTML.WriteString(SprintSummaryHTML(status, modelName, "%s", bashCommandSummary+outString))
+		}
+	}
+	if err := it.Err(); err != nil {
+		return "", false, err
+	}
+
+	// Edge case: handle last modelDir.
+	if !condensed || !modelDirPass {
+		htmlOut.WriteString(SprintSummaryHTML(commonci.BoolStatusToString(modelDirPass), prevModelDirName, "%s", modelHTML.String()))
+	}
+
+	return lintWaiversSummaryHTML(allWaivers, invalidWaivers) + htmlOut.String(), allPass, nil
+}
+
+// MessageCounts contains the number of errors and warnings parsed from a
+// validator's structured output.
+type MessageCounts struct {
+	Errors   int
+	Warnings int
+}
+
+// CountMessages counts the errors and warnings in the per-model output
+// files of the given validator. It returns nil if the validator's output is
+// not structured.
+func CountMessages(validatorId, validatorResultDir string) (*MessageCounts, error) {
+	if !strings.Contains(validatorId, "pyang") && validatorId != "confd" && validatorId != "yanglint" && validatorId != "yangson" {
+		return nil, nil
+	}
+
+	counts := &MessageCounts{}
+	it, err := commonci.NewResultsIterator(validatorResultDir)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		outString := it.Result().Output
+		switch validatorId {
+		case "confd":
+			standardOutput := util.ParseStandardOutput(outString)
+			counts.Errors += len(standardOutput.ErrorLines)
+			counts.Warnings += len(standardOutput.WarningLines)
+		case "yanglint":
+			standardOutput := util.ParseYanglintOutput(outString)
+			counts.Errors += len(standardOutput.ErrorLines)
+			counts.Warnings += len(standardOutput.WarningLines)
+		case "yangson":
+			standardOutput := util.ParseYangsonOutput(outString)
+			counts.Errors += len(standardOutput.ErrorLines)
+		default:
+			pyangOutput, err := util.ParsePyangTextprotoOutput(outString)
+			if err != nil {
+				// Unstructured output isn't counted.
+				continue
+			}
+			for _, msg := range pyangOutput.Messages {
+				switch {
+				case strings.Contains(msg.Type, "error"):
+					counts.Errors++
+				case strings.Contains(msg.Type, "warning"):
+					counts.Warnings++
+				}
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// annotationLevel returns the level of the check run annotation of the message
+// of the given type (e.g. "error" or "warning"), or "" if it isn't annotated.
+func annotationLevel(msgType string, noWarnings bool) string {
+	switch {
+	case strings.Contains(msgType, "error"):
+		return "failure"
+	case strings.Contains(msgType, "warning") && !noWarnings:
+		return "warning"
+	}
+	return ""
+}
+
+// newAnnotation returns the check run annotation of the message at the line of
+// the file at absPath, or nil if the file isn't within the models repo, whose
+// lines can't be annotated.
+func newAnnotation(absPath string, line int, level, title, message string) *commonci.CheckRunAnnotation {
+	if !filepath.IsAbs(absPath) {
+		return nil
+	}
+	repoPath, err := filepath.Rel(commonci.RootDir, absPath)
+	if err != nil || strings.HasPrefix(repoPath, "..") {
+		return nil
+	}
+	if line < 1 {
+		// The message is about the whole file.
+		line = 1
+	}
+	return &commonci.CheckRunAnnotation{
+		Path:      filepath.ToSlash(repoPath),
+		StartLine: line,
+		EndLine:   line,
+		Level:     level,
+		Title:     title,
+		Message:   message,
+	}
+}
+
+// Annotations returns the check run annotations of the messages in the
+// per-model output files of the given validator, omitting the messages that
+// its report omits. Messages waived by lint waivers are annotated as notices.
+// It returns nil if the validator's output is not structured.
+func Annotations(validatorId, validatorResultDir string, maxLevel uint32) ([]*commonci.CheckRunAnnotation, error) {
+	var resultsFormat string
+	if validator, ok := commonci.Validators[validatorId]; ok {
+		resultsFormat = validator.ResultsFormat
+	}
+	pyangFormat := strings.Contains(validatorId, "pyang") || resultsFormat == commonci.ResultsFormatPyangTextproto
+	standardFormat := validatorId == "confd" || validatorId == "yanglint" || validatorId == "yangson" || resultsFormat == commonci.ResultsFormatStandard
+	if !pyangFormat && !standardFormat {
+		return nil, nil
+	}
+
+	var annotations []*commonci.CheckRunAnnotation
+	add := func(a *commonci.CheckRunAnnotation) {
+		if a != nil {
+			annotations = append(annotations, a)
+		}
+	}
+	var prevModelDirName string
+	var waivers *modelLintWaivers
+	it, err := commonci.NewResultsIterator(validatorResultDir)
+	if err != nil {
+		return nil, err
+	}
+	for it.Next() {
+		result := it.Result()
+		if validatorId == "oc-pyang" && result.ModelDir != prevModelDirName {
+			if waivers, err = readModelLintWaivers(result.ModelDir); err != nil {
+				// The report notes broken waivers files.
+				waivers = nil
+			}
+		}
+		prevModelDirName = result.ModelDir
+		if result.TimedOut() {
+			// The output is likely truncated, so isn't parsed.
+			continue
+		}
+
+		if !pyangFormat {
+			var standardOutput util.StandardOutput
+			noWarnings := false
+			switch validatorId {
+			case "yanglint":
+				standardOutput = util.ParseYanglintOutput(result.Output)
+			case "yangson":
+				standardOutput = util.ParseYangsonOutput(result.Output)
+			default:
+				standardOutput = util.ParseStandardOutput(result.Output)
+				noWarnings = validatorId == "confd" && IgnoreConfdWarnings
+			}
+			for _, errLine := range append(standardOutput.ErrorLines, standardOutput.WarningLines...) {
+				if level := annotationLevel(errLine.Status, noWarnings); level != "" {
+					add(newAnnotation(errLine.Path, int(errLine.LineNo), level, "", errLine.Message))
+				}
+			}
+			continue
+		}
+
+		pyangOutput, err := util.ParsePyangTextprotoOutput(result.Output)
+		if err != nil {
+			// Unstructured output isn't annotated.
+			continue
+		}
+		noWarnings := strings.Contains(validatorId, "pyang") && IgnorePyangWarnings
+		for _, msg := range pyangOutput.Messages {
+			if levelOmitted(msg, maxLevel) {
+				continue
+			}
+			level := annotationLevel(msg.Type, noWarnings)
+			if level == "" {
+				continue
+			}
+			absPath := msg.Path
+			message := msg.Message
+			if waivers != nil {
+				if msg.Path, err = relModelPath(msg.Path); err != nil {
+					return nil, fmt.Errorf("failed to calculate relpath at path %q (ModelRoot %q) parsed from error message: %v", msg.Path, ModelRoot, err)
+				}
+				if waiver := waivers.match(msg); waiver != nil {
+					level = "notice"
+					message = fmt.Sprintf("%s (waived until %s: %s)", message, waiver.Expiry, waiver.Justification)
+				}
+			}
+			add(newAnnotation(absPath, int(msg.Line), level, msg.Code, message))
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return annotations, nil
+}
+
+// Result parses the results for the given validator and its results
+// directory, and returns the string to be put in a GitHub gist comment as well
+// as the status (i.e. pass or fail).
+//
+// It also returns a newline-separated string of the list of all YANG files
+// that have their major versions updated, which is empty if there are no major
+// version changes.
+//
+// If condensed=true, then only errors are provided.
+// If maxLevel is non-zero, then structured non-error messages with a greater
+// level are omitted.
+func Result(validatorId, resultsDir string, condensed bool, maxLevel uint32) (string, bool, VersionRecords, error) {
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return "", false, nil, fmt.Errorf("validator %q not found", validatorId)
+	}
+
+	// outString is parsed stdout.
+	var outString string
+	// pass is the overall validation result.
+	var pass bool
+	// versionRecords contains all information regarding YANG version changes.
+	var versionRecords VersionRecords
+
+	failFileBytes, err := os.ReadFile(filepath.Join(resultsDir, commonci.FailFileName))
+	// existent fail file == failure.
+	executionFailed := err == nil
+	err = nil
+
+	switch {
+	case validator.IsPerModel && validatorId == "misc-checks":
+		var outcome *MiscChecksOutcome
+		if outString, outcome, versionRecords, err = processMiscChecksOutput(resultsDir); err == nil {
+			pass = outcome.Pass
+		}
+	case validator.IsPerModel:
+		outString, pass, err = parseModelResultsHTML(validatorId, resultsDir, condensed, maxLevel)
+		if pass && condensed {
+			outString = "All models passed.\n" + outString
+		}
+	case !executionFailed:
+		outString = "Test passed."
+		pass = true
+	}
+
+	if executionFailed {
+		failString := string(failFileBytes)
+		if failString == "" {
+			failString = "Test failed with no stderr output."
+		}
+		// For per-model validators, an execution failure suggests a CI infra failure.
+		if validator.IsPerModel {
+			failString = fmt.Sprintf("Validator script failed -- infra bug?\n%s", BlockQuote(failString))
+		}
+		pass = false
+
+		// The processing error could be due to the validator script
+		// failing, so catch it and don't fail the result posting
+		// process.
+		if err != nil {
+			failString += "\n" + fmt.Sprint(err)
+			err = nil
+		}
+
+		if outString != "" {
+			outString = failString + "\n" + outString
+		} else {
+			outString = failString
+		}
+	}
+
+	return outString, pass, versionRecords, err
+}
+
+// Heading gets the description and content of the result gist for the
+// given validator from its script output file. The "description" is the title
+// of the gist, and "content" is the script execution output.
+// NOTE: The parsed test result output (distinct from the script execution
+// output) should be attached as a comment on the same gist.
+func Heading(validatorId, version, resultsDir string) (string, string, error) {
+	validator, ok := commonci.Validators[validatorId]
+	if !ok {
+		return "", "", fmt.Errorf("validator %q not found", validatorId)
+	}
+
+	validatorDesc := commonci.AppendVersionToName(validator.Name, version)
+	// If version is latest, then get the concrete version output by the tool if it exists.
+	if version == "" {
+		if outBytes, err := os.ReadFile(filepath.Join(resultsDir, commonci.LatestVersionFileName)); err != nil {
+			log.Printf("INFO: did not read latest version for %s: %v", validatorId, err)
+		} else {
+			// Get the first line of the version output as the tool's display title.
+			nameAndVersionParts := strings.Fields(strings.TrimSpace(strings.SplitN(string(outBytes), "\n", 2)[0]))
+			// Format it a little.
+			validatorDesc = nameAndVersionParts[0]
+			if len(nameAndVersionParts) > 1 {
+				validatorDesc = commonci.AppendVersionToName(validatorDesc, strings.Join(nameAndVersionParts[1:], " "))
+			}
+		}
+	}
+
+	outBytes, err := os.ReadFile(filepath.Join(resultsDir, commonci.OutFileName))
+	if err != nil {
+		return "", "", err
+	}
+	content := string(outBytes)
+	if content == "" {
+		content = "No output"
+	}
+
+	return validatorDesc, content, nil
+}
@@ -12,19 +12,60 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package main
+package report
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/models-ci/commonci"
+
+	pb "github.com/openconfig/models-ci/proto/results"
 )
 
+func TestRelModelPath(t *testing.T) {
+	ModelRoot = "/workspace/release/yang,/workspace/experimental"
+	defer func() { ModelRoot = "" }()
+
+	tests := []struct {
+		name   string
+		inPath string
+		want   string
+	}{{
+		name:   "first model root",
+		inPath: "/workspace/release/yang/acl/openconfig-acl.yang",
+		want:   "acl/openconfig-acl.yang",
+	}, {
+		name:   "second model root",
+		inPath: "/workspace/experimental/wifi/openconfig-wifi-mac.yang",
+		want:   "wifi/openconfig-wifi-mac.yang",
+	}, {
+		name:   "outside model roots",
+		inPath: "/workspace/third_party/ietf/ietf-interfaces.yang",
+		want:   "../../third_party/ietf/ietf-interfaces.yang",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := relModelPath(tt.inPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestProcessStandardOutput(t *testing.T) {
-	modelRoot = "/workspace/release/yang"
+	ModelRoot = "/workspace/release/yang"
 
 	tests := []struct {
 		name         string
@@ -141,6 +182,19 @@ func TestProcessStandardOutput(t *testing.T) {
   <li>platform/openconfig-platform-port.yang (139): warning: <pre>the node is config, but refers to a non-config node 'type' defined at /workspace/release/yang/platform/openconfig-platform.yang:302</pre></li>
   <li>platform/openconfig-platform-transceiver.yang (557): warning: <pre>the node is config, but refers to a non-config node 'type' defined at /workspace/release/yang/platform/openconfig-platform.yang:302</pre></li>
 </ul>
+`,
+	}, {
+		name: "adversarial messages are escaped",
+		in: "/workspace/release/yang/acl/openconfig-acl.yang:1: error: description \"</pre></li></ul><img src=x onerror=alert(1)>\" is invalid\n" +
+			"/workspace/release/yang/acl/openconfig-acl.yang:2: warning: ```[click me](https://example.com) *bold* ~~struck~~ 100%s\n" +
+			"<script>alert(1)</script> & more\n",
+		inPass:       false,
+		inNoWarnings: false,
+		want: `<ul>
+  <li>acl/openconfig-acl.yang (1): error: <pre>description "&lt;/pre&gt;&lt;/li&gt;&lt;/ul&gt;&lt;img src=x onerror=alert(1)&gt;" is invalid</pre></li>
+  <li>acl/openconfig-acl.yang (2): warning: <pre>&#96;&#96;&#96;&#91;click me&#93;(https://example.com) &#42;bold&#42; &#126;&#126;struck&#126;&#126; 100%s</pre></li>
+  <li>&lt;script&gt;alert(1)&lt;/script&gt; &amp; more</li>
+</ul>
 `,
 	}}
 
@@ -157,6 +211,330 @@ func TestProcessStandardOutput(t *testing.T) {
 	}
 }
 
+func TestProcessPyangOutput(t *testing.T) {
+	ModelRoot = "/workspace/release/yang"
+
+	tests := []struct {
+		name   string
+		in     string
+		inPass bool
+		want   string
+	}{{
+		name:   "adversarial structured message is escaped",
+		in:     `messages:{path:"/workspace/release/yang/acl/openconfig-acl.yang" line:3 code:"OC_BAD" type:"error" level:1 message:'pattern "</pre><a href=x>[x](y)</a>" uses ` + "`" + `\\d` + "`" + `'}` + "\n",
+		inPass: false,
+		want: `<ul>
+  <li>acl/openconfig-acl.yang (3): error: <pre>pattern "&lt;/pre&gt;&lt;a href=x&gt;&#91;x&#93;(y)&lt;/a&gt;" uses &#96;&#92;d&#96;</pre></li>
+</ul>
+`,
+	}, {
+		name:   "adversarial unstructured output is escaped",
+		in:     "</pre>**oops**",
+		inPass: false,
+		want: `<ul>
+  <pre>&lt;/pre&gt;&#42;&#42;oops&#42;&#42;</pre>
+</ul>
+`,
+	}, {
+		name:   "OpenConfig linter codes are explained",
+		in:     `messages:{path:"/workspace/release/yang/acl/openconfig-acl.yang" line:3 code:"OC_RELATIVE_PATH" type:"error" level:1 message:'absolute path'}` + "\n" + `messages:{path:"/workspace/release/yang/acl/openconfig-acl.yang" line:5 code:"OC_STYLE_BAD_INDENT" type:"warning" level:4 message:'bad indent'}` + "\n",
+		inPass: false,
+		want: `<ul>
+  <li>acl/openconfig-acl.yang (3): error: <pre>absolute path</pre> <i>OC_RELATIVE_PATH: leafref paths within a module must be relative rather than absolute.</i> (<a href="https://github.com/openconfig/public/blob/master/doc/openconfig_style_guide.md">style guide</a>)</li>
+  <li>acl/openconfig-acl.yang (5): warning: <pre>bad indent</pre> <i>OC_STYLE_BAD_INDENT: the statement doesn't follow the OpenConfig style conventions.</i> (<a href="https://github.com/openconfig/public/blob/master/doc/openconfig_style_guide.md">style guide</a>)</li>
+</ul>
+`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := processPyangOutput(tt.in, tt.inPass, false, 0, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(strings.Split(tt.want, "\n"), strings.Split(got, "\n")); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProcessYanglintOutput(t *testing.T) {
+	ModelRoot = "/workspace/release/yang"
+	defer func() { ModelRoot = "" }()
+
+	got, err := processYanglintOutput(`yanglint-file: /workspace/release/yang/acl/openconfig-acl.yang
+libyang warn: Leafref target is config false. (Path "/openconfig-acl:acl/state", line number 34.)
+libyang err: Invalid character sequence "<lef>", expected a keyword. (Line number 12.)
+`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<ul>
+  <li>acl/openconfig-acl.yang (12): error: <pre>Invalid character sequence "&lt;lef&gt;", expected a keyword. (Line number 12.)</pre></li>
+  <li>acl/openconfig-acl.yang (34): warning: <pre>Leafref target is config false. (Path "/openconfig-acl:acl/state", line number 34.)</pre></li>
+</ul>
+`
+	if diff := cmp.Diff(strings.Split(want, "\n"), strings.Split(got, "\n")); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestLocationHTML(t *testing.T) {
+	SourceURL = "https://github.com/openconfig/public/blob/abc"
+	defer func() { SourceURL = "" }()
+
+	tests := []struct {
+		name      string
+		inAbsPath string
+		inRelPath string
+		inLine    int
+		want      string
+	}{{
+		name:      "linked to line",
+		inAbsPath: "/workspace/release/yang/acl/openconfig-acl.yang",
+		inRelPath: "acl/openconfig-acl.yang",
+		inLine:    3,
+		want:      `<a href="https://github.com/openconfig/public/blob/abc/release/yang/acl/openconfig-acl.yang#L3">acl/openconfig-acl.yang (3)</a>`,
+	}, {
+		name:      "no line number",
+		inAbsPath: "/workspace/release/yang/acl/openconfig-acl.yang",
+		inRelPath: "acl/openconfig-acl.yang",
+		want:      `<a href="https://github.com/openconfig/public/blob/abc/release/yang/acl/openconfig-acl.yang">acl/openconfig-acl.yang (0)</a>`,
+	}, {
+		name:      "path is escaped",
+		inAbsPath: "/workspace/release/yang/acl/openconfig acl#1.yang",
+		inRelPath: "acl/openconfig acl#1.yang",
+		inLine:    3,
+		want:      `<a href="https://github.com/openconfig/public/blob/abc/release/yang/acl/openconfig%20acl%231.yang#L3">acl/openconfig acl#1.yang (3)</a>`,
+	}, {
+		name:      "outside of the models repo",
+		inAbsPath: "/usr/share/yang/ietf-inet-types.yang",
+		inRelPath: "../../../usr/share/yang/ietf-inet-types.yang",
+		inLine:    3,
+		want:      "../../../usr/share/yang/ietf-inet-types.yang (3)",
+	}, {
+		name:   "no path",
+		inLine: 3,
+		want:   " (3)",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := locationHTML(tt.inAbsPath, tt.inRelPath, tt.inLine); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessYangsonOutput(t *testing.T) {
+	got, err := processYangsonOutput(`Module not found: openconfig-missing@
+`, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<ul>
+  <li> (0): error: <pre>Module not found: openconfig-missing@</pre></li>
+</ul>
+`
+	if diff := cmp.Diff(strings.Split(want, "\n"), strings.Split(got, "\n")); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestProcessExamplesOutput(t *testing.T) {
+	ModelRoot = "/workspace/release/yang"
+	defer func() { ModelRoot = "" }()
+
+	tests := []struct {
+		name   string
+		in     string
+		inPass bool
+		want   string
+	}{{
+		name: "passing examples",
+		in: `example-file: /workspace/release/yang/acl/examples/acl.json
+example-status: pass
+example-file: /workspace/release/yang/acl/examples/acl.xml
+example-status: pass
+`,
+		inPass: true,
+		want: `Passed.
+<ul>
+  <li>&#x2705;&nbsp; acl/examples/acl.json</li>
+  <li>&#x2705;&nbsp; acl/examples/acl.xml</li>
+</ul>
+`,
+	}, {
+		name: "failing example",
+		in: `example-file: /workspace/release/yang/acl/examples/acl.json
+example-status: pass
+example-file: /workspace/release/yang/acl/examples/bad.json
+libyang err: Invalid value "<foo>" of "name". (Line number 3.)
+example-status: fail
+`,
+		want: `<ul>
+  <li>&#x2705;&nbsp; acl/examples/acl.json</li>
+  <li>&#x26D4;&nbsp; acl/examples/bad.json: <pre>libyang err: Invalid value "&lt;foo&gt;" of "name". (Line number 3.)</pre></li>
+</ul>
+`,
+	}, {
+		name: "missing examples directory",
+		in:   "examples directory /workspace/release/yang/acl/examples does not exist\n",
+		want: `<ul>
+  <li>examples directory /workspace/release/yang/acl/examples does not exist</li>
+</ul>
+`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := processExamplesOutput(tt.in, tt.inPass)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(strings.Split(tt.want, "\n"), strings.Split(got, "\n")); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProcessTreeDiffOutput(t *testing.T) {
+	tests := []struct {
+		name  string
+		inOut string
+		want  string
+	}{{
+		name: "no changes",
+		want: "No tree changes.\n",
+	}, {
+		name: "changes",
+		inOut: `--- base
++++ head
+@@ -1,3 +1,3 @@
+ module: openconfig-acl
+   +--rw acl
+-     +--rw config
++     +--rw state
++     +--rw <new>
+`,
+		want: `Tree changes: 2 lines added, 1 lines removed
+<pre>--- base
++++ head
+@@ -1,3 +1,3 @@
+ module: openconfig-acl
+   +--rw acl
+-     +--rw config
++     +--rw state
++     +--rw &lt;new&gt;</pre>
+`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, processTreeDiffOutput(tt.inOut)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLevelOmitted(t *testing.T) {
+	tests := []struct {
+		desc       string
+		inType     string
+		inLevel    uint32
+		inMaxLevel uint32
+		want       bool
+	}{{
+		desc:       "warning within max level",
+		inType:     "warning",
+		inLevel:    3,
+		inMaxLevel: 3,
+	}, {
+		desc:       "warning above max level",
+		inType:     "warning",
+		inLevel:    4,
+		inMaxLevel: 3,
+		want:       true,
+	}, {
+		desc:    "warning without max level",
+		inType:  "warning",
+		inLevel: 4,
+	}, {
+		desc:       "error above max level",
+		inType:     "error",
+		inLevel:    4,
+		inMaxLevel: 3,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := levelOmitted(&pb.PyangMessage{Type: tt.inType, Level: tt.inLevel}, tt.inMaxLevel); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupOCCode(t *testing.T) {
+	tests := []struct {
+		in     string
+		wantOK bool
+		want   ocCodeInfo
+	}{{
+		in:     "OC_ENUM_CASE",
+		wantOK: true,
+		want:   ocCodes["OC_ENUM_CASE"],
+	}, {
+		in:     "OC_STYLE_WHITESPACE",
+		wantOK: true,
+		want:   ocCodes["OC_STYLE_"],
+	}, {
+		in: "OC_STYLE",
+	}, {
+		in: "OC_BAD",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := lookupOCCode(tt.in)
+			if ok != tt.wantOK {
+				t.Fatalf("got ok %v, want %v", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(ocCodeInfo{})); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBlockQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{{
+		name: "no backticks",
+		in:   "I failed",
+		want: "```\nI failed\n```",
+	}, {
+		name: "backticks cannot close the code block",
+		in:   "I failed\n```\n<b>injected</b>\n````",
+		want: "`````\nI failed\n```\n<b>injected</b>\n````\n`````",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BlockQuote(tt.in); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestCheckSemverIncrease(t *testing.T) {
 	tests := []struct {
 		desc          string
@@ -210,11 +588,11 @@ func TestCheckSemverIncrease(t *testing.T) {
 func TestVersionRecords(t *testing.T) {
 	tests := []struct {
 		desc             string
-		inVersionRecords versionRecordSlice
+		inVersionRecords VersionRecords
 		wantHasBreaking  bool
 	}{{
 		desc: "has breaking",
-		inVersionRecords: versionRecordSlice{{
+		inVersionRecords: VersionRecords{{
 			File:            "openconfig-interface-submodule.yang",
 			OldMajorVersion: 0,
 			NewMajorVersion: 1,
@@ -230,7 +608,7 @@ func TestVersionRecords(t *testing.T) {
 		wantHasBreaking: true,
 	}, {
 		desc: "non-breaking",
-		inVersionRecords: versionRecordSlice{{
+		inVersionRecords: VersionRecords{{
 			File:            "openconfig-interface-submodule.yang",
 			OldMajorVersion: 0,
 			NewMajorVersion: 1,
@@ -240,7 +618,7 @@ func TestVersionRecords(t *testing.T) {
 		wantHasBreaking: false,
 	}, {
 		desc: "non-breaking",
-		inVersionRecords: versionRecordSlice{{
+		inVersionRecords: VersionRecords{{
 			File:            "openconfig-interface-submodule.yang",
 			OldMajorVersion: 1,
 			NewMajorVersion: 1,
@@ -256,25 +634,26 @@ func TestVersionRecords(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			if gotHasBreaking, want := tt.inVersionRecords.hasBreaking(), tt.wantHasBreaking; gotHasBreaking != want {
+			if gotHasBreaking, want := tt.inVersionRecords.HasBreaking(), tt.wantHasBreaking; gotHasBreaking != want {
 				t.Errorf("gotHasBreaking %v, want %v", gotHasBreaking, want)
 			}
 		})
 	}
 }
 
-func TestGetResult(t *testing.T) {
-	modelRoot = "/workspace/release/yang"
+func TestResult(t *testing.T) {
+	ModelRoot = "/workspace/release/yang"
 
 	tests := []struct {
 		name                   string
 		inValidatorResultDir   string
 		inValidatorId          string
+		inMaxLevel             uint32
 		wantPass               bool
 		wantOut                string
 		wantCondensedOut       string
 		wantCondensedOutSame   bool
-		wantVersionRecordSlice versionRecordSlice
+		wantVersionRecordSlice VersionRecords
 		wantErrSubstr          string
 	}{{
 		name:                 "basic pyang pass",
@@ -427,6 +806,41 @@ Failed.
 </details>
 </details>
 `,
+	}, {
+		name:                 "pyang with all message levels",
+		inValidatorResultDir: "testdata/pyang-levels",
+		inValidatorId:        "pyang",
+		wantPass:             false,
+		wantOut: `<details>
+  <summary>&#x26D4;&nbsp; acl</summary>
+<details>
+  <summary>&#x26D4;&nbsp; openconfig-acl</summary>
+<ul>
+  <li>acl/openconfig-acl.yang (10): error: <pre>bad value</pre></li>
+  <li>acl/openconfig-acl.yang (12): error: <pre>warning treated as error</pre></li>
+</ul>
+</details>
+</details>
+`,
+		wantCondensedOutSame: true,
+	}, {
+		name:                 "pyang with max reported level",
+		inValidatorResultDir: "testdata/pyang-levels",
+		inValidatorId:        "pyang",
+		inMaxLevel:           3,
+		wantPass:             false,
+		wantOut: `<details>
+  <summary>&#x26D4;&nbsp; acl</summary>
+<details>
+  <summary>&#x26D4;&nbsp; openconfig-acl</summary>
+<ul>
+  <li>acl/openconfig-acl.yang (10): error: <pre>bad value</pre></li>
+  <li>acl/openconfig-acl.yang (12): error: <pre>warning treated as error</pre></li>
+</ul>
+</details>
+</details>
+`,
+		wantCondensedOutSame: true,
 	}, {
 		name:                 "confd with pass and fails",
 		inValidatorResultDir: "testdata/confd-with-invalid-files",
@@ -476,7 +890,7 @@ Failed.
 	}, {
 		name:                 "non-pyang with pass and fails",
 		inValidatorResultDir: "testdata/confd-with-invalid-files",
-		inValidatorId:        "yanglint",
+		inValidatorId:        "goyang-ygot",
 		wantPass:             false,
 		wantOut: `<details>
   <summary>&#x26D4;&nbsp; acl</summary>
@@ -570,7 +984,7 @@ All models passed.
 		inValidatorResultDir: "testdata/misc-checks-pass",
 		inValidatorId:        "misc-checks",
 		wantPass:             true,
-		wantVersionRecordSlice: versionRecordSlice{{
+		wantVersionRecordSlice: VersionRecords{{
 			File:            "openconfig-acl-submodule.yang",
 			OldMajorVersion: 1,
 			NewMajorVersion: 1,
@@ -607,7 +1021,19 @@ All models passed.
 			OldVersion:      "1.0.0",
 			NewVersion:      "",
 		}},
-		wantOut: `<details>
+		wantOut: `<table>
+  <tr><th>files changed</th><th>modules touched</th><th>major bumps</th><th>minor bumps</th><th>patch bumps</th><th>versioned files deleted</th></tr>
+  <tr><td>12</td><td>11</td><td>2</td><td>2</td><td>1</td><td>1</td></tr>
+</table>
+<details>
+  <summary>&#x2705;&nbsp; .spec.yml build file existence check</summary>
+All build files referenced by .spec.yml files exist.
+</details>
+<details>
+  <summary>&#x2705;&nbsp; model directory README and index check</summary>
+All model directories have a README and a complete model-index.yml entry.
+</details>
+<details>
   <summary>&#x2705;&nbsp; openconfig-version update check</summary>
 9 file(s) correctly updated.
 </details>
@@ -618,6 +1044,18 @@ All models passed.
 <details>
   <summary>&#x2705;&nbsp; submodule versions must match the belonging module's version</summary>
 7 module/submodule file groups have matching versions</details>
+<details>
+  <summary>&#x2705;&nbsp; belonging module's latest revision date must not precede its submodules'</summary>
+1 module/submodule file groups have ordered revision dates.
+</details>
+<details>
+  <summary>&#x2705;&nbsp; file name, namespace and prefix check</summary>
+2 changed file(s) have consistent names, namespaces and prefixes.
+</details>
+<details>
+  <summary>&#x2705;&nbsp; whitespace check</summary>
+9 changed file(s) have no tabs, trailing whitespace, CRLF line endings or missing final newlines.
+</details>
 `,
 		wantCondensedOutSame: true,
 	}, {
@@ -625,7 +1063,20 @@ All models passed.
 		inValidatorResultDir: "testdata/misc-checks-fail",
 		inValidatorId:        "misc-checks",
 		wantPass:             false,
-		wantOut: `<details>
+		wantOut: `<table>
+  <tr><th>files changed</th><th>modules touched</th><th>major bumps</th><th>minor bumps</th><th>patch bumps</th><th>versioned files deleted</th></tr>
+  <tr><td>7</td><td>7</td><td>0</td><td>0</td><td>0</td><td>0</td></tr>
+</table>
+<details>
+  <summary>&#x26D4;&nbsp; .spec.yml build file existence check</summary>
+  <li>acl/.spec.yml:4: build file acl/openconfig-acl-deleted.yang does not exist</li>
+</details>
+<details>
+  <summary>&#x26D4;&nbsp; model directory README and index check</summary>
+  <li>acl: missing README</li>
+  <li>wifi/mac: missing entry in model-index.yml</li>
+</details>
+<details>
   <summary>&#x26D4;&nbsp; openconfig-version update check</summary>
   <li>changed-version-to-noversion.yang: openconfig-version was removed</li>
   <li>openconfig-acl.yang: file updated but openconfig-version string not updated: "1.2.2"</li>
@@ -636,12 +1087,29 @@ All models passed.
   <li>changed-noversion-to-unreached.yang: file not used by any .spec.yml build.</li>
   <li>changed-unreached-to-unreached.yang: file not used by any .spec.yml build.</li>
   <li>changed-version-to-unreached.yang: file not used by any .spec.yml build.</li>
+  <li>openconfig-mpls-misnamed.yang: file not used by any .spec.yml build.</li>
   <li>unchanged-unreached.yang: file not used by any .spec.yml build.</li>
 </details>
 <details>
   <summary>&#x26D4;&nbsp; submodule versions must match the belonging module's version</summary>
   <li>module set openconfig-mpls is at <b>2.3.4</b> (openconfig-mpls-submodule.yang), non-matching files: <b>openconfig-mpls-submodule2.yang</b> (2.3.2), <b>openconfig-mpls.yang</b> (2.2.5)</li>
 </details>
+<details>
+  <summary>&#x26D4;&nbsp; belonging module's latest revision date must not precede its submodules'</summary>
+  <li><b>openconfig-mpls-submodule.yang</b> latest revision (2023-03-15) is later than that of its belonging module <b>openconfig-mpls.yang</b> (2023-01-01)</li>
+</details>
+<details>
+  <summary>&#x26D4;&nbsp; file name, namespace and prefix check</summary>
+  <li>openconfig-acl.yang: namespace "http://example.com/yang/acl" does not follow the OpenConfig convention of starting with "http://openconfig.net/yang/"</li>
+  <li>openconfig-acl.yang: prefix "acl" does not follow the OpenConfig style guide of starting with "oc-"</li>
+  <li>openconfig-mpls-misnamed.yang: file name does not match the name of the module or submodule it defines (openconfig-mpls-te)</li>
+</details>
+<details>
+  <summary>&#x26D4;&nbsp; whitespace check</summary>
+  <li>openconfig-mpls.yang (line 12): tab character</li>
+  <li>openconfig-mpls.yang (line 40): trailing whitespace</li>
+  <li>openconfig-acl.yang (line 3): CRLF line ending</li>
+</details>
 `,
 		wantCondensedOutSame: true,
 	}}
@@ -649,7 +1117,7 @@ All models passed.
 	for _, tt := range tests {
 		for _, condensed := range []bool{false, true} {
 			t.Run(fmt.Sprintf(tt.name+"@condensed=%v", condensed), func(t *testing.T) {
-				gotOut, gotPass, versionRecords, err := getResult(tt.inValidatorId, tt.inValidatorResultDir, condensed)
+				gotOut, gotPass, versionRecords, err := Result(tt.inValidatorId, tt.inValidatorResultDir, condensed, tt.inMaxLevel)
 				if err != nil {
 					if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
 						t.Fatalf("did not get expected error, %s", diff)
@@ -674,7 +1142,244 @@ All models passed.
 	}
 }
 
-func TestGetGistHeading(t *testing.T) {
+func TestParseModelResultsHTMLLintWaivers(t *testing.T) {
+	root := t.TempDir()
+	ModelRoot = filepath.Join(root, "yang")
+	defer func() { ModelRoot = "" }()
+	now = func() time.Time { return time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(ModelRoot, "acl", "lint-waivers.yaml"), `waivers:
+  - code: OC_RELATIVE_PATH
+    path: openconfig-acl.yang
+    justification: false positive
+    expiry: 2024-06-01
+  - code: OC_ENUM_CASE
+    path: openconfig-acl.yang
+    justification: long gone
+    expiry: 2024-01-01
+`)
+	resultsDir := filepath.Join(root, "results")
+	aclFile := filepath.Join(ModelRoot, "acl", "openconfig-acl.yang")
+	writeFile(filepath.Join(resultsDir, "acl==openconfig-acl==fail"), `messages:{path:"`+aclFile+`" line:3 code:"OC_RELATIVE_PATH" type:"error" level:1 message:'absolute path'}`+"\n")
+	writeFile(filepath.Join(resultsDir, "acl==openconfig-acl-types==fail"), `messages:{path:"`+aclFile+`" line:5 code:"OC_ENUM_CASE" type:"error" level:1 message:'lowercase'}`+"\n")
+
+	got, pass, err := parseModelResultsHTML("oc-pyang", resultsDir, false, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pass {
+		t.Errorf("got pass, want fail due to expired waiver")
+	}
+	want := `<details>
+  <summary>&#x26A0;&#xFE0F;&nbsp; lint waivers</summary>
+<ul>
+  <li>acl/openconfig-acl.yang: OC_RELATIVE_PATH until 2024-06-01: false positive</li>
+  <li>&#x26A0;&#xFE0F; expired: acl/openconfig-acl.yang: OC_ENUM_CASE until 2024-01-01: long gone</li>
+</ul>
+</details>
+<details>
+  <summary>&#x26D4;&nbsp; acl</summary>
+<details>
+  <summary>&#x26D4;&nbsp; openconfig-acl-types</summary>
+<ul>
+  <li>acl/openconfig-acl.yang (5): error: <pre>lowercase</pre> <i>OC_ENUM_CASE: enumeration values and identity names must be UPPER_CASE.</i> (<a href="https://github.com/openconfig/public/blob/master/doc/openconfig_style_guide.md">style guide</a>)</li>
+</ul>
+</details>
+<details>
+  <summary>&#x2705;&nbsp; openconfig-acl</summary>
+Passed.
+<ul>
+  <li>acl/openconfig-acl.yang (3): error: <pre>absolute path</pre> <i>OC_RELATIVE_PATH: leafref paths within a module must be relative rather than absolute.</i> (<a href="https://github.com/openconfig/public/blob/master/doc/openconfig_style_guide.md">style guide</a>) <i>waived until 2024-06-01: false positive</i></li>
+</ul>
+</details>
+</details>
+`
+	if diff := cmp.Diff(strings.Split(want, "\n"), strings.Split(got, "\n")); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestParseModelResultsHTMLTimeout(t *testing.T) {
+	resultsDir := t.TempDir()
+	for name, content := range map[string]string{
+		"acl==openconfig-acl==pass":    "",
+		"aft==openconfig-aft==cmd":     "pyang -f pybind openconfig-aft.yang",
+		"aft==openconfig-aft==timeout": "partial <output>\ntimed out after 600s\n",
+	} {
+		if err := os.WriteFile(filepath.Join(resultsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, pass, err := parseModelResultsHTML("pyangbind", resultsDir, true, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pass {
+		t.Errorf("got pass, want fail due to timeout")
+	}
+	want := `<details>
+  <summary>&#x26D4;&nbsp; aft</summary>
+<details>
+  <summary>&#x231B;&nbsp; openconfig-aft</summary>
+&#x1F4B2;&nbsp; bash command
+<pre>pyang -f pybind openconfig-aft.yang</pre>
+Timed out.<br>
+partial &lt;output&gt;<br>
+timed out after 600s<br>
+</details>
+</details>
+`
+	if diff := cmp.Diff(strings.Split(want, "\n"), strings.Split(got, "\n")); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestParseModelResultsHTMLResultsFormat(t *testing.T) {
+	ModelRoot = "/workspace/release/yang"
+	standardOut := "/workspace/release/yang/acl/openconfig-acl.yang:3: error: unexpected <keyword>\n"
+	pyangOut := `messages:{path:"/workspace/release/yang/acl/openconfig-acl.yang" line:3 code:"UNEXPECTED_KEYWORD" type:"error" level:1 message:'unexpected <keyword>'}
+`
+
+	tests := []struct {
+		name            string
+		inResultsFormat string
+		inOutput        string
+		want            string
+	}{{
+		name:     "raw by default",
+		inOutput: standardOut,
+		want:     "/workspace/release/yang/acl/openconfig-acl.yang:3: error: unexpected &lt;keyword&gt;<br>\n",
+	}, {
+		name:            "standard",
+		inResultsFormat: commonci.ResultsFormatStandard,
+		inOutput:        standardOut,
+		want:            "<li>acl/openconfig-acl.yang (3): error: <pre>unexpected &lt;keyword&gt;</pre></li>",
+	}, {
+		name:            "pyang textproto",
+		inResultsFormat: commonci.ResultsFormatPyangTextproto,
+		inOutput:        pyangOut,
+		want:            "<li>acl/openconfig-acl.yang (3): error: <pre>unexpected &lt;keyword&gt;</pre></li>",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commonci.Validators["my-linter"] = &commonci.Validator{Name: "My Linter", IsPerModel: true, ResultsFormat: tt.inResultsFormat}
+			defer delete(commonci.Validators, "my-linter")
+			resultsDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(resultsDir, "acl==openconfig-acl==fail"), []byte(tt.inOutput), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, pass, err := parseModelResultsHTML("my-linter", resultsDir, false, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pass {
+				t.Errorf("got pass, want fail")
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("got results:\n%s\nwant them to contain:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountMessages(t *testing.T) {
+	tests := []struct {
+		name                 string
+		inValidatorResultDir string
+		inValidatorId        string
+		want                 *MessageCounts
+	}{{
+		name:                 "pyang structured output",
+		inValidatorResultDir: "testdata/pyang-counts",
+		inValidatorId:        "pyang",
+		want:                 &MessageCounts{Errors: 1, Warnings: 2},
+	}, {
+		name:                 "confd",
+		inValidatorResultDir: "testdata/confd-with-invalid-files",
+		inValidatorId:        "confd",
+		want:                 &MessageCounts{Errors: 1, Warnings: 0},
+	}, {
+		name:                 "unstructured output",
+		inValidatorResultDir: "testdata/pyang-counts",
+		inValidatorId:        "goyang-ygot",
+		want:                 nil,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CountMessages(tt.inValidatorId, tt.inValidatorResultDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(MessageCounts{})); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	tests := []struct {
+		name                 string
+		inValidatorResultDir string
+		inValidatorId        string
+		want                 []*commonci.CheckRunAnnotation
+	}{{
+		name:                 "pyang structured output without warnings",
+		inValidatorResultDir: "testdata/pyang-counts",
+		inValidatorId:        "pyang",
+		want: []*commonci.CheckRunAnnotation{{
+			Path:      "release/yang/acl/openconfig-acl.yang",
+			StartLine: 10,
+			EndLine:   10,
+			Level:     "failure",
+			Title:     "BAD_VALUE",
+			Message:   "bad value",
+		}},
+	}, {
+		name:                 "confd",
+		inValidatorResultDir: "testdata/confd-with-invalid-files",
+		inValidatorId:        "confd",
+		want: []*commonci.CheckRunAnnotation{{
+			Path:      "release/yang/wifi/mac/openconfig-wifi-mac.yang",
+			StartLine: 1244,
+			EndLine:   1244,
+			Level:     "failure",
+			Message:   `enum value "B" should be of the form UPPERCASE_WITH_UNDERSCORES: B`,
+		}},
+	}, {
+		name:                 "unstructured output",
+		inValidatorResultDir: "testdata/pyang-counts",
+		inValidatorId:        "goyang-ygot",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Annotations(tt.inValidatorId, tt.inValidatorResultDir, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHeading(t *testing.T) {
 	tests := []struct {
 		name                 string
 		inValidatorResultDir string
@@ -715,7 +1420,7 @@ func TestGetGistHeading(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotDescription, gotContent, err := getGistHeading(tt.inValidatorId, tt.inVersion, tt.inValidatorResultDir)
+			gotDescription, gotContent, err := Heading(tt.inValidatorId, tt.inVersion, tt.inValidatorResultDir)
 			if err != nil {
 				if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
 					t.Fatalf("did not get expected error, %s", diff)
@@ -731,63 +1436,3 @@ func TestGetGistHeading(t *testing.T) {
 		})
 	}
 }
-
-func TestWriteBadgeUploadCmdFile(t *testing.T) {
-	repoSlug = "openconfig/repo"
-	tests := []struct {
-		name                 string
-		inValidatorDesc      string
-		inValidatorUniqueStr string
-		inVersion            string
-		inPass               bool
-		inResultsDir         string
-		wantFileContent      string
-	}{{
-		name:                 "pass",
-		inValidatorDesc:      "pyang@1.2.3",
-		inValidatorUniqueStr: "pyang@latest",
-		inPass:               true,
-		inResultsDir:         "results-dir",
-		wantFileContent: `REMOTE_PATH_PFX=gs://openconfig/compatibility-badges/openconfig-repo:
-RESULTSDIR=results-dir
-upload-public-file() {
-	gsutil cp $RESULTSDIR/$1 "$REMOTE_PATH_PFX"$1
-	gsutil acl ch -u AllUsers:R "$REMOTE_PATH_PFX"$1
-	gsutil setmeta -h "Cache-Control:no-cache" "$REMOTE_PATH_PFX"$1
-}
-badge "pass" "pyang@1.2.3" :brightgreen > $RESULTSDIR/pyang@latest.svg
-upload-public-file pyang@latest.svg
-upload-public-file pyang@latest.html
-`,
-	}, {
-		name:                 "fail",
-		inValidatorDesc:      "pyang@2.3.4",
-		inValidatorUniqueStr: "pyang",
-		inPass:               false,
-		inResultsDir:         "results-directory",
-		wantFileContent: `REMOTE_PATH_PFX=gs://openconfig/compatibility-badges/openconfig-repo:
-RESULTSDIR=results-directory
-upload-public-file() {
-	gsutil cp $RESULTSDIR/$1 "$REMOTE_PATH_PFX"$1
-	gsutil acl ch -u AllUsers:R "$REMOTE_PATH_PFX"$1
-	gsutil setmeta -h "Cache-Control:no-cache" "$REMOTE_PATH_PFX"$1
-}
-badge "fail" "pyang@2.3.4" :red > $RESULTSDIR/pyang.svg
-upload-public-file pyang.svg
-upload-public-file pyang.html
-`,
-	}}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := WriteBadgeUploadCmdFile(tt.inValidatorDesc, tt.inValidatorUniqueStr, tt.inPass, tt.inResultsDir)
-			if err != nil {
-				t.Fatal(err)
-			}
-
-			if got != tt.wantFileContent {
-				t.Errorf("gotFileContent:\n%v\nwant:\n%v", got, tt.wantFileContent)
-			}
-		})
-	}
-}
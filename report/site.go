@@ -0,0 +1,200 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openconfig/models-ci/commonci"
+)
+
+// IndexFileName is the name of the static site's index page.
+const IndexFileName = "index.html"
+
+var (
+	// indexTemplate is the static site's index page linking to the page of
+	// each validator.
+	indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Validation Results</title>
+</head>
+<body>
+<h1>Validation Results</h1>
+<ul>
+{{- range . }}
+  <li>{{ .Emoji }}&nbsp; <a href="{{ .FileName }}">{{ .Desc }}</a></li>
+{{- end }}
+</ul>
+</body>
+</html>
+`))
+
+	// pageTemplate is the static site's page of a single validator.
+	pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Desc }}</title>
+</head>
+<body>
+<p><a href="` + IndexFileName + `">All validators</a></p>
+<h1>{{ .Emoji }}&nbsp; {{ .Desc }}</h1>
+<div style="white-space: pre-line">
+{{ .Result }}
+</div>
+<h2>Execution output</h2>
+<pre>{{ .Output }}</pre>
+</body>
+</html>
+`))
+)
+
+var (
+	// fenceRegex matches the line opening or closing a markdown code block
+	// (see BlockQuote).
+	fenceRegex = regexp.MustCompile("^```+$")
+	// headingRegex matches a markdown heading, e.g. within the fail file of
+	// a repo-level validator.
+	headingRegex = regexp.MustCompile(`^(#{1,6}) (.*)$`)
+	// codeSpanRegex matches markdown inline code.
+	codeSpanRegex = regexp.MustCompile("`([^`]+)`")
+)
+
+// resultHTML converts the GitHub markdown within a result (see Result), i.e.
+// its code blocks, headings and inline code, to HTML, since the site isn't
+// rendered by GitHub. The contents of code blocks are escaped, since they're
+// displayed verbatim by GitHub.
+func resultHTML(result string) template.HTML {
+	var b strings.Builder
+	var fence string
+	for _, line := range strings.Split(result, "\n") {
+		switch {
+		case fence != "" && line == fence:
+			b.WriteString("</pre>\n")
+			fence = ""
+		case fence != "":
+			b.WriteString(html.EscapeString(line) + "\n")
+		case fenceRegex.MatchString(line):
+			b.WriteString("<pre>\n")
+			fence = line
+		default:
+			line = codeSpanRegex.ReplaceAllString(line, "<code>$1</code>")
+			if m := headingRegex.FindStringSubmatch(line); m != nil {
+				// Headings are nested within the page's heading.
+				level := len(m[1]) + 1
+				if level > 6 {
+					level = 6
+				}
+				line = fmt.Sprintf("<h%d>%s</h%d>", level, m[2], level)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	if fence != "" {
+		b.WriteString("</pre>\n")
+	}
+	return template.HTML(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// sitePage is a page of the static site reporting the results of a validator.
+type sitePage struct {
+	// FileName is the name of the page's file within the site.
+	FileName string
+	// Desc describes the validator and its version (see Heading).
+	Desc string
+	// Emoji is the HTML of the emoji of the validator's status.
+	Emoji template.HTML
+	// Result is the HTML of the validator's parsed results (see Result and
+	// resultHTML).
+	Result template.HTML
+	// Output is the execution output of the validator script.
+	Output string
+}
+
+// GenerateSite writes a standalone static site into outDir reporting the
+// results of each validator within resultsRoot (normally
+// commonci.ResultsDir), such that the results can be published without
+// GitHub. The site consists of an index page linking to a page with the full
+// results of each validator.
+func GenerateSite(resultsRoot, outDir string) error {
+	entries, err := os.ReadDir(resultsRoot)
+	if err != nil {
+		return fmt.Errorf("failed to read results directory %q: %v", resultsRoot, err)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create site directory %q: %v", outDir, err)
+	}
+
+	var pages []*sitePage
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		segments := strings.SplitN(name, "@", 2)
+		validatorId, version := segments[0], ""
+		if len(segments) == 2 {
+			version = segments[1]
+		}
+		if validator, ok := commonci.Validators[validatorId]; !ok || validator.ReportOnly {
+			log.Printf("INFO: skipping results directory %q, which isn't of a recognized validator", name)
+			continue
+		}
+
+		resultsDir := filepath.Join(resultsRoot, name)
+		desc, output, err := Heading(validatorId, version, resultsDir)
+		if err != nil {
+			return fmt.Errorf("couldn't read the output of %s: %v", name, err)
+		}
+		result, pass, _, err := Result(validatorId, resultsDir, false, 0)
+		if err != nil {
+			return fmt.Errorf("couldn't parse the results of %s: %v", name, err)
+		}
+		page := &sitePage{
+			FileName: name + ".html",
+			Desc:     desc,
+			Emoji:    template.HTML(commonci.Emoji(commonci.BoolStatusToString(pass))),
+			Result:   resultHTML(result),
+			Output:   output,
+		}
+		if err := writeSiteFile(filepath.Join(outDir, page.FileName), pageTemplate, page); err != nil {
+			return err
+		}
+		pages = append(pages, page)
+	}
+
+	return writeSiteFile(filepath.Join(outDir, IndexFileName), indexTemplate, pages)
+}
+
+// writeSiteFile executes the template on data into the file at path.
+func writeSiteFile(path string, tmpl *template.Template, data interface{}) error {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return fmt.Errorf("failed to generate %q: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %q: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSite(t *testing.T) {
+	resultsRoot := t.TempDir()
+	for path, content := range map[string]string{
+		"oc-pyang/out":                       "oc-pyang output",
+		"oc-pyang/acl==openconfig-acl==pass": "",
+		"regexp@1.2/out":                     "",
+		"regexp@1.2/fail":                    "## RFC7950 `pattern` statement\ninvalid pattern",
+		"pyang/out":                          "",
+		"pyang/fail":                         "broken <script>",
+		"compat-report/out":                  "",
+		"unknown/out":                        "",
+	} {
+		path = filepath.Join(resultsRoot, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outDir := filepath.Join(t.TempDir(), "site")
+	if err := GenerateSite(resultsRoot, outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		file         string
+		wantContains []string
+	}{{
+		file: IndexFileName,
+		wantContains: []string{
+			`&#x2705;&nbsp; <a href="oc-pyang.html">OpenConfig Linter</a>`,
+			`&#x26D4;&nbsp; <a href="regexp@1.2.html">regexp tests@1.2</a>`,
+		},
+	}, {
+		file: "oc-pyang.html",
+		wantContains: []string{
+			`<a href="index.html">All validators</a>`,
+			"<summary>&#x2705;&nbsp; acl</summary>",
+			"<pre>oc-pyang output</pre>",
+		},
+	}, {
+		file:         "regexp@1.2.html",
+		wantContains: []string{"<h3>RFC7950 <code>pattern</code> statement</h3>\ninvalid pattern"},
+	}, {
+		file:         "pyang.html",
+		wantContains: []string{"Validator script failed -- infra bug?\n<pre>\nbroken &lt;script&gt;\n</pre>"},
+	}} {
+		bs, err := os.ReadFile(filepath.Join(outDir, tt.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.wantContains {
+			if !strings.Contains(string(bs), want) {
+				t.Errorf("%s doesn't contain %q:\n%s", tt.file, want, bs)
+			}
+		}
+	}
+
+	// Report-only and unrecognized validators don't have pages.
+	for _, file := range []string{"compat-report.html", "unknown.html"} {
+		if _, err := os.Stat(filepath.Join(outDir, file)); err == nil {
+			t.Errorf("got unexpected page %s", file)
+		}
+	}
+}
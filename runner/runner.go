@@ -0,0 +1,293 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package runner runs the per-model commands of a validator directly rather
+// than through a generated bash script, writing the same result files.
+//
+// A Plan is generated by cmd_gen and run by "openconfig-ci run-validator".
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/yangutil"
+)
+
+// PlanFormatVersion is the version of the Plan format, which must be
+// incremented whenever it changes incompatibly.
+const PlanFormatVersion = 1
+
+// Plan is the per-model commands of a validator.
+type Plan struct {
+	FormatVersion int    `json:"format-version"`
+	ValidatorId   string `json:"validator"`
+	ResultsDir    string `json:"results-dir"`
+	// Parallel indicates that models may be validated concurrently.
+	Parallel bool `json:"parallel,omitempty"`
+	// TimeoutSeconds is the maximum time spent validating each model, or 0
+	// for no limit. A model that times out fails with the
+	// commonci.TimeoutStatus status.
+	TimeoutSeconds int     `json:"timeout-seconds,omitempty"`
+	Models         []Model `json:"models"`
+}
+
+// Model is the commands validating a single model.
+type Model struct {
+	ModelDirName string `json:"model-dir"`
+	ModelName    string `json:"model"`
+	// Cmd is the command line displayed in the model's report, if any.
+	Cmd   string `json:"cmd,omitempty"`
+	Steps []Step `json:"steps"`
+	// ContinueOnFailure runs the remaining steps after a step fails, e.g.
+	// when each build file is checked separately.
+	ContinueOnFailure bool `json:"continue-on-failure,omitempty"`
+	// Retries is the number of times that the steps are run again after
+	// the model fails (but not after it times out), with the output of the
+	// failed attempt discarded.
+	Retries int `json:"retries,omitempty"`
+	// Env are the "NAME=value" environment variables set for every step in
+	// addition to the runner's environment.
+	Env []string `json:"env,omitempty"`
+}
+
+// Step is a single command. Within Argv, the element "$@" is replaced by the
+// runner's arguments, "$1" to "$9" by a single argument, and other "$VAR"
+// references by environment variables, as in the bash scripts.
+type Step struct {
+	Argv []string `json:"argv"`
+	// Dir is the working directory of the command, which is created if it
+	// doesn't exist. It defaults to the runner's working directory.
+	Dir string `json:"dir,omitempty"`
+	// Marker is written as a line, or lines, to the model's output before
+	// running the command, e.g. to name the files whose messages follow.
+	Marker string `json:"marker,omitempty"`
+}
+
+// MarshalPlan returns the contents of the plan's file.
+func MarshalPlan(p *Plan) ([]byte, error) {
+	p.FormatVersion = PlanFormatVersion
+	bs, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal run plan: %v", err)
+	}
+	return append(bs, '\n'), nil
+}
+
+// ReadPlan reads the plan at path.
+func ReadPlan(path string) (*Plan, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run plan file %q: %v", path, err)
+	}
+	p := &Plan{}
+	if err := json.Unmarshal(bs, p); err != nil {
+		return nil, fmt.Errorf("failed to parse run plan file %q: %v", path, err)
+	}
+	if p.FormatVersion != PlanFormatVersion {
+		return nil, fmt.Errorf("run plan file %q has format version %d, want %d; cmd_gen and openconfig-ci may be out of sync", path, p.FormatVersion, PlanFormatVersion)
+	}
+	return p, nil
+}
+
+// expandArgv expands the references within argv to the runner's arguments
+// and to environment variables.
+func expandArgv(argv, args []string) []string {
+	var out []string
+	for _, arg := range argv {
+		if arg == "$@" {
+			out = append(out, args...)
+			continue
+		}
+		out = append(out, os.Expand(arg, func(name string) string {
+			if n, err := strconv.Atoi(name); err == nil {
+				if n >= 1 && n <= len(args) {
+					return args[n-1]
+				}
+				return ""
+			}
+			return os.Getenv(name)
+		}))
+	}
+	return out
+}
+
+// Run runs the plan with the given arguments, validating up to parallelism
+// models at once if the plan is parallel. Each model's output is written to
+// its "<modelDir>==<model>==pass", "==fail" or "==timeout" result file, and
+// its command line to its "==cmd" file, as by the bash scripts. An error is
+// only returned if the result files couldn't be written; failing commands
+// fail their model.
+func Run(ctx context.Context, p *Plan, args []string, parallelism int) error {
+	if err := os.MkdirAll(p.ResultsDir, 0755); err != nil {
+		return fmt.Errorf("cannot create results directory %q: %v", p.ResultsDir, err)
+	}
+	if !p.Parallel || parallelism < 1 {
+		parallelism = 1
+	}
+
+	completed, err := os.OpenFile(filepath.Join(p.ResultsDir, commonci.CompletedModelsFileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot open completed models file: %v", err)
+	}
+	defer completed.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for _, m := range p.Models {
+		m := m
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			prefix := filepath.Join(p.ResultsDir, m.ModelDirName+"=="+m.ModelName+"==")
+			err := runModel(ctx, m, prefix, args, time.Duration(p.TimeoutSeconds)*time.Second)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			if _, err := fmt.Fprintln(completed, prefix); err != nil {
+				errs = append(errs, fmt.Errorf("cannot record completion of model %s: %v", m.ModelName, err))
+			}
+		}()
+	}
+	wg.Wait()
+	if errs != nil {
+		var msgs []string
+		for _, err := range errs {
+			msgs = append(msgs, err.Error())
+		}
+		return fmt.Errorf("%s", strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// runModel runs the steps of a model, writing its result files with the
+// given path prefix. A failed model is run again up to m.Retries times.
+func runModel(ctx context.Context, m Model, prefix string, args []string, timeout time.Duration) error {
+	if m.Cmd != "" {
+		if err := os.WriteFile(prefix+"cmd", []byte(m.Cmd+"\n"), 0644); err != nil {
+			return fmt.Errorf("cannot write command of model %s: %v", m.ModelName, err)
+		}
+	}
+	resultStatus, err := runModelAttempt(ctx, m, prefix, args, timeout)
+	for retry := 1; err == nil && resultStatus == "fail" && retry <= m.Retries && ctx.Err() == nil; retry++ {
+		fmt.Fprintf(os.Stderr, "retrying %s after failure (retry %d of %d)\n", prefix, retry, m.Retries)
+		resultStatus, err = runModelAttempt(ctx, m, prefix, args, timeout)
+	}
+	if err != nil {
+		return err
+	}
+	if resultStatus != "pass" {
+		if err := os.Rename(prefix+"pass", prefix+resultStatus); err != nil {
+			return fmt.Errorf("cannot record failure of model %s: %v", m.ModelName, err)
+		}
+	}
+	return nil
+}
+
+// runModelAttempt runs the steps of a model once within the timeout, writing
+// its output to its pass file, and returns its result status.
+func runModelAttempt(ctx context.Context, m Model, prefix string, args []string, timeout time.Duration) (string, error) {
+	out, err := os.Create(prefix + "pass")
+	if err != nil {
+		return "", fmt.Errorf("cannot create output file of model %s: %v", m.ModelName, err)
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	resultStatus := "pass"
+	for _, step := range m.Steps {
+		if step.Marker != "" {
+			fmt.Fprintln(out, step.Marker)
+		}
+		if err := runStep(ctx, step, args, m.Env, out); err != nil {
+			if resultStatus != commonci.TimeoutStatus {
+				resultStatus = "fail"
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				fmt.Fprintf(out, "timed out after %v\n", timeout)
+				resultStatus = commonci.TimeoutStatus
+				break
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			// A command that timed out itself (e.g. a parse with
+			// -timeout) exits like timeout(1), which the bash
+			// scripts also report as a timeout.
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == yangutil.TimeoutExitCode {
+				resultStatus = commonci.TimeoutStatus
+			}
+			if !m.ContinueOnFailure {
+				break
+			}
+		}
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("cannot write output of model %s: %v", m.ModelName, err)
+	}
+	return resultStatus, nil
+}
+
+// runStep runs a single command with the given environment variables in
+// addition to the runner's, writing its combined output to out.
+func runStep(ctx context.Context, step Step, args, env []string, out *os.File) error {
+	argv := expandArgv(step.Argv, args)
+	if len(argv) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	if step.Dir != "" {
+		if err := os.MkdirAll(step.Dir, 0755); err != nil {
+			fmt.Fprintln(out, err)
+			return err
+		}
+	}
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = step.Dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = out
+	cmd.Stderr = out
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			// e.g. the command doesn't exist, which bash would also report.
+			fmt.Fprintln(out, err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpandArgv(t *testing.T) {
+	os.Setenv("RUNNER_TEST_DIR", "/plugins")
+	defer os.Unsetenv("RUNNER_TEST_DIR")
+
+	got := expandArgv([]string{"$@", "--plugindir", "$RUNNER_TEST_DIR", "$2", "$3", "a.yang"}, []string{"pyang", "-x"})
+	want := []string{"pyang", "-x", "--plugindir", "/plugins", "-x", "", "a.yang"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	resultsDir := t.TempDir()
+	p := &Plan{
+		FormatVersion:  PlanFormatVersion,
+		ValidatorId:    "test",
+		ResultsDir:     resultsDir,
+		Parallel:       true,
+		TimeoutSeconds: 1,
+		Models: []Model{{
+			ModelDirName: "acl",
+			ModelName:    "openconfig-acl",
+			Cmd:          "echo acl",
+			Steps:        []Step{{Argv: []string{"$1", "-c", "echo acl"}}},
+		}, {
+			ModelDirName: "aft",
+			ModelName:    "openconfig-aft",
+			Steps: []Step{
+				{Marker: "file: a", Argv: []string{"$1", "-c", "echo a; exit 1"}},
+				{Marker: "file: b", Argv: []string{"$1", "-c", "echo b"}},
+			},
+			ContinueOnFailure: true,
+		}, {
+			ModelDirName: "bgp",
+			ModelName:    "openconfig-bgp",
+			Steps: []Step{
+				{Argv: []string{"$1", "-c", "exit 1"}},
+				{Argv: []string{"$1", "-c", "echo unreachable"}},
+			},
+		}, {
+			ModelDirName: "isis",
+			ModelName:    "openconfig-isis",
+			Steps:        []Step{{Argv: []string{"$1", "-c", "sleep 5"}}},
+		}, {
+			ModelDirName: "mpls",
+			ModelName:    "openconfig-mpls",
+			Steps: []Step{
+				{Argv: []string{"$1", "-c", "echo a; exit 124"}},
+				{Argv: []string{"$1", "-c", "echo b; exit 1"}},
+			},
+			ContinueOnFailure: true,
+		}, {
+			ModelDirName: "ospf",
+			ModelName:    "openconfig-ospf",
+			Steps:        []Step{{Argv: []string{"does-not-exist"}}},
+		}},
+	}
+	if err := Run(context.Background(), p, []string{"sh"}, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := map[string]string{
+		"acl==openconfig-acl==cmd":       "echo acl\n",
+		"acl==openconfig-acl==pass":      "acl\n",
+		"aft==openconfig-aft==fail":      "file: a\na\nfile: b\nb\n",
+		"bgp==openconfig-bgp==fail":      "",
+		"isis==openconfig-isis==timeout": "timed out after 1s\n",
+		"mpls==openconfig-mpls==timeout": "a\nb\n",
+	}
+	for name, want := range wantFiles {
+		got, err := os.ReadFile(filepath.Join(resultsDir, name))
+		if err != nil {
+			t.Errorf("missing result file: %v", err)
+			continue
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s (-want, +got):\n%s", name, diff)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(resultsDir, "ospf==openconfig-ospf==fail")); err != nil {
+		t.Errorf("missing command didn't fail its model: %v", err)
+	}
+
+	completed, err := os.ReadFile(filepath.Join(resultsDir, "completed-models"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotCompleted := strings.Split(strings.TrimSpace(string(completed)), "\n")
+	sort.Strings(gotCompleted)
+	var wantCompleted []string
+	for _, m := range p.Models {
+		wantCompleted = append(wantCompleted, filepath.Join(resultsDir, m.ModelDirName+"=="+m.ModelName+"=="))
+	}
+	if diff := cmp.Diff(wantCompleted, gotCompleted); diff != "" {
+		t.Errorf("completed models (-want, +got):\n%s", diff)
+	}
+}
+
+func TestRunRetries(t *testing.T) {
+	resultsDir, countDir := t.TempDir(), t.TempDir()
+	// flaky fails the model until it has run more than the given number of
+	// times, counting the runs of each model in its own file.
+	flaky := func(name string, failures int) Step {
+		count := filepath.Join(countDir, name)
+		return Step{Argv: []string{"$1", "-c", fmt.Sprintf("echo -n x >> %s; echo run $(cat %s); [ $(wc -c < %s) -gt %d ]", count, count, count, failures)}}
+	}
+	p := &Plan{
+		FormatVersion: PlanFormatVersion,
+		ValidatorId:   "test",
+		ResultsDir:    resultsDir,
+		Models: []Model{{
+			ModelDirName: "acl",
+			ModelName:    "openconfig-acl",
+			Steps:        []Step{flaky("acl", 2)},
+			Retries:      2,
+		}, {
+			ModelDirName: "aft",
+			ModelName:    "openconfig-aft",
+			Steps:        []Step{flaky("aft", 3)},
+			Retries:      2,
+		}, {
+			ModelDirName: "bgp",
+			ModelName:    "openconfig-bgp",
+			Steps:        []Step{flaky("bgp", 1)},
+		}},
+	}
+	if err := Run(context.Background(), p, []string{"sh"}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := map[string]string{
+		"acl==openconfig-acl==pass": "run xxx\n",
+		"aft==openconfig-aft==fail": "run xxx\n",
+		"bgp==openconfig-bgp==fail": "run x\n",
+	}
+	for name, want := range wantFiles {
+		got, err := os.ReadFile(filepath.Join(resultsDir, name))
+		if err != nil {
+			t.Errorf("missing result file: %v", err)
+			continue
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s (-want, +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	resultsDir := t.TempDir()
+	p := &Plan{
+		FormatVersion: PlanFormatVersion,
+		ValidatorId:   "test",
+		ResultsDir:    resultsDir,
+		Models: []Model{{
+			ModelDirName: "acl",
+			ModelName:    "openconfig-acl",
+			Steps:        []Step{{Argv: []string{"$1", "-c", "printenv FEATURE LEVEL"}}},
+			Env:          []string{"FEATURE=when-stmts", "LEVEL=2"},
+		}, {
+			ModelDirName: "aft",
+			ModelName:    "openconfig-aft",
+			Steps:        []Step{{Argv: []string{"$1", "-c", "printenv FEATURE || echo unset"}}},
+		}},
+	}
+	if err := Run(context.Background(), p, []string{"sh"}, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := map[string]string{
+		"acl==openconfig-acl==pass": "when-stmts\n2\n",
+		"aft==openconfig-aft==pass": "unset\n",
+	}
+	for name, want := range wantFiles {
+		got, err := os.ReadFile(filepath.Join(resultsDir, name))
+		if err != nil {
+			t.Errorf("missing result file: %v", err)
+			continue
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s (-want, +got):\n%s", name, diff)
+		}
+	}
+}
+
+func TestReadPlan(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	want := &Plan{
+		ValidatorId: "pyang",
+		ResultsDir:  "/results",
+		Models: []Model{{
+			ModelDirName: "acl",
+			ModelName:    "openconfig-acl",
+			Steps:        []Step{{Argv: []string{"$@", "a.yang"}}},
+		}},
+	}
+	bs, err := MarshalPlan(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, bs, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadPlan(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+
+	oldPath := filepath.Join(t.TempDir(), "old.json")
+	if err := os.WriteFile(oldPath, []byte(`{"format-version": 0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPlan(oldPath); err == nil {
+		t.Errorf("got no error for plan of a different format version")
+	}
+}
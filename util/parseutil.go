@@ -17,6 +17,7 @@ package util
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,6 +31,22 @@ const (
 	// PYANG_MSG_TEMPLATE_STRING sets up an output template for pyang using
 	// its commandline option --msg-template.
 	PYANG_MSG_TEMPLATE_STRING = `PYANG_MSG_TEMPLATE='messages:{{path:"{file}" line:{line} code:"{code}" type:"{type}" level:{level} message:'"'{msg}'}}"`
+
+	// PyangMsgTemplate is the value of the PYANG_MSG_TEMPLATE variable set
+	// by PYANG_MSG_TEMPLATE_STRING, for passing to pyang without a shell.
+	PyangMsgTemplate = `messages:{{path:"{file}" line:{line} code:"{code}" type:"{type}" level:{level} message:'{msg}'}}`
+
+	// YanglintFileMarker prefixes the lines output by the yanglint
+	// validator script naming each build file it checks, such that
+	// yanglint's messages, which name the module rather than the file, can
+	// be attributed to it.
+	YanglintFileMarker = "yanglint-file: "
+	// ExampleFileMarker prefixes the line output by the yang-examples
+	// validator script before validating each example instance document,
+	// and ExampleStatusMarker prefixes the line with its status (pass or
+	// fail) after validating it.
+	ExampleFileMarker   = "example-file: "
+	ExampleStatusMarker = "example-status: "
 )
 
 var (
@@ -41,6 +58,30 @@ var (
 	// TODO(wenovus): Should use --msg-template to ingest pyang output as
 	// textproto instead of using regex.
 	stdErrorRegex = regexp.MustCompile(`^([^:]+):\s*(\d+)\s*(\([^\)]+\))?\s*:([^:]+):(.+)$`)
+
+	// yanglintMsgRegex recognizes the error/warning lines from yanglint.
+	// It currently recognizes the following patterns:
+	// - libyang err: message (libyang 2)
+	// - YANGLINT[E]: message (yanglint 2)
+	// - err : message (libyang 1)
+	yanglintMsgRegex = regexp.MustCompile(`^(?:libyang\s+(err|warn)|YANGLINT\[([EW])\]|(err|warn)\s*)\s*:\s*(.+)$`)
+	// yanglintLineNoRegex extracts the line number from a yanglint message
+	// (e.g. "(Line number 12.)" or "(Path "...", line number 12.)").
+	yanglintLineNoRegex = regexp.MustCompile(`(?i)line number (\d+)`)
+	// yanglintLocationRegex extracts the module of the schema or data
+	// location of a yanglint message (e.g. "(Schema location
+	// "/openconfig-acl:acl/state", line number 34.)" or "(Path
+	// "/openconfig-acl:acl/state", line number 34.)").
+	yanglintLocationRegex = regexp.MustCompile(`(?:(?:Schema|Data) location|Path) "/?([\w.-]+):`)
+	// yanglintModuleRegex extracts the module, or its file, named by a
+	// yanglint message (e.g. "Parsing module "openconfig-acl" failed.").
+	yanglintModuleRegex = regexp.MustCompile(`(?i)module "([^"]+)"`)
+
+	// yangsonMsgRegex recognizes the error lines from yangson.
+	// It currently recognizes the following patterns:
+	// - Module not found: message (yangson CLI)
+	// - yangson.exceptions.SchemaError: message (uncaught exception)
+	yangsonMsgRegex = regexp.MustCompile(`^(Invalid YANG library|Module not found|Module not registered|Multiple implemented revisions|Unsupported pre-requisite feature|Invalid data model|yangson\.exceptions\.\w+|\w+Error)\s*:\s*(.+)$`)
 )
 
 // StandardErrorLine contains a parsed commandline output from pyang.
@@ -106,6 +147,110 @@ func ParseStandardOutput(rawOut string) StandardOutput {
 	return out
 }
 
+// yanglintModuleName returns the name of the module of the YANG file at
+// path, e.g. "openconfig-acl" for "acl/openconfig-acl@2023-01-01.yang".
+func yanglintModuleName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".yang")
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// ParseYanglintOutput parses raw yanglint output, as output by the yanglint
+// validator script, into a structured format. yanglint checks all of a
+// model's build files in one invocation, and each message is attributed to
+// the YanglintFileMarker file of the module in its schema location, or else
+// of the module it names. A message naming no known module is attributed to
+// the only file if there's one, and to no file otherwise. Its line number is
+// 0 if yanglint doesn't report one.
+func ParseYanglintOutput(rawOut string) StandardOutput {
+	var out StandardOutput
+	var files []string
+	moduleFiles := map[string]string{}
+	lines := strings.Split(rawOut, "\n")
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, YanglintFileMarker) {
+			file := strings.TrimSpace(strings.TrimPrefix(line, YanglintFileMarker))
+			files = append(files, file)
+			moduleFiles[yanglintModuleName(file)] = file
+		}
+	}
+	fileOf := func(msg string) string {
+		if m := yanglintLocationRegex.FindStringSubmatch(msg); m != nil {
+			if file, ok := moduleFiles[m[1]]; ok {
+				return file
+			}
+		}
+		for _, m := range yanglintModuleRegex.FindAllStringSubmatch(msg, -1) {
+			if file, ok := moduleFiles[yanglintModuleName(m[1])]; ok {
+				return file
+			}
+		}
+		if len(files) == 1 {
+			return files[0]
+		}
+		return ""
+	}
+
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, YanglintFileMarker) {
+			continue
+		}
+
+		matches := yanglintMsgRegex.FindStringSubmatch(line)
+		if matches == nil {
+			out.OtherLines = append(out.OtherLines, line)
+			continue
+		}
+		errLine := &StandardErrorLine{
+			Message: strings.TrimSpace(matches[4]),
+		}
+		errLine.Path = fileOf(errLine.Message)
+		if lineMatches := yanglintLineNoRegex.FindStringSubmatch(errLine.Message); lineMatches != nil {
+			if lineNumber, err := strconv.ParseInt(lineMatches[1], 10, 32); err == nil {
+				errLine.LineNo = int32(lineNumber)
+			}
+		}
+		switch matches[1] + matches[2] + matches[3] {
+		case "err", "E":
+			errLine.Status = "error"
+			out.ErrorLines = append(out.ErrorLines, errLine)
+		default:
+			errLine.Status = "warning"
+			out.WarningLines = append(out.WarningLines, errLine)
+		}
+	}
+	return out
+}
+
+// ParseYangsonOutput parses raw yangson output, as output by the yangson
+// validator script, into a structured format. yangson validates the data
+// model described by a YANG library as a whole, so its messages aren't
+// attributed to a file. The tracebacks of uncaught exceptions are omitted
+// since their final line, which is parsed, describes the error.
+func ParseYangsonOutput(rawOut string) StandardOutput {
+	var out StandardOutput
+	for _, line := range strings.Split(rawOut, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "Traceback ") {
+			continue
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		matches := yangsonMsgRegex.FindStringSubmatch(line)
+		if matches == nil {
+			out.OtherLines = append(out.OtherLines, line)
+			continue
+		}
+		out.ErrorLines = append(out.ErrorLines, &StandardErrorLine{
+			Status:  "error",
+			Message: fmt.Sprintf("%s: %s", matches[1], strings.TrimSpace(matches[2])),
+		})
+	}
+	return out
+}
+
 // ParsePyangTextprotoOutput parses textproto-formatted pyang output into a
 // proto message. It assumes that the input string has format
 // defined by PYANG_MSG_TEMPLATE_STRING.
@@ -140,3 +285,38 @@ func ParsePyangTextprotoOutput(textprotoOut string) (*pb.PyangOutput, error) {
 	err := prototext.Unmarshal(escapedOutput, output)
 	return output, err
 }
+
+// ExampleResult is the result of validating an example instance document.
+type ExampleResult struct {
+	Path string
+	Pass bool
+	// Lines are the lines output while validating the example.
+	Lines []string
+}
+
+// ParseExamplesOutput parses raw output of the yang-examples validator script
+// into the result of each example, in order. Lines output before the first
+// ExampleFileMarker line (e.g. about a missing examples directory) are
+// returned separately.
+func ParseExamplesOutput(rawOut string) ([]*ExampleResult, []string) {
+	var results []*ExampleResult
+	var otherLines []string
+	var current *ExampleResult
+	for _, line := range strings.Split(rawOut, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, ExampleFileMarker):
+			current = &ExampleResult{Path: strings.TrimSpace(strings.TrimPrefix(line, ExampleFileMarker))}
+			results = append(results, current)
+		case strings.HasPrefix(line, ExampleStatusMarker) && current != nil:
+			current.Pass = strings.TrimSpace(strings.TrimPrefix(line, ExampleStatusMarker)) == "pass"
+		case current != nil:
+			current.Lines = append(current.Lines, line)
+		default:
+			otherLines = append(otherLines, line)
+		}
+	}
+	return results, otherLines
+}
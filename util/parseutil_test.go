@@ -95,6 +95,129 @@ foo
 	}
 }
 
+func TestParseYanglintOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want StandardOutput
+	}{{
+		name: "no messages",
+		in:   "yanglint-file: /workspace/release/yang/acl/openconfig-acl.yang\n",
+		want: StandardOutput{},
+	}, {
+		name: "libyang 1 and 2 messages across files",
+		in: `yanglint-file: /workspace/release/yang/acl/openconfig-acl.yang
+yanglint-file: /workspace/release/yang/acl/openconfig-acl-types.yang
+libyang warn: Module "foo" not found.
+libyang err: Invalid character sequence "lef", expected a keyword. (Line number 12.)
+libyang err: Parsing module "openconfig-acl" failed.
+YANGLINT[E]: Parsing schema module "/workspace/release/yang/acl/openconfig-acl.yang" failed.
+warn: Leafref target is config false. (Path "/openconfig-acl-types:acl/state", line number 34.)
+libyang err: Invalid leafref path "../foo". (Schema location "/openconfig-acl:acl/config/name", line number 40.)
+err : Unexpected end of input.
+something else
+`,
+		want: StandardOutput{
+			ErrorLines: []*StandardErrorLine{{
+				LineNo:  12,
+				Status:  "error",
+				Message: `Invalid character sequence "lef", expected a keyword. (Line number 12.)`,
+			}, {
+				Path:    "/workspace/release/yang/acl/openconfig-acl.yang",
+				Status:  "error",
+				Message: `Parsing module "openconfig-acl" failed.`,
+			}, {
+				Path:    "/workspace/release/yang/acl/openconfig-acl.yang",
+				Status:  "error",
+				Message: `Parsing schema module "/workspace/release/yang/acl/openconfig-acl.yang" failed.`,
+			}, {
+				Path:    "/workspace/release/yang/acl/openconfig-acl.yang",
+				LineNo:  40,
+				Status:  "error",
+				Message: `Invalid leafref path "../foo". (Schema location "/openconfig-acl:acl/config/name", line number 40.)`,
+			}, {
+				Status:  "error",
+				Message: `Unexpected end of input.`,
+			}},
+			WarningLines: []*StandardErrorLine{{
+				Status:  "warning",
+				Message: `Module "foo" not found.`,
+			}, {
+				Path:    "/workspace/release/yang/acl/openconfig-acl-types.yang",
+				LineNo:  34,
+				Status:  "warning",
+				Message: `Leafref target is config false. (Path "/openconfig-acl-types:acl/state", line number 34.)`,
+			}},
+			OtherLines: []string{"something else"},
+		},
+	}, {
+		name: "single revisioned file",
+		in: `yanglint-file: /workspace/release/yang/acl/openconfig-acl@2023-01-01.yang
+libyang err: Invalid character sequence "lef", expected a keyword. (Line number 12.)
+libyang err: Parsing module "openconfig-acl" failed.
+`,
+		want: StandardOutput{
+			ErrorLines: []*StandardErrorLine{{
+				Path:    "/workspace/release/yang/acl/openconfig-acl@2023-01-01.yang",
+				LineNo:  12,
+				Status:  "error",
+				Message: `Invalid character sequence "lef", expected a keyword. (Line number 12.)`,
+			}, {
+				Path:    "/workspace/release/yang/acl/openconfig-acl@2023-01-01.yang",
+				Status:  "error",
+				Message: `Parsing module "openconfig-acl" failed.`,
+			}},
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, ParseYanglintOutput(tt.in)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseYangsonOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want StandardOutput
+	}{{
+		name: "no messages",
+		in:   "",
+		want: StandardOutput{},
+	}, {
+		name: "CLI errors and uncaught exception",
+		in: `Module not found: openconfig-missing@
+Traceback (most recent call last):
+  File "/usr/local/bin/yangson", line 8, in <module>
+    sys.exit(main())
+yangson.exceptions.DefinitionNotFound: grouping oc-a:missing-config
+open /workspace/release/models/a/openconfig-a.yang: no such file or directory
+`,
+		want: StandardOutput{
+			ErrorLines: []*StandardErrorLine{{
+				Status:  "error",
+				Message: "Module not found: openconfig-missing@",
+			}, {
+				Status:  "error",
+				Message: "yangson.exceptions.DefinitionNotFound: grouping oc-a:missing-config",
+			}},
+			OtherLines: []string{"open /workspace/release/models/a/openconfig-a.yang: no such file or directory"},
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, ParseYangsonOutput(tt.in)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestParsePyangTextprotoOutput(t *testing.T) {
 	tests := []struct {
 		desc          string
@@ -177,3 +300,57 @@ messages:{path:"/workspace/yang/isis/openconfig-isis.yang" line:27 code:"LINT_BA
 		})
 	}
 }
+
+func TestParseExamplesOutput(t *testing.T) {
+	tests := []struct {
+		name           string
+		in             string
+		wantResults    []*ExampleResult
+		wantOtherLines []string
+	}{{
+		name: "no examples",
+		in:   "",
+	}, {
+		name: "passing and failing examples",
+		in: `example-file: /workspace/release/yang/acl/examples/acl.json
+example-status: pass
+example-file: /workspace/release/yang/acl/examples/bad.xml
+libyang err: Invalid value "foo" of "name". (Line number 3.)
+YANGLINT[E]: Failed to parse input data file "bad.xml".
+example-status: fail
+`,
+		wantResults: []*ExampleResult{{
+			Path: "/workspace/release/yang/acl/examples/acl.json",
+			Pass: true,
+		}, {
+			Path: "/workspace/release/yang/acl/examples/bad.xml",
+			Lines: []string{
+				`libyang err: Invalid value "foo" of "name". (Line number 3.)`,
+				`YANGLINT[E]: Failed to parse input data file "bad.xml".`,
+			},
+		}},
+	}, {
+		name:           "missing examples directory",
+		in:             "examples directory /workspace/release/yang/acl/examples does not exist\n",
+		wantOtherLines: []string{"examples directory /workspace/release/yang/acl/examples does not exist"},
+	}, {
+		name: "interrupted example fails",
+		in:   "example-file: /workspace/release/yang/acl/examples/acl.json\ntimed out after 300s\n",
+		wantResults: []*ExampleResult{{
+			Path:  "/workspace/release/yang/acl/examples/acl.json",
+			Lines: []string{"timed out after 300s"},
+		}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotResults, gotOtherLines := ParseExamplesOutput(tt.in)
+			if diff := cmp.Diff(tt.wantResults, gotResults); diff != "" {
+				t.Errorf("results (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantOtherLines, gotOtherLines); diff != "" {
+				t.Errorf("other lines (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,18 +16,20 @@
 
 ROOT_DIR=/workspace
 USERCONFIG_DIR=$ROOT_DIR/user-config
+PLAN_FILE=$USERCONFIG_DIR/plan.json
 
 if [ -z $_PR_NUMBER ]; then
   echo "skipping: don't post compatibility report for push to master"
   exit 0
 fi
 
-if ! [ -s $USERCONFIG_DIR/compat-report-validators.txt ]; then
+COMPAT_REPORT=$($GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE compat-report)
+if [[ -z $COMPAT_REPORT ]]; then
   echo "skipping: no validators to report in compatibility report"
   exit 0
 fi
 
 echo validators to be put in compability report:
-cat $USERCONFIG_DIR/compat-report-validators.txt
+echo "$COMPAT_REPORT"
 
 $GOPATH/bin/post_results -validator=compat-report -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -commit-sha=$COMMIT_SHA -pr-number=$_PR_NUMBER -branch=$BRANCH_NAME
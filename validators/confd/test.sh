@@ -19,25 +19,101 @@ ZIP_FILE=$ROOT_DIR/confd.zip
 RESULTSDIR=$ROOT_DIR/results/confd
 OUTFILE=$RESULTSDIR/out
 FAILFILE=$RESULTSDIR/fail
+PLAN_FILE=$ROOT_DIR/user-config/plan.json
+# The location from which the zips of extra ConfD Basic versions are fetched.
+CONFD_ZIP_LOCATION=${CONFD_ZIP_LOCATION:-gs://openconfig/confd}
+
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=confd -mark-exited' EXIT
+
+CONFDPATH=`find ${_MODEL_ROOT//,/ } -type d | tr '\n' ':'`:$ROOT_DIR/third_party/ietf
+
+# The extra versions of the validator are listed by the plan relayed by cmd_gen.
+extra-versions() {
+  $GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE extra-versions $1
+}
+
+# For running the extra versions of ConfD Basic, each of which is installed
+# from a confd-<version>.zip, which is fetched from $CONFD_ZIP_LOCATION unless
+# the build has already fetched it alongside confd.zip. Failing to install the
+# version is a CI infra failure (exit status 3) rather than a result of the
+# version.
+run-confd-version() {
+  local RESULTSDIR=$ROOT_DIR/results/confd@$1
+  if ! stat $RESULTSDIR; then
+    return 0
+  fi
+  echo "running extra ConfD Basic version $1"
+  local ZIP=$ROOT_DIR/confd-$1.zip
+  if [[ ! -f $ZIP ]] && ! gsutil cp $CONFD_ZIP_LOCATION/confd-$1.zip $ZIP; then
+    echo "failed to fetch confd-$1.zip from $CONFD_ZIP_LOCATION" >&2
+    return 3
+  fi
+  # ConfD Basic is installed outside of the results directory, such that its
+  # files aren't mistaken for results.
+  local INSTALLDIR
+  if ! INSTALLDIR=$(mktemp -d); then
+    echo "failed to create the install directory of ConfD Basic version $1" >&2
+    return 3
+  fi
+  if ! unzip -q $ZIP -d $INSTALLDIR/confd-unzipped; then
+    echo "failed to unzip confd-$1.zip" >&2
+    return 3
+  fi
+  local INSTALLER
+  INSTALLER=$(find $INSTALLDIR/confd-unzipped -name 'confd-basic-*.linux.x86_64.installer.bin' | head -n 1)
+  if [[ -z $INSTALLER ]] || ! $INSTALLER $INSTALLDIR/confd-install; then
+    echo "failed to install ConfD Basic version $1 from confd-$1.zip" >&2
+    return 3
+  fi
+  local CONFDC=$INSTALLDIR/confd-install/bin/confdc
+  if ! $CONFDC --version; then
+    echo "confdc of ConfD Basic version $1 doesn't run" >&2
+    return 3
+  fi
+  if bash $RESULTSDIR/script.sh $CONFDC $CONFDPATH > $RESULTSDIR/out 2> $RESULTSDIR/fail; then
+    # Delete fail file if it's empty and the script passed.
+    find $RESULTSDIR/fail -size 0 -delete
+  fi
+  $GOPATH/bin/post_results -validator=confd -version=$1 -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
+}
+
+# wait-versions waits for the extra versions, exiting with the status of any
+# that failed, e.g. 3 for a failure to install the version.
+wait-versions() {
+  local status=0
+  for pid in "${VERSION_PIDS[@]}"; do
+    wait $pid || status=$?
+  done
+  exit $status
+}
+
+EXTRA_VERSIONS=$(extra-versions confd)
+if [[ -n $EXTRA_VERSIONS ]] || stat $RESULTSDIR; then
+  if ! apt install -qy unzip; then
+    echo "failed to install unzip" >&2
+    exit 3
+  fi
+fi
+VERSION_PIDS=()
+for version in $EXTRA_VERSIONS; do
+  run-confd-version "$version" &
+  VERSION_PIDS+=( $! )
+done
 
 if ! stat $RESULTSDIR; then
-  exit 0
+  wait-versions
 fi
 
-apt install -qy unzip
 unzip $ZIP_FILE -d $RESULTSDIR/confd-unzipped
 find $RESULTSDIR/confd-unzipped -name 'confd-basic-*.linux.x86_64.installer.bin' -exec {} $RESULTSDIR/confd-install \;
 CONFDC=$RESULTSDIR/confd-install/bin/confdc
 
-CONFDPATH=`find $_MODEL_ROOT -type d | tr '\n' ':'`:$ROOT_DIR/third_party/ietf
-
 $CONFDC --version > $RESULTSDIR/latest-version.txt
 if bash $RESULTSDIR/script.sh $CONFDC $CONFDPATH > $OUTFILE 2> $FAILFILE; then
   # Delete fail file if it's empty and the script passed.
   find $FAILFILE -size 0 -delete
 fi
 $GOPATH/bin/post_results -validator=confd -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-BADGEFILE=$RESULTSDIR/upload-badge.sh
-if stat $BADGEFILE; then
-  bash $BADGEFILE
-fi
+wait-versions
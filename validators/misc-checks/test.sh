@@ -18,6 +18,16 @@ ROOT_DIR=/workspace
 RESULTSDIR=$ROOT_DIR/results/misc-checks
 OUTFILE=$RESULTSDIR/out
 FAILFILE=$RESULTSDIR/fail
+PLAN_FILE=$ROOT_DIR/user-config/plan.json
+# The PR's base branch and merge base with it are resolved by cmd_gen, and the
+# merge base is otherwise computed against the base branch, which defaults to
+# the default branch.
+BASE_REF=$($GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE base-ref)
+MERGE_BASE=$($GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE merge-base)
+
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=misc-checks -mark-exited' EXIT
 
 if ! stat $RESULTSDIR; then
   exit 0
@@ -26,7 +36,7 @@ fi
 go get github.com/openconfig/models-ci/validators/misc-checks/...
 
 # all-non-empty-files.txt
-find $_MODEL_ROOT -name '*.yang' > $RESULTSDIR/all-non-empty-files.txt 2>> $OUTFILE
+find ${_MODEL_ROOT//,/ } -name '*.yang' > $RESULTSDIR/all-non-empty-files.txt 2>> $OUTFILE
 
 # pr-file-parse-log
 # This output is used to check for both the version update as well as build
@@ -52,18 +62,43 @@ PRBRANCH=gcb-ci-remote-repo-long-name-to-avoid-conflict
 # fetching the PR directly from GitHub handles both normal PRs as well as forks.
 git fetch origin pull/$_PR_NUMBER/head:$PRBRANCH
 git checkout $PRBRANCH
-BASE_COMMIT=$(git merge-base $PRBRANCH origin/master)
+BASE_COMMIT=$MERGE_BASE
+if [[ -z $BASE_COMMIT ]]; then
+  BASE_COMMIT=$(git merge-base $PRBRANCH origin/$BASE_REF)
+fi
 git diff --name-only $BASE_COMMIT | grep -E '.*\.yang$' > $RESULTSDIR/changed-files.txt 2>> $OUTFILE
 
+# whitespace-log
+# Lint the PR's version of the changed files for tabs, trailing whitespace,
+# CRLF line endings and missing final newlines.
+cat $RESULTSDIR/changed-files.txt | xargs $GOPATH/bin/wscheck > $RESULTSDIR/whitespace-log 2>> $FAILFILE
+
 # master-file-parse-log
 git checkout $BASE_COMMIT &>> $OUTFILE
-if find $REPODIR -name '*.yang' | xargs $GOPATH/bin/ocversion -p $REPODIR > $RESULTSDIR/master-file-parse-log 2>> $FAILFILE; then
+if find $REPODIR -name '*.yang' | xargs $GOPATH/bin/ocversion -timeout=10m -cache-dir=/workspace/parse-cache -p $REPODIR > $RESULTSDIR/master-file-parse-log 2>> $FAILFILE; then
   # Delete fail file if it's empty and the script passed.
   find $FAILFILE -size 0 -delete
 fi
 
+# disallowed-incompats
+# The backward-incompatible changes that the PR's version increments don't
+# allow, which fail the required status.
+OLD_PATHS=$REPODIR/third_party
+NEW_PATHS=$ROOT_DIR/third_party
+for root in ${_MODEL_ROOT//,/ }; do
+  OLD_PATHS+=,$REPODIR/$root
+  NEW_PATHS+=,$ROOT_DIR/$root
+done
+: > $RESULTSDIR/disallowed-incompats
+for root in ${_MODEL_ROOT//,/ }; do
+  $GOPATH/bin/openconfig-ci diff --disallowed-incompats --oldp "$OLD_PATHS" --newp "$NEW_PATHS" --oldroot $REPODIR/$root --newroot $ROOT_DIR/$root >> $RESULTSDIR/disallowed-incompats 2>> $OUTFILE
+  status=$?
+  # Exit status 4 means that disallowed changes were found.
+  if [[ $status -ne 0 && $status -ne 4 ]]; then
+    echo "openconfig-ci diff failed for $root with exit status $status" >> $FAILFILE
+    rm -f $RESULTSDIR/disallowed-incompats
+    break
+  fi
+done
+
 $GOPATH/bin/post_results -validator=misc-checks -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-BADGEFILE=$RESULTSDIR/upload-badge.sh
-if stat $BADGEFILE; then
-  bash $BADGEFILE
-fi
@@ -0,0 +1,78 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// wscheck lists whitespace hygiene issues in the given YANG files, one per
+// line in "<file>:<line>: <issue>" format. Files that don't exist (e.g.
+// deleted by a PR) are skipped.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// whitespaceIssues returns the whitespace issues found in the given file
+// content, in "<name>:<line>: <issue>" format.
+func whitespaceIssues(name string, content []byte) []string {
+	var issues []string
+	if len(content) == 0 {
+		return nil
+	}
+	lines := bytes.Split(content, []byte("\n"))
+	// A file with a trailing newline has an empty last element.
+	if last := lines[len(lines)-1]; len(last) != 0 {
+		issues = append(issues, fmt.Sprintf("%s:%d: missing newline at end of file", name, len(lines)))
+	} else {
+		lines = lines[:len(lines)-1]
+	}
+	for i, line := range lines {
+		lineNo := i + 1
+		if bytes.HasSuffix(line, []byte("\r")) {
+			issues = append(issues, fmt.Sprintf("%s:%d: CRLF line ending", name, lineNo))
+			line = bytes.TrimSuffix(line, []byte("\r"))
+		}
+		if bytes.ContainsRune(line, '\t') {
+			issues = append(issues, fmt.Sprintf("%s:%d: tab character", name, lineNo))
+		}
+		if trimmed := bytes.TrimRight(line, " \t"); len(trimmed) != len(line) {
+			issues = append(issues, fmt.Sprintf("%s:%d: trailing whitespace", name, lineNo))
+		}
+	}
+	return issues
+}
+
+func main() {
+	flag.Parse()
+
+	var issues []string
+	for _, name := range flag.Args() {
+		content, err := os.ReadFile(name)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		issues = append(issues, whitespaceIssues(name, content)...)
+	}
+	if len(issues) > 0 {
+		fmt.Println(strings.Join(issues, "\n"))
+	}
+}
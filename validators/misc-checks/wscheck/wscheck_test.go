@@ -0,0 +1,67 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWhitespaceIssues(t *testing.T) {
+	tests := []struct {
+		desc      string
+		inContent string
+		want      []string
+	}{{
+		desc:      "clean",
+		inContent: "module foo {\n  prefix \"foo\";\n}\n",
+	}, {
+		desc:      "empty",
+		inContent: "",
+	}, {
+		desc:      "tab",
+		inContent: "module foo {\n\tprefix \"foo\";\n}\n",
+		want:      []string{"f.yang:2: tab character"},
+	}, {
+		desc:      "trailing whitespace",
+		inContent: "module foo { \n  prefix \"foo\";\t\n}\n",
+		want: []string{
+			"f.yang:1: trailing whitespace",
+			"f.yang:2: tab character",
+			"f.yang:2: trailing whitespace",
+		},
+	}, {
+		desc:      "CRLF",
+		inContent: "module foo {\r\n  prefix \"foo\";\r\n}\r\n",
+		want: []string{
+			"f.yang:1: CRLF line ending",
+			"f.yang:2: CRLF line ending",
+			"f.yang:3: CRLF line ending",
+		},
+	}, {
+		desc:      "missing trailing newline",
+		inContent: "module foo {\n  prefix \"foo\";\n}",
+		want:      []string{"f.yang:3: missing newline at end of file"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, whitespaceIssues("f.yang", []byte(tt.inContent))); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -16,15 +16,23 @@
 
 ########################## COMMON SETUP #############################
 ROOT_DIR=/workspace
-MODELROOT=$ROOT_DIR/release/yang
 TESTDIR=$ROOT_DIR
 VENVDIR=$TESTDIR/pyangvenv
 RESULTSDIR=$ROOT_DIR/results/pyang
 OUTFILE_NAME=out
 FAILFILE_NAME=fail
-EXTRA_VERSIONS_FILE=$ROOT_DIR/user-config/extra-pyang-versions.txt
+PLAN_FILE=$ROOT_DIR/user-config/plan.json
+
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=pyang -mark-exited' EXIT
 
 ########################## PYANG #############################
+# The extra versions of the validator are listed by the plan relayed by cmd_gen.
+extra-versions() {
+  $GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE extra-versions $1
+}
+
 # For running older versions of pyang
 run-pyang-version() {
   local RESULTSDIR=$ROOT_DIR/results/pyang@$1
@@ -41,10 +49,6 @@ run-pyang-version() {
     find $RESULTSDIR/$FAILFILE_NAME -size 0 -delete
   fi
   $GOPATH/bin/post_results -validator=pyang -version=$1 -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-  BADGEFILE=$RESULTSDIR/upload-badge.sh
-  if stat $BADGEFILE; then
-    bash $BADGEFILE
-  fi
 }
 
 run-pyang-head() {
@@ -70,7 +74,7 @@ run-pyang-head() {
 }
 
 run-pyang-head &
-for version in $(< $EXTRA_VERSIONS_FILE); do
+for version in $(extra-versions pyang); do
   run-pyang-version "$version" &
 done
 
@@ -87,10 +91,12 @@ if bash $RESULTSDIR/script.sh $VENVDIR/bin/pyang > $RESULTSDIR/$OUTFILE_NAME 2>
   find $RESULTSDIR/$FAILFILE_NAME -size 0 -delete
 fi
 $GOPATH/bin/post_results -validator=pyang -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-BADGEFILE=$RESULTSDIR/upload-badge.sh
-if stat $BADGEFILE; then
-  bash $BADGEFILE
-fi
 
 ########################## CLEANUP #############################
 wait
+
+# Report the results of every pyang version together within the pyang version
+# matrix if requested by cmd_gen.
+if [[ -n $_PR_NUMBER && $($GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE pyang-matrix) == true ]]; then
+  $GOPATH/bin/post_results -validator=pyang-matrix -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
+fi
@@ -16,13 +16,16 @@
 
 ########################## SETUP #############################
 ROOT_DIR=/workspace
-MODELROOT=$ROOT_DIR/release/yang
 TESTDIR=$ROOT_DIR
 VENVDIR=$TESTDIR/pyangbindvenv
 RESULTSDIR=$ROOT_DIR/results/pyangbind
 OUTFILE=$RESULTSDIR/out
 FAILFILE=$RESULTSDIR/fail
 
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=pyangbind -mark-exited' EXIT
+
 if ! stat $RESULTSDIR; then
   exit 0
 fi
@@ -55,10 +58,6 @@ if bash $RESULTSDIR/script.sh $VENVDIR/bin/pyang > $OUTFILE 2> $FAILFILE; then
   find $FAILFILE -size 0 -delete
 fi
 $GOPATH/bin/post_results -validator=pyangbind -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-BADGEFILE=$RESULTSDIR/upload-badge.sh
-if stat $BADGEFILE; then
-  bash $BADGEFILE
-fi
 
 ########################## CLEANUP #############################
 teardown
@@ -21,6 +21,10 @@ OUTFILE=$RESULTSDIR/out
 FAILFILE=$RESULTSDIR/fail
 VENVDIR=$ROOT_DIR/regexpvenv
 
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=regexp -mark-exited' EXIT
+
 if ! stat $RESULTSDIR; then
   exit 0
 fi
@@ -39,12 +43,35 @@ setup
 
 ########################## regexp #############################
 FAIL=0
+# The output of every test below is appended to the output file.
+> $OUTFILE
 
 TESTFILES_FILE="$(mktemp)"
-find "$ROOT_DIR/regexp-tests" -name "*.yang" -print0 > "$TESTFILES_FILE"
+find "$ROOT_DIR/regexp-tests" -name "*.yang" -print0 | sort -z > "$TESTFILES_FILE"
+
+# With REGEXP_SHARD=N/M (e.g. 2/4), only every Mth test file starting from the
+# Nth is run, such that a large test corpus can be split across CI workers.
+if [[ -n $REGEXP_SHARD ]]; then
+  SHARD_INDEX=${REGEXP_SHARD%/*}
+  SHARD_COUNT=${REGEXP_SHARD#*/}
+  if ! [[ $SHARD_INDEX =~ ^[0-9]+$ && $SHARD_COUNT =~ ^[0-9]+$ ]] || (( SHARD_INDEX < 1 || SHARD_INDEX > SHARD_COUNT )); then
+    echo "invalid REGEXP_SHARD $REGEXP_SHARD, expected N/M with 1 <= N <= M" >&2
+    exit 2
+  fi
+  SHARDFILES_FILE="$(mktemp)"
+  i=0
+  while IFS= read -r -d '' f; do
+    if (( i % SHARD_COUNT == SHARD_INDEX - 1 )); then
+      printf '%s\0' "$f" >> "$SHARDFILES_FILE"
+    fi
+    i=$((i + 1))
+  done < "$TESTFILES_FILE"
+  TESTFILES_FILE=$SHARDFILES_FILE
+  echo "running regexp test shard $REGEXP_SHARD" >> $OUTFILE
+fi
 echo '## RFC7950 `pattern` statement' >> $FAILFILE
 XSDFAILFILE=$RESULTSDIR/xsdfail
-if cat "$TESTFILES_FILE" | OCDIR=$_MODEL_ROOT xargs -0 $GOPATH/src/github.com/openconfig/pattern-regex-tests/pytests/pattern_test.sh > $OUTFILE 2> $XSDFAILFILE; then
+if cat "$TESTFILES_FILE" | OCDIR=$_MODEL_ROOT xargs -0 $GOPATH/src/github.com/openconfig/pattern-regex-tests/pytests/pattern_test.sh >> $OUTFILE 2> $XSDFAILFILE; then
   echo "Passed." >> $FAILFILE
 else
   FAIL=1
@@ -73,10 +100,6 @@ if [ $FAIL -eq 0 ]; then
 fi
 
 $GOPATH/bin/post_results -validator=regexp -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-BADGEFILE=$RESULTSDIR/upload-badge.sh
-if stat $BADGEFILE; then
-  bash $BADGEFILE
-fi
 
 ########################## CLEANUP #############################
 teardown
@@ -0,0 +1,67 @@
+#!/bin/bash
+# Copyright 2024 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+
+ROOT_DIR=/workspace
+RESULTSDIR=$ROOT_DIR/results/tree-diff
+OUTFILE=$RESULTSDIR/out
+FAILFILE=$RESULTSDIR/fail
+VENVDIR=$ROOT_DIR/pyangvenv-tree-diff
+# The base is checked out outside of the results directory, such that its
+# files aren't mistaken for results.
+REPODIR=$ROOT_DIR/tree-diff-base
+PLAN_FILE=$ROOT_DIR/user-config/plan.json
+DEFAULT_BRANCH=$($GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE default-branch)
+# The PR's base branch and merge base with it are resolved by cmd_gen, and the
+# merge base is otherwise computed against the base branch, which defaults to
+# the default branch.
+BASE_REF=$($GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE base-ref)
+MERGE_BASE=$($GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE merge-base)
+
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=tree-diff -mark-exited' EXIT
+
+if ! stat $RESULTSDIR; then
+  exit 0
+fi
+
+virtualenv $VENVDIR
+source $VENVDIR/bin/activate
+pip3 install pyang &> $OUTFILE
+pyang --version > $RESULTSDIR/latest-version.txt
+
+# Check out the PR's merge base with its base branch, or the default branch
+# itself for a push to it, in which case there are no changes.
+git clone "git@github.com:$_REPO_SLUG.git" $REPODIR &>> $OUTFILE
+cd $REPODIR
+BASE_COMMIT=origin/$DEFAULT_BRANCH
+if [[ -n "$_PR_NUMBER" ]]; then
+  PRBRANCH=gcb-ci-remote-repo-long-name-to-avoid-conflict
+  # fetching the PR directly from GitHub handles both normal PRs as well as forks.
+  git fetch origin pull/$_PR_NUMBER/head:$PRBRANCH &>> $OUTFILE
+  BASE_COMMIT=$MERGE_BASE
+  if [[ -z $BASE_COMMIT ]]; then
+    BASE_COMMIT=$(git merge-base $PRBRANCH origin/$BASE_REF)
+  fi
+fi
+git checkout $BASE_COMMIT &>> $OUTFILE
+cd $ROOT_DIR
+
+if bash $RESULTSDIR/script.sh $REPODIR $VENVDIR/bin/pyang >> $OUTFILE 2>> $FAILFILE; then
+  # Delete fail file if it's empty and the script passed.
+  find $FAILFILE -size 0 -delete
+fi
+$GOPATH/bin/post_results -validator=tree-diff -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
@@ -0,0 +1,41 @@
+#!/bin/bash
+# Copyright 2024 Google LLC
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#      http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+
+ROOT_DIR=/workspace
+DEB_FILE1=$ROOT_DIR/libyang.deb
+DEB_FILE2=$ROOT_DIR/yanglint.deb
+RESULTSDIR=$ROOT_DIR/results/yang-examples
+OUTFILE=$RESULTSDIR/out
+FAILFILE=$RESULTSDIR/fail
+
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=yang-examples -mark-exited' EXIT
+
+if ! stat $RESULTSDIR; then
+  exit 0
+fi
+
+# The examples are validated using the same yanglint as the yanglint validator.
+apt install $DEB_FILE1
+apt install $DEB_FILE2
+
+yanglint -v > $RESULTSDIR/latest-version.txt
+if bash $RESULTSDIR/script.sh > $OUTFILE 2> $FAILFILE; then
+  # Delete fail file if it's empty and the script passed.
+  find $FAILFILE -size 0 -delete
+fi
+$GOPATH/bin/post_results -validator=yang-examples -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
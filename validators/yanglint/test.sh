@@ -20,9 +20,82 @@ DEB_FILE2=$ROOT_DIR/yanglint.deb
 RESULTSDIR=$ROOT_DIR/results/yanglint
 OUTFILE=$RESULTSDIR/out
 FAILFILE=$RESULTSDIR/fail
+PLAN_FILE=$ROOT_DIR/user-config/plan.json
+LIBYANG_REPO=https://github.com/CESNET/libyang.git
+
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=yanglint -mark-exited' EXIT
+
+# The extra versions of the validator are listed by the plan relayed by cmd_gen.
+extra-versions() {
+  $GOPATH/bin/openconfig-ci plan --plan-file $PLAN_FILE extra-versions $1
+}
+
+# For running the versions of yanglint pinned by the models repo, which are
+# built from the corresponding libyang release tag, or from the latest release
+# of the minor version for versions like 2.1.x. Failing to build the version is
+# a CI infra failure (exit status 3) rather than a result of the version.
+run-yanglint-version() {
+  local RESULTSDIR=$ROOT_DIR/results/yanglint@$1
+  if ! stat $RESULTSDIR; then
+    return 0
+  fi
+  echo "running extra yanglint version $1"
+  local TAG=v$1
+  if [[ $1 == *.x ]]; then
+    TAG=$(git ls-remote --tags --refs $LIBYANG_REPO "v${1%x}*" | sed 's|.*refs/tags/||' | grep -E '^v[0-9]+\.[0-9]+\.[0-9]+$' | sort -V | tail -n 1)
+    if [[ -z $TAG ]]; then
+      echo "no libyang release found for yanglint version $1" >&2
+      return 3
+    fi
+  fi
+  # The build is outside of the results directory, such that its files aren't
+  # mistaken for results.
+  local BUILDDIR
+  if ! BUILDDIR=$(mktemp -d); then
+    echo "failed to create the build directory of yanglint version $1" >&2
+    return 3
+  fi
+  local REPODIR=$BUILDDIR/libyang
+  local INSTALLDIR=$BUILDDIR/install
+  if ! git clone --depth 1 --branch $TAG $LIBYANG_REPO $REPODIR; then
+    echo "failed to clone libyang $TAG for yanglint version $1" >&2
+    return 3
+  fi
+  if ! cmake -S $REPODIR -B $REPODIR/build -DCMAKE_INSTALL_PREFIX=$INSTALLDIR -DCMAKE_BUILD_TYPE=Release; then
+    echo "failed to configure the build of libyang $TAG for yanglint version $1" >&2
+    return 3
+  fi
+  if ! make -C $REPODIR/build install; then
+    echo "failed to build libyang $TAG for yanglint version $1" >&2
+    return 3
+  fi
+  if PATH=$INSTALLDIR/bin:$PATH LD_LIBRARY_PATH=$INSTALLDIR/lib bash $RESULTSDIR/script.sh > $RESULTSDIR/out 2> $RESULTSDIR/fail; then
+    # Delete fail file if it's empty and the script passed.
+    find $RESULTSDIR/fail -size 0 -delete
+  fi
+  $GOPATH/bin/post_results -validator=yanglint -version=$1 -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
+}
+
+# wait-versions waits for the extra versions, exiting with the status of any
+# that failed, e.g. 3 for a failure to build the version.
+wait-versions() {
+  local status=0
+  for pid in "${VERSION_PIDS[@]}"; do
+    wait $pid || status=$?
+  done
+  exit $status
+}
+
+VERSION_PIDS=()
+for version in $(extra-versions yanglint); do
+  run-yanglint-version "$version" &
+  VERSION_PIDS+=( $! )
+done
 
 if ! stat $RESULTSDIR; then
-  exit 0
+  wait-versions
 fi
 
 apt install $DEB_FILE1
@@ -34,7 +107,4 @@ if bash $RESULTSDIR/script.sh > $OUTFILE 2> $FAILFILE; then
   find $FAILFILE -size 0 -delete
 fi
 $GOPATH/bin/post_results -validator=yanglint -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-BADGEFILE=$RESULTSDIR/upload-badge.sh
-if stat $BADGEFILE; then
-  bash $BADGEFILE
-fi
+wait-versions
@@ -0,0 +1,196 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// yanglib writes the YANG library (RFC 7895) of the given YANG files and the
+// modules they import, which yangson requires as its description of the data
+// model to validate. It prints the colon-separated directories of the modules
+// for yangson's module search path.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+var (
+	pathStr string
+	outFile string
+)
+
+func init() {
+	flag.StringVar(&pathStr, "p", "", "comma separated list of directories to add to search path")
+	flag.StringVar(&outFile, "o", "", "file to write the YANG library to")
+}
+
+// yangLibrary is the ietf-yang-library modules-state JSON consumed by yangson.
+type yangLibrary struct {
+	ModulesState modulesState `json:"ietf-yang-library:modules-state"`
+}
+
+type modulesState struct {
+	ModuleSetID string          `json:"module-set-id"`
+	Modules     []libraryModule `json:"module"`
+}
+
+type libraryModule struct {
+	Name string `json:"name"`
+	// Revision is always empty since yangson would otherwise look for
+	// <name>@<revision>.yang files, whereas OpenConfig files are named
+	// after their module only.
+	Revision        string             `json:"revision"`
+	Namespace       string             `json:"namespace"`
+	ConformanceType string             `json:"conformance-type"`
+	Submodules      []librarySubmodule `json:"submodule,omitempty"`
+}
+
+type librarySubmodule struct {
+	Name     string `json:"name"`
+	Revision string `json:"revision"`
+}
+
+// sourceFile returns the file from which m was parsed, or "" if unknown.
+func sourceFile(m *yang.Module) string {
+	if m.Source == nil {
+		return ""
+	}
+	loc := m.Source.Location()
+	if loc == "unknown" {
+		return ""
+	}
+	return strings.SplitN(loc, ":", 2)[0]
+}
+
+// buildLibrary parses the files with the given search paths and returns the
+// YANG library of the modules of the files, which are implemented, and of
+// the modules they import, as well as the directories of all modules and
+// submodules.
+func buildLibrary(paths, files []string) (*yangLibrary, []string, []error) {
+	ms := yang.NewModules()
+
+	var errs []error
+	for _, path := range paths {
+		expanded, err := yang.PathsWithModules(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ms.AddPath(expanded...)
+	}
+	for _, name := range files {
+		if err := ms.Read(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if errs != nil {
+		return nil, nil, errs
+	}
+	if errs := ms.Process(); errs != nil {
+		return nil, nil, errs
+	}
+
+	implemented := map[string]bool{}
+	for _, file := range files {
+		implemented[filepath.Clean(file)] = true
+	}
+
+	modules := map[string]*libraryModule{}
+	dirs := map[string]bool{}
+	// ms.Modules is keyed by both <name> and <name>@<revision>.
+	for _, m := range ms.Modules {
+		if _, ok := modules[m.Name]; ok {
+			continue
+		}
+		lm := &libraryModule{Name: m.Name, ConformanceType: "import"}
+		if m.Namespace != nil {
+			lm.Namespace = m.Namespace.Name
+		}
+		if src := sourceFile(m); src != "" {
+			dirs[filepath.Dir(src)] = true
+			if implemented[filepath.Clean(src)] {
+				lm.ConformanceType = "implement"
+			}
+		}
+		modules[m.Name] = lm
+	}
+	submodules := map[string]bool{}
+	for _, m := range ms.SubModules {
+		if submodules[m.Name] {
+			continue
+		}
+		submodules[m.Name] = true
+		lm, ok := modules[m.BelongsTo.Name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("belonging module %q of submodule %q not found", m.BelongsTo.Name, m.Name))
+			continue
+		}
+		lm.Submodules = append(lm.Submodules, librarySubmodule{Name: m.Name})
+		if src := sourceFile(m); src != "" {
+			dirs[filepath.Dir(src)] = true
+			if implemented[filepath.Clean(src)] {
+				lm.ConformanceType = "implement"
+			}
+		}
+	}
+	if errs != nil {
+		return nil, nil, errs
+	}
+
+	lib := &yangLibrary{ModulesState: modulesState{ModuleSetID: "models-ci"}}
+	for _, lm := range modules {
+		sort.Slice(lm.Submodules, func(i, j int) bool { return lm.Submodules[i].Name < lm.Submodules[j].Name })
+		lib.ModulesState.Modules = append(lib.ModulesState.Modules, *lm)
+	}
+	sort.Slice(lib.ModulesState.Modules, func(i, j int) bool {
+		return lib.ModulesState.Modules[i].Name < lib.ModulesState.Modules[j].Name
+	})
+	var dirList []string
+	for dir := range dirs {
+		dirList = append(dirList, dir)
+	}
+	sort.Strings(dirList)
+	return lib, dirList, nil
+}
+
+func main() {
+	flag.Parse()
+	if outFile == "" {
+		fmt.Fprintln(os.Stderr, "no output file (-o) specified")
+		os.Exit(1)
+	}
+
+	lib, dirs, errs := buildLibrary(strings.Split(pathStr, ","), flag.Args())
+	if errs != nil {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+	bs, err := json.MarshalIndent(lib, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(outFile, bs, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(strings.Join(dirs, ":"))
+}
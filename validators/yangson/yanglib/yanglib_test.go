@@ -0,0 +1,67 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildLibrary(t *testing.T) {
+	tests := []struct {
+		desc     string
+		inFiles  []string
+		want     *yangLibrary
+		wantDirs []string
+		wantErr  bool
+	}{{
+		desc:    "module with submodule and import",
+		inFiles: []string{"testdata/openconfig-a.yang"},
+		want: &yangLibrary{ModulesState: modulesState{
+			ModuleSetID: "models-ci",
+			Modules: []libraryModule{{
+				Name:            "openconfig-a",
+				Namespace:       "http://openconfig.net/yang/a",
+				ConformanceType: "implement",
+				Submodules:      []librarySubmodule{{Name: "openconfig-a-sub"}},
+			}, {
+				Name:            "openconfig-b",
+				Namespace:       "http://openconfig.net/yang/b",
+				ConformanceType: "import",
+			}},
+		}},
+		wantDirs: []string{"testdata"},
+	}, {
+		desc:    "missing import",
+		inFiles: []string{"testdata/openconfig-invalid.yang"},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotDirs, errs := buildLibrary([]string{"testdata"}, tt.inFiles)
+			if gotErr := errs != nil; gotErr != tt.wantErr {
+				t.Fatalf("got errors %v, wantErr: %v", errs, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("library (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDirs, gotDirs); diff != "" {
+				t.Errorf("dirs (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
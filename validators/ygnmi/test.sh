@@ -19,6 +19,10 @@ RESULTSDIR=$ROOT_DIR/results/ygnmi
 OUTFILE=$RESULTSDIR/out
 FAILFILE=$RESULTSDIR/fail
 
+# Let the post_results reporter service know once this step exits, such that
+# it doesn't wait for results that the step didn't mark as done.
+trap '$GOPATH/bin/post_results -validator=ygnmi -mark-exited' EXIT
+
 if ! stat $RESULTSDIR; then
   exit 0
 fi
@@ -34,7 +38,3 @@ if bash $RESULTSDIR/script.sh >> $OUTFILE 2>> $FAILFILE; then
   find $FAILFILE -size 0 -delete
 fi
 $GOPATH/bin/post_results -validator=ygnmi -modelRoot=$_MODEL_ROOT -repo-slug=$_REPO_SLUG -pr-number=$_PR_NUMBER -commit-sha=$COMMIT_SHA -branch=$BRANCH_NAME
-BADGEFILE=$RESULTSDIR/upload-badge.sh
-if stat $BADGEFILE; then
-  bash $BADGEFILE
-fi
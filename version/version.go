@@ -0,0 +1,60 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package version contains the version of the models-ci binaries, allowing
+// changes in CI behaviour to be correlated with models-ci releases.
+//
+// The version is stamped at build time (see .goreleaser.yaml), e.g.
+//
+//	go build -ldflags "-X github.com/openconfig/models-ci/version.Version=v1.2.3 -X github.com/openconfig/models-ci/version.Commit=abc123"
+//
+// Absent stamping, the module version recorded by "go install" is used.
+package version
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+var (
+	// Version is the release version of models-ci.
+	Version string
+	// Commit is the git commit from which models-ci was built.
+	Commit string
+)
+
+// String returns a description of the version of models-ci, e.g.
+// "v1.2.3 (commit abc123)".
+func String() string {
+	version, commit := Version, Commit
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if version == "" && info.Main.Version != "" {
+			version = info.Main.Version
+		}
+		if commit == "" {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					commit = setting.Value
+				}
+			}
+		}
+	}
+	if version == "" {
+		version = "(devel)"
+	}
+	if commit == "" {
+		return version
+	}
+	return fmt.Sprintf("%s (commit %s)", version, commit)
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package version
+
+import "testing"
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		desc      string
+		inVersion string
+		inCommit  string
+		want      string
+	}{{
+		desc:      "stamped version and commit",
+		inVersion: "v1.2.3",
+		inCommit:  "abc123",
+		want:      "v1.2.3 (commit abc123)",
+	}, {
+		desc:      "stamped version only",
+		inVersion: "v1.2.3",
+		want:      "v1.2.3",
+	}, {
+		desc: "unstamped",
+		want: "(devel)",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			Version, Commit = tt.inVersion, tt.inCommit
+			defer func() { Version, Commit = "", "" }()
+			if got := String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
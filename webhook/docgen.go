@@ -0,0 +1,129 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	glog "github.com/golang/glog"
+	"github.com/google/go-github/github"
+)
+
+var (
+	// docFailureIssue is the GitHub issue on which doc generation failures
+	// are reported to the maintainers.
+	docFailureIssue = flag.String("doc-failure-issue", "", "GitHub issue (<owner>/<repo>#<number>) on which failures to generate docs are reported; if empty, failures are only logged")
+)
+
+// docFailure is a failure of the doc gen script to generate docs, as
+// recorded in its DOC_STATUS_FILE.
+type docFailure struct {
+	// model is the model whose docs weren't generated, or empty if the
+	// failure isn't specific to a model.
+	model  string
+	reason string
+}
+
+// parseDocFailures parses the failures recorded by the doc gen script, one
+// per line as "<model>\t<reason>".
+func parseDocFailures(r io.Reader) ([]docFailure, error) {
+	var failures []docFailure
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		model, reason, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("invalid doc gen failure %q, want <model>\\t<reason>", line)
+		}
+		failures = append(failures, docFailure{model: model, reason: reason})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+// docFailureSummary returns the markdown summary of the failures to generate
+// the docs of branch, where docsErr is the error of the doc gen script (nil
+// if it succeeded).
+func docFailureSummary(branch string, failures []docFailure, docsErr error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**Doc generation failed for `%s`**\n\n", branch)
+	var models []string
+	for _, f := range failures {
+		if f.model == "" {
+			fmt.Fprintf(&b, "* %s\n", f.reason)
+			continue
+		}
+		models = append(models, f.model)
+		fmt.Fprintf(&b, "* `%s`: %s\n", f.model, f.reason)
+	}
+	if len(failures) == 0 && docsErr != nil {
+		fmt.Fprintf(&b, "* doc gen script failed: %v\n", docsErr)
+	}
+	if len(models) != 0 {
+		fmt.Fprintf(&b, "\nThe docs of %d model(s) were excluded from the published index.\n", len(models))
+	}
+	return b.String()
+}
+
+// parseIssue parses an issue of the form <owner>/<repo>#<number>.
+func parseIssue(spec string) (owner, repo string, number int, err error) {
+	repoSpec, numSpec, ok := strings.Cut(spec, "#")
+	if ok {
+		owner, repo, ok = strings.Cut(repoSpec, "/")
+	}
+	if !ok || owner == "" || repo == "" {
+		return "", "", 0, fmt.Errorf("invalid issue %q, want <owner>/<repo>#<number>", spec)
+	}
+	if number, err = strconv.Atoi(numSpec); err != nil || number <= 0 {
+		return "", "", 0, fmt.Errorf("invalid issue number in %q", spec)
+	}
+	return owner, repo, number, nil
+}
+
+// reportDocFailures reports the failures to generate the docs of branch to the
+// maintainers by commenting on the issue (see parseIssue), if any.
+func (g *githubRequestHandler) reportDocFailures(issue, branch string, failures []docFailure, docsErr error) error {
+	if len(failures) == 0 && docsErr == nil {
+		return nil
+	}
+	summary := docFailureSummary(branch, failures, docsErr)
+	glog.Errorf("%s", summary)
+	if issue == "" {
+		return nil
+	}
+	owner, repo, number, err := parseIssue(issue)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, _, err := g.client.Issues.CreateComment(ctx, owner, repo, number, &github.IssueComment{Body: &summary}); err != nil {
+		return fmt.Errorf("could not report doc gen failures on %s: %v", issue, err)
+	}
+	return nil
+}
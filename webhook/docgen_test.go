@@ -0,0 +1,170 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func TestParseDocFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []docFailure
+		wantErr bool
+	}{{
+		name: "no failures",
+	}, {
+		name: "model and script failures",
+		in:   "aft\tno docs generated at aft.html\n\toc-stage.sh exited with status 1\n",
+		want: []docFailure{
+			{model: "aft", reason: "no docs generated at aft.html"},
+			{reason: "oc-stage.sh exited with status 1"},
+		},
+	}, {
+		name:    "missing reason",
+		in:      "aft\n",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDocFailures(strings.NewReader(tt.in))
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(docFailure{})); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDocFailureSummary(t *testing.T) {
+	tests := []struct {
+		name       string
+		inFailures []docFailure
+		inErr      error
+		want       string
+	}{{
+		name: "model failures",
+		inFailures: []docFailure{
+			{model: "aft", reason: "no docs generated at aft.html"},
+			{reason: "oc-stage.sh exited with status 1"},
+		},
+		inErr: errors.New("exit status 1"),
+		want: "**Doc generation failed for `master`**\n\n" +
+			"* `aft`: no docs generated at aft.html\n" +
+			"* oc-stage.sh exited with status 1\n" +
+			"\nThe docs of 1 model(s) were excluded from the published index.\n",
+	}, {
+		name:  "script failure without recorded failures",
+		inErr: errors.New("exit status 1"),
+		want: "**Doc generation failed for `master`**\n\n" +
+			"* doc gen script failed: exit status 1\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, docFailureSummary("master", tt.inFailures, tt.inErr)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseIssue(t *testing.T) {
+	tests := []struct {
+		in         string
+		wantOwner  string
+		wantRepo   string
+		wantNumber int
+		wantErr    bool
+	}{{
+		in:         "openconfig/public#42",
+		wantOwner:  "openconfig",
+		wantRepo:   "public",
+		wantNumber: 42,
+	}, {
+		in:      "openconfig/public",
+		wantErr: true,
+	}, {
+		in:      "public#42",
+		wantErr: true,
+	}, {
+		in:      "openconfig/public#x",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			owner, repo, number, err := parseIssue(tt.in)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if owner != tt.wantOwner || repo != tt.wantRepo || number != tt.wantNumber {
+				t.Errorf("got %s/%s#%d, want %s/%s#%d", owner, repo, number, tt.wantOwner, tt.wantRepo, tt.wantNumber)
+			}
+		})
+	}
+}
+
+func TestReportDocFailures(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	var gotBodies []string
+	mux.HandleFunc("/repos/o/r/issues/42/comments", func(w http.ResponseWriter, r *http.Request) {
+		c := new(github.IssueComment)
+		if err := json.NewDecoder(r.Body).Decode(c); err != nil {
+			t.Fatal(err)
+		}
+		gotBodies = append(gotBodies, c.GetBody())
+		fmt.Fprint(w, `{}`)
+	})
+
+	g := &githubRequestHandler{client: client}
+	if err := g.reportDocFailures("o/r#42", "master", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(gotBodies) != 0 {
+		t.Errorf("got comments %v for successful doc gen, want none", gotBodies)
+	}
+
+	failures := []docFailure{{model: "aft", reason: "no docs generated at aft.html"}}
+	if err := g.reportDocFailures("o/r#42", "master", failures, errors.New("exit status 1")); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{docFailureSummary("master", failures, nil)}, gotBodies); diff != "" {
+		t.Errorf("comments (-want, +got):\n%s", diff)
+	}
+
+	if err := g.reportDocFailures("o/r#43", "master", failures, nil); err == nil {
+		t.Errorf("got no error for failed comment")
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"html/template"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -32,6 +35,8 @@ import (
 
 	glog "github.com/golang/glog"
 	"github.com/google/go-github/github"
+	"github.com/openconfig/models-ci/commonci"
+	"github.com/openconfig/models-ci/version"
 )
 
 var (
@@ -50,6 +55,26 @@ var (
 	// it is in /home/ghci/models-ci/bin
 	docGenLoc = flag.String("docgendir", "/home/ghci/models-ci/bin", "location of the doc gen script")
 
+	// docRoot is the directory served as the docs site, within which the
+	// docs of branches with a site path target are published.
+	docRoot = flag.String("docroot", "/opt/nginx/nginx/html/branches", "directory served as the docs site")
+
+	// docsURL is the absolute URL at which docRoot is served.
+	docsURL = flag.String("docs-url", "", "absolute URL at which -docroot is served (e.g. https://openconfig.net/branches), from which the links to the docs of site path targets are built; required if there are any")
+
+	// docTargetsSpec maps each branch to the location where its docs are
+	// published. If empty, the docs of every branch are published to the
+	// default output of the doc gen script.
+	docTargetsSpec = flag.String("doc-targets", "", "comma-separated list of <branch>=<target>, where target is either a GCS prefix (gs://bucket/prefix) or a site path relative to -docroot, e.g. master=master,release-1.x=gs://oc-docs/release-1.x")
+
+	// signedURLDuration, if non-zero, links to the docs of GCS targets with
+	// signed URLs, since the docs aren't public.
+	signedURLDuration = flag.Duration("signed-url-duration", 0, "(optional) for docs that can't be public, link to the index page of each GCS doc target with a URL signed for this duration whenever the docs index is served (at most 168h, or 12h without -signing-key-file)")
+
+	// signingKeyFile is the service account private key file signing the
+	// URLs of the docs of GCS targets.
+	signingKeyFile = flag.String("signing-key-file", "", "(optional) service account private key file with which to sign the URLs of -signed-url-duration, instead of the environment's service account")
+
 	// TODO(aashaikh): add a cmd line flag to supply parameters to the docgen script
 )
 
@@ -68,6 +93,170 @@ type githubRequestHandler struct {
 	// runs concurrently.  This serves primarily to protect against two concurrent
 	// requests for the same branch.
 	docsmu sync.Mutex
+	// docTargets are the per-branch doc publication targets, in the order
+	// in which they're displayed by the docs index.
+	docTargets []*docTarget
+	// signedURLDuration and signingKeyFile, if the former is non-zero, sign
+	// the URLs of the docs of GCS targets, see commonci.GCSBucket.
+	signedURLDuration time.Duration
+	signingKeyFile    string
+	// docsURL is the absolute URL at which the doc root is served.
+	docsURL string
+}
+
+// docTarget is the location where the docs of a branch are published.
+type docTarget struct {
+	branch string
+	// location is either a GCS prefix (gs://bucket/prefix) or a site path
+	// relative to the doc root.
+	location string
+}
+
+// isGCS returns whether the target is a GCS prefix.
+func (t *docTarget) isGCS() bool {
+	return strings.HasPrefix(t.location, gcsScheme)
+}
+
+// output returns the output location passed to the doc gen script.
+func (t *docTarget) output(docRoot string) string {
+	if t.isGCS() {
+		return t.location
+	}
+	return filepath.Join(docRoot, t.location)
+}
+
+// url returns the absolute URL at which the published docs are served, given
+// the absolute URL at which the doc root is served.
+func (t *docTarget) url(docsURL string) string {
+	if t.isGCS() {
+		return "https://storage.googleapis.com/" + escapePath(strings.TrimPrefix(t.location, gcsScheme)) + "/index.html"
+	}
+	return strings.TrimSuffix(docsURL, "/") + "/" + escapePath(t.location) + "/"
+}
+
+// escapePath escapes each segment of the slash-separated path for use in a
+// URL, e.g. for release tags with a "+".
+func escapePath(p string) string {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// validateDocsURL returns an error if the docs of any of the targets are
+// published to a site path while docsURL isn't an absolute URL.
+func validateDocsURL(docsURL string, targets []*docTarget) error {
+	for _, t := range targets {
+		if t.isGCS() {
+			continue
+		}
+		if u, err := url.Parse(docsURL); err != nil || !u.IsAbs() || u.Host == "" {
+			return fmt.Errorf("%q isn't an absolute URL, which is required to link to the docs of site path target %q", docsURL, t.location)
+		}
+	}
+	return nil
+}
+
+// docURL returns the URL at which the published docs of the target are
+// served, which is signed if the docs of GCS targets aren't public.
+func (g *githubRequestHandler) docURL(ctx context.Context, t *docTarget) (string, error) {
+	if !t.isGCS() || g.signedURLDuration == 0 {
+		return t.url(g.docsURL), nil
+	}
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(t.location, gcsScheme), "/")
+	b := &commonci.GCSBucket{Bucket: bucket, SignedURLDuration: g.signedURLDuration, SigningKeyFile: g.signingKeyFile}
+	return b.ObjectURL(ctx, strings.TrimPrefix(prefix+"/index.html", "/"))
+}
+
+// gcsScheme is the scheme of GCS doc targets.
+const gcsScheme = "gs://"
+
+// parseDocTargets parses a comma-separated list of <branch>=<target> doc
+// publication targets.
+func parseDocTargets(spec string) ([]*docTarget, error) {
+	var targets []*docTarget
+	seen := map[string]bool{}
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		branch, location, ok := strings.Cut(entry, "=")
+		branch, location = strings.TrimSpace(branch), strings.TrimSpace(location)
+		if strings.HasPrefix(location, gcsScheme) {
+			location = gcsScheme + strings.Trim(strings.TrimPrefix(location, gcsScheme), "/")
+		} else {
+			location = strings.Trim(location, "/")
+		}
+		switch {
+		case !ok || branch == "" || location == "" || location == gcsScheme:
+			return nil, fmt.Errorf("invalid doc target %q, expected <branch>=<target>", entry)
+		case seen[branch]:
+			return nil, fmt.Errorf("duplicate doc target for branch %q", branch)
+		case !strings.HasPrefix(location, gcsScheme) && strings.Contains("/"+location+"/", "/../"):
+			return nil, fmt.Errorf("doc target %q for branch %q is outside of the doc root", location, branch)
+		}
+		seen[branch] = true
+		targets = append(targets, &docTarget{branch: branch, location: location})
+	}
+	return targets, nil
+}
+
+// docTarget returns the doc publication target of the branch, or nil if it has
+// none.
+func (g *githubRequestHandler) docTarget(branch string) *docTarget {
+	for _, t := range g.docTargets {
+		if t.branch == branch {
+			return t
+		}
+	}
+	return nil
+}
+
+// docsIndexTemplate is the docs index page, with a selector of the branches
+// whose docs are published.
+var docsIndexTemplate = template.Must(template.New("docs-index").Parse(`<!DOCTYPE html>
+<html>
+<head><title>OpenConfig model documentation</title></head>
+<body>
+<h1>OpenConfig model documentation</h1>
+<label for="branch">Branch:</label>
+<select id="branch" onchange="if (this.value) { window.location = this.value; }">
+  <option value="">Select a branch</option>
+{{- range . }}
+  <option value="{{ .URL }}">{{ .Branch }}</option>
+{{- end }}
+</select>
+<ul>
+{{- range . }}
+  <li><a href="{{ .URL }}">{{ .Branch }}</a></li>
+{{- end }}
+</ul>
+</body>
+</html>
+`))
+
+// docsIndexHandler serves the docs index, which links to the published docs of
+// each branch.
+func (g *githubRequestHandler) docsIndexHandler(w http.ResponseWriter, r *http.Request) {
+	type branchLink struct {
+		Branch string
+		URL    string
+	}
+	var links []branchLink
+	for _, t := range g.docTargets {
+		url, err := g.docURL(r.Context(), t)
+		if err != nil {
+			glog.Errorf("Could not get the URL of the docs of branch %s: %v", t.branch, err)
+			http.Error(w, "could not list the published docs", http.StatusInternalServerError)
+			return
+		}
+		links = append(links, branchLink{Branch: t.branch, URL: url})
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := docsIndexTemplate.Execute(w, links); err != nil {
+		glog.Errorf("Could not write docs index: %v", err)
+	}
 }
 
 // githubPushEvent decodes the interesting fields of the input JSON for a push
@@ -103,8 +292,13 @@ func (g *githubRequestHandler) pushHandler(w http.ResponseWriter, r *http.Reques
 	glog.Info("Received GitHub request:  ", r)
 
 	reqID := r.Header.Get("X-GitHub-Delivery")
-	if event := r.Header.Get("X-GitHub-Event"); event != "push" {
-		glog.Errorf("Not processing event %s as it is not a push, is: %s", reqID, event)
+	switch event := r.Header.Get("X-GitHub-Event"); event {
+	case "push":
+	case "release":
+		g.releaseHandler(reqID, r.Body)
+		return
+	default:
+		glog.Errorf("Not processing event %s as it is not a push or release, is: %s", reqID, event)
 		return
 	}
 
@@ -138,8 +332,12 @@ func (g *githubRequestHandler) pushHandler(w http.ResponseWriter, r *http.Reques
 	branch := refp[2]
 
 	//TODO(aashaikh): consider moving docs generation to another handler / path
-	glog.Infof("Generating updated docs for branch %s", branch)
-	go g.runGenDocs(branch)
+	if len(g.docTargets) != 0 && g.docTarget(branch) == nil {
+		glog.Infof("Not generating docs for branch %s since it has no doc target", branch)
+	} else {
+		glog.Infof("Generating updated docs for branch %s", branch)
+		go g.runGenDocs(branch)
+	}
 
 	run := false
 	for _, s := range pushCIBranches {
@@ -158,13 +356,13 @@ func (g *githubRequestHandler) pushHandler(w http.ResponseWriter, r *http.Reques
 // scripts within a mutex lock.
 func (g *githubRequestHandler) runGenDocs(branch string) {
 	g.docsmu.Lock()
-	g.generateDocs(branch)
+	g.generateDocs(branch, g.docTarget(branch))
 	defer g.docsmu.Unlock()
 }
 
 // generateDocs runs the documentation generation plugin for the
-// branch specified in the push request.
-func (g *githubRequestHandler) generateDocs(branch string) {
+// branch (or tag) specified, publishing the docs to target if non-nil.
+func (g *githubRequestHandler) generateDocs(branch string, target *docTarget) {
 
 	scriptfile := *docGenLoc + "/gen_docs_branch.sh"
 	if _, err := os.Stat(scriptfile); err != nil {
@@ -176,6 +374,20 @@ func (g *githubRequestHandler) generateDocs(branch string) {
 		fmt.Sprintf("GITHUB_ACCESS_TOKEN=%s", g.accessToken),
 		fmt.Sprintf("PUSH_BRANCH=%s", branch),
 	}
+	if target != nil {
+		envs = append(envs, fmt.Sprintf("DOC_TARGET=%s", target.output(*docRoot)))
+	}
+
+	// The script records the models whose docs it failed to generate
+	// within the status file.
+	statusFile, err := os.CreateTemp("", "docgen-status-*")
+	if err != nil {
+		glog.Errorf("Could not create doc gen status file: %v", err)
+		return
+	}
+	defer os.Remove(statusFile.Name())
+	defer statusFile.Close()
+	envs = append(envs, fmt.Sprintf("DOC_STATUS_FILE=%s", statusFile.Name()))
 	docsCmd.Env = envs
 
 	out, docsErr := docsCmd.CombinedOutput()
@@ -183,9 +395,14 @@ func (g *githubRequestHandler) generateDocs(branch string) {
 
 	if docsErr != nil {
 		glog.Errorf("Doc gen failed: %s", docsErr)
-		return
 	}
-
+	failures, err := parseDocFailures(statusFile)
+	if err != nil {
+		glog.Errorf("Could not parse doc gen status file: %v", err)
+	}
+	if err := g.reportDocFailures(*docFailureIssue, branch, failures, docsErr); err != nil {
+		glog.Errorf("Could not report doc gen failures: %v", err)
+	}
 }
 
 // newGitHubRequestHandler sets up a new githubRequestHandler struct which
@@ -223,6 +440,7 @@ func newGitHubRequestHandler() (*githubRequestHandler, error) {
 
 func main() {
 	flag.Parse()
+	glog.Infof("webhook version %s", version.String())
 
 	h, err := newGitHubRequestHandler()
 	if err != nil {
@@ -234,9 +452,29 @@ func main() {
 		glog.Warning("Will not validate GitHub messages...")
 	}
 
-	// We only handle a single URL currently, which is a path for the
-	// continuous integration tests.
+	if h.docTargets, err = parseDocTargets(*docTargetsSpec); err != nil {
+		glog.Errorf("Could not parse doc targets: %v", err)
+		return
+	}
+	h.docsURL = *docsURL
+	targets := append([]*docTarget{}, h.docTargets...)
+	if *releaseDocTarget != "" {
+		targets = append(targets, &docTarget{location: *releaseDocTarget})
+	}
+	if err := validateDocsURL(h.docsURL, targets); err != nil {
+		glog.Errorf("Invalid -docs-url: %v", err)
+		return
+	}
+	h.signedURLDuration, h.signingKeyFile = *signedURLDuration, *signingKeyFile
+	if err := (&commonci.GCSBucket{SignedURLDuration: h.signedURLDuration, SigningKeyFile: h.signingKeyFile}).ValidateSignedURLs(); err != nil {
+		glog.Errorf("Invalid -signed-url-duration: %v", err)
+		return
+	}
 
+	// The push path is used for the continuous integration tests and
+	// release publication, and the docs path serves the index of the
+	// published docs.
 	http.HandleFunc("/ci/repo_push", h.pushHandler)
+	http.HandleFunc("/docs/", h.docsIndexHandler)
 	http.ListenAndServe(*listenSpec, nil)
 }
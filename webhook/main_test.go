@@ -15,8 +15,12 @@
 package main
 
 import (
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestNewGitHubRequestHandler(t *testing.T) {
@@ -52,3 +56,142 @@ func TestNewGitHubRequestHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDocTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []*docTarget
+		wantErr bool
+	}{{
+		name: "empty",
+		in:   "",
+	}, {
+		name: "site paths and GCS prefixes",
+		in:   "master=master, release-1.x=/releases/1.x/,release-2.x=gs://oc-docs/release-2.x/",
+		want: []*docTarget{
+			{branch: "master", location: "master"},
+			{branch: "release-1.x", location: "releases/1.x"},
+			{branch: "release-2.x", location: "gs://oc-docs/release-2.x"},
+		},
+	}, {
+		name:    "missing target",
+		in:      "master",
+		wantErr: true,
+	}, {
+		name:    "empty GCS prefix",
+		in:      "master=gs://",
+		wantErr: true,
+	}, {
+		name:    "duplicate branch",
+		in:      "master=master,master=gs://oc-docs/master",
+		wantErr: true,
+	}, {
+		name:    "site path outside of the doc root",
+		in:      "master=../master",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDocTargets(tt.in)
+			if gotErr := err != nil; gotErr != tt.wantErr {
+				t.Fatalf("got error %v, wantErr: %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(docTarget{})); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDocTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		in         *docTarget
+		wantOutput string
+		wantURL    string
+	}{{
+		name:       "site path",
+		in:         &docTarget{branch: "release-1.x", location: "releases/1.x"},
+		wantOutput: "/opt/docs/releases/1.x",
+		wantURL:    "https://openconfig.net/branches/releases/1.x/",
+	}, {
+		name:       "escaped GCS prefix",
+		in:         &docTarget{branch: "v1.0.0+beta", location: "gs://oc-docs/releases/v1.0.0+beta"},
+		wantOutput: "gs://oc-docs/releases/v1.0.0+beta",
+		wantURL:    "https://storage.googleapis.com/oc-docs/releases/v1.0.0+beta/index.html",
+	}, {
+		name:       "GCS prefix",
+		in:         &docTarget{branch: "master", location: "gs://oc-docs/master"},
+		wantOutput: "gs://oc-docs/master",
+		wantURL:    "https://storage.googleapis.com/oc-docs/master/index.html",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.output("/opt/docs"); got != tt.wantOutput {
+				t.Errorf("output: got %q, want %q", got, tt.wantOutput)
+			}
+			if got := tt.in.url("https://openconfig.net/branches/"); got != tt.wantURL {
+				t.Errorf("url: got %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestValidateDocsURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		inDocsURL string
+		inTargets []*docTarget
+		wantErr   bool
+	}{{
+		name:      "only GCS targets",
+		inTargets: []*docTarget{{branch: "master", location: "gs://oc-docs/master"}},
+	}, {
+		name:      "site path target",
+		inDocsURL: "https://openconfig.net/branches",
+		inTargets: []*docTarget{{branch: "master", location: "master"}},
+	}, {
+		name:      "site path target without docs URL",
+		inTargets: []*docTarget{{branch: "master", location: "master"}},
+		wantErr:   true,
+	}, {
+		name:      "site path target with relative docs URL",
+		inDocsURL: "/branches",
+		inTargets: []*docTarget{{branch: "master", location: "master"}},
+		wantErr:   true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateDocsURL(tt.inDocsURL, tt.inTargets); (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDocsIndexHandler(t *testing.T) {
+	g := &githubRequestHandler{
+		docsURL: "https://openconfig.net/branches",
+		docTargets: []*docTarget{
+			{branch: "master", location: "master"},
+			{branch: "release-<1.x>", location: "gs://oc-docs/release-1.x"},
+		},
+	}
+	w := httptest.NewRecorder()
+	g.docsIndexHandler(w, httptest.NewRequest("GET", "/docs/", nil))
+
+	got := w.Body.String()
+	for _, want := range []string{
+		`<option value="https://openconfig.net/branches/master/">master</option>`,
+		`<option value="https://storage.googleapis.com/oc-docs/release-1.x/index.html">release-&lt;1.x&gt;</option>`,
+		`<li><a href="https://openconfig.net/branches/master/">master</a></li>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("docs index does not contain %q, got:\n%s", want, got)
+		}
+	}
+}
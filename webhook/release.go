@@ -0,0 +1,258 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	glog "github.com/golang/glog"
+	"github.com/google/go-github/github"
+	"github.com/openconfig/models-ci/openconfig-ci/ocdiff"
+	"github.com/openconfig/models-ci/yangutil"
+)
+
+var (
+	// releaseDocTarget is the location within which the docs of each
+	// release are published, in the same format as the targets of
+	// -doc-targets.
+	releaseDocTarget = flag.String("release-doc-target", "", "location within which the docs of each published release are published under its tag, either a GCS prefix (gs://bucket/prefix) or a site path relative to -docroot; if empty, release docs aren't published")
+
+	// releaseModelRoot and releaseSearchPath are the directories of the
+	// models repo diffed between releases.
+	releaseModelRoot  = flag.String("release-model-root", "release", "directory of the models repo containing the models diffed between releases")
+	releaseSearchPath = flag.String("release-search-path", "third_party", "directory of the models repo used to resolve imports when diffing releases")
+
+	// releaseTagRegex matches the release tags that are published. A tag is
+	// used as a path of the release's doc target and as a git ref, so e.g.
+	// tags with path separators or a leading "-" or "." aren't published.
+	releaseTagRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+)
+
+const (
+	// releaseNotesAssetName is the name of the release notes attached to
+	// each published release.
+	releaseNotesAssetName = "release-notes.md"
+	// releaseCallTimeout is the timeout of each GitHub API call and git
+	// clone made while publishing a release.
+	releaseCallTimeout = 180 * time.Second
+)
+
+// githubReleaseEvent decodes the interesting fields of the input JSON for a
+// release event from GitHub.
+type githubReleaseEvent struct {
+	Action     string                    `json:"action"`     // Action is the release activity, e.g. "published".
+	Release    *github.RepositoryRelease `json:"release"`    // Release is the release that the event was for.
+	Repository *githubPushRepository     `json:"repository"` // Repository is the repo of the release.
+}
+
+// decodeGitHubReleaseJSON decodes the GitHub JSON document that GitHub sends
+// when there is release activity in a repo.
+func decodeGitHubReleaseJSON(r io.Reader) (*githubReleaseEvent, error) {
+	var ghIn *githubReleaseEvent
+	if err := json.NewDecoder(r).Decode(&ghIn); err != nil {
+		return nil, fmt.Errorf("could not decode Release JSON input: %v", err)
+	}
+	return ghIn, nil
+}
+
+// releaseHandler handles a release event, publishing the docs and release
+// notes of newly-published releases.
+func (g *githubRequestHandler) releaseHandler(reqID string, body io.Reader) {
+	relReq, err := decodeGitHubReleaseJSON(body)
+	if err != nil {
+		glog.Errorf("Could not decode JSON for release event %s, err: %v", reqID, err)
+		return
+	}
+	if relReq.Action != "published" {
+		glog.Infof("Not processing release event %s with action %s", reqID, relReq.Action)
+		return
+	}
+	if relReq.Release == nil || relReq.Release.GetTagName() == "" || relReq.Repository == nil {
+		glog.Errorf("Could not resolve the release for event %s", reqID)
+		return
+	}
+	if tag := relReq.Release.GetTagName(); !releaseTagRegex.MatchString(tag) {
+		glog.Errorf("Not publishing release %q of event %s, whose tag doesn't match %s", tag, reqID, releaseTagRegex)
+		return
+	}
+	repop := strings.Split(relReq.Repository.FullName, "/")
+	if len(repop) != 2 {
+		glog.Errorf("Could not determine owner and repo name for event %s, got: %v", reqID, repop)
+		return
+	}
+
+	glog.Infof("Publishing docs and release notes for release %s", relReq.Release.GetTagName())
+	go g.publishRelease(repop[0], repop[1], relReq.Release)
+}
+
+// releaseDocTargetFor returns the doc publication target of the release tag,
+// or nil if release docs aren't published.
+func releaseDocTargetFor(tag string) *docTarget {
+	if *releaseDocTarget == "" {
+		return nil
+	}
+	return &docTarget{branch: tag, location: strings.TrimSuffix(*releaseDocTarget, "/") + "/" + tag}
+}
+
+// previousReleaseTag returns the tag of the latest non-draft release published
+// before the release with the given tag, or "" if there is none.
+func previousReleaseTag(releases []*github.RepositoryRelease, tag string) string {
+	var published time.Time
+	for _, r := range releases {
+		if r.GetTagName() == tag {
+			published = r.GetPublishedAt().Time
+		}
+	}
+
+	var prev *github.RepositoryRelease
+	for _, r := range releases {
+		switch {
+		case r.GetTagName() == tag, r.GetDraft(), r.PublishedAt == nil:
+			continue
+		case !published.IsZero() && !r.GetPublishedAt().Time.Before(published):
+			continue
+		case prev == nil || r.GetPublishedAt().Time.After(prev.GetPublishedAt().Time):
+			prev = r
+		}
+	}
+	return prev.GetTagName()
+}
+
+// releaseNotes creates the release notes markdown of the release given the
+// GitHub comment-style ocdiff report against the previous release.
+func releaseNotes(tag, prevTag, report, docsURL string) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# Release notes for %s\n\n", tag))
+	if docsURL != "" {
+		b.WriteString(fmt.Sprintf("Documentation: %s\n\n", docsURL))
+	}
+	switch {
+	case prevTag == "":
+		b.WriteString("No previous release to compare against.\n")
+	case report == "":
+		b.WriteString(fmt.Sprintf("No schema changes since %s.\n", prevTag))
+	default:
+		b.WriteString(fmt.Sprintf("## Schema changes since %s\n\n%s", prevTag, report))
+	}
+	return b.String()
+}
+
+// cloneTag clones the given tag of the GitHub repo into dir, failing after
+// releaseCallTimeout.
+func cloneTag(owner, repo, tag, dir string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), releaseCallTimeout)
+	defer cancel()
+	url := fmt.Sprintf("https://github.com/%s/%s.git", owner, repo)
+	if out, err := exec.CommandContext(ctx, "git", "clone", "--quiet", "--depth=1", "--branch", tag, url, dir).CombinedOutput(); err != nil {
+		return fmt.Errorf("could not clone %s at %s: %v: %s", url, tag, err, out)
+	}
+	return nil
+}
+
+// releaseDiff returns the GitHub comment-style ocdiff report between the
+// models of two tags of the GitHub repo.
+func releaseDiff(owner, repo, prevTag, tag string) (string, error) {
+	dir, err := os.MkdirTemp("", "release-diff-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	var paths, files [2][]string
+	for i, t := range []string{prevTag, tag} {
+		tagDir := filepath.Join(dir, fmt.Sprint(i))
+		if err := cloneTag(owner, repo, t, tagDir); err != nil {
+			return "", err
+		}
+		paths[i] = []string{filepath.Join(tagDir, *releaseSearchPath)}
+		if files[i], err = yangutil.GetAllYANGFiles(filepath.Join(tagDir, *releaseModelRoot)); err != nil {
+			return "", fmt.Errorf("error while finding YANG files of %s: %v", t, err)
+		}
+	}
+	report, err := ocdiff.NewDiffReport(paths[0], paths[1], files[0], files[1])
+	if err != nil {
+		return "", err
+	}
+	return report.Report(ocdiff.WithGithubCommentStyle()), nil
+}
+
+// publishRelease publishes the docs of the release, and attaches release notes
+// containing the schema changes since the previous release to it.
+func (g *githubRequestHandler) publishRelease(owner, repo string, release *github.RepositoryRelease) {
+	tag := release.GetTagName()
+	docs := releaseDocTargetFor(tag)
+	if docs != nil {
+		g.docsmu.Lock()
+		g.generateDocs(tag, docs)
+		g.docsmu.Unlock()
+	}
+
+	// Each call has its own timeout, such that the time taken by the diff
+	// doesn't count against the upload of the release notes.
+	ctx, cancel := context.WithTimeout(context.Background(), releaseCallTimeout)
+	releases, _, err := g.client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 100})
+	cancel()
+	if err != nil {
+		glog.Errorf("Could not list releases of %s/%s: %v", owner, repo, err)
+		return
+	}
+
+	var report string
+	prevTag := previousReleaseTag(releases, tag)
+	if prevTag != "" {
+		if report, err = releaseDiff(owner, repo, prevTag, tag); err != nil {
+			glog.Errorf("Could not diff release %s against %s: %v", tag, prevTag, err)
+			return
+		}
+	}
+
+	notesFile, err := os.CreateTemp("", "release-notes-*.md")
+	if err != nil {
+		glog.Errorf("Could not create release notes file: %v", err)
+		return
+	}
+	defer os.Remove(notesFile.Name())
+	defer notesFile.Close()
+	var docsURL string
+	// A signed URL would expire, while the release notes don't.
+	if docs != nil && !(docs.isGCS() && g.signedURLDuration != 0) {
+		docsURL = docs.url(g.docsURL)
+	}
+	if _, err := notesFile.WriteString(releaseNotes(tag, prevTag, report, docsURL)); err != nil {
+		glog.Errorf("Could not write release notes file: %v", err)
+		return
+	}
+	if _, err := notesFile.Seek(0, io.SeekStart); err != nil {
+		glog.Errorf("Could not rewind release notes file: %v", err)
+		return
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), releaseCallTimeout)
+	defer cancel()
+	if _, _, err := g.client.Repositories.UploadReleaseAsset(ctx, owner, repo, release.GetID(), &github.UploadOptions{Name: releaseNotesAssetName}, notesFile); err != nil {
+		glog.Errorf("Could not attach release notes to release %s: %v", tag, err)
+		return
+	}
+	glog.Infof("Attached release notes to release %s", tag)
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func TestDecodeGitHubReleaseJSON(t *testing.T) {
+	got, err := decodeGitHubReleaseJSON(strings.NewReader(`{
+  "action": "published",
+  "release": {"id": 42, "tag_name": "v5.1.0", "draft": false},
+  "repository": {"name": "public", "full_name": "openconfig/public"}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Action != "published" || got.Release.GetID() != 42 || got.Release.GetTagName() != "v5.1.0" || got.Repository.FullName != "openconfig/public" {
+		t.Errorf("got %+v, release %+v, repository %+v", got, got.Release, got.Repository)
+	}
+
+	if _, err := decodeGitHubReleaseJSON(strings.NewReader("{")); err == nil {
+		t.Errorf("got no error for invalid JSON")
+	}
+}
+
+func TestPreviousReleaseTag(t *testing.T) {
+	release := func(tag string, day int, draft bool) *github.RepositoryRelease {
+		r := &github.RepositoryRelease{TagName: github.String(tag), Draft: github.Bool(draft)}
+		if day != 0 {
+			r.PublishedAt = &github.Timestamp{Time: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)}
+		}
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		inReleases []*github.RepositoryRelease
+		inTag      string
+		want       string
+	}{{
+		name: "latest earlier release",
+		inReleases: []*github.RepositoryRelease{
+			release("v5.2.0", 20, false),
+			release("v5.1.0", 10, false),
+			release("v5.0.0", 1, false),
+		},
+		inTag: "v5.2.0",
+		want:  "v5.1.0",
+	}, {
+		name: "drafts and later releases skipped",
+		inReleases: []*github.RepositoryRelease{
+			release("v6.0.0", 0, true),
+			release("v5.1.1", 15, false),
+			release("v5.2.0", 12, false),
+			release("v5.1.0", 10, false),
+		},
+		inTag: "v5.2.0",
+		want:  "v5.1.0",
+	}, {
+		name: "first release",
+		inReleases: []*github.RepositoryRelease{
+			release("v1.0.0", 1, false),
+		},
+		inTag: "v1.0.0",
+		want:  "",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := previousReleaseTag(tt.inReleases, tt.inTag); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleaseNotes(t *testing.T) {
+	tests := []struct {
+		name      string
+		inPrevTag string
+		inReport  string
+		inDocsURL string
+		want      string
+	}{{
+		name:      "changes",
+		inPrevTag: "v5.0.0",
+		inReport:  "leaf added: `/a/b`\n* (\"a\": openconfig-version 1.0.0 -> 1.1.0)\n\n",
+		inDocsURL: "https://storage.googleapis.com/oc-docs/releases/v5.1.0/index.html",
+		want: "# Release notes for v5.1.0\n\n" +
+			"Documentation: https://storage.googleapis.com/oc-docs/releases/v5.1.0/index.html\n\n" +
+			"## Schema changes since v5.0.0\n\n" +
+			"leaf added: `/a/b`\n* (\"a\": openconfig-version 1.0.0 -> 1.1.0)\n\n",
+	}, {
+		name:      "no changes",
+		inPrevTag: "v5.0.0",
+		want:      "# Release notes for v5.1.0\n\nNo schema changes since v5.0.0.\n",
+	}, {
+		name: "no previous release",
+		want: "# Release notes for v5.1.0\n\nNo previous release to compare against.\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, releaseNotes("v5.1.0", tt.inPrevTag, tt.inReport, tt.inDocsURL)); diff != "" {
+				t.Errorf("(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReleaseDocTargetFor(t *testing.T) {
+	defer func(target string) { *releaseDocTarget = target }(*releaseDocTarget)
+
+	*releaseDocTarget = ""
+	if got := releaseDocTargetFor("v5.1.0"); got != nil {
+		t.Errorf("got %+v, want nil", got)
+	}
+
+	*releaseDocTarget = "releases/"
+	if diff := cmp.Diff(&docTarget{branch: "v5.1.0", location: "releases/v5.1.0"}, releaseDocTargetFor("v5.1.0"), cmp.AllowUnexported(docTarget{})); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
+
+func TestReleaseTagRegex(t *testing.T) {
+	for tag, want := range map[string]bool{
+		"v5.1.0":          true,
+		"v1.0.0+beta_1":   true,
+		"":                false,
+		"../v5.1.0":       false,
+		"releases/v5.1.0": false,
+		"-v5.1.0":         false,
+		".v5.1.0":         false,
+		"v5.1.0 notes":    false,
+	} {
+		if got := releaseTagRegex.MatchString(tag); got != want {
+			t.Errorf("%q: got match %v, want %v", tag, got, want)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// ModuleInfo is the metadata of a parsed YANG module or submodule. Unlike a
+// yang.Entry tree, it can be serialized, and so cached across the validators
+// of a build.
+type ModuleInfo struct {
+	Name string `json:"name"`
+	// BelongingModule is the module name if this is a module, and the
+	// belonging module name if this is a submodule.
+	BelongingModule string `json:"belonging-module"`
+	// OpenconfigVersion is the value of the openconfig-version extension,
+	// if present.
+	OpenconfigVersion string `json:"openconfig-version,omitempty"`
+	SourceFile        string `json:"source-file,omitempty"`
+	Namespace         string `json:"namespace,omitempty"`
+	Prefix            string `json:"prefix,omitempty"`
+	// LatestRevisionDate is the date of the latest revision statement, if
+	// any.
+	LatestRevisionDate string `json:"latest-revision-date,omitempty"`
+	// Errors are problems encountered while extracting the metadata.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// belongingModule returns the module name if m is a module and the belonging
+// module name if m is a submodule.
+func belongingModule(m *yang.Module) string {
+	if m.Kind() == "submodule" {
+		return m.BelongsTo.Name
+	}
+	return m.Name
+}
+
+// NewModuleInfos extracts the metadata of each module or submodule entry. An
+// error is returned if any entry is not a module or submodule.
+func NewModuleInfos(entries []*yang.Entry) ([]*ModuleInfo, error) {
+	var infos []*ModuleInfo
+	for _, e := range entries {
+		m, ok := e.Node.(*yang.Module)
+		if !ok {
+			return nil, fmt.Errorf("cannot convert entry %q to *yang.Module", e.Name)
+		}
+
+		info := &ModuleInfo{
+			Name:            m.Name,
+			BelongingModule: belongingModule(m),
+		}
+		for _, e := range m.Extensions {
+			keywordParts := strings.Split(e.Keyword, ":")
+			if len(keywordParts) != 2 {
+				// Unrecognized extension declaration
+				continue
+			}
+			pfx, ext := strings.TrimSpace(keywordParts[0]), strings.TrimSpace(keywordParts[1])
+			if ext == "openconfig-version" {
+				extMod := yang.FindModuleByPrefix(m, pfx)
+				if extMod == nil {
+					info.Errors = append(info.Errors, fmt.Sprintf("unable to find module using prefix %q from referencing module %q", pfx, m.Name))
+				} else if belongingModule(extMod) == "openconfig-extensions" {
+					info.OpenconfigVersion = e.Argument
+				}
+			}
+		}
+
+		if m.Source != nil {
+			if loc := m.Source.Location(); loc != "unknown" {
+				info.SourceFile = filepath.Base(strings.SplitN(loc, ":", 2)[0])
+			}
+		}
+		if m.Namespace != nil {
+			info.Namespace = m.Namespace.Name
+		}
+		info.Prefix = m.GetPrefix()
+		for _, r := range m.Revision {
+			// Revision dates are YYYY-MM-DD, so they sort lexically.
+			if r.Name > info.LatestRevisionDate {
+				info.LatestRevisionDate = r.Name
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// parseCacheFormatVersion is the version of the ModuleInfo format of parse
+// cache entries, which must be incremented whenever metadata is added.
+const parseCacheFormatVersion = 2
+
+// ParseCache is an on-disk cache of module metadata keyed by the contents of
+// the parsed files and their search paths, and by the version of the tool
+// parsing them. Since keys change whenever any input changes, a cache
+// directory may be safely shared by all validators of a build, and by
+// different builds if persisted, even if they check out the models elsewhere.
+type ParseCache struct {
+	dir string
+}
+
+// NewParseCache returns a cache storing its entries within dir, creating dir
+// if it doesn't exist.
+func NewParseCache(dir string) (*ParseCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("cannot create parse cache directory %q: %v", dir, err)
+	}
+	return &ParseCache{dir: dir}, nil
+}
+
+// ParseKey computes the cache key of parsing files with the given search
+// paths using the given version of the tool (e.g. version.String()). The key
+// covers the contents of every YANG file within the search paths, since any of
+// them may be imported. Only the base names of the files are covered, since
+// they're part of the metadata (see ModuleInfo.SourceFile), but not their
+// directories, such that the key is the same wherever the models are checked
+// out.
+func ParseKey(toolVersion string, paths, files []string) (string, error) {
+	h := sha256.New()
+	// Entries of older formats are missing newer metadata.
+	fmt.Fprintf(h, "format:%d\n", parseCacheFormatVersion)
+	fmt.Fprintf(h, "tool:%s\n", toolVersion)
+	fileHash := func(file string) (string, error) {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		fh := sha256.New()
+		if _, err := io.Copy(fh, f); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s:%x", filepath.Base(file), fh.Sum(nil)), nil
+	}
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		pathFiles, err := GetAllYANGFiles(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot find YANG files within search path %q: %v", path, err)
+		}
+		// The files are sorted by their hashes rather than their paths,
+		// which include the search path.
+		var hashes []string
+		for _, file := range pathFiles {
+			fh, err := fileHash(file)
+			if err != nil {
+				return "", err
+			}
+			hashes = append(hashes, fh)
+		}
+		sort.Strings(hashes)
+		fmt.Fprintf(h, "path:%s\n", strings.Join(hashes, ","))
+	}
+	for _, file := range files {
+		fh, err := fileHash(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "file:%s\n", fh)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Get returns the cached module metadata for key, and false if there is no
+// such entry.
+func (c *ParseCache) Get(key string) ([]*ModuleInfo, bool, error) {
+	bs, err := os.ReadFile(filepath.Join(c.dir, key+".json"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, err
+	}
+	var infos []*ModuleInfo
+	if err := json.Unmarshal(bs, &infos); err != nil {
+		return nil, false, fmt.Errorf("corrupted parse cache entry %q: %v", key, err)
+	}
+	return infos, true, nil
+}
+
+// Put stores the module metadata for key. Since validators run in parallel,
+// the entry is written atomically.
+func (c *ParseCache) Put(key string, infos []*ModuleInfo) error {
+	bs, err := json.Marshal(infos)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(bs); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(c.dir, key+".json"))
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCache(t *testing.T) {
+	modelDir := t.TempDir()
+	file := filepath.Join(modelDir, "openconfig-foo.yang")
+	if err := os.WriteFile(file, []byte("module openconfig-foo {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewParseCache(filepath.Join(t.TempDir(), "parse-cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKey("v1.0.0", []string{modelDir}, []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok, err := cache.Get(key); err != nil || ok {
+		t.Fatalf("Get on empty cache: got (%v, %v), want (false, nil)", ok, err)
+	}
+
+	want := []*ModuleInfo{{
+		Name:              "openconfig-foo",
+		BelongingModule:   "openconfig-foo",
+		OpenconfigVersion: "1.0.0",
+		Namespace:         "urn:foo",
+		Prefix:            "oc-foo",
+	}}
+	if err := cache.Put(key, want); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := cache.Get(key)
+	if err != nil || !ok {
+		t.Fatalf("Get after Put: got (%v, %v), want (true, nil)", ok, err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+
+	// The key doesn't depend on where the models are checked out.
+	movedDir := t.TempDir()
+	movedFile := filepath.Join(movedDir, "openconfig-foo.yang")
+	if err := os.WriteFile(movedFile, []byte("module openconfig-foo {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if movedKey, err := ParseKey("v1.0.0", []string{movedDir}, []string{movedFile}); err != nil || movedKey != key {
+		t.Errorf("ParseKey of moved files: got (%s, %v), want (%s, nil)", movedKey, err, key)
+	}
+	if versionKey, err := ParseKey("v1.1.0", []string{modelDir}, []string{file}); err != nil || versionKey == key {
+		t.Errorf("ParseKey did not change with the tool version: got (%s, %v)", versionKey, err)
+	}
+
+	// Any change to the inputs must change the key.
+	if err := os.WriteFile(file, []byte("module openconfig-foo { }\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := ParseKey("v1.0.0", []string{modelDir}, []string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newKey == key {
+		t.Errorf("ParseKey did not change after the file was modified")
+	}
+	if _, err := ParseKey("v1.0.0", []string{modelDir}, []string{filepath.Join(modelDir, "dne.yang")}); err == nil {
+		t.Errorf("ParseKey: got no error for a non-existent file")
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangutil
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// WriteTree writes the schema tree of the module entry in the style of
+// "pyang -f tree". Since goyang doesn't retain the statement order, the
+// children of each node are sorted by name, and augmentations of the module
+// are displayed within the modules they augment.
+func WriteTree(w io.Writer, module *yang.Entry) error {
+	if _, err := fmt.Fprintf(w, "module: %s\n", module.Name); err != nil {
+		return err
+	}
+	return writeTreeChildren(w, module, "  ")
+}
+
+// writeTreeChildren writes the subtrees of the children of e, each line
+// prefixed by prefix.
+func writeTreeChildren(w io.Writer, e *yang.Entry, prefix string) error {
+	names := make([]string, 0, len(e.Dir))
+	for name := range e.Dir {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := e.Dir[name]
+		if _, err := fmt.Fprintf(w, "%s+--%s\n", prefix, treeNodeDesc(child)); err != nil {
+			return err
+		}
+		childPrefix := prefix + "|  "
+		if i == len(names)-1 {
+			childPrefix = prefix + "   "
+		}
+		if err := writeTreeChildren(w, child, childPrefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeNodeDesc returns the description of a node within its tree line, e.g.
+// "rw name?   string".
+func treeNodeDesc(e *yang.Entry) string {
+	if e.IsCase() {
+		return fmt.Sprintf(":(%s)", e.Name)
+	}
+
+	flags := "rw"
+	if e.ReadOnly() {
+		flags = "ro"
+	}
+
+	name := e.Name
+	switch {
+	case e.IsChoice():
+		name = fmt.Sprintf("(%s)", e.Name)
+		if e.Mandatory != yang.TSTrue {
+			name += "?"
+		}
+	case e.IsList():
+		name += "*"
+		if e.Key != "" {
+			name += fmt.Sprintf(" [%s]", e.Key)
+		}
+	case e.IsLeafList():
+		name += "*"
+	case e.IsContainer():
+		if c, ok := e.Node.(*yang.Container); ok && c.Presence != nil {
+			name += "!"
+		}
+	case e.IsLeaf() && e.Mandatory != yang.TSTrue && !isListKey(e):
+		name += "?"
+	}
+
+	desc := flags + " " + name
+	if e.Type != nil {
+		typeName := e.Type.Name
+		if e.Type.Kind == yang.Yleafref {
+			typeName = "-> " + e.Type.Path
+		}
+		// Align the types of sibling leaves as pyang does.
+		desc = fmt.Sprintf("%-*s %s", len(flags)+1+treeNameWidth(e.Parent), desc, typeName)
+	}
+	return desc
+}
+
+// isListKey returns whether the leaf is a key of its parent list.
+func isListKey(e *yang.Entry) bool {
+	if e.Parent == nil || !e.Parent.IsList() {
+		return false
+	}
+	for _, key := range strings.Fields(e.Parent.Key) {
+		if key == e.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// treeNameWidth returns the width of the longest decorated leaf name among
+// the children of e.
+func treeNameWidth(e *yang.Entry) int {
+	width := 0
+	if e == nil {
+		return width
+	}
+	for _, child := range e.Dir {
+		if !child.IsLeaf() && !child.IsLeafList() {
+			continue
+		}
+		n := len(child.Name)
+		if child.IsLeafList() || (child.Mandatory != yang.TSTrue && !isListKey(child)) {
+			n++
+		}
+		if n > width {
+			width = n
+		}
+	}
+	return width + 2
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/goyang/pkg/yangentry"
+)
+
+func TestWriteTree(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test-module.yang")
+	if err := os.WriteFile(file, []byte(`module test-module {
+  prefix "t";
+  namespace "urn:t";
+
+  container top {
+    container config {
+      leaf name { type string; }
+      leaf enabled { type boolean; mandatory true; }
+    }
+    container state {
+      config false;
+      leaf counter { type uint64; }
+    }
+    container feature {
+      presence "enables the feature";
+    }
+    list item {
+      key "name";
+      leaf name { type leafref { path "../config/name"; } }
+      container config {
+        leaf name { type string; }
+        leaf-list tags { type string; }
+      }
+    }
+    choice mode {
+      case fast {
+        leaf speed { type uint32; }
+      }
+    }
+  }
+}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, errs := yangentry.Parse([]string{file}, nil)
+	if errs != nil {
+		t.Fatal(errs)
+	}
+	var b strings.Builder
+	if err := WriteTree(&b, entries["test-module"]); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `module: test-module
+  +--rw top
+     +--rw config
+     |  +--rw enabled   boolean
+     |  +--rw name?     string
+     +--rw feature!
+     +--rw item* [name]
+     |  +--rw config
+     |  |  +--rw name?   string
+     |  |  +--rw tags*   string
+     |  +--rw name   -> ../config/name
+     +--rw (mode)?
+     |  +--:(fast)
+     |     +--rw speed?   uint32
+     +--ro state
+        +--ro counter?   uint64
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("(-want, +got):\n%s", diff)
+	}
+}
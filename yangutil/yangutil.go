@@ -16,10 +16,16 @@
 package yangutil
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 )
 
+// TimeoutExitCode is the exit code of binaries whose YANG parsing timed out.
+// It matches that of timeout(1), and allows CI to report a timeout as an infra
+// error rather than a model failure.
+const TimeoutExitCode = 124
+
 func GetAllYANGFiles(path string) ([]string, error) {
 	var files []string
 	if err := filepath.Walk(path,
@@ -37,3 +43,20 @@ func GetAllYANGFiles(path string) ([]string, error) {
 	}
 	return files, nil
 }
+
+// RunWithContext runs f, which is typically a long-running YANG parse that
+// cannot otherwise be cancelled, returning its error, or the context's error
+// if ctx is done before f returns. In the latter case, f is left running in
+// the background, so the caller should exit soon afterwards.
+func RunWithContext(ctx context.Context, f func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- f()
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yangutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunWithContext(t *testing.T) {
+	errFoo := errors.New("foo")
+	block := make(chan struct{})
+	defer close(block)
+
+	tests := []struct {
+		desc      string
+		inTimeout time.Duration
+		inFunc    func() error
+		wantErr   error
+	}{{
+		desc:      "success",
+		inTimeout: time.Minute,
+		inFunc:    func() error { return nil },
+	}, {
+		desc:      "error",
+		inTimeout: time.Minute,
+		inFunc:    func() error { return errFoo },
+		wantErr:   errFoo,
+	}, {
+		desc:      "timeout",
+		inTimeout: time.Millisecond,
+		inFunc: func() error {
+			<-block
+			return nil
+		},
+		wantErr: context.DeadlineExceeded,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.inTimeout)
+			defer cancel()
+			if err := RunWithContext(ctx, tt.inFunc); !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}